@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type createGrantRequest struct {
+	Grantee       string    `json:"grantee" binding:"required,alphanum"`
+	Scopes        []string  `json:"scopes" binding:"required,min=1,unique,dive,grant_scope"`
+	TransferLimit *int64    `json:"transfer_limit" binding:"omitempty,gt=0"`
+	ExpiresAt     time.Time `json:"expires_at" binding:"required"`
+}
+
+func (server *Server) createGrant(ctx *gin.Context) {
+	var req createGrantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.ExpiresAt.After(time.Now()) {
+		err := errors.New("expires_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Grantee == authPayload.Username {
+		err := errors.New("cannot grant access to yourself")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account := authorizedAccount(ctx)
+	grant, err := server.store.CreateAccessGrant(ctx, db.CreateAccessGrantParams{
+		Grantor:       authPayload.Username,
+		Grantee:       req.Grantee,
+		AccountID:     account.ID,
+		Scopes:        req.Scopes,
+		TransferLimit: optionalInt8(req.TransferLimit),
+		ExpiresAt:     req.ExpiresAt,
+	})
+	if err != nil {
+		if db.ErrCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "access_grants", strconv.FormatInt(grant.ID, 10), nil, grant)
+
+	ctx.JSON(http.StatusCreated, grant)
+}
+
+func (server *Server) listGrants(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	grants, err := server.store.ListAccessGrants(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, grants)
+}
+
+type grantRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) revokeGrant(ctx *gin.Context) {
+	oldGrant := authorizedGrant(ctx)
+
+	grant, err := server.store.RevokeAccessGrant(ctx, oldGrant.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := fmt.Errorf("access grant %d is already revoked", oldGrant.ID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "access_grants", strconv.FormatInt(grant.ID, 10), oldGrant, grant)
+
+	ctx.JSON(http.StatusOK, grant)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessGrantWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	var users []db.User
+	for i := 0; i < 3; i++ {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	grantor, grantee, stranger := users[0], users[1], users[2]
+
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    grantor.Username,
+		Balance:  100,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	target, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    stranger.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	grantsURL := fmt.Sprintf("/accounts/%d/grants", account.ID)
+	entriesURL := fmt.Sprintf("/accounts/%d/entries", account.ID)
+	transfer := func(amount int64) gin.H {
+		return gin.H{
+			"from_account_id": account.ID,
+			"to_account_id":   target.ID,
+			"amount":          amount,
+			"currency":        util.USD,
+		}
+	}
+	grantBody := gin.H{
+		"grantee":        grantee.Username,
+		"scopes":         []string{util.ViewScope, util.TransferScope},
+		"transfer_limit": 20,
+		"expires_at":     time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name   string
+		user   db.User
+		update func(body gin.H)
+		code   int
+	}{
+		{"NotManager", stranger, func(body gin.H) {}, http.StatusForbidden},
+		{"Expired", grantor, func(body gin.H) { body["expires_at"] = time.Now().Add(-time.Minute) }, http.StatusBadRequest},
+		{"SelfGrant", grantor, func(body gin.H) { body["grantee"] = grantor.Username }, http.StatusBadRequest},
+		{"UnsupportedScope", grantor, func(body gin.H) { body["scopes"] = []string{"manage"} }, http.StatusBadRequest},
+		{"DuplicateScope", grantor, func(body gin.H) { body["scopes"] = []string{util.ViewScope, util.ViewScope} }, http.StatusBadRequest},
+		{"UnknownGrantee", grantor, func(body gin.H) { body["grantee"] = util.RandomOwner() + "unknown" }, http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		body := gin.H{}
+		for key, value := range grantBody {
+			body[key] = value
+		}
+		tc.update(body)
+
+		recorder := send(http.MethodPost, grantsURL, tc.user, body)
+		require.Equal(t, tc.code, recorder.Code, tc.name)
+	}
+
+	// before the grant, the grantee has no access
+	recorder := send(http.MethodGet, entriesURL, grantee, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodPost, grantsURL, grantor, grantBody)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	var grant db.AccessGrant
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &grant))
+	require.Equal(t, int64(20), grant.TransferLimit.Int64)
+
+	recorder = send(http.MethodGet, "/grants", grantee, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var grants []db.AccessGrant
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &grants))
+	require.Len(t, grants, 1)
+	require.Equal(t, grant.ID, grants[0].ID)
+
+	// delegates read statements and make transfers within the limit
+	recorder = send(http.MethodGet, entriesURL, grantee, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodPost, "/transfers", grantee, transfer(20))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodPost, "/transfers", grantee, transfer(21))
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// delegates cannot share the account further
+	recorder = send(http.MethodPost, fmt.Sprintf("/accounts/%d/members", account.ID), grantee, gin.H{"username": stranger.Username, "permission": util.ViewPermission})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{
+		GrantID: pgtype.Int8{Int64: grant.ID, Valid: true},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 3, "the read and both transfers of the delegate are audited")
+	for _, auditLog := range auditLogs {
+		require.Equal(t, grantee.Username, auditLog.Actor)
+	}
+
+	recorder = send(http.MethodDelete, fmt.Sprintf("/grants/%d", grant.ID), stranger, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodDelete, fmt.Sprintf("/grants/%d", grant.ID), grantor, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodDelete, fmt.Sprintf("/grants/%d", grant.ID), grantee, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodGet, entriesURL, grantee, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
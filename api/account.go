@@ -2,12 +2,14 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
 	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -43,6 +45,9 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
+	setAuditChange(ctx, "accounts", strconv.FormatInt(account.ID, 10), nil, account)
+	setETag(ctx, account.Version)
+
 	ctx.JSON(http.StatusCreated, account)
 }
 
@@ -51,146 +56,175 @@ type getAccountRequest struct {
 }
 
 func (server *Server) getAccount(ctx *gin.Context) {
-	var req getAccountRequest
-	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return
-	}
-
-	account, err := server.store.GetAccount(ctx, req.ID)
-	if err != nil {
-		if errors.Is(err, db.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
-		return
-	}
+	account := authorizedAccount(ctx)
 
+	setETag(ctx, account.Version)
 	ctx.JSON(http.StatusOK, account)
 }
 
-type listAccountsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+func accountPosition(account db.Account) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: account.CreatedAt,
+		ID:      strconv.FormatInt(account.ID, 10),
+	}
 }
 
 func (server *Server) listAccounts(ctx *gin.Context) {
-	var req listAccountsRequest
+	var req pageRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
-	}
-
-	accounts, err := server.store.ListAccounts(ctx, arg)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	scope := "accounts:" + authPayload.Username
+
+	page, ok := listPage(ctx, server, scope, req, accountPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.Account, error) {
+			arg := db.ListAccountsAfterParams{
+				Owner: authPayload.Username,
+				Limit: limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListAccountsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.Account, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListAccountsBefore(ctx, db.ListAccountsBeforeParams{
+				Owner:           authPayload.Username,
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "accounts", page.Items, page))
 }
 
-type listAccountsWithCursorRequest struct {
-	Cursor time.Time `form:"cursor"`
-	Limit  int32     `form:"limit" binding:"required,min=5,max=10"`
+type updateAccountRequest struct {
+	// ID      int64 `uri:"id" binding:"required,min=1"`
+	Balance int64 `json:"balance" binding:"required,min=0"`
 }
 
-func (server *Server) listAccountsWithCursor(ctx *gin.Context) {
-	var req listAccountsWithCursorRequest
-	if err := ctx.ShouldBindQuery(&req); err != nil {
+// updateAccount adjusts the balance of an account once a second banker approves it
+func (server *Server) updateAccount(ctx *gin.Context) {
+	var req updateAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	limitPlusOne := req.Limit + 1
-
-	arg := db.ListAccountWithCursorParams{
-		Limit: limitPlusOne,
-		Cursor: pgtype.Timestamptz{
-			Time:  req.Cursor,
-			Valid: !req.Cursor.IsZero(),
-		},
-	}
-
-	accounts, err := server.store.ListAccountWithCursor(ctx, arg)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	account := authorizedAccount(ctx)
+	version, ok := server.checkIfMatch(ctx, account.Version)
+	if !ok {
 		return
 	}
 
-	newAccounts := accounts
-	if int32(len(accounts)) > req.Limit {
-		newAccounts = accounts[0:req.Limit]
-	}
-
-	rsp := gin.H{
-		"accounts": newAccounts,
-		"has_more": int32(len(accounts)) == limitPlusOne,
+	// the adjustment only applies to the account as it is now,
+	// it fails if the balance moves before the adjustment is approved
+	if !version.Valid {
+		version = pgtype.Int8{Int64: account.Version, Valid: true}
 	}
 
-	ctx.JSON(http.StatusOK, rsp)
+	server.holdForApproval(ctx, db.CreateApprovalParams{
+		Kind:           util.AdjustmentApproval,
+		AccountID:      account.ID,
+		Balance:        pgtype.Int8{Int64: req.Balance, Valid: true},
+		AccountVersion: version,
+		Currency:       account.Currency,
+	})
 }
 
-type updateAccountRequest struct {
-	// ID      int64 `uri:"id" binding:"required,min=1"`
-	Balance int64 `json:"balance" binding:"required,min=0"`
+type updateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,account_status"`
 }
 
-func (server *Server) updateAccount(ctx *gin.Context) {
-	ID, _ := strconv.Atoi(ctx.Param("id"))
-
-	var req updateAccountRequest
+// updateAccountStatus freezes, closes or reactivates an account. Frozen and closed accounts
+// take part in no transfer, and closed accounts stay closed.
+func (server *Server) updateAccountStatus(ctx *gin.Context) {
+	var req updateAccountStatusRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	arg := db.UpdateAccountParams{
-		ID:      int64(ID),
-		Balance: req.Balance,
+	oldAccount := authorizedAccount(ctx)
+	version, ok := server.checkIfMatch(ctx, oldAccount.Version)
+	if !ok {
+		return
+	}
+
+	if oldAccount.Status == util.ClosedAccount && req.Status != util.ClosedAccount {
+		err := fmt.Errorf("account [%d] is closed", oldAccount.ID)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
 	}
 
-	account, err := server.store.UpdateAccount(ctx, arg)
+	account, err := server.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+		ID:      oldAccount.ID,
+		Status:  req.Status,
+		Version: version,
+	})
 	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			if version.Valid {
+				ctx.JSON(http.StatusPreconditionFailed, errorResponse(errPreconditionFailed))
+				return
+			}
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, account)
-}
+	setAuditChange(ctx, "accounts", strconv.FormatInt(account.ID, 10), oldAccount, account)
+	setETag(ctx, account.Version)
 
-// TODO: need to update for reference table
-type deleteAccountRequest struct {
-	ID int64 `uri:"id" binding:"required,min=1"`
+	ctx.JSON(http.StatusOK, account)
 }
 
 func (server *Server) deleteAccount(ctx *gin.Context) {
-	var req deleteAccountRequest
-	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	account := authorizedAccount(ctx)
+
+	version, ok := server.checkIfMatch(ctx, account.Version)
+	if !ok {
 		return
 	}
 
-	err := server.store.DeleteAccount(ctx, req.ID)
+	rows, err := server.store.DeleteAccount(ctx, db.DeleteAccountParams{
+		ID:      account.ID,
+		Version: version,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	if rows == 0 {
+		if version.Valid {
+			// the account was modified after it was read
+			ctx.JSON(http.StatusPreconditionFailed, errorResponse(errPreconditionFailed))
+			return
+		}
+		ctx.JSON(http.StatusNotFound, errorResponse(db.ErrRecordNotFound))
+		return
+	}
+
+	setAuditChange(ctx, "accounts", strconv.FormatInt(account.ID, 10), account, nil)
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
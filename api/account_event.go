@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	accountEventBufferSize      = 16
+	accountEventHeartbeat       = 15 * time.Second
+	accountEventListenRetryWait = 5 * time.Second
+)
+
+// accountEventBroker fans out account events to the SSE streams subscribed to each account
+type accountEventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[int64]map[chan db.AccountEvent]struct{}
+}
+
+func newAccountEventBroker() *accountEventBroker {
+	return &accountEventBroker{
+		subscribers: make(map[int64]map[chan db.AccountEvent]struct{}),
+	}
+}
+
+func (broker *accountEventBroker) subscribe(accountID int64) chan db.AccountEvent {
+	events := make(chan db.AccountEvent, accountEventBufferSize)
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if broker.subscribers[accountID] == nil {
+		broker.subscribers[accountID] = make(map[chan db.AccountEvent]struct{})
+	}
+	broker.subscribers[accountID][events] = struct{}{}
+
+	return events
+}
+
+func (broker *accountEventBroker) unsubscribe(accountID int64, events chan db.AccountEvent) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	delete(broker.subscribers[accountID], events)
+	if len(broker.subscribers[accountID]) == 0 {
+		delete(broker.subscribers, accountID)
+	}
+}
+
+// publish delivers the event to every subscriber of the account.
+// Slow subscribers whose buffer is full miss the event instead of blocking the others.
+func (broker *accountEventBroker) publish(event db.AccountEvent) {
+	broker.mu.RLock()
+	defer broker.mu.RUnlock()
+
+	for events := range broker.subscribers[event.AccountID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// listenAccountEvents feeds the broker from the database until the context is cancelled,
+// reconnecting whenever the listener fails
+func (server *Server) listenAccountEvents(ctx context.Context) {
+	for {
+		err := server.store.ListenAccountEvents(ctx, server.accountEvents.publish)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Println("account event listener stopped: ", err)
+		time.Sleep(accountEventListenRetryWait)
+	}
+}
+
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	account := authorizedAccount(ctx)
+
+	events := server.accountEvents.subscribe(account.ID)
+	defer server.accountEvents.unsubscribe(account.ID, events)
+
+	heartbeat := time.NewTicker(accountEventHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	// send the current state first so clients can render without a separate request
+	ctx.SSEvent("account", account)
+	ctx.Writer.Flush()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event := <-events:
+			ctx.SSEvent("balance", event)
+		case <-heartbeat.C:
+			ctx.SSEvent("heartbeat", gin.H{"time": time.Now()})
+		}
+		ctx.Writer.Flush()
+	}
+}
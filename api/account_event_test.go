@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/foyez/simplebank/db/mock"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		setupAuth     func(request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: "unauthorized_user"})).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetActiveAccessGrant(gomock.Any(), gomock.Eq(db.GetActiveAccessGrantParams{AccountID: account.ID, Grantee: "unauthorized_user", Scope: util.ViewScope})).
+					Times(1).
+					Return(db.AccessGrant{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalServerError",
+			accountID: account.ID,
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/events", tc.accountID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(request, server.tokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestStreamAccountEventsDelivery(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	otherAccount := randomAccount(user.Username)
+	otherAccount.ID = account.ID + 1
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	url := fmt.Sprintf("/accounts/%d/events", account.ID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		server.router.ServeHTTP(recorder, request)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(accountEventSubscribers(server, account.ID)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	event := db.AccountEvent{
+		AccountID:  account.ID,
+		EntryID:    util.RandomInt(1, 1000),
+		TransferID: util.RandomInt(1, 1000),
+		Amount:     10,
+		Balance:    account.Balance + 10,
+		Currency:   account.Currency,
+	}
+	server.accountEvents.publish(db.AccountEvent{AccountID: otherAccount.ID, EntryID: event.EntryID + 1})
+	server.accountEvents.publish(event)
+
+	require.Eventually(t, func() bool {
+		for _, events := range accountEventSubscribers(server, account.ID) {
+			if len(events) > 0 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	require.Empty(t, accountEventSubscribers(server, account.ID))
+
+	body := recorder.Body.String()
+	require.Contains(t, body, "event:account\n")
+	require.Contains(t, body, "event:balance\n")
+	require.Contains(t, body, fmt.Sprintf(`"entry_id":%d`, event.EntryID))
+	require.Contains(t, body, fmt.Sprintf(`"balance":%d`, event.Balance))
+	require.NotContains(t, body, fmt.Sprintf(`"entry_id":%d`, event.EntryID+1))
+}
+
+func accountEventSubscribers(server *Server, accountID int64) []chan db.AccountEvent {
+	server.accountEvents.mu.RLock()
+	defer server.accountEvents.mu.RUnlock()
+
+	var subscribers []chan db.AccountEvent
+	for events := range server.accountEvents.subscribers[accountID] {
+		subscribers = append(subscribers, events)
+	}
+	return subscribers
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type listAccountMembersResponse struct {
+	Owner   string             `json:"owner"`
+	Members []db.AccountMember `json:"members"`
+}
+
+func (server *Server) listAccountMembers(ctx *gin.Context) {
+	account := authorizedAccount(ctx)
+
+	members, err := server.store.ListAccountMembers(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, listAccountMembersResponse{
+		Owner:   account.Owner,
+		Members: members,
+	})
+}
+
+type inviteAccountMemberRequest struct {
+	Username   string `json:"username" binding:"required,alphanum"`
+	Permission string `json:"permission" binding:"required,member_permission"`
+}
+
+func (server *Server) inviteAccountMember(ctx *gin.Context) {
+	var req inviteAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account := authorizedAccount(ctx)
+	if req.Username == account.Owner {
+		err := errors.New("the account owner is already a member")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, err := server.store.CreateAccountMember(ctx, db.CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   req.Username,
+		Permission: req.Permission,
+		InvitedBy:  authPayload.Username,
+	})
+	if err != nil {
+		errCode := db.ErrCode(err)
+		if errCode == db.ForeignKeyViolation || errCode == db.UniqueViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "account_members", accountMemberID(member), nil, member)
+
+	ctx.JSON(http.StatusCreated, member)
+}
+
+type accountMemberRequest struct {
+	Username string `uri:"username" binding:"required"`
+}
+
+type updateAccountMemberRequest struct {
+	Permission string `json:"permission" binding:"required,member_permission"`
+}
+
+func (server *Server) updateAccountMember(ctx *gin.Context) {
+	var uri accountMemberRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account := authorizedAccount(ctx)
+	oldMember, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	member, err := server.store.UpdateAccountMember(ctx, db.UpdateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   uri.Username,
+		Permission: req.Permission,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "account_members", accountMemberID(member), oldMember, member)
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+func (server *Server) removeAccountMember(ctx *gin.Context) {
+	var uri accountMemberRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account := authorizedAccount(ctx)
+	member, ok := server.deleteAccountMember(ctx, account.ID, uri.Username, false)
+	if !ok {
+		return
+	}
+
+	setAuditChange(ctx, "account_members", accountMemberID(member), member, nil)
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (server *Server) listAccountInvitations(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	invitations, err := server.store.ListAccountInvitations(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invitations)
+}
+
+func (server *Server) acceptAccountInvitation(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, err := server.store.AcceptAccountMember(ctx, db.AcceptAccountMemberParams{
+		AccountID: uri.ID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("no pending invitation to this account")
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "account_members", accountMemberID(member), nil, member)
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+func (server *Server) declineAccountInvitation(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, ok := server.deleteAccountMember(ctx, uri.ID, authPayload.Username, true)
+	if !ok {
+		return
+	}
+
+	setAuditChange(ctx, "account_members", accountMemberID(member), member, nil)
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// deleteAccountMember removes a member of the account, or only a pending invitation if pendingOnly
+func (server *Server) deleteAccountMember(ctx *gin.Context, accountID int64, username string, pendingOnly bool) (db.AccountMember, bool) {
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err == nil && pendingOnly && member.AcceptedAt.Valid {
+		err = db.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return member, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return member, false
+	}
+
+	_, err = server.store.DeleteAccountMember(ctx, db.DeleteAccountMemberParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return member, false
+	}
+
+	return member, true
+}
+
+func accountMemberID(member db.AccountMember) string {
+	return fmt.Sprintf("%d/%s", member.AccountID, member.Username)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJointAccountWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	var users []db.User
+	for i := 0; i < 3; i++ {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	owner, member, stranger := users[0], users[1], users[2]
+
+	shared, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    owner.Username,
+		Balance:  100,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	target, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    stranger.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	accountURL := fmt.Sprintf("/accounts/%d", shared.ID)
+	membersURL := accountURL + "/members"
+	transfer := gin.H{
+		"from_account_id": shared.ID,
+		"to_account_id":   target.ID,
+		"amount":          10,
+		"currency":        util.USD,
+	}
+
+	// only managers may invite
+	recorder := send(http.MethodPost, membersURL, stranger, gin.H{"username": member.Username, "permission": util.ViewPermission})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodPost, membersURL, owner, gin.H{"username": member.Username, "permission": util.ViewPermission})
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	recorder = send(http.MethodPost, membersURL, owner, gin.H{"username": member.Username, "permission": util.ViewPermission})
+	require.Equal(t, http.StatusForbidden, recorder.Code, "already invited")
+
+	recorder = send(http.MethodPost, membersURL, owner, gin.H{"username": owner.Username, "permission": util.ViewPermission})
+	require.Equal(t, http.StatusForbidden, recorder.Code, "the owner is a member already")
+
+	// a pending invitation grants nothing
+	recorder = send(http.MethodGet, accountURL, member, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodGet, "/invitations", member, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var invitations []db.AccountMember
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &invitations))
+	require.Len(t, invitations, 1)
+	require.Equal(t, shared.ID, invitations[0].AccountID)
+	require.Equal(t, owner.Username, invitations[0].InvitedBy)
+
+	recorder = send(http.MethodPost, fmt.Sprintf("/invitations/%d/accept", shared.ID), member, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodPost, fmt.Sprintf("/invitations/%d/accept", shared.ID), member, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code, "invitations are accepted once")
+
+	// viewers read the account and see it listed, but cannot move money
+	recorder = send(http.MethodGet, accountURL, member, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodGet, "/accounts", member, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var page struct {
+		Accounts []db.Account `json:"accounts"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Accounts, 1)
+	require.Equal(t, shared.ID, page.Accounts[0].ID)
+
+	recorder = send(http.MethodPost, "/transfers", member, transfer)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodPost, membersURL, member, gin.H{"username": stranger.Username, "permission": util.ViewPermission})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// once allowed to transact, members can send money from the account
+	recorder = send(http.MethodPatch, membersURL+"/"+member.Username, owner, gin.H{"permission": util.TransactPermission})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodPost, "/transfers", member, transfer)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodGet, membersURL, member, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var members listAccountMembersResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &members))
+	require.Equal(t, owner.Username, members.Owner)
+	require.Len(t, members.Members, 1)
+	require.Equal(t, util.TransactPermission, members.Members[0].Permission)
+
+	// removed members lose access
+	recorder = send(http.MethodDelete, membersURL+"/"+member.Username, owner, nil)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = send(http.MethodGet, accountURL, member, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{Limit: 10})
+	require.NoError(t, err)
+
+	var memberChanges int
+	for _, auditLog := range auditLogs {
+		if auditLog.ResourceType == "account_members" {
+			require.Equal(t, fmt.Sprintf("%d/%s", shared.ID, member.Username), auditLog.ResourceID)
+			memberChanges++
+		}
+	}
+	require.Equal(t, 4, memberChanges, "invite, accept, update and remove are audited")
+}
+
+func TestDeclineAccountInvitationWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	var users []db.User
+	for i := 0; i < 2; i++ {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	owner, invitee := users[0], users[1]
+
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    owner.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateAccountMember(context.Background(), db.CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   invitee.Username,
+		Permission: util.ManagePermission,
+		InvitedBy:  owner.Username,
+	})
+	require.NoError(t, err)
+
+	decline := func() int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/invitations/%d/decline", account.ID), nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, invitee.Username, invitee.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusNoContent, decline())
+	require.Equal(t, http.StatusNotFound, decline())
+
+	members, err := store.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Empty(t, members)
+}
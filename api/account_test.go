@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -11,12 +12,15 @@ import (
 	"testing"
 	"time"
 
+	memorydb "github.com/foyez/simplebank/db/memory"
 	mockdb "github.com/foyez/simplebank/db/mock"
 	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
 	"github.com/foyez/simplebank/token"
 	"github.com/foyez/simplebank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -43,6 +47,32 @@ func TestGetAccountAPI(t *testing.T) {
 					Times(1).
 					Return(account, nil)
 			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, newETag(account.Version), recorder.Header().Get(etagHeaderKey))
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:      "Banker",
+			accountID: account.ID,
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: "banker"})).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetActiveAccessGrant(gomock.Any(), gomock.Eq(db.GetActiveAccessGrantParams{AccountID: account.ID, Grantee: "banker", Scope: util.ViewScope})).
+					Times(1).
+					Return(db.AccessGrant{}, db.ErrRecordNotFound)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchAccount(t, recorder.Body, account)
@@ -59,9 +89,17 @@ func TestGetAccountAPI(t *testing.T) {
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
 					Return(account, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: "unauthorized_user"})).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetActiveAccessGrant(gomock.Any(), gomock.Eq(db.GetActiveAccessGrantParams{AccountID: account.ID, Grantee: "unauthorized_user", Scope: util.ViewScope})).
+					Times(1).
+					Return(db.AccessGrant{}, db.ErrRecordNotFound)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
 		{
@@ -279,10 +317,13 @@ func TestListAccountsAPI(t *testing.T) {
 	accounts := make([]db.Account, n)
 	for i := 0; i < n; i++ {
 		accounts[i] = randomAccount(user.Username)
+		accounts[i].CreatedAt = time.Date(2023, 1, n-i, 0, 0, 0, 0, time.UTC)
 	}
 
+	scope := "accounts:" + user.Username
+
 	type Query struct {
-		pageID   int
+		cursor   func(codec *pagination.Codec) string
 		pageSize int
 	}
 
@@ -294,89 +335,195 @@ func TestListAccountsAPI(t *testing.T) {
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "OK",
+			name: "FirstPage",
 			query: Query{
-				pageID:   1,
-				pageSize: n,
+				pageSize: n - 1,
 			},
 			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.ListAccountsParams{
-					Owner:  user.Username,
-					Limit:  int32(n),
-					Offset: 0,
+				arg := db.ListAccountsAfterParams{
+					Owner: user.Username,
+					Limit: int32(n),
 				}
 
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					ListAccountsAfter(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
 					Return(accounts, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccounts(t, recorder.Body, accounts)
+				next, prev := requireBodyMatchPage(t, recorder.Body, "accounts", accounts[:n-1])
+				require.NotNil(t, next)
+				require.Nil(t, prev)
 			},
 		},
 		{
-			name: "NoAuthorization",
+			name: "NextPage",
 			query: Query{
-				pageID:   1,
-				pageSize: n,
+				cursor: func(codec *pagination.Codec) string {
+					return codec.Encode(scope, accountPosition(accounts[0]))
+				},
+				pageSize: n - 1,
 			},
 			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsAfterParams{
+					Owner:           user.Username,
+					CursorCreatedAt: pgtype.Timestamptz{Time: accounts[0].CreatedAt, Valid: true},
+					CursorID:        pgtype.Int8{Int64: accounts[0].ID, Valid: true},
+					Limit:           int32(n),
+				}
+
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
-					Times(0)
+					ListAccountsAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[1:], nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next, prev := requireBodyMatchPage(t, recorder.Body, "accounts", accounts[1:])
+				require.Nil(t, next)
+				require.NotNil(t, prev)
 			},
 		},
 		{
-			name: "InternalError",
+			name: "PrevPage",
 			query: Query{
-				pageID:   1,
-				pageSize: n,
+				cursor: func(codec *pagination.Codec) string {
+					cursor := accountPosition(accounts[n-1])
+					cursor.Backward = true
+					return codec.Encode(scope, cursor)
+				},
+				pageSize: n - 1,
 			},
 			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsBeforeParams{
+					Owner:           user.Username,
+					CursorCreatedAt: accounts[n-1].CreatedAt,
+					CursorID:        accounts[n-1].ID,
+					Limit:           int32(n),
+				}
+
+				// rows before a cursor come oldest first
+				rows := make([]db.Account, 0, n-1)
+				for i := n - 2; i >= 0; i-- {
+					rows = append(rows, accounts[i])
+				}
+
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
+					ListAccountsBefore(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return([]db.Account{}, sql.ErrConnDone)
+					Return(rows, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next, prev := requireBodyMatchPage(t, recorder.Body, "accounts", accounts[:n-1])
+				require.NotNil(t, next)
+				require.Nil(t, prev)
 			},
 		},
 		{
-			name: "InvalidPageID",
+			name: "CursorOfAnotherListing",
 			query: Query{
-				pageID:   -1,
-				pageSize: n,
+				cursor: func(codec *pagination.Codec) string {
+					return codec.Encode("accounts:"+util.RandomOwner(), accountPosition(accounts[0]))
+				},
 			},
 			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
+					ListAccountsAfter(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "TamperedCursor",
+			query: Query{
+				cursor: func(codec *pagination.Codec) string {
+					return codec.Encode(scope, accountPosition(accounts[0])) + "A"
+				},
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCursorID",
+			query: Query{
+				cursor: func(codec *pagination.Codec) string {
+					return codec.Encode(scope, pagination.Cursor{ID: "abc"})
+				},
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidPageSize",
 			query: Query{
-				pageID:   1,
 				pageSize: 100000,
 			},
 			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
@@ -384,7 +531,7 @@ func TestListAccountsAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
+					ListAccountsAfter(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -412,8 +559,12 @@ func TestListAccountsAPI(t *testing.T) {
 
 			// Add query parameters to request URL
 			q := request.URL.Query()
-			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
-			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			if tc.query.cursor != nil {
+				q.Add("cursor", tc.query.cursor(server.pageCodec))
+			}
+			if tc.query.pageSize != 0 {
+				q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			}
 			request.URL.RawQuery = q.Encode()
 
 			tc.setupAuth(request, server.tokenMaker)
@@ -424,12 +575,97 @@ func TestListAccountsAPI(t *testing.T) {
 	}
 }
 
+func TestAccountStatusWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		require.NoError(t, json.NewEncoder(&data).Encode(body))
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	var users []db.User
+	var accounts []db.Account
+	for i := 0; i < 2; i++ {
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: util.RandomString(6),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  100,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+	banker, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(6),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	banker = setRole(t, store, banker, util.BankerRole)
+
+	setStatus := func(user db.User, account db.Account, status string) *httptest.ResponseRecorder {
+		return send(http.MethodPatch, fmt.Sprintf("/accounts/%d/status", account.ID), user, gin.H{"status": status})
+	}
+	transfer := func(from db.Account, to db.Account) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/transfers", users[0], gin.H{
+			"from_account_id": from.ID,
+			"to_account_id":   to.ID,
+			"amount":          10,
+			"currency":        util.USD,
+		})
+	}
+
+	require.Equal(t, http.StatusForbidden, setStatus(users[0], accounts[0], util.FrozenAccount).Code)
+	require.Equal(t, http.StatusBadRequest, setStatus(banker, accounts[0], "dormant").Code)
+
+	recorder := setStatus(banker, accounts[1], util.FrozenAccount)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var frozen db.Account
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &frozen))
+	require.Equal(t, util.FrozenAccount, frozen.Status)
+	require.Equal(t, accounts[1].Version+1, frozen.Version)
+
+	// frozen accounts neither send nor receive money
+	require.Equal(t, http.StatusConflict, transfer(accounts[0], accounts[1]).Code)
+	account, err := store.GetAccount(context.Background(), accounts[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.Balance)
+
+	require.Equal(t, http.StatusOK, setStatus(banker, accounts[1], util.ActiveAccount).Code)
+	require.Equal(t, http.StatusOK, transfer(accounts[0], accounts[1]).Code)
+
+	// closed accounts stay closed
+	require.Equal(t, http.StatusOK, setStatus(banker, accounts[1], util.ClosedAccount).Code)
+	require.Equal(t, http.StatusConflict, transfer(accounts[0], accounts[1]).Code)
+	require.Equal(t, http.StatusConflict, setStatus(banker, accounts[1], util.ActiveAccount).Code)
+}
+
 func randomAccount(owner string) db.Account {
 	return db.Account{
 		ID:       util.RandomInt(1, 1000),
 		Owner:    owner,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
+		Version:  util.RandomInt(1, 10),
+		Status:   util.ActiveAccount,
 	}
 }
 
@@ -442,13 +678,3 @@ func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Accoun
 	require.NoError(t, err)
 	require.Equal(t, account, gotAccount)
 }
-
-func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account) {
-	data, err := io.ReadAll(body)
-	require.NoError(t, err)
-
-	var gotAccounts []db.Account
-	err = json.Unmarshal(data, &gotAccounts)
-	require.NoError(t, err)
-	require.Equal(t, accounts, gotAccounts)
-}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// adminUserResponse is the view of a user given to bankers, with the fields that control access
+type adminUserResponse struct {
+	userResponse
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
+}
+
+func newAdminUserResponse(user db.User) adminUserResponse {
+	return adminUserResponse{
+		userResponse: newUserResponse(user),
+		Role:         user.Role,
+		Disabled:     user.Disabled,
+	}
+}
+
+type userAccessResponse struct {
+	User            adminUserResponse `json:"user"`
+	RevokedSessions int64             `json:"revoked_sessions"`
+}
+
+func userPosition(user db.User) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: user.CreatedAt,
+		ID:      user.Username,
+	}
+}
+
+func (server *Server) listUsers(ctx *gin.Context) {
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	const scope = "users"
+
+	page, ok := listPage(ctx, server, scope, req, userPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.User, error) {
+			arg := db.ListUsersAfterParams{
+				Limit: limit,
+			}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorUsername = pgtype.Text{String: cursor.ID, Valid: true}
+			}
+			return server.store.ListUsersAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.User, error) {
+			return server.store.ListUsersBefore(ctx, db.ListUsersBeforeParams{
+				CursorCreatedAt: cursor.SortKey,
+				CursorUsername:  cursor.ID,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	users := make([]adminUserResponse, len(page.Items))
+	for i, user := range page.Items {
+		users[i] = newAdminUserResponse(user)
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "users", users, page))
+}
+
+type adminUserRequest struct {
+	Username string `uri:"username" binding:"required"`
+}
+
+type changeUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+func (server *Server) changeUserRole(ctx *gin.Context) {
+	var uri adminUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req changeUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.updateUserAccess(ctx, db.UpdateUserAccessTxParams{
+		Username: uri.Username,
+		Role: pgtype.Text{
+			String: req.Role,
+			Valid:  true,
+		},
+	})
+}
+
+func (server *Server) disableUser(ctx *gin.Context) {
+	server.setUserDisabled(ctx, true)
+}
+
+func (server *Server) enableUser(ctx *gin.Context) {
+	server.setUserDisabled(ctx, false)
+}
+
+func (server *Server) setUserDisabled(ctx *gin.Context, disabled bool) {
+	var uri adminUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.updateUserAccess(ctx, db.UpdateUserAccessTxParams{
+		Username: uri.Username,
+		Disabled: pgtype.Bool{
+			Bool:  disabled,
+			Valid: true,
+		},
+	})
+}
+
+// updateUserAccess applies an access change to a user and revokes the user's sessions,
+// since their refresh tokens still carry the previous role
+func (server *Server) updateUserAccess(ctx *gin.Context, arg db.UpdateUserAccessTxParams) {
+	// a banker locking themselves out could leave nobody able to undo it
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if arg.Username == authPayload.Username {
+		err := errors.New("bankers cannot change their own access")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, arg.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.UpdateUserAccessTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "users", user.Username, newAdminUserResponse(user), newAdminUserResponse(result.User))
+	setETag(ctx, result.User.Version)
+
+	ctx.JSON(http.StatusOK, userAccessResponse{
+		User:            newAdminUserResponse(result.User),
+		RevokedSessions: result.RevokedSessions,
+	})
+}
+
+func (server *Server) revokeUserSessions(ctx *gin.Context) {
+	var uri adminUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, uri.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	revokedSessions, err := server.store.BlockUserSessions(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "sessions", user.Username, nil, gin.H{"revoked_sessions": revokedSessions})
+
+	ctx.JSON(http.StatusOK, userAccessResponse{
+		User:            newAdminUserResponse(user),
+		RevokedSessions: revokedSessions,
+	})
+}
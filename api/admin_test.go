@@ -0,0 +1,427 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/foyez/simplebank/db/mock"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUsersAPI(t *testing.T) {
+	n := 3
+	users := make([]db.User, n)
+	responses := make([]adminUserResponse, n)
+	for i := 0; i < n; i++ {
+		users[i], _ = randomUser(t)
+		users[i].CreatedAt = time.Date(2023, 1, n-i, 0, 0, 0, 0, time.UTC)
+		responses[i] = newAdminUserResponse(users[i])
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListUsersAfterParams{
+					Limit: int32(n),
+				}
+				store.EXPECT().
+					ListUsersAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(users, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next, prev := requireBodyMatchPage(t, recorder.Body, "users", responses[:n-1])
+				require.NotNil(t, next)
+				require.Nil(t, prev)
+			},
+		},
+		{
+			name: "Depositor",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, users[0].Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsersAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsersAfter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/users?page_size=%d", n-1)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(request, server.tokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateUserAccessAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	promotedUser := user
+	promotedUser.Role = util.BankerRole
+	promotedUser.Version++
+
+	disabledUser := user
+	disabledUser.Disabled = true
+	disabledUser.Version++
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          gin.H
+		setupAuth     func(request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "ChangeRole",
+			method: http.MethodPatch,
+			url:    fmt.Sprintf("/admin/users/%s/role", user.Username),
+			body:   gin.H{"role": util.BankerRole},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				arg := db.UpdateUserAccessTxParams{
+					Username: user.Username,
+					Role:     pgtype.Text{String: util.BankerRole, Valid: true},
+				}
+				store.EXPECT().
+					UpdateUserAccessTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateUserAccessTxResult{User: promotedUser, RevokedSessions: 2}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUserAccess(t, recorder.Body, promotedUser, 2)
+			},
+		},
+		{
+			name:   "InvalidRole",
+			method: http.MethodPatch,
+			url:    fmt.Sprintf("/admin/users/%s/role", user.Username),
+			body:   gin.H{"role": "superuser"},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserAccessTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "Disable",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/admin/users/%s/disable", user.Username),
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				arg := db.UpdateUserAccessTxParams{
+					Username: user.Username,
+					Disabled: pgtype.Bool{Bool: true, Valid: true},
+				}
+				store.EXPECT().
+					UpdateUserAccessTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateUserAccessTxResult{User: disabledUser, RevokedSessions: 1}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUserAccess(t, recorder.Body, disabledUser, 1)
+			},
+		},
+		{
+			name:   "Enable",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/admin/users/%s/enable", user.Username),
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(disabledUser, nil)
+
+				arg := db.UpdateUserAccessTxParams{
+					Username: user.Username,
+					Disabled: pgtype.Bool{Bool: false, Valid: true},
+				}
+				store.EXPECT().
+					UpdateUserAccessTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateUserAccessTxResult{User: user}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUserAccess(t, recorder.Body, user, 0)
+			},
+		},
+		{
+			name:   "OwnAccess",
+			method: http.MethodPost,
+			url:    "/admin/users/banker/disable",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserAccessTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "Depositor",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/admin/users/%s/disable", user.Username),
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.RandomOwner(), util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserAccessTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "UserNotFound",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/admin/users/%s/disable", user.Username),
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					UpdateUserAccessTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "RevokeSessions",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/admin/users/%s/revoke_sessions", user.Username),
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(int64(3), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUserAccess(t, recorder.Body, user, 3)
+			},
+		},
+		{
+			name:   "RevokeSessionsInternalError",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/admin/users/%s/revoke_sessions", user.Username),
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				err := json.NewEncoder(&body).Encode(tc.body)
+				require.NoError(t, err)
+			}
+
+			request, err := http.NewRequest(tc.method, tc.url, &body)
+			require.NoError(t, err)
+
+			tc.setupAuth(request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateUserAccessAudit(t *testing.T) {
+	user, _ := randomUser(t)
+
+	disabledUser := user
+	disabledUser.Disabled = true
+	disabledUser.Version++
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		UpdateUserAccessTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.UpdateUserAccessTxResult{User: disabledUser}, nil)
+
+	var auditLog db.CreateAuditLogParams
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			auditLog = arg
+			return db.AuditLog{}, nil
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/admin/users/%s/disable", user.Username)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Equal(t, "banker", auditLog.Actor)
+	require.Equal(t, "users", auditLog.ResourceType)
+	require.Equal(t, user.Username, auditLog.ResourceID)
+
+	var changes map[string]fieldChange
+	err = json.Unmarshal(auditLog.Changes, &changes)
+	require.NoError(t, err)
+	require.Equal(t, fieldChange{Before: false, After: true}, changes["disabled"])
+}
+
+func requireBodyMatchUserAccess(t *testing.T, body *bytes.Buffer, user db.User, revokedSessions int64) {
+	var gotResponse struct {
+		User struct {
+			Username string `json:"username"`
+			Role     string `json:"role"`
+			Disabled bool   `json:"disabled"`
+		} `json:"user"`
+		RevokedSessions int64 `json:"revoked_sessions"`
+	}
+	err := json.NewDecoder(body).Decode(&gotResponse)
+	require.NoError(t, err)
+
+	require.Equal(t, user.Username, gotResponse.User.Username)
+	require.Equal(t, user.Role, gotResponse.User.Role)
+	require.Equal(t, user.Disabled, gotResponse.User.Disabled)
+	require.Equal(t, revokedSessions, gotResponse.RevokedSessions)
+}
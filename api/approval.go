@@ -0,0 +1,256 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// defaultApprovalTimeout is how long an operation waits for a checker unless APPROVAL_TIMEOUT is set
+const defaultApprovalTimeout = 24 * time.Hour
+
+// needsApproval reports whether a transfer of the amount waits for a banker to approve it.
+// A zero threshold executes every transfer immediately.
+func (server *Server) needsApproval(amount int64) bool {
+	threshold := server.config.TransferApprovalThreshold
+	return threshold > 0 && amount > threshold
+}
+
+// holdForApproval keeps the operation of the authenticated user until a banker other than them
+// approves it, and responds with the pending approval
+func (server *Server) holdForApproval(ctx *gin.Context, arg db.CreateApprovalParams) {
+	approval, ok := server.createApproval(ctx, arg)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, approval)
+}
+
+// createApproval holds the operation of the authenticated user for approval
+func (server *Server) createApproval(ctx *gin.Context, arg db.CreateApprovalParams) (db.Approval, bool) {
+	timeout := server.config.ApprovalTimeout
+	if timeout == 0 {
+		timeout = defaultApprovalTimeout
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg.Maker = authPayload.Username
+	arg.ExpiresAt = time.Now().Add(timeout)
+
+	approval, err := server.store.CreateApproval(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return approval, false
+	}
+
+	setAuditChange(ctx, "approvals", strconv.FormatInt(approval.ID, 10), nil, approval)
+
+	return approval, true
+}
+
+type listApprovalsRequest struct {
+	pageRequest
+	Maker  string `form:"maker" binding:"omitempty,alphanum"`
+	Status string `form:"status" binding:"omitempty,approval_status"`
+}
+
+func approvalPosition(approval db.Approval) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: approval.CreatedAt,
+		ID:      strconv.FormatInt(approval.ID, 10),
+	}
+}
+
+// listApprovals lists the operations held for approval, oldest first
+func (server *Server) listApprovals(ctx *gin.Context) {
+	var req listApprovalsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	maker := pgtype.Text{String: req.Maker, Valid: req.Maker != ""}
+	status := pgtype.Text{String: req.Status, Valid: req.Status != ""}
+
+	scope := "approvals:" + req.Maker + ":" + req.Status
+
+	page, ok := listPage(ctx, server, scope, req.pageRequest, approvalPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.Approval, error) {
+			arg := db.ListApprovalsAfterParams{
+				Maker:  maker,
+				Status: status,
+				Limit:  limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListApprovalsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.Approval, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListApprovalsBefore(ctx, db.ListApprovalsBeforeParams{
+				Maker:           maker,
+				Status:          status,
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "approvals", page.Items, page))
+}
+
+type approvalRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getApproval(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, authorizedApproval(ctx))
+}
+
+// approveOperation executes the held operation on behalf of its maker
+func (server *Server) approveOperation(ctx *gin.Context) {
+	approval := authorizedApproval(ctx)
+	if !reviewableApproval(ctx, approval) {
+		return
+	}
+
+	// the maker may have lost the right to the transfer while it was held
+	if approval.Kind == util.TransferApproval && !server.authorizeApprovedTransfer(ctx, approval) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.ApproveTx(ctx, db.ApproveTxParams{
+		ID:      approval.ID,
+		Checker: authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the approval was resolved after it was read, or the adjusted account changed since it was asked for
+			err := fmt.Errorf("approval %d is no longer pending or its account changed, reject it and ask again", approval.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			// an account was frozen or closed while the transfer was held
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if db.IsTxConflict(err) {
+			ctx.Header("Retry-After", "1")
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "approvals", strconv.FormatInt(approval.ID, 10), approval, result.Approval)
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// authorizeApprovedTransfer runs the checks of authorizeTransfer again, on behalf of the maker of the held
+// transfer: their role and membership or access grant, the limit of the grant, their verified email, and
+// the screening of everyone involved may all have changed since they asked for it.
+func (server *Server) authorizeApprovedTransfer(ctx *gin.Context, approval db.Approval) bool {
+	maker, err := server.store.GetUser(ctx, approval.Maker)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if maker.Disabled {
+		err := fmt.Errorf("maker %s of approval %d is disabled, reject it", maker.Username, approval.ID)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return false
+	}
+
+	checkerPayload := ctx.MustGet(authorizationPayloadKey)
+	ctx.Set(authorizationPayloadKey, &token.Payload{Username: maker.Username, Role: maker.Role})
+	defer func() {
+		// the approval is audited as made by the checker, not through the grant of the maker
+		ctx.Set(authorizationPayloadKey, checkerPayload)
+		delete(ctx.Keys, accessGrantKey)
+	}()
+
+	return server.authorizeTransfer(ctx, transferRequest{
+		FromAccountID: approval.AccountID,
+		ToAccountID:   approval.ToAccountID.Int64,
+		Amount:        approval.Amount.Int64,
+		Currency:      approval.Currency,
+	})
+}
+
+// rejectOperation drops the held operation without executing it
+func (server *Server) rejectOperation(ctx *gin.Context) {
+	oldApproval := authorizedApproval(ctx)
+	if !reviewableApproval(ctx, oldApproval) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	approval, err := server.store.ResolveApproval(ctx, db.ResolveApprovalParams{
+		ID:      oldApproval.ID,
+		Status:  util.RejectedApproval,
+		Checker: pgtype.Text{String: authPayload.Username, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := fmt.Errorf("approval %d is no longer pending", oldApproval.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "approvals", strconv.FormatInt(approval.ID, 10), oldApproval, approval)
+
+	ctx.JSON(http.StatusOK, approval)
+}
+
+// reviewableApproval checks that the approval still waits for a checker and that the user is not its maker.
+// Approvals past their timeout count as rejected even before the timeout job marks them so.
+func reviewableApproval(ctx *gin.Context, approval db.Approval) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if approval.Maker == authPayload.Username {
+		err := fmt.Errorf("approval %d must be reviewed by someone other than its maker", approval.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+
+	status := approval.Status
+	if status == util.PendingApproval && !approval.ExpiresAt.After(time.Now()) {
+		status = util.RejectedApproval
+	}
+
+	if status != util.PendingApproval {
+		err := fmt.Errorf("approval %d is %s", approval.ID, status)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.config.TransferApprovalThreshold = 100
+
+	var users []db.User
+	for i := 0; i < 4; i++ {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	maker, recipient, banker, checker := users[0], users[1], users[2], users[3]
+	banker = setRole(t, store, banker, util.BankerRole)
+	checker = setRole(t, store, checker, util.BankerRole)
+
+	fromAccount, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    maker.Username,
+		Balance:  1000,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	toAccount, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    recipient.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	transfer := func(amount int64) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/transfers", maker, gin.H{
+			"from_account_id": fromAccount.ID,
+			"to_account_id":   toAccount.ID,
+			"amount":          amount,
+			"currency":        util.USD,
+		})
+	}
+	held := func(recorder *httptest.ResponseRecorder) db.Approval {
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+		var approval db.Approval
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &approval))
+		require.Equal(t, util.PendingApproval, approval.Status)
+		return approval
+	}
+	review := func(user db.User, approval db.Approval, action string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, fmt.Sprintf("/approvals/%d/%s", approval.ID, action), user, nil)
+	}
+	balance := func(account db.Account) int64 {
+		account, err := store.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		return account.Balance
+	}
+
+	// transfers up to the threshold execute immediately
+	require.Equal(t, http.StatusOK, transfer(100).Code)
+	require.Equal(t, int64(100), balance(toAccount))
+
+	approval := held(transfer(300))
+	require.Equal(t, util.TransferApproval, approval.Kind)
+	require.Equal(t, maker.Username, approval.Maker)
+	require.Equal(t, int64(300), approval.Amount.Int64)
+	require.WithinDuration(t, time.Now().Add(defaultApprovalTimeout), approval.ExpiresAt, time.Minute)
+	require.Equal(t, int64(100), balance(toAccount), "the transfer waits for approval")
+
+	// the maker follows their approval, only bankers review it
+	recorder := send(http.MethodGet, fmt.Sprintf("/approvals/%d", approval.ID), maker, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, http.StatusForbidden, send(http.MethodGet, fmt.Sprintf("/approvals/%d", approval.ID), recipient, nil).Code)
+	require.Equal(t, http.StatusForbidden, send(http.MethodGet, "/approvals", maker, nil).Code)
+	require.Equal(t, http.StatusForbidden, review(maker, approval, "approve").Code)
+
+	recorder = send(http.MethodGet, "/approvals?status=pending", banker, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var approvals struct {
+		Approvals  []db.Approval `json:"approvals"`
+		NextCursor *string       `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &approvals))
+	require.Equal(t, []db.Approval{approval}, approvals.Approvals)
+	require.Nil(t, approvals.NextCursor)
+
+	recorder = review(banker, approval, "approve")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var result db.ApproveTxResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	require.Equal(t, util.ApprovedApproval, result.Approval.Status)
+	require.Equal(t, banker.Username, result.Approval.Checker.String)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Approval.TransferID.Int64)
+	require.Equal(t, int64(400), balance(toAccount))
+
+	require.Equal(t, http.StatusConflict, review(checker, approval, "approve").Code, "approvals execute once")
+
+	// held transfers are authorized again for their maker when approved
+	delegate, _ := randomUser(t)
+	delegate, err = store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       delegate.Username,
+		HashedPassword: delegate.HashedPassword,
+		FullName:       delegate.FullName,
+		Email:          delegate.Email,
+	})
+	require.NoError(t, err)
+	grant, err := store.CreateAccessGrant(context.Background(), db.CreateAccessGrantParams{
+		Grantor:       maker.Username,
+		Grantee:       delegate.Username,
+		AccountID:     fromAccount.ID,
+		Scopes:        []string{util.TransferScope},
+		TransferLimit: pgtype.Int8{Int64: 500, Valid: true},
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	approval = held(send(http.MethodPost, "/transfers", delegate, gin.H{
+		"from_account_id": fromAccount.ID,
+		"to_account_id":   toAccount.ID,
+		"amount":          300,
+		"currency":        util.USD,
+	}))
+	_, err = store.RevokeAccessGrant(context.Background(), grant.ID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, review(checker, approval, "approve").Code, "the grant was revoked")
+	require.Equal(t, int64(400), balance(toAccount))
+	require.Equal(t, http.StatusOK, review(checker, approval, "reject").Code)
+
+	approval = held(transfer(300))
+	server.config.RequireVerifiedEmail = true
+	recorder = review(checker, approval, "approve")
+	server.config.RequireVerifiedEmail = false
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Contains(t, recorder.Body.String(), errEmailNotVerified.Error())
+	require.Equal(t, int64(400), balance(toAccount))
+	require.Equal(t, http.StatusOK, review(checker, approval, "reject").Code)
+
+	// rejected transfers never execute
+	approval = held(transfer(300))
+	recorder = review(checker, approval, "reject")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), fmt.Sprintf(`"checker":"%s"`, checker.Username))
+	require.Equal(t, http.StatusConflict, review(banker, approval, "approve").Code)
+	require.Equal(t, int64(400), balance(toAccount))
+
+	// adjustments need a banker other than the one asking for them
+	recorder = send(http.MethodPut, fmt.Sprintf("/accounts/%d", toAccount.ID), banker, gin.H{"balance": 50})
+	adjustment := held(recorder)
+	require.Equal(t, util.AdjustmentApproval, adjustment.Kind)
+	require.Equal(t, int64(400), balance(toAccount))
+
+	require.Equal(t, http.StatusForbidden, review(banker, adjustment, "approve").Code)
+	recorder = review(checker, adjustment, "approve")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	require.Equal(t, int64(50), result.Account.Balance)
+	require.Equal(t, int64(50), balance(toAccount))
+
+	// an adjustment does not overwrite money moved since it was asked for
+	adjustment = held(send(http.MethodPut, fmt.Sprintf("/accounts/%d", toAccount.ID), banker, gin.H{"balance": 5}))
+	require.Equal(t, http.StatusOK, transfer(10).Code)
+	require.Equal(t, http.StatusConflict, review(checker, adjustment, "approve").Code)
+	require.Equal(t, int64(60), balance(toAccount))
+
+	// approvals nobody reviewed in time are rejected
+	approval, err = store.CreateApproval(context.Background(), db.CreateApprovalParams{
+		Kind:        util.TransferApproval,
+		Maker:       maker.Username,
+		AccountID:   fromAccount.ID,
+		ToAccountID: pgtype.Int8{Int64: toAccount.ID, Valid: true},
+		Amount:      pgtype.Int8{Int64: 300, Valid: true},
+		Currency:    util.USD,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	recorder = review(checker, approval, "approve")
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Contains(t, recorder.Body.String(), util.RejectedApproval)
+
+	rejected, err := store.RejectExpiredApprovals(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rejected)
+
+	// money requests cannot go around the threshold
+	recorder = send(http.MethodPost, "/money_requests", recipient, gin.H{
+		"payer":         maker.Username,
+		"to_account_id": toAccount.ID,
+		"amount":        300,
+		"currency":      util.USD,
+	})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	requestIDHeaderKey = "X-Request-ID"
+	requestIDKey       = "request_id"
+	auditChangeKey     = "audit_change"
+	auditLogTimeout    = 5 * time.Second
+)
+
+// auditChange is the resource a handler changed, with its state before and after the change
+type auditChange struct {
+	ResourceType string
+	ResourceID   string
+	Before       any
+	After        any
+}
+
+// fieldChange holds the before and after values of a changed field
+type fieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// setAuditChange records the resource changed by the current request for the audit log.
+// Before is nil for created resources and after is nil for deleted ones.
+func setAuditChange(ctx *gin.Context, resourceType string, resourceID string, before any, after any) {
+	ctx.Set(auditChangeKey, auditChange{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// requestIDMiddleware tags every request with an ID, reusing the one sent by the client if any
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeaderKey)
+		if len(requestID) == 0 {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeaderKey, requestID)
+		ctx.Next()
+	}
+}
+
+// auditMiddleware writes an audit log record for every non-GET request once it has been handled,
+// and for every request made through an access grant
+func auditMiddleware(store db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		// reads are only audited when a delegate makes them
+		_, delegated := accessGrant(ctx)
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if !delegated {
+				return
+			}
+		}
+
+		arg, err := newAuditLogParams(ctx)
+		if err != nil {
+			log.Println("cannot build audit log: ", err)
+			return
+		}
+
+		// the request context may already be cancelled, but the record must still be written
+		auditCtx, cancel := context.WithTimeout(context.Background(), auditLogTimeout)
+		defer cancel()
+
+		_, err = store.CreateAuditLog(auditCtx, arg)
+		if err != nil {
+			log.Println("cannot create audit log: ", err)
+		}
+	}
+}
+
+func newAuditLogParams(ctx *gin.Context) (db.CreateAuditLogParams, error) {
+	arg := db.CreateAuditLogParams{
+		RequestID:  ctx.GetString(requestIDKey),
+		Method:     ctx.Request.Method,
+		Route:      ctx.FullPath(),
+		StatusCode: int32(ctx.Writer.Status()),
+		Changes:    json.RawMessage("{}"),
+		ClientIp:   ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+	}
+
+	if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+		authPayload := payload.(*token.Payload)
+		arg.Actor = authPayload.Username
+		arg.ActorRole = authPayload.Role
+	}
+
+	if grant, ok := accessGrant(ctx); ok {
+		arg.GrantID = pgtype.Int8{Int64: grant.ID, Valid: true}
+	}
+
+	arg.ResourceType, arg.ResourceID = routeResource(ctx)
+
+	if value, ok := ctx.Get(auditChangeKey); ok {
+		change := value.(auditChange)
+		arg.ResourceType = change.ResourceType
+		arg.ResourceID = change.ResourceID
+
+		changes, err := diffResources(change.Before, change.After)
+		if err != nil {
+			return arg, err
+		}
+		arg.Changes = changes
+	}
+
+	return arg, nil
+}
+
+// routeResource guesses the target resource from the route, e.g. "/accounts/:id" gives ("accounts", id)
+func routeResource(ctx *gin.Context) (resourceType string, resourceID string) {
+	route := ctx.FullPath()
+	if len(route) == 0 {
+		route = ctx.Request.URL.Path
+	}
+
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	resourceType = segments[0]
+
+	for _, param := range ctx.Params {
+		resourceID = param.Value
+		break
+	}
+
+	return
+}
+
+// diffResources returns the fields that differ between before and after as JSON
+func diffResources(before any, after any) (json.RawMessage, error) {
+	beforeFields, err := resourceFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := resourceFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]fieldChange)
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = fieldChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = fieldChange{Before: nil, After: value}
+		}
+	}
+
+	return json.Marshal(changes)
+}
+
+func resourceFields(resource any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if resource == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+type listAuditLogsRequest struct {
+	pageRequest
+	Actor        string    `form:"actor"`
+	Method       string    `form:"method"`
+	ResourceType string    `form:"resource_type"`
+	ResourceID   string    `form:"resource_id"`
+	RequestID    string    `form:"request_id"`
+	GrantID      *int64    `form:"grant_id" binding:"omitempty,min=1"`
+	From         time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To           time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// scope identifies the listing of the filters, so that a cursor only pages through the listing it came from
+func (req listAuditLogsRequest) scope() string {
+	grantID := ""
+	if req.GrantID != nil {
+		grantID = strconv.FormatInt(*req.GrantID, 10)
+	}
+	return strings.Join([]string{
+		"audit_logs", req.Actor, strings.ToUpper(req.Method), req.ResourceType, req.ResourceID, req.RequestID, grantID,
+		req.From.Format(time.RFC3339Nano), req.To.Format(time.RFC3339Nano),
+	}, ":")
+}
+
+func auditLogPosition(auditLog db.AuditLog) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: auditLog.CreatedAt,
+		ID:      strconv.FormatInt(auditLog.ID, 10),
+	}
+}
+
+// listAuditLogs lists the audit logs matching the filters, newest first
+func (server *Server) listAuditLogs(ctx *gin.Context) {
+	var req listAuditLogsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	actor := optionalText(req.Actor)
+	method := optionalText(strings.ToUpper(req.Method))
+	resourceType := optionalText(req.ResourceType)
+	resourceID := optionalText(req.ResourceID)
+	requestID := optionalText(req.RequestID)
+	grantID := optionalInt8(req.GrantID)
+	createdFrom := pgtype.Timestamptz{
+		Time:  req.From,
+		Valid: !req.From.IsZero(),
+	}
+	createdTo := pgtype.Timestamptz{
+		Time:  req.To,
+		Valid: !req.To.IsZero(),
+	}
+
+	scope := req.scope()
+
+	page, ok := listPage(ctx, server, scope, req.pageRequest, auditLogPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.AuditLog, error) {
+			arg := db.ListAuditLogsAfterParams{
+				Actor:        actor,
+				Method:       method,
+				ResourceType: resourceType,
+				ResourceID:   resourceID,
+				RequestID:    requestID,
+				GrantID:      grantID,
+				CreatedFrom:  createdFrom,
+				CreatedTo:    createdTo,
+				Limit:        limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListAuditLogsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.AuditLog, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListAuditLogsBefore(ctx, db.ListAuditLogsBeforeParams{
+				Actor:           actor,
+				Method:          method,
+				ResourceType:    resourceType,
+				ResourceID:      resourceID,
+				RequestID:       requestID,
+				GrantID:         grantID,
+				CreatedFrom:     createdFrom,
+				CreatedTo:       createdTo,
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "audit_logs", page.Items, page))
+}
+
+// optionalText turns an empty query filter into a NULL argument
+func optionalText(value string) pgtype.Text {
+	return pgtype.Text{
+		String: value,
+		Valid:  len(value) > 0,
+	}
+}
@@ -0,0 +1,451 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/foyez/simplebank/db/mock"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	user, _ := randomUser(t)
+	newEmail := util.RandomEmail()
+
+	updatedUser := user
+	updatedUser.Email = newEmail
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		UpdateUserTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.UpdateUserTxResult{User: updatedUser}, nil)
+
+	var auditLog db.CreateAuditLogParams
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			auditLog = arg
+			return db.AuditLog{}, nil
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"email": newEmail})
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("/users/%s", user.Username)
+	request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	request.Header.Set(requestIDHeaderKey, "test-request-id")
+	request.Header.Set("User-Agent", "audit-test")
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "test-request-id", recorder.Header().Get(requestIDHeaderKey))
+
+	require.Equal(t, "test-request-id", auditLog.RequestID)
+	require.Equal(t, "banker", auditLog.Actor)
+	require.Equal(t, util.BankerRole, auditLog.ActorRole)
+	require.Equal(t, http.MethodPatch, auditLog.Method)
+	require.Equal(t, "/users/:username", auditLog.Route)
+	require.Equal(t, "users", auditLog.ResourceType)
+	require.Equal(t, user.Username, auditLog.ResourceID)
+	require.Equal(t, int32(http.StatusOK), auditLog.StatusCode)
+	require.Equal(t, "audit-test", auditLog.UserAgent)
+
+	var changes map[string]fieldChange
+	err = json.Unmarshal(auditLog.Changes, &changes)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, user.Email, changes["email"].Before)
+	require.Equal(t, newEmail, changes["email"].After)
+}
+
+func TestAuditMiddlewareSkipsReads(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get(requestIDHeaderKey))
+}
+
+func TestDiffResources(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+
+	updatedAccount := account
+	updatedAccount.Balance = account.Balance + 100
+
+	testCases := []struct {
+		name    string
+		before  any
+		after   any
+		changes map[string]fieldChange
+	}{
+		{
+			name:   "Update",
+			before: account,
+			after:  updatedAccount,
+			changes: map[string]fieldChange{
+				"balance": {Before: float64(account.Balance), After: float64(updatedAccount.Balance)},
+			},
+		},
+		{
+			name:    "Unchanged",
+			before:  account,
+			after:   account,
+			changes: map[string]fieldChange{},
+		},
+		{
+			name:   "Create",
+			before: nil,
+			after:  gin.H{"currency": account.Currency},
+			changes: map[string]fieldChange{
+				"currency": {Before: nil, After: account.Currency},
+			},
+		},
+		{
+			name:   "Delete",
+			before: gin.H{"currency": account.Currency},
+			after:  nil,
+			changes: map[string]fieldChange{
+				"currency": {Before: account.Currency, After: nil},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := diffResources(tc.before, tc.after)
+			require.NoError(t, err)
+
+			var changes map[string]fieldChange
+			err = json.Unmarshal(data, &changes)
+			require.NoError(t, err)
+			require.Equal(t, tc.changes, changes)
+		})
+	}
+}
+
+func TestListAuditLogsAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	n := 5
+	auditLogs := make([]db.AuditLog, n)
+	for i := 0; i < n; i++ {
+		auditLogs[i] = randomAuditLog(banker.Username)
+		auditLogs[i].ID = int64(n - i)
+		auditLogs[i].CreatedAt = time.Date(2023, 1, n-i, 0, 0, 0, 0, time.UTC)
+	}
+
+	actor := pgtype.Text{String: banker.Username, Valid: true}
+	scope := listAuditLogsRequest{Actor: banker.Username}.scope()
+
+	type Query struct {
+		cursor   func(codec *pagination.Codec) string
+		pageSize int
+		actor    string
+	}
+
+	testCases := []struct {
+		name          string
+		query         Query
+		setupAuth     func(request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstPage",
+			query: Query{
+				pageSize: n - 1,
+				actor:    banker.Username,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditLogsAfterParams{
+					Actor: actor,
+					Limit: int32(n),
+				}
+
+				store.EXPECT().
+					ListAuditLogsAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(auditLogs, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next, prev := requireBodyMatchAuditLogs(t, recorder.Body, auditLogs[:n-1])
+				require.NotNil(t, next)
+				require.Nil(t, prev)
+			},
+		},
+		{
+			name: "NextPage",
+			query: Query{
+				cursor: func(codec *pagination.Codec) string {
+					return codec.Encode(scope, auditLogPosition(auditLogs[0]))
+				},
+				pageSize: n - 1,
+				actor:    banker.Username,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditLogsAfterParams{
+					Actor:           actor,
+					CursorCreatedAt: pgtype.Timestamptz{Time: auditLogs[0].CreatedAt, Valid: true},
+					CursorID:        pgtype.Int8{Int64: auditLogs[0].ID, Valid: true},
+					Limit:           int32(n),
+				}
+
+				store.EXPECT().
+					ListAuditLogsAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(auditLogs[1:], nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next, prev := requireBodyMatchAuditLogs(t, recorder.Body, auditLogs[1:])
+				require.Nil(t, next)
+				require.NotNil(t, prev)
+			},
+		},
+		{
+			name: "PrevPage",
+			query: Query{
+				cursor: func(codec *pagination.Codec) string {
+					cursor := auditLogPosition(auditLogs[n-1])
+					cursor.Backward = true
+					return codec.Encode(scope, cursor)
+				},
+				pageSize: n - 1,
+				actor:    banker.Username,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditLogsBeforeParams{
+					Actor:           actor,
+					CursorCreatedAt: auditLogs[n-1].CreatedAt,
+					CursorID:        auditLogs[n-1].ID,
+					Limit:           int32(n),
+				}
+
+				// rows before a cursor come oldest first
+				rows := make([]db.AuditLog, 0, n-1)
+				for i := n - 2; i >= 0; i-- {
+					rows = append(rows, auditLogs[i])
+				}
+
+				store.EXPECT().
+					ListAuditLogsBefore(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next, prev := requireBodyMatchAuditLogs(t, recorder.Body, auditLogs[:n-1])
+				require.NotNil(t, next)
+				require.Nil(t, prev)
+			},
+		},
+		{
+			name: "CursorOfOtherFilters",
+			query: Query{
+				cursor: func(codec *pagination.Codec) string {
+					return codec.Encode(scope, auditLogPosition(auditLogs[0]))
+				},
+				actor: util.RandomOwner(),
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditLogsAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DepositorForbidden",
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditLogsAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditLogsAfter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.AuditLog{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			query: Query{
+				pageSize: 1000,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditLogsAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/audit", nil)
+			require.NoError(t, err)
+
+			q := request.URL.Query()
+			if tc.query.cursor != nil {
+				q.Add("cursor", tc.query.cursor(server.pageCodec))
+			}
+			if tc.query.pageSize > 0 {
+				q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			}
+			if len(tc.query.actor) > 0 {
+				q.Add("actor", tc.query.actor)
+			}
+			request.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(request, server.tokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomAuditLog(actor string) db.AuditLog {
+	return db.AuditLog{
+		ID:           util.RandomInt(1, 1000),
+		RequestID:    util.RandomString(12),
+		Actor:        actor,
+		ActorRole:    util.BankerRole,
+		Method:       http.MethodPatch,
+		Route:        "/users/:username",
+		ResourceType: "users",
+		ResourceID:   util.RandomOwner(),
+		StatusCode:   http.StatusOK,
+		Changes:      json.RawMessage(`{}`),
+		ClientIp:     "127.0.0.1",
+		UserAgent:    "test",
+	}
+}
+
+func requireBodyMatchAuditLogs(t *testing.T, body *bytes.Buffer, auditLogs []db.AuditLog) (next *string, prev *string) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var page struct {
+		AuditLogs  []db.AuditLog `json:"audit_logs"`
+		NextCursor *string       `json:"next_cursor"`
+		PrevCursor *string       `json:"prev_cursor"`
+	}
+	err = json.Unmarshal(data, &page)
+	require.NoError(t, err)
+	require.Equal(t, len(auditLogs), len(page.AuditLogs))
+	for i := range auditLogs {
+		require.Equal(t, auditLogs[i].ID, page.AuditLogs[i].ID)
+		require.Equal(t, auditLogs[i].RequestID, page.AuditLogs[i].RequestID)
+		require.JSONEq(t, string(auditLogs[i].Changes), string(page.AuditLogs[i].Changes))
+	}
+
+	return page.NextCursor, page.PrevCursor
+}
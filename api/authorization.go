@@ -0,0 +1,300 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	authorizedAccountKey      = "authorized_account"
+	authorizedGrantKey        = "authorized_grant"
+	authorizedMoneyRequestKey = "authorized_money_request"
+	authorizedApprovalKey     = "authorized_approval"
+	authorizedScreeningHitKey = "authorized_screening_hit"
+	accessGrantKey            = "access_grant"
+)
+
+var errPermissionDenied = errors.New("permission denied")
+
+// ownership resolves whether the resource targeted by a request belongs to the authenticated user.
+// It writes the response itself and returns ok false when the resource cannot be resolved.
+type ownership func(ctx *gin.Context, authPayload *token.Payload) (owned bool, ok bool)
+
+// requirePermission only lets the request through if the role of the authenticated user
+// grants the action on the targeted resource. A nil ownership means the route acts on
+// resources of the authenticated user.
+//
+// Roles not granted the action even on their own resources are refused before the resource
+// is resolved, so that they cannot tell the resources that exist from the missing ones.
+func (server *Server) requirePermission(resource string, action string, resolve ownership) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !server.authorize(ctx, resource, action, true) {
+			return
+		}
+
+		owned := true
+		if resolve != nil {
+			var ok bool
+			owned, ok = resolve(ctx, authPayload)
+			if !ok {
+				ctx.Abort()
+				return
+			}
+		}
+
+		if !server.authorize(ctx, resource, action, owned) {
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// authorize checks the permission of the authenticated user and responds with 403 if it is not granted
+func (server *Server) authorize(ctx *gin.Context, resource string, action string, owned bool) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.policy.Allows(authPayload.Role, resource, action, owned) {
+		err := fmt.Errorf("%w: cannot %s %s", errPermissionDenied, action, resource)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// anyOwnership is used by routes spanning resources of every user
+func anyOwnership(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+	return false, true
+}
+
+// userOwnership resolves the user of the username in the uri
+func userOwnership(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+	return ctx.Param("username") == authPayload.Username, true
+}
+
+// accountOwnership resolves the account of the id in the uri, owned only by its owner,
+// keeping it for the handler to read with authorizedAccount
+func (server *Server) accountOwnership(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+	account, ok := server.loadAuthorizedAccount(ctx)
+	if !ok {
+		return false, false
+	}
+	return account.Owner == authPayload.Username, true
+}
+
+// accountMembership resolves the account of the id in the uri, owned by its owner
+// and by the members granted at least the required permission
+func (server *Server) accountMembership(required string) ownership {
+	return func(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+		account, ok := server.loadAuthorizedAccount(ctx)
+		if !ok {
+			return false, false
+		}
+		return server.holdsAccount(ctx, authPayload, account, required)
+	}
+}
+
+func (server *Server) loadAuthorizedAccount(ctx *gin.Context) (db.Account, bool) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Account{}, false
+	}
+
+	account, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	ctx.Set(authorizedAccountKey, account)
+	return account, true
+}
+
+// holdsAccount reports whether the authenticated user owns the account, has joined it
+// with at least the required permission, or was granted that access by one of its managers
+func (server *Server) holdsAccount(ctx *gin.Context, authPayload *token.Payload, account db.Account, required string) (bool, bool) {
+	if account.Owner == authPayload.Username {
+		return true, true
+	}
+
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  authPayload.Username,
+	})
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, false
+	}
+
+	// pending invitations grant nothing
+	if err == nil && member.AcceptedAt.Valid && util.MemberPermissionIncludes(member.Permission, required) {
+		return true, true
+	}
+
+	return server.delegatedAccess(ctx, authPayload, account, required)
+}
+
+// grantScopes maps the member permissions that can be delegated to the scope granting them
+var grantScopes = map[string]string{
+	util.ViewPermission:     util.ViewScope,
+	util.TransactPermission: util.TransferScope,
+}
+
+// delegatedAccess looks for an active grant of the required permission to the authenticated user,
+// keeping it so that the request is audited as made by a delegate
+func (server *Server) delegatedAccess(ctx *gin.Context, authPayload *token.Payload, account db.Account, required string) (bool, bool) {
+	scope, ok := grantScopes[required]
+	if !ok {
+		return false, true
+	}
+
+	grant, err := server.store.GetActiveAccessGrant(ctx, db.GetActiveAccessGrantParams{
+		AccountID: account.ID,
+		Grantee:   authPayload.Username,
+		Scope:     scope,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return false, true
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, false
+	}
+
+	ctx.Set(accessGrantKey, grant)
+	return true, true
+}
+
+// accessGrant returns the grant the authenticated user acts through, if any
+func accessGrant(ctx *gin.Context) (db.AccessGrant, bool) {
+	value, ok := ctx.Get(accessGrantKey)
+	if !ok {
+		return db.AccessGrant{}, false
+	}
+	return value.(db.AccessGrant), true
+}
+
+// grantOwnership resolves the access grant of the id in the uri, owned by both its grantor and grantee,
+// keeping it for the handler to read with authorizedGrant
+func (server *Server) grantOwnership(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+	var req grantRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, false
+	}
+
+	grant, err := server.store.GetAccessGrant(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, false
+	}
+
+	ctx.Set(authorizedGrantKey, grant)
+	return grant.Grantor == authPayload.Username || grant.Grantee == authPayload.Username, true
+}
+
+// moneyRequestOwnership loads the money request of the route, owned by both its requester and its payer
+func (server *Server) moneyRequestOwnership(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+	var req moneyRequestRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, false
+	}
+
+	request, err := server.store.GetMoneyRequest(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, false
+	}
+
+	ctx.Set(authorizedMoneyRequestKey, request)
+	return request.Requester == authPayload.Username || request.Payer == authPayload.Username, true
+}
+
+// approvalOwnership loads the approval of the route, owned by its maker
+func (server *Server) approvalOwnership(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+	var req approvalRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, false
+	}
+
+	approval, err := server.store.GetApproval(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, false
+	}
+
+	ctx.Set(authorizedApprovalKey, approval)
+	return approval.Maker == authPayload.Username, true
+}
+
+// screeningHitOwnership loads the screening hit of the route, owned by the user it matched
+func (server *Server) screeningHitOwnership(ctx *gin.Context, authPayload *token.Payload) (bool, bool) {
+	var req screeningHitRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, false
+	}
+
+	hit, err := server.store.GetScreeningHit(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, false
+	}
+
+	ctx.Set(authorizedScreeningHitKey, hit)
+	return hit.Username == authPayload.Username, true
+}
+
+// authorizedAccount returns the account resolved by accountOwnership or accountMembership
+func authorizedAccount(ctx *gin.Context) db.Account {
+	return ctx.MustGet(authorizedAccountKey).(db.Account)
+}
+
+// authorizedGrant returns the access grant resolved by grantOwnership
+func authorizedGrant(ctx *gin.Context) db.AccessGrant {
+	return ctx.MustGet(authorizedGrantKey).(db.AccessGrant)
+}
+
+// authorizedMoneyRequest returns the money request resolved by moneyRequestOwnership
+func authorizedMoneyRequest(ctx *gin.Context) db.MoneyRequest {
+	return ctx.MustGet(authorizedMoneyRequestKey).(db.MoneyRequest)
+}
+
+// authorizedApproval returns the approval resolved by approvalOwnership
+func authorizedApproval(ctx *gin.Context) db.Approval {
+	return ctx.MustGet(authorizedApprovalKey).(db.Approval)
+}
+
+// authorizedScreeningHit returns the screening hit resolved by screeningHitOwnership
+func authorizedScreeningHit(ctx *gin.Context) db.ScreeningHit {
+	return ctx.MustGet(authorizedScreeningHitKey).(db.ScreeningHit)
+}
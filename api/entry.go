@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func entryPosition(entry db.Entry) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: entry.CreatedAt,
+		ID:      strconv.FormatInt(entry.ID, 10),
+	}
+}
+
+func (server *Server) listEntries(ctx *gin.Context) {
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account := authorizedAccount(ctx)
+
+	scope := "entries:" + strconv.FormatInt(account.ID, 10)
+
+	page, ok := listPage(ctx, server, scope, req, entryPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.Entry, error) {
+			arg := db.ListEntriesAfterParams{
+				AccountID: account.ID,
+				Limit:     limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListEntriesAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.Entry, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListEntriesBefore(ctx, db.ListEntriesBeforeParams{
+				AccountID:       account.ID,
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "entries", page.Items, page))
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	etagHeaderKey    = "ETag"
+	ifMatchHeaderKey = "If-Match"
+)
+
+var (
+	errPreconditionFailed   = errors.New("resource has been modified, fetch it again and retry")
+	errPreconditionRequired = errors.New("If-Match header is required")
+)
+
+// newETag formats a resource version as a strong entity tag
+func newETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+func setETag(ctx *gin.Context, version int64) {
+	ctx.Header(etagHeaderKey, newETag(version))
+}
+
+// checkIfMatch validates the If-Match header against the current version of a resource.
+// It returns the version the update must be conditioned on, which is NULL when the client
+// did not ask for a conditional request. When the precondition fails, the error response
+// is written and false is returned.
+func (server *Server) checkIfMatch(ctx *gin.Context, currentVersion int64) (pgtype.Int8, bool) {
+	ifMatch := strings.TrimSpace(ctx.GetHeader(ifMatchHeaderKey))
+	if len(ifMatch) == 0 {
+		if server.config.RequireIfMatch {
+			ctx.JSON(http.StatusPreconditionRequired, errorResponse(errPreconditionRequired))
+			return pgtype.Int8{}, false
+		}
+		return pgtype.Int8{}, true
+	}
+
+	if ifMatch == "*" {
+		return pgtype.Int8{}, true
+	}
+
+	currentETag := newETag(currentVersion)
+	for _, etag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(etag) == currentETag {
+			return pgtype.Int8{Int64: currentVersion, Valid: true}, true
+		}
+	}
+
+	err := fmt.Errorf("%w: current version is %s", errPreconditionFailed, currentETag)
+	ctx.JSON(http.StatusPreconditionFailed, errorResponse(err))
+	return pgtype.Int8{}, false
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/lockout"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	// errInvalidCredentials does not tell an unknown username from a wrong password
+	errInvalidCredentials = errors.New("username or password is incorrect")
+	errTooManyLogins      = errors.New("too many failed logins, try again later")
+)
+
+// loginAttemptKey keeps the attempt counted by loginAllowed for refundLogin
+const loginAttemptKey = "login_attempt"
+
+// loginAllowed counts the login as failed against the username and the client ip before its password
+// is checked, and refuses it while either is locked out
+func (server *Server) loginAllowed(ctx *gin.Context, username string) bool {
+	attempt, wait, err := server.limiter.Reserve(ctx, server.store, username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if wait > 0 {
+		setRetryAfter(ctx, wait)
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLogins))
+		return false
+	}
+
+	ctx.Set(loginAttemptKey, attempt)
+	return true
+}
+
+// refundLogin takes back the failure counted by loginAllowed once the password or code turned out right
+func (server *Server) refundLogin(ctx *gin.Context) bool {
+	value, _ := ctx.Get(loginAttemptKey)
+	attempt, _ := value.(lockout.Attempt)
+	if err := server.limiter.Refund(ctx, server.store, attempt); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+type listLoginLockoutsRequest struct {
+	pageRequest
+	Scope string `form:"scope" binding:"omitempty,lockout_scope"`
+}
+
+// loginLockoutPosition keeps the scope of the lockout in the cursor,
+// since an identifier is only unique within its scope
+func loginLockoutPosition(lockout db.LoginLockout) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: lockout.LockedUntil.Time,
+		ID:      lockout.Identifier,
+		Value:   lockout.Scope,
+	}
+}
+
+// listLoginLockouts lists the usernames and client ips that are locked out, the longest locked first
+func (server *Server) listLoginLockouts(ctx *gin.Context) {
+	var req listLoginLockoutsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scopeFilter := pgtype.Text{String: req.Scope, Valid: req.Scope != ""}
+
+	scope := "lockouts:" + req.Scope
+
+	page, ok := listPage(ctx, server, scope, req.pageRequest, loginLockoutPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.LoginLockout, error) {
+			arg := db.ListLoginLockoutsAfterParams{
+				Scope: scopeFilter,
+				Limit: limit,
+			}
+			if cursor != nil {
+				arg.CursorLockedUntil = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorScope = pgtype.Text{String: cursor.Value, Valid: true}
+				arg.CursorIdentifier = pgtype.Text{String: cursor.ID, Valid: true}
+			}
+			return server.store.ListLoginLockoutsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.LoginLockout, error) {
+			return server.store.ListLoginLockoutsBefore(ctx, db.ListLoginLockoutsBeforeParams{
+				Scope:             scopeFilter,
+				CursorLockedUntil: cursor.SortKey,
+				CursorScope:       cursor.Value,
+				CursorIdentifier:  cursor.ID,
+				Limit:             limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "lockouts", page.Items, page))
+}
+
+type loginLockoutRequest struct {
+	Scope      string `uri:"scope" binding:"required,lockout_scope"`
+	Identifier string `uri:"identifier" binding:"required"`
+}
+
+// clearLoginLockout lets a username or a client ip log in again at once, and forgets its failures
+func (server *Server) clearLoginLockout(ctx *gin.Context) {
+	var req loginLockoutRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	lockout, err := server.store.GetLoginLockout(ctx, db.GetLoginLockoutParams{
+		Scope:      req.Scope,
+		Identifier: req.Identifier,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.DeleteLoginLockout(ctx, db.DeleteLoginLockoutParams{
+		Scope:      lockout.Scope,
+		Identifier: lockout.Identifier,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "login_lockouts", lockout.Scope+":"+lockout.Identifier, lockout, nil)
+
+	ctx.JSON(http.StatusOK, lockout)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/lockout"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginLockoutWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.limiter = lockout.NewLimiter(util.Config{
+		LoginUsernameThreshold: 3,
+		LoginLockoutDelay:      time.Minute,
+	})
+
+	send := func(method string, url string, user *db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		if user != nil {
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+		}
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	banker, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(6),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	banker = setRole(t, store, banker, util.BankerRole)
+
+	login := func(username string, password string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/users/login", nil, gin.H{"username": username, "password": password})
+	}
+
+	// unknown usernames cannot be told from wrong passwords
+	unknown := login(util.RandomOwner(), password)
+	wrong := login(user.Username, "incorrect")
+	require.Equal(t, http.StatusUnauthorized, unknown.Code)
+	require.Equal(t, http.StatusUnauthorized, wrong.Code)
+	require.Equal(t, unknown.Body.String(), wrong.Body.String())
+
+	// a success forgets the failures of the username
+	require.Equal(t, http.StatusOK, login(user.Username, password).Code)
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusUnauthorized, login(user.Username, "incorrect").Code)
+	}
+	recorder := login(user.Username, password)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code, "even the right password waits out the lockout")
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+	require.Contains(t, recorder.Body.String(), errTooManyLogins.Error())
+
+	list := func(query string) []db.LoginLockout {
+		recorder := send(http.MethodGet, "/admin/lockouts"+query, &banker, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp struct {
+			Lockouts   []db.LoginLockout `json:"lockouts"`
+			NextCursor *string           `json:"next_cursor"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Nil(t, rsp.NextCursor)
+		return rsp.Lockouts
+	}
+
+	require.Equal(t, http.StatusForbidden, send(http.MethodGet, "/admin/lockouts", &user, nil).Code)
+	require.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/admin/lockouts?scope=email", &banker, nil).Code)
+	require.Empty(t, list("?scope="+util.ClientIPLockout), "the ip is below its threshold")
+
+	lockouts := list("?scope=" + util.UsernameLockout)
+	require.Len(t, lockouts, 1)
+	require.Equal(t, user.Username, lockouts[0].Identifier)
+	require.Equal(t, int32(3), lockouts[0].FailedAttempts)
+
+	// lockouts are paged by cursor
+	other := util.RandomOwner()
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusUnauthorized, login(other, "incorrect").Code)
+	}
+	var identifiers []string
+	query := "?scope=" + util.UsernameLockout + "&page_size=1"
+	for {
+		recorder := send(http.MethodGet, "/admin/lockouts"+query, &banker, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp struct {
+			Lockouts   []db.LoginLockout `json:"lockouts"`
+			NextCursor *string           `json:"next_cursor"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Len(t, rsp.Lockouts, 1)
+		identifiers = append(identifiers, rsp.Lockouts[0].Identifier)
+		if rsp.NextCursor == nil {
+			break
+		}
+		query = "?scope=" + util.UsernameLockout + "&page_size=1&cursor=" + *rsp.NextCursor
+	}
+	require.Equal(t, []string{other, user.Username}, identifiers, "the longest locked come first")
+	require.Equal(t, http.StatusOK, send(http.MethodDelete, "/admin/lockouts/"+util.UsernameLockout+"/"+other, &banker, nil).Code)
+
+	// a banker lets the user log in again
+	clearURL := "/admin/lockouts/" + util.UsernameLockout + "/" + user.Username
+	require.Equal(t, http.StatusForbidden, send(http.MethodDelete, clearURL, &user, nil).Code)
+	require.Equal(t, http.StatusOK, send(http.MethodDelete, clearURL, &banker, nil).Code)
+	require.Equal(t, http.StatusNotFound, send(http.MethodDelete, clearURL, &banker, nil).Code)
+	require.Empty(t, list(""))
+
+	require.Equal(t, http.StatusOK, login(user.Username, password).Code)
+}
+
+func TestLoginLockoutTrustedProxies(t *testing.T) {
+	login := func(server *Server, forwardedFor string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"username":%q,"password":"incorrect"}`, util.RandomOwner())
+		request := httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body))
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// X-Forwarded-For is ignored unless it comes from a trusted proxy
+	server := newTestServer(t, memorydb.NewStore())
+	server.limiter = lockout.NewLimiter(util.Config{LoginClientIPThreshold: 2})
+	require.Equal(t, http.StatusUnauthorized, login(server, "10.0.0.1").Code)
+	require.Equal(t, http.StatusUnauthorized, login(server, "10.0.0.2").Code)
+	require.Equal(t, http.StatusTooManyRequests, login(server, "10.0.0.3").Code, "the remote address is locked out")
+
+	config := server.config
+	config.TrustedProxies = []string{"192.0.2.1"} // the remote address of httptest requests
+	server, err := NewServer(config, memorydb.NewStore())
+	require.NoError(t, err)
+	server.limiter = lockout.NewLimiter(util.Config{LoginClientIPThreshold: 2})
+	require.Equal(t, http.StatusUnauthorized, login(server, "10.0.0.1").Code)
+	require.Equal(t, http.StatusUnauthorized, login(server, "10.0.0.1").Code)
+	require.Equal(t, http.StatusUnauthorized, login(server, "10.0.0.2").Code, "the proxy forwards for several clients")
+	require.Equal(t, http.StatusTooManyRequests, login(server, "10.0.0.1").Code)
+
+	config.TrustedProxies = []string{"not an ip"}
+	_, err = NewServer(config, nil)
+	require.Error(t, err)
+}
@@ -1,22 +1,80 @@
 package api
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
 
+	mockdb "github.com/foyez/simplebank/db/mock"
 	db "github.com/foyez/simplebank/db/sqlc"
 	"github.com/foyez/simplebank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
 		TokenSymmetricKey:   util.RandomString(32),
+		CursorSymmetricKey:  util.RandomString(32),
+		TwoFactorSecretKey:  util.RandomString(32),
 		AccessTokenDuration: time.Minute,
 	}
 
+	// every mutating request is audited, tests that care set their own expectation first
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			CreateAuditLog(gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		// access tokens are checked against their user, who is active in an empty mock
+		// and holds the role of their last token
+		mockStore.EXPECT().
+			GetUserAccess(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			DoAndReturn(func(ctx context.Context, username string) (db.GetUserAccessRow, error) {
+				return db.GetUserAccessRow{Role: tokenRoles[username]}, nil
+			})
+
+		// transfers are assessed by the risk rules, which find nothing in an empty mock
+		mockStore.EXPECT().
+			CountTransfersBetween(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			ListTransfersSince(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			GetClientIPHistory(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			CountRiskDecisionsFromIP(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			CreateRiskDecision(gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		// and held while someone involved has a screening hit, which an empty mock never has
+		mockStore.EXPECT().
+			CountBlockingScreeningHits(gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		// and logins are counted against lockouts, which an empty mock never has
+		mockStore.EXPECT().
+			GetLoginLockout(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.LoginLockout{}, db.ErrRecordNotFound)
+		mockStore.EXPECT().
+			ReserveLoginAttempt(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			RefundLoginAttempt(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			DeleteLoginLockout(gomock.Any(), gomock.Any()).
+			AnyTimes()
+	}
+
 	server, err := NewServer(config, store)
 	require.NoError(t, err)
 
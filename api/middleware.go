@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	db "github.com/foyez/simplebank/db/sqlc"
 	"github.com/foyez/simplebank/token"
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +18,20 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker, accessibleRoles []string) gin.HandlerFunc {
+// errRevokedToken is returned for the tokens of users who were disabled or deleted,
+// who changed their password or role, or whose sessions were revoked since the token was issued
+var errRevokedToken = errors.New("token has been revoked")
+
+// accessRevoked reports whether the user lost the access a token issued at issuedAt was given under
+func accessRevoked(access db.GetUserAccessRow, issuedAt time.Time) bool {
+	return access.Disabled ||
+		issuedAt.Before(access.PasswordChangedAt) ||
+		issuedAt.Before(access.TokensValidAfter)
+}
+
+// authMiddleware accepts the access tokens the token maker verifies, as long as their user
+// has not lost access since the token was issued
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -46,9 +61,17 @@ func authMiddleware(tokenMaker token.Maker, accessibleRoles []string) gin.Handle
 			return
 		}
 
-		if !hasPermission(payload.Role, accessibleRoles) {
-			err := fmt.Errorf("permission denied")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		access, err := store.GetUserAccess(ctx, payload.Username)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errRevokedToken))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if accessRevoked(access, payload.IssuedAt) || payload.Role != access.Role {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errRevokedToken))
 			return
 		}
 
@@ -56,12 +79,3 @@ func authMiddleware(tokenMaker token.Maker, accessibleRoles []string) gin.Handle
 		ctx.Next()
 	}
 }
-
-func hasPermission(userRole string, accessibleRoles []string) bool {
-	for _, role := range accessibleRoles {
-		if userRole == role {
-			return true
-		}
-	}
-	return false
-}
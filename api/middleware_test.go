@@ -1,18 +1,26 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
 	"github.com/foyez/simplebank/token"
 	"github.com/foyez/simplebank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
+// tokenRoles holds the role of the last token added for each user,
+// which the mock store of newTestServer gives them
+var tokenRoles = make(map[string]string)
+
 func addAuthorization(
 	t *testing.T,
 	request *http.Request,
@@ -25,6 +33,7 @@ func addAuthorization(
 	token, payload, err := tokenMaker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
+	tokenRoles[username] = role
 
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
@@ -37,6 +46,7 @@ func TestAuthMiddleware(t *testing.T) {
 	testCases := []struct {
 		name          string
 		setupAuth     func(request *http.Request, tokenMaker token.Maker)
+		updateUser    func(store db.Store)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
@@ -83,18 +93,94 @@ func TestAuthMiddleware(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "UnknownUser",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.RandomOwner(), role, time.Minute)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "DisabledUser",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, role, time.Minute)
+			},
+			updateUser: func(store db.Store) {
+				_, err := store.UpdateUserAccessTx(context.Background(), db.UpdateUserAccessTxParams{
+					Username: username,
+					Disabled: pgtype.Bool{Bool: true, Valid: true},
+				})
+				require.NoError(t, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errRevokedToken.Error())
+			},
+		},
+		{
+			name: "PasswordChangedSinceIssued",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, role, time.Minute)
+			},
+			updateUser: func(store db.Store) {
+				_, err := store.UpdateUser(context.Background(), db.UpdateUserParams{
+					Username:          username,
+					PasswordChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+				})
+				require.NoError(t, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errRevokedToken.Error())
+			},
+		},
+		{
+			name: "RoleChangedSinceIssued",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, util.BankerRole, time.Minute)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errRevokedToken.Error())
+			},
+		},
+		{
+			name: "SessionsRevokedSinceIssued",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, role, time.Minute)
+			},
+			updateUser: func(store db.Store) {
+				_, err := store.BlockUserSessions(context.Background(), username)
+				require.NoError(t, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errRevokedToken.Error())
+			},
+		},
 	}
 
 	for i := range testCases {
 		tc := testCases[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			store := memorydb.NewStore()
+			server := newTestServer(t, store)
+
+			_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+				Username:       username,
+				HashedPassword: util.RandomString(6),
+				FullName:       util.RandomOwner(),
+				Email:          util.RandomEmail(),
+			})
+			require.NoError(t, err)
 
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker, []string{util.BankerRole, util.DepositorRole}),
+				authMiddleware(server.tokenMaker, server.store),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -105,6 +191,9 @@ func TestAuthMiddleware(t *testing.T) {
 			require.NoError(t, err)
 
 			tc.setupAuth(request, server.tokenMaker)
+			if tc.updateUser != nil {
+				tc.updateUser(store)
+			}
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
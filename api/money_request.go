@@ -0,0 +1,372 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// defaultMoneyRequestTTL is how long a money request stays payable unless the requester sets expires_at
+const defaultMoneyRequestTTL = 7 * 24 * time.Hour
+
+type createMoneyRequestRequest struct {
+	Payer       string     `json:"payer" binding:"required,alphanum"`
+	ToAccountID int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64      `json:"amount" binding:"required,gt=0"`
+	Currency    string     `json:"currency" binding:"required,currency"`
+	Note        string     `json:"note" binding:"max=140"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (server *Server) createMoneyRequest(ctx *gin.Context) {
+	var req createMoneyRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	expiresAt := time.Now().Add(defaultMoneyRequestTTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			err := errors.New("expires_at must be in the future")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	if !server.payableWithoutApproval(ctx, req.Amount) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Payer == authPayload.Username {
+		err := errors.New("cannot request money from yourself")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	owned, ok := server.holdsAccount(ctx, authPayload, account, util.TransactPermission)
+	if !ok || !server.authorize(ctx, "money_requests", "create", owned) {
+		return
+	}
+
+	request, err := server.store.CreateMoneyRequest(ctx, db.CreateMoneyRequestParams{
+		Requester:   authPayload.Username,
+		Payer:       req.Payer,
+		ToAccountID: account.ID,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Note:        req.Note,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		if db.ErrCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "money_requests", strconv.FormatInt(request.ID, 10), nil, request)
+
+	ctx.JSON(http.StatusCreated, request)
+}
+
+type listMoneyRequestsRequest struct {
+	pageRequest
+	Status string `form:"status" binding:"omitempty,money_request_status"`
+}
+
+func (req listMoneyRequestsRequest) status() pgtype.Text {
+	return pgtype.Text{String: req.Status, Valid: req.Status != ""}
+}
+
+func moneyRequestPosition(request db.MoneyRequest) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: request.CreatedAt,
+		ID:      strconv.FormatInt(request.ID, 10),
+	}
+}
+
+// listIncomingMoneyRequests lists the requests the user is asked to pay
+func (server *Server) listIncomingMoneyRequests(ctx *gin.Context) {
+	var req listMoneyRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	scope := "money_requests:incoming:" + authPayload.Username + ":" + req.Status
+
+	page, ok := listPage(ctx, server, scope, req.pageRequest, moneyRequestPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.MoneyRequest, error) {
+			arg := db.ListIncomingMoneyRequestsAfterParams{
+				Payer:  authPayload.Username,
+				Status: req.status(),
+				Limit:  limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListIncomingMoneyRequestsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.MoneyRequest, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListIncomingMoneyRequestsBefore(ctx, db.ListIncomingMoneyRequestsBeforeParams{
+				Payer:           authPayload.Username,
+				Status:          req.status(),
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "money_requests", page.Items, page))
+}
+
+// listOutgoingMoneyRequests lists the requests the user has sent
+func (server *Server) listOutgoingMoneyRequests(ctx *gin.Context) {
+	var req listMoneyRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	scope := "money_requests:outgoing:" + authPayload.Username + ":" + req.Status
+
+	page, ok := listPage(ctx, server, scope, req.pageRequest, moneyRequestPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.MoneyRequest, error) {
+			arg := db.ListOutgoingMoneyRequestsAfterParams{
+				Requester: authPayload.Username,
+				Status:    req.status(),
+				Limit:     limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListOutgoingMoneyRequestsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.MoneyRequest, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListOutgoingMoneyRequestsBefore(ctx, db.ListOutgoingMoneyRequestsBeforeParams{
+				Requester:       authPayload.Username,
+				Status:          req.status(),
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "money_requests", page.Items, page))
+}
+
+type moneyRequestRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getMoneyRequest(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, authorizedMoneyRequest(ctx))
+}
+
+type acceptMoneyRequestRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+}
+
+// acceptMoneyRequest pays the request from an account the payer chooses
+func (server *Server) acceptMoneyRequest(ctx *gin.Context) {
+	var req acceptMoneyRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	request := authorizedMoneyRequest(ctx)
+	if !partyToMoneyRequest(ctx, request, payerSide) || !pendingMoneyRequest(ctx, request) {
+		return
+	}
+
+	if !server.payableWithoutApproval(ctx, request.Amount) {
+		return
+	}
+
+	transfer := transferRequest{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}
+	if !server.authorizeTransfer(ctx, transfer) {
+		return
+	}
+
+	// accepting pays right away, so a payment the risk rules would hold for review is refused too
+	assessment, ok := server.assessTransfer(ctx, transfer)
+	if !ok {
+		return
+	}
+	if assessment.Outcome != util.AllowOutcome {
+		server.blockTransfer(ctx, transfer, assessment)
+		return
+	}
+	decision, ok := server.riskDecisionParams(ctx, transfer, assessment, pgtype.Int8{})
+	if !ok {
+		return
+	}
+
+	result, err := server.store.AcceptMoneyRequestTx(ctx, db.AcceptMoneyRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: req.FromAccountID,
+		RiskDecision:  &decision,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the request was resolved or expired after it was read
+			err := fmt.Errorf("money request %d is no longer pending", request.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if db.IsTxConflict(err) {
+			ctx.Header("Retry-After", "1")
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "money_requests", strconv.FormatInt(request.ID, 10), request, result.MoneyRequest)
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) declineMoneyRequest(ctx *gin.Context) {
+	request := authorizedMoneyRequest(ctx)
+	server.resolveMoneyRequest(ctx, request, payerSide, util.DeclinedRequest)
+}
+
+func (server *Server) cancelMoneyRequest(ctx *gin.Context) {
+	request := authorizedMoneyRequest(ctx)
+	server.resolveMoneyRequest(ctx, request, requesterSide, util.CancelledRequest)
+}
+
+// resolveMoneyRequest lets the given side of a pending request move it to the status
+func (server *Server) resolveMoneyRequest(ctx *gin.Context, oldRequest db.MoneyRequest, side string, status string) {
+	if !partyToMoneyRequest(ctx, oldRequest, side) || !pendingMoneyRequest(ctx, oldRequest) {
+		return
+	}
+
+	request, err := server.store.ResolveMoneyRequest(ctx, db.ResolveMoneyRequestParams{
+		ID:     oldRequest.ID,
+		Status: status,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := fmt.Errorf("money request %d is no longer pending", oldRequest.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "money_requests", strconv.FormatInt(request.ID, 10), oldRequest, request)
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+// payableWithoutApproval checks that a request can be paid without a banker approving the transfer,
+// since accepting it executes the transfer right away
+func (server *Server) payableWithoutApproval(ctx *gin.Context, amount int64) bool {
+	if server.needsApproval(amount) {
+		err := fmt.Errorf("amount exceeds the approval threshold %d, send a transfer instead", server.config.TransferApprovalThreshold)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// Sides of a money request
+const (
+	payerSide     = "payer"
+	requesterSide = "requester"
+)
+
+// partyToMoneyRequest checks that the user is on the side of the request the action belongs to
+func partyToMoneyRequest(ctx *gin.Context, request db.MoneyRequest, side string) bool {
+	party := request.Payer
+	if side == requesterSide {
+		party = request.Requester
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != party {
+		err := fmt.Errorf("only the %s of money request %d can do this", side, request.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// pendingMoneyRequest checks that the request can still be accepted, declined or cancelled.
+// Requests past their expiry count as expired even before the expiry job marks them so.
+func pendingMoneyRequest(ctx *gin.Context, request db.MoneyRequest) bool {
+	status := request.Status
+	if status == util.PendingRequest && !request.ExpiresAt.After(time.Now()) {
+		status = util.ExpiredRequest
+	}
+
+	if status != util.PendingRequest {
+		err := fmt.Errorf("money request %d is %s", request.ID, status)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyRequestWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	var users []db.User
+	for i := 0; i < 3; i++ {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	requester, payer, stranger := users[0], users[1], users[2]
+
+	toAccount, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    requester.Username,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+	fromAccount, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    payer.Username,
+		Balance:  100,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	requestMoney := func() db.MoneyRequest {
+		recorder := send(http.MethodPost, "/money_requests", requester, gin.H{
+			"payer":         payer.Username,
+			"to_account_id": toAccount.ID,
+			"amount":        25,
+			"currency":      util.EUR,
+			"note":          "dinner",
+		})
+		require.Equal(t, http.StatusCreated, recorder.Code)
+
+		var request db.MoneyRequest
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &request))
+		return request
+	}
+	respond := func(user db.User, request db.MoneyRequest, action string, body gin.H) int {
+		return send(http.MethodPost, fmt.Sprintf("/money_requests/%d/%s", request.ID, action), user, body).Code
+	}
+	accept := gin.H{"from_account_id": fromAccount.ID}
+
+	testCases := []struct {
+		name   string
+		update func(body gin.H)
+		code   int
+	}{
+		{"SelfRequest", func(body gin.H) { body["payer"] = requester.Username }, http.StatusBadRequest},
+		{"UnknownPayer", func(body gin.H) { body["payer"] = "unknown" + payer.Username }, http.StatusForbidden},
+		{"CurrencyMismatch", func(body gin.H) { body["currency"] = util.USD }, http.StatusBadRequest},
+		{"Expired", func(body gin.H) { body["expires_at"] = time.Now().Add(-time.Minute) }, http.StatusBadRequest},
+		{"NotHolder", func(body gin.H) { body["to_account_id"] = fromAccount.ID }, http.StatusForbidden},
+		{"NoAmount", func(body gin.H) { delete(body, "amount") }, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		body := gin.H{
+			"payer":         payer.Username,
+			"to_account_id": toAccount.ID,
+			"amount":        25,
+			"currency":      util.EUR,
+		}
+		tc.update(body)
+
+		recorder := send(http.MethodPost, "/money_requests", requester, body)
+		require.Equal(t, tc.code, recorder.Code, tc.name)
+	}
+
+	request := requestMoney()
+	require.Equal(t, util.PendingRequest, request.Status)
+	require.WithinDuration(t, time.Now().Add(defaultMoneyRequestTTL), request.ExpiresAt, time.Minute)
+
+	// both sides see the request
+	recorder := send(http.MethodGet, "/money_requests/incoming?status=pending", payer, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var incoming struct {
+		MoneyRequests []db.MoneyRequest `json:"money_requests"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &incoming))
+	require.Equal(t, []db.MoneyRequest{request}, incoming.MoneyRequests)
+
+	recorder = send(http.MethodGet, "/money_requests/outgoing", requester, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var outgoing struct {
+		MoneyRequests []db.MoneyRequest `json:"money_requests"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &outgoing))
+	require.Equal(t, []db.MoneyRequest{request}, outgoing.MoneyRequests)
+
+	recorder = send(http.MethodGet, "/money_requests/incoming?status=paid", payer, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodGet, fmt.Sprintf("/money_requests/%d", request.ID), stranger, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// only the payer accepts, from an account they may send money from
+	require.Equal(t, http.StatusForbidden, respond(requester, request, "accept", accept))
+	require.Equal(t, http.StatusForbidden, respond(stranger, request, "accept", accept))
+	require.Equal(t, http.StatusForbidden, respond(payer, request, "accept", gin.H{"from_account_id": toAccount.ID}))
+
+	recorder = send(http.MethodPost, fmt.Sprintf("/money_requests/%d/accept", request.ID), payer, accept)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var result db.AcceptMoneyRequestTxResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	require.Equal(t, util.AcceptedRequest, result.MoneyRequest.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.MoneyRequest.TransferID.Int64)
+	require.Equal(t, int64(75), result.Transfer.FromAccount.Balance)
+	require.Equal(t, int64(25), result.Transfer.ToAccount.Balance)
+
+	require.Equal(t, http.StatusConflict, respond(payer, request, "accept", accept), "requests are paid once")
+	require.Equal(t, http.StatusConflict, respond(requester, request, "cancel", nil))
+
+	// the payer declines, the requester cancels
+	request = requestMoney()
+	require.Equal(t, http.StatusForbidden, respond(requester, request, "decline", nil))
+	require.Equal(t, http.StatusOK, respond(payer, request, "decline", nil))
+	require.Equal(t, http.StatusConflict, respond(payer, request, "accept", accept))
+
+	request = requestMoney()
+	require.Equal(t, http.StatusForbidden, respond(payer, request, "cancel", nil))
+	require.Equal(t, http.StatusOK, respond(requester, request, "cancel", nil))
+	require.Equal(t, http.StatusConflict, respond(payer, request, "decline", nil))
+
+	// expired requests cannot be paid even before the expiry job marks them
+	request, err = store.CreateMoneyRequest(context.Background(), db.CreateMoneyRequestParams{
+		Requester:   requester.Username,
+		Payer:       payer.Username,
+		ToAccountID: toAccount.ID,
+		Amount:      25,
+		Currency:    util.EUR,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	recorder = send(http.MethodPost, fmt.Sprintf("/money_requests/%d/accept", request.ID), payer, accept)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Contains(t, recorder.Body.String(), util.ExpiredRequest)
+
+	fromAccount, err = store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(75), fromAccount.Balance)
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{Limit: 100})
+	require.NoError(t, err)
+	var changes int
+	for _, auditLog := range auditLogs {
+		if auditLog.ResourceType == "money_requests" && auditLog.StatusCode < 300 {
+			changes++
+		}
+	}
+	require.Equal(t, 6, changes, "three requests created, then accepted, declined and cancelled")
+}
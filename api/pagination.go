@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/foyez/simplebank/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPageSize = 20
+
+type pageRequest struct {
+	Cursor   string `form:"cursor"`
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+func (req pageRequest) size() int32 {
+	if req.PageSize == 0 {
+		return defaultPageSize
+	}
+	return req.PageSize
+}
+
+// cursorID parses the id of a cursor over a table with integer keys
+func cursorID(cursor pagination.Cursor) (int64, error) {
+	id, err := strconv.ParseInt(cursor.ID, 10, 64)
+	if err != nil {
+		return 0, pagination.ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// listPage serves one page of the keyset listing identified by scope.
+// after lists the rows following a cursor in the order of the listing, or the first rows when it is nil;
+// before lists the rows preceding a cursor in the reverse order.
+func listPage[T any](
+	ctx *gin.Context,
+	server *Server,
+	scope string,
+	req pageRequest,
+	position func(T) pagination.Cursor,
+	after func(cursor *pagination.Cursor, limit int32) ([]T, error),
+	before func(cursor pagination.Cursor, limit int32) ([]T, error),
+) (pagination.Page[T], bool) {
+	var cursor *pagination.Cursor
+	if req.Cursor != "" {
+		decoded, err := server.pageCodec.Decode(scope, req.Cursor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return pagination.Page[T]{}, false
+		}
+		cursor = &decoded
+	}
+
+	var rows []T
+	var err error
+	if cursor != nil && cursor.Backward {
+		rows, err = before(*cursor, req.size()+1)
+	} else {
+		rows, err = after(cursor, req.size()+1)
+	}
+	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return pagination.Page[T]{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return pagination.Page[T]{}, false
+	}
+
+	return pagination.NewPage(rows, int(req.size()), cursor, position), true
+}
+
+// pageResponse renders the items of a page under key, along with the tokens of the pages around it
+func pageResponse[T any, R any](server *Server, scope string, key string, items []R, page pagination.Page[T]) gin.H {
+	encode := func(cursor *pagination.Cursor) *string {
+		if cursor == nil {
+			return nil
+		}
+		token := server.pageCodec.Encode(scope, *cursor)
+		return &token
+	}
+
+	return gin.H{
+		key:           items,
+		"next_cursor": encode(page.Next),
+		"prev_cursor": encode(page.Prev),
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorKeyDiffersFromTokenKey(t *testing.T) {
+	key := util.RandomString(32)
+	_, err := NewServer(util.Config{TokenSymmetricKey: key, CursorSymmetricKey: key}, nil)
+	require.ErrorContains(t, err, "cursor symmetric key")
+}
+
+// requireBodyMatchPage checks the items of a page response and returns its cursor tokens
+func requireBodyMatchPage[T any](t *testing.T, body *bytes.Buffer, key string, items []T) (next *string, prev *string) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var page map[string]json.RawMessage
+	err = json.Unmarshal(data, &page)
+	require.NoError(t, err)
+
+	var gotItems []T
+	err = json.Unmarshal(page[key], &gotItems)
+	require.NoError(t, err)
+	require.Equal(t, items, gotItems)
+
+	err = json.Unmarshal(page["next_cursor"], &next)
+	require.NoError(t, err)
+	err = json.Unmarshal(page["prev_cursor"], &prev)
+	require.NoError(t, err)
+
+	return next, prev
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// hashNewPassword hashes a password the user chose, once the password policy accepts it
+func (server *Server) hashNewPassword(ctx *gin.Context, password string) (string, bool) {
+	if err := server.passwordPolicy.Check(password); err != nil {
+		if errors.Is(err, util.ErrBreachedPasswordList) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return "", false
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return "", false
+	}
+
+	hashedPassword, err := server.hasher.Hash(password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", false
+	}
+	return hashedPassword, true
+}
+
+// rehashPassword moves the hash of a user who just proved their password to the current scheme
+// and costs. The login goes on if it fails, the hash is moved at a later login.
+func (server *Server) rehashPassword(ctx *gin.Context, user db.User, password string) {
+	if !server.hasher.NeedsRehash(user.HashedPassword) {
+		return
+	}
+
+	hashedPassword, err := server.hasher.Hash(password)
+	if err != nil {
+		log.Println("cannot rehash password: ", err)
+		return
+	}
+
+	_, err = server.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+		Username:          user.Username,
+		OldHashedPassword: user.HashedPassword,
+		NewHashedPassword: hashedPassword,
+	})
+	if err != nil {
+		log.Println("cannot rehash password: ", err)
+	}
+}
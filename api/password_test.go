@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordWithMemoryStore(t *testing.T) {
+	breachedPassword := "correcthorse"
+	hash := sha1.Sum([]byte(breachedPassword))
+	breachedList := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(breachedList, []byte(hex.EncodeToString(hash[:])+":42\n"), 0o600))
+
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	passwordPolicy, err := util.LoadPasswordPolicy(util.Config{BreachedPasswords: breachedList})
+	require.NoError(t, err)
+	server.passwordPolicy = passwordPolicy
+
+	send := func(url string, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		require.NoError(t, json.NewEncoder(&data).Encode(body))
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, url, &data)
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	createUser := func(password string) *httptest.ResponseRecorder {
+		return send("/users", gin.H{
+			"username":  util.RandomOwner(),
+			"password":  password,
+			"full_name": util.RandomOwner(),
+			"email":     util.RandomEmail(),
+		})
+	}
+	login := func(username string, password string) *httptest.ResponseRecorder {
+		return send("/users/login", gin.H{"username": username, "password": password})
+	}
+
+	recorder := createUser(breachedPassword)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), util.ErrBreachedPassword.Error())
+	require.Equal(t, http.StatusBadRequest, createUser(util.RandomString(util.DefaultPasswordMinLength-1)).Code)
+	require.Equal(t, http.StatusBadRequest, createUser(util.RandomString(util.DefaultPasswordMaxLength+1)).Code)
+
+	recorder = createUser(util.RandomString(util.DefaultPasswordMinLength))
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	var created userResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	user, err := store.GetUser(context.Background(), created.Username)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(user.HashedPassword, "$argon2id$"))
+
+	// users who chose their password before Argon2id keep logging in with it
+	password := util.RandomString(6)
+	bcryptHashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	legacyUser, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: string(bcryptHashedPassword),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusUnauthorized, login(legacyUser.Username, util.RandomString(6)).Code)
+	user, err = store.GetUser(context.Background(), legacyUser.Username)
+	require.NoError(t, err)
+	require.Equal(t, legacyUser.HashedPassword, user.HashedPassword, "a wrong password does not rehash")
+
+	// and their hash moves to Argon2id once they do
+	require.Equal(t, http.StatusOK, login(legacyUser.Username, password).Code)
+	user, err = store.GetUser(context.Background(), legacyUser.Username)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(user.HashedPassword, "$argon2id$"))
+	require.False(t, server.hasher.NeedsRehash(user.HashedPassword))
+	require.Equal(t, legacyUser.Version, user.Version)
+	require.Equal(t, legacyUser.PasswordChangedAt, user.PasswordChangedAt)
+
+	require.Equal(t, http.StatusOK, login(legacyUser.Username, password).Code)
+}
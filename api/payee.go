@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type createPayeeRequest struct {
+	Nickname  string `json:"nickname" binding:"required,max=64"`
+	AccountID int64  `json:"account_id" binding:"required,min=1"`
+	Currency  string `json:"currency" binding:"required,currency"`
+}
+
+func (server *Server) createPayee(ctx *gin.Context) {
+	var req createPayeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, valid := server.validAccount(ctx, req.AccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	payee, err := server.store.CreatePayee(ctx, db.CreatePayeeParams{
+		Owner:     authPayload.Username,
+		Nickname:  req.Nickname,
+		AccountID: req.AccountID,
+		Currency:  req.Currency,
+	})
+	if err != nil {
+		errCode := db.ErrCode(err)
+		if errCode == db.ForeignKeyViolation || errCode == db.UniqueViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "payees", strconv.FormatInt(payee.ID, 10), nil, payee)
+
+	ctx.JSON(http.StatusCreated, payee)
+}
+
+func (server *Server) listPayees(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	payees, err := server.store.ListPayees(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payees)
+}
+
+type payeeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deletePayee(ctx *gin.Context) {
+	var req payeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, ok := server.ownPayee(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	_, err := server.store.DeletePayee(ctx, db.DeletePayeeParams{
+		ID:    payee.ID,
+		Owner: payee.Owner,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "payees", strconv.FormatInt(payee.ID, 10), payee, nil)
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// ownPayee loads a payee from the address book of the user,
+// answering not found for payees of other users so that their ids are not disclosed
+func (server *Server) ownPayee(ctx *gin.Context, id int64) (db.Payee, bool) {
+	payee, err := server.store.GetPayee(ctx, id)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return payee, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if err != nil || payee.Owner != authPayload.Username {
+		err := fmt.Errorf("payee %d not found", id)
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return payee, false
+	}
+
+	return payee, true
+}
+
+type confirmPayeeRequest struct {
+	AccountID int64 `form:"account_id" binding:"required,min=1"`
+}
+
+type confirmPayeeResponse struct {
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	OwnerName string `json:"owner_name"`
+}
+
+// confirmPayee lets users check who holds an account before sending money to it,
+// disclosing no more than the initials of the owner
+func (server *Server) confirmPayee(ctx *gin.Context) {
+	var req confirmPayeeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// each lookup tells the initials of someone, so users cannot walk through the account ids
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.allowRequest(ctx, util.PayeeConfirmationRateLimit, authPayload.Username) {
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, req.AccountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	owner, err := server.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmPayeeResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		OwnerName: util.MaskName(owner.FullName),
+	})
+}
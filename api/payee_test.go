@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/ratelimit"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayeeWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.rateLimiter = ratelimit.NewLimiter(util.Config{RateLimitWindow: time.Minute, PayeeConfirmationLimit: 3})
+
+	var users []db.User
+	for _, fullName := range []string{"Alice Payer", "Bob Van Payee", "Carol Other"} {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       fullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	payer, recipient, other := users[0], users[1], users[2]
+
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    payer.Username,
+		Balance:  100,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	target, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    recipient.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// confirmation of payee discloses the initials of the owner only
+	recorder := send(http.MethodGet, fmt.Sprintf("/payees/confirm?account_id=%d", target.ID), payer, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var confirmation confirmPayeeResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &confirmation))
+	require.Equal(t, confirmPayeeResponse{AccountID: target.ID, Currency: util.USD, OwnerName: "B*** V*** P***"}, confirmation)
+
+	recorder = send(http.MethodGet, fmt.Sprintf("/payees/confirm?account_id=%d", target.ID+1000), payer, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// lookups are limited per user, those of missing accounts count as well
+	require.Equal(t, http.StatusOK, send(http.MethodGet, fmt.Sprintf("/payees/confirm?account_id=%d", target.ID), payer, nil).Code)
+	recorder = send(http.MethodGet, fmt.Sprintf("/payees/confirm?account_id=%d", target.ID), payer, nil)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusOK, send(http.MethodGet, fmt.Sprintf("/payees/confirm?account_id=%d", target.ID), other, nil).Code)
+
+	testCases := []struct {
+		name string
+		body gin.H
+		code int
+	}{
+		{"CurrencyMismatch", gin.H{"nickname": "bob", "account_id": target.ID, "currency": util.EUR}, http.StatusBadRequest},
+		{"UnknownAccount", gin.H{"nickname": "bob", "account_id": target.ID + 1000, "currency": util.USD}, http.StatusNotFound},
+		{"NoNickname", gin.H{"account_id": target.ID, "currency": util.USD}, http.StatusBadRequest},
+		{"Created", gin.H{"nickname": "bob", "account_id": target.ID, "currency": util.USD}, http.StatusCreated},
+		{"DuplicateNickname", gin.H{"nickname": "bob", "account_id": target.ID, "currency": util.USD}, http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		recorder := send(http.MethodPost, "/payees", payer, tc.body)
+		require.Equal(t, tc.code, recorder.Code, tc.name)
+	}
+
+	recorder = send(http.MethodGet, "/payees", payer, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var payees []db.Payee
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &payees))
+	require.Len(t, payees, 1)
+	payee := payees[0]
+	require.Equal(t, target.ID, payee.AccountID)
+
+	recorder = send(http.MethodGet, "/payees", other, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, "[]", recorder.Body.String())
+
+	transfer := func(user db.User, body gin.H) int {
+		body["from_account_id"] = account.ID
+		body["amount"] = 10
+		if _, ok := body["currency"]; !ok {
+			body["currency"] = util.USD
+		}
+		return send(http.MethodPost, "/transfers", user, body).Code
+	}
+
+	require.Equal(t, http.StatusOK, transfer(payer, gin.H{"payee_id": payee.ID}))
+	require.Equal(t, http.StatusBadRequest, transfer(payer, gin.H{"payee_id": payee.ID, "to_account_id": target.ID}), "payee and account are exclusive")
+	require.Equal(t, http.StatusBadRequest, transfer(payer, gin.H{}), "a recipient is required")
+	require.Equal(t, http.StatusBadRequest, transfer(payer, gin.H{"payee_id": payee.ID, "currency": util.EUR}))
+	require.Equal(t, http.StatusNotFound, transfer(other, gin.H{"payee_id": payee.ID}), "payees are private to their owner")
+
+	target, err = store.GetAccount(context.Background(), target.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), target.Balance)
+
+	recorder = send(http.MethodDelete, fmt.Sprintf("/payees/%d", payee.ID), other, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodDelete, fmt.Sprintf("/payees/%d", payee.ID), payer, nil)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	require.Equal(t, http.StatusNotFound, transfer(payer, gin.H{"payee_id": payee.ID}))
+}
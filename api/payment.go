@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type lookupDirectoryRequest struct {
+	Alias    string `form:"alias" binding:"required"`
+	Currency string `form:"currency" binding:"required,currency"`
+}
+
+type directoryEntryResponse struct {
+	Username  string `json:"username"`
+	OwnerName string `json:"owner_name"`
+	Currency  string `json:"currency"`
+}
+
+// lookupDirectory tells the sender whom a username or email pays before sending the payment
+func (server *Server) lookupDirectory(ctx *gin.Context) {
+	var req lookupDirectoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, account, ok := server.resolveAlias(ctx, req.Alias, req.Currency)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, directoryEntryResponse{
+		Username:  user.Username,
+		OwnerName: util.MaskName(user.FullName),
+		Currency:  account.Currency,
+	})
+}
+
+type createPaymentRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	// Recipient is the username or the email of a discoverable user
+	Recipient string `json:"recipient" binding:"required"`
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Currency  string `json:"currency" binding:"required,currency"`
+}
+
+// createPayment sends money to the account a user holds in the currency of the payment
+func (server *Server) createPayment(ctx *gin.Context) {
+	var req createPaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, account, ok := server.resolveAlias(ctx, req.Recipient, req.Currency)
+	if !ok {
+		return
+	}
+
+	server.transfer(ctx, transferRequest{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   account.ID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+	})
+}
+
+// resolveAlias finds the discoverable user a username or an email belongs to and their account in the currency.
+// Unknown, hidden and disabled users as well as missing accounts all answer the same not found error,
+// so that the directory does not disclose who has opted out
+func (server *Server) resolveAlias(ctx *gin.Context, alias string, currency string) (db.User, db.Account, bool) {
+	// each lookup tells whether an email belongs to a user, so users cannot walk through lists of emails
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.allowRequest(ctx, util.DirectoryLookupRateLimit, authPayload.Username) {
+		return db.User{}, db.Account{}, false
+	}
+
+	notFound := fmt.Errorf("no %s account found for %q", currency, alias)
+
+	user, err := server.store.GetDiscoverableUser(ctx, alias)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(notFound))
+			return user, db.Account{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, db.Account{}, false
+	}
+
+	account, err := server.store.GetAccountByOwnerCurrency(ctx, db.GetAccountByOwnerCurrencyParams{
+		Owner:    user.Username,
+		Currency: currency,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(notFound))
+			return user, account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, account, false
+	}
+
+	return user, account, true
+}
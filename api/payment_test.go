@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/ratelimit"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	var users []db.User
+	for _, fullName := range []string{"Alice Payer", "Bob Payee"} {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       fullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	payer, payee := users[0], users[1]
+
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    payer.Username,
+		Balance:  100,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	target, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    payee.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	_, err = store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    payee.Username,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	lookup := func(alias string, currency string) *httptest.ResponseRecorder {
+		query := url.Values{"alias": {alias}, "currency": {currency}}
+		return send(http.MethodGet, "/directory?"+query.Encode(), payer, nil)
+	}
+	pay := func(recipient string, currency string) int {
+		return send(http.MethodPost, "/payments", payer, gin.H{
+			"from_account_id": account.ID,
+			"recipient":       recipient,
+			"amount":          10,
+			"currency":        currency,
+		}).Code
+	}
+
+	// users are left out of the directory until they opt in
+	require.Equal(t, http.StatusNotFound, lookup(payee.Username, util.USD).Code)
+	require.Equal(t, http.StatusNotFound, pay(payee.Email, util.USD))
+
+	recorder := send(http.MethodPatch, "/users/"+payee.Username, payee, gin.H{"discoverable": true})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"discoverable":true`)
+
+	for _, alias := range []string{payee.Username, payee.Email} {
+		recorder := lookup(alias, util.USD)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var entry directoryEntryResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &entry))
+		require.Equal(t, directoryEntryResponse{Username: payee.Username, OwnerName: "B*** P***", Currency: util.USD}, entry)
+	}
+
+	recorder = lookup(payee.Username, util.CAD)
+	require.Equal(t, http.StatusNotFound, recorder.Code, "the payee holds no account in the currency")
+
+	require.Equal(t, http.StatusOK, pay(payee.Email, util.USD))
+	require.Equal(t, http.StatusBadRequest, pay(payee.Email, util.EUR), "the from account is in another currency")
+	require.Equal(t, http.StatusNotFound, pay(payee.Username, util.CAD))
+
+	target, err = store.GetAccount(context.Background(), target.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), target.Balance)
+
+	// users who opt out of the directory look the same as unknown users
+	recorder = send(http.MethodPatch, "/users/"+payee.Username, payee, gin.H{"discoverable": false})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"discoverable":false`)
+
+	hidden := lookup(payee.Username, util.USD)
+	require.Equal(t, http.StatusNotFound, hidden.Code)
+	unknown := lookup("unknown"+payee.Username, util.USD)
+	require.Equal(t, http.StatusNotFound, unknown.Code)
+	require.Equal(t,
+		strings.Replace(unknown.Body.String(), "unknown", "", 1),
+		hidden.Body.String(),
+	)
+
+	require.Equal(t, http.StatusNotFound, pay(payee.Email, util.USD))
+
+	// lookups and payments share a limit per user, those of unknown aliases count as well
+	server.rateLimiter = ratelimit.NewLimiter(util.Config{RateLimitWindow: time.Minute, DirectoryLookupLimit: 2})
+	lookupAs := func(user db.User, alias string) *httptest.ResponseRecorder {
+		query := url.Values{"alias": {alias}, "currency": {util.USD}}
+		return send(http.MethodGet, "/directory?"+query.Encode(), user, nil)
+	}
+	require.Equal(t, http.StatusNotFound, lookupAs(payee, util.RandomEmail()).Code)
+	require.Equal(t, http.StatusNotFound, lookupAs(payee, util.RandomEmail()).Code)
+	recorder = lookupAs(payee, payer.Email)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+	recorder = send(http.MethodPost, "/payments", payee, gin.H{
+		"from_account_id": target.ID,
+		"recipient":       payer.Email,
+		"amount":          1,
+		"currency":        util.USD,
+	})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errTooManyRequests = errors.New("too many requests, try again later")
+
+// allowRequest counts the request against the rate limit of the identifier in the scope,
+// and refuses it once the identifier went over the limit
+func (server *Server) allowRequest(ctx *gin.Context, scope string, identifier string) bool {
+	wait, err := server.rateLimiter.Hit(ctx, server.store, scope, identifier)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if wait > 0 {
+		setRetryAfter(ctx, wait)
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyRequests))
+		return false
+	}
+	return true
+}
+
+// setRetryAfter tells the client how many seconds to wait before trying again
+func setRetryAfter(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
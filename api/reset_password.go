@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/foyez/simplebank/worker"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidResetPassword does not tell an unknown token from a used or expired one
+var errInvalidResetPassword = errors.New("reset link is invalid, used or expired")
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword emails a password reset link to the user with the email. It answers the same
+// whether a user has the email or not, so that it cannot be used to find out who has an account.
+// Requests are rate limited per client ip and per email, so that it cannot flood an inbox either.
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.allowRequest(ctx, util.ForgotPasswordIPRateLimit, ctx.ClientIP()) ||
+		!server.allowRequest(ctx, util.ForgotPasswordEmailRateLimit, strings.ToLower(req.Email)) {
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err == nil {
+		arg, err := worker.NewResetPasswordJob(user.Username, user.Email)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		job, err := server.store.CreateJob(ctx, arg)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		setAuditChange(ctx, "jobs", strconv.FormatInt(job.ID, 10), nil, job)
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "if a user has this email, a reset link was sent to it"})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type resetPasswordResponse struct {
+	User            userResponse `json:"user"`
+	RevokedSessions int64        `json:"revoked_sessions"`
+}
+
+// resetPassword consumes the token of a reset link and sets the new password. Every session of
+// the user is blocked, so that whoever knew the former password is signed out.
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, ok := server.hashNewPassword(ctx, req.Password)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(req.Token),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetPassword))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "users", result.User.Username, nil, gin.H{
+		"password_changed_at": result.User.PasswordChangedAt,
+		"revoked_sessions":    result.RevokedSessions,
+	})
+	setETag(ctx, result.User.Version)
+
+	ctx.JSON(http.StatusOK, resetPasswordResponse{
+		User:            newUserResponse(result.User),
+		RevokedSessions: result.RevokedSessions,
+	})
+}
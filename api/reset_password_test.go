@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/ratelimit"
+	"github.com/foyez/simplebank/util"
+	"github.com/foyez/simplebank/worker"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResetPasswordWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.config.RefreshTokenDuration = server.config.AccessTokenDuration
+
+	mailer := &recordingMailer{}
+	jobWorker, err := worker.NewWorker(store, mailer, util.Config{
+		VerifyEmailURL:   "http://localhost:3000/verify_email",
+		ResetPasswordURL: "http://localhost:3000/reset_password",
+	})
+	require.NoError(t, err)
+	runJobs := func() {
+		for {
+			ran, err := jobWorker.RunNext(context.Background())
+			require.NoError(t, err)
+			if !ran {
+				return
+			}
+		}
+	}
+
+	send := func(url string, body gin.H) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	login := func(username string, password string) *httptest.ResponseRecorder {
+		return send("/users/login", gin.H{"username": username, "password": password})
+	}
+
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	recorder := login(user.Username, password)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var session loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &session))
+	require.Equal(t, http.StatusOK, send("/tokens/renew_access", gin.H{"refresh_token": session.RefreshToken}).Code)
+
+	// an unknown email gets the same answer, and no email is sent
+	unknown := send("/users/password/forgot", gin.H{"email": util.RandomEmail()})
+	known := send("/users/password/forgot", gin.H{"email": user.Email})
+	require.Equal(t, http.StatusAccepted, unknown.Code)
+	require.Equal(t, unknown.Code, known.Code)
+	require.Equal(t, unknown.Body.String(), known.Body.String())
+	require.Equal(t, http.StatusBadRequest, send("/users/password/forgot", gin.H{"email": "invalid-email"}).Code)
+
+	runJobs()
+	require.Len(t, mailer.contents, 1)
+	link, err := url.Parse(regexp.MustCompile(`http://\S+`).FindString(mailer.contents[0]))
+	require.NoError(t, err)
+	token := link.Query().Get("token")
+
+	newPassword := util.RandomString(8)
+	require.Equal(t, http.StatusBadRequest, send("/users/password/reset", gin.H{"token": token, "password": "123"}).Code)
+	require.Equal(t, http.StatusBadRequest, send("/users/password/reset", gin.H{"token": util.RandomString(43), "password": newPassword}).Code)
+
+	recorder = send("/users/password/reset", gin.H{"token": token, "password": newPassword})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var rsp resetPasswordResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, user.Username, rsp.User.Username)
+	require.True(t, rsp.User.PasswordChangedAt.After(user.PasswordChangedAt))
+	require.Equal(t, int64(1), rsp.RevokedSessions)
+
+	recorder = send("/users/password/reset", gin.H{"token": token, "password": util.RandomString(8)})
+	require.Equal(t, http.StatusBadRequest, recorder.Code, "tokens are single-use")
+	require.Contains(t, recorder.Body.String(), errInvalidResetPassword.Error())
+
+	// the sessions opened with the former password can no longer be renewed
+	recorder = send("/tokens/renew_access", gin.H{"refresh_token": session.RefreshToken})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	require.Equal(t, http.StatusUnauthorized, login(user.Username, password).Code)
+	require.Equal(t, http.StatusOK, login(user.Username, newPassword).Code)
+
+	// reset links are rate limited per email, whatever its case, and per client ip
+	server.rateLimiter = ratelimit.NewLimiter(util.Config{
+		RateLimitWindow:          time.Minute,
+		ForgotPasswordEmailLimit: 3,
+		ForgotPasswordIPLimit:    5,
+	})
+	forgot := func(email string) *httptest.ResponseRecorder {
+		return send("/users/password/forgot", gin.H{"email": email})
+	}
+	require.Equal(t, http.StatusAccepted, forgot(strings.ToUpper(user.Email)).Code)
+	require.Equal(t, http.StatusAccepted, forgot(user.Email).Code)
+	recorder = forgot(user.Email)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code, "the email went over its limit")
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusTooManyRequests, forgot(util.RandomEmail()).Code, "the client ip went over its limit")
+}
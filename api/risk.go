@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/risk"
+	"github.com/foyez/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// errRiskBlocked tells nothing about the rules that fired, which would teach a fraudster
+// how to get around them. Bankers find the rules in the risk decisions.
+var errRiskBlocked = errors.New("transfer was blocked by risk checks")
+
+// assessTransfer runs the risk rules on the transfer the authenticated user asks for
+func (server *Server) assessTransfer(ctx *gin.Context, req transferRequest) (risk.Assessment, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	assessment, err := server.riskEngine.Assess(ctx, server.store, risk.Transfer{
+		Username:      authPayload.Username,
+		ClientIP:      ctx.ClientIP(),
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return assessment, false
+	}
+	return assessment, true
+}
+
+// riskDecisionParams describes the outcome of the risk rules on the transfer with the rules that fired,
+// and the approval the transfer is parked in if any
+func (server *Server) riskDecisionParams(ctx *gin.Context, req transferRequest, assessment risk.Assessment, approvalID pgtype.Int8) (db.CreateRiskDecisionParams, bool) {
+	firedRules, err := json.Marshal(assessment.Hits)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.CreateRiskDecisionParams{}, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	return db.CreateRiskDecisionParams{
+		Username:      authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ClientIp:      ctx.ClientIP(),
+		Outcome:       assessment.Outcome,
+		FiredRules:    firedRules,
+		ApprovalID:    approvalID,
+	}, true
+}
+
+// recordRiskDecision stores the outcome of the risk rules on a transfer that does not happen right away.
+// Transfers the rules allow record their decision in the transfer transaction instead.
+func (server *Server) recordRiskDecision(ctx *gin.Context, req transferRequest, assessment risk.Assessment, approvalID pgtype.Int8) (db.RiskDecision, bool) {
+	arg, ok := server.riskDecisionParams(ctx, req, assessment, approvalID)
+	if !ok {
+		return db.RiskDecision{}, false
+	}
+
+	decision, err := server.store.CreateRiskDecision(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return decision, false
+	}
+	return decision, true
+}
+
+// blockTransfer records that the risk rules blocked the transfer and refuses it
+func (server *Server) blockTransfer(ctx *gin.Context, req transferRequest, assessment risk.Assessment) {
+	decision, ok := server.recordRiskDecision(ctx, req, assessment, pgtype.Int8{})
+	if !ok {
+		return
+	}
+
+	setAuditChange(ctx, "risk_decisions", strconv.FormatInt(decision.ID, 10), nil, decision)
+
+	ctx.JSON(http.StatusForbidden, errorResponse(errRiskBlocked))
+}
+
+type listRiskDecisionsRequest struct {
+	pageRequest
+	Username   string `form:"username" binding:"omitempty,alphanum"`
+	Outcome    string `form:"outcome" binding:"omitempty,risk_outcome"`
+	ApprovalID int64  `form:"approval_id" binding:"omitempty,min=1"`
+}
+
+func riskDecisionPosition(decision db.RiskDecision) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: decision.CreatedAt,
+		ID:      strconv.FormatInt(decision.ID, 10),
+	}
+}
+
+// listRiskDecisions lists the outcomes of the risk rules on transfers, newest first
+func (server *Server) listRiskDecisions(ctx *gin.Context) {
+	var req listRiskDecisionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	username := pgtype.Text{String: req.Username, Valid: req.Username != ""}
+	outcome := pgtype.Text{String: req.Outcome, Valid: req.Outcome != ""}
+	approvalID := pgtype.Int8{Int64: req.ApprovalID, Valid: req.ApprovalID != 0}
+
+	scope := "risk_decisions:" + req.Username + ":" + req.Outcome + ":" + strconv.FormatInt(req.ApprovalID, 10)
+
+	page, ok := listPage(ctx, server, scope, req.pageRequest, riskDecisionPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.RiskDecision, error) {
+			arg := db.ListRiskDecisionsAfterParams{
+				Username:   username,
+				Outcome:    outcome,
+				ApprovalID: approvalID,
+				Limit:      limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListRiskDecisionsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.RiskDecision, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListRiskDecisionsBefore(ctx, db.ListRiskDecisionsBeforeParams{
+				Username:        username,
+				Outcome:         outcome,
+				ApprovalID:      approvalID,
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "risk_decisions", page.Items, page))
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/risk"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	var err error
+	server.riskEngine, err = risk.NewEngine("new_payee_amount:review amount=500; velocity:block count=3 window=1h")
+	require.NoError(t, err)
+
+	var users []db.User
+	for i := 0; i < 4; i++ {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	sender, recipient, payee, banker := users[0], users[1], users[2], users[3]
+	banker = setRole(t, store, banker, util.BankerRole)
+
+	var accounts []db.Account
+	for i, user := range []db.User{sender, recipient, payee} {
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  int64(1000 * (1 - i)),
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+	fromAccount, toAccount, payeeAccount := accounts[0], accounts[1], accounts[2]
+
+	send := func(method string, url string, user db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	transfer := func(to db.Account, amount int64) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/transfers", sender, gin.H{
+			"from_account_id": fromAccount.ID,
+			"to_account_id":   to.ID,
+			"amount":          amount,
+			"currency":        util.USD,
+		})
+	}
+	decisions := func(query string) []db.RiskDecision {
+		recorder := send(http.MethodGet, "/risk_decisions?"+query, banker, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp struct {
+			RiskDecisions []db.RiskDecision `json:"risk_decisions"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		return rsp.RiskDecisions
+	}
+	firedRules := func(decision db.RiskDecision) []risk.Hit {
+		var hits []risk.Hit
+		require.NoError(t, json.Unmarshal(decision.FiredRules, &hits))
+		return hits
+	}
+
+	require.Equal(t, http.StatusOK, transfer(toAccount, 100).Code)
+
+	// a large first transfer to an account is parked for a banker
+	recorder := transfer(payeeAccount, 600)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	var approval db.Approval
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &approval))
+	require.Equal(t, util.PendingApproval, approval.Status)
+	require.Equal(t, int64(600), approval.Amount.Int64)
+
+	held := decisions(fmt.Sprintf("approval_id=%d", approval.ID))
+	require.Len(t, held, 1)
+	require.Equal(t, util.ReviewOutcome, held[0].Outcome)
+	require.Equal(t, sender.Username, held[0].Username)
+	hits := firedRules(held[0])
+	require.Len(t, hits, 1)
+	require.Equal(t, "new_payee_amount", hits[0].Rule)
+
+	// a large transfer to an account paid before goes through
+	require.Equal(t, http.StatusOK, transfer(toAccount, 600).Code)
+
+	// the velocity rule blocks the next transfers without telling why
+	require.Equal(t, http.StatusOK, transfer(toAccount, 50).Code)
+	recorder = transfer(toAccount, 50)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), "velocity")
+
+	moneyRequest, err := store.CreateMoneyRequest(context.Background(), db.CreateMoneyRequestParams{
+		Requester:   recipient.Username,
+		Payer:       sender.Username,
+		ToAccountID: toAccount.ID,
+		Amount:      50,
+		Currency:    util.USD,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	recorder = send(http.MethodPost, fmt.Sprintf("/money_requests/%d/accept", moneyRequest.ID), sender, gin.H{
+		"from_account_id": fromAccount.ID,
+	})
+	require.Equal(t, http.StatusForbidden, recorder.Code, "money requests do not go around the rules")
+
+	fromAccount, err = store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(250), fromAccount.Balance)
+
+	// every decision is kept, only bankers read them
+	require.Len(t, decisions(""), 6)
+	require.Len(t, decisions("outcome="+util.AllowOutcome), 3)
+	blocked := decisions("outcome=" + util.BlockOutcome)
+	require.Len(t, blocked, 2)
+	for _, decision := range blocked {
+		require.Equal(t, "velocity", firedRules(decision)[0].Rule)
+	}
+
+	require.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/risk_decisions?outcome=maybe", banker, nil).Code)
+	require.Equal(t, http.StatusForbidden, send(http.MethodGet, "/risk_decisions", sender, nil).Code)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// errScreeningHold tells nothing about the list entry a user matched, which would tip them off
+var errScreeningHold = errors.New("transfer is on hold pending a compliance review")
+
+// clearedForTransfer refuses the transfer while one of the users involved has a screening hit
+// that a banker has not cleared
+func (server *Server) clearedForTransfer(ctx *gin.Context, usernames ...string) bool {
+	count, err := server.store.CountBlockingScreeningHits(ctx, usernames)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if count > 0 {
+		ctx.JSON(http.StatusForbidden, errorResponse(errScreeningHold))
+		return false
+	}
+	return true
+}
+
+type listScreeningHitsRequest struct {
+	pageRequest
+	Username string `form:"username" binding:"omitempty,alphanum"`
+	Status   string `form:"status" binding:"omitempty,screening_hit_status"`
+}
+
+func screeningHitPosition(hit db.ScreeningHit) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: hit.CreatedAt,
+		ID:      strconv.FormatInt(hit.ID, 10),
+	}
+}
+
+// listScreeningHits lists the names that matched the screening list, oldest first
+func (server *Server) listScreeningHits(ctx *gin.Context) {
+	var req listScreeningHitsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	username := pgtype.Text{String: req.Username, Valid: req.Username != ""}
+	status := pgtype.Text{String: req.Status, Valid: req.Status != ""}
+
+	scope := "screening_hits:" + req.Username + ":" + req.Status
+
+	page, ok := listPage(ctx, server, scope, req.pageRequest, screeningHitPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.ScreeningHit, error) {
+			arg := db.ListScreeningHitsAfterParams{
+				Username: username,
+				Status:   status,
+				Limit:    limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListScreeningHitsAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.ScreeningHit, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListScreeningHitsBefore(ctx, db.ListScreeningHitsBeforeParams{
+				Username:        username,
+				Status:          status,
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "screening_hits", page.Items, page))
+}
+
+type screeningHitRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// clearScreeningHit records that the hit is a false positive and releases the transfers of its user
+func (server *Server) clearScreeningHit(ctx *gin.Context) {
+	server.resolveScreeningHit(ctx, util.ClearedHit)
+}
+
+// confirmScreeningHit records that the user is the listed person and keeps their transfers held
+func (server *Server) confirmScreeningHit(ctx *gin.Context) {
+	server.resolveScreeningHit(ctx, util.ConfirmedHit)
+}
+
+func (server *Server) resolveScreeningHit(ctx *gin.Context, status string) {
+	oldHit := authorizedScreeningHit(ctx)
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if oldHit.Username == authPayload.Username {
+		err := fmt.Errorf("screening hit %d must be reviewed by someone other than its user", oldHit.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	if oldHit.Status != util.OpenHit {
+		err := fmt.Errorf("screening hit %d is %s", oldHit.ID, oldHit.Status)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	hit, err := server.store.ResolveScreeningHit(ctx, db.ResolveScreeningHitParams{
+		ID:         oldHit.ID,
+		Status:     status,
+		ReviewedBy: pgtype.Text{String: authPayload.Username, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := fmt.Errorf("screening hit %d is no longer open", oldHit.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "screening_hits", strconv.FormatInt(hit.ID, 10), oldHit, hit)
+
+	ctx.JSON(http.StatusOK, hit)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/screening"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScreeningWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.screener = screening.NewScreener([]screening.Entry{
+		{ID: "SL-1", Name: "Vladimir Petrov"},
+		{ID: "SL-2", Name: "Marta Oyelaran", Aliases: []string{"Marta Oye"}},
+	}, screening.DefaultThreshold)
+
+	send := func(method string, url string, user *db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		if user != nil {
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+		}
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	signup := func(fullName string) db.User {
+		recorder := send(http.MethodPost, "/users", nil, gin.H{
+			"username":  util.RandomOwner(),
+			"password":  util.RandomString(8),
+			"full_name": fullName,
+			"email":     util.RandomEmail(),
+		})
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		require.NotContains(t, recorder.Body.String(), "SL-", "the user is not told about hits")
+
+		var rsp userResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		user, err := store.GetUser(context.Background(), rsp.Username)
+		require.NoError(t, err)
+		return user
+	}
+	flagged := signup("Vladímir Petrov")
+	sender := signup("Ada Lovelace")
+	banker := signup("Grace Hopper")
+	banker = setRole(t, store, banker, util.BankerRole)
+
+	hits := func(query string) []db.ScreeningHit {
+		recorder := send(http.MethodGet, "/screening_hits?"+query, &banker, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp struct {
+			ScreeningHits []db.ScreeningHit `json:"screening_hits"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		return rsp.ScreeningHits
+	}
+
+	var accounts []db.Account
+	for _, user := range []db.User{flagged, sender} {
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  100,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+	flaggedAccount, senderAccount := accounts[0], accounts[1]
+
+	transfer := func(from db.User, fromAccount db.Account, toAccount db.Account) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/transfers", &from, gin.H{
+			"from_account_id": fromAccount.ID,
+			"to_account_id":   toAccount.ID,
+			"amount":          10,
+			"currency":        util.USD,
+		})
+	}
+
+	open := hits("status=" + util.OpenHit)
+	require.Len(t, open, 1)
+	hit := open[0]
+	require.Equal(t, flagged.Username, hit.Username)
+	require.Equal(t, flagged.FullName, hit.ScreenedName)
+	require.Equal(t, "SL-1", hit.ListEntryID)
+	require.GreaterOrEqual(t, hit.Score, int32(screening.DefaultThreshold))
+
+	// transfers of a flagged user are held both ways, without telling what matched
+	recorder := transfer(flagged, flaggedAccount, senderAccount)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), "Petrov")
+	require.Equal(t, http.StatusForbidden, transfer(sender, senderAccount, flaggedAccount).Code)
+
+	// only a banker other than the flagged user clears the hit
+	clearURL := fmt.Sprintf("/screening_hits/%d/clear", hit.ID)
+	require.Equal(t, http.StatusForbidden, send(http.MethodPost, clearURL, &flagged, nil).Code)
+	require.Equal(t, http.StatusForbidden, send(http.MethodGet, "/screening_hits", &sender, nil).Code)
+
+	recorder = send(http.MethodPost, clearURL, &banker, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var cleared db.ScreeningHit
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &cleared))
+	require.Equal(t, util.ClearedHit, cleared.Status)
+	require.Equal(t, banker.Username, cleared.ReviewedBy.String)
+	require.True(t, cleared.ReviewedAt.Valid)
+
+	recorder = send(http.MethodPost, fmt.Sprintf("/screening_hits/%d/confirm", hit.ID), &banker, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	require.Equal(t, http.StatusOK, transfer(flagged, flaggedAccount, senderAccount).Code)
+	require.Equal(t, http.StatusOK, transfer(sender, senderAccount, flaggedAccount).Code)
+
+	// a new name is screened again
+	recorder = send(http.MethodPatch, "/users/"+sender.Username, &sender, gin.H{"full_name": "Marta Oye"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	open = hits("status=" + util.OpenHit + "&username=" + sender.Username)
+	require.Len(t, open, 1)
+	require.Equal(t, "SL-2", open[0].ListEntryID)
+
+	recorder = send(http.MethodPost, fmt.Sprintf("/screening_hits/%d/confirm", open[0].ID), &banker, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, http.StatusForbidden, transfer(sender, senderAccount, flaggedAccount).Code, "confirmed hits keep transfers held")
+
+	require.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/screening_hits?status=maybe", &banker, nil).Code)
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{
+		ResourceType: pgtype.Text{String: "screening_hits", Valid: true},
+		Limit:        10,
+	})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 4, "refused reviews are audited too")
+	var reviews int
+	for _, auditLog := range auditLogs {
+		if auditLog.StatusCode == http.StatusOK {
+			require.Equal(t, banker.Username, auditLog.Actor)
+			require.Contains(t, string(auditLog.Changes), `"status":{"before":"open"`)
+			reviews++
+		}
+	}
+	require.Equal(t, 2, reviews)
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	csvFormat = "csv"
+	// csvExportBatchSize is the number of rows read per query while exporting every search result
+	csvExportBatchSize = 500
+)
+
+type searchRequest struct {
+	pageRequest
+	SortBy string `form:"sort_by"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Format string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// searchScope identifies a search by its filters and order, so that its cursors only continue the same search
+func searchScope(ctx *gin.Context, name string) string {
+	query := ctx.Request.URL.Query()
+	query.Del("cursor")
+	query.Del("page_size")
+	query.Del("format")
+	return name + "?" + query.Encode()
+}
+
+// respondSearch renders one keyset page of search results as JSON, or every result as a CSV attachment.
+// search is called with the position the rows follow, nil for the first rows, and reverse set
+// to read the rows preceding the position instead, nearest first.
+func respondSearch[T any, R any](
+	ctx *gin.Context,
+	server *Server,
+	req searchRequest,
+	scope string,
+	key string,
+	filename string,
+	header []string,
+	record func(T) []string,
+	response func(T) R,
+	position func(T) pagination.Cursor,
+	search func(cursor *pagination.Cursor, reverse bool, limit int32) ([]T, error),
+) {
+	if req.Format != csvFormat {
+		page, ok := listPage(ctx, server, scope, req.pageRequest, position,
+			func(cursor *pagination.Cursor, limit int32) ([]T, error) {
+				return search(cursor, false, limit)
+			},
+			func(cursor pagination.Cursor, limit int32) ([]T, error) {
+				return search(&cursor, true, limit)
+			},
+		)
+		if !ok {
+			return
+		}
+
+		results := make([]R, len(page.Items))
+		for i, row := range page.Items {
+			results[i] = response(row)
+		}
+		ctx.JSON(http.StatusOK, pageResponse(server, scope, key, results, page))
+		return
+	}
+
+	// read the first batch before writing so that a failing search still gets an error status
+	rows, err := search(nil, false, csvExportBatchSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Status(http.StatusOK)
+
+	// each batch continues after the last row of the previous one,
+	// so that rows inserted meanwhile neither shift nor repeat the export
+	writer := csv.NewWriter(ctx.Writer)
+	writer.Write(header)
+	for {
+		for _, row := range rows {
+			writer.Write(record(row))
+		}
+		if len(rows) < csvExportBatchSize {
+			break
+		}
+
+		cursor := position(rows[len(rows)-1])
+		rows, err = search(&cursor, false, csvExportBatchSize)
+		if err != nil {
+			// the status is already sent, so all that is left is to cut the export short
+			log.Println("cannot export search results: ", err)
+			break
+		}
+	}
+	writer.Flush()
+}
+
+// csvField stops spreadsheets from evaluating user supplied values such as full names as formulas
+func csvField(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type searchAccountsRequest struct {
+	searchRequest
+	Owner      string    `form:"owner"`
+	Currency   string    `form:"currency" binding:"omitempty,currency"`
+	Status     string    `form:"status" binding:"omitempty,account_status"`
+	MinBalance *int64    `form:"min_balance"`
+	MaxBalance *int64    `form:"max_balance"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func (server *Server) searchAccounts(ctx *gin.Context) {
+	var req searchAccountsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	switch req.SortBy {
+	case "", "id", "owner", "balance", "created_at":
+	default:
+		err := fmt.Errorf("cannot sort accounts by %q", req.SortBy)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.SearchAccountsParams{
+		Owner:      optionalText(req.Owner),
+		Currency:   optionalText(req.Currency),
+		Status:     optionalText(req.Status),
+		MinBalance: optionalInt8(req.MinBalance),
+		MaxBalance: optionalInt8(req.MaxBalance),
+		CreatedFrom: pgtype.Timestamptz{
+			Time:  req.From,
+			Valid: !req.From.IsZero(),
+		},
+		CreatedTo: pgtype.Timestamptz{
+			Time:  req.To,
+			Valid: !req.To.IsZero(),
+		},
+		SortBy: req.SortBy,
+	}
+
+	position := func(account db.Account) pagination.Cursor {
+		cursor := accountPosition(account)
+		switch req.SortBy {
+		case "owner":
+			cursor.Value = account.Owner
+		case "balance":
+			cursor.Value = strconv.FormatInt(account.Balance, 10)
+		}
+		return cursor
+	}
+
+	respondSearch(ctx, server, req.searchRequest, searchScope(ctx, "search_accounts"), "accounts", "accounts.csv",
+		[]string{"id", "owner", "balance", "currency", "status", "created_at", "version"},
+		func(account db.Account) []string {
+			return []string{
+				strconv.FormatInt(account.ID, 10),
+				account.Owner,
+				strconv.FormatInt(account.Balance, 10),
+				account.Currency,
+				account.Status,
+				account.CreatedAt.Format(time.RFC3339),
+				strconv.FormatInt(account.Version, 10),
+			}
+		},
+		func(account db.Account) db.Account {
+			return account
+		},
+		position,
+		func(cursor *pagination.Cursor, reverse bool, limit int32) ([]db.Account, error) {
+			arg := arg
+			arg.SortDesc = (req.Order == "desc") != reverse
+			arg.Limit = limit
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+				switch req.SortBy {
+				case "owner":
+					arg.CursorOwner = pgtype.Text{String: cursor.Value, Valid: true}
+				case "balance":
+					balance, err := strconv.ParseInt(cursor.Value, 10, 64)
+					if err != nil {
+						return nil, pagination.ErrInvalidCursor
+					}
+					arg.CursorBalance = pgtype.Int8{Int64: balance, Valid: true}
+				case "created_at":
+					arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				}
+			}
+			return server.store.SearchAccounts(ctx, arg)
+		},
+	)
+}
+
+type searchUsersRequest struct {
+	searchRequest
+	Username string `form:"username"`
+	Email    string `form:"email"`
+	FullName string `form:"full_name"`
+}
+
+func (server *Server) searchUsers(ctx *gin.Context) {
+	var req searchUsersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	switch req.SortBy {
+	case "", "username", "full_name", "email", "created_at":
+	default:
+		err := fmt.Errorf("cannot sort users by %q", req.SortBy)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.SearchUsersParams{
+		UsernamePrefix: optionalText(req.Username),
+		EmailPrefix:    optionalText(req.Email),
+		FullNamePrefix: optionalText(req.FullName),
+		SortBy:         req.SortBy,
+	}
+
+	position := func(user db.User) pagination.Cursor {
+		cursor := userPosition(user)
+		switch req.SortBy {
+		case "full_name":
+			cursor.Value = user.FullName
+		case "email":
+			cursor.Value = user.Email
+		}
+		return cursor
+	}
+
+	respondSearch(ctx, server, req.searchRequest, searchScope(ctx, "search_users"), "users", "users.csv",
+		[]string{"username", "full_name", "email", "password_changed_at", "created_at"},
+		func(user db.User) []string {
+			return []string{
+				user.Username,
+				csvField(user.FullName),
+				csvField(user.Email),
+				user.PasswordChangedAt.Format(time.RFC3339),
+				user.CreatedAt.Format(time.RFC3339),
+			}
+		},
+		newUserResponse,
+		position,
+		func(cursor *pagination.Cursor, reverse bool, limit int32) ([]db.User, error) {
+			arg := arg
+			arg.SortDesc = (req.Order == "desc") != reverse
+			arg.Limit = limit
+			if cursor != nil {
+				arg.CursorUsername = pgtype.Text{String: cursor.ID, Valid: true}
+				switch req.SortBy {
+				case "full_name":
+					arg.CursorFullName = pgtype.Text{String: cursor.Value, Valid: true}
+				case "email":
+					arg.CursorEmail = pgtype.Text{String: cursor.Value, Valid: true}
+				case "created_at":
+					arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				}
+			}
+			return server.store.SearchUsers(ctx, arg)
+		},
+	)
+}
+
+// optionalInt8 turns a missing query filter into a NULL argument
+func optionalInt8(value *int64) pgtype.Int8 {
+	if value == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{
+		Int64: *value,
+		Valid: true,
+	}
+}
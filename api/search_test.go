@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	mockdb "github.com/foyez/simplebank/db/mock"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchAccountsAPI(t *testing.T) {
+	owner := util.RandomOwner()
+
+	n := 5
+	accounts := make([]db.Account, n)
+	for i := 0; i < n; i++ {
+		accounts[i] = randomAccount(owner)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"owner":       {owner},
+				"currency":    {util.USD},
+				"status":      {util.ActiveAccount},
+				"min_balance": {"0"},
+				"max_balance": {"1000"},
+				"sort_by":     {"balance"},
+				"order":       {"desc"},
+				"page_size":   {"5"},
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SearchAccountsParams{
+					Owner:      pgtype.Text{String: owner, Valid: true},
+					Currency:   pgtype.Text{String: util.USD, Valid: true},
+					Status:     pgtype.Text{String: util.ActiveAccount, Valid: true},
+					MinBalance: pgtype.Int8{Int64: 0, Valid: true},
+					MaxBalance: pgtype.Int8{Int64: 1000, Valid: true},
+					SortBy:     "balance",
+					SortDesc:   true,
+					Limit:      6,
+				}
+
+				store.EXPECT().
+					SearchAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next, prev := requireBodyMatchPage(t, recorder.Body, "accounts", accounts)
+				require.Nil(t, next)
+				require.Nil(t, prev)
+			},
+		},
+		{
+			name: "CSV",
+			query: url.Values{
+				"owner":  {owner},
+				"format": {"csv"},
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SearchAccountsParams{
+					Owner: pgtype.Text{String: owner, Valid: true},
+					Limit: csvExportBatchSize,
+				}
+
+				store.EXPECT().
+					SearchAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+
+				records, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, records, n+1)
+				require.Equal(t, []string{"id", "owner", "balance", "currency", "status", "created_at", "version"}, records[0])
+				require.Equal(t, owner, records[1][1])
+			},
+		},
+		{
+			name:  "Depositor",
+			query: url.Values{},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidStatus",
+			query: url.Values{
+				"status": {"dormant"},
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidSortBy",
+			query: url.Values{
+				"sort_by": {"hashed_password"},
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			query: url.Values{
+				"format": {"csv"},
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/search/accounts?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSearchUsersCSVAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.FullName = "=HYPERLINK(\"http://example.com\")"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	// every batch is full until the last one
+	batch := make([]db.User, csvExportBatchSize)
+	for i := range batch {
+		batch[i] = user
+	}
+	arg := db.SearchUsersParams{
+		FullNamePrefix: pgtype.Text{String: "=", Valid: true},
+		Limit:          csvExportBatchSize,
+	}
+	store.EXPECT().
+		SearchUsers(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(batch, nil)
+	arg.CursorUsername = pgtype.Text{String: user.Username, Valid: true}
+	store.EXPECT().
+		SearchUsers(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return([]db.User{user}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/search/users?format=csv&full_name=%3D", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, csvExportBatchSize+2)
+	require.Equal(t, user.Username, records[1][0])
+	require.Equal(t, "'"+user.FullName, records[1][1], "formulas must not be exported as is")
+}
+
+func TestSearchAccountsWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	createUser := func() db.User {
+		user, _ := randomUser(t)
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		return user
+	}
+	user, banker := createUser(), createUser()
+	banker = setRole(t, store, banker, util.BankerRole)
+
+	var accounts []db.Account
+	for i, currency := range []string{util.USD, util.EUR, util.CAD} {
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  int64(i+1) * 10,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	search := func(query url.Values) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/search/accounts?"+query.Encode(), nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Username, util.BankerRole, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	requirePage := func(recorder *httptest.ResponseRecorder, accounts ...db.Account) (next *string, prev *string) {
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var page struct {
+			Accounts   []db.Account `json:"accounts"`
+			NextCursor *string      `json:"next_cursor"`
+			PrevCursor *string      `json:"prev_cursor"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+		require.Len(t, page.Accounts, len(accounts))
+		for i := range accounts {
+			require.Equal(t, accounts[i].ID, page.Accounts[i].ID)
+		}
+		return page.NextCursor, page.PrevCursor
+	}
+	query := url.Values{"sort_by": {"balance"}, "order": {"desc"}, "page_size": {"2"}}
+
+	next, prev := requirePage(search(query), accounts[2], accounts[1])
+	require.NotNil(t, next)
+	require.Nil(t, prev)
+
+	// an account created meanwhile at the top neither shifts nor repeats the next page
+	_, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    createUser().Username,
+		Balance:  1000,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	query.Set("cursor", *next)
+	next, prev = requirePage(search(query), accounts[0])
+	require.Nil(t, next)
+	require.NotNil(t, prev)
+
+	query.Set("cursor", *prev)
+	requirePage(search(query), accounts[2], accounts[1])
+
+	// cursors only continue the search they were issued for
+	query.Set("order", "asc")
+	require.Equal(t, http.StatusBadRequest, search(query).Code)
+}
@@ -1,10 +1,20 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"expvar"
 	"fmt"
 
+	"github.com/foyez/simplebank/authz"
 	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/lockout"
+	"github.com/foyez/simplebank/pagination"
+	"github.com/foyez/simplebank/ratelimit"
+	"github.com/foyez/simplebank/risk"
+	"github.com/foyez/simplebank/screening"
 	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/totp"
 	"github.com/foyez/simplebank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -13,10 +23,21 @@ import (
 
 // Server serves HTTP requests.
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config              util.Config
+	store               db.Store
+	tokenMaker          token.Maker
+	router              *gin.Engine
+	accountEvents       *accountEventBroker
+	pageCodec           *pagination.Codec
+	secretCipher        *totp.SecretCipher
+	policy              *authz.Policy
+	riskEngine          *risk.Engine
+	screener            *screening.Screener
+	limiter             *lockout.Limiter
+	rateLimiter         *ratelimit.Limiter
+	hasher              *util.PasswordHasher
+	passwordPolicy      *util.PasswordPolicy
+	dummyHashedPassword string
 }
 
 // NewServer creates a new HTTP server and setup routing.
@@ -26,46 +47,201 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	// a key shared with the tokens would let a leak of one forge the other
+	if config.CursorSymmetricKey == config.TokenSymmetricKey {
+		return nil, errors.New("cursor symmetric key must differ from the token symmetric key")
+	}
+	pageCodec, err := pagination.NewCodec(config.CursorSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create page codec: %w", err)
+	}
+
+	if config.TwoFactorSecretKey == config.TokenSymmetricKey || config.TwoFactorSecretKey == config.CursorSymmetricKey {
+		return nil, errors.New("two-factor secret key must differ from the token and cursor symmetric keys")
+	}
+	secretCipher, err := totp.NewSecretCipher(config.TwoFactorSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create two-factor secret cipher: %w", err)
+	}
+
+	rolePermissions := authz.DefaultRolePermissions
+	if config.RolePermissions != "" {
+		rolePermissions, err = authz.ParseRolePermissions(config.RolePermissions)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse role permissions: %w", err)
+		}
+	}
+
+	policy, err := authz.NewPolicy(rolePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create authorization policy: %w", err)
+	}
+
+	riskRules := risk.DefaultRules
+	if config.RiskRules != "" {
+		riskRules = config.RiskRules
+	}
+
+	riskEngine, err := risk.NewEngine(riskRules)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create risk engine: %w", err)
+	}
+
+	screener, err := screening.LoadScreener(config.SanctionsList, config.ScreeningThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create screener: %w", err)
+	}
+
+	hasher, err := util.NewPasswordHasher(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password hasher: %w", err)
+	}
+
+	// checked against the passwords of unknown usernames, so that they take as long to refuse as wrong passwords
+	dummyHashedPassword, err := hasher.Hash(util.RandomString(32))
+	if err != nil {
+		return nil, fmt.Errorf("cannot hash dummy password: %w", err)
+	}
+
+	passwordPolicy, err := util.LoadPasswordPolicy(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password policy: %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:              config,
+		store:               store,
+		tokenMaker:          tokenMaker,
+		accountEvents:       newAccountEventBroker(),
+		pageCodec:           pageCodec,
+		secretCipher:        secretCipher,
+		policy:              policy,
+		riskEngine:          riskEngine,
+		screener:            screener,
+		limiter:             lockout.NewLimiter(config),
+		rateLimiter:         ratelimit.NewLimiter(config),
+		hasher:              hasher,
+		passwordPolicy:      passwordPolicy,
+		dummyHashedPassword: dummyHashedPassword,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("account_status", validAccountStatus)
+		v.RegisterValidation("role", validRole)
+		v.RegisterValidation("member_permission", validMemberPermission)
+		v.RegisterValidation("grant_scope", validGrantScope)
+		v.RegisterValidation("money_request_status", validMoneyRequestStatus)
+		v.RegisterValidation("approval_status", validApprovalStatus)
+		v.RegisterValidation("risk_outcome", validRiskOutcome)
+		v.RegisterValidation("screening_hit_status", validScreeningHitStatus)
+		v.RegisterValidation("lockout_scope", validLockoutScope)
 	}
 
-	server.setupRouter()
+	if err := server.setupRouter(); err != nil {
+		return nil, fmt.Errorf("cannot setup router: %w", err)
+	}
 
 	return server, nil
 }
 
 // setupRouter setups the routers
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
 	router := gin.Default()
+	// X-Forwarded-For is only believed from the configured proxies, or any client could pick
+	// the ip that lockouts and rate limits count it against
+	if err := router.SetTrustedProxies(server.config.TrustedProxies); err != nil {
+		return err
+	}
+	router.Use(requestIDMiddleware(), auditMiddleware(server.store))
 
-	authRouter := router.Group("/").Use(authMiddleware(server.tokenMaker, []string{util.BankerRole, util.DepositorRole}))
+	authRouter := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store))
+	allow := server.requirePermission
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/users/login/two_factor", server.loginTwoFactor)
 	router.POST("/tokens/renew_access", server.renewAccessToken)
-	authRouter.PATCH("/users/:username", server.updateUser)
-
-	authRouter.POST("/accounts", server.createAccount)
-	authRouter.GET("/accounts/:id", server.getAccount)
-	authRouter.GET("/accounts", server.listAccounts)
-	authRouter.GET("/accountsWithCursor", server.listAccountsWithCursor)
-	authRouter.PUT("/accounts/:id", server.updateAccount)
-	authRouter.DELETE("/accounts/:id", server.deleteAccount)
-
+	router.POST("/verify_email", server.verifyEmail)
+	router.POST("/users/password/forgot", server.forgotPassword)
+	router.POST("/users/password/reset", server.resetPassword)
+	authRouter.GET("/users/:username", allow("users", "read", userOwnership), server.getUser)
+	authRouter.PATCH("/users/:username", allow("users", "update", userOwnership), server.updateUser)
+	authRouter.POST("/users/:username/verify_email", allow("users", "update", userOwnership), server.resendVerifyEmail)
+	authRouter.GET("/users/:username/two_factor", allow("users", "read", userOwnership), server.getTwoFactor)
+	// the handlers only let users set up their own two-factor authentication
+	authRouter.POST("/users/:username/two_factor", allow("users", "update", userOwnership), server.enrollTwoFactor)
+	authRouter.POST("/users/:username/two_factor/confirm", allow("users", "update", userOwnership), server.confirmTwoFactor)
+
+	authRouter.POST("/accounts", allow("accounts", "create", nil), server.createAccount)
+	authRouter.GET("/accounts/:id", allow("accounts", "read", server.accountMembership(util.ViewPermission)), server.getAccount)
+	authRouter.GET("/accounts", allow("accounts", "read", nil), server.listAccounts)
+	// kept for clients of the former created_at cursor, now served by the keyset listing
+	authRouter.GET("/accountsWithCursor", allow("accounts", "read", nil), server.listAccounts)
+	authRouter.PUT("/accounts/:id", allow("accounts", "adjust", server.accountOwnership), server.updateAccount)
+	authRouter.PATCH("/accounts/:id/status", allow("accounts", "adjust", server.accountOwnership), server.updateAccountStatus)
+	authRouter.DELETE("/accounts/:id", allow("accounts", "delete", server.accountOwnership), server.deleteAccount)
+	authRouter.GET("/accounts/:id/events", allow("accounts", "read", server.accountMembership(util.ViewPermission)), server.streamAccountEvents)
+	authRouter.GET("/accounts/:id/entries", allow("accounts", "read", server.accountMembership(util.ViewPermission)), server.listEntries)
+	authRouter.GET("/accounts/:id/transfers", allow("accounts", "read", server.accountMembership(util.ViewPermission)), server.listTransfers)
+
+	authRouter.GET("/accounts/:id/members", allow("accounts", "read", server.accountMembership(util.ViewPermission)), server.listAccountMembers)
+	authRouter.POST("/accounts/:id/members", allow("accounts", "share", server.accountMembership(util.ManagePermission)), server.inviteAccountMember)
+	authRouter.PATCH("/accounts/:id/members/:username", allow("accounts", "share", server.accountMembership(util.ManagePermission)), server.updateAccountMember)
+	authRouter.DELETE("/accounts/:id/members/:username", allow("accounts", "share", server.accountMembership(util.ManagePermission)), server.removeAccountMember)
+	authRouter.POST("/accounts/:id/grants", allow("grants", "create", server.accountMembership(util.ManagePermission)), server.createGrant)
+	authRouter.GET("/grants", allow("grants", "read", nil), server.listGrants)
+	authRouter.DELETE("/grants/:id", allow("grants", "revoke", server.grantOwnership), server.revokeGrant)
+	authRouter.POST("/payees", allow("payees", "create", nil), server.createPayee)
+	authRouter.GET("/payees", allow("payees", "read", nil), server.listPayees)
+	authRouter.DELETE("/payees/:id", allow("payees", "delete", nil), server.deletePayee)
+	authRouter.GET("/payees/confirm", allow("payees", "confirm", nil), server.confirmPayee)
+	authRouter.GET("/invitations", allow("accounts", "join", nil), server.listAccountInvitations)
+	authRouter.POST("/invitations/:id/accept", allow("accounts", "join", nil), server.acceptAccountInvitation)
+	authRouter.POST("/invitations/:id/decline", allow("accounts", "join", nil), server.declineAccountInvitation)
+
+	// ownership of the from account is checked by the handler once the body is read
 	authRouter.POST("/transfers", server.createTransfer)
+	authRouter.GET("/directory", allow("directory", "read", nil), server.lookupDirectory)
+	// ownership of the from account is checked by the handler once the recipient is resolved
+	authRouter.POST("/payments", server.createPayment)
+	authRouter.POST("/money_requests", allow("money_requests", "create", nil), server.createMoneyRequest)
+	authRouter.GET("/money_requests/incoming", allow("money_requests", "read", nil), server.listIncomingMoneyRequests)
+	authRouter.GET("/money_requests/outgoing", allow("money_requests", "read", nil), server.listOutgoingMoneyRequests)
+	authRouter.GET("/money_requests/:id", allow("money_requests", "read", server.moneyRequestOwnership), server.getMoneyRequest)
+	authRouter.POST("/money_requests/:id/accept", allow("money_requests", "respond", server.moneyRequestOwnership), server.acceptMoneyRequest)
+	authRouter.POST("/money_requests/:id/decline", allow("money_requests", "respond", server.moneyRequestOwnership), server.declineMoneyRequest)
+	authRouter.POST("/money_requests/:id/cancel", allow("money_requests", "respond", server.moneyRequestOwnership), server.cancelMoneyRequest)
+	authRouter.GET("/approvals", allow("approvals", "read", anyOwnership), server.listApprovals)
+	authRouter.GET("/approvals/:id", allow("approvals", "read", server.approvalOwnership), server.getApproval)
+	authRouter.POST("/approvals/:id/approve", allow("approvals", "review", server.approvalOwnership), server.approveOperation)
+	authRouter.POST("/approvals/:id/reject", allow("approvals", "review", server.approvalOwnership), server.rejectOperation)
+	authRouter.GET("/risk_decisions", allow("risk_decisions", "read", anyOwnership), server.listRiskDecisions)
+	authRouter.GET("/screening_hits", allow("screening", "read", anyOwnership), server.listScreeningHits)
+	authRouter.POST("/screening_hits/:id/clear", allow("screening", "review", server.screeningHitOwnership), server.clearScreeningHit)
+	authRouter.POST("/screening_hits/:id/confirm", allow("screening", "review", server.screeningHitOwnership), server.confirmScreeningHit)
+
+	authRouter.GET("/search/accounts", allow("accounts", "read", anyOwnership), server.searchAccounts)
+	authRouter.GET("/search/users", allow("users", "read", anyOwnership), server.searchUsers)
+	authRouter.GET("/admin/users", allow("users", "read", anyOwnership), server.listUsers)
+	authRouter.PATCH("/admin/users/:username/role", allow("users", "manage", userOwnership), server.changeUserRole)
+	authRouter.POST("/admin/users/:username/disable", allow("users", "manage", userOwnership), server.disableUser)
+	authRouter.POST("/admin/users/:username/enable", allow("users", "manage", userOwnership), server.enableUser)
+	authRouter.POST("/admin/users/:username/revoke_sessions", allow("users", "manage", userOwnership), server.revokeUserSessions)
+	authRouter.DELETE("/admin/users/:username/two_factor", allow("users", "manage", userOwnership), server.resetTwoFactor)
+	authRouter.GET("/admin/lockouts", allow("users", "read", anyOwnership), server.listLoginLockouts)
+	authRouter.DELETE("/admin/lockouts/:scope/:identifier", allow("users", "manage", anyOwnership), server.clearLoginLockout)
+	authRouter.GET("/audit", allow("audit", "read", anyOwnership), server.listAuditLogs)
+	authRouter.GET("/debug/vars", allow("debug", "read", anyOwnership), gin.WrapH(expvar.Handler()))
 
 	server.router = router
+	return nil
 }
 
 // Start runs the HTTP server on a specific address.
 func (server *Server) Start(address string) error {
+	go server.listenAccountEvents(context.Background())
+
 	return server.router.Run(address)
 }
 
@@ -66,9 +66,24 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
+	// the new token carries the current role, unless the user lost access since logging in
+	access, err := server.store.GetUserAccess(ctx, refreshPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errRevokedToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if accessRevoked(access, refreshPayload.IssuedAt) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errRevokedToken))
+		return
+	}
+
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		refreshPayload.Username,
-		refreshPayload.Role,
+		access.Role,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
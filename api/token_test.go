@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.config.RefreshTokenDuration = time.Hour
+
+	send := func(url string, body gin.H) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	updateUser := func(arg db.UpdateUserParams) {
+		_, err := store.UpdateUser(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	recorder := send("/users/login", gin.H{"username": user.Username, "password": password})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var session loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &session))
+	renew := func() *httptest.ResponseRecorder {
+		return send("/tokens/renew_access", gin.H{"refresh_token": session.RefreshToken})
+	}
+
+	// the renewed token carries the role the user holds now, not the one they logged in with
+	updateUser(db.UpdateUserParams{
+		Username: user.Username,
+		Role:     pgtype.Text{String: util.BankerRole, Valid: true},
+	})
+	recorder = renew()
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var rsp renewAccessTokenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	payload, err := server.tokenMaker.VerifyToken(rsp.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, payload.Role)
+
+	// disabled users cannot renew, even with a session that was not blocked
+	updateUser(db.UpdateUserParams{
+		Username: user.Username,
+		Disabled: pgtype.Bool{Bool: true, Valid: true},
+	})
+	recorder = renew()
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), errRevokedToken.Error())
+
+	updateUser(db.UpdateUserParams{
+		Username: user.Username,
+		Disabled: pgtype.Bool{Bool: false, Valid: true},
+	})
+	require.Equal(t, http.StatusOK, renew().Code)
+
+	// nor can they once their password changed after the refresh token was issued
+	updateUser(db.UpdateUserParams{
+		Username:          user.Username,
+		PasswordChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	recorder = renew()
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), errRevokedToken.Error())
+}
@@ -4,18 +4,24 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/pagination"
 	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type transferRequest struct {
 	// json tag to de-serialize json body
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	// the recipient is either an account or a payee saved by the user
+	ToAccountID int64  `json:"to_account_id" binding:"required_without=PayeeID,omitempty,min=1"`
+	PayeeID     int64  `json:"payee_id" binding:"excluded_with=ToAccountID,omitempty,min=1"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"required,currency"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -25,20 +31,62 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
-	if !valid {
+	if req.PayeeID != 0 {
+		payee, ok := server.ownPayee(ctx, req.PayeeID)
+		if !ok {
+			return
+		}
+		if payee.Currency != req.Currency {
+			err := fmt.Errorf("payee [%d] currency mismatch: %s vs %s", payee.ID, payee.Currency, req.Currency)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		req.ToAccountID = payee.AccountID
+	}
+
+	server.transfer(ctx, req)
+}
+
+// transfer moves money once the recipient account of the request is resolved and the risk rules
+// allow it. Transfers the rules send to review or above the threshold are held for approval.
+func (server *Server) transfer(ctx *gin.Context, req transferRequest) {
+	if !server.authorizeTransfer(ctx, req) {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	assessment, ok := server.assessTransfer(ctx, req)
+	if !ok {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
-	if !valid {
+	if assessment.Outcome == util.BlockOutcome {
+		server.blockTransfer(ctx, req, assessment)
+		return
+	}
+
+	if assessment.Outcome == util.ReviewOutcome || server.needsApproval(req.Amount) {
+		approval, ok := server.createApproval(ctx, db.CreateApprovalParams{
+			Kind:        util.TransferApproval,
+			AccountID:   req.FromAccountID,
+			ToAccountID: pgtype.Int8{Int64: req.ToAccountID, Valid: true},
+			Amount:      pgtype.Int8{Int64: req.Amount, Valid: true},
+			Currency:    req.Currency,
+		})
+		if !ok {
+			return
+		}
+
+		_, ok = server.recordRiskDecision(ctx, req, assessment, pgtype.Int8{Int64: approval.ID, Valid: true})
+		if !ok {
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, approval)
+		return
+	}
+
+	decision, ok := server.riskDecisionParams(ctx, req, assessment, pgtype.Int8{})
+	if !ok {
 		return
 	}
 
@@ -46,17 +94,64 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		RiskDecision:  &decision,
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrAccountNotActive) {
+			// an account was frozen or closed after it was checked
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if db.IsTxConflict(err) {
+			// the transfer kept conflicting with concurrent ones even after retrying
+			ctx.Header("Retry-After", "1")
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	setAuditChange(ctx, "transfers", strconv.FormatInt(result.Transfer.ID, 10), nil, result.Transfer)
+
 	ctx.JSON(http.StatusOK, result)
 }
 
+// authorizeTransfer checks that both accounts exist in the currency of the transfer,
+// that the user may send the amount from the from account, that their email is verified
+// and that no one involved waits for a compliance review
+func (server *Server) authorizeTransfer(ctx *gin.Context, req transferRequest) bool {
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	owned, ok := server.holdsAccount(ctx, authPayload, fromAccount, util.TransactPermission)
+	if !ok || !server.authorize(ctx, "transfers", "create", owned) {
+		return false
+	}
+
+	if !server.verifiedForTransfer(ctx, authPayload) {
+		return false
+	}
+
+	if grant, ok := accessGrant(ctx); ok && grant.TransferLimit.Valid && req.Amount > grant.TransferLimit.Int64 {
+		err := fmt.Errorf("amount exceeds the transfer limit %d of access grant %d", grant.TransferLimit.Int64, grant.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return false
+	}
+
+	return server.clearedForTransfer(ctx, authPayload.Username, fromAccount.Owner, toAccount.Owner)
+}
+
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
@@ -74,5 +169,65 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency s
 		return account, false
 	}
 
+	if account.Status != util.ActiveAccount {
+		err := fmt.Errorf("account [%d] is %s", account.ID, account.Status)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return account, false
+	}
+
 	return account, true
 }
+
+func transferPosition(transfer db.Transfer) pagination.Cursor {
+	return pagination.Cursor{
+		SortKey: transfer.CreatedAt,
+		ID:      strconv.FormatInt(transfer.ID, 10),
+	}
+}
+
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account := authorizedAccount(ctx)
+
+	scope := "transfers:" + strconv.FormatInt(account.ID, 10)
+
+	page, ok := listPage(ctx, server, scope, req, transferPosition,
+		func(cursor *pagination.Cursor, limit int32) ([]db.Transfer, error) {
+			arg := db.ListTransfersAfterParams{
+				AccountID: account.ID,
+				Limit:     limit,
+			}
+			if cursor != nil {
+				id, err := cursorID(*cursor)
+				if err != nil {
+					return nil, err
+				}
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.SortKey, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: id, Valid: true}
+			}
+			return server.store.ListTransfersAfter(ctx, arg)
+		},
+		func(cursor pagination.Cursor, limit int32) ([]db.Transfer, error) {
+			id, err := cursorID(cursor)
+			if err != nil {
+				return nil, err
+			}
+			return server.store.ListTransfersBefore(ctx, db.ListTransfersBeforeParams{
+				AccountID:       account.ID,
+				CursorCreatedAt: cursor.SortKey,
+				CursorID:        id,
+				Limit:           limit,
+			})
+		},
+	)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse(server, scope, "transfers", page.Items, page))
+}
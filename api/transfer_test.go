@@ -2,18 +2,23 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	memorydb "github.com/foyez/simplebank/db/memory"
 	mockdb "github.com/foyez/simplebank/db/mock"
 	db "github.com/foyez/simplebank/db/sqlc"
 	"github.com/foyez/simplebank/token"
 	"github.com/foyez/simplebank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 )
 
@@ -57,6 +62,15 @@ func TestCreateTransfer(t *testing.T) {
 					FromAccountID: account1.ID,
 					ToAccountID:   account2.ID,
 					Amount:        amount,
+					RiskDecision: &db.CreateRiskDecisionParams{
+						Username:      user1.Username,
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+						Currency:      util.USD,
+						Outcome:       util.AllowOutcome,
+						FiredRules:    json.RawMessage("[]"),
+					},
 				}
 
 				store.EXPECT().
@@ -81,11 +95,13 @@ func TestCreateTransfer(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().GetActiveAccessGrant(gomock.Any(), gomock.Any()).Times(1).Return(db.AccessGrant{}, db.ErrRecordNotFound)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
 				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
 		{
@@ -186,6 +202,53 @@ func TestCreateTransfer(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "TxConflict",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, user1.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &pgconn.PgError{Code: db.SerializationFailure})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "TransferTxError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, user1.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, sql.ErrTxDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidCurrency",
 			body: gin.H{
@@ -255,3 +318,148 @@ func TestCreateTransfer(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateTransferWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	var accounts []db.Account
+	for _, user := range []db.User{user1, user2} {
+		_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  100,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	data, err := json.Marshal(gin.H{
+		"from_account_id": accounts[0].ID,
+		"to_account_id":   accounts[1].ID,
+		"amount":          30,
+		"currency":        util.USD,
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, user1.Role, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var result db.TransferTxResult
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	require.NoError(t, err)
+	require.Equal(t, int64(70), result.FromAccount.Balance)
+	require.Equal(t, int64(130), result.ToAccount.Balance)
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 1)
+	require.Equal(t, "transfers", auditLogs[0].ResourceType)
+	require.Equal(t, user1.Username, auditLogs[0].Actor)
+}
+
+func TestListTransfersWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	var accounts []db.Account
+	for _, user := range []db.User{user1, user2} {
+		_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	n := 5
+	var ids []int64
+	for i := 0; i < n; i++ {
+		transfer, err := store.CreateTransfer(context.Background(), db.CreateTransferParams{
+			FromAccountID: accounts[i%2].ID,
+			ToAccountID:   accounts[(i+1)%2].ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+		ids = append([]int64{transfer.ID}, ids...)
+	}
+
+	listPage := func(cursor *string) (transfers []db.Transfer, next *string, prev *string) {
+		url := fmt.Sprintf("/accounts/%d/transfers?page_size=2", accounts[0].ID)
+		if cursor != nil {
+			url += "&cursor=" + *cursor
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, user1.Role, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp struct {
+			Transfers  []db.Transfer `json:"transfers"`
+			NextCursor *string       `json:"next_cursor"`
+			PrevCursor *string       `json:"prev_cursor"`
+		}
+		err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+		require.NoError(t, err)
+		return rsp.Transfers, rsp.NextCursor, rsp.PrevCursor
+	}
+
+	var listed []int64
+	var cursor, prev *string
+	for {
+		transfers, next, prevCursor := listPage(cursor)
+		for _, transfer := range transfers {
+			listed = append(listed, transfer.ID)
+		}
+		prev = prevCursor
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+	require.Equal(t, ids, listed, "transfers must be listed newest first without gaps")
+
+	// walking back from the last page lands on the first one
+	var listedBack []int64
+	for prev != nil {
+		var transfers []db.Transfer
+		transfers, _, prev = listPage(prev)
+		var page []int64
+		for _, transfer := range transfers {
+			page = append(page, transfer.ID)
+		}
+		listedBack = append(page, listedBack...)
+	}
+	require.Equal(t, ids[:len(ids)-1], listedBack)
+}
@@ -0,0 +1,417 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/totp"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// twoFactorIssuer names the account in authenticator apps
+	twoFactorIssuer        = "Simple Bank"
+	loginChallengeDuration = 5 * time.Minute
+	// maxLoginChallengeAttempts bounds the codes that can be tried against one challenge
+	maxLoginChallengeAttempts = 5
+)
+
+var (
+	errInvalidTwoFactorCode = errors.New("two-factor code is invalid")
+	// errInvalidLoginChallenge does not tell an unknown challenge from a used, expired or exhausted one
+	errInvalidLoginChallenge = errors.New("login challenge is invalid, used or expired")
+)
+
+type twoFactorRequest struct {
+	Username string `uri:"username" binding:"required"`
+}
+
+type twoFactorResponse struct {
+	IsEnabled         bool       `json:"is_enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+// getTwoFactor tells whether the user enabled two-factor authentication,
+// and how many recovery codes they have left
+func (server *Server) getTwoFactor(ctx *gin.Context) {
+	var req twoFactorRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	twoFactor, err := server.store.GetTwoFactor(ctx, req.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var rsp twoFactorResponse
+	if twoFactor.IsEnabled {
+		rsp.IsEnabled = true
+		rsp.EnabledAt = &twoFactor.EnabledAt.Time
+
+		rsp.RecoveryCodesLeft, err = server.store.CountRecoveryCodes(ctx, req.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type enrollTwoFactorResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// enrollTwoFactor generates a new secret for the user to add to their authenticator app.
+// The secret is only enabled once the user confirms it with a first code, and is stored
+// encrypted so that it is only ever in clear in this response.
+func (server *Server) enrollTwoFactor(ctx *gin.Context) {
+	var req twoFactorRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !selfOnly(ctx, req.Username) {
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	encryptedSecret, err := server.secretCipher.Encrypt(req.Username, secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	twoFactor, err := server.store.CreateTwoFactor(ctx, db.CreateTwoFactorParams{
+		Username: req.Username,
+		Secret:   encryptedSecret,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("two-factor authentication is already enabled")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if db.ErrCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "two_factors", twoFactor.Username, nil, gin.H{"is_enabled": false})
+
+	ctx.JSON(http.StatusCreated, enrollTwoFactorResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(twoFactorIssuer, twoFactor.Username, secret),
+	})
+}
+
+type confirmTwoFactorRequest struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
+}
+
+type confirmTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTwoFactor enables the secret of the user once they prove their app generates
+// its codes. It returns the recovery codes, which are never shown again.
+func (server *Server) confirmTwoFactor(ctx *gin.Context) {
+	var uri twoFactorRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req confirmTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !selfOnly(ctx, uri.Username) {
+		return
+	}
+
+	twoFactor, err := server.store.GetTwoFactor(ctx, uri.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if twoFactor.IsEnabled {
+		err := errors.New("two-factor authentication is already enabled")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	secret, err := server.secretCipher.Decrypt(twoFactor.Username, twoFactor.Secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	step, ok := totp.Validate(secret, req.Code, time.Now(), twoFactor.LastUsedStep)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidTwoFactorCode))
+		return
+	}
+
+	codes, err := totp.GenerateRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = util.HashSecret(totp.NormalizeRecoveryCode(code))
+	}
+
+	result, err := server.store.EnableTwoFactorTx(ctx, db.EnableTwoFactorTxParams{
+		Username:           uri.Username,
+		Step:               step,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// another request enabled or reset the secret since it was read
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "two_factors", result.TwoFactor.Username, gin.H{"is_enabled": false}, gin.H{
+		"is_enabled":     true,
+		"recovery_codes": len(result.RecoveryCodes),
+	})
+
+	ctx.JSON(http.StatusOK, confirmTwoFactorResponse{RecoveryCodes: codes})
+}
+
+type loginChallengeResponse struct {
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// startLoginChallenge answers the first login step of a user with two-factor authentication,
+// whose password was checked, with the token to send along with their code
+func (server *Server) startLoginChallenge(ctx *gin.Context, user db.User) {
+	challengeToken, tokenHash, err := util.NewSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	challenge, err := server.store.CreateLoginChallenge(ctx, db.CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(loginChallengeDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "login_challenges", strconv.FormatInt(challenge.ID, 10), nil, gin.H{
+		"username":   challenge.Username,
+		"expires_at": challenge.ExpiresAt,
+	})
+
+	ctx.JSON(http.StatusAccepted, loginChallengeResponse{
+		ChallengeToken: challengeToken,
+		ExpiresAt:      challenge.ExpiresAt,
+	})
+}
+
+type loginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode   string `json:"recovery_code" binding:"required_without=Code,excluded_with=Code"`
+}
+
+// loginTwoFactor completes the login of a user with two-factor authentication, trading the
+// challenge token of the first step and a code of their app, or a recovery code, for a session
+func (server *Server) loginTwoFactor(ctx *gin.Context) {
+	var req loginTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	challenge, err := server.store.GetLoginChallenge(ctx, util.HashSecret(req.ChallengeToken))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidLoginChallenge))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if challenge.IsUsed || !challenge.ExpiresAt.After(time.Now()) || challenge.Attempts >= maxLoginChallengeAttempts {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidLoginChallenge))
+		return
+	}
+
+	// codes are guessed like passwords, one challenge after another
+	if !server.loginAllowed(ctx, challenge.Username) {
+		return
+	}
+
+	twoFactor, err := server.store.GetTwoFactor(ctx, challenge.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !twoFactor.IsEnabled {
+		// a banker reset the two-factor authentication of the user since the first step
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidLoginChallenge))
+		return
+	}
+
+	arg := db.CompleteLoginChallengeTxParams{ChallengeID: challenge.ID}
+	if req.RecoveryCode != "" {
+		arg.RecoveryCodeHash = pgtype.Text{
+			String: util.HashSecret(totp.NormalizeRecoveryCode(req.RecoveryCode)),
+			Valid:  true,
+		}
+	} else {
+		secret, err := server.secretCipher.Decrypt(twoFactor.Username, twoFactor.Secret)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		step, ok := totp.Validate(secret, req.Code, time.Now(), twoFactor.LastUsedStep)
+		if !ok {
+			server.failLoginChallenge(ctx, challenge)
+			return
+		}
+		arg.Step = pgtype.Int8{Int64: step, Valid: true}
+	}
+
+	result, err := server.store.CompleteLoginChallengeTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the recovery code is wrong or used, or the code was used by a concurrent request
+			server.failLoginChallenge(ctx, challenge)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !server.refundLogin(ctx) {
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, result.LoginChallenge.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Disabled {
+		err := errors.New("user is disabled")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	server.createLoginSession(ctx, user)
+}
+
+// failLoginChallenge counts a wrong code against the challenge. It already counts as a failed login
+// of its user, as every login does until its code turns out right.
+func (server *Server) failLoginChallenge(ctx *gin.Context, challenge db.LoginChallenge) {
+	challenge, err := server.store.FailLoginChallenge(ctx, challenge.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "login_challenges", strconv.FormatInt(challenge.ID, 10), nil, gin.H{"attempts": challenge.Attempts})
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidTwoFactorCode))
+}
+
+type resetTwoFactorResponse struct {
+	User                 adminUserResponse `json:"user"`
+	DeletedRecoveryCodes int64             `json:"deleted_recovery_codes"`
+}
+
+// resetTwoFactor removes the two-factor authentication of a user who lost their app
+// and their recovery codes, so that they log in with their password alone and enroll again
+func (server *Server) resetTwoFactor(ctx *gin.Context) {
+	var uri adminUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, uri.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ResetTwoFactorTx(ctx, user.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("user has not set up two-factor authentication")
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "two_factors", user.Username, gin.H{"is_set_up": true}, gin.H{
+		"is_set_up":              false,
+		"deleted_recovery_codes": result.DeletedRecoveryCodes,
+	})
+
+	ctx.JSON(http.StatusOK, resetTwoFactorResponse{
+		User:                 newAdminUserResponse(user),
+		DeletedRecoveryCodes: result.DeletedRecoveryCodes,
+	})
+}
+
+// selfOnly refuses to let anyone but the user themselves, bankers included,
+// set up the two-factor authentication of a user
+func selfOnly(ctx *gin.Context, username string) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != username {
+		err := errors.New("two-factor authentication can only be set up by the user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/lockout"
+	"github.com/foyez/simplebank/totp"
+	"github.com/foyez/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.config.RefreshTokenDuration = server.config.AccessTokenDuration
+	// the wrong codes below are refused by their challenge before the user is locked out
+	server.limiter = lockout.NewLimiter(util.Config{LoginUsernameThreshold: 2 * maxLoginChallengeAttempts})
+
+	send := func(method string, url string, user *db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		if user != nil {
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+		}
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	banker, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(6),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	banker = setRole(t, store, banker, util.BankerRole)
+
+	login := func() *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/users/login", nil, gin.H{"username": user.Username, "password": password})
+	}
+	challenge := func() string {
+		recorder := login()
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+		var rsp loginChallengeResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.NotEmpty(t, rsp.ChallengeToken)
+		return rsp.ChallengeToken
+	}
+	answer := func(challengeToken string, body gin.H) *httptest.ResponseRecorder {
+		body["challenge_token"] = challengeToken
+		return send(http.MethodPost, "/users/login/two_factor", nil, body)
+	}
+	status := func() twoFactorResponse {
+		recorder := send(http.MethodGet, "/users/"+user.Username+"/two_factor", &user, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp twoFactorResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		return rsp
+	}
+
+	require.Equal(t, http.StatusOK, login().Code)
+	require.False(t, status().IsEnabled)
+
+	// bankers cannot set up the two-factor authentication of users
+	require.Equal(t, http.StatusForbidden, send(http.MethodPost, "/users/"+user.Username+"/two_factor", &banker, nil).Code)
+
+	recorder := send(http.MethodPost, "/users/"+user.Username+"/two_factor", &user, nil)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	var enrollment enrollTwoFactorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enrollment))
+	require.True(t, strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/"))
+	require.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+	// only the encryption of the secret is stored
+	twoFactor, err := store.GetTwoFactor(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.NotContains(t, twoFactor.Secret, enrollment.Secret)
+	secret, err := server.secretCipher.Decrypt(user.Username, twoFactor.Secret)
+	require.NoError(t, err)
+	require.Equal(t, enrollment.Secret, secret)
+
+	// the secret is not enforced until it is confirmed
+	require.Equal(t, http.StatusOK, login().Code)
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(enrollment.Secret, step)
+	require.NoError(t, err)
+	wrongCode, err := totp.Code(enrollment.Secret, step-10)
+	require.NoError(t, err)
+
+	confirm := func(code string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/users/"+user.Username+"/two_factor/confirm", &user, gin.H{"code": code})
+	}
+	require.Equal(t, http.StatusBadRequest, confirm(wrongCode).Code)
+
+	recorder = confirm(code)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var confirmation confirmTwoFactorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &confirmation))
+	require.Len(t, confirmation.RecoveryCodes, totp.RecoveryCodeCount)
+
+	require.Equal(t, http.StatusConflict, confirm(code).Code)
+	require.Equal(t, http.StatusConflict, send(http.MethodPost, "/users/"+user.Username+"/two_factor", &user, nil).Code)
+	require.True(t, status().IsEnabled)
+
+	// the password alone no longer opens a session
+	challengeToken := challenge()
+	require.NotContains(t, login().Body.String(), "access_token")
+
+	recorder = answer(challengeToken, gin.H{"code": code})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code, "the code used to confirm cannot be used again")
+	require.Contains(t, recorder.Body.String(), errInvalidTwoFactorCode.Error())
+
+	nextCode, err := totp.Code(enrollment.Secret, step+1)
+	require.NoError(t, err)
+	recorder = answer(challengeToken, gin.H{"code": nextCode})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var session loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &session))
+	require.Equal(t, user.Username, session.User.Username)
+	require.NotEmpty(t, session.AccessToken)
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "/tokens/renew_access", nil, gin.H{"refresh_token": session.RefreshToken}).Code)
+
+	recorder = answer(challengeToken, gin.H{"code": nextCode})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code, "challenges are single-use")
+	require.Contains(t, recorder.Body.String(), errInvalidLoginChallenge.Error())
+
+	// recovery codes are accepted however they are typed, once
+	recoveryCode := strings.ToUpper(confirmation.RecoveryCodes[0])
+	require.Equal(t, http.StatusOK, answer(challenge(), gin.H{"recovery_code": recoveryCode}).Code)
+	require.Equal(t, http.StatusUnauthorized, answer(challenge(), gin.H{"recovery_code": recoveryCode}).Code)
+	require.Equal(t, int64(totp.RecoveryCodeCount-1), status().RecoveryCodesLeft)
+
+	require.Equal(t, http.StatusBadRequest, answer(challenge(), gin.H{}).Code)
+	require.Equal(t, http.StatusBadRequest, answer(challenge(), gin.H{"code": nextCode, "recovery_code": recoveryCode}).Code)
+	require.Equal(t, http.StatusUnauthorized, answer(util.RandomString(43), gin.H{"code": nextCode}).Code)
+
+	// a challenge is refused once too many wrong codes were tried against it
+	challengeToken = challenge()
+	for i := 0; i < maxLoginChallengeAttempts; i++ {
+		require.Equal(t, http.StatusUnauthorized, answer(challengeToken, gin.H{"code": wrongCode}).Code)
+	}
+	recorder = answer(challengeToken, gin.H{"recovery_code": confirmation.RecoveryCodes[1]})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), errInvalidLoginChallenge.Error())
+
+	// a banker resets the two-factor authentication of a user who lost their app
+	challengeToken = challenge()
+	require.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/admin/users/"+user.Username+"/two_factor", &user, nil).Code)
+
+	recorder = send(http.MethodDelete, "/admin/users/"+user.Username+"/two_factor", &banker, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var reset resetTwoFactorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &reset))
+	require.Equal(t, int64(totp.RecoveryCodeCount), reset.DeletedRecoveryCodes)
+
+	require.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/admin/users/"+user.Username+"/two_factor", &banker, nil).Code)
+	require.Equal(t, http.StatusUnauthorized, answer(challengeToken, gin.H{"recovery_code": confirmation.RecoveryCodes[1]}).Code)
+	require.False(t, status().IsEnabled)
+	require.Equal(t, http.StatusOK, login().Code)
+}
+
+func TestTwoFactorKeyDiffersFromOtherKeys(t *testing.T) {
+	key := util.RandomString(32)
+	_, err := NewServer(util.Config{TokenSymmetricKey: key, CursorSymmetricKey: util.RandomString(32), TwoFactorSecretKey: key}, nil)
+	require.ErrorContains(t, err, "two-factor secret key")
+}
@@ -6,8 +6,8 @@ import (
 	"time"
 
 	db "github.com/foyez/simplebank/db/sqlc"
-	"github.com/foyez/simplebank/token"
 	"github.com/foyez/simplebank/util"
+	"github.com/foyez/simplebank/worker"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -15,7 +15,7 @@ import (
 
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	FullName string `json:"full_name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 }
@@ -26,6 +26,9 @@ type userResponse struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Version           int64     `json:"version"`
+	Discoverable      bool      `json:"discoverable"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
 }
 
 func newUserResponse(user db.User) userResponse {
@@ -35,6 +38,9 @@ func newUserResponse(user db.User) userResponse {
 		Email:             user.Email,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
+		Version:           user.Version,
+		Discoverable:      user.Discoverable,
+		IsEmailVerified:   user.IsEmailVerified,
 	}
 }
 
@@ -45,19 +51,30 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := util.HashPassword(req.Password)
+	hashedPassword, ok := server.hashNewPassword(ctx, req.Password)
+	if !ok {
+		return
+	}
+
+	job, err := worker.NewVerifyEmailJob(req.Username, req.Email)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		// the verification email is only sent once the user is created
+		Jobs: []db.CreateJobParams{job},
+		// hits are for bankers to review, the response does not tell the user about them
+		ScreeningHits: server.screener.Hits(req.Username, req.FullName),
 	}
-	user, err := server.store.CreateUser(ctx, arg)
+	result, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if db.ErrCode(err) == db.UniqueViolation {
 			ctx.JSON(http.StatusConflict, errorResponse(err))
@@ -67,8 +84,11 @@ func (server *Server) createUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	user := result.User
 
 	rsp := newUserResponse(user)
+	setAuditChange(ctx, "users", user.Username, nil, rsp)
+	setETag(ctx, user.Version)
 
 	ctx.JSON(http.StatusCreated, rsp)
 }
@@ -94,10 +114,16 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	if !server.loginAllowed(ctx, req.Username) {
+		return
+	}
+
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			// unknown usernames take as long and fail the same as wrong passwords
+			util.CheckPassword(req.Password, server.dummyHashedPassword)
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -106,7 +132,38 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	if !server.refundLogin(ctx) {
+		return
+	}
+	server.rehashPassword(ctx, user, req.Password)
+
+	if user.Disabled {
+		err := errors.New("user is disabled")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	twoFactor, err := server.store.GetTwoFactor(ctx, user.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if twoFactor.IsEnabled {
+		server.startLoginChallenge(ctx, user)
+		return
+	}
+
+	server.createLoginSession(ctx, user)
+}
+
+// createLoginSession issues the access and refresh tokens of a user who proved who they are,
+// and records the session of the refresh token
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User) {
+	if err := server.limiter.Succeed(ctx, server.store, user.Username); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
@@ -144,6 +201,8 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	setAuditChange(ctx, "users", user.Username, nil, nil)
+
 	rsp := loginUserResponse{
 		SessionID:             session.ID,
 		AccessToken:           accessToken,
@@ -155,6 +214,31 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+type getUserRequest struct {
+	Username string `uri:"username" binding:"required"`
+}
+
+func (server *Server) getUser(ctx *gin.Context) {
+	var req getUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setETag(ctx, user.Version)
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
 type updateUserParams struct {
 	Username string `uri:"username" binding:"required"`
 }
@@ -163,7 +247,9 @@ type updateUserRequest struct {
 	// Username string  `json:"username" binding:"required"`
 	FullName *string `json:"full_name,omitempty" binding:"omitempty"`
 	Email    *string `json:"email,omitempty" binding:"omitempty,email"`
-	Password *string `json:"password,omitempty" binding:"omitempty,min=6"`
+	Password *string `json:"password,omitempty" binding:"omitempty"`
+	// Discoverable lists the user in the payment directory
+	Discoverable *bool `json:"discoverable,omitempty"`
 }
 
 func (server *Server) updateUser(ctx *gin.Context) {
@@ -179,15 +265,26 @@ func (server *Server) updateUser(ctx *gin.Context) {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if authPayload.Role != util.BankerRole && params.Username != authPayload.Username {
-		err := errors.New("user doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	oldUser, err := server.store.GetUser(ctx, params.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	arg := db.UpdateUserParams{
-		Username: params.Username,
+	version, ok := server.checkIfMatch(ctx, oldUser.Version)
+	if !ok {
+		return
+	}
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: params.Username,
+			Version:  version,
+		},
 	}
 
 	if req.FullName != nil {
@@ -195,6 +292,10 @@ func (server *Server) updateUser(ctx *gin.Context) {
 			String: *req.FullName,
 			Valid:  req.FullName != nil,
 		}
+
+		if *req.FullName != oldUser.FullName {
+			arg.ScreeningHits = server.screener.Hits(params.Username, *req.FullName)
+		}
 	}
 
 	if req.Email != nil {
@@ -202,12 +303,33 @@ func (server *Server) updateUser(ctx *gin.Context) {
 			String: *req.Email,
 			Valid:  req.Email != nil,
 		}
+
+		if *req.Email != oldUser.Email {
+			// a new email is unverified until the user follows the link sent to it
+			arg.IsEmailVerified = pgtype.Bool{
+				Bool:  false,
+				Valid: true,
+			}
+
+			job, err := worker.NewVerifyEmailJob(params.Username, *req.Email)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			arg.Jobs = append(arg.Jobs, job)
+		}
+	}
+
+	if req.Discoverable != nil {
+		arg.Discoverable = pgtype.Bool{
+			Bool:  *req.Discoverable,
+			Valid: true,
+		}
 	}
 
 	if req.Password != nil {
-		hashedPassword, err := util.HashPassword(*req.Password)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		hashedPassword, ok := server.hashNewPassword(ctx, *req.Password)
+		if !ok {
 			return
 		}
 
@@ -221,17 +343,25 @@ func (server *Server) updateUser(ctx *gin.Context) {
 		}
 	}
 
-	user, err := server.store.UpdateUser(ctx, arg)
+	result, err := server.store.UpdateUserTx(ctx, arg)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
+			if version.Valid {
+				// the user was modified after it was read
+				ctx.JSON(http.StatusPreconditionFailed, errorResponse(errPreconditionFailed))
+				return
+			}
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	user := result.User
 
 	rsp := newUserResponse(user)
+	setAuditChange(ctx, "users", user.Username, newUserResponse(oldUser), rsp)
+	setETag(ctx, user.Version)
 
 	ctx.JSON(http.StatusOK, rsp)
 }
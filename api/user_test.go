@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	db "github.com/foyez/simplebank/db/sqlc"
 	"github.com/foyez/simplebank/token"
 	"github.com/foyez/simplebank/util"
+	"github.com/foyez/simplebank/worker"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -23,12 +25,12 @@ import (
 )
 
 type eqCreateUserParamsMatcher struct {
-	arg      db.CreateUserParams
+	arg      db.CreateUserTxParams
 	password string
 }
 
 func (e eqCreateUserParamsMatcher) Matches(x any) bool {
-	arg, ok := x.(db.CreateUserParams)
+	arg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
@@ -42,7 +44,7 @@ func (e eqCreateUserParamsMatcher) Matches(x any) bool {
 
 	// Check if types assignable and convert them to common type
 	x1Val := reflect.ValueOf(e.arg)
-	x2Val := reflect.ValueOf(e.arg)
+	x2Val := reflect.ValueOf(arg)
 
 	if x1Val.Type().AssignableTo(x2Val.Type()) {
 		x1ValConverted := x1Val.Convert(x2Val.Type())
@@ -56,7 +58,7 @@ func (e eqCreateUserParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
 
-func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
+func EqCreateUserParams(arg db.CreateUserTxParams, password string) gomock.Matcher {
 	return eqCreateUserParamsMatcher{arg, password}
 }
 
@@ -80,17 +82,23 @@ func TestCreateUserAPI(t *testing.T) {
 				"email":     user.Email,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateUserParams{
-					Username:       user.Username,
-					HashedPassword: hashedPassword,
-					FullName:       user.FullName,
-					Email:          user.Email,
+				job, err := worker.NewVerifyEmailJob(user.Username, user.Email)
+				require.NoError(t, err)
+
+				arg := db.CreateUserTxParams{
+					CreateUserParams: db.CreateUserParams{
+						Username:       user.Username,
+						HashedPassword: hashedPassword,
+						FullName:       user.FullName,
+						Email:          user.Email,
+					},
+					Jobs: []db.CreateJobParams{job},
 				}
 
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
 					Times(1).
-					Return(user, nil)
+					Return(db.CreateUserTxResult{User: user}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusCreated, recorder.Code)
@@ -107,9 +115,9 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrConnDone)
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -125,9 +133,9 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, db.ErrUniqueViolation)
+					Return(db.CreateUserTxResult{}, db.ErrUniqueViolation)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusConflict, recorder.Code)
@@ -143,7 +151,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -160,7 +168,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -177,7 +185,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -233,14 +241,51 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					GetTwoFactor(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TwoFactor{}, db.ErrRecordNotFound)
 				store.EXPECT().
 					CreateSession(gomock.Any(), gomock.Any()).
 					Times(1)
+				// the attempt counted against the username and the client ip is taken back
+				store.EXPECT().
+					RefundLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(2)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "TwoFactorRequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetTwoFactor(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TwoFactor{Username: user.Username, IsEnabled: true}, nil)
+				store.EXPECT().
+					CreateLoginChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginChallenge{ID: 1, Username: user.Username}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "challenge_token")
+				require.NotContains(t, recorder.Body.String(), "access_token")
+			},
+		},
 		{
 			name: "UserNotFound",
 			body: gin.H{
@@ -252,9 +297,69 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					ReserveLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(2)
+				store.EXPECT().
+					RefundLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errInvalidCredentials.Error())
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReserveLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Eq(db.GetLoginLockoutParams{
+						Scope:      util.UsernameLockout,
+						Identifier: user.Username,
+					})).
+					Times(1).
+					Return(db.LoginLockout{
+						Scope:       util.UsernameLockout,
+						Identifier:  user.Username,
+						LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+					}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "DisabledUser",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				disabledUser := user
+				disabledUser.Disabled = true
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(disabledUser, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
 		{
@@ -268,9 +373,16 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					ReserveLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(2)
+				store.EXPECT().
+					RefundLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errInvalidCredentials.Error())
 			},
 		},
 		{
@@ -343,16 +455,26 @@ func TestUpdateUserAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.UpdateUserParams{
-					Username: user.Username,
-					FullName: pgtype.Text{
-						String: newName,
-						Valid:  true,
-					},
-					Email: pgtype.Text{
-						String: newEmail,
-						Valid:  true,
+				job, err := worker.NewVerifyEmailJob(user.Username, newEmail)
+				require.NoError(t, err)
+
+				arg := db.UpdateUserTxParams{
+					UpdateUserParams: db.UpdateUserParams{
+						Username: user.Username,
+						FullName: pgtype.Text{
+							String: newName,
+							Valid:  true,
+						},
+						Email: pgtype.Text{
+							String: newEmail,
+							Valid:  true,
+						},
+						IsEmailVerified: pgtype.Bool{
+							Bool:  false,
+							Valid: true,
+						},
 					},
+					Jobs: []db.CreateJobParams{job},
 				}
 				updatedUser := db.User{
 					Username:          user.Username,
@@ -364,9 +486,13 @@ func TestUpdateUserAPI(t *testing.T) {
 				}
 
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Eq(arg)).
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return(updatedUser, nil)
+					Return(db.UpdateUserTxResult{User: updatedUser}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -396,9 +522,12 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -416,7 +545,7 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -435,7 +564,7 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -454,11 +583,11 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
 	}
@@ -492,8 +621,277 @@ func TestUpdateUserAPI(t *testing.T) {
 	}
 }
 
+func TestGetUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, newETag(user.Version), recorder.Header().Get(etagHeaderKey))
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "Banker",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "UnAuthorized",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized-user", user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/users/%s", user.Username)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(request, server.tokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateUserIfMatchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	newEmail := util.RandomEmail()
+
+	updatedUser := user
+	updatedUser.Email = newEmail
+	updatedUser.Version = user.Version + 1
+
+	testCases := []struct {
+		name           string
+		ifMatch        string
+		requireIfMatch bool
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "Match",
+			ifMatch: newETag(user.Version),
+			buildStubs: func(store *mockdb.MockStore) {
+				job, err := worker.NewVerifyEmailJob(user.Username, newEmail)
+				require.NoError(t, err)
+
+				arg := db.UpdateUserTxParams{
+					UpdateUserParams: db.UpdateUserParams{
+						Username: user.Username,
+						Email: pgtype.Text{
+							String: newEmail,
+							Valid:  true,
+						},
+						IsEmailVerified: pgtype.Bool{
+							Bool:  false,
+							Valid: true,
+						},
+						Version: pgtype.Int8{
+							Int64: user.Version,
+							Valid: true,
+						},
+					},
+					Jobs: []db.CreateJobParams{job},
+				}
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateUserTxResult{User: updatedUser}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, newETag(updatedUser.Version), recorder.Header().Get(etagHeaderKey))
+			},
+		},
+		{
+			name:    "Wildcard",
+			ifMatch: "*",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{User: updatedUser}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "PreconditionFailed",
+			ifMatch: newETag(user.Version + 1),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:    "ConcurrentUpdate",
+			ifMatch: newETag(user.Version),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name: "DeletedMeanwhile",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:           "PreconditionRequired",
+			requireIfMatch: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.RequireIfMatch = tc.requireIfMatch
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"email": newEmail})
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/users/%s", user.Username)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(data))
+			require.NoError(t, err)
+
+			if len(tc.ifMatch) > 0 {
+				request.Header.Set(ifMatchHeaderKey, tc.ifMatch)
+			}
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
 func randomUser(t *testing.T) (user db.User, password string) {
-	password = util.RandomString(6)
+	password = util.RandomString(8)
 	hashedPassword, err := util.HashPassword(password)
 	require.NoError(t, err)
 
@@ -504,10 +902,21 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		FullName:       util.RandomOwner(),
 		Email:          util.RandomEmail(),
 		CreatedAt:      time.Now(),
+		Version:        util.RandomInt(1, 10),
 	}
 	return
 }
 
+// setRole gives a user of the store a new role, which the tokens issued with it must match
+func setRole(t *testing.T, store db.Store, user db.User, role string) db.User {
+	user, err := store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username: user.Username,
+		Role:     pgtype.Text{String: role, Valid: true},
+	})
+	require.NoError(t, err)
+	return user
+}
+
 func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	data, err := io.ReadAll(body)
 	require.NoError(t, err)
@@ -12,3 +12,75 @@ var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 
 	return false
 }
+
+var validAccountStatus validator.Func = func(fl validator.FieldLevel) bool {
+	if status, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedAccountStatus(status)
+	}
+
+	return false
+}
+
+var validRole validator.Func = func(fl validator.FieldLevel) bool {
+	if role, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedRole(role)
+	}
+
+	return false
+}
+
+var validMemberPermission validator.Func = func(fl validator.FieldLevel) bool {
+	if permission, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedMemberPermission(permission)
+	}
+
+	return false
+}
+
+var validGrantScope validator.Func = func(fl validator.FieldLevel) bool {
+	if scope, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedGrantScope(scope)
+	}
+
+	return false
+}
+
+var validMoneyRequestStatus validator.Func = func(fl validator.FieldLevel) bool {
+	if status, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedMoneyRequestStatus(status)
+	}
+
+	return false
+}
+
+var validApprovalStatus validator.Func = func(fl validator.FieldLevel) bool {
+	if status, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedApprovalStatus(status)
+	}
+
+	return false
+}
+
+var validRiskOutcome validator.Func = func(fl validator.FieldLevel) bool {
+	if outcome, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedRiskOutcome(outcome)
+	}
+
+	return false
+}
+
+var validScreeningHitStatus validator.Func = func(fl validator.FieldLevel) bool {
+	if status, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedScreeningHitStatus(status)
+	}
+
+	return false
+}
+
+var validLockoutScope validator.Func = func(fl validator.FieldLevel) bool {
+	if scope, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedLockoutScope(scope)
+	}
+
+	return false
+}
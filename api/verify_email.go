@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/token"
+	"github.com/foyez/simplebank/util"
+	"github.com/foyez/simplebank/worker"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// errInvalidVerifyEmail does not tell an unknown token from a used or expired one
+	errInvalidVerifyEmail = errors.New("verification link is invalid, used or expired")
+	errEmailNotVerified   = errors.New("email must be verified before sending money")
+)
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// verifyEmail consumes the token of a verification link. It is a POST rather than the link
+// itself, so that mail scanners opening the link do not use the token up.
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, util.HashSecret(req.Token))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerifyEmail))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newUserResponse(result.User)
+	setAuditChange(ctx, "users", result.User.Username, newUserResponse(result.OldUser), rsp)
+	setETag(ctx, result.User.Version)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type resendVerifyEmailRequest struct {
+	Username string `uri:"username" binding:"required"`
+}
+
+// resendVerifyEmail sends a new verification link, for users whose link expired or got lost
+func (server *Server) resendVerifyEmail(ctx *gin.Context) {
+	var req resendVerifyEmailRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		err := errors.New("email is already verified")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	arg, err := worker.NewVerifyEmailJob(user.Username, user.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	job, err := server.store.CreateJob(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	setAuditChange(ctx, "jobs", strconv.FormatInt(job.ID, 10), nil, job)
+
+	ctx.JSON(http.StatusAccepted, newUserResponse(user))
+}
+
+// verifiedForTransfer refuses transfers from users who have not verified their email yet,
+// when the server requires it
+func (server *Server) verifiedForTransfer(ctx *gin.Context, authPayload *token.Payload) bool {
+	if !server.config.RequireVerifiedEmail {
+		return true
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if !user.IsEmailVerified {
+		ctx.JSON(http.StatusForbidden, errorResponse(errEmailNotVerified))
+		return false
+	}
+	return true
+}
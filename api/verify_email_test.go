@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/foyez/simplebank/worker"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer keeps the content of the emails it sends
+type recordingMailer struct {
+	contents []string
+}
+
+func (mailer *recordingMailer) SendEmail(to []string, subject string, content string) error {
+	mailer.contents = append(mailer.contents, content)
+	return nil
+}
+
+func TestVerifyEmailWithMemoryStore(t *testing.T) {
+	store := memorydb.NewStore()
+	server := newTestServer(t, store)
+	server.config.RequireVerifiedEmail = true
+
+	mailer := &recordingMailer{}
+	jobWorker, err := worker.NewWorker(store, mailer, util.Config{
+		VerifyEmailURL:   "http://localhost:3000/verify_email",
+		ResetPasswordURL: "http://localhost:3000/reset_password",
+	})
+	require.NoError(t, err)
+	runJobs := func() {
+		for {
+			ran, err := jobWorker.RunNext(context.Background())
+			require.NoError(t, err)
+			if !ran {
+				return
+			}
+		}
+	}
+	lastToken := func() string {
+		require.NotEmpty(t, mailer.contents)
+		link, err := url.Parse(regexp.MustCompile(`http://\S+`).FindString(mailer.contents[len(mailer.contents)-1]))
+		require.NoError(t, err)
+		return link.Query().Get("token")
+	}
+
+	send := func(method string, url string, user *db.User, body gin.H) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, &data)
+		require.NoError(t, err)
+		if user != nil {
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+		}
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	verify := func(token string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/verify_email", nil, gin.H{"token": token})
+	}
+
+	recorder := send(http.MethodPost, "/users", nil, gin.H{
+		"username":  util.RandomOwner(),
+		"password":  util.RandomString(8),
+		"full_name": util.RandomOwner(),
+		"email":     util.RandomEmail(),
+	})
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	var rsp userResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.False(t, rsp.IsEmailVerified)
+	user, err := store.GetUser(context.Background(), rsp.Username)
+	require.NoError(t, err)
+
+	recipient, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(60),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	var accounts []db.Account
+	for _, owner := range []string{user.Username, recipient.Username} {
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    owner,
+			Balance:  100,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+	transfer := func() *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/transfers", &user, gin.H{
+			"from_account_id": accounts[0].ID,
+			"to_account_id":   accounts[1].ID,
+			"amount":          10,
+			"currency":        util.USD,
+		})
+	}
+
+	recorder = transfer()
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Contains(t, recorder.Body.String(), errEmailNotVerified.Error())
+
+	// the link is only sent once the worker runs the job enqueued with the user
+	require.Empty(t, mailer.contents)
+	runJobs()
+	require.Len(t, mailer.contents, 1)
+	firstToken := lastToken()
+
+	// a new link does not revoke the previous one
+	require.Equal(t, http.StatusAccepted, send(http.MethodPost, "/users/"+user.Username+"/verify_email", &user, nil).Code)
+	runJobs()
+	require.Len(t, mailer.contents, 2)
+
+	require.Equal(t, http.StatusBadRequest, verify(util.RandomString(43)).Code)
+
+	recorder = verify(firstToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.True(t, rsp.IsEmailVerified)
+
+	recorder = verify(firstToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code, "tokens are single-use")
+	require.Contains(t, recorder.Body.String(), errInvalidVerifyEmail.Error())
+
+	require.Equal(t, http.StatusOK, transfer().Code)
+	require.Equal(t, http.StatusConflict, send(http.MethodPost, "/users/"+user.Username+"/verify_email", &user, nil).Code)
+
+	// a new email needs verifying again, the link sent to the former one no longer works
+	recorder = send(http.MethodPatch, "/users/"+user.Username, &user, gin.H{"email": util.RandomEmail()})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.False(t, rsp.IsEmailVerified)
+	require.Equal(t, http.StatusForbidden, transfer().Code)
+
+	require.Equal(t, http.StatusBadRequest, verify(lastToken()).Code)
+	runJobs()
+	require.Len(t, mailer.contents, 3)
+	require.Equal(t, http.StatusOK, verify(lastToken()).Code)
+	require.Equal(t, http.StatusOK, transfer().Code)
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{
+		ResourceType: pgtype.Text{String: "users", Valid: true},
+		ResourceID:   pgtype.Text{String: user.Username, Valid: true},
+		Limit:        20,
+	})
+	require.NoError(t, err)
+	var verified int
+	for _, auditLog := range auditLogs {
+		if auditLog.Route == "/verify_email" && auditLog.StatusCode == http.StatusOK {
+			require.Contains(t, string(auditLog.Changes), `"is_email_verified":{"before":false,"after":true}`)
+			verified++
+		}
+	}
+	require.Equal(t, 2, verified)
+}
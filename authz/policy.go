@@ -0,0 +1,143 @@
+// Package authz decides which actions a role may take on which resources.
+//
+// A permission reads "resource:action:scope", e.g. "accounts:read:own". The "own" scope
+// only covers resources belonging to the user acting, while "any" covers every resource.
+package authz
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foyez/simplebank/util"
+)
+
+// Permission scopes
+const (
+	ScopeOwn = "own"
+	ScopeAny = "any"
+)
+
+// DefaultRolePermissions are the permissions of each role when none are configured
+var DefaultRolePermissions = map[string][]string{
+	util.DepositorRole: {
+		"users:read:own",
+		"users:update:own",
+		"accounts:create:own",
+		"accounts:read:own",
+		"accounts:delete:own",
+		"accounts:share:own",
+		"accounts:join:own",
+		"grants:create:own",
+		"grants:read:own",
+		"grants:revoke:own",
+		"payees:create:own",
+		"payees:read:own",
+		"payees:delete:own",
+		"payees:confirm:own",
+		"transfers:create:own",
+		"directory:read:own",
+		"money_requests:create:own",
+		"money_requests:read:own",
+		"money_requests:respond:own",
+		"approvals:read:own",
+	},
+	util.BankerRole: {
+		"users:read:any",
+		"users:update:any",
+		"users:manage:any",
+		"accounts:create:own",
+		"accounts:read:any",
+		"accounts:adjust:any",
+		"accounts:delete:any",
+		"accounts:share:own",
+		"accounts:join:own",
+		"grants:create:own",
+		"grants:read:own",
+		"grants:revoke:any",
+		"payees:create:own",
+		"payees:read:own",
+		"payees:delete:own",
+		"payees:confirm:own",
+		"transfers:create:own",
+		"directory:read:own",
+		"money_requests:create:own",
+		"money_requests:read:own",
+		"money_requests:respond:own",
+		"approvals:read:any",
+		"approvals:review:any",
+		"risk_decisions:read:any",
+		"screening:read:any",
+		"screening:review:any",
+		"audit:read:any",
+		"debug:read:any",
+	},
+}
+
+// Policy holds the permissions granted to each role
+type Policy struct {
+	permissions map[string]map[string]bool
+}
+
+// NewPolicy creates a new Policy from the permissions of each role
+func NewPolicy(rolePermissions map[string][]string) (*Policy, error) {
+	policy := &Policy{
+		permissions: make(map[string]map[string]bool),
+	}
+
+	for role, permissions := range rolePermissions {
+		granted := make(map[string]bool)
+		for _, permission := range permissions {
+			fields := strings.Split(permission, ":")
+			if len(fields) != 3 || fields[0] == "" || fields[1] == "" {
+				return nil, fmt.Errorf("invalid permission %q of role %s", permission, role)
+			}
+			if fields[2] != ScopeOwn && fields[2] != ScopeAny {
+				return nil, fmt.Errorf("invalid scope of permission %q of role %s", permission, role)
+			}
+			granted[permission] = true
+		}
+		policy.permissions[role] = granted
+	}
+
+	return policy, nil
+}
+
+// ParseRolePermissions reads the permissions of each role from a spec such as
+// "depositor=accounts:read:own accounts:create:own;banker=accounts:read:any"
+func ParseRolePermissions(spec string) (map[string][]string, error) {
+	rolePermissions := make(map[string][]string)
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		role, permissions, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid role permissions %q", entry)
+		}
+		if _, ok := rolePermissions[role]; ok {
+			return nil, fmt.Errorf("duplicate permissions of role %s", role)
+		}
+
+		rolePermissions[role] = strings.Fields(permissions)
+	}
+
+	return rolePermissions, nil
+}
+
+// Allows reports whether the role may take the action on a resource,
+// given whether that resource belongs to the user acting
+func (policy *Policy) Allows(role string, resource string, action string, owned bool) bool {
+	granted := policy.permissions[role]
+	if granted[permission(resource, action, ScopeAny)] {
+		return true
+	}
+	return owned && granted[permission(resource, action, ScopeOwn)]
+}
+
+func permission(resource string, action string, scope string) string {
+	return resource + ":" + action + ":" + scope
+}
@@ -0,0 +1,70 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	policy, err := NewPolicy(map[string][]string{
+		util.DepositorRole: {"accounts:read:own"},
+		util.BankerRole:    {"accounts:read:any", "accounts:adjust:any"},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		role     string
+		action   string
+		owned    bool
+		expected bool
+	}{
+		{"OwnScopeOwned", util.DepositorRole, "read", true, true},
+		{"OwnScopeNotOwned", util.DepositorRole, "read", false, false},
+		{"AnyScope", util.BankerRole, "read", false, true},
+		{"MissingAction", util.DepositorRole, "adjust", true, false},
+		{"UnknownRole", "auditor", "read", true, false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, policy.Allows(tc.role, "accounts", tc.action, tc.owned))
+		})
+	}
+}
+
+func TestNewPolicyInvalidPermission(t *testing.T) {
+	for _, permission := range []string{"accounts:read", "accounts::own", "accounts:read:all"} {
+		_, err := NewPolicy(map[string][]string{util.DepositorRole: {permission}})
+		require.Error(t, err, permission)
+	}
+}
+
+func TestParseRolePermissions(t *testing.T) {
+	rolePermissions, err := ParseRolePermissions(" depositor=accounts:read:own  accounts:create:own ; banker=accounts:read:any;")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		util.DepositorRole: {"accounts:read:own", "accounts:create:own"},
+		util.BankerRole:    {"accounts:read:any"},
+	}, rolePermissions)
+
+	_, err = ParseRolePermissions("depositor")
+	require.Error(t, err)
+
+	_, err = ParseRolePermissions("banker=audit:read:any;banker=debug:read:any")
+	require.Error(t, err)
+}
+
+func TestDefaultRolePermissions(t *testing.T) {
+	policy, err := NewPolicy(DefaultRolePermissions)
+	require.NoError(t, err)
+
+	require.True(t, policy.Allows(util.DepositorRole, "accounts", "read", true))
+	require.False(t, policy.Allows(util.DepositorRole, "accounts", "read", false))
+	require.False(t, policy.Allows(util.DepositorRole, "accounts", "adjust", true))
+	require.True(t, policy.Allows(util.BankerRole, "accounts", "adjust", false))
+}
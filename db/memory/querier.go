@@ -0,0 +1,772 @@
+package memorydb
+
+import (
+	"context"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (store *Store) AcceptAccountMember(ctx context.Context, arg db.AcceptAccountMemberParams) (db.AccountMember, error) {
+	return run(store, func(q *queries) (db.AccountMember, error) {
+		return q.AcceptAccountMember(ctx, arg)
+	})
+}
+
+func (store *Store) AcceptMoneyRequest(ctx context.Context, arg db.AcceptMoneyRequestParams) (db.MoneyRequest, error) {
+	return run(store, func(q *queries) (db.MoneyRequest, error) {
+		return q.AcceptMoneyRequest(ctx, arg)
+	})
+}
+
+func (store *Store) AddAccountBalance(ctx context.Context, arg db.AddAccountBalanceParams) (db.Account, error) {
+	return run(store, func(q *queries) (db.Account, error) {
+		return q.AddAccountBalance(ctx, arg)
+	})
+}
+
+func (store *Store) BlockUserSessions(ctx context.Context, username string) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.BlockUserSessions(ctx, username)
+	})
+}
+
+func (store *Store) ClaimJob(ctx context.Context, lockedUntil pgtype.Timestamptz) (db.Job, error) {
+	return run(store, func(q *queries) (db.Job, error) {
+		return q.ClaimJob(ctx, lockedUntil)
+	})
+}
+
+func (store *Store) CompleteJob(ctx context.Context, arg db.CompleteJobParams) (db.Job, error) {
+	return run(store, func(q *queries) (db.Job, error) {
+		return q.CompleteJob(ctx, arg)
+	})
+}
+
+func (store *Store) CountArchivedEntries(ctx context.Context, accountID int64) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.CountArchivedEntries(ctx, accountID)
+	})
+}
+
+func (store *Store) CountArchivedTransfers(ctx context.Context, arg db.CountArchivedTransfersParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.CountArchivedTransfers(ctx, arg)
+	})
+}
+
+func (store *Store) CountBlockingScreeningHits(ctx context.Context, usernames []string) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.CountBlockingScreeningHits(ctx, usernames)
+	})
+}
+
+func (store *Store) CountRecoveryCodes(ctx context.Context, username string) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.CountRecoveryCodes(ctx, username)
+	})
+}
+
+func (store *Store) CountRiskDecisionsFromIP(ctx context.Context, arg db.CountRiskDecisionsFromIPParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.CountRiskDecisionsFromIP(ctx, arg)
+	})
+}
+
+func (store *Store) CountTransfersBetween(ctx context.Context, arg db.CountTransfersBetweenParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.CountTransfersBetween(ctx, arg)
+	})
+}
+
+func (store *Store) CreateAccessGrant(ctx context.Context, arg db.CreateAccessGrantParams) (db.AccessGrant, error) {
+	return run(store, func(q *queries) (db.AccessGrant, error) {
+		return q.CreateAccessGrant(ctx, arg)
+	})
+}
+
+func (store *Store) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	return run(store, func(q *queries) (db.Account, error) {
+		return q.CreateAccount(ctx, arg)
+	})
+}
+
+func (store *Store) CreateAccountMember(ctx context.Context, arg db.CreateAccountMemberParams) (db.AccountMember, error) {
+	return run(store, func(q *queries) (db.AccountMember, error) {
+		return q.CreateAccountMember(ctx, arg)
+	})
+}
+
+func (store *Store) CreateApproval(ctx context.Context, arg db.CreateApprovalParams) (db.Approval, error) {
+	return run(store, func(q *queries) (db.Approval, error) {
+		return q.CreateApproval(ctx, arg)
+	})
+}
+
+func (store *Store) CreateArchivedPartition(ctx context.Context, arg db.CreateArchivedPartitionParams) (db.ArchivedPartition, error) {
+	return run(store, func(q *queries) (db.ArchivedPartition, error) {
+		return q.CreateArchivedPartition(ctx, arg)
+	})
+}
+
+func (store *Store) CreateAuditLog(ctx context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+	return run(store, func(q *queries) (db.AuditLog, error) {
+		return q.CreateAuditLog(ctx, arg)
+	})
+}
+
+func (store *Store) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+	return run(store, func(q *queries) (db.Entry, error) {
+		return q.CreateEntry(ctx, arg)
+	})
+}
+
+func (store *Store) CreateJob(ctx context.Context, arg db.CreateJobParams) (db.Job, error) {
+	return run(store, func(q *queries) (db.Job, error) {
+		return q.CreateJob(ctx, arg)
+	})
+}
+
+func (store *Store) CreateLoginChallenge(ctx context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+	return run(store, func(q *queries) (db.LoginChallenge, error) {
+		return q.CreateLoginChallenge(ctx, arg)
+	})
+}
+
+func (store *Store) CreateMoneyRequest(ctx context.Context, arg db.CreateMoneyRequestParams) (db.MoneyRequest, error) {
+	return run(store, func(q *queries) (db.MoneyRequest, error) {
+		return q.CreateMoneyRequest(ctx, arg)
+	})
+}
+
+func (store *Store) CreatePayee(ctx context.Context, arg db.CreatePayeeParams) (db.Payee, error) {
+	return run(store, func(q *queries) (db.Payee, error) {
+		return q.CreatePayee(ctx, arg)
+	})
+}
+
+func (store *Store) CreateRecoveryCode(ctx context.Context, arg db.CreateRecoveryCodeParams) (db.RecoveryCode, error) {
+	return run(store, func(q *queries) (db.RecoveryCode, error) {
+		return q.CreateRecoveryCode(ctx, arg)
+	})
+}
+
+func (store *Store) CreateResetPassword(ctx context.Context, arg db.CreateResetPasswordParams) (db.ResetPassword, error) {
+	return run(store, func(q *queries) (db.ResetPassword, error) {
+		return q.CreateResetPassword(ctx, arg)
+	})
+}
+
+func (store *Store) CreateRiskDecision(ctx context.Context, arg db.CreateRiskDecisionParams) (db.RiskDecision, error) {
+	return run(store, func(q *queries) (db.RiskDecision, error) {
+		return q.CreateRiskDecision(ctx, arg)
+	})
+}
+
+func (store *Store) CreateScreeningHit(ctx context.Context, arg db.CreateScreeningHitParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.CreateScreeningHit(ctx, arg)
+	})
+}
+
+func (store *Store) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	return run(store, func(q *queries) (db.Session, error) {
+		return q.CreateSession(ctx, arg)
+	})
+}
+
+func (store *Store) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+	return run(store, func(q *queries) (db.Transfer, error) {
+		return q.CreateTransfer(ctx, arg)
+	})
+}
+
+func (store *Store) CreateTwoFactor(ctx context.Context, arg db.CreateTwoFactorParams) (db.TwoFactor, error) {
+	return run(store, func(q *queries) (db.TwoFactor, error) {
+		return q.CreateTwoFactor(ctx, arg)
+	})
+}
+
+func (store *Store) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	return run(store, func(q *queries) (db.User, error) {
+		return q.CreateUser(ctx, arg)
+	})
+}
+
+func (store *Store) CreateVerifyEmail(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+	return run(store, func(q *queries) (db.VerifyEmail, error) {
+		return q.CreateVerifyEmail(ctx, arg)
+	})
+}
+
+func (store *Store) DeleteAccount(ctx context.Context, arg db.DeleteAccountParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeleteAccount(ctx, arg)
+	})
+}
+
+func (store *Store) DeleteAccountMember(ctx context.Context, arg db.DeleteAccountMemberParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeleteAccountMember(ctx, arg)
+	})
+}
+
+func (store *Store) DeleteLoginLockout(ctx context.Context, arg db.DeleteLoginLockoutParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeleteLoginLockout(ctx, arg)
+	})
+}
+
+func (store *Store) DeletePayee(ctx context.Context, arg db.DeletePayeeParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeletePayee(ctx, arg)
+	})
+}
+
+func (store *Store) DeleteRecoveryCodes(ctx context.Context, username string) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeleteRecoveryCodes(ctx, username)
+	})
+}
+
+func (store *Store) DeleteStaleLoginLockouts(ctx context.Context, failedBefore time.Time) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeleteStaleLoginLockouts(ctx, failedBefore)
+	})
+}
+
+func (store *Store) DeleteStaleRateLimits(ctx context.Context, startedBefore time.Time) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeleteStaleRateLimits(ctx, startedBefore)
+	})
+}
+
+func (store *Store) DeleteTwoFactor(ctx context.Context, username string) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.DeleteTwoFactor(ctx, username)
+	})
+}
+
+func (store *Store) EnableTwoFactor(ctx context.Context, arg db.EnableTwoFactorParams) (db.TwoFactor, error) {
+	return run(store, func(q *queries) (db.TwoFactor, error) {
+		return q.EnableTwoFactor(ctx, arg)
+	})
+}
+
+func (store *Store) ExpireMoneyRequests(ctx context.Context) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.ExpireMoneyRequests(ctx)
+	})
+}
+
+func (store *Store) FailLoginChallenge(ctx context.Context, id int64) (db.LoginChallenge, error) {
+	return run(store, func(q *queries) (db.LoginChallenge, error) {
+		return q.FailLoginChallenge(ctx, id)
+	})
+}
+
+func (store *Store) FailJob(ctx context.Context, arg db.FailJobParams) (db.Job, error) {
+	return run(store, func(q *queries) (db.Job, error) {
+		return q.FailJob(ctx, arg)
+	})
+}
+
+func (store *Store) GetAccessGrant(ctx context.Context, id int64) (db.AccessGrant, error) {
+	return run(store, func(q *queries) (db.AccessGrant, error) {
+		return q.GetAccessGrant(ctx, id)
+	})
+}
+
+func (store *Store) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	return run(store, func(q *queries) (db.Account, error) {
+		return q.GetAccount(ctx, id)
+	})
+}
+
+func (store *Store) GetAccountByOwnerCurrency(ctx context.Context, arg db.GetAccountByOwnerCurrencyParams) (db.Account, error) {
+	return run(store, func(q *queries) (db.Account, error) {
+		return q.GetAccountByOwnerCurrency(ctx, arg)
+	})
+}
+
+func (store *Store) GetAccountForUpdate(ctx context.Context, id int64) (db.Account, error) {
+	return run(store, func(q *queries) (db.Account, error) {
+		return q.GetAccountForUpdate(ctx, id)
+	})
+}
+
+func (store *Store) GetAccountMember(ctx context.Context, arg db.GetAccountMemberParams) (db.AccountMember, error) {
+	return run(store, func(q *queries) (db.AccountMember, error) {
+		return q.GetAccountMember(ctx, arg)
+	})
+}
+
+func (store *Store) GetActiveAccessGrant(ctx context.Context, arg db.GetActiveAccessGrantParams) (db.AccessGrant, error) {
+	return run(store, func(q *queries) (db.AccessGrant, error) {
+		return q.GetActiveAccessGrant(ctx, arg)
+	})
+}
+
+func (store *Store) GetApproval(ctx context.Context, id int64) (db.Approval, error) {
+	return run(store, func(q *queries) (db.Approval, error) {
+		return q.GetApproval(ctx, id)
+	})
+}
+
+func (store *Store) GetArchivedEntry(ctx context.Context, id int64) (db.Entry, error) {
+	return run(store, func(q *queries) (db.Entry, error) {
+		return q.GetArchivedEntry(ctx, id)
+	})
+}
+
+func (store *Store) GetArchivedTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	return run(store, func(q *queries) (db.Transfer, error) {
+		return q.GetArchivedTransfer(ctx, id)
+	})
+}
+
+func (store *Store) GetClientIPHistory(ctx context.Context, arg db.GetClientIPHistoryParams) (db.GetClientIPHistoryRow, error) {
+	return run(store, func(q *queries) (db.GetClientIPHistoryRow, error) {
+		return q.GetClientIPHistory(ctx, arg)
+	})
+}
+
+func (store *Store) GetDiscoverableUser(ctx context.Context, alias string) (db.User, error) {
+	return run(store, func(q *queries) (db.User, error) {
+		return q.GetDiscoverableUser(ctx, alias)
+	})
+}
+
+func (store *Store) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+	return run(store, func(q *queries) (db.Entry, error) {
+		return q.GetEntry(ctx, id)
+	})
+}
+
+func (store *Store) GetJob(ctx context.Context, id int64) (db.Job, error) {
+	return run(store, func(q *queries) (db.Job, error) {
+		return q.GetJob(ctx, id)
+	})
+}
+
+func (store *Store) GetLoginChallenge(ctx context.Context, tokenHash string) (db.LoginChallenge, error) {
+	return run(store, func(q *queries) (db.LoginChallenge, error) {
+		return q.GetLoginChallenge(ctx, tokenHash)
+	})
+}
+
+func (store *Store) GetLoginLockout(ctx context.Context, arg db.GetLoginLockoutParams) (db.LoginLockout, error) {
+	return run(store, func(q *queries) (db.LoginLockout, error) {
+		return q.GetLoginLockout(ctx, arg)
+	})
+}
+
+func (store *Store) GetMoneyRequest(ctx context.Context, id int64) (db.MoneyRequest, error) {
+	return run(store, func(q *queries) (db.MoneyRequest, error) {
+		return q.GetMoneyRequest(ctx, id)
+	})
+}
+
+func (store *Store) GetPayee(ctx context.Context, id int64) (db.Payee, error) {
+	return run(store, func(q *queries) (db.Payee, error) {
+		return q.GetPayee(ctx, id)
+	})
+}
+
+func (store *Store) GetScreeningHit(ctx context.Context, id int64) (db.ScreeningHit, error) {
+	return run(store, func(q *queries) (db.ScreeningHit, error) {
+		return q.GetScreeningHit(ctx, id)
+	})
+}
+
+func (store *Store) GetSession(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	return run(store, func(q *queries) (db.Session, error) {
+		return q.GetSession(ctx, id)
+	})
+}
+
+func (store *Store) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	return run(store, func(q *queries) (db.Transfer, error) {
+		return q.GetTransfer(ctx, id)
+	})
+}
+
+func (store *Store) GetTwoFactor(ctx context.Context, username string) (db.TwoFactor, error) {
+	return run(store, func(q *queries) (db.TwoFactor, error) {
+		return q.GetTwoFactor(ctx, username)
+	})
+}
+
+func (store *Store) GetUser(ctx context.Context, username string) (db.User, error) {
+	return run(store, func(q *queries) (db.User, error) {
+		return q.GetUser(ctx, username)
+	})
+}
+
+func (store *Store) GetUserAccess(ctx context.Context, username string) (db.GetUserAccessRow, error) {
+	return run(store, func(q *queries) (db.GetUserAccessRow, error) {
+		return q.GetUserAccess(ctx, username)
+	})
+}
+
+func (store *Store) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	return run(store, func(q *queries) (db.User, error) {
+		return q.GetUserByEmail(ctx, email)
+	})
+}
+
+func (store *Store) HitRateLimit(ctx context.Context, arg db.HitRateLimitParams) (db.RateLimit, error) {
+	return run(store, func(q *queries) (db.RateLimit, error) {
+		return q.HitRateLimit(ctx, arg)
+	})
+}
+
+func (store *Store) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	return run(store, func(q *queries) ([]db.Account, error) {
+		return q.ListAccounts(ctx, arg)
+	})
+}
+
+func (store *Store) ListAccountsAfter(ctx context.Context, arg db.ListAccountsAfterParams) ([]db.Account, error) {
+	return run(store, func(q *queries) ([]db.Account, error) {
+		return q.ListAccountsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListAccountsBefore(ctx context.Context, arg db.ListAccountsBeforeParams) ([]db.Account, error) {
+	return run(store, func(q *queries) ([]db.Account, error) {
+		return q.ListAccountsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListAccessGrants(ctx context.Context, username string) ([]db.AccessGrant, error) {
+	return run(store, func(q *queries) ([]db.AccessGrant, error) {
+		return q.ListAccessGrants(ctx, username)
+	})
+}
+
+func (store *Store) ListAccountInvitations(ctx context.Context, username string) ([]db.AccountMember, error) {
+	return run(store, func(q *queries) ([]db.AccountMember, error) {
+		return q.ListAccountInvitations(ctx, username)
+	})
+}
+
+func (store *Store) ListAccountMembers(ctx context.Context, accountID int64) ([]db.AccountMember, error) {
+	return run(store, func(q *queries) ([]db.AccountMember, error) {
+		return q.ListAccountMembers(ctx, accountID)
+	})
+}
+
+func (store *Store) ListApprovalsAfter(ctx context.Context, arg db.ListApprovalsAfterParams) ([]db.Approval, error) {
+	return run(store, func(q *queries) ([]db.Approval, error) {
+		return q.ListApprovalsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListApprovalsBefore(ctx context.Context, arg db.ListApprovalsBeforeParams) ([]db.Approval, error) {
+	return run(store, func(q *queries) ([]db.Approval, error) {
+		return q.ListApprovalsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListArchivedEntries(ctx context.Context, arg db.ListArchivedEntriesParams) ([]db.Entry, error) {
+	return run(store, func(q *queries) ([]db.Entry, error) {
+		return q.ListArchivedEntries(ctx, arg)
+	})
+}
+
+func (store *Store) ListArchivedEntriesAfter(ctx context.Context, arg db.ListArchivedEntriesAfterParams) ([]db.Entry, error) {
+	return run(store, func(q *queries) ([]db.Entry, error) {
+		return q.ListArchivedEntriesAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListArchivedEntriesBefore(ctx context.Context, arg db.ListArchivedEntriesBeforeParams) ([]db.Entry, error) {
+	return run(store, func(q *queries) ([]db.Entry, error) {
+		return q.ListArchivedEntriesBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListArchivedPartitions(ctx context.Context, parentTable string) ([]db.ArchivedPartition, error) {
+	return run(store, func(q *queries) ([]db.ArchivedPartition, error) {
+		return q.ListArchivedPartitions(ctx, parentTable)
+	})
+}
+
+func (store *Store) ListArchivedTransfers(ctx context.Context, arg db.ListArchivedTransfersParams) ([]db.Transfer, error) {
+	return run(store, func(q *queries) ([]db.Transfer, error) {
+		return q.ListArchivedTransfers(ctx, arg)
+	})
+}
+
+func (store *Store) ListArchivedTransfersAfter(ctx context.Context, arg db.ListArchivedTransfersAfterParams) ([]db.Transfer, error) {
+	return run(store, func(q *queries) ([]db.Transfer, error) {
+		return q.ListArchivedTransfersAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListArchivedTransfersBefore(ctx context.Context, arg db.ListArchivedTransfersBeforeParams) ([]db.Transfer, error) {
+	return run(store, func(q *queries) ([]db.Transfer, error) {
+		return q.ListArchivedTransfersBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListAuditLogsAfter(ctx context.Context, arg db.ListAuditLogsAfterParams) ([]db.AuditLog, error) {
+	return run(store, func(q *queries) ([]db.AuditLog, error) {
+		return q.ListAuditLogsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListAuditLogsBefore(ctx context.Context, arg db.ListAuditLogsBeforeParams) ([]db.AuditLog, error) {
+	return run(store, func(q *queries) ([]db.AuditLog, error) {
+		return q.ListAuditLogsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+	return run(store, func(q *queries) ([]db.Entry, error) {
+		return q.ListEntries(ctx, arg)
+	})
+}
+
+func (store *Store) ListEntriesAfter(ctx context.Context, arg db.ListEntriesAfterParams) ([]db.Entry, error) {
+	return run(store, func(q *queries) ([]db.Entry, error) {
+		return q.ListEntriesAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListEntriesBefore(ctx context.Context, arg db.ListEntriesBeforeParams) ([]db.Entry, error) {
+	return run(store, func(q *queries) ([]db.Entry, error) {
+		return q.ListEntriesBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListIncomingMoneyRequestsAfter(ctx context.Context, arg db.ListIncomingMoneyRequestsAfterParams) ([]db.MoneyRequest, error) {
+	return run(store, func(q *queries) ([]db.MoneyRequest, error) {
+		return q.ListIncomingMoneyRequestsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListIncomingMoneyRequestsBefore(ctx context.Context, arg db.ListIncomingMoneyRequestsBeforeParams) ([]db.MoneyRequest, error) {
+	return run(store, func(q *queries) ([]db.MoneyRequest, error) {
+		return q.ListIncomingMoneyRequestsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListLoginLockoutsAfter(ctx context.Context, arg db.ListLoginLockoutsAfterParams) ([]db.LoginLockout, error) {
+	return run(store, func(q *queries) ([]db.LoginLockout, error) {
+		return q.ListLoginLockoutsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListLoginLockoutsBefore(ctx context.Context, arg db.ListLoginLockoutsBeforeParams) ([]db.LoginLockout, error) {
+	return run(store, func(q *queries) ([]db.LoginLockout, error) {
+		return q.ListLoginLockoutsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListOutgoingMoneyRequestsAfter(ctx context.Context, arg db.ListOutgoingMoneyRequestsAfterParams) ([]db.MoneyRequest, error) {
+	return run(store, func(q *queries) ([]db.MoneyRequest, error) {
+		return q.ListOutgoingMoneyRequestsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListOutgoingMoneyRequestsBefore(ctx context.Context, arg db.ListOutgoingMoneyRequestsBeforeParams) ([]db.MoneyRequest, error) {
+	return run(store, func(q *queries) ([]db.MoneyRequest, error) {
+		return q.ListOutgoingMoneyRequestsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListPayees(ctx context.Context, owner string) ([]db.Payee, error) {
+	return run(store, func(q *queries) ([]db.Payee, error) {
+		return q.ListPayees(ctx, owner)
+	})
+}
+
+func (store *Store) ListRiskDecisionsAfter(ctx context.Context, arg db.ListRiskDecisionsAfterParams) ([]db.RiskDecision, error) {
+	return run(store, func(q *queries) ([]db.RiskDecision, error) {
+		return q.ListRiskDecisionsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListRiskDecisionsBefore(ctx context.Context, arg db.ListRiskDecisionsBeforeParams) ([]db.RiskDecision, error) {
+	return run(store, func(q *queries) ([]db.RiskDecision, error) {
+		return q.ListRiskDecisionsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListScreeningHitsAfter(ctx context.Context, arg db.ListScreeningHitsAfterParams) ([]db.ScreeningHit, error) {
+	return run(store, func(q *queries) ([]db.ScreeningHit, error) {
+		return q.ListScreeningHitsAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListScreeningHitsBefore(ctx context.Context, arg db.ListScreeningHitsBeforeParams) ([]db.ScreeningHit, error) {
+	return run(store, func(q *queries) ([]db.ScreeningHit, error) {
+		return q.ListScreeningHitsBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+	return run(store, func(q *queries) ([]db.Transfer, error) {
+		return q.ListTransfers(ctx, arg)
+	})
+}
+
+func (store *Store) ListTransfersAfter(ctx context.Context, arg db.ListTransfersAfterParams) ([]db.Transfer, error) {
+	return run(store, func(q *queries) ([]db.Transfer, error) {
+		return q.ListTransfersAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListTransfersBefore(ctx context.Context, arg db.ListTransfersBeforeParams) ([]db.Transfer, error) {
+	return run(store, func(q *queries) ([]db.Transfer, error) {
+		return q.ListTransfersBefore(ctx, arg)
+	})
+}
+
+func (store *Store) ListTransfersSince(ctx context.Context, arg db.ListTransfersSinceParams) ([]db.Transfer, error) {
+	return run(store, func(q *queries) ([]db.Transfer, error) {
+		return q.ListTransfersSince(ctx, arg)
+	})
+}
+
+func (store *Store) ListUsersAfter(ctx context.Context, arg db.ListUsersAfterParams) ([]db.User, error) {
+	return run(store, func(q *queries) ([]db.User, error) {
+		return q.ListUsersAfter(ctx, arg)
+	})
+}
+
+func (store *Store) ListUsersBefore(ctx context.Context, arg db.ListUsersBeforeParams) ([]db.User, error) {
+	return run(store, func(q *queries) ([]db.User, error) {
+		return q.ListUsersBefore(ctx, arg)
+	})
+}
+
+func (store *Store) NotifyAccountEvent(ctx context.Context, arg db.NotifyAccountEventParams) error {
+	return store.execTx(func(q *queries) error {
+		return q.NotifyAccountEvent(ctx, arg)
+	})
+}
+
+func (store *Store) RefundLoginAttempt(ctx context.Context, arg db.RefundLoginAttemptParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.RefundLoginAttempt(ctx, arg)
+	})
+}
+
+func (store *Store) RehashUserPassword(ctx context.Context, arg db.RehashUserPasswordParams) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.RehashUserPassword(ctx, arg)
+	})
+}
+
+func (store *Store) RejectExpiredApprovals(ctx context.Context) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.RejectExpiredApprovals(ctx)
+	})
+}
+
+func (store *Store) ReserveLoginAttempt(ctx context.Context, arg db.ReserveLoginAttemptParams) (db.LoginLockout, error) {
+	return run(store, func(q *queries) (db.LoginLockout, error) {
+		return q.ReserveLoginAttempt(ctx, arg)
+	})
+}
+
+func (store *Store) ResolveApproval(ctx context.Context, arg db.ResolveApprovalParams) (db.Approval, error) {
+	return run(store, func(q *queries) (db.Approval, error) {
+		return q.ResolveApproval(ctx, arg)
+	})
+}
+
+func (store *Store) ResolveMoneyRequest(ctx context.Context, arg db.ResolveMoneyRequestParams) (db.MoneyRequest, error) {
+	return run(store, func(q *queries) (db.MoneyRequest, error) {
+		return q.ResolveMoneyRequest(ctx, arg)
+	})
+}
+
+func (store *Store) ResolveScreeningHit(ctx context.Context, arg db.ResolveScreeningHitParams) (db.ScreeningHit, error) {
+	return run(store, func(q *queries) (db.ScreeningHit, error) {
+		return q.ResolveScreeningHit(ctx, arg)
+	})
+}
+
+func (store *Store) RevokeAccessGrant(ctx context.Context, id int64) (db.AccessGrant, error) {
+	return run(store, func(q *queries) (db.AccessGrant, error) {
+		return q.RevokeAccessGrant(ctx, id)
+	})
+}
+
+func (store *Store) SearchAccounts(ctx context.Context, arg db.SearchAccountsParams) ([]db.Account, error) {
+	return run(store, func(q *queries) ([]db.Account, error) {
+		return q.SearchAccounts(ctx, arg)
+	})
+}
+
+func (store *Store) SearchUsers(ctx context.Context, arg db.SearchUsersParams) ([]db.User, error) {
+	return run(store, func(q *queries) ([]db.User, error) {
+		return q.SearchUsers(ctx, arg)
+	})
+}
+
+func (store *Store) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+	return run(store, func(q *queries) (db.Account, error) {
+		return q.UpdateAccount(ctx, arg)
+	})
+}
+
+func (store *Store) UpdateAccountMember(ctx context.Context, arg db.UpdateAccountMemberParams) (db.AccountMember, error) {
+	return run(store, func(q *queries) (db.AccountMember, error) {
+		return q.UpdateAccountMember(ctx, arg)
+	})
+}
+
+func (store *Store) UpdateAccountStatus(ctx context.Context, arg db.UpdateAccountStatusParams) (db.Account, error) {
+	return run(store, func(q *queries) (db.Account, error) {
+		return q.UpdateAccountStatus(ctx, arg)
+	})
+}
+
+func (store *Store) UpdateUser(ctx context.Context, arg db.UpdateUserParams) (db.User, error) {
+	return run(store, func(q *queries) (db.User, error) {
+		return q.UpdateUser(ctx, arg)
+	})
+}
+
+func (store *Store) UseLoginChallenge(ctx context.Context, id int64) (db.LoginChallenge, error) {
+	return run(store, func(q *queries) (db.LoginChallenge, error) {
+		return q.UseLoginChallenge(ctx, id)
+	})
+}
+
+func (store *Store) UseRecoveryCode(ctx context.Context, arg db.UseRecoveryCodeParams) (db.RecoveryCode, error) {
+	return run(store, func(q *queries) (db.RecoveryCode, error) {
+		return q.UseRecoveryCode(ctx, arg)
+	})
+}
+
+func (store *Store) UseResetPassword(ctx context.Context, tokenHash string) (db.ResetPassword, error) {
+	return run(store, func(q *queries) (db.ResetPassword, error) {
+		return q.UseResetPassword(ctx, tokenHash)
+	})
+}
+
+func (store *Store) UseTwoFactorStep(ctx context.Context, arg db.UseTwoFactorStepParams) (db.TwoFactor, error) {
+	return run(store, func(q *queries) (db.TwoFactor, error) {
+		return q.UseTwoFactorStep(ctx, arg)
+	})
+}
+
+func (store *Store) UseUserResetPasswords(ctx context.Context, username string) (int64, error) {
+	return run(store, func(q *queries) (int64, error) {
+		return q.UseUserResetPasswords(ctx, username)
+	})
+}
+
+func (store *Store) UseVerifyEmail(ctx context.Context, tokenHash string) (db.VerifyEmail, error) {
+	return run(store, func(q *queries) (db.VerifyEmail, error) {
+		return q.UseVerifyEmail(ctx, tokenHash)
+	})
+}
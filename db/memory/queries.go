@@ -0,0 +1,2309 @@
+package memorydb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// queries runs statements against the tables while recording how to undo them
+type queries struct {
+	tables *tables
+	undo   []func()
+	events []db.AccountEvent
+}
+
+var _ db.Querier = (*queries)(nil)
+
+// The memory store never detaches partitions, so its archive tables stay empty
+func (q *queries) CountArchivedEntries(ctx context.Context, accountID int64) (int64, error) {
+	return 0, nil
+}
+
+func (q *queries) CountArchivedTransfers(ctx context.Context, arg db.CountArchivedTransfersParams) (int64, error) {
+	return 0, nil
+}
+
+func (q *queries) GetArchivedEntry(ctx context.Context, id int64) (db.Entry, error) {
+	return db.Entry{}, db.ErrRecordNotFound
+}
+
+func (q *queries) GetArchivedTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	return db.Transfer{}, db.ErrRecordNotFound
+}
+
+func (q *queries) ListArchivedEntries(ctx context.Context, arg db.ListArchivedEntriesParams) ([]db.Entry, error) {
+	return []db.Entry{}, nil
+}
+
+func (q *queries) ListArchivedEntriesAfter(ctx context.Context, arg db.ListArchivedEntriesAfterParams) ([]db.Entry, error) {
+	return []db.Entry{}, nil
+}
+
+func (q *queries) ListArchivedEntriesBefore(ctx context.Context, arg db.ListArchivedEntriesBeforeParams) ([]db.Entry, error) {
+	return []db.Entry{}, nil
+}
+
+func (q *queries) ListArchivedTransfers(ctx context.Context, arg db.ListArchivedTransfersParams) ([]db.Transfer, error) {
+	return []db.Transfer{}, nil
+}
+
+func (q *queries) ListArchivedTransfersAfter(ctx context.Context, arg db.ListArchivedTransfersAfterParams) ([]db.Transfer, error) {
+	return []db.Transfer{}, nil
+}
+
+func (q *queries) ListArchivedTransfersBefore(ctx context.Context, arg db.ListArchivedTransfersBeforeParams) ([]db.Transfer, error) {
+	return []db.Transfer{}, nil
+}
+
+func (q *queries) onRollback(undo func()) {
+	q.undo = append(q.undo, undo)
+}
+
+func (q *queries) rollback() {
+	for i := len(q.undo) - 1; i >= 0; i-- {
+		q.undo[i]()
+	}
+	q.undo = nil
+	q.events = nil
+}
+
+func (q *queries) publish(event db.AccountEvent) {
+	q.events = append(q.events, event)
+}
+
+func (q *queries) putUser(user db.User) {
+	old, existed := q.tables.users[user.Username]
+	q.tables.users[user.Username] = user
+	q.onRollback(func() {
+		if existed {
+			q.tables.users[user.Username] = old
+		} else {
+			delete(q.tables.users, user.Username)
+		}
+	})
+}
+
+func (q *queries) putAccount(account db.Account) {
+	old, existed := q.tables.accounts[account.ID]
+	q.tables.accounts[account.ID] = account
+	q.onRollback(func() {
+		if existed {
+			q.tables.accounts[account.ID] = old
+		} else {
+			delete(q.tables.accounts, account.ID)
+		}
+	})
+}
+
+func (q *queries) putAccountMember(member db.AccountMember) {
+	key := accountMemberKey{member.AccountID, member.Username}
+	old, existed := q.tables.accountMembers[key]
+	q.tables.accountMembers[key] = member
+	q.onRollback(func() {
+		if existed {
+			q.tables.accountMembers[key] = old
+		} else {
+			delete(q.tables.accountMembers, key)
+		}
+	})
+}
+
+func (q *queries) deleteAccountMember(key accountMemberKey) bool {
+	member, ok := q.tables.accountMembers[key]
+	if !ok {
+		return false
+	}
+
+	delete(q.tables.accountMembers, key)
+	q.onRollback(func() {
+		q.tables.accountMembers[key] = member
+	})
+	return true
+}
+
+func (q *queries) AcceptAccountMember(ctx context.Context, arg db.AcceptAccountMemberParams) (db.AccountMember, error) {
+	member, ok := q.tables.accountMembers[accountMemberKey{arg.AccountID, arg.Username}]
+	if !ok || member.AcceptedAt.Valid {
+		return db.AccountMember{}, db.ErrRecordNotFound
+	}
+
+	member.AcceptedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	q.putAccountMember(member)
+	return member, nil
+}
+
+func (q *queries) putMoneyRequest(request db.MoneyRequest) {
+	old, existed := q.tables.moneyRequests[request.ID]
+	q.tables.moneyRequests[request.ID] = request
+	q.onRollback(func() {
+		if existed {
+			q.tables.moneyRequests[request.ID] = old
+		} else {
+			delete(q.tables.moneyRequests, request.ID)
+		}
+	})
+}
+
+// pendingMoneyRequest returns the request if it can still be accepted, declined or cancelled
+func (q *queries) pendingMoneyRequest(id int64) (db.MoneyRequest, bool) {
+	request, ok := q.tables.moneyRequests[id]
+	if !ok || request.Status != util.PendingRequest || !request.ExpiresAt.After(now()) {
+		return db.MoneyRequest{}, false
+	}
+	return request, true
+}
+
+func (q *queries) AcceptMoneyRequest(ctx context.Context, arg db.AcceptMoneyRequestParams) (db.MoneyRequest, error) {
+	request, ok := q.pendingMoneyRequest(arg.ID)
+	if !ok {
+		return db.MoneyRequest{}, db.ErrRecordNotFound
+	}
+
+	request.Status = util.AcceptedRequest
+	request.TransferID = arg.TransferID
+	request.ResolvedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	q.putMoneyRequest(request)
+	return request, nil
+}
+
+func (q *queries) AddAccountBalance(ctx context.Context, arg db.AddAccountBalanceParams) (db.Account, error) {
+	account, ok := q.tables.accounts[arg.ID]
+	if !ok {
+		return db.Account{}, db.ErrRecordNotFound
+	}
+
+	account.Balance += arg.Amount
+	account.Version++
+	q.putAccount(account)
+	return account, nil
+}
+
+func (q *queries) BlockUserSessions(ctx context.Context, username string) (int64, error) {
+	if user, ok := q.tables.users[username]; ok {
+		user.TokensValidAfter = now()
+		q.putUser(user)
+	}
+
+	var rows int64
+	for id, session := range q.tables.sessions {
+		if session.Username != username || session.IsBlocked || !session.ExpiresAt.After(now()) {
+			continue
+		}
+
+		old := session
+		session.IsBlocked = true
+		q.tables.sessions[id] = session
+		q.onRollback(func() {
+			q.tables.sessions[old.ID] = old
+		})
+		rows++
+	}
+	return rows, nil
+}
+
+func (q *queries) putJob(job db.Job) {
+	old, existed := q.tables.jobs[job.ID]
+	q.tables.jobs[job.ID] = job
+	q.onRollback(func() {
+		if existed {
+			q.tables.jobs[job.ID] = old
+		} else {
+			delete(q.tables.jobs, job.ID)
+		}
+	})
+}
+
+// runningJob returns the job if the attempt still holds it
+func (q *queries) runningJob(id int64, attempts int32) (db.Job, bool) {
+	job, ok := q.tables.jobs[id]
+	if !ok || job.Status != util.RunningJob || job.Attempts != attempts {
+		return db.Job{}, false
+	}
+	return job, true
+}
+
+func (q *queries) ClaimJob(ctx context.Context, lockedUntil pgtype.Timestamptz) (db.Job, error) {
+	current := now()
+	jobs := sortedValues(q.tables.jobs,
+		func(job db.Job) bool {
+			if job.Status == util.PendingJob {
+				return !job.RunAt.After(current)
+			}
+			return job.Status == util.RunningJob && job.LockedUntil.Valid && !job.LockedUntil.Time.After(current)
+		},
+		func(a, b db.Job) bool {
+			return keysetLess(a.RunAt, a.ID, b.RunAt, b.ID)
+		},
+	)
+	if len(jobs) == 0 {
+		return db.Job{}, db.ErrRecordNotFound
+	}
+
+	job := jobs[0]
+	job.Status = util.RunningJob
+	job.Attempts++
+	job.LockedUntil = lockedUntil
+	q.putJob(job)
+	return job, nil
+}
+
+func (q *queries) CompleteJob(ctx context.Context, arg db.CompleteJobParams) (db.Job, error) {
+	job, ok := q.runningJob(arg.ID, arg.Attempts)
+	if !ok {
+		return db.Job{}, db.ErrRecordNotFound
+	}
+
+	job.Status = util.DoneJob
+	job.LockedUntil = pgtype.Timestamptz{}
+	job.FinishedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	q.putJob(job)
+	return job, nil
+}
+
+func (q *queries) CountBlockingScreeningHits(ctx context.Context, usernames []string) (int64, error) {
+	var count int64
+	for _, hit := range q.tables.screeningHits {
+		if hit.Status == util.ClearedHit {
+			continue
+		}
+		for _, username := range usernames {
+			if hit.Username == username {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+func (q *queries) CountRecoveryCodes(ctx context.Context, username string) (int64, error) {
+	var count int64
+	for _, code := range q.tables.recoveryCodes {
+		if code.Username == username && !code.UsedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *queries) CountRiskDecisionsFromIP(ctx context.Context, arg db.CountRiskDecisionsFromIPParams) (int64, error) {
+	var count int64
+	for _, decision := range q.tables.riskDecisions {
+		if decision.Username != arg.Username || decision.ClientIp != arg.ClientIp {
+			continue
+		}
+		if decision.ApprovalID.Valid {
+			if q.tables.approvals[decision.ApprovalID.Int64].Status == util.ApprovedApproval {
+				count++
+			}
+		} else if decision.Outcome == util.AllowOutcome {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *queries) CountTransfersBetween(ctx context.Context, arg db.CountTransfersBetweenParams) (int64, error) {
+	var count int64
+	for _, transfer := range q.tables.transfers {
+		if transfer.FromAccountID == arg.FromAccountID && transfer.ToAccountID == arg.ToAccountID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *queries) putAccessGrant(grant db.AccessGrant) {
+	old, existed := q.tables.accessGrants[grant.ID]
+	q.tables.accessGrants[grant.ID] = grant
+	q.onRollback(func() {
+		if existed {
+			q.tables.accessGrants[grant.ID] = old
+		} else {
+			delete(q.tables.accessGrants, grant.ID)
+		}
+	})
+}
+
+func (q *queries) CreateAccessGrant(ctx context.Context, arg db.CreateAccessGrantParams) (db.AccessGrant, error) {
+	if len(arg.Scopes) == 0 {
+		return db.AccessGrant{}, constraintError(db.CheckViolation, "access_grants_scopes_check")
+	}
+	for _, scope := range arg.Scopes {
+		if !util.IsSupportedGrantScope(scope) {
+			return db.AccessGrant{}, constraintError(db.CheckViolation, "access_grants_scopes_check")
+		}
+	}
+	if arg.TransferLimit.Valid && arg.TransferLimit.Int64 <= 0 {
+		return db.AccessGrant{}, constraintError(db.CheckViolation, "access_grants_transfer_limit_check")
+	}
+	if arg.Grantee == arg.Grantor {
+		return db.AccessGrant{}, constraintError(db.CheckViolation, "access_grants_grantee_check")
+	}
+	if _, ok := q.tables.users[arg.Grantor]; !ok {
+		return db.AccessGrant{}, constraintError(db.ForeignKeyViolation, "access_grants_grantor_fkey")
+	}
+	if _, ok := q.tables.users[arg.Grantee]; !ok {
+		return db.AccessGrant{}, constraintError(db.ForeignKeyViolation, "access_grants_grantee_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.AccountID]; !ok {
+		return db.AccessGrant{}, constraintError(db.ForeignKeyViolation, "access_grants_account_id_fkey")
+	}
+
+	q.tables.accessGrantSeq++
+	grant := db.AccessGrant{
+		ID:            q.tables.accessGrantSeq,
+		Grantor:       arg.Grantor,
+		Grantee:       arg.Grantee,
+		AccountID:     arg.AccountID,
+		Scopes:        append([]string{}, arg.Scopes...),
+		TransferLimit: arg.TransferLimit,
+		ExpiresAt:     arg.ExpiresAt.Truncate(time.Microsecond),
+		CreatedAt:     now(),
+	}
+	q.putAccessGrant(grant)
+	return grant, nil
+}
+
+func (q *queries) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	if _, ok := q.tables.users[arg.Owner]; !ok {
+		return db.Account{}, constraintError(db.ForeignKeyViolation, "accounts_owner_fkey")
+	}
+
+	for _, account := range q.tables.accounts {
+		if account.Owner == arg.Owner && account.Currency == arg.Currency {
+			return db.Account{}, constraintError(db.UniqueViolation, "owner_currency_key")
+		}
+	}
+
+	q.tables.accountSeq++
+	account := db.Account{
+		ID:        q.tables.accountSeq,
+		Owner:     arg.Owner,
+		Balance:   arg.Balance,
+		Currency:  arg.Currency,
+		CreatedAt: now(),
+		Version:   1,
+		Status:    util.ActiveAccount,
+	}
+	q.putAccount(account)
+	return account, nil
+}
+
+func (q *queries) CreateAccountMember(ctx context.Context, arg db.CreateAccountMemberParams) (db.AccountMember, error) {
+	// postgres checks constraints before the primary key, and foreign keys last
+	if !util.IsSupportedMemberPermission(arg.Permission) {
+		return db.AccountMember{}, constraintError(db.CheckViolation, "account_members_permission_check")
+	}
+	if _, ok := q.tables.accountMembers[accountMemberKey{arg.AccountID, arg.Username}]; ok {
+		return db.AccountMember{}, constraintError(db.UniqueViolation, "account_members_pkey")
+	}
+	if _, ok := q.tables.accounts[arg.AccountID]; !ok {
+		return db.AccountMember{}, constraintError(db.ForeignKeyViolation, "account_members_account_id_fkey")
+	}
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.AccountMember{}, constraintError(db.ForeignKeyViolation, "account_members_username_fkey")
+	}
+	if _, ok := q.tables.users[arg.InvitedBy]; !ok {
+		return db.AccountMember{}, constraintError(db.ForeignKeyViolation, "account_members_invited_by_fkey")
+	}
+
+	member := db.AccountMember{
+		AccountID:  arg.AccountID,
+		Username:   arg.Username,
+		Permission: arg.Permission,
+		InvitedBy:  arg.InvitedBy,
+		CreatedAt:  now(),
+	}
+	q.putAccountMember(member)
+	return member, nil
+}
+
+func (q *queries) putApproval(approval db.Approval) {
+	old, existed := q.tables.approvals[approval.ID]
+	q.tables.approvals[approval.ID] = approval
+	q.onRollback(func() {
+		if existed {
+			q.tables.approvals[approval.ID] = old
+		} else {
+			delete(q.tables.approvals, approval.ID)
+		}
+	})
+}
+
+func (q *queries) CreateApproval(ctx context.Context, arg db.CreateApprovalParams) (db.Approval, error) {
+	if arg.Kind != util.TransferApproval && arg.Kind != util.AdjustmentApproval {
+		return db.Approval{}, constraintError(db.CheckViolation, "approvals_kind_check")
+	}
+	if arg.Kind == util.TransferApproval && (!arg.ToAccountID.Valid || !arg.Amount.Valid || arg.Amount.Int64 <= 0) {
+		return db.Approval{}, constraintError(db.CheckViolation, "approvals_transfer_check")
+	}
+	if arg.Kind == util.AdjustmentApproval && (!arg.Balance.Valid || arg.Balance.Int64 < 0) {
+		return db.Approval{}, constraintError(db.CheckViolation, "approvals_adjustment_check")
+	}
+	if _, ok := q.tables.users[arg.Maker]; !ok {
+		return db.Approval{}, constraintError(db.ForeignKeyViolation, "approvals_maker_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.AccountID]; !ok {
+		return db.Approval{}, constraintError(db.ForeignKeyViolation, "approvals_account_id_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.ToAccountID.Int64]; arg.ToAccountID.Valid && !ok {
+		return db.Approval{}, constraintError(db.ForeignKeyViolation, "approvals_to_account_id_fkey")
+	}
+
+	q.tables.approvalSeq++
+	approval := db.Approval{
+		ID:             q.tables.approvalSeq,
+		Kind:           arg.Kind,
+		Maker:          arg.Maker,
+		AccountID:      arg.AccountID,
+		ToAccountID:    arg.ToAccountID,
+		Amount:         arg.Amount,
+		Balance:        arg.Balance,
+		AccountVersion: arg.AccountVersion,
+		Currency:       arg.Currency,
+		Status:         util.PendingApproval,
+		ExpiresAt:      arg.ExpiresAt.Truncate(time.Microsecond),
+		CreatedAt:      now(),
+	}
+	q.putApproval(approval)
+	return approval, nil
+}
+
+func (q *queries) CreateArchivedPartition(ctx context.Context, arg db.CreateArchivedPartitionParams) (db.ArchivedPartition, error) {
+	for _, partition := range q.tables.archivedPartitions {
+		if partition.TableName == arg.TableName {
+			return db.ArchivedPartition{}, constraintError(db.UniqueViolation, "archived_partitions_table_name_key")
+		}
+	}
+
+	q.tables.archivedPartitionSeq++
+	partition := db.ArchivedPartition{
+		ID:          q.tables.archivedPartitionSeq,
+		TableName:   arg.TableName,
+		ParentTable: arg.ParentTable,
+		RangeStart:  arg.RangeStart,
+		RangeEnd:    arg.RangeEnd,
+		FilePath:    arg.FilePath,
+		Checksum:    arg.Checksum,
+		RowCount:    arg.RowCount,
+		MinID:       arg.MinID,
+		MaxID:       arg.MaxID,
+		ArchivedAt:  now(),
+	}
+
+	q.tables.archivedPartitions[partition.ID] = partition
+	q.onRollback(func() {
+		delete(q.tables.archivedPartitions, partition.ID)
+	})
+	return partition, nil
+}
+
+func (q *queries) CreateAuditLog(ctx context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+	changes := cloneJSON(arg.Changes)
+	if changes == nil {
+		changes = json.RawMessage("{}")
+	}
+
+	q.tables.auditLogSeq++
+	auditLog := db.AuditLog{
+		ID:           q.tables.auditLogSeq,
+		RequestID:    arg.RequestID,
+		Actor:        arg.Actor,
+		ActorRole:    arg.ActorRole,
+		Method:       arg.Method,
+		Route:        arg.Route,
+		ResourceType: arg.ResourceType,
+		ResourceID:   arg.ResourceID,
+		StatusCode:   arg.StatusCode,
+		Changes:      changes,
+		ClientIp:     arg.ClientIp,
+		UserAgent:    arg.UserAgent,
+		CreatedAt:    now(),
+		GrantID:      arg.GrantID,
+	}
+
+	q.tables.auditLogs[auditLog.ID] = auditLog
+	q.onRollback(func() {
+		delete(q.tables.auditLogs, auditLog.ID)
+	})
+	return auditLog, nil
+}
+
+func (q *queries) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+	if _, ok := q.tables.accounts[arg.AccountID]; !ok {
+		return db.Entry{}, constraintError(db.ForeignKeyViolation, "entries_account_id_fkey")
+	}
+
+	q.tables.entrySeq++
+	entry := db.Entry{
+		ID:        q.tables.entrySeq,
+		AccountID: arg.AccountID,
+		Amount:    arg.Amount,
+		CreatedAt: now(),
+	}
+
+	q.tables.entries[entry.ID] = entry
+	q.onRollback(func() {
+		delete(q.tables.entries, entry.ID)
+	})
+	return entry, nil
+}
+
+func (q *queries) CreateJob(ctx context.Context, arg db.CreateJobParams) (db.Job, error) {
+	if arg.MaxAttempts <= 0 {
+		return db.Job{}, constraintError(db.CheckViolation, "jobs_max_attempts_check")
+	}
+
+	q.tables.jobSeq++
+	createdAt := now()
+	job := db.Job{
+		ID:          q.tables.jobSeq,
+		Kind:        arg.Kind,
+		Payload:     cloneJSON(arg.Payload),
+		Status:      util.PendingJob,
+		MaxAttempts: arg.MaxAttempts,
+		RunAt:       createdAt,
+		CreatedAt:   createdAt,
+	}
+	q.putJob(job)
+	return job, nil
+}
+
+func (q *queries) putPayee(payee db.Payee) {
+	q.tables.payees[payee.ID] = payee
+	q.onRollback(func() {
+		delete(q.tables.payees, payee.ID)
+	})
+}
+
+func (q *queries) deletePayee(payee db.Payee) {
+	delete(q.tables.payees, payee.ID)
+	q.onRollback(func() {
+		q.tables.payees[payee.ID] = payee
+	})
+}
+
+func (q *queries) putLoginChallenge(challenge db.LoginChallenge) {
+	old, existed := q.tables.loginChallenges[challenge.ID]
+	q.tables.loginChallenges[challenge.ID] = challenge
+	q.onRollback(func() {
+		if existed {
+			q.tables.loginChallenges[challenge.ID] = old
+		} else {
+			delete(q.tables.loginChallenges, challenge.ID)
+		}
+	})
+}
+
+func (q *queries) CreateLoginChallenge(ctx context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.LoginChallenge{}, constraintError(db.ForeignKeyViolation, "login_challenges_username_fkey")
+	}
+	for _, challenge := range q.tables.loginChallenges {
+		if challenge.TokenHash == arg.TokenHash {
+			return db.LoginChallenge{}, constraintError(db.UniqueViolation, "login_challenges_token_hash_key")
+		}
+	}
+
+	q.tables.loginChallengeSeq++
+	challenge := db.LoginChallenge{
+		ID:        q.tables.loginChallengeSeq,
+		Username:  arg.Username,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: now(),
+	}
+	q.putLoginChallenge(challenge)
+	return challenge, nil
+}
+
+func (q *queries) CreateMoneyRequest(ctx context.Context, arg db.CreateMoneyRequestParams) (db.MoneyRequest, error) {
+	if arg.Amount <= 0 {
+		return db.MoneyRequest{}, constraintError(db.CheckViolation, "money_requests_amount_check")
+	}
+	if arg.Payer == arg.Requester {
+		return db.MoneyRequest{}, constraintError(db.CheckViolation, "money_requests_payer_check")
+	}
+	if _, ok := q.tables.users[arg.Requester]; !ok {
+		return db.MoneyRequest{}, constraintError(db.ForeignKeyViolation, "money_requests_requester_fkey")
+	}
+	if _, ok := q.tables.users[arg.Payer]; !ok {
+		return db.MoneyRequest{}, constraintError(db.ForeignKeyViolation, "money_requests_payer_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.ToAccountID]; !ok {
+		return db.MoneyRequest{}, constraintError(db.ForeignKeyViolation, "money_requests_to_account_id_fkey")
+	}
+
+	q.tables.moneyRequestSeq++
+	request := db.MoneyRequest{
+		ID:          q.tables.moneyRequestSeq,
+		Requester:   arg.Requester,
+		Payer:       arg.Payer,
+		ToAccountID: arg.ToAccountID,
+		Amount:      arg.Amount,
+		Currency:    arg.Currency,
+		Note:        arg.Note,
+		Status:      util.PendingRequest,
+		ExpiresAt:   arg.ExpiresAt.Truncate(time.Microsecond),
+		CreatedAt:   now(),
+	}
+	q.putMoneyRequest(request)
+	return request, nil
+}
+
+func (q *queries) CreatePayee(ctx context.Context, arg db.CreatePayeeParams) (db.Payee, error) {
+	for _, payee := range q.tables.payees {
+		if payee.Owner == arg.Owner && payee.Nickname == arg.Nickname {
+			return db.Payee{}, constraintError(db.UniqueViolation, "owner_nickname_key")
+		}
+	}
+	if _, ok := q.tables.users[arg.Owner]; !ok {
+		return db.Payee{}, constraintError(db.ForeignKeyViolation, "payees_owner_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.AccountID]; !ok {
+		return db.Payee{}, constraintError(db.ForeignKeyViolation, "payees_account_id_fkey")
+	}
+
+	q.tables.payeeSeq++
+	payee := db.Payee{
+		ID:        q.tables.payeeSeq,
+		Owner:     arg.Owner,
+		Nickname:  arg.Nickname,
+		AccountID: arg.AccountID,
+		Currency:  arg.Currency,
+		CreatedAt: now(),
+	}
+	q.putPayee(payee)
+	return payee, nil
+}
+
+func (q *queries) putRecoveryCode(code db.RecoveryCode) {
+	old, existed := q.tables.recoveryCodes[code.ID]
+	q.tables.recoveryCodes[code.ID] = code
+	q.onRollback(func() {
+		if existed {
+			q.tables.recoveryCodes[code.ID] = old
+		} else {
+			delete(q.tables.recoveryCodes, code.ID)
+		}
+	})
+}
+
+func (q *queries) CreateRecoveryCode(ctx context.Context, arg db.CreateRecoveryCodeParams) (db.RecoveryCode, error) {
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.RecoveryCode{}, constraintError(db.ForeignKeyViolation, "recovery_codes_username_fkey")
+	}
+	for _, code := range q.tables.recoveryCodes {
+		if code.Username == arg.Username && code.CodeHash == arg.CodeHash {
+			return db.RecoveryCode{}, constraintError(db.UniqueViolation, "recovery_codes_username_code_hash_idx")
+		}
+	}
+
+	q.tables.recoveryCodeSeq++
+	code := db.RecoveryCode{
+		ID:        q.tables.recoveryCodeSeq,
+		Username:  arg.Username,
+		CodeHash:  arg.CodeHash,
+		CreatedAt: now(),
+	}
+	q.putRecoveryCode(code)
+	return code, nil
+}
+
+func (q *queries) putResetPassword(resetPassword db.ResetPassword) {
+	old, existed := q.tables.resetPasswords[resetPassword.ID]
+	q.tables.resetPasswords[resetPassword.ID] = resetPassword
+	q.onRollback(func() {
+		if existed {
+			q.tables.resetPasswords[resetPassword.ID] = old
+		} else {
+			delete(q.tables.resetPasswords, resetPassword.ID)
+		}
+	})
+}
+
+func (q *queries) CreateResetPassword(ctx context.Context, arg db.CreateResetPasswordParams) (db.ResetPassword, error) {
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.ResetPassword{}, constraintError(db.ForeignKeyViolation, "reset_passwords_username_fkey")
+	}
+	for _, resetPassword := range q.tables.resetPasswords {
+		if resetPassword.TokenHash == arg.TokenHash {
+			return db.ResetPassword{}, constraintError(db.UniqueViolation, "reset_passwords_token_hash_key")
+		}
+	}
+
+	q.tables.resetPasswordSeq++
+	resetPassword := db.ResetPassword{
+		ID:        q.tables.resetPasswordSeq,
+		Username:  arg.Username,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: now(),
+	}
+	q.putResetPassword(resetPassword)
+	return resetPassword, nil
+}
+
+func (q *queries) CreateRiskDecision(ctx context.Context, arg db.CreateRiskDecisionParams) (db.RiskDecision, error) {
+	if !util.IsSupportedRiskOutcome(arg.Outcome) {
+		return db.RiskDecision{}, constraintError(db.CheckViolation, "risk_decisions_outcome_check")
+	}
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.RiskDecision{}, constraintError(db.ForeignKeyViolation, "risk_decisions_username_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.FromAccountID]; !ok {
+		return db.RiskDecision{}, constraintError(db.ForeignKeyViolation, "risk_decisions_from_account_id_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.ToAccountID]; !ok {
+		return db.RiskDecision{}, constraintError(db.ForeignKeyViolation, "risk_decisions_to_account_id_fkey")
+	}
+	if _, ok := q.tables.approvals[arg.ApprovalID.Int64]; arg.ApprovalID.Valid && !ok {
+		return db.RiskDecision{}, constraintError(db.ForeignKeyViolation, "risk_decisions_approval_id_fkey")
+	}
+
+	firedRules := cloneJSON(arg.FiredRules)
+	if firedRules == nil {
+		firedRules = json.RawMessage("[]")
+	}
+
+	q.tables.riskDecisionSeq++
+	decision := db.RiskDecision{
+		ID:            q.tables.riskDecisionSeq,
+		Username:      arg.Username,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		ClientIp:      arg.ClientIp,
+		Outcome:       arg.Outcome,
+		FiredRules:    firedRules,
+		ApprovalID:    arg.ApprovalID,
+		CreatedAt:     now(),
+	}
+
+	q.tables.riskDecisions[decision.ID] = decision
+	q.onRollback(func() {
+		delete(q.tables.riskDecisions, decision.ID)
+	})
+	return decision, nil
+}
+
+func (q *queries) putScreeningHit(hit db.ScreeningHit) {
+	old, existed := q.tables.screeningHits[hit.ID]
+	q.tables.screeningHits[hit.ID] = hit
+	q.onRollback(func() {
+		if existed {
+			q.tables.screeningHits[hit.ID] = old
+		} else {
+			delete(q.tables.screeningHits, hit.ID)
+		}
+	})
+}
+
+func (q *queries) CreateScreeningHit(ctx context.Context, arg db.CreateScreeningHitParams) (int64, error) {
+	if arg.Score < 0 || arg.Score > 100 {
+		return 0, constraintError(db.CheckViolation, "screening_hits_score_check")
+	}
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return 0, constraintError(db.ForeignKeyViolation, "screening_hits_username_fkey")
+	}
+	for _, hit := range q.tables.screeningHits {
+		if hit.Username == arg.Username && hit.ListEntryID == arg.ListEntryID && hit.ScreenedName == arg.ScreenedName {
+			return 0, nil
+		}
+	}
+
+	q.tables.screeningHitSeq++
+	q.putScreeningHit(db.ScreeningHit{
+		ID:           q.tables.screeningHitSeq,
+		Username:     arg.Username,
+		ScreenedName: arg.ScreenedName,
+		ListEntryID:  arg.ListEntryID,
+		ListedName:   arg.ListedName,
+		Score:        arg.Score,
+		Status:       util.OpenHit,
+		CreatedAt:    now(),
+	})
+	return 1, nil
+}
+
+func (q *queries) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.Session{}, constraintError(db.ForeignKeyViolation, "sessions_username_fkey")
+	}
+	if _, ok := q.tables.sessions[arg.ID]; ok {
+		return db.Session{}, constraintError(db.UniqueViolation, "sessions_pkey")
+	}
+
+	session := db.Session{
+		ID:           arg.ID,
+		Username:     arg.Username,
+		RefreshToken: arg.RefreshToken,
+		UserAgent:    arg.UserAgent,
+		ClientIp:     arg.ClientIp,
+		IsBlocked:    arg.IsBlocked,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    now(),
+	}
+
+	q.tables.sessions[session.ID] = session
+	q.onRollback(func() {
+		delete(q.tables.sessions, session.ID)
+	})
+	return session, nil
+}
+
+func (q *queries) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+	if _, ok := q.tables.accounts[arg.FromAccountID]; !ok {
+		return db.Transfer{}, constraintError(db.ForeignKeyViolation, "transfers_from_account_id_fkey")
+	}
+	if _, ok := q.tables.accounts[arg.ToAccountID]; !ok {
+		return db.Transfer{}, constraintError(db.ForeignKeyViolation, "transfers_to_account_id_fkey")
+	}
+
+	q.tables.transferSeq++
+	transfer := db.Transfer{
+		ID:            q.tables.transferSeq,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		CreatedAt:     now(),
+	}
+
+	q.tables.transfers[transfer.ID] = transfer
+	q.onRollback(func() {
+		delete(q.tables.transfers, transfer.ID)
+	})
+	return transfer, nil
+}
+
+func (q *queries) putTwoFactor(twoFactor db.TwoFactor) {
+	old, existed := q.tables.twoFactors[twoFactor.Username]
+	q.tables.twoFactors[twoFactor.Username] = twoFactor
+	q.onRollback(func() {
+		if existed {
+			q.tables.twoFactors[twoFactor.Username] = old
+		} else {
+			delete(q.tables.twoFactors, twoFactor.Username)
+		}
+	})
+}
+
+func (q *queries) CreateTwoFactor(ctx context.Context, arg db.CreateTwoFactorParams) (db.TwoFactor, error) {
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.TwoFactor{}, constraintError(db.ForeignKeyViolation, "two_factors_username_fkey")
+	}
+
+	twoFactor, ok := q.tables.twoFactors[arg.Username]
+	if ok && twoFactor.IsEnabled {
+		return db.TwoFactor{}, db.ErrRecordNotFound
+	}
+	if !ok {
+		twoFactor = db.TwoFactor{Username: arg.Username}
+	}
+
+	twoFactor.Secret = arg.Secret
+	twoFactor.CreatedAt = now()
+	q.putTwoFactor(twoFactor)
+	return twoFactor, nil
+}
+
+func (q *queries) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	if _, ok := q.tables.users[arg.Username]; ok {
+		return db.User{}, constraintError(db.UniqueViolation, "users_pkey")
+	}
+	for _, user := range q.tables.users {
+		if user.Email == arg.Email {
+			return db.User{}, constraintError(db.UniqueViolation, "users_email_key")
+		}
+	}
+
+	user := db.User{
+		Username:       arg.Username,
+		HashedPassword: arg.HashedPassword,
+		FullName:       arg.FullName,
+		Email:          arg.Email,
+		CreatedAt:      now(),
+		Role:           util.DepositorRole,
+		Version:        1,
+	}
+	q.putUser(user)
+	return user, nil
+}
+
+func (q *queries) putVerifyEmail(verifyEmail db.VerifyEmail) {
+	old, existed := q.tables.verifyEmails[verifyEmail.ID]
+	q.tables.verifyEmails[verifyEmail.ID] = verifyEmail
+	q.onRollback(func() {
+		if existed {
+			q.tables.verifyEmails[verifyEmail.ID] = old
+		} else {
+			delete(q.tables.verifyEmails, verifyEmail.ID)
+		}
+	})
+}
+
+func (q *queries) CreateVerifyEmail(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+	if _, ok := q.tables.users[arg.Username]; !ok {
+		return db.VerifyEmail{}, constraintError(db.ForeignKeyViolation, "verify_emails_username_fkey")
+	}
+	for _, verifyEmail := range q.tables.verifyEmails {
+		if verifyEmail.TokenHash == arg.TokenHash {
+			return db.VerifyEmail{}, constraintError(db.UniqueViolation, "verify_emails_token_hash_key")
+		}
+	}
+
+	q.tables.verifyEmailSeq++
+	verifyEmail := db.VerifyEmail{
+		ID:        q.tables.verifyEmailSeq,
+		Username:  arg.Username,
+		Email:     arg.Email,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: now(),
+	}
+	q.putVerifyEmail(verifyEmail)
+	return verifyEmail, nil
+}
+
+func (q *queries) DeleteAccount(ctx context.Context, arg db.DeleteAccountParams) (int64, error) {
+	id := arg.ID
+	account, ok := q.tables.accounts[id]
+	if !ok || (arg.Version.Valid && account.Version != arg.Version.Int64) {
+		return 0, nil
+	}
+
+	for _, entry := range q.tables.entries {
+		if entry.AccountID == id {
+			return 0, constraintError(db.ForeignKeyViolation, "entries_account_id_fkey")
+		}
+	}
+	for _, transfer := range q.tables.transfers {
+		if transfer.FromAccountID == id {
+			return 0, constraintError(db.ForeignKeyViolation, "transfers_from_account_id_fkey")
+		}
+		if transfer.ToAccountID == id {
+			return 0, constraintError(db.ForeignKeyViolation, "transfers_to_account_id_fkey")
+		}
+	}
+
+	// members, grants, payees, money requests, approvals and risk decisions are deleted along with the account
+	for grantID, grant := range q.tables.accessGrants {
+		if grant.AccountID == id {
+			delete(q.tables.accessGrants, grantID)
+			q.onRollback(func() {
+				q.tables.accessGrants[grant.ID] = grant
+			})
+		}
+	}
+	for key := range q.tables.accountMembers {
+		if key.accountID == id {
+			q.deleteAccountMember(key)
+		}
+	}
+
+	for _, payee := range q.tables.payees {
+		if payee.AccountID == id {
+			q.deletePayee(payee)
+		}
+	}
+
+	for requestID, request := range q.tables.moneyRequests {
+		if request.ToAccountID == id {
+			delete(q.tables.moneyRequests, requestID)
+			q.onRollback(func() {
+				q.tables.moneyRequests[request.ID] = request
+			})
+		}
+	}
+
+	for approvalID, approval := range q.tables.approvals {
+		if approval.AccountID == id || (approval.ToAccountID.Valid && approval.ToAccountID.Int64 == id) {
+			delete(q.tables.approvals, approvalID)
+			q.onRollback(func() {
+				q.tables.approvals[approval.ID] = approval
+			})
+		}
+	}
+
+	for decisionID, decision := range q.tables.riskDecisions {
+		if decision.FromAccountID == id || decision.ToAccountID == id {
+			delete(q.tables.riskDecisions, decisionID)
+			q.onRollback(func() {
+				q.tables.riskDecisions[decision.ID] = decision
+			})
+		}
+	}
+
+	delete(q.tables.accounts, id)
+	q.onRollback(func() {
+		q.tables.accounts[id] = account
+	})
+	return 1, nil
+}
+
+func (q *queries) DeleteAccountMember(ctx context.Context, arg db.DeleteAccountMemberParams) (int64, error) {
+	if !q.deleteAccountMember(accountMemberKey{arg.AccountID, arg.Username}) {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (q *queries) putLoginLockout(lockout db.LoginLockout) {
+	key := loginLockoutKey{lockout.Scope, lockout.Identifier}
+	old, existed := q.tables.loginLockouts[key]
+	q.tables.loginLockouts[key] = lockout
+	q.onRollback(func() {
+		if existed {
+			q.tables.loginLockouts[key] = old
+		} else {
+			delete(q.tables.loginLockouts, key)
+		}
+	})
+}
+
+func (q *queries) deleteLoginLockout(key loginLockoutKey) bool {
+	old, ok := q.tables.loginLockouts[key]
+	if !ok {
+		return false
+	}
+
+	delete(q.tables.loginLockouts, key)
+	q.onRollback(func() {
+		q.tables.loginLockouts[key] = old
+	})
+	return true
+}
+
+func (q *queries) DeleteLoginLockout(ctx context.Context, arg db.DeleteLoginLockoutParams) (int64, error) {
+	if !q.deleteLoginLockout(loginLockoutKey{arg.Scope, arg.Identifier}) {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (q *queries) DeletePayee(ctx context.Context, arg db.DeletePayeeParams) (int64, error) {
+	payee, ok := q.tables.payees[arg.ID]
+	if !ok || payee.Owner != arg.Owner {
+		return 0, nil
+	}
+
+	q.deletePayee(payee)
+	return 1, nil
+}
+
+func (q *queries) DeleteRecoveryCodes(ctx context.Context, username string) (int64, error) {
+	var rows int64
+	for id, code := range q.tables.recoveryCodes {
+		if code.Username != username {
+			continue
+		}
+
+		old := code
+		delete(q.tables.recoveryCodes, id)
+		q.onRollback(func() {
+			q.tables.recoveryCodes[old.ID] = old
+		})
+		rows++
+	}
+	return rows, nil
+}
+
+func (q *queries) DeleteStaleLoginLockouts(ctx context.Context, failedBefore time.Time) (int64, error) {
+	current := now()
+	var rows int64
+	for key, lockout := range q.tables.loginLockouts {
+		if !lockout.LastFailedAt.Before(failedBefore) || (lockout.LockedUntil.Valid && lockout.LockedUntil.Time.After(current)) {
+			continue
+		}
+
+		q.deleteLoginLockout(key)
+		rows++
+	}
+	return rows, nil
+}
+
+func (q *queries) putRateLimit(rateLimit db.RateLimit) {
+	key := rateLimitKey{rateLimit.Scope, rateLimit.Identifier}
+	old, existed := q.tables.rateLimits[key]
+	q.tables.rateLimits[key] = rateLimit
+	q.onRollback(func() {
+		if existed {
+			q.tables.rateLimits[key] = old
+		} else {
+			delete(q.tables.rateLimits, key)
+		}
+	})
+}
+
+func (q *queries) DeleteStaleRateLimits(ctx context.Context, startedBefore time.Time) (int64, error) {
+	var rows int64
+	for key, rateLimit := range q.tables.rateLimits {
+		if !rateLimit.WindowStartedAt.Before(startedBefore) {
+			continue
+		}
+
+		old := rateLimit
+		delete(q.tables.rateLimits, key)
+		q.onRollback(func() {
+			q.tables.rateLimits[key] = old
+		})
+		rows++
+	}
+	return rows, nil
+}
+
+func (q *queries) DeleteTwoFactor(ctx context.Context, username string) (int64, error) {
+	old, ok := q.tables.twoFactors[username]
+	if !ok {
+		return 0, nil
+	}
+
+	delete(q.tables.twoFactors, username)
+	q.onRollback(func() {
+		q.tables.twoFactors[old.Username] = old
+	})
+	return 1, nil
+}
+
+func (q *queries) EnableTwoFactor(ctx context.Context, arg db.EnableTwoFactorParams) (db.TwoFactor, error) {
+	twoFactor, ok := q.tables.twoFactors[arg.Username]
+	if !ok || twoFactor.IsEnabled {
+		return db.TwoFactor{}, db.ErrRecordNotFound
+	}
+
+	twoFactor.IsEnabled = true
+	twoFactor.EnabledAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	twoFactor.LastUsedStep = arg.LastUsedStep
+	q.putTwoFactor(twoFactor)
+	return twoFactor, nil
+}
+
+func (q *queries) ExpireMoneyRequests(ctx context.Context) (int64, error) {
+	var rows int64
+	for _, request := range q.tables.moneyRequests {
+		if request.Status != util.PendingRequest || request.ExpiresAt.After(now()) {
+			continue
+		}
+
+		request.Status = util.ExpiredRequest
+		request.ResolvedAt = pgtype.Timestamptz{Time: request.ExpiresAt, Valid: true}
+		q.putMoneyRequest(request)
+		rows++
+	}
+	return rows, nil
+}
+
+func (q *queries) FailLoginChallenge(ctx context.Context, id int64) (db.LoginChallenge, error) {
+	challenge, ok := q.tables.loginChallenges[id]
+	if !ok {
+		return db.LoginChallenge{}, db.ErrRecordNotFound
+	}
+
+	challenge.Attempts++
+	q.putLoginChallenge(challenge)
+	return challenge, nil
+}
+
+func (q *queries) FailJob(ctx context.Context, arg db.FailJobParams) (db.Job, error) {
+	job, ok := q.runningJob(arg.ID, arg.Attempts)
+	if !ok {
+		return db.Job{}, db.ErrRecordNotFound
+	}
+
+	job.Status = util.PendingJob
+	job.FinishedAt = pgtype.Timestamptz{}
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = util.FailedJob
+		job.FinishedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	}
+	job.LastError = arg.LastError
+	job.RunAt = arg.RunAt
+	job.LockedUntil = pgtype.Timestamptz{}
+	q.putJob(job)
+	return job, nil
+}
+
+func (q *queries) GetAccessGrant(ctx context.Context, id int64) (db.AccessGrant, error) {
+	grant, ok := q.tables.accessGrants[id]
+	if !ok {
+		return db.AccessGrant{}, db.ErrRecordNotFound
+	}
+	return grant, nil
+}
+
+func (q *queries) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	account, ok := q.tables.accounts[id]
+	if !ok {
+		return db.Account{}, db.ErrRecordNotFound
+	}
+	return account, nil
+}
+
+func (q *queries) GetAccountByOwnerCurrency(ctx context.Context, arg db.GetAccountByOwnerCurrencyParams) (db.Account, error) {
+	for _, account := range q.tables.accounts {
+		if account.Owner == arg.Owner && account.Currency == arg.Currency {
+			return account, nil
+		}
+	}
+	return db.Account{}, db.ErrRecordNotFound
+}
+
+// GetAccountForUpdate needs no row lock since the whole store is locked during a transaction
+func (q *queries) GetAccountForUpdate(ctx context.Context, id int64) (db.Account, error) {
+	return q.GetAccount(ctx, id)
+}
+
+func (q *queries) GetAccountMember(ctx context.Context, arg db.GetAccountMemberParams) (db.AccountMember, error) {
+	member, ok := q.tables.accountMembers[accountMemberKey{arg.AccountID, arg.Username}]
+	if !ok {
+		return db.AccountMember{}, db.ErrRecordNotFound
+	}
+	return member, nil
+}
+
+func (q *queries) GetActiveAccessGrant(ctx context.Context, arg db.GetActiveAccessGrantParams) (db.AccessGrant, error) {
+	grants := sortedValues(q.tables.accessGrants,
+		func(grant db.AccessGrant) bool {
+			return grant.AccountID == arg.AccountID &&
+				grant.Grantee == arg.Grantee &&
+				hasElement(grant.Scopes, arg.Scope) &&
+				!grant.RevokedAt.Valid &&
+				grant.ExpiresAt.After(now()) &&
+				q.managesAccount(grant.AccountID, grant.Grantor)
+		},
+		func(a, b db.AccessGrant) bool {
+			// transfer_limit DESC NULLS FIRST, id
+			if a.TransferLimit.Valid != b.TransferLimit.Valid {
+				return !a.TransferLimit.Valid
+			}
+			if a.TransferLimit.Int64 != b.TransferLimit.Int64 {
+				return a.TransferLimit.Int64 > b.TransferLimit.Int64
+			}
+			return a.ID < b.ID
+		},
+	)
+	if len(grants) == 0 {
+		return db.AccessGrant{}, db.ErrRecordNotFound
+	}
+	return grants[0], nil
+}
+
+// managesAccount reports whether the user owns the account or manages it as an accepted member
+func (q *queries) managesAccount(accountID int64, username string) bool {
+	account, ok := q.tables.accounts[accountID]
+	if !ok {
+		return false
+	}
+	if account.Owner == username {
+		return true
+	}
+	member, ok := q.tables.accountMembers[accountMemberKey{accountID, username}]
+	return ok && member.AcceptedAt.Valid && member.Permission == util.ManagePermission
+}
+
+func (q *queries) GetApproval(ctx context.Context, id int64) (db.Approval, error) {
+	approval, ok := q.tables.approvals[id]
+	if !ok {
+		return db.Approval{}, db.ErrRecordNotFound
+	}
+	return approval, nil
+}
+
+func (q *queries) GetClientIPHistory(ctx context.Context, arg db.GetClientIPHistoryParams) (db.GetClientIPHistoryRow, error) {
+	var history db.GetClientIPHistoryRow
+	for _, session := range q.tables.sessions {
+		if session.Username != arg.Username || !session.CreatedAt.Before(arg.Before) {
+			continue
+		}
+		history.Sessions++
+		if session.ClientIp == arg.ClientIp {
+			history.ClientIpSessions++
+		}
+	}
+	return history, nil
+}
+
+func (q *queries) GetDiscoverableUser(ctx context.Context, alias string) (db.User, error) {
+	for _, user := range q.tables.users {
+		if (user.Username == alias || user.Email == alias) && user.Discoverable && !user.Disabled {
+			return user, nil
+		}
+	}
+	return db.User{}, db.ErrRecordNotFound
+}
+
+func (q *queries) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+	entry, ok := q.tables.entries[id]
+	if !ok {
+		return db.Entry{}, db.ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+func (q *queries) GetJob(ctx context.Context, id int64) (db.Job, error) {
+	job, ok := q.tables.jobs[id]
+	if !ok {
+		return db.Job{}, db.ErrRecordNotFound
+	}
+	return job, nil
+}
+
+func (q *queries) GetLoginChallenge(ctx context.Context, tokenHash string) (db.LoginChallenge, error) {
+	for _, challenge := range q.tables.loginChallenges {
+		if challenge.TokenHash == tokenHash {
+			return challenge, nil
+		}
+	}
+	return db.LoginChallenge{}, db.ErrRecordNotFound
+}
+
+func (q *queries) GetLoginLockout(ctx context.Context, arg db.GetLoginLockoutParams) (db.LoginLockout, error) {
+	lockout, ok := q.tables.loginLockouts[loginLockoutKey{arg.Scope, arg.Identifier}]
+	if !ok {
+		return db.LoginLockout{}, db.ErrRecordNotFound
+	}
+	return lockout, nil
+}
+
+func (q *queries) GetMoneyRequest(ctx context.Context, id int64) (db.MoneyRequest, error) {
+	request, ok := q.tables.moneyRequests[id]
+	if !ok {
+		return db.MoneyRequest{}, db.ErrRecordNotFound
+	}
+	return request, nil
+}
+
+func (q *queries) GetPayee(ctx context.Context, id int64) (db.Payee, error) {
+	payee, ok := q.tables.payees[id]
+	if !ok {
+		return db.Payee{}, db.ErrRecordNotFound
+	}
+	return payee, nil
+}
+
+func (q *queries) GetScreeningHit(ctx context.Context, id int64) (db.ScreeningHit, error) {
+	hit, ok := q.tables.screeningHits[id]
+	if !ok {
+		return db.ScreeningHit{}, db.ErrRecordNotFound
+	}
+	return hit, nil
+}
+
+func (q *queries) GetSession(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	session, ok := q.tables.sessions[id]
+	if !ok {
+		return db.Session{}, db.ErrRecordNotFound
+	}
+	return session, nil
+}
+
+func (q *queries) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	transfer, ok := q.tables.transfers[id]
+	if !ok {
+		return db.Transfer{}, db.ErrRecordNotFound
+	}
+	return transfer, nil
+}
+
+func (q *queries) GetTwoFactor(ctx context.Context, username string) (db.TwoFactor, error) {
+	twoFactor, ok := q.tables.twoFactors[username]
+	if !ok {
+		return db.TwoFactor{}, db.ErrRecordNotFound
+	}
+	return twoFactor, nil
+}
+
+func (q *queries) GetUser(ctx context.Context, username string) (db.User, error) {
+	user, ok := q.tables.users[username]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (q *queries) GetUserAccess(ctx context.Context, username string) (db.GetUserAccessRow, error) {
+	user, ok := q.tables.users[username]
+	if !ok {
+		return db.GetUserAccessRow{}, db.ErrRecordNotFound
+	}
+	return db.GetUserAccessRow{
+		Role:              user.Role,
+		Disabled:          user.Disabled,
+		PasswordChangedAt: user.PasswordChangedAt,
+		TokensValidAfter:  user.TokensValidAfter,
+	}, nil
+}
+
+func (q *queries) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	for _, user := range q.tables.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return db.User{}, db.ErrRecordNotFound
+}
+
+func (q *queries) HitRateLimit(ctx context.Context, arg db.HitRateLimitParams) (db.RateLimit, error) {
+	rateLimit, ok := q.tables.rateLimits[rateLimitKey{arg.Scope, arg.Identifier}]
+	switch {
+	case !ok || rateLimit.WindowStartedAt.Before(arg.ResetBefore):
+		rateLimit = db.RateLimit{
+			Scope:           arg.Scope,
+			Identifier:      arg.Identifier,
+			Hits:            1,
+			WindowStartedAt: now(),
+		}
+	default:
+		rateLimit.Hits++
+	}
+
+	q.putRateLimit(rateLimit)
+	return rateLimit, nil
+}
+
+func (q *queries) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	accounts := sortedValues(q.tables.accounts,
+		func(account db.Account) bool {
+			return account.Owner == arg.Owner
+		},
+		func(a, b db.Account) bool {
+			return a.ID < b.ID
+		},
+	)
+	return paginate(accounts, arg.Limit, arg.Offset), nil
+}
+
+func (q *queries) ListAccountsAfter(ctx context.Context, arg db.ListAccountsAfterParams) ([]db.Account, error) {
+	accounts := sortedValues(q.tables.accounts,
+		func(account db.Account) bool {
+			return q.tables.holdsAccount(account, arg.Owner) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(account.CreatedAt, account.ID, arg.CursorCreatedAt.Time, arg.CursorID.Int64))
+		},
+		func(a, b db.Account) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+	return paginate(accounts, arg.Limit, 0), nil
+}
+
+func (q *queries) ListAccountsBefore(ctx context.Context, arg db.ListAccountsBeforeParams) ([]db.Account, error) {
+	accounts := sortedValues(q.tables.accounts,
+		func(account db.Account) bool {
+			return q.tables.holdsAccount(account, arg.Owner) &&
+				keysetLess(arg.CursorCreatedAt, arg.CursorID, account.CreatedAt, account.ID)
+		},
+		func(a, b db.Account) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+	return paginate(accounts, arg.Limit, 0), nil
+}
+
+func (q *queries) ListAccessGrants(ctx context.Context, username string) ([]db.AccessGrant, error) {
+	return sortedValues(q.tables.accessGrants,
+		func(grant db.AccessGrant) bool {
+			return grant.Grantor == username || grant.Grantee == username
+		},
+		func(a, b db.AccessGrant) bool {
+			return a.ID > b.ID
+		},
+	), nil
+}
+
+func (q *queries) ListAccountInvitations(ctx context.Context, username string) ([]db.AccountMember, error) {
+	return sortedValues(q.tables.accountMembers,
+		func(member db.AccountMember) bool {
+			return member.Username == username && !member.AcceptedAt.Valid
+		},
+		func(a, b db.AccountMember) bool {
+			return keysetLess(a.CreatedAt, a.AccountID, b.CreatedAt, b.AccountID)
+		},
+	), nil
+}
+
+func (q *queries) ListAccountMembers(ctx context.Context, accountID int64) ([]db.AccountMember, error) {
+	return sortedValues(q.tables.accountMembers,
+		func(member db.AccountMember) bool {
+			return member.AccountID == accountID
+		},
+		func(a, b db.AccountMember) bool {
+			return keysetLess(a.CreatedAt, a.Username, b.CreatedAt, b.Username)
+		},
+	), nil
+}
+
+func (q *queries) ListApprovalsAfter(ctx context.Context, arg db.ListApprovalsAfterParams) ([]db.Approval, error) {
+	approvals := sortedValues(q.tables.approvals,
+		func(approval db.Approval) bool {
+			return (!arg.Maker.Valid || approval.Maker == arg.Maker.String) &&
+				(!arg.Status.Valid || approval.Status == arg.Status.String) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(arg.CursorCreatedAt.Time, arg.CursorID.Int64, approval.CreatedAt, approval.ID))
+		},
+		func(a, b db.Approval) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+	return paginate(approvals, arg.Limit, 0), nil
+}
+
+func (q *queries) ListApprovalsBefore(ctx context.Context, arg db.ListApprovalsBeforeParams) ([]db.Approval, error) {
+	approvals := sortedValues(q.tables.approvals,
+		func(approval db.Approval) bool {
+			return (!arg.Maker.Valid || approval.Maker == arg.Maker.String) &&
+				(!arg.Status.Valid || approval.Status == arg.Status.String) &&
+				keysetLess(approval.CreatedAt, approval.ID, arg.CursorCreatedAt, arg.CursorID)
+		},
+		func(a, b db.Approval) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+	return paginate(approvals, arg.Limit, 0), nil
+}
+
+func (q *queries) ListArchivedPartitions(ctx context.Context, parentTable string) ([]db.ArchivedPartition, error) {
+	return sortedValues(q.tables.archivedPartitions,
+		func(partition db.ArchivedPartition) bool {
+			return partition.ParentTable == parentTable
+		},
+		func(a, b db.ArchivedPartition) bool {
+			return a.RangeStart.Before(b.RangeStart)
+		},
+	), nil
+}
+
+// matchAuditLog reports whether an audit log passes the filters of a listing
+func matchAuditLog(auditLog db.AuditLog, actor, method, resourceType, resourceID, requestID pgtype.Text, grantID pgtype.Int8, createdFrom, createdTo pgtype.Timestamptz) bool {
+	return (!actor.Valid || auditLog.Actor == actor.String) &&
+		(!method.Valid || auditLog.Method == method.String) &&
+		(!resourceType.Valid || auditLog.ResourceType == resourceType.String) &&
+		(!resourceID.Valid || auditLog.ResourceID == resourceID.String) &&
+		(!requestID.Valid || auditLog.RequestID == requestID.String) &&
+		(!grantID.Valid || auditLog.GrantID == grantID) &&
+		(!createdFrom.Valid || !auditLog.CreatedAt.Before(createdFrom.Time)) &&
+		(!createdTo.Valid || auditLog.CreatedAt.Before(createdTo.Time))
+}
+
+func (q *queries) ListAuditLogsAfter(ctx context.Context, arg db.ListAuditLogsAfterParams) ([]db.AuditLog, error) {
+	auditLogs := sortedValues(q.tables.auditLogs,
+		func(auditLog db.AuditLog) bool {
+			return matchAuditLog(auditLog, arg.Actor, arg.Method, arg.ResourceType, arg.ResourceID, arg.RequestID, arg.GrantID, arg.CreatedFrom, arg.CreatedTo) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(auditLog.CreatedAt, auditLog.ID, arg.CursorCreatedAt.Time, arg.CursorID.Int64))
+		},
+		func(a, b db.AuditLog) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+
+	auditLogs = paginate(auditLogs, arg.Limit, 0)
+	for i := range auditLogs {
+		auditLogs[i].Changes = cloneJSON(auditLogs[i].Changes)
+	}
+	return auditLogs, nil
+}
+
+func (q *queries) ListAuditLogsBefore(ctx context.Context, arg db.ListAuditLogsBeforeParams) ([]db.AuditLog, error) {
+	auditLogs := sortedValues(q.tables.auditLogs,
+		func(auditLog db.AuditLog) bool {
+			return matchAuditLog(auditLog, arg.Actor, arg.Method, arg.ResourceType, arg.ResourceID, arg.RequestID, arg.GrantID, arg.CreatedFrom, arg.CreatedTo) &&
+				keysetLess(arg.CursorCreatedAt, arg.CursorID, auditLog.CreatedAt, auditLog.ID)
+		},
+		func(a, b db.AuditLog) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+
+	auditLogs = paginate(auditLogs, arg.Limit, 0)
+	for i := range auditLogs {
+		auditLogs[i].Changes = cloneJSON(auditLogs[i].Changes)
+	}
+	return auditLogs, nil
+}
+
+func (q *queries) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+	entries := sortedValues(q.tables.entries,
+		func(entry db.Entry) bool {
+			return entry.AccountID == arg.AccountID
+		},
+		func(a, b db.Entry) bool {
+			return a.ID < b.ID
+		},
+	)
+	return paginate(entries, arg.Limit, arg.Offset), nil
+}
+
+func (q *queries) ListEntriesAfter(ctx context.Context, arg db.ListEntriesAfterParams) ([]db.Entry, error) {
+	entries := sortedValues(q.tables.entries,
+		func(entry db.Entry) bool {
+			return entry.AccountID == arg.AccountID &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(entry.CreatedAt, entry.ID, arg.CursorCreatedAt.Time, arg.CursorID.Int64))
+		},
+		func(a, b db.Entry) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+	return paginate(entries, arg.Limit, 0), nil
+}
+
+func (q *queries) ListEntriesBefore(ctx context.Context, arg db.ListEntriesBeforeParams) ([]db.Entry, error) {
+	entries := sortedValues(q.tables.entries,
+		func(entry db.Entry) bool {
+			return entry.AccountID == arg.AccountID &&
+				keysetLess(arg.CursorCreatedAt, arg.CursorID, entry.CreatedAt, entry.ID)
+		},
+		func(a, b db.Entry) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+	return paginate(entries, arg.Limit, 0), nil
+}
+
+func (q *queries) ListIncomingMoneyRequestsAfter(ctx context.Context, arg db.ListIncomingMoneyRequestsAfterParams) ([]db.MoneyRequest, error) {
+	requests := sortedValues(q.tables.moneyRequests,
+		func(request db.MoneyRequest) bool {
+			return request.Payer == arg.Payer && (!arg.Status.Valid || request.Status == arg.Status.String) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(request.CreatedAt, request.ID, arg.CursorCreatedAt.Time, arg.CursorID.Int64))
+		},
+		func(a, b db.MoneyRequest) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+	return paginate(requests, arg.Limit, 0), nil
+}
+
+func (q *queries) ListIncomingMoneyRequestsBefore(ctx context.Context, arg db.ListIncomingMoneyRequestsBeforeParams) ([]db.MoneyRequest, error) {
+	requests := sortedValues(q.tables.moneyRequests,
+		func(request db.MoneyRequest) bool {
+			return request.Payer == arg.Payer && (!arg.Status.Valid || request.Status == arg.Status.String) &&
+				keysetLess(arg.CursorCreatedAt, arg.CursorID, request.CreatedAt, request.ID)
+		},
+		func(a, b db.MoneyRequest) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+	return paginate(requests, arg.Limit, 0), nil
+}
+
+func (q *queries) ListLoginLockoutsAfter(ctx context.Context, arg db.ListLoginLockoutsAfterParams) ([]db.LoginLockout, error) {
+	current := now()
+	cursor := db.LoginLockout{
+		Scope:       arg.CursorScope.String,
+		Identifier:  arg.CursorIdentifier.String,
+		LockedUntil: arg.CursorLockedUntil,
+	}
+	items := sortedValues(q.tables.loginLockouts,
+		func(lockout db.LoginLockout) bool {
+			return lockout.LockedUntil.Valid && lockout.LockedUntil.Time.After(current) &&
+				(!arg.Scope.Valid || lockout.Scope == arg.Scope.String) &&
+				(!arg.CursorLockedUntil.Valid || lockedLonger(cursor, lockout))
+		},
+		lockedLonger,
+	)
+	return paginate(items, arg.Limit, 0), nil
+}
+
+func (q *queries) ListLoginLockoutsBefore(ctx context.Context, arg db.ListLoginLockoutsBeforeParams) ([]db.LoginLockout, error) {
+	current := now()
+	cursor := db.LoginLockout{
+		Scope:       arg.CursorScope,
+		Identifier:  arg.CursorIdentifier,
+		LockedUntil: pgtype.Timestamptz{Time: arg.CursorLockedUntil, Valid: true},
+	}
+	items := sortedValues(q.tables.loginLockouts,
+		func(lockout db.LoginLockout) bool {
+			return lockout.LockedUntil.Valid && lockout.LockedUntil.Time.After(current) &&
+				(!arg.Scope.Valid || lockout.Scope == arg.Scope.String) &&
+				lockedLonger(lockout, cursor)
+		},
+		func(a, b db.LoginLockout) bool {
+			return lockedLonger(b, a)
+		},
+	)
+	return paginate(items, arg.Limit, 0), nil
+}
+
+func (q *queries) ListOutgoingMoneyRequestsAfter(ctx context.Context, arg db.ListOutgoingMoneyRequestsAfterParams) ([]db.MoneyRequest, error) {
+	requests := sortedValues(q.tables.moneyRequests,
+		func(request db.MoneyRequest) bool {
+			return request.Requester == arg.Requester && (!arg.Status.Valid || request.Status == arg.Status.String) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(request.CreatedAt, request.ID, arg.CursorCreatedAt.Time, arg.CursorID.Int64))
+		},
+		func(a, b db.MoneyRequest) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+	return paginate(requests, arg.Limit, 0), nil
+}
+
+func (q *queries) ListOutgoingMoneyRequestsBefore(ctx context.Context, arg db.ListOutgoingMoneyRequestsBeforeParams) ([]db.MoneyRequest, error) {
+	requests := sortedValues(q.tables.moneyRequests,
+		func(request db.MoneyRequest) bool {
+			return request.Requester == arg.Requester && (!arg.Status.Valid || request.Status == arg.Status.String) &&
+				keysetLess(arg.CursorCreatedAt, arg.CursorID, request.CreatedAt, request.ID)
+		},
+		func(a, b db.MoneyRequest) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+	return paginate(requests, arg.Limit, 0), nil
+}
+
+func (q *queries) ListPayees(ctx context.Context, owner string) ([]db.Payee, error) {
+	return sortedValues(q.tables.payees,
+		func(payee db.Payee) bool {
+			return payee.Owner == owner
+		},
+		func(a, b db.Payee) bool {
+			return a.Nickname < b.Nickname
+		},
+	), nil
+}
+
+func (q *queries) ListRiskDecisionsAfter(ctx context.Context, arg db.ListRiskDecisionsAfterParams) ([]db.RiskDecision, error) {
+	decisions := sortedValues(q.tables.riskDecisions,
+		func(decision db.RiskDecision) bool {
+			return (!arg.Username.Valid || decision.Username == arg.Username.String) &&
+				(!arg.Outcome.Valid || decision.Outcome == arg.Outcome.String) &&
+				(!arg.ApprovalID.Valid || decision.ApprovalID == arg.ApprovalID) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(decision.CreatedAt, decision.ID, arg.CursorCreatedAt.Time, arg.CursorID.Int64))
+		},
+		func(a, b db.RiskDecision) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+
+	decisions = paginate(decisions, arg.Limit, 0)
+	for i := range decisions {
+		decisions[i].FiredRules = cloneJSON(decisions[i].FiredRules)
+	}
+	return decisions, nil
+}
+
+func (q *queries) ListRiskDecisionsBefore(ctx context.Context, arg db.ListRiskDecisionsBeforeParams) ([]db.RiskDecision, error) {
+	decisions := sortedValues(q.tables.riskDecisions,
+		func(decision db.RiskDecision) bool {
+			return (!arg.Username.Valid || decision.Username == arg.Username.String) &&
+				(!arg.Outcome.Valid || decision.Outcome == arg.Outcome.String) &&
+				(!arg.ApprovalID.Valid || decision.ApprovalID == arg.ApprovalID) &&
+				keysetLess(arg.CursorCreatedAt, arg.CursorID, decision.CreatedAt, decision.ID)
+		},
+		func(a, b db.RiskDecision) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+
+	decisions = paginate(decisions, arg.Limit, 0)
+	for i := range decisions {
+		decisions[i].FiredRules = cloneJSON(decisions[i].FiredRules)
+	}
+	return decisions, nil
+}
+
+func (q *queries) ListScreeningHitsAfter(ctx context.Context, arg db.ListScreeningHitsAfterParams) ([]db.ScreeningHit, error) {
+	hits := sortedValues(q.tables.screeningHits,
+		func(hit db.ScreeningHit) bool {
+			return (!arg.Username.Valid || hit.Username == arg.Username.String) &&
+				(!arg.Status.Valid || hit.Status == arg.Status.String) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(arg.CursorCreatedAt.Time, arg.CursorID.Int64, hit.CreatedAt, hit.ID))
+		},
+		func(a, b db.ScreeningHit) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+	return paginate(hits, arg.Limit, 0), nil
+}
+
+func (q *queries) ListScreeningHitsBefore(ctx context.Context, arg db.ListScreeningHitsBeforeParams) ([]db.ScreeningHit, error) {
+	hits := sortedValues(q.tables.screeningHits,
+		func(hit db.ScreeningHit) bool {
+			return (!arg.Username.Valid || hit.Username == arg.Username.String) &&
+				(!arg.Status.Valid || hit.Status == arg.Status.String) &&
+				keysetLess(hit.CreatedAt, hit.ID, arg.CursorCreatedAt, arg.CursorID)
+		},
+		func(a, b db.ScreeningHit) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+	return paginate(hits, arg.Limit, 0), nil
+}
+
+func (q *queries) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+	transfers := sortedValues(q.tables.transfers,
+		func(transfer db.Transfer) bool {
+			return transfer.FromAccountID == arg.FromAccountID || transfer.ToAccountID == arg.ToAccountID
+		},
+		func(a, b db.Transfer) bool {
+			return a.ID < b.ID
+		},
+	)
+	return paginate(transfers, arg.Limit, arg.Offset), nil
+}
+
+func (q *queries) ListTransfersAfter(ctx context.Context, arg db.ListTransfersAfterParams) ([]db.Transfer, error) {
+	transfers := sortedValues(q.tables.transfers,
+		func(transfer db.Transfer) bool {
+			return (transfer.FromAccountID == arg.AccountID || transfer.ToAccountID == arg.AccountID) &&
+				(!arg.CursorCreatedAt.Valid || keysetLess(transfer.CreatedAt, transfer.ID, arg.CursorCreatedAt.Time, arg.CursorID.Int64))
+		},
+		func(a, b db.Transfer) bool {
+			return keysetLess(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+		},
+	)
+	return paginate(transfers, arg.Limit, 0), nil
+}
+
+func (q *queries) ListTransfersBefore(ctx context.Context, arg db.ListTransfersBeforeParams) ([]db.Transfer, error) {
+	transfers := sortedValues(q.tables.transfers,
+		func(transfer db.Transfer) bool {
+			return (transfer.FromAccountID == arg.AccountID || transfer.ToAccountID == arg.AccountID) &&
+				keysetLess(arg.CursorCreatedAt, arg.CursorID, transfer.CreatedAt, transfer.ID)
+		},
+		func(a, b db.Transfer) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	)
+	return paginate(transfers, arg.Limit, 0), nil
+}
+
+func (q *queries) ListTransfersSince(ctx context.Context, arg db.ListTransfersSinceParams) ([]db.Transfer, error) {
+	return sortedValues(q.tables.transfers,
+		func(transfer db.Transfer) bool {
+			return transfer.FromAccountID == arg.FromAccountID && !transfer.CreatedAt.Before(arg.CreatedAt)
+		},
+		func(a, b db.Transfer) bool {
+			return keysetLess(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+		},
+	), nil
+}
+
+func (q *queries) ListUsersAfter(ctx context.Context, arg db.ListUsersAfterParams) ([]db.User, error) {
+	users := sortedValues(q.tables.users,
+		func(user db.User) bool {
+			return !arg.CursorCreatedAt.Valid ||
+				keysetLess(user.CreatedAt, user.Username, arg.CursorCreatedAt.Time, arg.CursorUsername.String)
+		},
+		func(a, b db.User) bool {
+			return keysetLess(b.CreatedAt, b.Username, a.CreatedAt, a.Username)
+		},
+	)
+	return paginate(users, arg.Limit, 0), nil
+}
+
+func (q *queries) ListUsersBefore(ctx context.Context, arg db.ListUsersBeforeParams) ([]db.User, error) {
+	users := sortedValues(q.tables.users,
+		func(user db.User) bool {
+			return keysetLess(arg.CursorCreatedAt, arg.CursorUsername, user.CreatedAt, user.Username)
+		},
+		func(a, b db.User) bool {
+			return keysetLess(a.CreatedAt, a.Username, b.CreatedAt, b.Username)
+		},
+	)
+	return paginate(users, arg.Limit, 0), nil
+}
+
+func (q *queries) NotifyAccountEvent(ctx context.Context, arg db.NotifyAccountEventParams) error {
+	if arg.Channel != db.AccountEventsChannel {
+		return nil
+	}
+
+	var event db.AccountEvent
+	if err := json.Unmarshal([]byte(arg.Payload), &event); err != nil {
+		return fmt.Errorf("cannot unmarshal account event: %w", err)
+	}
+
+	q.publish(event)
+	return nil
+}
+
+func (q *queries) RefundLoginAttempt(ctx context.Context, arg db.RefundLoginAttemptParams) (int64, error) {
+	lockout, ok := q.tables.loginLockouts[loginLockoutKey{arg.Scope, arg.Identifier}]
+	if !ok {
+		return 0, nil
+	}
+
+	if lockout.FailedAttempts > 0 {
+		lockout.FailedAttempts--
+	}
+	if arg.LockedUntil.Valid && lockout.LockedUntil.Valid && lockout.LockedUntil.Time.Equal(arg.LockedUntil.Time) {
+		lockout.LockedUntil = pgtype.Timestamptz{}
+	}
+	q.putLoginLockout(lockout)
+	return 1, nil
+}
+
+func (q *queries) RehashUserPassword(ctx context.Context, arg db.RehashUserPasswordParams) (int64, error) {
+	user, ok := q.tables.users[arg.Username]
+	if !ok || user.HashedPassword != arg.OldHashedPassword {
+		return 0, nil
+	}
+
+	user.HashedPassword = arg.NewHashedPassword
+	q.putUser(user)
+	return 1, nil
+}
+
+func (q *queries) RejectExpiredApprovals(ctx context.Context) (int64, error) {
+	var rows int64
+	for _, approval := range q.tables.approvals {
+		if approval.Status != util.PendingApproval || approval.ExpiresAt.After(now()) {
+			continue
+		}
+
+		approval.Status = util.RejectedApproval
+		approval.ResolvedAt = pgtype.Timestamptz{Time: approval.ExpiresAt, Valid: true}
+		q.putApproval(approval)
+		rows++
+	}
+	return rows, nil
+}
+
+func (q *queries) ReserveLoginAttempt(ctx context.Context, arg db.ReserveLoginAttemptParams) (db.LoginLockout, error) {
+	if !util.IsSupportedLockoutScope(arg.Scope) {
+		return db.LoginLockout{}, constraintError(db.CheckViolation, "login_lockouts_scope_check")
+	}
+
+	lockout, ok := q.tables.loginLockouts[loginLockoutKey{arg.Scope, arg.Identifier}]
+	switch {
+	case !ok:
+		lockout = db.LoginLockout{
+			Scope:          arg.Scope,
+			Identifier:     arg.Identifier,
+			FailedAttempts: 1,
+		}
+	case lockout.LockedUntil.Valid && lockout.LockedUntil.Time.After(now()):
+		return db.LoginLockout{}, db.ErrRecordNotFound
+	case lockout.LastFailedAt.Before(arg.ResetBefore):
+		lockout.FailedAttempts = 1
+	default:
+		lockout.FailedAttempts++
+	}
+
+	lockout.LastFailedAt = now()
+	lockout.LockedUntil = pgtype.Timestamptz{}
+	if lockout.FailedAttempts >= arg.Threshold {
+		exponent := lockout.FailedAttempts - arg.Threshold
+		if exponent > 1000 {
+			exponent = 1000
+		}
+		seconds := math.Min(arg.MaxDelaySeconds, arg.DelaySeconds*math.Pow(2, float64(exponent)))
+		lockout.LockedUntil = pgtype.Timestamptz{
+			Time:  lockout.LastFailedAt.Add(time.Duration(seconds * float64(time.Second))).Truncate(time.Microsecond),
+			Valid: true,
+		}
+	}
+
+	q.putLoginLockout(lockout)
+	return lockout, nil
+}
+
+func (q *queries) ResolveApproval(ctx context.Context, arg db.ResolveApprovalParams) (db.Approval, error) {
+	approval, ok := q.tables.approvals[arg.ID]
+	if !ok || approval.Status != util.PendingApproval || !approval.ExpiresAt.After(now()) {
+		return db.Approval{}, db.ErrRecordNotFound
+	}
+	if !util.IsSupportedApprovalStatus(arg.Status) {
+		return db.Approval{}, constraintError(db.CheckViolation, "approvals_status_check")
+	}
+	if arg.Checker.Valid && arg.Checker.String == approval.Maker {
+		return db.Approval{}, constraintError(db.CheckViolation, "approvals_checker_check")
+	}
+	if _, ok := q.tables.users[arg.Checker.String]; arg.Checker.Valid && !ok {
+		return db.Approval{}, constraintError(db.ForeignKeyViolation, "approvals_checker_fkey")
+	}
+
+	approval.Status = arg.Status
+	approval.Checker = arg.Checker
+	approval.TransferID = arg.TransferID
+	approval.ResolvedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	q.putApproval(approval)
+	return approval, nil
+}
+
+func (q *queries) ResolveMoneyRequest(ctx context.Context, arg db.ResolveMoneyRequestParams) (db.MoneyRequest, error) {
+	request, ok := q.pendingMoneyRequest(arg.ID)
+	if !ok {
+		return db.MoneyRequest{}, db.ErrRecordNotFound
+	}
+	if !util.IsSupportedMoneyRequestStatus(arg.Status) {
+		return db.MoneyRequest{}, constraintError(db.CheckViolation, "money_requests_status_check")
+	}
+
+	request.Status = arg.Status
+	request.ResolvedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	q.putMoneyRequest(request)
+	return request, nil
+}
+
+func (q *queries) ResolveScreeningHit(ctx context.Context, arg db.ResolveScreeningHitParams) (db.ScreeningHit, error) {
+	hit, ok := q.tables.screeningHits[arg.ID]
+	if !ok || hit.Status != util.OpenHit {
+		return db.ScreeningHit{}, db.ErrRecordNotFound
+	}
+	if !util.IsSupportedScreeningHitStatus(arg.Status) {
+		return db.ScreeningHit{}, constraintError(db.CheckViolation, "screening_hits_status_check")
+	}
+	if arg.ReviewedBy.Valid && arg.ReviewedBy.String == hit.Username {
+		return db.ScreeningHit{}, constraintError(db.CheckViolation, "screening_hits_reviewer_check")
+	}
+	if _, ok := q.tables.users[arg.ReviewedBy.String]; arg.ReviewedBy.Valid && !ok {
+		return db.ScreeningHit{}, constraintError(db.ForeignKeyViolation, "screening_hits_reviewed_by_fkey")
+	}
+
+	hit.Status = arg.Status
+	hit.ReviewedBy = arg.ReviewedBy
+	hit.ReviewedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	q.putScreeningHit(hit)
+	return hit, nil
+}
+
+func (q *queries) RevokeAccessGrant(ctx context.Context, id int64) (db.AccessGrant, error) {
+	grant, ok := q.tables.accessGrants[id]
+	if !ok || grant.RevokedAt.Valid {
+		return db.AccessGrant{}, db.ErrRecordNotFound
+	}
+
+	grant.RevokedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+	q.putAccessGrant(grant)
+	return grant, nil
+}
+
+func (q *queries) SearchAccounts(ctx context.Context, arg db.SearchAccountsParams) ([]db.Account, error) {
+	less := func(a, b db.Account) bool {
+		var column int
+		switch arg.SortBy {
+		case "owner":
+			column = compare(a.Owner, b.Owner)
+		case "balance":
+			column = compare(a.Balance, b.Balance)
+		case "created_at":
+			column = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		return searchLess(column, compare(a.ID, b.ID), arg.SortDesc)
+	}
+	cursor := db.Account{
+		ID:        arg.CursorID.Int64,
+		Owner:     arg.CursorOwner.String,
+		Balance:   arg.CursorBalance.Int64,
+		CreatedAt: arg.CursorCreatedAt.Time,
+	}
+
+	accounts := sortedValues(q.tables.accounts,
+		func(account db.Account) bool {
+			return (!arg.Owner.Valid || account.Owner == arg.Owner.String) &&
+				(!arg.Currency.Valid || account.Currency == arg.Currency.String) &&
+				(!arg.Status.Valid || account.Status == arg.Status.String) &&
+				(!arg.MinBalance.Valid || account.Balance >= arg.MinBalance.Int64) &&
+				(!arg.MaxBalance.Valid || account.Balance <= arg.MaxBalance.Int64) &&
+				(!arg.CreatedFrom.Valid || !account.CreatedAt.Before(arg.CreatedFrom.Time)) &&
+				(!arg.CreatedTo.Valid || account.CreatedAt.Before(arg.CreatedTo.Time)) &&
+				(!arg.CursorID.Valid || less(cursor, account))
+		},
+		less,
+	)
+	return paginate(accounts, arg.Limit, 0), nil
+}
+
+func (q *queries) SearchUsers(ctx context.Context, arg db.SearchUsersParams) ([]db.User, error) {
+	less := func(a, b db.User) bool {
+		var column int
+		switch arg.SortBy {
+		case "full_name":
+			column = compare(a.FullName, b.FullName)
+		case "email":
+			column = compare(a.Email, b.Email)
+		case "created_at":
+			column = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		return searchLess(column, compare(a.Username, b.Username), arg.SortDesc)
+	}
+	cursor := db.User{
+		Username:  arg.CursorUsername.String,
+		FullName:  arg.CursorFullName.String,
+		Email:     arg.CursorEmail.String,
+		CreatedAt: arg.CursorCreatedAt.Time,
+	}
+
+	users := sortedValues(q.tables.users,
+		func(user db.User) bool {
+			return (!arg.UsernamePrefix.Valid || strings.HasPrefix(user.Username, arg.UsernamePrefix.String)) &&
+				(!arg.EmailPrefix.Valid || hasPrefixFold(user.Email, arg.EmailPrefix.String)) &&
+				(!arg.FullNamePrefix.Valid || hasPrefixFold(user.FullName, arg.FullNamePrefix.String)) &&
+				(!arg.CursorUsername.Valid || less(cursor, user))
+		},
+		less,
+	)
+	return paginate(users, arg.Limit, 0), nil
+}
+
+func (q *queries) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+	account, ok := q.tables.accounts[arg.ID]
+	if !ok || (arg.Version.Valid && account.Version != arg.Version.Int64) {
+		return db.Account{}, db.ErrRecordNotFound
+	}
+
+	account.Balance = arg.Balance
+	account.Version++
+	q.putAccount(account)
+	return account, nil
+}
+
+func (q *queries) UpdateAccountMember(ctx context.Context, arg db.UpdateAccountMemberParams) (db.AccountMember, error) {
+	member, ok := q.tables.accountMembers[accountMemberKey{arg.AccountID, arg.Username}]
+	if !ok {
+		return db.AccountMember{}, db.ErrRecordNotFound
+	}
+	if !util.IsSupportedMemberPermission(arg.Permission) {
+		return db.AccountMember{}, constraintError(db.CheckViolation, "account_members_permission_check")
+	}
+
+	member.Permission = arg.Permission
+	q.putAccountMember(member)
+	return member, nil
+}
+
+func (q *queries) UpdateAccountStatus(ctx context.Context, arg db.UpdateAccountStatusParams) (db.Account, error) {
+	account, ok := q.tables.accounts[arg.ID]
+	if !ok || (arg.Version.Valid && account.Version != arg.Version.Int64) {
+		return db.Account{}, db.ErrRecordNotFound
+	}
+	if !util.IsSupportedAccountStatus(arg.Status) {
+		return db.Account{}, constraintError(db.CheckViolation, "accounts_status_check")
+	}
+
+	account.Status = arg.Status
+	account.Version++
+	q.putAccount(account)
+	return account, nil
+}
+
+func (q *queries) UpdateUser(ctx context.Context, arg db.UpdateUserParams) (db.User, error) {
+	user, ok := q.tables.users[arg.Username]
+	if !ok || (arg.Version.Valid && user.Version != arg.Version.Int64) {
+		return db.User{}, db.ErrRecordNotFound
+	}
+
+	if arg.Email.Valid && arg.Email.String != user.Email {
+		for _, other := range q.tables.users {
+			if other.Email == arg.Email.String {
+				return db.User{}, constraintError(db.UniqueViolation, "users_email_key")
+			}
+		}
+		user.Email = arg.Email.String
+	}
+	if arg.HashedPassword.Valid {
+		user.HashedPassword = arg.HashedPassword.String
+	}
+	if arg.PasswordChangedAt.Valid {
+		user.PasswordChangedAt = arg.PasswordChangedAt.Time
+	}
+	if arg.FullName.Valid {
+		user.FullName = arg.FullName.String
+	}
+	if arg.Role.Valid {
+		user.Role = arg.Role.String
+	}
+	if arg.Disabled.Valid {
+		user.Disabled = arg.Disabled.Bool
+	}
+	if arg.Discoverable.Valid {
+		user.Discoverable = arg.Discoverable.Bool
+	}
+	if arg.IsEmailVerified.Valid {
+		user.IsEmailVerified = arg.IsEmailVerified.Bool
+	}
+	user.Version++
+
+	q.putUser(user)
+	return user, nil
+}
+
+func (q *queries) UseLoginChallenge(ctx context.Context, id int64) (db.LoginChallenge, error) {
+	challenge, ok := q.tables.loginChallenges[id]
+	if !ok || challenge.IsUsed || !challenge.ExpiresAt.After(now()) {
+		return db.LoginChallenge{}, db.ErrRecordNotFound
+	}
+
+	challenge.IsUsed = true
+	q.putLoginChallenge(challenge)
+	return challenge, nil
+}
+
+func (q *queries) UseRecoveryCode(ctx context.Context, arg db.UseRecoveryCodeParams) (db.RecoveryCode, error) {
+	for _, code := range q.tables.recoveryCodes {
+		if code.Username != arg.Username || code.CodeHash != arg.CodeHash || code.UsedAt.Valid {
+			continue
+		}
+
+		code.UsedAt = pgtype.Timestamptz{Time: now(), Valid: true}
+		q.putRecoveryCode(code)
+		return code, nil
+	}
+	return db.RecoveryCode{}, db.ErrRecordNotFound
+}
+
+func (q *queries) UseResetPassword(ctx context.Context, tokenHash string) (db.ResetPassword, error) {
+	for _, resetPassword := range q.tables.resetPasswords {
+		if resetPassword.TokenHash != tokenHash {
+			continue
+		}
+		if resetPassword.IsUsed || !resetPassword.ExpiresAt.After(now()) {
+			break
+		}
+
+		resetPassword.IsUsed = true
+		q.putResetPassword(resetPassword)
+		return resetPassword, nil
+	}
+	return db.ResetPassword{}, db.ErrRecordNotFound
+}
+
+func (q *queries) UseTwoFactorStep(ctx context.Context, arg db.UseTwoFactorStepParams) (db.TwoFactor, error) {
+	twoFactor, ok := q.tables.twoFactors[arg.Username]
+	if !ok || !twoFactor.IsEnabled || twoFactor.LastUsedStep >= arg.LastUsedStep {
+		return db.TwoFactor{}, db.ErrRecordNotFound
+	}
+
+	twoFactor.LastUsedStep = arg.LastUsedStep
+	q.putTwoFactor(twoFactor)
+	return twoFactor, nil
+}
+
+func (q *queries) UseUserResetPasswords(ctx context.Context, username string) (int64, error) {
+	var rows int64
+	for _, resetPassword := range q.tables.resetPasswords {
+		if resetPassword.Username != username || resetPassword.IsUsed {
+			continue
+		}
+
+		resetPassword.IsUsed = true
+		q.putResetPassword(resetPassword)
+		rows++
+	}
+	return rows, nil
+}
+
+func (q *queries) UseVerifyEmail(ctx context.Context, tokenHash string) (db.VerifyEmail, error) {
+	for _, verifyEmail := range q.tables.verifyEmails {
+		if verifyEmail.TokenHash != tokenHash {
+			continue
+		}
+		if verifyEmail.IsUsed || !verifyEmail.ExpiresAt.After(now()) {
+			break
+		}
+
+		verifyEmail.IsUsed = true
+		q.putVerifyEmail(verifyEmail)
+		return verifyEmail, nil
+	}
+	return db.VerifyEmail{}, db.ErrRecordNotFound
+}
@@ -0,0 +1,537 @@
+// Package memorydb provides an in-memory implementation of db.Store
+// for tests and for running the server without a database.
+package memorydb
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const listenerBufferSize = 256
+
+// Store keeps every table in memory and implements db.Store.
+// Each call runs atomically, and transactions either apply all their changes or none.
+type Store struct {
+	mu        sync.Mutex
+	tables    *tables
+	listenMu  sync.RWMutex
+	listeners map[chan db.AccountEvent]struct{}
+}
+
+var _ db.Store = (*Store)(nil)
+
+// NewStore creates a new empty in-memory store
+func NewStore() *Store {
+	return &Store{
+		tables:    newTables(),
+		listeners: make(map[chan db.AccountEvent]struct{}),
+	}
+}
+
+// execTx runs fn with exclusive access to the tables.
+// When fn fails, every change it made is undone.
+// Account events are only delivered once fn succeeds, like postgres NOTIFY on commit.
+func (store *Store) execTx(fn func(q *queries) error) error {
+	q, err := store.lockedTx(fn)
+	if err == nil {
+		store.deliver(q.events)
+	}
+	return err
+}
+
+// lockedTx runs fn holding the lock, which is released and the changes undone even if fn panics
+func (store *Store) lockedTx(fn func(q *queries) error) (q *queries, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	q = &queries{tables: store.tables}
+	committed := false
+	defer func() {
+		if !committed {
+			q.rollback()
+		}
+	}()
+
+	err = fn(q)
+	committed = err == nil
+	return q, err
+}
+
+// run executes a single query atomically
+func run[T any](store *Store, query func(q *queries) (T, error)) (T, error) {
+	var result T
+	err := store.execTx(func(q *queries) error {
+		var err error
+		result, err = query(q)
+		return err
+	})
+	return result, err
+}
+
+func (store *Store) deliver(events []db.AccountEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	store.listenMu.RLock()
+	defer store.listenMu.RUnlock()
+
+	for listener := range store.listeners {
+		for _, event := range events {
+			select {
+			case listener <- event:
+			default:
+				log.Println("account event listener is full, dropping event for account ", event.AccountID)
+			}
+		}
+	}
+}
+
+// ListenAccountEvents calls handle for every account event until the context is cancelled
+func (store *Store) ListenAccountEvents(ctx context.Context, handle func(db.AccountEvent)) error {
+	listener := make(chan db.AccountEvent, listenerBufferSize)
+
+	store.listenMu.Lock()
+	store.listeners[listener] = struct{}{}
+	store.listenMu.Unlock()
+
+	defer func() {
+		store.listenMu.Lock()
+		delete(store.listeners, listener)
+		store.listenMu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-listener:
+			handle(event)
+		}
+	}
+}
+
+// TransferTx performs a money transfer from one account to another
+// It creates a transfer record, add account entries,
+// and update accounts' balance within a single transaction
+func (store *Store) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	var result db.TransferTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+		result, err = q.transfer(ctx, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// transfer moves the money within the transaction of q
+func (q *queries) transfer(ctx context.Context, arg db.TransferTxParams) (result db.TransferTxResult, err error) {
+	result.Transfer, err = q.CreateTransfer(ctx, db.CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	result.FromEntry, err = q.CreateEntry(ctx, db.CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, db.CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	result.FromAccount, err = q.AddAccountBalance(ctx, db.AddAccountBalanceParams{
+		ID:     arg.FromAccountID,
+		Amount: -arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToAccount, err = q.AddAccountBalance(ctx, db.AddAccountBalanceParams{
+		ID:     arg.ToAccountID,
+		Amount: arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	if result.FromAccount.Status != util.ActiveAccount || result.ToAccount.Status != util.ActiveAccount {
+		err = db.ErrAccountNotActive
+		return
+	}
+
+	if arg.RiskDecision != nil {
+		_, err = q.CreateRiskDecision(ctx, *arg.RiskDecision)
+		if err != nil {
+			return
+		}
+	}
+
+	q.publish(newAccountEvent(result.Transfer, result.FromEntry, result.FromAccount))
+	q.publish(newAccountEvent(result.Transfer, result.ToEntry, result.ToAccount))
+	return
+}
+
+// AcceptMoneyRequestTx pays a pending money request from the given account
+// and marks it accepted within a single transaction
+func (store *Store) AcceptMoneyRequestTx(ctx context.Context, arg db.AcceptMoneyRequestTxParams) (db.AcceptMoneyRequestTxResult, error) {
+	var result db.AcceptMoneyRequestTxResult
+
+	err := store.execTx(func(q *queries) error {
+		request, err := q.GetMoneyRequest(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.transfer(ctx, db.TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   request.ToAccountID,
+			Amount:        request.Amount,
+			RiskDecision:  arg.RiskDecision,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.MoneyRequest, err = q.AcceptMoneyRequest(ctx, db.AcceptMoneyRequestParams{
+			ID:         request.ID,
+			TransferID: pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateUserAccessTx changes the role or the disabled flag of a user
+// and blocks all of the user's sessions within a single transaction
+func (store *Store) UpdateUserAccessTx(ctx context.Context, arg db.UpdateUserAccessTxParams) (db.UpdateUserAccessTxResult, error) {
+	var result db.UpdateUserAccessTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, db.UpdateUserParams{
+			Username: arg.Username,
+			Role:     arg.Role,
+			Disabled: arg.Disabled,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockUserSessions(ctx, arg.Username)
+		return err
+	})
+
+	return result, err
+}
+
+// CreateUserTx creates a user, enqueues its jobs and records its screening hits within a single transaction
+func (store *Store) CreateUserTx(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	var result db.CreateUserTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.Jobs, err = q.createJobs(ctx, arg.Jobs)
+		if err != nil {
+			return err
+		}
+
+		return q.createScreeningHits(ctx, arg.ScreeningHits)
+	})
+
+	return result, err
+}
+
+// UpdateUserTx updates a user, enqueues its jobs and records its screening hits within a single transaction.
+// A new password blocks all of the user's sessions, as a reset does.
+func (store *Store) UpdateUserTx(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+	var result db.UpdateUserTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.HashedPassword.Valid {
+			result.RevokedSessions, err = q.BlockUserSessions(ctx, result.User.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Jobs, err = q.createJobs(ctx, arg.Jobs)
+		if err != nil {
+			return err
+		}
+
+		return q.createScreeningHits(ctx, arg.ScreeningHits)
+	})
+
+	return result, err
+}
+
+func (q *queries) createJobs(ctx context.Context, args []db.CreateJobParams) ([]db.Job, error) {
+	jobs := []db.Job{}
+	for _, arg := range args {
+		job, err := q.CreateJob(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (q *queries) createScreeningHits(ctx context.Context, args []db.CreateScreeningHitParams) error {
+	for _, arg := range args {
+		if _, err := q.CreateScreeningHit(ctx, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyEmailTx consumes the token of a verification link and marks the email of its user
+// as verified within a single transaction
+func (store *Store) VerifyEmailTx(ctx context.Context, tokenHash string) (db.VerifyEmailTxResult, error) {
+	var result db.VerifyEmailTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		result.OldUser, err = q.GetUser(ctx, result.VerifyEmail.Username)
+		if err != nil {
+			return err
+		}
+		if result.OldUser.Email != result.VerifyEmail.Email {
+			return db.ErrRecordNotFound
+		}
+
+		result.User, err = q.UpdateUser(ctx, db.UpdateUserParams{
+			Username:        result.OldUser.Username,
+			IsEmailVerified: pgtype.Bool{Bool: true, Valid: true},
+			Version:         pgtype.Int8{Int64: result.OldUser.Version, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ResetPasswordTx consumes the token of a password reset link, sets the new password of its user,
+// consumes their other reset links and blocks all of their sessions within a single transaction
+func (store *Store) ResetPasswordTx(ctx context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+	var result db.ResetPasswordTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+
+		result.ResetPassword, err = q.UseResetPassword(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, db.UpdateUserParams{
+			Username:          result.ResetPassword.Username,
+			HashedPassword:    pgtype.Text{String: arg.HashedPassword, Valid: true},
+			PasswordChangedAt: pgtype.Timestamptz{Time: now(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// links sent before this one would otherwise change the new password again
+		if _, err = q.UseUserResetPasswords(ctx, result.User.Username); err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockUserSessions(ctx, result.User.Username)
+		return err
+	})
+
+	return result, err
+}
+
+// EnableTwoFactorTx enables the secret of the user and replaces their recovery codes
+// within a single transaction
+func (store *Store) EnableTwoFactorTx(ctx context.Context, arg db.EnableTwoFactorTxParams) (db.EnableTwoFactorTxResult, error) {
+	var result db.EnableTwoFactorTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+
+		result.TwoFactor, err = q.EnableTwoFactor(ctx, db.EnableTwoFactorParams{
+			Username:     arg.Username,
+			LastUsedStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.RecoveryCodes = make([]db.RecoveryCode, 0, len(arg.RecoveryCodeHashes))
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			code, err := q.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// CompleteLoginChallengeTx consumes the challenge and the code that answered it
+// within a single transaction
+func (store *Store) CompleteLoginChallengeTx(ctx context.Context, arg db.CompleteLoginChallengeTxParams) (db.CompleteLoginChallengeTxResult, error) {
+	var result db.CompleteLoginChallengeTxResult
+
+	err := store.execTx(func(q *queries) error {
+		var err error
+
+		result.LoginChallenge, err = q.UseLoginChallenge(ctx, arg.ChallengeID)
+		if err != nil {
+			return err
+		}
+
+		if arg.RecoveryCodeHash.Valid {
+			result.RecoveryCode, err = q.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+				Username: result.LoginChallenge.Username,
+				CodeHash: arg.RecoveryCodeHash.String,
+			})
+			return err
+		}
+
+		_, err = q.UseTwoFactorStep(ctx, db.UseTwoFactorStepParams{
+			Username:     result.LoginChallenge.Username,
+			LastUsedStep: arg.Step.Int64,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ResetTwoFactorTx deletes the secret and the recovery codes of the user within a single transaction
+func (store *Store) ResetTwoFactorTx(ctx context.Context, username string) (db.ResetTwoFactorTxResult, error) {
+	var result db.ResetTwoFactorTxResult
+
+	err := store.execTx(func(q *queries) error {
+		rows, err := q.DeleteTwoFactor(ctx, username)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return db.ErrRecordNotFound
+		}
+
+		result.DeletedRecoveryCodes, err = q.DeleteRecoveryCodes(ctx, username)
+		return err
+	})
+
+	return result, err
+}
+
+func newAccountEvent(transfer db.Transfer, entry db.Entry, account db.Account) db.AccountEvent {
+	return db.AccountEvent{
+		AccountID:  account.ID,
+		EntryID:    entry.ID,
+		TransferID: transfer.ID,
+		Amount:     entry.Amount,
+		Balance:    account.Balance,
+		Currency:   account.Currency,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
+
+// ApproveTx executes the operation held by a pending approval
+// and records the checker within a single transaction
+func (store *Store) ApproveTx(ctx context.Context, arg db.ApproveTxParams) (db.ApproveTxResult, error) {
+	var result db.ApproveTxResult
+
+	err := store.execTx(func(q *queries) error {
+		approval, err := q.GetApproval(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		var transferID pgtype.Int8
+		switch approval.Kind {
+		case util.TransferApproval:
+			transferResult, err := q.transfer(ctx, db.TransferTxParams{
+				FromAccountID: approval.AccountID,
+				ToAccountID:   approval.ToAccountID.Int64,
+				Amount:        approval.Amount.Int64,
+			})
+			if err != nil {
+				return err
+			}
+			result.Transfer = &transferResult
+			transferID = pgtype.Int8{Int64: transferResult.Transfer.ID, Valid: true}
+		case util.AdjustmentApproval:
+			account, err := q.UpdateAccount(ctx, db.UpdateAccountParams{
+				ID:      approval.AccountID,
+				Balance: approval.Balance.Int64,
+				Version: approval.AccountVersion,
+			})
+			if err != nil {
+				return err
+			}
+			result.Account = &account
+		}
+
+		result.Approval, err = q.ResolveApproval(ctx, db.ResolveApprovalParams{
+			ID:         approval.ID,
+			Status:     util.ApprovedApproval,
+			Checker:    pgtype.Text{String: arg.Checker, Valid: true},
+			TransferID: transferID,
+		})
+		return err
+	})
+
+	return result, err
+}
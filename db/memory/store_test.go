@@ -0,0 +1,43 @@
+package memorydb
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/db/storetest"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) db.Store {
+		return NewStore()
+	})
+}
+
+func TestExecTxPanic(t *testing.T) {
+	store := NewStore()
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username: util.RandomOwner(),
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	require.Panics(t, func() {
+		store.execTx(func(q *queries) error {
+			_, err := q.CreateAccount(context.Background(), db.CreateAccountParams{
+				Owner:    user.Username,
+				Currency: util.USD,
+			})
+			require.NoError(t, err)
+			panic("boom")
+		})
+	})
+
+	// the store is unlocked and the account created before the panic is gone
+	accounts, err := store.ListAccounts(context.Background(), db.ListAccountsParams{Owner: user.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
@@ -0,0 +1,224 @@
+package memorydb
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// tables holds the rows of every table, keyed by primary key
+type tables struct {
+	users     map[string]db.User
+	accounts  map[int64]db.Account
+	entries   map[int64]db.Entry
+	transfers map[int64]db.Transfer
+	sessions  map[uuid.UUID]db.Session
+	auditLogs map[int64]db.AuditLog
+
+	accessGrants       map[int64]db.AccessGrant
+	accountMembers     map[accountMemberKey]db.AccountMember
+	approvals          map[int64]db.Approval
+	archivedPartitions map[int64]db.ArchivedPartition
+	jobs               map[int64]db.Job
+	loginChallenges    map[int64]db.LoginChallenge
+	loginLockouts      map[loginLockoutKey]db.LoginLockout
+	moneyRequests      map[int64]db.MoneyRequest
+	payees             map[int64]db.Payee
+	rateLimits         map[rateLimitKey]db.RateLimit
+	recoveryCodes      map[int64]db.RecoveryCode
+	resetPasswords     map[int64]db.ResetPassword
+	riskDecisions      map[int64]db.RiskDecision
+	screeningHits      map[int64]db.ScreeningHit
+	twoFactors         map[string]db.TwoFactor
+	verifyEmails       map[int64]db.VerifyEmail
+
+	// sequences are never rolled back, like postgres ones
+	accountSeq  int64
+	entrySeq    int64
+	transferSeq int64
+	auditLogSeq int64
+
+	accessGrantSeq       int64
+	approvalSeq          int64
+	archivedPartitionSeq int64
+	jobSeq               int64
+	loginChallengeSeq    int64
+	moneyRequestSeq      int64
+	payeeSeq             int64
+	recoveryCodeSeq      int64
+	resetPasswordSeq     int64
+	riskDecisionSeq      int64
+	screeningHitSeq      int64
+	verifyEmailSeq       int64
+}
+
+func newTables() *tables {
+	return &tables{
+		users:     make(map[string]db.User),
+		accounts:  make(map[int64]db.Account),
+		entries:   make(map[int64]db.Entry),
+		transfers: make(map[int64]db.Transfer),
+		sessions:  make(map[uuid.UUID]db.Session),
+		auditLogs: make(map[int64]db.AuditLog),
+
+		accessGrants:       make(map[int64]db.AccessGrant),
+		accountMembers:     make(map[accountMemberKey]db.AccountMember),
+		approvals:          make(map[int64]db.Approval),
+		archivedPartitions: make(map[int64]db.ArchivedPartition),
+		jobs:               make(map[int64]db.Job),
+		loginChallenges:    make(map[int64]db.LoginChallenge),
+		loginLockouts:      make(map[loginLockoutKey]db.LoginLockout),
+		moneyRequests:      make(map[int64]db.MoneyRequest),
+		payees:             make(map[int64]db.Payee),
+		rateLimits:         make(map[rateLimitKey]db.RateLimit),
+		recoveryCodes:      make(map[int64]db.RecoveryCode),
+		resetPasswords:     make(map[int64]db.ResetPassword),
+		riskDecisions:      make(map[int64]db.RiskDecision),
+		screeningHits:      make(map[int64]db.ScreeningHit),
+		twoFactors:         make(map[string]db.TwoFactor),
+		verifyEmails:       make(map[int64]db.VerifyEmail),
+	}
+}
+
+// accountMemberKey is the primary key of account_members
+type accountMemberKey struct {
+	accountID int64
+	username  string
+}
+
+// loginLockoutKey is the primary key of login_lockouts
+type loginLockoutKey struct {
+	scope      string
+	identifier string
+}
+
+// rateLimitKey is the primary key of rate_limits
+type rateLimitKey struct {
+	scope      string
+	identifier string
+}
+
+// holdsAccount reports whether the user owns the account or has accepted to share it
+func (t *tables) holdsAccount(account db.Account, username string) bool {
+	if account.Owner == username {
+		return true
+	}
+	member, ok := t.accountMembers[accountMemberKey{account.ID, username}]
+	return ok && member.AcceptedAt.Valid
+}
+
+// now returns the current time with the precision of a postgres timestamptz
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// constraintError builds the error postgres returns when a constraint is violated,
+// so that db.ErrCode maps it the same way
+func constraintError(code string, constraintName string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           code,
+		Message:        "violates constraint " + constraintName,
+		ConstraintName: constraintName,
+	}
+}
+
+// sortedValues returns the rows of a table that match the filter, sorted with less
+func sortedValues[K comparable, V any](rows map[K]V, match func(V) bool, less func(a, b V) bool) []V {
+	items := []V{}
+	for _, row := range rows {
+		if match(row) {
+			items = append(items, row)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+	return items
+}
+
+// paginate applies LIMIT and OFFSET to sorted rows
+func paginate[V any](items []V, limit int32, offset int32) []V {
+	if offset < 0 {
+		offset = 0
+	}
+	if int(offset) >= len(items) {
+		return []V{}
+	}
+
+	items = items[offset:]
+	if limit >= 0 && int(limit) < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// keysetLess orders rows by (created_at, key) like the keyset queries' row comparisons
+func keysetLess[K ~int64 | ~string](a time.Time, aKey K, b time.Time, bKey K) bool {
+	if !a.Equal(b) {
+		return a.Before(b)
+	}
+	return aKey < bKey
+}
+
+// lockedLonger orders lockouts like the lockout listing, the longest locked first
+func lockedLonger(a, b db.LoginLockout) bool {
+	if !a.LockedUntil.Time.Equal(b.LockedUntil.Time) {
+		return a.LockedUntil.Time.After(b.LockedUntil.Time)
+	}
+	if a.Scope != b.Scope {
+		return a.Scope < b.Scope
+	}
+	return a.Identifier < b.Identifier
+}
+
+// compare returns -1, 0 or +1 like an ORDER BY on the column would
+func compare[T ~int64 | ~string](a T, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// searchLess orders rows by the sort column, then by their unique key,
+// both reversed when desc like the CASE expressions of the search queries
+func searchLess(column int, key int, desc bool) bool {
+	if column == 0 {
+		column = key
+	}
+	if desc {
+		return column > 0
+	}
+	return column < 0
+}
+
+// hasPrefixFold matches lower(s) LIKE lower(prefix) || '%'
+func hasPrefixFold(s string, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
+
+// hasElement matches value = ANY(array)
+func hasElement[T comparable](array []T, value T) bool {
+	for _, element := range array {
+		if element == value {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneJSON(data json.RawMessage) json.RawMessage {
+	if data == nil {
+		return nil
+	}
+	return append(json.RawMessage{}, data...)
+}
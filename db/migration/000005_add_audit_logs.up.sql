@@ -0,0 +1,35 @@
+CREATE TABLE "audit_logs" (
+  "id" bigserial PRIMARY KEY,
+  "request_id" varchar NOT NULL,
+  "actor" varchar NOT NULL,
+  "actor_role" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "route" varchar NOT NULL,
+  "resource_type" varchar NOT NULL,
+  "resource_id" varchar NOT NULL,
+  "status_code" int NOT NULL,
+  "changes" jsonb NOT NULL DEFAULT '{}',
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_logs" ("actor");
+
+CREATE INDEX ON "audit_logs" ("resource_type", "resource_id");
+
+CREATE INDEX ON "audit_logs" ("created_at");
+
+COMMENT ON TABLE "audit_logs" IS 'append-only record of every mutating api call';
+
+COMMENT ON COLUMN "audit_logs"."changes" IS 'changed fields with their before and after values';
+
+CREATE FUNCTION "reject_audit_log_change"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_logs_append_only"
+BEFORE UPDATE OR DELETE ON "audit_logs"
+FOR EACH ROW EXECUTE FUNCTION "reject_audit_log_change"();
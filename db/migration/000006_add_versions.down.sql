@@ -0,0 +1,3 @@
+ALTER TABLE "users" DROP COLUMN "version";
+
+ALTER TABLE "accounts" DROP COLUMN "version";
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE "users" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+
+ALTER TABLE "accounts" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "users"."version" IS 'incremented on every update, used for optimistic concurrency';
+
+COMMENT ON COLUMN "accounts"."version" IS 'incremented on every update, used for optimistic concurrency';
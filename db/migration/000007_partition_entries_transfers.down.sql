@@ -0,0 +1,51 @@
+-- rows of archived partitions stay in their archive files and are not restored
+
+CREATE TABLE "entries_unpartitioned" (LIKE "entries" INCLUDING DEFAULTS);
+
+CREATE TABLE "transfers_unpartitioned" (LIKE "transfers" INCLUDING DEFAULTS);
+
+INSERT INTO "entries_unpartitioned" SELECT * FROM "entries";
+
+INSERT INTO "transfers_unpartitioned" SELECT * FROM "transfers";
+
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries_unpartitioned"."id";
+
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers_unpartitioned"."id";
+
+DROP TABLE "entries";
+
+DROP TABLE "transfers";
+
+ALTER TABLE "entries_unpartitioned" RENAME TO "entries";
+
+ALTER TABLE "transfers_unpartitioned" RENAME TO "transfers";
+
+ALTER TABLE "entries" ADD PRIMARY KEY ("id");
+
+ALTER TABLE "transfers" ADD PRIMARY KEY ("id");
+
+CREATE INDEX ON "entries" ("account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id");
+
+CREATE INDEX ON "transfers" ("to_account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+
+COMMENT ON TABLE "entries" IS 'record balance changes';
+
+COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
+
+COMMENT ON TABLE "transfers" IS 'keep tack transfer history';
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+DROP FUNCTION IF EXISTS "create_monthly_partition";
+
+DROP TABLE IF EXISTS "archived_partitions";
@@ -0,0 +1,126 @@
+CREATE TABLE "archived_partitions" (
+  "id" bigserial PRIMARY KEY,
+  "table_name" varchar UNIQUE NOT NULL,
+  "parent_table" varchar NOT NULL,
+  "range_start" timestamptz NOT NULL,
+  "range_end" timestamptz NOT NULL,
+  "file_path" varchar NOT NULL,
+  "checksum" varchar NOT NULL,
+  "row_count" bigint NOT NULL,
+  "min_id" bigint NOT NULL,
+  "max_id" bigint NOT NULL,
+  "archived_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "archived_partitions" ("parent_table", "range_start");
+
+COMMENT ON TABLE "archived_partitions" IS 'monthly partitions exported to files and detached';
+
+COMMENT ON COLUMN "archived_partitions"."checksum" IS 'hex sha256 of the compressed archive file';
+
+-- create_monthly_partition adds the partition of parent_table covering the utc month of month.
+-- Rows that landed in the default partition while it was missing are moved into it.
+-- It returns false when the partition already exists or has been archived.
+CREATE FUNCTION "create_monthly_partition"(parent_table text, month timestamptz) RETURNS boolean AS $$
+DECLARE
+  range_start timestamptz := date_trunc('month', month AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+  range_end timestamptz := range_start + interval '1 month';
+  partition_name text := parent_table || '_' || to_char(range_start AT TIME ZONE 'UTC', 'YYYY_MM');
+BEGIN
+  IF to_regclass(partition_name) IS NOT NULL OR
+    EXISTS (SELECT 1 FROM "archived_partitions" WHERE "table_name" = partition_name) THEN
+    RETURN false;
+  END IF;
+
+  EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS)', partition_name, parent_table);
+  EXECUTE format(
+    'WITH moved AS (DELETE FROM %I WHERE created_at >= %L AND created_at < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+    parent_table || '_default', range_start, range_end, partition_name
+  );
+  EXECUTE format(
+    'ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+    parent_table, partition_name, range_start, range_end
+  );
+  RETURN true;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "entries" RENAME TO "entries_unpartitioned";
+
+ALTER TABLE "entries_unpartitioned" RENAME CONSTRAINT "entries_pkey" TO "entries_unpartitioned_pkey";
+
+ALTER TABLE "transfers" RENAME TO "transfers_unpartitioned";
+
+ALTER TABLE "transfers_unpartitioned" RENAME CONSTRAINT "transfers_pkey" TO "transfers_unpartitioned_pkey";
+
+CREATE TABLE "entries" (
+  "id" bigint NOT NULL DEFAULT nextval('entries_id_seq'),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("id", "created_at")
+) PARTITION BY RANGE ("created_at");
+
+CREATE TABLE "transfers" (
+  "id" bigint NOT NULL DEFAULT nextval('transfers_id_seq'),
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("id", "created_at")
+) PARTITION BY RANGE ("created_at");
+
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries"."id";
+
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers"."id";
+
+CREATE TABLE "entries_default" PARTITION OF "entries" DEFAULT;
+
+CREATE TABLE "transfers_default" PARTITION OF "transfers" DEFAULT;
+
+-- partition every month that has rows, plus the next three
+DO $$
+DECLARE
+  month timestamptz;
+BEGIN
+  month := LEAST(
+    COALESCE((SELECT min("created_at") FROM "entries_unpartitioned"), now()),
+    COALESCE((SELECT min("created_at") FROM "transfers_unpartitioned"), now())
+  );
+  WHILE month < now() + interval '3 months' LOOP
+    PERFORM create_monthly_partition('entries', month);
+    PERFORM create_monthly_partition('transfers', month);
+    month := month + interval '1 month';
+  END LOOP;
+END;
+$$;
+
+INSERT INTO "entries" SELECT "id", "account_id", "amount", "created_at" FROM "entries_unpartitioned";
+
+INSERT INTO "transfers" SELECT "id", "from_account_id", "to_account_id", "amount", "created_at" FROM "transfers_unpartitioned";
+
+DROP TABLE "entries_unpartitioned";
+
+DROP TABLE "transfers_unpartitioned";
+
+CREATE INDEX ON "entries" ("account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id");
+
+CREATE INDEX ON "transfers" ("to_account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+
+COMMENT ON TABLE "entries" IS 'record balance changes, partitioned by month of created_at';
+
+COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
+
+COMMENT ON TABLE "transfers" IS 'keep tack transfer history, partitioned by month of created_at';
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
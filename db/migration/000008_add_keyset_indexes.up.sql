@@ -0,0 +1,9 @@
+CREATE INDEX ON "accounts" ("owner", "created_at", "id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at", "id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at", "id");
+
+CREATE INDEX ON "transfers" ("to_account_id", "created_at", "id");
+
+CREATE INDEX ON "users" ("created_at", "username");
\ No newline at end of file
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS "users_full_name_prefix_idx";
+
+DROP INDEX IF EXISTS "users_email_prefix_idx";
+
+DROP INDEX IF EXISTS "users_username_prefix_idx";
+
+DROP FUNCTION IF EXISTS escape_like(text);
+
+DROP INDEX IF EXISTS "accounts_balance_idx";
+
+DROP INDEX IF EXISTS "accounts_currency_idx";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "status";
\ No newline at end of file
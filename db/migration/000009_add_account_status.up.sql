@@ -0,0 +1,20 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed';
+
+CREATE INDEX ON "accounts" ("currency");
+
+CREATE INDEX ON "accounts" ("balance");
+
+-- escape_like quotes the wildcards of a LIKE pattern so user input only ever matches literally
+CREATE FUNCTION escape_like(pattern text) RETURNS text AS $$
+  SELECT replace(replace(replace(pattern, '\', '\\'), '%', '\%'), '_', '\_')
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+CREATE INDEX "users_username_prefix_idx" ON "users" ("username" varchar_pattern_ops);
+
+CREATE INDEX "users_email_prefix_idx" ON "users" (lower("email") text_pattern_ops);
+
+CREATE INDEX "users_full_name_prefix_idx" ON "users" (lower("full_name") text_pattern_ops);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "disabled" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "users"."disabled" IS 'disabled users cannot log in';
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "permission" varchar NOT NULL,
+  "invited_by" varchar NOT NULL,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username"),
+  CONSTRAINT "account_members_permission_check" CHECK ("permission" IN ('view', 'transact', 'manage'))
+);
+
+CREATE INDEX ON "account_members" ("username");
+
+COMMENT ON TABLE "account_members" IS 'users sharing an account with its owner';
+
+COMMENT ON COLUMN "account_members"."permission" IS 'view, transact or manage';
+
+COMMENT ON COLUMN "account_members"."accepted_at" IS 'null while the invitation is pending';
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
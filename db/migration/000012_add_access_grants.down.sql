@@ -0,0 +1,3 @@
+ALTER TABLE "audit_logs" DROP COLUMN "grant_id";
+
+DROP TABLE IF EXISTS "access_grants";
\ No newline at end of file
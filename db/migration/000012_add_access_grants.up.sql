@@ -0,0 +1,34 @@
+CREATE TABLE "access_grants" (
+  "id" bigserial PRIMARY KEY,
+  "grantor" varchar NOT NULL,
+  "grantee" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "transfer_limit" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "access_grants_scopes_check" CHECK (cardinality("scopes") > 0 AND "scopes" <@ ARRAY['view', 'transfer']::varchar[]),
+  CONSTRAINT "access_grants_transfer_limit_check" CHECK ("transfer_limit" > 0),
+  CONSTRAINT "access_grants_grantee_check" CHECK ("grantee" <> "grantor")
+);
+
+CREATE INDEX ON "access_grants" ("grantor");
+
+CREATE INDEX ON "access_grants" ("grantee", "account_id");
+
+COMMENT ON TABLE "access_grants" IS 'access to an account delegated by one of its holders';
+
+COMMENT ON COLUMN "access_grants"."scopes" IS 'view or transfer';
+
+COMMENT ON COLUMN "access_grants"."transfer_limit" IS 'largest amount of a single transfer, null for no limit';
+
+ALTER TABLE "access_grants" ADD FOREIGN KEY ("grantor") REFERENCES "users" ("username");
+
+ALTER TABLE "access_grants" ADD FOREIGN KEY ("grantee") REFERENCES "users" ("username");
+
+ALTER TABLE "access_grants" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "audit_logs" ADD COLUMN "grant_id" bigint;
+
+COMMENT ON COLUMN "audit_logs"."grant_id" IS 'access grant the actor used as a delegate';
@@ -0,0 +1,17 @@
+CREATE TABLE "payees" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "nickname" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "owner_nickname_key" UNIQUE ("owner", "nickname")
+);
+
+COMMENT ON TABLE "payees" IS 'address book of accounts a user sends money to';
+
+COMMENT ON COLUMN "payees"."currency" IS 'currency of the payee account when it was saved';
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
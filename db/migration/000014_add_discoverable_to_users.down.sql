@@ -0,0 +1 @@
+ALTER TABLE "users" DROP COLUMN "discoverable";
\ No newline at end of file
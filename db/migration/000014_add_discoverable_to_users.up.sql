@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "discoverable" boolean NOT NULL DEFAULT true;
+
+COMMENT ON COLUMN "users"."discoverable" IS 'whether others can find the user by username or email to pay them';
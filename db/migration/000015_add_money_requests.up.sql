@@ -0,0 +1,37 @@
+CREATE TABLE "money_requests" (
+  "id" bigserial PRIMARY KEY,
+  "requester" varchar NOT NULL,
+  "payer" varchar NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "note" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "resolved_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "money_requests_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "money_requests_payer_check" CHECK ("payer" <> "requester"),
+  CONSTRAINT "money_requests_status_check" CHECK ("status" IN ('pending', 'accepted', 'declined', 'cancelled', 'expired'))
+);
+
+CREATE INDEX ON "money_requests" ("payer", "status");
+
+CREATE INDEX ON "money_requests" ("requester", "status");
+
+CREATE INDEX ON "money_requests" ("expires_at") WHERE "status" = 'pending';
+
+COMMENT ON TABLE "money_requests" IS 'requests to pay sent by one user to another';
+
+COMMENT ON COLUMN "money_requests"."to_account_id" IS 'account of the requester receiving the money';
+
+COMMENT ON COLUMN "money_requests"."status" IS 'pending, then accepted, declined, cancelled or expired';
+
+COMMENT ON COLUMN "money_requests"."transfer_id" IS 'transfer paying an accepted request';
+
+ALTER TABLE "money_requests" ADD FOREIGN KEY ("requester") REFERENCES "users" ("username");
+
+ALTER TABLE "money_requests" ADD FOREIGN KEY ("payer") REFERENCES "users" ("username");
+
+ALTER TABLE "money_requests" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
@@ -0,0 +1,50 @@
+CREATE TABLE "approvals" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "maker" varchar NOT NULL,
+  "checker" varchar,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint,
+  "amount" bigint,
+  "balance" bigint,
+  "account_version" bigint,
+  "currency" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "resolved_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "approvals_kind_check" CHECK ("kind" IN ('transfer', 'adjustment')),
+  CONSTRAINT "approvals_transfer_check" CHECK ("kind" <> 'transfer' OR ("to_account_id" IS NOT NULL AND "amount" IS NOT NULL AND "amount" > 0)),
+  CONSTRAINT "approvals_adjustment_check" CHECK ("kind" <> 'adjustment' OR ("balance" IS NOT NULL AND "balance" >= 0)),
+  CONSTRAINT "approvals_checker_check" CHECK ("checker" <> "maker"),
+  CONSTRAINT "approvals_status_check" CHECK ("status" IN ('pending', 'approved', 'rejected'))
+);
+
+CREATE INDEX ON "approvals" ("status");
+
+CREATE INDEX ON "approvals" ("expires_at") WHERE "status" = 'pending';
+
+COMMENT ON TABLE "approvals" IS 'operations held until a second banker approves them';
+
+COMMENT ON COLUMN "approvals"."kind" IS 'transfer above the approval threshold or balance adjustment';
+
+COMMENT ON COLUMN "approvals"."maker" IS 'user who asked for the operation';
+
+COMMENT ON COLUMN "approvals"."checker" IS 'banker who approved or rejected the operation, null when it timed out';
+
+COMMENT ON COLUMN "approvals"."account_id" IS 'account sending the transfer or adjusted';
+
+COMMENT ON COLUMN "approvals"."balance" IS 'balance an adjustment sets';
+
+COMMENT ON COLUMN "approvals"."account_version" IS 'version of the account an adjustment applies to';
+
+COMMENT ON COLUMN "approvals"."transfer_id" IS 'transfer executed on approval';
+
+ALTER TABLE "approvals" ADD FOREIGN KEY ("maker") REFERENCES "users" ("username");
+
+ALTER TABLE "approvals" ADD FOREIGN KEY ("checker") REFERENCES "users" ("username");
+
+ALTER TABLE "approvals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "approvals" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
@@ -0,0 +1,40 @@
+CREATE TABLE "risk_decisions" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "outcome" varchar NOT NULL,
+  "fired_rules" jsonb NOT NULL DEFAULT '[]',
+  "approval_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "risk_decisions_outcome_check" CHECK ("outcome" IN ('allow', 'review', 'block'))
+);
+
+CREATE INDEX ON "risk_decisions" ("username", "client_ip");
+
+CREATE INDEX ON "risk_decisions" ("outcome");
+
+CREATE INDEX ON "risk_decisions" ("approval_id");
+
+CREATE INDEX ON "sessions" ("username", "created_at");
+
+COMMENT ON TABLE "risk_decisions" IS 'outcome of the risk rules on every transfer asked for';
+
+COMMENT ON COLUMN "risk_decisions"."username" IS 'user who asked for the transfer';
+
+COMMENT ON COLUMN "risk_decisions"."outcome" IS 'allow, review or block';
+
+COMMENT ON COLUMN "risk_decisions"."fired_rules" IS 'rules that fired with their outcome and reason';
+
+COMMENT ON COLUMN "risk_decisions"."approval_id" IS 'approval the transfer was parked in for a banker to decide';
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("approval_id") REFERENCES "approvals" ("id") ON DELETE CASCADE;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "screening_hits";
\ No newline at end of file
@@ -0,0 +1,37 @@
+CREATE TABLE "screening_hits" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "screened_name" varchar NOT NULL,
+  "list_entry_id" varchar NOT NULL,
+  "listed_name" varchar NOT NULL,
+  "score" integer NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'open',
+  "reviewed_by" varchar,
+  "reviewed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "screening_hits_score_check" CHECK ("score" BETWEEN 0 AND 100),
+  CONSTRAINT "screening_hits_status_check" CHECK ("status" IN ('open', 'cleared', 'confirmed')),
+  CONSTRAINT "screening_hits_reviewer_check" CHECK ("reviewed_by" <> "username")
+);
+
+CREATE UNIQUE INDEX ON "screening_hits" ("username", "list_entry_id", "screened_name");
+
+CREATE INDEX ON "screening_hits" ("status");
+
+COMMENT ON TABLE "screening_hits" IS 'names of users matching an entry of the sanctions list';
+
+COMMENT ON COLUMN "screening_hits"."screened_name" IS 'full name of the user when it was screened';
+
+COMMENT ON COLUMN "screening_hits"."list_entry_id" IS 'id of the matching entry in the sanctions list';
+
+COMMENT ON COLUMN "screening_hits"."listed_name" IS 'name or alias of the entry that matched';
+
+COMMENT ON COLUMN "screening_hits"."score" IS 'similarity of the names from 0 to 100';
+
+COMMENT ON COLUMN "screening_hits"."status" IS 'open and confirmed hits block transfers of the user, cleared ones do not';
+
+COMMENT ON COLUMN "screening_hits"."reviewed_by" IS 'banker who cleared or confirmed the hit';
+
+ALTER TABLE "screening_hits" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "screening_hits" ADD FOREIGN KEY ("reviewed_by") REFERENCES "users" ("username");
@@ -0,0 +1,51 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "users"."is_email_verified" IS 'whether the user followed the verification link sent to their current email';
+
+CREATE TABLE "verify_emails" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "email" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "verify_emails" ("username");
+
+COMMENT ON TABLE "verify_emails" IS 'single-use tokens of the verification links sent to users';
+
+COMMENT ON COLUMN "verify_emails"."email" IS 'address the link was sent to, the user must still have it when following the link';
+
+COMMENT ON COLUMN "verify_emails"."token_hash" IS 'sha256 of the token in the link, the token itself is only in the email';
+
+ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "max_attempts" integer NOT NULL,
+  "last_error" varchar,
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "finished_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "jobs_status_check" CHECK ("status" IN ('pending', 'running', 'done', 'failed')),
+  CONSTRAINT "jobs_max_attempts_check" CHECK ("max_attempts" > 0)
+);
+
+CREATE INDEX ON "jobs" ("run_at") WHERE "status" IN ('pending', 'running');
+
+COMMENT ON TABLE "jobs" IS 'background jobs, claimed by the workers with skip locked';
+
+COMMENT ON COLUMN "jobs"."kind" IS 'selects the handler of the job, such as send_verify_email';
+
+COMMENT ON COLUMN "jobs"."status" IS 'running jobs past locked_until were left by a stopped worker and are claimed again';
+
+COMMENT ON COLUMN "jobs"."last_error" IS 'error of the last failed attempt';
+
+COMMENT ON COLUMN "jobs"."run_at" IS 'earliest time the next attempt runs';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "reset_passwords";
\ No newline at end of file
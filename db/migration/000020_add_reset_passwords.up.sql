@@ -0,0 +1,16 @@
+CREATE TABLE "reset_passwords" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "reset_passwords" ("username");
+
+COMMENT ON TABLE "reset_passwords" IS 'single-use tokens of the password reset links sent to users';
+
+COMMENT ON COLUMN "reset_passwords"."token_hash" IS 'sha256 of the token in the link, the token itself is only in the email';
+
+ALTER TABLE "reset_passwords" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,53 @@
+CREATE TABLE "two_factors" (
+  "username" varchar PRIMARY KEY,
+  "secret" varchar NOT NULL,
+  "is_enabled" boolean NOT NULL DEFAULT false,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "enabled_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "two_factors" IS 'totp secrets of the users who set up two-factor authentication';
+
+COMMENT ON COLUMN "two_factors"."secret" IS 'totp secret encrypted with aes-gcm under the two-factor secret key of the config, bound to the username';
+
+COMMENT ON COLUMN "two_factors"."is_enabled" IS 'false until the user confirms the secret with a first code';
+
+COMMENT ON COLUMN "two_factors"."last_used_step" IS 'time step of the last code used, older codes are refused so that none is used twice';
+
+ALTER TABLE "two_factors" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "code_hash");
+
+COMMENT ON TABLE "recovery_codes" IS 'single-use codes to log in without the authenticator app';
+
+COMMENT ON COLUMN "recovery_codes"."code_hash" IS 'sha256 of the normalized code, the code itself is only shown once to the user';
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE TABLE "login_challenges" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "login_challenges" IS 'logins of users with two-factor authentication waiting for their code';
+
+COMMENT ON COLUMN "login_challenges"."token_hash" IS 'sha256 of the challenge token returned by the first login step';
+
+COMMENT ON COLUMN "login_challenges"."attempts" IS 'wrong codes given, the challenge is refused once they reach the limit';
+
+ALTER TABLE "login_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
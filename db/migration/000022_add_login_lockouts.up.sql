@@ -0,0 +1,19 @@
+CREATE TABLE "login_lockouts" (
+  "scope" varchar NOT NULL,
+  "identifier" varchar NOT NULL,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  PRIMARY KEY ("scope", "identifier"),
+  CONSTRAINT "login_lockouts_scope_check" CHECK ("scope" IN ('username', 'client_ip'))
+);
+
+CREATE INDEX ON "login_lockouts" ("locked_until");
+
+COMMENT ON TABLE "login_lockouts" IS 'failed logins of usernames and client ips, which get locked out once they fail too often';
+
+COMMENT ON COLUMN "login_lockouts"."identifier" IS 'the username as typed, whether a user has it or not, or the client ip';
+
+COMMENT ON COLUMN "login_lockouts"."failed_attempts" IS 'failed logins since the count was last cleared, it starts over once the last failure is old enough';
+
+COMMENT ON COLUMN "login_lockouts"."locked_until" IS 'logins are refused until then, each failure past the threshold locks for longer';
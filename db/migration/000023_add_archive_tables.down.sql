@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "transfers_archive";
+
+DROP TABLE IF EXISTS "entries_archive";
+
+COMMENT ON TABLE "archived_partitions" IS 'monthly partitions exported to files and detached';
\ No newline at end of file
@@ -0,0 +1,28 @@
+CREATE TABLE "entries_archive" (
+  "id" bigint NOT NULL,
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id", "created_at")
+) PARTITION BY RANGE ("created_at");
+
+CREATE TABLE "transfers_archive" (
+  "id" bigint NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id", "created_at")
+) PARTITION BY RANGE ("created_at");
+
+CREATE INDEX ON "entries_archive" ("account_id");
+
+CREATE INDEX ON "transfers_archive" ("from_account_id");
+
+CREATE INDEX ON "transfers_archive" ("to_account_id");
+
+COMMENT ON TABLE "entries_archive" IS 'archived monthly partitions of entries, read by every server once the live rows run out';
+
+COMMENT ON TABLE "transfers_archive" IS 'archived monthly partitions of transfers, read by every server once the live rows run out';
+
+COMMENT ON TABLE "archived_partitions" IS 'monthly partitions moved to the archive tables, with an offline copy in a file';
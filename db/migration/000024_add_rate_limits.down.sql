@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "rate_limits";
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE "rate_limits" (
+  "scope" varchar NOT NULL,
+  "identifier" varchar NOT NULL,
+  "hits" int NOT NULL DEFAULT 0,
+  "window_started_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("scope", "identifier")
+);
+
+CREATE INDEX ON "rate_limits" ("window_started_at");
+
+COMMENT ON TABLE "rate_limits" IS 'requests of users, emails or client ips counted against the limits of the requests that can be abused';
+
+COMMENT ON COLUMN "rate_limits"."hits" IS 'requests since the window started, counted before they are served';
+
+COMMENT ON COLUMN "rate_limits"."window_started_at" IS 'the count starts over in a new window once this one is older than the window of the scope';
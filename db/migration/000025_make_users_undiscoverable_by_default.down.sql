@@ -0,0 +1,3 @@
+ALTER TABLE "users" ALTER COLUMN "discoverable" SET DEFAULT true;
+
+COMMENT ON COLUMN "users"."discoverable" IS 'whether others can find the user by username or email to pay them';
\ No newline at end of file
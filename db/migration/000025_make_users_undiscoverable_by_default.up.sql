@@ -0,0 +1,6 @@
+ALTER TABLE "users" ALTER COLUMN "discoverable" SET DEFAULT false;
+
+-- nobody chose to be listed when the column was added, users opt in from now on
+UPDATE "users" SET "discoverable" = false;
+
+COMMENT ON COLUMN "users"."discoverable" IS 'whether others can find the user by username or email to pay them, users opt in';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "money_requests_payer_created_at_id_idx";
+
+DROP INDEX IF EXISTS "money_requests_requester_created_at_id_idx";
+
+DROP INDEX IF EXISTS "approvals_created_at_id_idx";
+
+DROP INDEX IF EXISTS "risk_decisions_created_at_id_idx";
+
+DROP INDEX IF EXISTS "screening_hits_created_at_id_idx";
\ No newline at end of file
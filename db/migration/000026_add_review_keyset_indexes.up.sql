@@ -0,0 +1,9 @@
+CREATE INDEX ON "money_requests" ("payer", "created_at", "id");
+
+CREATE INDEX ON "money_requests" ("requester", "created_at", "id");
+
+CREATE INDEX ON "approvals" ("created_at", "id");
+
+CREATE INDEX ON "risk_decisions" ("created_at", "id");
+
+CREATE INDEX ON "screening_hits" ("created_at", "id");
\ No newline at end of file
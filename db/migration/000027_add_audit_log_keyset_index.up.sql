@@ -0,0 +1 @@
+CREATE INDEX ON "audit_logs" ("created_at", "id");
\ No newline at end of file
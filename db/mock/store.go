@@ -7,10 +7,12 @@ package mockdb
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	db "github.com/foyez/simplebank/db/sqlc"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
+	pgtype "github.com/jackc/pgx/v5/pgtype"
 )
 
 // MockStore is a mock of Store interface.
@@ -36,258 +38,1877 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AcceptAccountMember mocks base method.
+func (m *MockStore) AcceptAccountMember(arg0 context.Context, arg1 db.AcceptAccountMemberParams) (db.AccountMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptAccountMember", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptAccountMember indicates an expected call of AcceptAccountMember.
+func (mr *MockStoreMockRecorder) AcceptAccountMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptAccountMember", reflect.TypeOf((*MockStore)(nil).AcceptAccountMember), arg0, arg1)
+}
+
+// AcceptMoneyRequest mocks base method.
+func (m *MockStore) AcceptMoneyRequest(arg0 context.Context, arg1 db.AcceptMoneyRequestParams) (db.MoneyRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptMoneyRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.MoneyRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptMoneyRequest indicates an expected call of AcceptMoneyRequest.
+func (mr *MockStoreMockRecorder) AcceptMoneyRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptMoneyRequest", reflect.TypeOf((*MockStore)(nil).AcceptMoneyRequest), arg0, arg1)
+}
+
+// AcceptMoneyRequestTx mocks base method.
+func (m *MockStore) AcceptMoneyRequestTx(arg0 context.Context, arg1 db.AcceptMoneyRequestTxParams) (db.AcceptMoneyRequestTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptMoneyRequestTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AcceptMoneyRequestTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptMoneyRequestTx indicates an expected call of AcceptMoneyRequestTx.
+func (mr *MockStoreMockRecorder) AcceptMoneyRequestTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptMoneyRequestTx", reflect.TypeOf((*MockStore)(nil).AcceptMoneyRequestTx), arg0, arg1)
+}
+
 // AddAccountBalance mocks base method.
 func (m *MockStore) AddAccountBalance(arg0 context.Context, arg1 db.AddAccountBalanceParams) (db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddAccountBalance", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "AddAccountBalance", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountBalance indicates an expected call of AddAccountBalance.
+func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
+}
+
+// ApproveTx mocks base method.
+func (m *MockStore) ApproveTx(arg0 context.Context, arg1 db.ApproveTxParams) (db.ApproveTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ApproveTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveTx indicates an expected call of ApproveTx.
+func (mr *MockStoreMockRecorder) ApproveTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveTx", reflect.TypeOf((*MockStore)(nil).ApproveTx), arg0, arg1)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), arg0, arg1)
+}
+
+// ClaimJob mocks base method.
+func (m *MockStore) ClaimJob(arg0 context.Context, arg1 pgtype.Timestamptz) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJob indicates an expected call of ClaimJob.
+func (mr *MockStoreMockRecorder) ClaimJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJob", reflect.TypeOf((*MockStore)(nil).ClaimJob), arg0, arg1)
+}
+
+// CompleteJob mocks base method.
+func (m *MockStore) CompleteJob(arg0 context.Context, arg1 db.CompleteJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteJob indicates an expected call of CompleteJob.
+func (mr *MockStoreMockRecorder) CompleteJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteJob", reflect.TypeOf((*MockStore)(nil).CompleteJob), arg0, arg1)
+}
+
+// CompleteLoginChallengeTx mocks base method.
+func (m *MockStore) CompleteLoginChallengeTx(arg0 context.Context, arg1 db.CompleteLoginChallengeTxParams) (db.CompleteLoginChallengeTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLoginChallengeTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CompleteLoginChallengeTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteLoginChallengeTx indicates an expected call of CompleteLoginChallengeTx.
+func (mr *MockStoreMockRecorder) CompleteLoginChallengeTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLoginChallengeTx", reflect.TypeOf((*MockStore)(nil).CompleteLoginChallengeTx), arg0, arg1)
+}
+
+// CountArchivedEntries mocks base method.
+func (m *MockStore) CountArchivedEntries(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountArchivedEntries", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountArchivedEntries indicates an expected call of CountArchivedEntries.
+func (mr *MockStoreMockRecorder) CountArchivedEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountArchivedEntries", reflect.TypeOf((*MockStore)(nil).CountArchivedEntries), arg0, arg1)
+}
+
+// CountArchivedTransfers mocks base method.
+func (m *MockStore) CountArchivedTransfers(arg0 context.Context, arg1 db.CountArchivedTransfersParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountArchivedTransfers", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountArchivedTransfers indicates an expected call of CountArchivedTransfers.
+func (mr *MockStoreMockRecorder) CountArchivedTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountArchivedTransfers", reflect.TypeOf((*MockStore)(nil).CountArchivedTransfers), arg0, arg1)
+}
+
+// CountBlockingScreeningHits mocks base method.
+func (m *MockStore) CountBlockingScreeningHits(arg0 context.Context, arg1 []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountBlockingScreeningHits", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountBlockingScreeningHits indicates an expected call of CountBlockingScreeningHits.
+func (mr *MockStoreMockRecorder) CountBlockingScreeningHits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountBlockingScreeningHits", reflect.TypeOf((*MockStore)(nil).CountBlockingScreeningHits), arg0, arg1)
+}
+
+// CountRecoveryCodes mocks base method.
+func (m *MockStore) CountRecoveryCodes(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes.
+func (mr *MockStoreMockRecorder) CountRecoveryCodes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockStore)(nil).CountRecoveryCodes), arg0, arg1)
+}
+
+// CountRiskDecisionsFromIP mocks base method.
+func (m *MockStore) CountRiskDecisionsFromIP(arg0 context.Context, arg1 db.CountRiskDecisionsFromIPParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRiskDecisionsFromIP", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRiskDecisionsFromIP indicates an expected call of CountRiskDecisionsFromIP.
+func (mr *MockStoreMockRecorder) CountRiskDecisionsFromIP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRiskDecisionsFromIP", reflect.TypeOf((*MockStore)(nil).CountRiskDecisionsFromIP), arg0, arg1)
+}
+
+// CountTransfersBetween mocks base method.
+func (m *MockStore) CountTransfersBetween(arg0 context.Context, arg1 db.CountTransfersBetweenParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTransfersBetween", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTransfersBetween indicates an expected call of CountTransfersBetween.
+func (mr *MockStoreMockRecorder) CountTransfersBetween(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTransfersBetween", reflect.TypeOf((*MockStore)(nil).CountTransfersBetween), arg0, arg1)
+}
+
+// CreateAccessGrant mocks base method.
+func (m *MockStore) CreateAccessGrant(arg0 context.Context, arg1 db.CreateAccessGrantParams) (db.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessGrant", arg0, arg1)
+	ret0, _ := ret[0].(db.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessGrant indicates an expected call of CreateAccessGrant.
+func (mr *MockStoreMockRecorder) CreateAccessGrant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessGrant", reflect.TypeOf((*MockStore)(nil).CreateAccessGrant), arg0, arg1)
+}
+
+// CreateAccount mocks base method.
+func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
+}
+
+// CreateAccountMember mocks base method.
+func (m *MockStore) CreateAccountMember(arg0 context.Context, arg1 db.CreateAccountMemberParams) (db.AccountMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountMember", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountMember indicates an expected call of CreateAccountMember.
+func (mr *MockStoreMockRecorder) CreateAccountMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountMember", reflect.TypeOf((*MockStore)(nil).CreateAccountMember), arg0, arg1)
+}
+
+// CreateApproval mocks base method.
+func (m *MockStore) CreateApproval(arg0 context.Context, arg1 db.CreateApprovalParams) (db.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApproval", arg0, arg1)
+	ret0, _ := ret[0].(db.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApproval indicates an expected call of CreateApproval.
+func (mr *MockStoreMockRecorder) CreateApproval(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApproval", reflect.TypeOf((*MockStore)(nil).CreateApproval), arg0, arg1)
+}
+
+// CreateArchivedPartition mocks base method.
+func (m *MockStore) CreateArchivedPartition(arg0 context.Context, arg1 db.CreateArchivedPartitionParams) (db.ArchivedPartition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateArchivedPartition", arg0, arg1)
+	ret0, _ := ret[0].(db.ArchivedPartition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateArchivedPartition indicates an expected call of CreateArchivedPartition.
+func (mr *MockStoreMockRecorder) CreateArchivedPartition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateArchivedPartition", reflect.TypeOf((*MockStore)(nil).CreateArchivedPartition), arg0, arg1)
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockStore) CreateAuditLog(arg0 context.Context, arg1 db.CreateAuditLogParams) (db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockStoreMockRecorder) CreateAuditLog(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockStore)(nil).CreateAuditLog), arg0, arg1)
+}
+
+// CreateEntry mocks base method.
+func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEntry indicates an expected call of CreateEntry.
+func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
+}
+
+// CreateJob mocks base method.
+func (m *MockStore) CreateJob(arg0 context.Context, arg1 db.CreateJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockStoreMockRecorder) CreateJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockStore)(nil).CreateJob), arg0, arg1)
+}
+
+// CreateLoginChallenge mocks base method.
+func (m *MockStore) CreateLoginChallenge(arg0 context.Context, arg1 db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginChallenge", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLoginChallenge indicates an expected call of CreateLoginChallenge.
+func (mr *MockStoreMockRecorder) CreateLoginChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginChallenge", reflect.TypeOf((*MockStore)(nil).CreateLoginChallenge), arg0, arg1)
+}
+
+// CreateMoneyRequest mocks base method.
+func (m *MockStore) CreateMoneyRequest(arg0 context.Context, arg1 db.CreateMoneyRequestParams) (db.MoneyRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMoneyRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.MoneyRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMoneyRequest indicates an expected call of CreateMoneyRequest.
+func (mr *MockStoreMockRecorder) CreateMoneyRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMoneyRequest", reflect.TypeOf((*MockStore)(nil).CreateMoneyRequest), arg0, arg1)
+}
+
+// CreatePayee mocks base method.
+func (m *MockStore) CreatePayee(arg0 context.Context, arg1 db.CreatePayeeParams) (db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayee", arg0, arg1)
+	ret0, _ := ret[0].(db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayee indicates an expected call of CreatePayee.
+func (mr *MockStoreMockRecorder) CreatePayee(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayee", reflect.TypeOf((*MockStore)(nil).CreatePayee), arg0, arg1)
+}
+
+// CreateRecoveryCode mocks base method.
+func (m *MockStore) CreateRecoveryCode(arg0 context.Context, arg1 db.CreateRecoveryCodeParams) (db.RecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.RecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRecoveryCode indicates an expected call of CreateRecoveryCode.
+func (mr *MockStoreMockRecorder) CreateRecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecoveryCode", reflect.TypeOf((*MockStore)(nil).CreateRecoveryCode), arg0, arg1)
+}
+
+// CreateResetPassword mocks base method.
+func (m *MockStore) CreateResetPassword(arg0 context.Context, arg1 db.CreateResetPasswordParams) (db.ResetPassword, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateResetPassword", arg0, arg1)
+	ret0, _ := ret[0].(db.ResetPassword)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateResetPassword indicates an expected call of CreateResetPassword.
+func (mr *MockStoreMockRecorder) CreateResetPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResetPassword", reflect.TypeOf((*MockStore)(nil).CreateResetPassword), arg0, arg1)
+}
+
+// CreateRiskDecision mocks base method.
+func (m *MockStore) CreateRiskDecision(arg0 context.Context, arg1 db.CreateRiskDecisionParams) (db.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRiskDecision", arg0, arg1)
+	ret0, _ := ret[0].(db.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRiskDecision indicates an expected call of CreateRiskDecision.
+func (mr *MockStoreMockRecorder) CreateRiskDecision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRiskDecision", reflect.TypeOf((*MockStore)(nil).CreateRiskDecision), arg0, arg1)
+}
+
+// CreateScreeningHit mocks base method.
+func (m *MockStore) CreateScreeningHit(arg0 context.Context, arg1 db.CreateScreeningHitParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScreeningHit", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScreeningHit indicates an expected call of CreateScreeningHit.
+func (mr *MockStoreMockRecorder) CreateScreeningHit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScreeningHit", reflect.TypeOf((*MockStore)(nil).CreateScreeningHit), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+}
+
+// CreateTwoFactor mocks base method.
+func (m *MockStore) CreateTwoFactor(arg0 context.Context, arg1 db.CreateTwoFactorParams) (db.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTwoFactor", arg0, arg1)
+	ret0, _ := ret[0].(db.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTwoFactor indicates an expected call of CreateTwoFactor.
+func (mr *MockStoreMockRecorder) CreateTwoFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTwoFactor", reflect.TypeOf((*MockStore)(nil).CreateTwoFactor), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+}
+
+// CreateVerifyEmail mocks base method.
+func (m *MockStore) CreateVerifyEmail(arg0 context.Context, arg1 db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVerifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.VerifyEmail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVerifyEmail indicates an expected call of CreateVerifyEmail.
+func (mr *MockStoreMockRecorder) CreateVerifyEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerifyEmail", reflect.TypeOf((*MockStore)(nil).CreateVerifyEmail), arg0, arg1)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 db.DeleteAccountParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+}
+
+// DeleteAccountMember mocks base method.
+func (m *MockStore) DeleteAccountMember(arg0 context.Context, arg1 db.DeleteAccountMemberParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccountMember", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccountMember indicates an expected call of DeleteAccountMember.
+func (mr *MockStoreMockRecorder) DeleteAccountMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountMember", reflect.TypeOf((*MockStore)(nil).DeleteAccountMember), arg0, arg1)
+}
+
+// DeleteLoginLockout mocks base method.
+func (m *MockStore) DeleteLoginLockout(arg0 context.Context, arg1 db.DeleteLoginLockoutParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginLockout", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteLoginLockout indicates an expected call of DeleteLoginLockout.
+func (mr *MockStoreMockRecorder) DeleteLoginLockout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginLockout", reflect.TypeOf((*MockStore)(nil).DeleteLoginLockout), arg0, arg1)
+}
+
+// DeletePayee mocks base method.
+func (m *MockStore) DeletePayee(arg0 context.Context, arg1 db.DeletePayeeParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePayee", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePayee indicates an expected call of DeletePayee.
+func (mr *MockStoreMockRecorder) DeletePayee(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePayee", reflect.TypeOf((*MockStore)(nil).DeletePayee), arg0, arg1)
+}
+
+// DeleteRecoveryCodes mocks base method.
+func (m *MockStore) DeleteRecoveryCodes(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRecoveryCodes indicates an expected call of DeleteRecoveryCodes.
+func (mr *MockStoreMockRecorder) DeleteRecoveryCodes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecoveryCodes", reflect.TypeOf((*MockStore)(nil).DeleteRecoveryCodes), arg0, arg1)
+}
+
+// DeleteStaleLoginLockouts mocks base method.
+func (m *MockStore) DeleteStaleLoginLockouts(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleLoginLockouts", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStaleLoginLockouts indicates an expected call of DeleteStaleLoginLockouts.
+func (mr *MockStoreMockRecorder) DeleteStaleLoginLockouts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleLoginLockouts", reflect.TypeOf((*MockStore)(nil).DeleteStaleLoginLockouts), arg0, arg1)
+}
+
+// DeleteStaleRateLimits mocks base method.
+func (m *MockStore) DeleteStaleRateLimits(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleRateLimits", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStaleRateLimits indicates an expected call of DeleteStaleRateLimits.
+func (mr *MockStoreMockRecorder) DeleteStaleRateLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleRateLimits", reflect.TypeOf((*MockStore)(nil).DeleteStaleRateLimits), arg0, arg1)
+}
+
+// DeleteTwoFactor mocks base method.
+func (m *MockStore) DeleteTwoFactor(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTwoFactor", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTwoFactor indicates an expected call of DeleteTwoFactor.
+func (mr *MockStoreMockRecorder) DeleteTwoFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTwoFactor", reflect.TypeOf((*MockStore)(nil).DeleteTwoFactor), arg0, arg1)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockStore) EnableTwoFactor(arg0 context.Context, arg1 db.EnableTwoFactorParams) (db.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", arg0, arg1)
+	ret0, _ := ret[0].(db.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockStoreMockRecorder) EnableTwoFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockStore)(nil).EnableTwoFactor), arg0, arg1)
+}
+
+// EnableTwoFactorTx mocks base method.
+func (m *MockStore) EnableTwoFactorTx(arg0 context.Context, arg1 db.EnableTwoFactorTxParams) (db.EnableTwoFactorTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactorTx", arg0, arg1)
+	ret0, _ := ret[0].(db.EnableTwoFactorTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTwoFactorTx indicates an expected call of EnableTwoFactorTx.
+func (mr *MockStoreMockRecorder) EnableTwoFactorTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactorTx", reflect.TypeOf((*MockStore)(nil).EnableTwoFactorTx), arg0, arg1)
+}
+
+// ExpireMoneyRequests mocks base method.
+func (m *MockStore) ExpireMoneyRequests(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireMoneyRequests", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireMoneyRequests indicates an expected call of ExpireMoneyRequests.
+func (mr *MockStoreMockRecorder) ExpireMoneyRequests(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireMoneyRequests", reflect.TypeOf((*MockStore)(nil).ExpireMoneyRequests), arg0)
+}
+
+// FailJob mocks base method.
+func (m *MockStore) FailJob(arg0 context.Context, arg1 db.FailJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailJob indicates an expected call of FailJob.
+func (mr *MockStoreMockRecorder) FailJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailJob", reflect.TypeOf((*MockStore)(nil).FailJob), arg0, arg1)
+}
+
+// FailLoginChallenge mocks base method.
+func (m *MockStore) FailLoginChallenge(arg0 context.Context, arg1 int64) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailLoginChallenge", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailLoginChallenge indicates an expected call of FailLoginChallenge.
+func (mr *MockStoreMockRecorder) FailLoginChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailLoginChallenge", reflect.TypeOf((*MockStore)(nil).FailLoginChallenge), arg0, arg1)
+}
+
+// GetAccessGrant mocks base method.
+func (m *MockStore) GetAccessGrant(arg0 context.Context, arg1 int64) (db.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessGrant", arg0, arg1)
+	ret0, _ := ret[0].(db.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessGrant indicates an expected call of GetAccessGrant.
+func (mr *MockStoreMockRecorder) GetAccessGrant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessGrant", reflect.TypeOf((*MockStore)(nil).GetAccessGrant), arg0, arg1)
+}
+
+// GetAccount mocks base method.
+func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+}
+
+// GetAccountByOwnerCurrency mocks base method.
+func (m *MockStore) GetAccountByOwnerCurrency(arg0 context.Context, arg1 db.GetAccountByOwnerCurrencyParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByOwnerCurrency", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByOwnerCurrency indicates an expected call of GetAccountByOwnerCurrency.
+func (mr *MockStoreMockRecorder) GetAccountByOwnerCurrency(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByOwnerCurrency", reflect.TypeOf((*MockStore)(nil).GetAccountByOwnerCurrency), arg0, arg1)
+}
+
+// GetAccountForUpdate mocks base method.
+func (m *MockStore) GetAccountForUpdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
+func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
+}
+
+// GetAccountMember mocks base method.
+func (m *MockStore) GetAccountMember(arg0 context.Context, arg1 db.GetAccountMemberParams) (db.AccountMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountMember", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountMember indicates an expected call of GetAccountMember.
+func (mr *MockStoreMockRecorder) GetAccountMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountMember", reflect.TypeOf((*MockStore)(nil).GetAccountMember), arg0, arg1)
+}
+
+// GetActiveAccessGrant mocks base method.
+func (m *MockStore) GetActiveAccessGrant(arg0 context.Context, arg1 db.GetActiveAccessGrantParams) (db.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveAccessGrant", arg0, arg1)
+	ret0, _ := ret[0].(db.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveAccessGrant indicates an expected call of GetActiveAccessGrant.
+func (mr *MockStoreMockRecorder) GetActiveAccessGrant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveAccessGrant", reflect.TypeOf((*MockStore)(nil).GetActiveAccessGrant), arg0, arg1)
+}
+
+// GetApproval mocks base method.
+func (m *MockStore) GetApproval(arg0 context.Context, arg1 int64) (db.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApproval", arg0, arg1)
+	ret0, _ := ret[0].(db.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApproval indicates an expected call of GetApproval.
+func (mr *MockStoreMockRecorder) GetApproval(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApproval", reflect.TypeOf((*MockStore)(nil).GetApproval), arg0, arg1)
+}
+
+// GetArchivedEntry mocks base method.
+func (m *MockStore) GetArchivedEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchivedEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchivedEntry indicates an expected call of GetArchivedEntry.
+func (mr *MockStoreMockRecorder) GetArchivedEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchivedEntry", reflect.TypeOf((*MockStore)(nil).GetArchivedEntry), arg0, arg1)
+}
+
+// GetArchivedTransfer mocks base method.
+func (m *MockStore) GetArchivedTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchivedTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchivedTransfer indicates an expected call of GetArchivedTransfer.
+func (mr *MockStoreMockRecorder) GetArchivedTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchivedTransfer", reflect.TypeOf((*MockStore)(nil).GetArchivedTransfer), arg0, arg1)
+}
+
+// GetClientIPHistory mocks base method.
+func (m *MockStore) GetClientIPHistory(arg0 context.Context, arg1 db.GetClientIPHistoryParams) (db.GetClientIPHistoryRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClientIPHistory", arg0, arg1)
+	ret0, _ := ret[0].(db.GetClientIPHistoryRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClientIPHistory indicates an expected call of GetClientIPHistory.
+func (mr *MockStoreMockRecorder) GetClientIPHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClientIPHistory", reflect.TypeOf((*MockStore)(nil).GetClientIPHistory), arg0, arg1)
+}
+
+// GetDiscoverableUser mocks base method.
+func (m *MockStore) GetDiscoverableUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiscoverableUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDiscoverableUser indicates an expected call of GetDiscoverableUser.
+func (mr *MockStoreMockRecorder) GetDiscoverableUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiscoverableUser", reflect.TypeOf((*MockStore)(nil).GetDiscoverableUser), arg0, arg1)
+}
+
+// GetEntry mocks base method.
+func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+}
+
+// GetJob mocks base method.
+func (m *MockStore) GetJob(arg0 context.Context, arg1 int64) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockStoreMockRecorder) GetJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockStore)(nil).GetJob), arg0, arg1)
+}
+
+// GetLoginChallenge mocks base method.
+func (m *MockStore) GetLoginChallenge(arg0 context.Context, arg1 string) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginChallenge", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginChallenge indicates an expected call of GetLoginChallenge.
+func (mr *MockStoreMockRecorder) GetLoginChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginChallenge", reflect.TypeOf((*MockStore)(nil).GetLoginChallenge), arg0, arg1)
+}
+
+// GetLoginLockout mocks base method.
+func (m *MockStore) GetLoginLockout(arg0 context.Context, arg1 db.GetLoginLockoutParams) (db.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginLockout", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginLockout indicates an expected call of GetLoginLockout.
+func (mr *MockStoreMockRecorder) GetLoginLockout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLockout", reflect.TypeOf((*MockStore)(nil).GetLoginLockout), arg0, arg1)
+}
+
+// GetMoneyRequest mocks base method.
+func (m *MockStore) GetMoneyRequest(arg0 context.Context, arg1 int64) (db.MoneyRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMoneyRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.MoneyRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMoneyRequest indicates an expected call of GetMoneyRequest.
+func (mr *MockStoreMockRecorder) GetMoneyRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMoneyRequest", reflect.TypeOf((*MockStore)(nil).GetMoneyRequest), arg0, arg1)
+}
+
+// GetPayee mocks base method.
+func (m *MockStore) GetPayee(arg0 context.Context, arg1 int64) (db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayee", arg0, arg1)
+	ret0, _ := ret[0].(db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayee indicates an expected call of GetPayee.
+func (mr *MockStoreMockRecorder) GetPayee(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayee", reflect.TypeOf((*MockStore)(nil).GetPayee), arg0, arg1)
+}
+
+// GetScreeningHit mocks base method.
+func (m *MockStore) GetScreeningHit(arg0 context.Context, arg1 int64) (db.ScreeningHit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScreeningHit", arg0, arg1)
+	ret0, _ := ret[0].(db.ScreeningHit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScreeningHit indicates an expected call of GetScreeningHit.
+func (mr *MockStoreMockRecorder) GetScreeningHit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScreeningHit", reflect.TypeOf((*MockStore)(nil).GetScreeningHit), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+}
+
+// GetTwoFactor mocks base method.
+func (m *MockStore) GetTwoFactor(arg0 context.Context, arg1 string) (db.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactor", arg0, arg1)
+	ret0, _ := ret[0].(db.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactor indicates an expected call of GetTwoFactor.
+func (mr *MockStoreMockRecorder) GetTwoFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactor", reflect.TypeOf((*MockStore)(nil).GetTwoFactor), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserAccess mocks base method.
+func (m *MockStore) GetUserAccess(arg0 context.Context, arg1 string) (db.GetUserAccessRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAccess", arg0, arg1)
+	ret0, _ := ret[0].(db.GetUserAccessRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAccess indicates an expected call of GetUserAccess.
+func (mr *MockStoreMockRecorder) GetUserAccess(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAccess", reflect.TypeOf((*MockStore)(nil).GetUserAccess), arg0, arg1)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
+}
+
+// HitRateLimit mocks base method.
+func (m *MockStore) HitRateLimit(arg0 context.Context, arg1 db.HitRateLimitParams) (db.RateLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HitRateLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.RateLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HitRateLimit indicates an expected call of HitRateLimit.
+func (mr *MockStoreMockRecorder) HitRateLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HitRateLimit", reflect.TypeOf((*MockStore)(nil).HitRateLimit), arg0, arg1)
+}
+
+// ListAccessGrants mocks base method.
+func (m *MockStore) ListAccessGrants(arg0 context.Context, arg1 string) ([]db.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccessGrants", arg0, arg1)
+	ret0, _ := ret[0].([]db.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccessGrants indicates an expected call of ListAccessGrants.
+func (mr *MockStoreMockRecorder) ListAccessGrants(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccessGrants", reflect.TypeOf((*MockStore)(nil).ListAccessGrants), arg0, arg1)
+}
+
+// ListAccountInvitations mocks base method.
+func (m *MockStore) ListAccountInvitations(arg0 context.Context, arg1 string) ([]db.AccountMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountInvitations", arg0, arg1)
+	ret0, _ := ret[0].([]db.AccountMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountInvitations indicates an expected call of ListAccountInvitations.
+func (mr *MockStoreMockRecorder) ListAccountInvitations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountInvitations", reflect.TypeOf((*MockStore)(nil).ListAccountInvitations), arg0, arg1)
+}
+
+// ListAccountMembers mocks base method.
+func (m *MockStore) ListAccountMembers(arg0 context.Context, arg1 int64) ([]db.AccountMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountMembers", arg0, arg1)
+	ret0, _ := ret[0].([]db.AccountMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountMembers indicates an expected call of ListAccountMembers.
+func (mr *MockStoreMockRecorder) ListAccountMembers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountMembers", reflect.TypeOf((*MockStore)(nil).ListAccountMembers), arg0, arg1)
+}
+
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+}
+
+// ListAccountsAfter mocks base method.
+func (m *MockStore) ListAccountsAfter(arg0 context.Context, arg1 db.ListAccountsAfterParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsAfter indicates an expected call of ListAccountsAfter.
+func (mr *MockStoreMockRecorder) ListAccountsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsAfter", reflect.TypeOf((*MockStore)(nil).ListAccountsAfter), arg0, arg1)
+}
+
+// ListAccountsBefore mocks base method.
+func (m *MockStore) ListAccountsBefore(arg0 context.Context, arg1 db.ListAccountsBeforeParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsBefore indicates an expected call of ListAccountsBefore.
+func (mr *MockStoreMockRecorder) ListAccountsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsBefore", reflect.TypeOf((*MockStore)(nil).ListAccountsBefore), arg0, arg1)
+}
+
+// ListApprovalsAfter mocks base method.
+func (m *MockStore) ListApprovalsAfter(arg0 context.Context, arg1 db.ListApprovalsAfterParams) ([]db.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApprovalsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApprovalsAfter indicates an expected call of ListApprovalsAfter.
+func (mr *MockStoreMockRecorder) ListApprovalsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApprovalsAfter", reflect.TypeOf((*MockStore)(nil).ListApprovalsAfter), arg0, arg1)
+}
+
+// ListApprovalsBefore mocks base method.
+func (m *MockStore) ListApprovalsBefore(arg0 context.Context, arg1 db.ListApprovalsBeforeParams) ([]db.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApprovalsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApprovalsBefore indicates an expected call of ListApprovalsBefore.
+func (mr *MockStoreMockRecorder) ListApprovalsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApprovalsBefore", reflect.TypeOf((*MockStore)(nil).ListApprovalsBefore), arg0, arg1)
+}
+
+// ListArchivedEntries mocks base method.
+func (m *MockStore) ListArchivedEntries(arg0 context.Context, arg1 db.ListArchivedEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedEntries indicates an expected call of ListArchivedEntries.
+func (mr *MockStoreMockRecorder) ListArchivedEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedEntries", reflect.TypeOf((*MockStore)(nil).ListArchivedEntries), arg0, arg1)
+}
+
+// ListArchivedEntriesAfter mocks base method.
+func (m *MockStore) ListArchivedEntriesAfter(arg0 context.Context, arg1 db.ListArchivedEntriesAfterParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedEntriesAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedEntriesAfter indicates an expected call of ListArchivedEntriesAfter.
+func (mr *MockStoreMockRecorder) ListArchivedEntriesAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedEntriesAfter", reflect.TypeOf((*MockStore)(nil).ListArchivedEntriesAfter), arg0, arg1)
+}
+
+// ListArchivedEntriesBefore mocks base method.
+func (m *MockStore) ListArchivedEntriesBefore(arg0 context.Context, arg1 db.ListArchivedEntriesBeforeParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedEntriesBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedEntriesBefore indicates an expected call of ListArchivedEntriesBefore.
+func (mr *MockStoreMockRecorder) ListArchivedEntriesBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedEntriesBefore", reflect.TypeOf((*MockStore)(nil).ListArchivedEntriesBefore), arg0, arg1)
+}
+
+// ListArchivedPartitions mocks base method.
+func (m *MockStore) ListArchivedPartitions(arg0 context.Context, arg1 string) ([]db.ArchivedPartition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedPartitions", arg0, arg1)
+	ret0, _ := ret[0].([]db.ArchivedPartition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedPartitions indicates an expected call of ListArchivedPartitions.
+func (mr *MockStoreMockRecorder) ListArchivedPartitions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedPartitions", reflect.TypeOf((*MockStore)(nil).ListArchivedPartitions), arg0, arg1)
+}
+
+// ListArchivedTransfers mocks base method.
+func (m *MockStore) ListArchivedTransfers(arg0 context.Context, arg1 db.ListArchivedTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedTransfers indicates an expected call of ListArchivedTransfers.
+func (mr *MockStoreMockRecorder) ListArchivedTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedTransfers", reflect.TypeOf((*MockStore)(nil).ListArchivedTransfers), arg0, arg1)
+}
+
+// ListArchivedTransfersAfter mocks base method.
+func (m *MockStore) ListArchivedTransfersAfter(arg0 context.Context, arg1 db.ListArchivedTransfersAfterParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedTransfersAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedTransfersAfter indicates an expected call of ListArchivedTransfersAfter.
+func (mr *MockStoreMockRecorder) ListArchivedTransfersAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedTransfersAfter", reflect.TypeOf((*MockStore)(nil).ListArchivedTransfersAfter), arg0, arg1)
+}
+
+// ListArchivedTransfersBefore mocks base method.
+func (m *MockStore) ListArchivedTransfersBefore(arg0 context.Context, arg1 db.ListArchivedTransfersBeforeParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedTransfersBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedTransfersBefore indicates an expected call of ListArchivedTransfersBefore.
+func (mr *MockStoreMockRecorder) ListArchivedTransfersBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedTransfersBefore", reflect.TypeOf((*MockStore)(nil).ListArchivedTransfersBefore), arg0, arg1)
+}
+
+// ListAuditLogsAfter mocks base method.
+func (m *MockStore) ListAuditLogsAfter(arg0 context.Context, arg1 db.ListAuditLogsAfterParams) ([]db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditLogsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditLogsAfter indicates an expected call of ListAuditLogsAfter.
+func (mr *MockStoreMockRecorder) ListAuditLogsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditLogsAfter", reflect.TypeOf((*MockStore)(nil).ListAuditLogsAfter), arg0, arg1)
+}
+
+// ListAuditLogsBefore mocks base method.
+func (m *MockStore) ListAuditLogsBefore(arg0 context.Context, arg1 db.ListAuditLogsBeforeParams) ([]db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditLogsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditLogsBefore indicates an expected call of ListAuditLogsBefore.
+func (mr *MockStoreMockRecorder) ListAuditLogsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditLogsBefore", reflect.TypeOf((*MockStore)(nil).ListAuditLogsBefore), arg0, arg1)
+}
+
+// ListEntries mocks base method.
+func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+}
+
+// ListEntriesAfter mocks base method.
+func (m *MockStore) ListEntriesAfter(arg0 context.Context, arg1 db.ListEntriesAfterParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntriesAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntriesAfter indicates an expected call of ListEntriesAfter.
+func (mr *MockStoreMockRecorder) ListEntriesAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntriesAfter", reflect.TypeOf((*MockStore)(nil).ListEntriesAfter), arg0, arg1)
+}
+
+// ListEntriesBefore mocks base method.
+func (m *MockStore) ListEntriesBefore(arg0 context.Context, arg1 db.ListEntriesBeforeParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntriesBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntriesBefore indicates an expected call of ListEntriesBefore.
+func (mr *MockStoreMockRecorder) ListEntriesBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntriesBefore", reflect.TypeOf((*MockStore)(nil).ListEntriesBefore), arg0, arg1)
+}
+
+// ListIncomingMoneyRequestsAfter mocks base method.
+func (m *MockStore) ListIncomingMoneyRequestsAfter(arg0 context.Context, arg1 db.ListIncomingMoneyRequestsAfterParams) ([]db.MoneyRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIncomingMoneyRequestsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.MoneyRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIncomingMoneyRequestsAfter indicates an expected call of ListIncomingMoneyRequestsAfter.
+func (mr *MockStoreMockRecorder) ListIncomingMoneyRequestsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIncomingMoneyRequestsAfter", reflect.TypeOf((*MockStore)(nil).ListIncomingMoneyRequestsAfter), arg0, arg1)
+}
+
+// ListIncomingMoneyRequestsBefore mocks base method.
+func (m *MockStore) ListIncomingMoneyRequestsBefore(arg0 context.Context, arg1 db.ListIncomingMoneyRequestsBeforeParams) ([]db.MoneyRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIncomingMoneyRequestsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.MoneyRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIncomingMoneyRequestsBefore indicates an expected call of ListIncomingMoneyRequestsBefore.
+func (mr *MockStoreMockRecorder) ListIncomingMoneyRequestsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIncomingMoneyRequestsBefore", reflect.TypeOf((*MockStore)(nil).ListIncomingMoneyRequestsBefore), arg0, arg1)
+}
+
+// ListLoginLockoutsAfter mocks base method.
+func (m *MockStore) ListLoginLockoutsAfter(arg0 context.Context, arg1 db.ListLoginLockoutsAfterParams) ([]db.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginLockoutsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginLockoutsAfter indicates an expected call of ListLoginLockoutsAfter.
+func (mr *MockStoreMockRecorder) ListLoginLockoutsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginLockoutsAfter", reflect.TypeOf((*MockStore)(nil).ListLoginLockoutsAfter), arg0, arg1)
+}
+
+// ListLoginLockoutsBefore mocks base method.
+func (m *MockStore) ListLoginLockoutsBefore(arg0 context.Context, arg1 db.ListLoginLockoutsBeforeParams) ([]db.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginLockoutsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginLockoutsBefore indicates an expected call of ListLoginLockoutsBefore.
+func (mr *MockStoreMockRecorder) ListLoginLockoutsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginLockoutsBefore", reflect.TypeOf((*MockStore)(nil).ListLoginLockoutsBefore), arg0, arg1)
+}
+
+// ListOutgoingMoneyRequestsAfter mocks base method.
+func (m *MockStore) ListOutgoingMoneyRequestsAfter(arg0 context.Context, arg1 db.ListOutgoingMoneyRequestsAfterParams) ([]db.MoneyRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOutgoingMoneyRequestsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.MoneyRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOutgoingMoneyRequestsAfter indicates an expected call of ListOutgoingMoneyRequestsAfter.
+func (mr *MockStoreMockRecorder) ListOutgoingMoneyRequestsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOutgoingMoneyRequestsAfter", reflect.TypeOf((*MockStore)(nil).ListOutgoingMoneyRequestsAfter), arg0, arg1)
+}
+
+// ListOutgoingMoneyRequestsBefore mocks base method.
+func (m *MockStore) ListOutgoingMoneyRequestsBefore(arg0 context.Context, arg1 db.ListOutgoingMoneyRequestsBeforeParams) ([]db.MoneyRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOutgoingMoneyRequestsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.MoneyRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOutgoingMoneyRequestsBefore indicates an expected call of ListOutgoingMoneyRequestsBefore.
+func (mr *MockStoreMockRecorder) ListOutgoingMoneyRequestsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOutgoingMoneyRequestsBefore", reflect.TypeOf((*MockStore)(nil).ListOutgoingMoneyRequestsBefore), arg0, arg1)
+}
+
+// ListPayees mocks base method.
+func (m *MockStore) ListPayees(arg0 context.Context, arg1 string) ([]db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPayees", arg0, arg1)
+	ret0, _ := ret[0].([]db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPayees indicates an expected call of ListPayees.
+func (mr *MockStoreMockRecorder) ListPayees(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPayees", reflect.TypeOf((*MockStore)(nil).ListPayees), arg0, arg1)
+}
+
+// ListRiskDecisionsAfter mocks base method.
+func (m *MockStore) ListRiskDecisionsAfter(arg0 context.Context, arg1 db.ListRiskDecisionsAfterParams) ([]db.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRiskDecisionsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRiskDecisionsAfter indicates an expected call of ListRiskDecisionsAfter.
+func (mr *MockStoreMockRecorder) ListRiskDecisionsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRiskDecisionsAfter", reflect.TypeOf((*MockStore)(nil).ListRiskDecisionsAfter), arg0, arg1)
+}
+
+// ListRiskDecisionsBefore mocks base method.
+func (m *MockStore) ListRiskDecisionsBefore(arg0 context.Context, arg1 db.ListRiskDecisionsBeforeParams) ([]db.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRiskDecisionsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRiskDecisionsBefore indicates an expected call of ListRiskDecisionsBefore.
+func (mr *MockStoreMockRecorder) ListRiskDecisionsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRiskDecisionsBefore", reflect.TypeOf((*MockStore)(nil).ListRiskDecisionsBefore), arg0, arg1)
+}
+
+// ListScreeningHitsAfter mocks base method.
+func (m *MockStore) ListScreeningHitsAfter(arg0 context.Context, arg1 db.ListScreeningHitsAfterParams) ([]db.ScreeningHit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScreeningHitsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.ScreeningHit)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddAccountBalance indicates an expected call of AddAccountBalance.
-func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomock.Call {
+// ListScreeningHitsAfter indicates an expected call of ListScreeningHitsAfter.
+func (mr *MockStoreMockRecorder) ListScreeningHitsAfter(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScreeningHitsAfter", reflect.TypeOf((*MockStore)(nil).ListScreeningHitsAfter), arg0, arg1)
 }
 
-// CreateAccount mocks base method.
-func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
+// ListScreeningHitsBefore mocks base method.
+func (m *MockStore) ListScreeningHitsBefore(arg0 context.Context, arg1 db.ListScreeningHitsBeforeParams) ([]db.ScreeningHit, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "ListScreeningHitsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.ScreeningHit)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.Call {
+// ListScreeningHitsBefore indicates an expected call of ListScreeningHitsBefore.
+func (mr *MockStoreMockRecorder) ListScreeningHitsBefore(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScreeningHitsBefore", reflect.TypeOf((*MockStore)(nil).ListScreeningHitsBefore), arg0, arg1)
 }
 
-// CreateEntry mocks base method.
-func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
+// ListTransfers mocks base method.
+func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateEntry indicates an expected call of CreateEntry.
-func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Call {
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
 }
 
-// CreateSession mocks base method.
-func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+// ListTransfersAfter mocks base method.
+func (m *MockStore) ListTransfersAfter(arg0 context.Context, arg1 db.ListTransfersAfterParams) ([]db.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "ListTransfersAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateSession indicates an expected call of CreateSession.
-func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+// ListTransfersAfter indicates an expected call of ListTransfersAfter.
+func (mr *MockStoreMockRecorder) ListTransfersAfter(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfersAfter", reflect.TypeOf((*MockStore)(nil).ListTransfersAfter), arg0, arg1)
 }
 
-// CreateTransfer mocks base method.
-func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+// ListTransfersBefore mocks base method.
+func (m *MockStore) ListTransfersBefore(arg0 context.Context, arg1 db.ListTransfersBeforeParams) ([]db.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "ListTransfersBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateTransfer indicates an expected call of CreateTransfer.
-func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+// ListTransfersBefore indicates an expected call of ListTransfersBefore.
+func (mr *MockStoreMockRecorder) ListTransfersBefore(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfersBefore", reflect.TypeOf((*MockStore)(nil).ListTransfersBefore), arg0, arg1)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+// ListTransfersSince mocks base method.
+func (m *MockStore) ListTransfersSince(arg0 context.Context, arg1 db.ListTransfersSinceParams) ([]db.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListTransfersSince", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+// ListTransfersSince indicates an expected call of ListTransfersSince.
+func (mr *MockStoreMockRecorder) ListTransfersSince(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfersSince", reflect.TypeOf((*MockStore)(nil).ListTransfersSince), arg0, arg1)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+// ListUsersAfter mocks base method.
+func (m *MockStore) ListUsersAfter(arg0 context.Context, arg1 db.ListUsersAfterParams) ([]db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret := m.ctrl.Call(m, "ListUsersAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersAfter indicates an expected call of ListUsersAfter.
+func (mr *MockStoreMockRecorder) ListUsersAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersAfter", reflect.TypeOf((*MockStore)(nil).ListUsersAfter), arg0, arg1)
+}
+
+// ListUsersBefore mocks base method.
+func (m *MockStore) ListUsersBefore(arg0 context.Context, arg1 db.ListUsersBeforeParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersBefore indicates an expected call of ListUsersBefore.
+func (mr *MockStoreMockRecorder) ListUsersBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersBefore", reflect.TypeOf((*MockStore)(nil).ListUsersBefore), arg0, arg1)
+}
+
+// ListenAccountEvents mocks base method.
+func (m *MockStore) ListenAccountEvents(arg0 context.Context, arg1 func(db.AccountEvent)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListenAccountEvents", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+// ListenAccountEvents indicates an expected call of ListenAccountEvents.
+func (mr *MockStoreMockRecorder) ListenAccountEvents(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenAccountEvents", reflect.TypeOf((*MockStore)(nil).ListenAccountEvents), arg0, arg1)
 }
 
-// GetAccount mocks base method.
-func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+// NotifyAccountEvent mocks base method.
+func (m *MockStore) NotifyAccountEvent(arg0 context.Context, arg1 db.NotifyAccountEventParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "NotifyAccountEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifyAccountEvent indicates an expected call of NotifyAccountEvent.
+func (mr *MockStoreMockRecorder) NotifyAccountEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyAccountEvent", reflect.TypeOf((*MockStore)(nil).NotifyAccountEvent), arg0, arg1)
+}
+
+// RefundLoginAttempt mocks base method.
+func (m *MockStore) RefundLoginAttempt(arg0 context.Context, arg1 db.RefundLoginAttemptParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundLoginAttempt", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+// RefundLoginAttempt indicates an expected call of RefundLoginAttempt.
+func (mr *MockStoreMockRecorder) RefundLoginAttempt(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundLoginAttempt", reflect.TypeOf((*MockStore)(nil).RefundLoginAttempt), arg0, arg1)
 }
 
-// GetAccountForUpdate mocks base method.
-func (m *MockStore) GetAccountForUpdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+// RehashUserPassword mocks base method.
+func (m *MockStore) RehashUserPassword(arg0 context.Context, arg1 db.RehashUserPasswordParams) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountForUpdate", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "RehashUserPassword", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
-func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 interface{}) *gomock.Call {
+// RehashUserPassword indicates an expected call of RehashUserPassword.
+func (mr *MockStoreMockRecorder) RehashUserPassword(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashUserPassword", reflect.TypeOf((*MockStore)(nil).RehashUserPassword), arg0, arg1)
 }
 
-// GetEntry mocks base method.
-func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+// RejectExpiredApprovals mocks base method.
+func (m *MockStore) RejectExpiredApprovals(arg0 context.Context) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "RejectExpiredApprovals", arg0)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEntry indicates an expected call of GetEntry.
-func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+// RejectExpiredApprovals indicates an expected call of RejectExpiredApprovals.
+func (mr *MockStoreMockRecorder) RejectExpiredApprovals(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectExpiredApprovals", reflect.TypeOf((*MockStore)(nil).RejectExpiredApprovals), arg0)
 }
 
-// GetSession mocks base method.
-func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+// ReserveLoginAttempt mocks base method.
+func (m *MockStore) ReserveLoginAttempt(arg0 context.Context, arg1 db.ReserveLoginAttemptParams) (db.LoginLockout, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "ReserveLoginAttempt", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginLockout)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSession indicates an expected call of GetSession.
-func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+// ReserveLoginAttempt indicates an expected call of ReserveLoginAttempt.
+func (mr *MockStoreMockRecorder) ReserveLoginAttempt(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveLoginAttempt", reflect.TypeOf((*MockStore)(nil).ReserveLoginAttempt), arg0, arg1)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+// ResetPasswordTx mocks base method.
+func (m *MockStore) ResetPasswordTx(arg0 context.Context, arg1 db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "ResetPasswordTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ResetPasswordTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+// ResetPasswordTx indicates an expected call of ResetPasswordTx.
+func (mr *MockStoreMockRecorder) ResetPasswordTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordTx", reflect.TypeOf((*MockStore)(nil).ResetPasswordTx), arg0, arg1)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+// ResetTwoFactorTx mocks base method.
+func (m *MockStore) ResetTwoFactorTx(arg0 context.Context, arg1 string) (db.ResetTwoFactorTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ResetTwoFactorTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ResetTwoFactorTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+// ResetTwoFactorTx indicates an expected call of ResetTwoFactorTx.
+func (mr *MockStoreMockRecorder) ResetTwoFactorTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetTwoFactorTx", reflect.TypeOf((*MockStore)(nil).ResetTwoFactorTx), arg0, arg1)
 }
 
-// ListAccountWithCursor mocks base method.
-func (m *MockStore) ListAccountWithCursor(arg0 context.Context, arg1 db.ListAccountWithCursorParams) ([]db.Account, error) {
+// ResolveApproval mocks base method.
+func (m *MockStore) ResolveApproval(arg0 context.Context, arg1 db.ResolveApprovalParams) (db.Approval, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccountWithCursor", arg0, arg1)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "ResolveApproval", arg0, arg1)
+	ret0, _ := ret[0].(db.Approval)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccountWithCursor indicates an expected call of ListAccountWithCursor.
-func (mr *MockStoreMockRecorder) ListAccountWithCursor(arg0, arg1 interface{}) *gomock.Call {
+// ResolveApproval indicates an expected call of ResolveApproval.
+func (mr *MockStoreMockRecorder) ResolveApproval(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountWithCursor", reflect.TypeOf((*MockStore)(nil).ListAccountWithCursor), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveApproval", reflect.TypeOf((*MockStore)(nil).ResolveApproval), arg0, arg1)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+// ResolveMoneyRequest mocks base method.
+func (m *MockStore) ResolveMoneyRequest(arg0 context.Context, arg1 db.ResolveMoneyRequestParams) (db.MoneyRequest, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "ResolveMoneyRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.MoneyRequest)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+// ResolveMoneyRequest indicates an expected call of ResolveMoneyRequest.
+func (mr *MockStoreMockRecorder) ResolveMoneyRequest(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveMoneyRequest", reflect.TypeOf((*MockStore)(nil).ResolveMoneyRequest), arg0, arg1)
 }
 
-// ListEntries mocks base method.
-func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+// ResolveScreeningHit mocks base method.
+func (m *MockStore) ResolveScreeningHit(arg0 context.Context, arg1 db.ResolveScreeningHitParams) (db.ScreeningHit, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "ResolveScreeningHit", arg0, arg1)
+	ret0, _ := ret[0].(db.ScreeningHit)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntries indicates an expected call of ListEntries.
-func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
+// ResolveScreeningHit indicates an expected call of ResolveScreeningHit.
+func (mr *MockStoreMockRecorder) ResolveScreeningHit(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveScreeningHit", reflect.TypeOf((*MockStore)(nil).ResolveScreeningHit), arg0, arg1)
 }
 
-// ListTransfers mocks base method.
-func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+// RevokeAccessGrant mocks base method.
+func (m *MockStore) RevokeAccessGrant(arg0 context.Context, arg1 int64) (db.AccessGrant, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
-	ret0, _ := ret[0].([]db.Transfer)
+	ret := m.ctrl.Call(m, "RevokeAccessGrant", arg0, arg1)
+	ret0, _ := ret[0].(db.AccessGrant)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTransfers indicates an expected call of ListTransfers.
-func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
+// RevokeAccessGrant indicates an expected call of RevokeAccessGrant.
+func (mr *MockStoreMockRecorder) RevokeAccessGrant(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessGrant", reflect.TypeOf((*MockStore)(nil).RevokeAccessGrant), arg0, arg1)
+}
+
+// SearchAccounts mocks base method.
+func (m *MockStore) SearchAccounts(arg0 context.Context, arg1 db.SearchAccountsParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchAccounts indicates an expected call of SearchAccounts.
+func (mr *MockStoreMockRecorder) SearchAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchAccounts", reflect.TypeOf((*MockStore)(nil).SearchAccounts), arg0, arg1)
+}
+
+// SearchUsers mocks base method.
+func (m *MockStore) SearchUsers(arg0 context.Context, arg1 db.SearchUsersParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockStoreMockRecorder) SearchUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockStore)(nil).SearchUsers), arg0, arg1)
 }
 
 // TransferTx mocks base method.
@@ -320,6 +1941,36 @@ func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
 }
 
+// UpdateAccountMember mocks base method.
+func (m *MockStore) UpdateAccountMember(arg0 context.Context, arg1 db.UpdateAccountMemberParams) (db.AccountMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountMember", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountMember indicates an expected call of UpdateAccountMember.
+func (mr *MockStoreMockRecorder) UpdateAccountMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountMember", reflect.TypeOf((*MockStore)(nil).UpdateAccountMember), arg0, arg1)
+}
+
+// UpdateAccountStatus mocks base method.
+func (m *MockStore) UpdateAccountStatus(arg0 context.Context, arg1 db.UpdateAccountStatusParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus.
+func (mr *MockStoreMockRecorder) UpdateAccountStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockStore)(nil).UpdateAccountStatus), arg0, arg1)
+}
+
 // UpdateUser mocks base method.
 func (m *MockStore) UpdateUser(arg0 context.Context, arg1 db.UpdateUserParams) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -334,3 +1985,138 @@ func (mr *MockStoreMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), arg0, arg1)
 }
+
+// UpdateUserAccessTx mocks base method.
+func (m *MockStore) UpdateUserAccessTx(arg0 context.Context, arg1 db.UpdateUserAccessTxParams) (db.UpdateUserAccessTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserAccessTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateUserAccessTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserAccessTx indicates an expected call of UpdateUserAccessTx.
+func (mr *MockStoreMockRecorder) UpdateUserAccessTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserAccessTx", reflect.TypeOf((*MockStore)(nil).UpdateUserAccessTx), arg0, arg1)
+}
+
+// UpdateUserTx mocks base method.
+func (m *MockStore) UpdateUserTx(arg0 context.Context, arg1 db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserTx indicates an expected call of UpdateUserTx.
+func (mr *MockStoreMockRecorder) UpdateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTx", reflect.TypeOf((*MockStore)(nil).UpdateUserTx), arg0, arg1)
+}
+
+// UseLoginChallenge mocks base method.
+func (m *MockStore) UseLoginChallenge(arg0 context.Context, arg1 int64) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseLoginChallenge", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseLoginChallenge indicates an expected call of UseLoginChallenge.
+func (mr *MockStoreMockRecorder) UseLoginChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseLoginChallenge", reflect.TypeOf((*MockStore)(nil).UseLoginChallenge), arg0, arg1)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockStore) UseRecoveryCode(arg0 context.Context, arg1 db.UseRecoveryCodeParams) (db.RecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.RecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockStoreMockRecorder) UseRecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockStore)(nil).UseRecoveryCode), arg0, arg1)
+}
+
+// UseResetPassword mocks base method.
+func (m *MockStore) UseResetPassword(arg0 context.Context, arg1 string) (db.ResetPassword, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseResetPassword", arg0, arg1)
+	ret0, _ := ret[0].(db.ResetPassword)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseResetPassword indicates an expected call of UseResetPassword.
+func (mr *MockStoreMockRecorder) UseResetPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseResetPassword", reflect.TypeOf((*MockStore)(nil).UseResetPassword), arg0, arg1)
+}
+
+// UseTwoFactorStep mocks base method.
+func (m *MockStore) UseTwoFactorStep(arg0 context.Context, arg1 db.UseTwoFactorStepParams) (db.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTwoFactorStep", arg0, arg1)
+	ret0, _ := ret[0].(db.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTwoFactorStep indicates an expected call of UseTwoFactorStep.
+func (mr *MockStoreMockRecorder) UseTwoFactorStep(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTwoFactorStep", reflect.TypeOf((*MockStore)(nil).UseTwoFactorStep), arg0, arg1)
+}
+
+// UseUserResetPasswords mocks base method.
+func (m *MockStore) UseUserResetPasswords(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseUserResetPasswords", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseUserResetPasswords indicates an expected call of UseUserResetPasswords.
+func (mr *MockStoreMockRecorder) UseUserResetPasswords(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseUserResetPasswords", reflect.TypeOf((*MockStore)(nil).UseUserResetPasswords), arg0, arg1)
+}
+
+// UseVerifyEmail mocks base method.
+func (m *MockStore) UseVerifyEmail(arg0 context.Context, arg1 string) (db.VerifyEmail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseVerifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.VerifyEmail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseVerifyEmail indicates an expected call of UseVerifyEmail.
+func (mr *MockStoreMockRecorder) UseVerifyEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseVerifyEmail", reflect.TypeOf((*MockStore)(nil).UseVerifyEmail), arg0, arg1)
+}
+
+// VerifyEmailTx mocks base method.
+func (m *MockStore) VerifyEmailTx(arg0 context.Context, arg1 string) (db.VerifyEmailTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmailTx", arg0, arg1)
+	ret0, _ := ret[0].(db.VerifyEmailTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmailTx indicates an expected call of VerifyEmailTx.
+func (mr *MockStoreMockRecorder) VerifyEmailTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmailTx", reflect.TypeOf((*MockStore)(nil).VerifyEmailTx), arg0, arg1)
+}
@@ -0,0 +1,48 @@
+-- name: CreateAccessGrant :one
+INSERT INTO access_grants (
+  grantor,
+  grantee,
+  account_id,
+  scopes,
+  transfer_limit,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetAccessGrant :one
+SELECT * FROM access_grants
+WHERE id = $1 LIMIT 1;
+
+-- name: GetActiveAccessGrant :one
+-- GetActiveAccessGrant picks the least restricted grant with the scope
+-- that is still backed by its grantor managing the account
+SELECT g.* FROM access_grants g
+JOIN accounts a ON a.id = g.account_id
+WHERE
+  g.account_id = sqlc.arg(account_id) AND
+  g.grantee = sqlc.arg(grantee) AND
+  sqlc.arg(scope)::varchar = ANY(g.scopes) AND
+  g.revoked_at IS NULL AND
+  g.expires_at > now() AND
+  (a.owner = g.grantor OR EXISTS (
+    SELECT 1 FROM account_members m
+    WHERE
+      m.account_id = g.account_id AND
+      m.username = g.grantor AND
+      m.permission = 'manage' AND
+      m.accepted_at IS NOT NULL
+  ))
+ORDER BY g.transfer_limit DESC NULLS FIRST, g.id
+LIMIT 1;
+
+-- name: ListAccessGrants :many
+SELECT * FROM access_grants
+WHERE grantor = sqlc.arg(username) OR grantee = sqlc.arg(username)
+ORDER BY id DESC;
+
+-- name: RevokeAccessGrant :one
+UPDATE access_grants
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING *;
@@ -11,6 +11,10 @@ INSERT INTO accounts (
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByOwnerCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
@@ -23,24 +27,90 @@ ORDER BY id
 LIMIT $2
 OFFSET $3;
 
--- name: ListAccountWithCursor :many
+-- name: ListAccountsAfter :many
+SELECT * FROM accounts
+WHERE
+  (owner = sqlc.arg(owner) OR id IN (
+    SELECT account_id FROM account_members
+    WHERE username = sqlc.arg(owner) AND accepted_at IS NOT NULL
+  )) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListAccountsBefore :many
 SELECT * FROM accounts
-WHERE created_at < sqlc.narg('cursor') OR sqlc.narg('cursor') IS NULL
-ORDER BY created_at DESC
+WHERE
+  (owner = sqlc.arg(owner) OR id IN (
+    SELECT account_id FROM account_members
+    WHERE username = sqlc.arg(owner) AND accepted_at IS NOT NULL
+  )) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: SearchAccounts :many
+SELECT * FROM accounts
+WHERE
+  (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner)) AND
+  (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency)) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (sqlc.narg(min_balance)::bigint IS NULL OR balance >= sqlc.narg(min_balance)) AND
+  (sqlc.narg(max_balance)::bigint IS NULL OR balance <= sqlc.narg(max_balance)) AND
+  (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)) AND
+  (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)) AND
+  (sqlc.narg(cursor_id)::bigint IS NULL OR CASE
+    WHEN sqlc.arg(sort_by)::varchar = 'owner' AND NOT sqlc.arg(sort_desc)::bool THEN (owner, id) > (sqlc.narg(cursor_owner)::varchar, sqlc.narg(cursor_id))
+    WHEN sqlc.arg(sort_by) = 'owner' THEN (owner, id) < (sqlc.narg(cursor_owner), sqlc.narg(cursor_id))
+    WHEN sqlc.arg(sort_by) = 'balance' AND NOT sqlc.arg(sort_desc) THEN (balance, id) > (sqlc.narg(cursor_balance)::bigint, sqlc.narg(cursor_id))
+    WHEN sqlc.arg(sort_by) = 'balance' THEN (balance, id) < (sqlc.narg(cursor_balance), sqlc.narg(cursor_id))
+    WHEN sqlc.arg(sort_by) = 'created_at' AND NOT sqlc.arg(sort_desc) THEN (created_at, id) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id))
+    WHEN sqlc.arg(sort_by) = 'created_at' THEN (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id))
+    WHEN NOT sqlc.arg(sort_desc) THEN id > sqlc.narg(cursor_id)
+    ELSE id < sqlc.narg(cursor_id)
+  END)
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'owner' AND NOT sqlc.arg(sort_desc) THEN owner END,
+  CASE WHEN sqlc.arg(sort_by) = 'owner' AND sqlc.arg(sort_desc) THEN owner END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'balance' AND NOT sqlc.arg(sort_desc) THEN balance END,
+  CASE WHEN sqlc.arg(sort_by) = 'balance' AND sqlc.arg(sort_desc) THEN balance END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND NOT sqlc.arg(sort_desc) THEN created_at END,
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND sqlc.arg(sort_desc) THEN created_at END DESC,
+  CASE WHEN NOT sqlc.arg(sort_desc) THEN id END,
+  CASE WHEN sqlc.arg(sort_desc) THEN id END DESC
 LIMIT sqlc.arg('limit');
 
 -- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
-WHERE id = $1
+SET
+  balance = sqlc.arg(balance),
+  version = version + 1
+WHERE
+  id = sqlc.arg(id) AND
+  (sqlc.narg(version)::bigint IS NULL OR version = sqlc.narg(version))
+RETURNING *;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET
+  status = sqlc.arg(status),
+  version = version + 1
+WHERE
+  id = sqlc.arg(id) AND
+  (sqlc.narg(version)::bigint IS NULL OR version = sqlc.narg(version))
 RETURNING *;
 
 -- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET
+  balance = balance + sqlc.arg(amount),
+  version = version + 1
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
--- name: DeleteAccount :exec
+-- name: DeleteAccount :execrows
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE
+  id = sqlc.arg(id) AND
+  (sqlc.narg(version)::bigint IS NULL OR version = sqlc.narg(version));
\ No newline at end of file
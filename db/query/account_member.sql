@@ -0,0 +1,39 @@
+-- name: CreateAccountMember :one
+INSERT INTO account_members (
+  account_id,
+  username,
+  permission,
+  invited_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetAccountMember :one
+SELECT * FROM account_members
+WHERE account_id = $1 AND username = $2 LIMIT 1;
+
+-- name: ListAccountMembers :many
+SELECT * FROM account_members
+WHERE account_id = $1
+ORDER BY created_at, username;
+
+-- name: ListAccountInvitations :many
+SELECT * FROM account_members
+WHERE username = $1 AND accepted_at IS NULL
+ORDER BY created_at, account_id;
+
+-- name: AcceptAccountMember :one
+UPDATE account_members
+SET accepted_at = now()
+WHERE account_id = $1 AND username = $2 AND accepted_at IS NULL
+RETURNING *;
+
+-- name: UpdateAccountMember :one
+UPDATE account_members
+SET permission = $3
+WHERE account_id = $1 AND username = $2
+RETURNING *;
+
+-- name: DeleteAccountMember :execrows
+DELETE FROM account_members
+WHERE account_id = $1 AND username = $2;
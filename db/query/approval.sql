@@ -0,0 +1,57 @@
+-- name: CreateApproval :one
+INSERT INTO approvals (
+  kind,
+  maker,
+  account_id,
+  to_account_id,
+  amount,
+  balance,
+  account_version,
+  currency,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetApproval :one
+SELECT * FROM approvals
+WHERE id = $1 LIMIT 1;
+
+-- name: ListApprovalsAfter :many
+-- ListApprovalsAfter lists approvals oldest first, the order bankers review them in
+SELECT * FROM approvals
+WHERE
+  (sqlc.narg(maker)::varchar IS NULL OR maker = sqlc.narg(maker)) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListApprovalsBefore :many
+SELECT * FROM approvals
+WHERE
+  (sqlc.narg(maker)::varchar IS NULL OR maker = sqlc.narg(maker)) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (created_at, id) < (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: RejectExpiredApprovals :execrows
+-- RejectExpiredApprovals rejects the operations nobody reviewed in time
+UPDATE approvals
+SET
+  status = 'rejected',
+  resolved_at = expires_at
+WHERE status = 'pending' AND expires_at <= now();
+
+-- name: ResolveApproval :one
+-- ResolveApproval records the decision of the checker on an approval that is still pending
+UPDATE approvals
+SET
+  status = sqlc.arg(status),
+  checker = sqlc.arg(checker),
+  transfer_id = sqlc.arg(transfer_id),
+  resolved_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending' AND expires_at > now()
+RETURNING *;
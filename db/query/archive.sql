@@ -0,0 +1,71 @@
+-- name: CountArchivedEntries :one
+-- CountArchivedEntries counts the entries of an account in the archived months
+SELECT count(*) FROM entries_archive
+WHERE account_id = $1;
+
+-- name: CountArchivedTransfers :one
+-- CountArchivedTransfers counts the transfers of an account in the archived months
+SELECT count(*) FROM transfers_archive
+WHERE from_account_id = $1 OR to_account_id = $2;
+
+-- name: GetArchivedEntry :one
+-- GetArchivedEntry reads an entry of the archived months
+SELECT * FROM entries_archive
+WHERE id = $1 LIMIT 1;
+
+-- name: GetArchivedTransfer :one
+-- GetArchivedTransfer reads a transfer of the archived months
+SELECT * FROM transfers_archive
+WHERE id = $1 LIMIT 1;
+
+-- name: ListArchivedEntries :many
+-- ListArchivedEntries lists the entries of an account in the archived months
+SELECT * FROM entries_archive
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListArchivedEntriesAfter :many
+SELECT * FROM entries_archive
+WHERE
+  account_id = sqlc.arg(account_id) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListArchivedEntriesBefore :many
+SELECT * FROM entries_archive
+WHERE
+  account_id = sqlc.arg(account_id) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListArchivedTransfers :many
+-- ListArchivedTransfers lists the transfers of an account in the archived months
+SELECT * FROM transfers_archive
+WHERE
+  from_account_id = $1 OR
+  to_account_id = $2
+ORDER BY id
+LIMIT $3
+OFFSET $4;
+
+-- name: ListArchivedTransfersAfter :many
+SELECT * FROM transfers_archive
+WHERE
+  (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListArchivedTransfersBefore :many
+SELECT * FROM transfers_archive
+WHERE
+  (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id)) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
\ No newline at end of file
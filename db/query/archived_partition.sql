@@ -0,0 +1,19 @@
+-- name: CreateArchivedPartition :one
+INSERT INTO archived_partitions (
+  table_name,
+  parent_table,
+  range_start,
+  range_end,
+  file_path,
+  checksum,
+  row_count,
+  min_id,
+  max_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: ListArchivedPartitions :many
+SELECT * FROM archived_partitions
+WHERE parent_table = $1
+ORDER BY range_start;
\ No newline at end of file
@@ -0,0 +1,49 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (
+  request_id,
+  actor,
+  actor_role,
+  method,
+  route,
+  resource_type,
+  resource_id,
+  status_code,
+  changes,
+  client_ip,
+  user_agent,
+  grant_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: ListAuditLogsAfter :many
+-- ListAuditLogsAfter lists audit logs newest first
+SELECT * FROM audit_logs
+WHERE
+  (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor)) AND
+  (sqlc.narg(method)::varchar IS NULL OR method = sqlc.narg(method)) AND
+  (sqlc.narg(resource_type)::varchar IS NULL OR resource_type = sqlc.narg(resource_type)) AND
+  (sqlc.narg(resource_id)::varchar IS NULL OR resource_id = sqlc.narg(resource_id)) AND
+  (sqlc.narg(request_id)::varchar IS NULL OR request_id = sqlc.narg(request_id)) AND
+  (sqlc.narg(grant_id)::bigint IS NULL OR grant_id = sqlc.narg(grant_id)) AND
+  (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)) AND
+  (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListAuditLogsBefore :many
+SELECT * FROM audit_logs
+WHERE
+  (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor)) AND
+  (sqlc.narg(method)::varchar IS NULL OR method = sqlc.narg(method)) AND
+  (sqlc.narg(resource_type)::varchar IS NULL OR resource_type = sqlc.narg(resource_type)) AND
+  (sqlc.narg(resource_id)::varchar IS NULL OR resource_id = sqlc.narg(resource_id)) AND
+  (sqlc.narg(request_id)::varchar IS NULL OR request_id = sqlc.narg(request_id)) AND
+  (sqlc.narg(grant_id)::bigint IS NULL OR grant_id = sqlc.narg(grant_id)) AND
+  (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)) AND
+  (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,54 @@
+-- name: ClaimJob :one
+-- ClaimJob takes the next job that is due, or that a stopped worker left running,
+-- and keeps the other workers off it until locked_until
+UPDATE jobs
+SET
+  status = 'running',
+  attempts = attempts + 1,
+  locked_until = sqlc.arg(locked_until)
+WHERE id = (
+  SELECT id FROM jobs
+  WHERE
+    (status = 'pending' AND run_at <= now()) OR
+    (status = 'running' AND locked_until <= now())
+  ORDER BY run_at, id
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :one
+-- CompleteJob marks the attempt of the job as done, unless another worker claimed the job since
+UPDATE jobs
+SET
+  status = 'done',
+  locked_until = NULL,
+  finished_at = now()
+WHERE id = sqlc.arg(id) AND status = 'running' AND attempts = sqlc.arg(attempts)
+RETURNING *;
+
+-- name: CreateJob :one
+INSERT INTO jobs (
+  kind,
+  payload,
+  max_attempts
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: FailJob :one
+-- FailJob records the error of the attempt and runs the job again at run_at,
+-- or gives it up after its last attempt
+UPDATE jobs
+SET
+  status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'pending' END,
+  last_error = sqlc.arg(last_error),
+  run_at = sqlc.arg(run_at),
+  locked_until = NULL,
+  finished_at = CASE WHEN attempts >= max_attempts THEN now() END
+WHERE id = sqlc.arg(id) AND status = 'running' AND attempts = sqlc.arg(attempts)
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1 LIMIT 1;
@@ -0,0 +1,25 @@
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+  username,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: FailLoginChallenge :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: GetLoginChallenge :one
+SELECT * FROM login_challenges
+WHERE token_hash = $1 LIMIT 1;
+
+-- name: UseLoginChallenge :one
+-- UseLoginChallenge consumes the challenge unless it was used or expired
+UPDATE login_challenges
+SET is_used = true
+WHERE id = $1 AND NOT is_used AND expires_at > now()
+RETURNING *;
@@ -0,0 +1,84 @@
+-- name: DeleteLoginLockout :execrows
+DELETE FROM login_lockouts
+WHERE scope = $1 AND identifier = $2;
+
+-- name: DeleteStaleLoginLockouts :execrows
+-- DeleteStaleLoginLockouts forgets the failures older than failed_before that no longer lock anyone out
+DELETE FROM login_lockouts
+WHERE last_failed_at < sqlc.arg(failed_before) AND (locked_until IS NULL OR locked_until <= now());
+
+-- name: GetLoginLockout :one
+SELECT * FROM login_lockouts
+WHERE scope = $1 AND identifier = $2 LIMIT 1;
+
+-- name: ListLoginLockoutsAfter :many
+-- ListLoginLockoutsAfter lists the usernames and client ips that are locked out, the longest locked first
+SELECT * FROM login_lockouts
+WHERE
+  locked_until > now() AND
+  (sqlc.narg(scope)::varchar IS NULL OR scope = sqlc.narg(scope)) AND
+  (sqlc.narg(cursor_locked_until)::timestamptz IS NULL OR
+    locked_until < sqlc.narg(cursor_locked_until) OR
+    (locked_until = sqlc.narg(cursor_locked_until) AND
+      (scope, identifier) > (sqlc.narg(cursor_scope)::varchar, sqlc.narg(cursor_identifier)::varchar)))
+ORDER BY locked_until DESC, scope, identifier
+LIMIT sqlc.arg('limit');
+
+-- name: ListLoginLockoutsBefore :many
+SELECT * FROM login_lockouts
+WHERE
+  locked_until > now() AND
+  (sqlc.narg(scope)::varchar IS NULL OR scope = sqlc.narg(scope)) AND
+  (locked_until > sqlc.arg(cursor_locked_until)::timestamptz OR
+    (locked_until = sqlc.arg(cursor_locked_until) AND
+      (scope, identifier) < (sqlc.arg(cursor_scope)::varchar, sqlc.arg(cursor_identifier)::varchar)))
+ORDER BY locked_until, scope DESC, identifier DESC
+LIMIT sqlc.arg('limit');
+
+-- name: RefundLoginAttempt :execrows
+-- RefundLoginAttempt takes back a login counted by ReserveLoginAttempt whose password turned out
+-- right, and lifts the lockout it started, the one until locked_until
+UPDATE login_lockouts
+SET
+  failed_attempts = GREATEST(failed_attempts - 1, 0),
+  locked_until = CASE
+    WHEN locked_until = sqlc.narg(locked_until) THEN NULL
+    ELSE locked_until
+  END
+WHERE scope = sqlc.arg(scope) AND identifier = sqlc.arg(identifier);
+
+-- name: ReserveLoginAttempt :one
+-- ReserveLoginAttempt counts a login as failed before its password is checked, starting the count
+-- over when the last failure happened before reset_before. The failure that reaches the threshold
+-- locks the scope out for delay_seconds, doubled with every further failure up to max_delay_seconds.
+-- Nothing is counted nor returned while the scope is locked out, so that a burst of parallel logins
+-- cannot get past the threshold before the lockout starts.
+INSERT INTO login_lockouts (
+  scope,
+  identifier,
+  failed_attempts,
+  locked_until
+) VALUES (
+  sqlc.arg(scope), sqlc.arg(identifier), 1,
+  CASE
+    WHEN sqlc.arg(threshold)::int <= 1
+    THEN now() + make_interval(secs => LEAST(sqlc.arg(delay_seconds)::float8, sqlc.arg(max_delay_seconds)::float8))
+  END
+)
+ON CONFLICT (scope, identifier) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_lockouts.last_failed_at < sqlc.arg(reset_before) THEN 1
+    ELSE login_lockouts.failed_attempts + 1
+  END,
+  last_failed_at = now(),
+  locked_until = CASE
+    WHEN login_lockouts.last_failed_at < sqlc.arg(reset_before) THEN EXCLUDED.locked_until
+    -- the exponent is capped below the overflow of power, the delay is long capped by then
+    WHEN login_lockouts.failed_attempts + 1 >= sqlc.arg(threshold) THEN now() + make_interval(secs => LEAST(
+      sqlc.arg(max_delay_seconds),
+      sqlc.arg(delay_seconds) * power(2, LEAST(login_lockouts.failed_attempts + 1 - sqlc.arg(threshold), 1000))
+    ))
+  END
+WHERE login_lockouts.locked_until IS NULL OR login_lockouts.locked_until <= now()
+RETURNING *;
@@ -0,0 +1,79 @@
+-- name: AcceptMoneyRequest :one
+UPDATE money_requests
+SET
+  status = 'accepted',
+  transfer_id = sqlc.arg(transfer_id),
+  resolved_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending' AND expires_at > now()
+RETURNING *;
+
+-- name: CreateMoneyRequest :one
+INSERT INTO money_requests (
+  requester,
+  payer,
+  to_account_id,
+  amount,
+  currency,
+  note,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ExpireMoneyRequests :execrows
+UPDATE money_requests
+SET
+  status = 'expired',
+  resolved_at = expires_at
+WHERE status = 'pending' AND expires_at <= now();
+
+-- name: GetMoneyRequest :one
+SELECT * FROM money_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: ListIncomingMoneyRequestsAfter :many
+SELECT * FROM money_requests
+WHERE
+  payer = sqlc.arg(payer) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListIncomingMoneyRequestsBefore :many
+SELECT * FROM money_requests
+WHERE
+  payer = sqlc.arg(payer) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListOutgoingMoneyRequestsAfter :many
+SELECT * FROM money_requests
+WHERE
+  requester = sqlc.arg(requester) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListOutgoingMoneyRequestsBefore :many
+SELECT * FROM money_requests
+WHERE
+  requester = sqlc.arg(requester) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ResolveMoneyRequest :one
+-- ResolveMoneyRequest declines or cancels a request that is still pending
+UPDATE money_requests
+SET
+  status = sqlc.arg(status),
+  resolved_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending' AND expires_at > now()
+RETURNING *;
@@ -0,0 +1,22 @@
+-- name: CreatePayee :one
+INSERT INTO payees (
+  owner,
+  nickname,
+  account_id,
+  currency
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: DeletePayee :execrows
+DELETE FROM payees
+WHERE id = $1 AND owner = $2;
+
+-- name: GetPayee :one
+SELECT * FROM payees
+WHERE id = $1 LIMIT 1;
+
+-- name: ListPayees :many
+SELECT * FROM payees
+WHERE owner = $1
+ORDER BY nickname;
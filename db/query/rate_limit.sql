@@ -0,0 +1,26 @@
+-- name: DeleteStaleRateLimits :execrows
+-- DeleteStaleRateLimits forgets the counts of the windows that started before started_before
+DELETE FROM rate_limits
+WHERE window_started_at < sqlc.arg(started_before);
+
+-- name: HitRateLimit :one
+-- HitRateLimit counts a request, starting a new window when the current one started
+-- before reset_before
+INSERT INTO rate_limits (
+  scope,
+  identifier,
+  hits
+) VALUES (
+  sqlc.arg(scope), sqlc.arg(identifier), 1
+)
+ON CONFLICT (scope, identifier) DO UPDATE
+SET
+  hits = CASE
+    WHEN rate_limits.window_started_at < sqlc.arg(reset_before) THEN 1
+    ELSE rate_limits.hits + 1
+  END,
+  window_started_at = CASE
+    WHEN rate_limits.window_started_at < sqlc.arg(reset_before) THEN now()
+    ELSE rate_limits.window_started_at
+  END
+RETURNING *;
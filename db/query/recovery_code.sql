@@ -0,0 +1,22 @@
+-- name: CountRecoveryCodes :one
+-- CountRecoveryCodes counts the codes the user has not used yet
+SELECT count(*) FROM recovery_codes
+WHERE username = $1 AND used_at IS NULL;
+
+-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+  username,
+  code_hash
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: DeleteRecoveryCodes :execrows
+DELETE FROM recovery_codes
+WHERE username = $1;
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = now()
+WHERE username = $1 AND code_hash = $2 AND used_at IS NULL
+RETURNING *;
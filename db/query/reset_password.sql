@@ -0,0 +1,21 @@
+-- name: CreateResetPassword :one
+INSERT INTO reset_passwords (
+  username,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: UseResetPassword :one
+-- UseResetPassword consumes the token of a password reset link unless it was used or expired
+UPDATE reset_passwords
+SET is_used = true
+WHERE token_hash = $1 AND NOT is_used AND expires_at > now()
+RETURNING *;
+
+-- name: UseUserResetPasswords :execrows
+-- UseUserResetPasswords consumes every reset link of the user that was not used yet
+UPDATE reset_passwords
+SET is_used = true
+WHERE username = $1 AND NOT is_used;
\ No newline at end of file
@@ -0,0 +1,45 @@
+-- name: CountRiskDecisionsFromIP :one
+-- CountRiskDecisionsFromIP counts the transfers of the user from the client ip that went through,
+-- allowed at once or held and approved by a banker
+SELECT count(*) FROM risk_decisions d
+LEFT JOIN approvals a ON a.id = d.approval_id
+WHERE
+  d.username = $1 AND
+  d.client_ip = $2 AND
+  ((d.approval_id IS NULL AND d.outcome = 'allow') OR a.status = 'approved');
+
+-- name: CreateRiskDecision :one
+INSERT INTO risk_decisions (
+  username,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  client_ip,
+  outcome,
+  fired_rules,
+  approval_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: ListRiskDecisionsAfter :many
+SELECT * FROM risk_decisions
+WHERE
+  (sqlc.narg(username)::varchar IS NULL OR username = sqlc.narg(username)) AND
+  (sqlc.narg(outcome)::varchar IS NULL OR outcome = sqlc.narg(outcome)) AND
+  (sqlc.narg(approval_id)::bigint IS NULL OR approval_id = sqlc.narg(approval_id)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListRiskDecisionsBefore :many
+SELECT * FROM risk_decisions
+WHERE
+  (sqlc.narg(username)::varchar IS NULL OR username = sqlc.narg(username)) AND
+  (sqlc.narg(outcome)::varchar IS NULL OR outcome = sqlc.narg(outcome)) AND
+  (sqlc.narg(approval_id)::bigint IS NULL OR approval_id = sqlc.narg(approval_id)) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- name: CountBlockingScreeningHits :one
+-- CountBlockingScreeningHits counts the hits of the users that are not cleared
+SELECT count(*) FROM screening_hits
+WHERE username = ANY(sqlc.arg(usernames)::varchar[]) AND status <> 'cleared';
+
+-- name: CreateScreeningHit :execrows
+-- CreateScreeningHit records a hit unless the name of the user already matched the entry
+INSERT INTO screening_hits (
+  username,
+  screened_name,
+  list_entry_id,
+  listed_name,
+  score
+) VALUES (
+  $1, $2, $3, $4, $5
+) ON CONFLICT (username, list_entry_id, screened_name) DO NOTHING;
+
+-- name: GetScreeningHit :one
+SELECT * FROM screening_hits
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScreeningHitsAfter :many
+-- ListScreeningHitsAfter lists screening hits oldest first, the order bankers review them in
+SELECT * FROM screening_hits
+WHERE
+  (sqlc.narg(username)::varchar IS NULL OR username = sqlc.narg(username)) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListScreeningHitsBefore :many
+SELECT * FROM screening_hits
+WHERE
+  (sqlc.narg(username)::varchar IS NULL OR username = sqlc.narg(username)) AND
+  (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)) AND
+  (created_at, id) < (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ResolveScreeningHit :one
+-- ResolveScreeningHit records the review of a banker on a hit that is still open
+UPDATE screening_hits
+SET
+  status = sqlc.arg(status),
+  reviewed_by = sqlc.arg(reviewed_by),
+  reviewed_at = now()
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
\ No newline at end of file
@@ -1,3 +1,17 @@
+-- name: BlockUserSessions :execrows
+-- BlockUserSessions blocks the sessions of a user and revokes the access tokens issued to them until now
+WITH revoked_tokens AS (
+  UPDATE users
+  SET tokens_valid_after = now()
+  WHERE username = $1
+)
+UPDATE sessions
+SET is_blocked = true
+WHERE
+  username = $1 AND
+  NOT is_blocked AND
+  expires_at > now();
+
 -- name: CreateSession :one
 INSERT INTO sessions (
   id,
@@ -11,6 +25,14 @@ INSERT INTO sessions (
   $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
+-- name: GetClientIPHistory :one
+-- GetClientIPHistory counts the sessions a user opened before a time, in total and from a client ip
+SELECT
+  count(*) AS sessions,
+  count(*) FILTER (WHERE client_ip = sqlc.arg(client_ip)) AS client_ip_sessions
+FROM sessions
+WHERE username = sqlc.arg(username) AND created_at < sqlc.arg(before);
+
 -- name: GetSession :one
 SELECT * FROM sessions
 WHERE id = $1 LIMIT 1;
\ No newline at end of file
@@ -1,3 +1,7 @@
+-- name: CountTransfersBetween :one
+SELECT count(*) FROM transfers
+WHERE from_account_id = $1 AND to_account_id = $2;
+
 -- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
@@ -18,4 +22,27 @@ WHERE
   to_account_id = $2
 ORDER BY id
 LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: ListTransfersAfter :many
+SELECT * FROM transfers
+WHERE
+  (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id)) AND
+  (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+    (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransfersBefore :many
+SELECT * FROM transfers
+WHERE
+  (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id)) AND
+  (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransfersSince :many
+-- ListTransfersSince lists the transfers sent from an account since a time, oldest first
+SELECT * FROM transfers
+WHERE from_account_id = $1 AND created_at >= $2
+ORDER BY created_at, id;
\ No newline at end of file
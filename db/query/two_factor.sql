@@ -0,0 +1,35 @@
+-- name: CreateTwoFactor :one
+-- CreateTwoFactor sets up a new secret for the user, replacing the one they did not confirm.
+-- It returns no row while two-factor authentication is enabled.
+INSERT INTO two_factors (
+  username,
+  secret
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (username) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = now()
+WHERE NOT two_factors.is_enabled
+RETURNING *;
+
+-- name: DeleteTwoFactor :execrows
+DELETE FROM two_factors
+WHERE username = $1;
+
+-- name: EnableTwoFactor :one
+-- EnableTwoFactor enables the secret the user confirmed with the code of last_used_step
+UPDATE two_factors
+SET is_enabled = true, enabled_at = now(), last_used_step = $2
+WHERE username = $1 AND NOT is_enabled
+RETURNING *;
+
+-- name: GetTwoFactor :one
+SELECT * FROM two_factors
+WHERE username = $1 LIMIT 1;
+
+-- name: UseTwoFactorStep :one
+-- UseTwoFactorStep records the step of a code, unless that code or a later one was used
+UPDATE two_factors
+SET last_used_step = $2
+WHERE username = $1 AND is_enabled AND last_used_step < $2
+RETURNING *;
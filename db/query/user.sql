@@ -12,13 +12,89 @@ INSERT INTO users (
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetDiscoverableUser :one
+-- GetDiscoverableUser resolves a username or an email to a user who can be paid through the directory
+SELECT * FROM users
+WHERE
+  (username = sqlc.arg(alias) OR email = sqlc.arg(alias)) AND
+  discoverable AND
+  NOT disabled
+LIMIT 1;
+
+-- name: GetUserAccess :one
+-- GetUserAccess reads what decides whether the access tokens of a user still hold
+SELECT role, disabled, password_changed_at, tokens_valid_after FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: ListUsersAfter :many
+SELECT * FROM users
+WHERE
+  sqlc.narg(cursor_created_at)::timestamptz IS NULL OR
+  (created_at, username) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_username)::varchar)
+ORDER BY created_at DESC, username DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListUsersBefore :many
+SELECT * FROM users
+WHERE
+  (created_at, username) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_username)::varchar)
+ORDER BY created_at, username
+LIMIT sqlc.arg('limit');
+
+-- name: SearchUsers :many
+SELECT * FROM users
+WHERE
+  (sqlc.narg(username_prefix)::varchar IS NULL OR
+    username LIKE escape_like(sqlc.narg(username_prefix)) || '%') AND
+  (sqlc.narg(email_prefix)::varchar IS NULL OR
+    lower(email) LIKE escape_like(lower(sqlc.narg(email_prefix))) || '%') AND
+  (sqlc.narg(full_name_prefix)::varchar IS NULL OR
+    lower(full_name) LIKE escape_like(lower(sqlc.narg(full_name_prefix))) || '%') AND
+  (sqlc.narg(cursor_username)::varchar IS NULL OR CASE
+    WHEN sqlc.arg(sort_by)::varchar = 'full_name' AND NOT sqlc.arg(sort_desc)::bool THEN (full_name, username) > (sqlc.narg(cursor_full_name)::varchar, sqlc.narg(cursor_username))
+    WHEN sqlc.arg(sort_by) = 'full_name' THEN (full_name, username) < (sqlc.narg(cursor_full_name), sqlc.narg(cursor_username))
+    WHEN sqlc.arg(sort_by) = 'email' AND NOT sqlc.arg(sort_desc) THEN (email, username) > (sqlc.narg(cursor_email)::varchar, sqlc.narg(cursor_username))
+    WHEN sqlc.arg(sort_by) = 'email' THEN (email, username) < (sqlc.narg(cursor_email), sqlc.narg(cursor_username))
+    WHEN sqlc.arg(sort_by) = 'created_at' AND NOT sqlc.arg(sort_desc) THEN (created_at, username) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_username))
+    WHEN sqlc.arg(sort_by) = 'created_at' THEN (created_at, username) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_username))
+    WHEN NOT sqlc.arg(sort_desc) THEN username > sqlc.narg(cursor_username)
+    ELSE username < sqlc.narg(cursor_username)
+  END)
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'full_name' AND NOT sqlc.arg(sort_desc) THEN full_name END,
+  CASE WHEN sqlc.arg(sort_by) = 'full_name' AND sqlc.arg(sort_desc) THEN full_name END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'email' AND NOT sqlc.arg(sort_desc) THEN email END,
+  CASE WHEN sqlc.arg(sort_by) = 'email' AND sqlc.arg(sort_desc) THEN email END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND NOT sqlc.arg(sort_desc) THEN created_at END,
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND sqlc.arg(sort_desc) THEN created_at END DESC,
+  CASE WHEN NOT sqlc.arg(sort_desc) THEN username END,
+  CASE WHEN sqlc.arg(sort_desc) THEN username END DESC
+LIMIT sqlc.arg('limit');
+
 -- name: UpdateUser :one
 UPDATE users
 SET
   hashed_password = COALESCE(sqlc.narg(hashed_password), hashed_password),
   password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
   full_name = COALESCE(sqlc.narg(full_name), full_name),
-  email = COALESCE(sqlc.narg(email), email)
+  email = COALESCE(sqlc.narg(email), email),
+  role = COALESCE(sqlc.narg(role), role),
+  disabled = COALESCE(sqlc.narg(disabled), disabled),
+  discoverable = COALESCE(sqlc.narg(discoverable), discoverable),
+  is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified),
+  version = version + 1
 WHERE
-  username = sqlc.arg(username)
-RETURNING *;
\ No newline at end of file
+  username = sqlc.arg(username) AND
+  (sqlc.narg(version)::bigint IS NULL OR version = sqlc.narg(version))
+RETURNING *;
+
+-- name: RehashUserPassword :execrows
+-- RehashUserPassword swaps the hash of the password for one of the current scheme. It keeps the version
+-- and password_changed_at, and leaves a password that changed since old_hashed_password was read.
+UPDATE users
+SET hashed_password = sqlc.arg(new_hashed_password)
+WHERE username = sqlc.arg(username) AND hashed_password = sqlc.arg(old_hashed_password);
\ No newline at end of file
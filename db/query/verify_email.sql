@@ -0,0 +1,16 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+  username,
+  email,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: UseVerifyEmail :one
+-- UseVerifyEmail consumes the token of a verification link unless it was used or expired
+UPDATE verify_emails
+SET is_used = true
+WHERE token_hash = $1 AND NOT is_used AND expires_at > now()
+RETURNING *;
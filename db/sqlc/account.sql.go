@@ -7,15 +7,18 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + $1
+SET
+  balance = balance + $1,
+  version = version + 1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, version, status
 `
 
 type AddAccountBalanceParams struct {
@@ -32,6 +35,8 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
@@ -43,7 +48,7 @@ INSERT INTO accounts (
   currency
 ) VALUES (
   $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+) RETURNING id, owner, balance, currency, created_at, version, status
 `
 
 type CreateAccountParams struct {
@@ -61,22 +66,34 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
 
-const deleteAccount = `-- name: DeleteAccount :exec
+const deleteAccount = `-- name: DeleteAccount :execrows
 DELETE FROM accounts
-WHERE id = $1
+WHERE
+  id = $1 AND
+  ($2::bigint IS NULL OR version = $2)
 `
 
-func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteAccount, id)
-	return err
+type DeleteAccountParams struct {
+	ID      int64       `json:"id"`
+	Version pgtype.Int8 `json:"version"`
+}
+
+func (q *Queries) DeleteAccount(ctx context.Context, arg DeleteAccountParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAccount, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, version, status FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -89,12 +106,39 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getAccountByOwnerCurrency = `-- name: GetAccountByOwnerCurrency :one
+SELECT id, owner, balance, currency, created_at, version, status FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+type GetAccountByOwnerCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetAccountByOwnerCurrency(ctx context.Context, arg GetAccountByOwnerCurrencyParams) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountByOwnerCurrency, arg.Owner, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, version, status FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -108,24 +152,28 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
 
-const listAccountWithCursor = `-- name: ListAccountWithCursor :many
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE created_at < $1 OR $1 IS NULL
-ORDER BY created_at DESC
+const listAccounts = `-- name: ListAccounts :many
+SELECT id, owner, balance, currency, created_at, version, status FROM accounts
+WHERE owner = $1
+ORDER BY id
 LIMIT $2
+OFFSET $3
 `
 
-type ListAccountWithCursorParams struct {
-	Cursor pgtype.Timestamptz `json:"cursor"`
-	Limit  int32              `json:"limit"`
+type ListAccountsParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
 }
 
-func (q *Queries) ListAccountWithCursor(ctx context.Context, arg ListAccountWithCursorParams) ([]Account, error) {
-	rows, err := q.db.Query(ctx, listAccountWithCursor, arg.Cursor, arg.Limit)
+func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +187,8 @@ func (q *Queries) ListAccountWithCursor(ctx context.Context, arg ListAccountWith
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Version,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -150,22 +200,33 @@ func (q *Queries) ListAccountWithCursor(ctx context.Context, arg ListAccountWith
 	return items, nil
 }
 
-const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE owner = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3
+const listAccountsAfter = `-- name: ListAccountsAfter :many
+SELECT id, owner, balance, currency, created_at, version, status FROM accounts
+WHERE
+  (owner = $1 OR id IN (
+    SELECT account_id FROM account_members
+    WHERE username = $1 AND accepted_at IS NOT NULL
+  )) AND
+  ($2::timestamptz IS NULL OR
+    (created_at, id) < ($2, $3::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
 `
 
-type ListAccountsParams struct {
-	Owner  string `json:"owner"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+type ListAccountsAfterParams struct {
+	Owner           string             `json:"owner"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.Int8        `json:"cursor_id"`
+	Limit           int32              `json:"limit"`
 }
 
-func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.Query(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsAfter,
+		arg.Owner,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -179,6 +240,152 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Version,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsBefore = `-- name: ListAccountsBefore :many
+SELECT id, owner, balance, currency, created_at, version, status FROM accounts
+WHERE
+  (owner = $1 OR id IN (
+    SELECT account_id FROM account_members
+    WHERE username = $1 AND accepted_at IS NOT NULL
+  )) AND
+  (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListAccountsBeforeParams struct {
+	Owner           string    `json:"owner"`
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorID        int64     `json:"cursor_id"`
+	Limit           int32     `json:"limit"`
+}
+
+func (q *Queries) ListAccountsBefore(ctx context.Context, arg ListAccountsBeforeParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsBefore,
+		arg.Owner,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Version,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchAccounts = `-- name: SearchAccounts :many
+SELECT id, owner, balance, currency, created_at, version, status FROM accounts
+WHERE
+  ($1::varchar IS NULL OR owner = $1) AND
+  ($2::varchar IS NULL OR currency = $2) AND
+  ($3::varchar IS NULL OR status = $3) AND
+  ($4::bigint IS NULL OR balance >= $4) AND
+  ($5::bigint IS NULL OR balance <= $5) AND
+  ($6::timestamptz IS NULL OR created_at >= $6) AND
+  ($7::timestamptz IS NULL OR created_at < $7) AND
+  ($8::bigint IS NULL OR CASE
+    WHEN $9::varchar = 'owner' AND NOT $10::bool THEN (owner, id) > ($11::varchar, $8)
+    WHEN $9 = 'owner' THEN (owner, id) < ($11, $8)
+    WHEN $9 = 'balance' AND NOT $10 THEN (balance, id) > ($12::bigint, $8)
+    WHEN $9 = 'balance' THEN (balance, id) < ($12, $8)
+    WHEN $9 = 'created_at' AND NOT $10 THEN (created_at, id) > ($13::timestamptz, $8)
+    WHEN $9 = 'created_at' THEN (created_at, id) < ($13, $8)
+    WHEN NOT $10 THEN id > $8
+    ELSE id < $8
+  END)
+ORDER BY
+  CASE WHEN $9 = 'owner' AND NOT $10 THEN owner END,
+  CASE WHEN $9 = 'owner' AND $10 THEN owner END DESC,
+  CASE WHEN $9 = 'balance' AND NOT $10 THEN balance END,
+  CASE WHEN $9 = 'balance' AND $10 THEN balance END DESC,
+  CASE WHEN $9 = 'created_at' AND NOT $10 THEN created_at END,
+  CASE WHEN $9 = 'created_at' AND $10 THEN created_at END DESC,
+  CASE WHEN NOT $10 THEN id END,
+  CASE WHEN $10 THEN id END DESC
+LIMIT $14;
+`
+
+type SearchAccountsParams struct {
+	Owner           pgtype.Text        `json:"owner"`
+	Currency        pgtype.Text        `json:"currency"`
+	Status          pgtype.Text        `json:"status"`
+	MinBalance      pgtype.Int8        `json:"min_balance"`
+	MaxBalance      pgtype.Int8        `json:"max_balance"`
+	CreatedFrom     pgtype.Timestamptz `json:"created_from"`
+	CreatedTo       pgtype.Timestamptz `json:"created_to"`
+	CursorID        pgtype.Int8        `json:"cursor_id"`
+	SortBy          string             `json:"sort_by"`
+	SortDesc        bool               `json:"sort_desc"`
+	CursorOwner     pgtype.Text        `json:"cursor_owner"`
+	CursorBalance   pgtype.Int8        `json:"cursor_balance"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	Limit           int32              `json:"limit"`
+}
+
+func (q *Queries) SearchAccounts(ctx context.Context, arg SearchAccountsParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, searchAccounts,
+		arg.Owner,
+		arg.Currency,
+		arg.Status,
+		arg.MinBalance,
+		arg.MaxBalance,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.CursorOwner,
+		arg.CursorBalance,
+		arg.CursorCreatedAt,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Version,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -192,18 +399,55 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
-WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+SET
+  balance = $1,
+  version = version + 1
+WHERE
+  id = $2 AND
+  ($3::bigint IS NULL OR version = $3)
+RETURNING id, owner, balance, currency, created_at, version, status
 `
 
 type UpdateAccountParams struct {
-	ID      int64 `json:"id"`
-	Balance int64 `json:"balance"`
+	Balance int64       `json:"balance"`
+	ID      int64       `json:"id"`
+	Version pgtype.Int8 `json:"version"`
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRow(ctx, updateAccount, arg.ID, arg.Balance)
+	row := q.db.QueryRow(ctx, updateAccount, arg.Balance, arg.ID, arg.Version)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET
+  status = $1,
+  version = version + 1
+WHERE
+  id = $2 AND
+  ($3::bigint IS NULL OR version = $3)
+RETURNING id, owner, balance, currency, created_at, version, status
+`
+
+type UpdateAccountStatusParams struct {
+	Status  string      `json:"status"`
+	ID      int64       `json:"id"`
+	Version pgtype.Int8 `json:"version"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountStatus, arg.Status, arg.ID, arg.Version)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -211,6 +455,8 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// AccountEventsChannel is the postgres NOTIFY channel used to broadcast account events
+const AccountEventsChannel = "account_events"
+
+// AccountEvent describes a balance change caused by an entry posted to an account
+type AccountEvent struct {
+	AccountID  int64     `json:"account_id"`
+	EntryID    int64     `json:"entry_id"`
+	TransferID int64     `json:"transfer_id"`
+	Amount     int64     `json:"amount"`
+	Balance    int64     `json:"balance"`
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// newAccountEvent builds the event for an entry posted to an account
+func newAccountEvent(transfer Transfer, entry Entry, account Account) AccountEvent {
+	return AccountEvent{
+		AccountID:  account.ID,
+		EntryID:    entry.ID,
+		TransferID: transfer.ID,
+		Amount:     entry.Amount,
+		Balance:    account.Balance,
+		Currency:   account.Currency,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
+
+// publishAccountEvent queues the event on the account events channel.
+// Postgres only delivers it once the surrounding transaction commits.
+func publishAccountEvent(ctx context.Context, q *Queries, event AccountEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("cannot marshal account event: %w", err)
+	}
+
+	return q.NotifyAccountEvent(ctx, NotifyAccountEventParams{
+		Channel: AccountEventsChannel,
+		Payload: string(payload),
+	})
+}
+
+// ListenAccountEvents listens on the account events channel and calls handle for every event
+// until the context is cancelled or the connection fails
+func (store *SQLStore) ListenAccountEvents(ctx context.Context, handle func(AccountEvent)) error {
+	conn, err := store.connPool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "LISTEN "+AccountEventsChannel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event AccountEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Println("cannot unmarshal account event: ", err)
+			continue
+		}
+
+		handle(event)
+	}
+}
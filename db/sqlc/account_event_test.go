@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAccountEvents(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan AccountEvent, 2)
+	listening := make(chan error, 1)
+	go func() {
+		listening <- testStore.ListenAccountEvents(ctx, func(event AccountEvent) {
+			if event.AccountID == account1.ID || event.AccountID == account2.ID {
+				events <- event
+			}
+		})
+	}()
+
+	// give the listener time to issue LISTEN before the transfer commits
+	time.Sleep(100 * time.Millisecond)
+
+	amount := int64(10)
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	received := make(map[int64]AccountEvent)
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-events:
+			received[event.AccountID] = event
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for account events")
+		}
+	}
+
+	fromEvent := received[account1.ID]
+	require.Equal(t, result.Transfer.ID, fromEvent.TransferID)
+	require.Equal(t, result.FromEntry.ID, fromEvent.EntryID)
+	require.Equal(t, -amount, fromEvent.Amount)
+	require.Equal(t, result.FromAccount.Balance, fromEvent.Balance)
+
+	toEvent := received[account2.ID]
+	require.Equal(t, result.Transfer.ID, toEvent.TransferID)
+	require.Equal(t, result.ToEntry.ID, toEvent.EntryID)
+	require.Equal(t, amount, toEvent.Amount)
+	require.Equal(t, result.ToAccount.Balance, toEvent.Balance)
+
+	cancel()
+	require.Error(t, <-listening)
+}
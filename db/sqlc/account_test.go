@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,8 +33,9 @@ func createRandomAccount(t *testing.T) Account {
 }
 
 func deleteAccountUtil(t *testing.T, id int64) {
-	err := testStore.DeleteAccount(context.Background(), id)
+	rows, err := testStore.DeleteAccount(context.Background(), DeleteAccountParams{ID: id})
 	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
 
 	account2, err := testStore.GetAccount(context.Background(), id)
 	require.Error(t, err)
@@ -88,6 +90,32 @@ func TestUpdateAccount(t *testing.T) {
 	})
 }
 
+func TestUpdateAccountVersion(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Equal(t, int64(1), account1.Version)
+
+	arg := UpdateAccountParams{
+		ID:      account1.ID,
+		Balance: util.RandomMoney(),
+		Version: pgtype.Int8{
+			Int64: account1.Version,
+			Valid: true,
+		},
+	}
+
+	account2, err := testStore.UpdateAccount(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account1.Version+1, account2.Version)
+
+	// the same version is now stale
+	_, err = testStore.UpdateAccount(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	t.Cleanup(func() {
+		deleteAccountUtil(t, account1.ID)
+	})
+}
+
 func TestDeleteAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 	deleteAccountUtil(t, account1.ID)
@@ -0,0 +1,383 @@
+package db
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	archiveFileExt  = ".jsonl.gz"
+	checksumFileExt = ".sha256"
+)
+
+// archiveTable describes how the rows of a partitioned table are exported to files and restored from them
+type archiveTable struct {
+	columns []string
+	scan    func(rows pgx.Rows) (id int64, record any, err error)
+	decode  func(decoder *json.Decoder) ([]any, error)
+}
+
+var archiveTables = map[string]archiveTable{
+	"entries": {
+		columns: []string{"id", "account_id", "amount", "created_at"},
+		scan: func(rows pgx.Rows) (int64, any, error) {
+			var i Entry
+			err := rows.Scan(&i.ID, &i.AccountID, &i.Amount, &i.CreatedAt)
+			return i.ID, i, err
+		},
+		decode: func(decoder *json.Decoder) ([]any, error) {
+			var i Entry
+			err := decoder.Decode(&i)
+			return []any{i.ID, i.AccountID, i.Amount, i.CreatedAt}, err
+		},
+	},
+	"transfers": {
+		columns: []string{"id", "from_account_id", "to_account_id", "amount", "created_at"},
+		scan: func(rows pgx.Rows) (int64, any, error) {
+			var i Transfer
+			err := rows.Scan(&i.ID, &i.FromAccountID, &i.ToAccountID, &i.Amount, &i.CreatedAt)
+			return i.ID, i, err
+		},
+		decode: func(decoder *json.Decoder) ([]any, error) {
+			var i Transfer
+			err := decoder.Decode(&i)
+			return []any{i.ID, i.FromAccountID, i.ToAccountID, i.Amount, i.CreatedAt}, err
+		},
+	},
+}
+
+// archiveParent is the partitioned table that holds the archived partitions of table
+func archiveParent(table string) string {
+	return table + "_archive"
+}
+
+// archiveSummary describes the content of a written archive file
+type archiveSummary struct {
+	checksum string
+	rowCount int64
+	minID    int64
+	maxID    int64
+}
+
+// writeArchive streams the rows to path as gzip-compressed json lines,
+// next to a sha256sum compatible checksum file.
+// The archive only appears under its final name once it is complete.
+func writeArchive(path string, rows pgx.Rows, scan func(rows pgx.Rows) (int64, any, error)) (archiveSummary, error) {
+	var summary archiveSummary
+	defer rows.Close()
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return summary, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	zw := gzip.NewWriter(io.MultiWriter(file, hash))
+	encoder := json.NewEncoder(zw)
+
+	for rows.Next() {
+		id, record, err := scan(rows)
+		if err != nil {
+			return summary, err
+		}
+		if err := encoder.Encode(record); err != nil {
+			return summary, err
+		}
+
+		if summary.rowCount == 0 || id < summary.minID {
+			summary.minID = id
+		}
+		if id > summary.maxID {
+			summary.maxID = id
+		}
+		summary.rowCount++
+	}
+	if err := rows.Err(); err != nil {
+		return summary, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return summary, err
+	}
+	if err := file.Sync(); err != nil {
+		return summary, err
+	}
+	if err := file.Close(); err != nil {
+		return summary, err
+	}
+
+	summary.checksum = hex.EncodeToString(hash.Sum(nil))
+	checksumLine := fmt.Sprintf("%s  %s\n", summary.checksum, filepath.Base(path))
+	if err := os.WriteFile(path+checksumFileExt, []byte(checksumLine), 0o640); err != nil {
+		return summary, err
+	}
+
+	return summary, os.Rename(file.Name(), path)
+}
+
+// archiveReader reads the records of an archive file
+type archiveReader struct {
+	file *os.File
+	*gzip.Reader
+}
+
+func (reader *archiveReader) Close() error {
+	reader.Reader.Close()
+	return reader.file.Close()
+}
+
+// openArchive opens the file of an archived partition once it matches the catalog checksum
+func openArchive(partition ArchivedPartition) (*archiveReader, error) {
+	file, err := os.Open(partition.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive of %s: %w", partition.TableName, err)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != partition.Checksum {
+		file.Close()
+		return nil, fmt.Errorf("archive %s does not match its checksum", partition.FilePath)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &archiveReader{file: file, Reader: zr}, nil
+}
+
+// archiveSource feeds the records of an archive file to CopyFrom
+type archiveSource struct {
+	decoder *json.Decoder
+	decode  func(decoder *json.Decoder) ([]any, error)
+	values  []any
+	err     error
+}
+
+func (source *archiveSource) Next() bool {
+	if source.err != nil {
+		return false
+	}
+
+	source.values, source.err = source.decode(source.decoder)
+	if errors.Is(source.err, io.EOF) {
+		source.err = nil
+		return false
+	}
+	return source.err == nil
+}
+
+func (source *archiveSource) Values() ([]any, error) {
+	return source.values, nil
+}
+
+func (source *archiveSource) Err() error {
+	return source.err
+}
+
+// listWithArchives returns an offset page of rows ordered by id, made of the archived rows followed by the live ones.
+// Archived partitions hold the oldest months, so their ids come first.
+func listWithArchives[T any](
+	limit int32,
+	offset int32,
+	countArchived func() (int64, error),
+	listArchived func(limit int32, offset int32) ([]T, error),
+	listLive func(limit int32, offset int32) ([]T, error),
+) ([]T, error) {
+	archived, err := listArchived(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if int32(len(archived)) >= limit {
+		return archived, nil
+	}
+
+	// the live page starts where the archived rows end
+	if len(archived) > 0 {
+		offset = 0
+	} else {
+		count, err := countArchived()
+		if err != nil {
+			return nil, err
+		}
+		offset -= int32(count)
+	}
+
+	live, err := listLive(limit-int32(len(archived)), offset)
+	if err != nil {
+		return nil, err
+	}
+	return append(archived, live...), nil
+}
+
+// GetEntry reads an entry from a replica, in the live or the archive tables
+func (store *SQLStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	return readFromReplica(ctx, store, func(q *Queries) (Entry, error) {
+		entry, err := q.GetEntry(ctx, id)
+		if errors.Is(err, ErrRecordNotFound) {
+			return q.GetArchivedEntry(ctx, id)
+		}
+		return entry, err
+	})
+}
+
+// ListEntries reads entries from a replica, the archived ones first
+func (store *SQLStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Entry, error) {
+		return listWithArchives(arg.Limit, arg.Offset,
+			func() (int64, error) {
+				return q.CountArchivedEntries(ctx, arg.AccountID)
+			},
+			func(limit int32, offset int32) ([]Entry, error) {
+				return q.ListArchivedEntries(ctx, ListArchivedEntriesParams{
+					AccountID: arg.AccountID,
+					Limit:     limit,
+					Offset:    offset,
+				})
+			},
+			func(limit int32, offset int32) ([]Entry, error) {
+				return q.ListEntries(ctx, ListEntriesParams{
+					AccountID: arg.AccountID,
+					Limit:     limit,
+					Offset:    offset,
+				})
+			},
+		)
+	})
+}
+
+// GetTransfer reads a transfer from a replica, in the live or the archive tables
+func (store *SQLStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	return readFromReplica(ctx, store, func(q *Queries) (Transfer, error) {
+		transfer, err := q.GetTransfer(ctx, id)
+		if errors.Is(err, ErrRecordNotFound) {
+			return q.GetArchivedTransfer(ctx, id)
+		}
+		return transfer, err
+	})
+}
+
+// ListTransfers reads transfers from a replica, the archived ones first
+func (store *SQLStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Transfer, error) {
+		return listWithArchives(arg.Limit, arg.Offset,
+			func() (int64, error) {
+				return q.CountArchivedTransfers(ctx, CountArchivedTransfersParams{
+					FromAccountID: arg.FromAccountID,
+					ToAccountID:   arg.ToAccountID,
+				})
+			},
+			func(limit int32, offset int32) ([]Transfer, error) {
+				return q.ListArchivedTransfers(ctx, ListArchivedTransfersParams{
+					FromAccountID: arg.FromAccountID,
+					ToAccountID:   arg.ToAccountID,
+					Limit:         limit,
+					Offset:        offset,
+				})
+			},
+			func(limit int32, offset int32) ([]Transfer, error) {
+				return q.ListTransfers(ctx, ListTransfersParams{
+					FromAccountID: arg.FromAccountID,
+					ToAccountID:   arg.ToAccountID,
+					Limit:         limit,
+					Offset:        offset,
+				})
+			},
+		)
+	})
+}
+
+// ListEntriesAfter reads newest first entries from a replica,
+// continuing into the archived months, which are older than any live row, once the live ones run out
+func (store *SQLStore) ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entry, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Entry, error) {
+		live, err := q.ListEntriesAfter(ctx, arg)
+		if err != nil || int32(len(live)) >= arg.Limit {
+			return live, err
+		}
+
+		archivedArg := ListArchivedEntriesAfterParams(arg)
+		archivedArg.Limit -= int32(len(live))
+		archived, err := q.ListArchivedEntriesAfter(ctx, archivedArg)
+		if err != nil {
+			return nil, err
+		}
+		return append(live, archived...), nil
+	})
+}
+
+// ListEntriesBefore reads oldest first entries from a replica, the archived months first
+func (store *SQLStore) ListEntriesBefore(ctx context.Context, arg ListEntriesBeforeParams) ([]Entry, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Entry, error) {
+		archived, err := q.ListArchivedEntriesBefore(ctx, ListArchivedEntriesBeforeParams(arg))
+		if err != nil || int32(len(archived)) >= arg.Limit {
+			return archived, err
+		}
+
+		liveArg := arg
+		liveArg.Limit -= int32(len(archived))
+		live, err := q.ListEntriesBefore(ctx, liveArg)
+		if err != nil {
+			return nil, err
+		}
+		return append(archived, live...), nil
+	})
+}
+
+// ListTransfersAfter reads newest first transfers from a replica,
+// continuing into the archived months, which are older than any live row, once the live ones run out
+func (store *SQLStore) ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfer, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Transfer, error) {
+		live, err := q.ListTransfersAfter(ctx, arg)
+		if err != nil || int32(len(live)) >= arg.Limit {
+			return live, err
+		}
+
+		archivedArg := ListArchivedTransfersAfterParams(arg)
+		archivedArg.Limit -= int32(len(live))
+		archived, err := q.ListArchivedTransfersAfter(ctx, archivedArg)
+		if err != nil {
+			return nil, err
+		}
+		return append(live, archived...), nil
+	})
+}
+
+// ListTransfersBefore reads oldest first transfers from a replica, the archived months first
+func (store *SQLStore) ListTransfersBefore(ctx context.Context, arg ListTransfersBeforeParams) ([]Transfer, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Transfer, error) {
+		archived, err := q.ListArchivedTransfersBefore(ctx, ListArchivedTransfersBeforeParams(arg))
+		if err != nil || int32(len(archived)) >= arg.Limit {
+			return archived, err
+		}
+
+		liveArg := arg
+		liveArg.Limit -= int32(len(archived))
+		live, err := q.ListTransfersBefore(ctx, liveArg)
+		if err != nil {
+			return nil, err
+		}
+		return append(archived, live...), nil
+	})
+}
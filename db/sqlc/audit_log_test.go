@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAuditLog(t *testing.T, actor string) AuditLog {
+	arg := CreateAuditLogParams{
+		RequestID:    util.RandomString(12),
+		Actor:        actor,
+		ActorRole:    util.BankerRole,
+		Method:       "PATCH",
+		Route:        "/users/:username",
+		ResourceType: "users",
+		ResourceID:   util.RandomOwner(),
+		StatusCode:   200,
+		Changes:      json.RawMessage(`{"email": {"before": "a@email.com", "after": "b@email.com"}}`),
+		ClientIp:     "127.0.0.1",
+		UserAgent:    "test",
+	}
+
+	auditLog, err := testStore.CreateAuditLog(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, auditLog)
+
+	require.Equal(t, arg.RequestID, auditLog.RequestID)
+	require.Equal(t, arg.Actor, auditLog.Actor)
+	require.Equal(t, arg.ActorRole, auditLog.ActorRole)
+	require.Equal(t, arg.Method, auditLog.Method)
+	require.Equal(t, arg.Route, auditLog.Route)
+	require.Equal(t, arg.ResourceType, auditLog.ResourceType)
+	require.Equal(t, arg.ResourceID, auditLog.ResourceID)
+	require.Equal(t, arg.StatusCode, auditLog.StatusCode)
+	require.JSONEq(t, string(arg.Changes), string(auditLog.Changes))
+	require.NotZero(t, auditLog.ID)
+	require.NotZero(t, auditLog.CreatedAt)
+
+	return auditLog
+}
+
+func TestCreateAuditLog(t *testing.T) {
+	createRandomAuditLog(t, util.RandomOwner())
+}
+
+func TestListAuditLogs(t *testing.T) {
+	actor := util.RandomOwner()
+	for i := 0; i < 3; i++ {
+		createRandomAuditLog(t, actor)
+	}
+	createRandomAuditLog(t, util.RandomOwner())
+
+	arg := ListAuditLogsAfterParams{
+		Actor: pgtype.Text{
+			String: actor,
+			Valid:  true,
+		},
+		Limit: 10,
+	}
+	auditLogs, err := testStore.ListAuditLogsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 3)
+
+	for i, auditLog := range auditLogs {
+		require.Equal(t, actor, auditLog.Actor)
+		if i > 0 {
+			require.Less(t, auditLog.ID, auditLogs[i-1].ID)
+		}
+	}
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	auditLog := createRandomAuditLog(t, util.RandomOwner())
+
+	store := testStore.(*SQLStore)
+	_, err := store.connPool.Exec(context.Background(), "DELETE FROM audit_logs WHERE id = $1", auditLog.ID)
+	require.Error(t, err)
+
+	_, err = store.connPool.Exec(context.Background(), "UPDATE audit_logs SET actor = 'someone' WHERE id = $1", auditLog.ID)
+	require.Error(t, err)
+}
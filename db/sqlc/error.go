@@ -2,15 +2,17 @@ package db
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 const (
-	ForeignKeyViolation = "23503"
-	UniqueViolation     = "23505"
+	ForeignKeyViolation  = "23503"
+	UniqueViolation      = "23505"
+	CheckViolation       = "23514"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
 )
 
 var ErrRecordNotFound = pgx.ErrNoRows
@@ -22,8 +24,14 @@ var ErrUniqueViolation = &pgconn.PgError{
 func ErrCode(err error) string {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
-		fmt.Println(">>", pgErr.ConstraintName)
 		return pgErr.Code
 	}
 	return ""
 }
+
+// IsTxConflict reports whether the transaction failed because it conflicted
+// with a concurrent one and can be retried
+func IsTxConflict(err error) bool {
+	code := ErrCode(err)
+	return code == SerializationFailure || code == DeadlockDetected
+}
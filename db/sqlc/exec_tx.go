@@ -2,12 +2,65 @@ package db
 
 import (
 	"context"
+	"expvar"
 	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxTxAttempts      = 8
+	txRetryBaseDelay   = 5 * time.Millisecond
+	txRetryMaxDelay    = time.Second
+	txMetricsRetried   = "retried"
+	txMetricsRecovered = "recovered"
+	txMetricsExhausted = "exhausted"
 )
 
-// execTx executes a function within a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.connPool.Begin(ctx)
+// txMetrics counts transaction retries, published through expvar as "db_tx_retries"
+var txMetrics = expvar.NewMap("db_tx_retries")
+
+// execTx executes a function within a database transaction using the given options.
+// Transactions that fail with a serialization failure or a deadlock are retried
+// with jittered exponential backoff, so fn must be safe to run more than once.
+func (store *SQLStore) execTx(ctx context.Context, txOptions pgx.TxOptions, fn func(*Queries) error) error {
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, txOptions, fn)
+		if err == nil {
+			if attempt > 1 {
+				txMetrics.Add(txMetricsRecovered, 1)
+			}
+			return nil
+		}
+
+		if !IsTxConflict(err) {
+			return err
+		}
+
+		if attempt == maxTxAttempts {
+			txMetrics.Add(txMetricsExhausted, 1)
+			log.Printf("transaction failed after %d attempts: %v", attempt, err)
+			return err
+		}
+
+		txMetrics.Add(txMetricsRetried, 1)
+		delay := txRetryDelay(attempt)
+		log.Printf("retrying transaction in %s (attempt %d/%d): %v", delay, attempt, maxTxAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runTx runs fn once within a database transaction
+func (store *SQLStore) runTx(ctx context.Context, txOptions pgx.TxOptions, fn func(*Queries) error) error {
+	tx, err := store.connPool.BeginTx(ctx, txOptions)
 	if err != nil {
 		return err
 	}
@@ -16,10 +69,22 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
 
 	return tx.Commit(ctx)
 }
+
+// txRetryDelay returns a random delay between half and all of the
+// exponential backoff for the given attempt, capped at txRetryMaxDelay
+func txRetryDelay(attempt int) time.Duration {
+	delay := txRetryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > txRetryMaxDelay {
+		delay = txRetryMaxDelay
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxRetryDelay(t *testing.T) {
+	for attempt := 1; attempt <= maxTxAttempts*2; attempt++ {
+		delay := txRetryDelay(attempt)
+
+		backoff := txRetryBaseDelay << (attempt - 1)
+		if backoff <= 0 || backoff > txRetryMaxDelay {
+			backoff = txRetryMaxDelay
+		}
+
+		require.GreaterOrEqual(t, delay, backoff/2)
+		require.LessOrEqual(t, delay, backoff)
+	}
+}
+
+func TestExecTxRetriesConflicts(t *testing.T) {
+	store := testStore.(*SQLStore)
+
+	for _, code := range []string{SerializationFailure, DeadlockDetected} {
+		attempts := 0
+		retried := txMetricsValue(txMetricsRetried)
+
+		err := store.execTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.Serializable}, func(q *Queries) error {
+			attempts++
+			if attempts < 3 {
+				return &pgconn.PgError{Code: code}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, attempts)
+		require.Equal(t, retried+2, txMetricsValue(txMetricsRetried))
+	}
+}
+
+func TestExecTxGivesUpAfterMaxAttempts(t *testing.T) {
+	store := testStore.(*SQLStore)
+	exhausted := txMetricsValue(txMetricsExhausted)
+
+	attempts := 0
+	err := store.execTx(context.Background(), pgx.TxOptions{}, func(q *Queries) error {
+		attempts++
+		return &pgconn.PgError{Code: SerializationFailure}
+	})
+	require.True(t, IsTxConflict(err))
+	require.Equal(t, maxTxAttempts, attempts)
+	require.Equal(t, exhausted+1, txMetricsValue(txMetricsExhausted))
+}
+
+func TestExecTxDoesNotRetryOtherErrors(t *testing.T) {
+	store := testStore.(*SQLStore)
+	errFailed := errors.New("failed")
+
+	attempts := 0
+	err := store.execTx(context.Background(), pgx.TxOptions{}, func(q *Queries) error {
+		attempts++
+		return errFailed
+	})
+	require.ErrorIs(t, err, errFailed)
+	require.Equal(t, 1, attempts)
+}
+
+func TestExecTxStopsRetryingWhenCancelled(t *testing.T) {
+	store := testStore.(*SQLStore)
+
+	ctx, cancel := context.WithTimeout(context.Background(), txRetryBaseDelay)
+	defer cancel()
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		time.Sleep(txRetryBaseDelay)
+		return &pgconn.PgError{Code: DeadlockDetected}
+	})
+	require.Error(t, err)
+}
+
+func txMetricsValue(key string) int64 {
+	value := txMetrics.Get(key)
+	if value == nil {
+		return 0
+	}
+	return value.(interface{ Value() int64 }).Value()
+}
@@ -5,11 +5,28 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// access to an account delegated by one of its holders
+type AccessGrant struct {
+	ID        int64  `json:"id"`
+	Grantor   string `json:"grantor"`
+	Grantee   string `json:"grantee"`
+	AccountID int64  `json:"account_id"`
+	// view or transfer
+	Scopes []string `json:"scopes"`
+	// largest amount of a single transfer, null for no limit
+	TransferLimit pgtype.Int8        `json:"transfer_limit"`
+	ExpiresAt     time.Time          `json:"expires_at"`
+	RevokedAt     pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
 // table "accounts" contains account information
 type Account struct {
 	ID        int64     `json:"id"`
@@ -17,9 +34,87 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// incremented on every update, used for optimistic concurrency
+	Version int64 `json:"version"`
+	// active, frozen or closed
+	Status string `json:"status"`
+}
+
+// users sharing an account with its owner
+type AccountMember struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	// view, transact or manage
+	Permission string `json:"permission"`
+	InvitedBy  string `json:"invited_by"`
+	// null while the invitation is pending
+	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// operations held until a second banker approves them
+type Approval struct {
+	ID int64 `json:"id"`
+	// transfer above the approval threshold or balance adjustment
+	Kind string `json:"kind"`
+	// user who asked for the operation
+	Maker string `json:"maker"`
+	// banker who approved or rejected the operation, null when it timed out
+	Checker pgtype.Text `json:"checker"`
+	// account sending the transfer or adjusted
+	AccountID   int64       `json:"account_id"`
+	ToAccountID pgtype.Int8 `json:"to_account_id"`
+	Amount      pgtype.Int8 `json:"amount"`
+	// balance an adjustment sets
+	Balance pgtype.Int8 `json:"balance"`
+	// version of the account an adjustment applies to
+	AccountVersion pgtype.Int8 `json:"account_version"`
+	Currency       string      `json:"currency"`
+	Status         string      `json:"status"`
+	// transfer executed on approval
+	TransferID pgtype.Int8        `json:"transfer_id"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	ResolvedAt pgtype.Timestamptz `json:"resolved_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// monthly partitions moved to the archive tables, with an offline copy in a file
+type ArchivedPartition struct {
+	ID          int64     `json:"id"`
+	TableName   string    `json:"table_name"`
+	ParentTable string    `json:"parent_table"`
+	RangeStart  time.Time `json:"range_start"`
+	RangeEnd    time.Time `json:"range_end"`
+	FilePath    string    `json:"file_path"`
+	// hex sha256 of the compressed archive file
+	Checksum   string    `json:"checksum"`
+	RowCount   int64     `json:"row_count"`
+	MinID      int64     `json:"min_id"`
+	MaxID      int64     `json:"max_id"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// append-only record of every mutating api call
+type AuditLog struct {
+	ID           int64  `json:"id"`
+	RequestID    string `json:"request_id"`
+	Actor        string `json:"actor"`
+	ActorRole    string `json:"actor_role"`
+	Method       string `json:"method"`
+	Route        string `json:"route"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	StatusCode   int32  `json:"status_code"`
+	// changed fields with their before and after values
+	Changes   json.RawMessage `json:"changes"`
+	ClientIp  string          `json:"client_ip"`
+	UserAgent string          `json:"user_agent"`
+	CreatedAt time.Time       `json:"created_at"`
+	// access grant the actor used as a delegate
+	GrantID pgtype.Int8 `json:"grant_id"`
 }
 
-// record balance changes
+// record balance changes, partitioned by month of created_at
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
@@ -28,6 +123,150 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// background jobs, claimed by the workers with skip locked
+type Job struct {
+	ID int64 `json:"id"`
+	// selects the handler of the job, such as send_verify_email
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+	// running jobs past locked_until were left by a stopped worker and are claimed again
+	Status      string `json:"status"`
+	Attempts    int32  `json:"attempts"`
+	MaxAttempts int32  `json:"max_attempts"`
+	// error of the last failed attempt
+	LastError pgtype.Text `json:"last_error"`
+	// earliest time the next attempt runs
+	RunAt       time.Time          `json:"run_at"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	FinishedAt  pgtype.Timestamptz `json:"finished_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// logins of users with two-factor authentication waiting for their code
+type LoginChallenge struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the challenge token returned by the first login step
+	TokenHash string `json:"token_hash"`
+	// wrong codes given, the challenge is refused once they reach the limit
+	Attempts  int32     `json:"attempts"`
+	IsUsed    bool      `json:"is_used"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// failed logins of usernames and client ips, which get locked out once they fail too often
+type LoginLockout struct {
+	Scope string `json:"scope"`
+	// the username as typed, whether a user has it or not, or the client ip
+	Identifier string `json:"identifier"`
+	// failed logins since the count was last cleared, it starts over once the last failure is old enough
+	FailedAttempts int32     `json:"failed_attempts"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+	// logins are refused until then, each failure past the threshold locks for longer
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+}
+
+// requests to pay sent by one user to another
+type MoneyRequest struct {
+	ID        int64  `json:"id"`
+	Requester string `json:"requester"`
+	Payer     string `json:"payer"`
+	// account of the requester receiving the money
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Note        string `json:"note"`
+	// pending, then accepted, declined, cancelled or expired
+	Status string `json:"status"`
+	// transfer paying an accepted request
+	TransferID pgtype.Int8        `json:"transfer_id"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	ResolvedAt pgtype.Timestamptz `json:"resolved_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// address book of accounts a user sends money to
+type Payee struct {
+	ID        int64  `json:"id"`
+	Owner     string `json:"owner"`
+	Nickname  string `json:"nickname"`
+	AccountID int64  `json:"account_id"`
+	// currency of the payee account when it was saved
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// requests of users, emails or client ips counted against the limits of the requests that can be abused
+type RateLimit struct {
+	Scope      string `json:"scope"`
+	Identifier string `json:"identifier"`
+	// requests since the window started, counted before they are served
+	Hits int32 `json:"hits"`
+	// the count starts over in a new window once this one is older than the window of the scope
+	WindowStartedAt time.Time `json:"window_started_at"`
+}
+
+// single-use codes to log in without the authenticator app
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the normalized code, the code itself is only shown once to the user
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// single-use tokens of the password reset links sent to users
+type ResetPassword struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token in the link, the token itself is only in the email
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// outcome of the risk rules on every transfer asked for
+type RiskDecision struct {
+	ID int64 `json:"id"`
+	// user who asked for the transfer
+	Username      string `json:"username"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	ClientIp      string `json:"client_ip"`
+	// allow, review or block
+	Outcome string `json:"outcome"`
+	// rules that fired with their outcome and reason
+	FiredRules json.RawMessage `json:"fired_rules"`
+	// approval the transfer was parked in for a banker to decide
+	ApprovalID pgtype.Int8 `json:"approval_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// names of users matching an entry of the sanctions list
+type ScreeningHit struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// full name of the user when it was screened
+	ScreenedName string `json:"screened_name"`
+	// id of the matching entry in the sanctions list
+	ListEntryID string `json:"list_entry_id"`
+	// name or alias of the entry that matched
+	ListedName string `json:"listed_name"`
+	// similarity of the names from 0 to 100
+	Score int32 `json:"score"`
+	// open and confirmed hits block transfers of the user, cleared ones do not
+	Status string `json:"status"`
+	// banker who cleared or confirmed the hit
+	ReviewedBy pgtype.Text        `json:"reviewed_by"`
+	ReviewedAt pgtype.Timestamptz `json:"reviewed_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
 type Session struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
@@ -39,7 +278,7 @@ type Session struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
-// keep tack transfer history
+// keep tack transfer history, partitioned by month of created_at
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
@@ -49,6 +288,18 @@ type Transfer struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// totp secrets of the users who set up two-factor authentication
+type TwoFactor struct {
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+	// false until the user confirms the secret with a first code
+	IsEnabled bool `json:"is_enabled"`
+	// time step of the last code used, older codes are refused so that none is used twice
+	LastUsedStep int64              `json:"last_used_step"`
+	EnabledAt    pgtype.Timestamptz `json:"enabled_at"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
 type User struct {
 	Username          string    `json:"username"`
 	HashedPassword    string    `json:"hashed_password"`
@@ -57,4 +308,27 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	Role              string    `json:"role"`
+	// incremented on every update, used for optimistic concurrency
+	Version int64 `json:"version"`
+	// disabled users cannot log in
+	Disabled bool `json:"disabled"`
+	// whether others can find the user by username or email to pay them, users opt in
+	Discoverable bool `json:"discoverable"`
+	// whether the user followed the verification link sent to their current email
+	IsEmailVerified bool `json:"is_email_verified"`
+	// access tokens issued before this time are revoked
+	TokensValidAfter time.Time `json:"tokens_valid_after"`
+}
+
+// single-use tokens of the verification links sent to users
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// address the link was sent to, the user must still have it when following the link
+	Email string `json:"email"`
+	// sha256 of the token in the link, the token itself is only in the email
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// partitionedTables are range-partitioned by the utc month of created_at
+var partitionedTables = []string{"entries", "transfers"}
+
+const listPartitions = `SELECT c.relname::text FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = $1::regclass
+ORDER BY c.relname`
+
+// PartitionManager maintains the monthly partitions of entries and transfers
+type PartitionManager struct {
+	connPool *pgxpool.Pool
+}
+
+// NewPartitionManager creates a new partition manager
+func NewPartitionManager(connPool *pgxpool.Pool) *PartitionManager {
+	return &PartitionManager{
+		connPool: connPool,
+	}
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func partitionName(table string, month time.Time) string {
+	return table + "_" + month.Format("2006_01")
+}
+
+// partitionMonth parses the month out of a partition name,
+// it returns false for the default partition
+func partitionMonth(table string, name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, table+"_")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	month, err := time.Parse("2006_01", suffix)
+	return month, err == nil
+}
+
+// CreatePartitions makes sure every table has a partition for the month of from
+// and for the given number of months after it.
+// It returns the names of the partitions it had to create.
+func (manager *PartitionManager) CreatePartitions(ctx context.Context, from time.Time, months int) ([]string, error) {
+	var created []string
+
+	for _, table := range partitionedTables {
+		for i := 0; i <= months; i++ {
+			month := monthStart(from).AddDate(0, i, 0)
+
+			var ok bool
+			err := manager.connPool.QueryRow(ctx, "SELECT create_monthly_partition($1, $2)", table, month).Scan(&ok)
+			if err != nil {
+				return created, fmt.Errorf("cannot create partition %s: %w", partitionName(table, month), err)
+			}
+			if ok {
+				created = append(created, partitionName(table, month))
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// ArchivePartitions moves every partition whose month ended before the cutoff from its live table
+// to the archive table, with an offline copy in a file in dir.
+// Every server reads the archived rows from the archive tables afterwards, the files are never read by requests.
+func (manager *PartitionManager) ArchivePartitions(ctx context.Context, dir string, before time.Time) ([]ArchivedPartition, error) {
+	var archived []ArchivedPartition
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	for _, table := range partitionedTables {
+		rows, err := manager.connPool.Query(ctx, listPartitions, table)
+		if err != nil {
+			return archived, err
+		}
+		names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return archived, err
+		}
+
+		for _, name := range names {
+			month, ok := partitionMonth(table, name)
+			if !ok || month.AddDate(0, 1, 0).After(before) {
+				continue
+			}
+
+			partition, err := manager.archivePartition(ctx, dir, table, name, month)
+			if err != nil {
+				return archived, fmt.Errorf("cannot archive partition %s: %w", name, err)
+			}
+			archived = append(archived, partition)
+		}
+	}
+
+	return archived, nil
+}
+
+// archivePartition writes the rows of one partition to its archive file,
+// moves it to the archive table and records it in the catalog within a single transaction
+func (manager *PartitionManager) archivePartition(ctx context.Context, dir string, table string, name string, month time.Time) (ArchivedPartition, error) {
+	var partition ArchivedPartition
+	path := filepath.Join(dir, name+archiveFileExt)
+	identifier := pgx.Identifier{name}.Sanitize()
+
+	err := pgx.BeginFunc(ctx, manager.connPool, func(tx pgx.Tx) error {
+		// block writes so that the file holds exactly the rows that get dropped
+		_, err := tx.Exec(ctx, "LOCK TABLE "+identifier+" IN SHARE MODE")
+		if err != nil {
+			return err
+		}
+
+		columns := strings.Join(archiveTables[table].columns, ", ")
+		rows, err := tx.Query(ctx, fmt.Sprintf("SELECT %s FROM %s ORDER BY id", columns, identifier))
+		if err != nil {
+			return err
+		}
+		summary, err := writeArchive(path, rows, archiveTables[table].scan)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", pgx.Identifier{table}.Sanitize(), identifier))
+		if err != nil {
+			return err
+		}
+		if err := attachArchive(ctx, tx, table, name, month); err != nil {
+			return err
+		}
+
+		partition, err = New(tx).CreateArchivedPartition(ctx, CreateArchivedPartitionParams{
+			TableName:   name,
+			ParentTable: table,
+			RangeStart:  month,
+			RangeEnd:    month.AddDate(0, 1, 0),
+			FilePath:    path,
+			Checksum:    summary.checksum,
+			RowCount:    summary.rowCount,
+			MinID:       summary.minID,
+			MaxID:       summary.maxID,
+		})
+		return err
+	})
+	if err != nil {
+		os.Remove(path)
+		os.Remove(path + checksumFileExt)
+	}
+
+	return partition, err
+}
+
+// attachArchive attaches the partition of the month to the archive table of table
+func attachArchive(ctx context.Context, tx pgx.Tx, table string, name string, month time.Time) error {
+	_, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')",
+		pgx.Identifier{archiveParent(table)}.Sanitize(),
+		pgx.Identifier{name}.Sanitize(),
+		month.Format(time.RFC3339),
+		month.AddDate(0, 1, 0).Format(time.RFC3339),
+	))
+	return err
+}
+
+// RestoreArchives loads the partitions archived before the archive tables existed, which only have
+// their file, into the archive tables. It has to run where the files are, once.
+func (manager *PartitionManager) RestoreArchives(ctx context.Context) ([]ArchivedPartition, error) {
+	var restored []ArchivedPartition
+
+	for _, table := range partitionedTables {
+		partitions, err := New(manager.connPool).ListArchivedPartitions(ctx, table)
+		if err != nil {
+			return restored, err
+		}
+
+		for _, partition := range partitions {
+			var exists bool
+			err := manager.connPool.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", partition.TableName).Scan(&exists)
+			if err != nil {
+				return restored, err
+			}
+			if exists {
+				continue
+			}
+
+			if err := manager.restoreArchive(ctx, partition); err != nil {
+				return restored, fmt.Errorf("cannot restore partition %s: %w", partition.TableName, err)
+			}
+			restored = append(restored, partition)
+		}
+	}
+
+	return restored, nil
+}
+
+// restoreArchive copies the rows of an archive file into a new partition of the archive table
+func (manager *PartitionManager) restoreArchive(ctx context.Context, partition ArchivedPartition) error {
+	archive, err := openArchive(partition)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	table := archiveTables[partition.ParentTable]
+	return pgx.BeginFunc(ctx, manager.connPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS)",
+			pgx.Identifier{partition.TableName}.Sanitize(),
+			pgx.Identifier{archiveParent(partition.ParentTable)}.Sanitize(),
+		))
+		if err != nil {
+			return err
+		}
+
+		copied, err := tx.CopyFrom(ctx, pgx.Identifier{partition.TableName}, table.columns, &archiveSource{
+			decoder: json.NewDecoder(archive),
+			decode:  table.decode,
+		})
+		if err != nil {
+			return err
+		}
+		if copied != partition.RowCount {
+			return fmt.Errorf("archive %s holds %d rows instead of %d", partition.FilePath, copied, partition.RowCount)
+		}
+
+		return attachArchive(ctx, tx, partition.ParentTable, partition.TableName, partition.RangeStart)
+	})
+}
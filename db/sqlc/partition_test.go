@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionMonth(t *testing.T) {
+	month, ok := partitionMonth("entries", "entries_2023_07")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2023, time.July, 1, 0, 0, 0, 0, time.UTC), month)
+
+	_, ok = partitionMonth("entries", "entries_default")
+	require.False(t, ok)
+
+	require.Equal(t, "transfers_2023_12", partitionName("transfers", monthStart(time.Date(2023, time.December, 31, 23, 0, 0, 0, time.UTC))))
+}
+
+func TestReadArchiveChecksumMismatch(t *testing.T) {
+	path := t.TempDir() + "/entries_2000_01" + archiveFileExt
+	require.NoError(t, os.WriteFile(path, []byte("not an archive"), 0o640))
+
+	_, err := openArchive(ArchivedPartition{FilePath: path, Checksum: "0000"})
+	require.ErrorContains(t, err, "checksum")
+}
+
+func TestArchivePartitions(t *testing.T) {
+	store := testStore.(*SQLStore)
+	manager := NewPartitionManager(store.connPool)
+	ctx := context.Background()
+
+	// use a month far in the past so that no other test writes to it
+	month := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		_, err := store.connPool.Exec(ctx, "DROP TABLE IF EXISTS entries_2000_01, transfers_2000_01")
+		require.NoError(t, err)
+		_, err = store.connPool.Exec(ctx, "DELETE FROM archived_partitions WHERE range_start = $1", month)
+		require.NoError(t, err)
+	})
+
+	_, err := manager.CreatePartitions(ctx, month, 0)
+	require.NoError(t, err)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	var entryID, transferID int64
+	err = store.connPool.QueryRow(ctx,
+		"INSERT INTO entries (account_id, amount, created_at) VALUES ($1, 10, $2) RETURNING id",
+		account1.ID, month.Add(time.Hour),
+	).Scan(&entryID)
+	require.NoError(t, err)
+	err = store.connPool.QueryRow(ctx,
+		"INSERT INTO transfers (from_account_id, to_account_id, amount, created_at) VALUES ($1, $2, 10, $3) RETURNING id",
+		account2.ID, account1.ID, month.Add(time.Hour),
+	).Scan(&transferID)
+	require.NoError(t, err)
+	liveEntry := createRandomEntry(t, account1)
+
+	archived, err := manager.ArchivePartitions(ctx, t.TempDir(), month.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Len(t, archived, 2)
+	require.Equal(t, "entries_2000_01", archived[0].TableName)
+	require.Equal(t, entryID, archived[0].MinID)
+	require.Equal(t, "transfers_2000_01", archived[1].TableName)
+
+	for _, partition := range archived {
+		require.FileExists(t, partition.FilePath)
+		require.FileExists(t, partition.FilePath+checksumFileExt)
+	}
+
+	_, err = store.Queries.GetEntry(ctx, entryID)
+	require.ErrorIs(t, err, ErrRecordNotFound, "archived rows leave the live table")
+
+	entry, err := store.GetEntry(ctx, entryID)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, entry.AccountID)
+	require.WithinDuration(t, month.Add(time.Hour), entry.CreatedAt, time.Second)
+
+	archivedEntry, err := store.Queries.GetArchivedEntry(ctx, entryID)
+	require.NoError(t, err)
+	require.Equal(t, entry, archivedEntry)
+
+	count, err := store.Queries.CountArchivedEntries(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	entries, err := store.ListEntries(ctx, ListEntriesParams{
+		AccountID: account1.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, entryID, entries[0].ID)
+	require.Equal(t, liveEntry.ID, entries[1].ID)
+
+	entries, err = store.ListEntries(ctx, ListEntriesParams{
+		AccountID: account1.ID,
+		Limit:     5,
+		Offset:    1,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, liveEntry.ID, entries[0].ID)
+
+	newest, err := store.ListEntriesAfter(ctx, ListEntriesAfterParams{
+		AccountID: account1.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, newest, 2)
+	require.Equal(t, liveEntry.ID, newest[0].ID)
+	require.Equal(t, entryID, newest[1].ID)
+
+	oldest, err := store.ListEntriesBefore(ctx, ListEntriesBeforeParams{
+		AccountID:       account1.ID,
+		CursorCreatedAt: month.AddDate(0, 0, -1),
+		Limit:           1,
+	})
+	require.NoError(t, err)
+	require.Len(t, oldest, 1)
+	require.Equal(t, entryID, oldest[0].ID)
+
+	transfer, err := store.GetTransfer(ctx, transferID)
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, transfer.FromAccountID)
+
+	// partitions archived before the archive tables existed only have their file
+	_, err = store.connPool.Exec(ctx, "DROP TABLE entries_2000_01")
+	require.NoError(t, err)
+	_, err = store.GetEntry(ctx, entryID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	restored, err := manager.RestoreArchives(ctx)
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+	require.Equal(t, "entries_2000_01", restored[0].TableName)
+
+	entry, err = store.GetEntry(ctx, entryID)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, entry.AccountID)
+
+	restored, err = manager.RestoreArchives(ctx)
+	require.NoError(t, err)
+	require.Empty(t, restored)
+
+	created, err := manager.CreatePartitions(ctx, month, 0)
+	require.NoError(t, err)
+	require.Empty(t, created, "archived months must not be recreated")
+}
@@ -6,30 +6,192 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AcceptAccountMember(ctx context.Context, arg AcceptAccountMemberParams) (AccountMember, error)
+	AcceptMoneyRequest(ctx context.Context, arg AcceptMoneyRequestParams) (MoneyRequest, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	// BlockUserSessions blocks the sessions of a user and revokes the access tokens issued to them until now
+	BlockUserSessions(ctx context.Context, username string) (int64, error)
+	// ClaimJob takes the next job that is due, or that a stopped worker left running,
+	// and keeps the other workers off it until locked_until
+	ClaimJob(ctx context.Context, lockedUntil pgtype.Timestamptz) (Job, error)
+	// CompleteJob marks the attempt of the job as done, unless another worker claimed the job since
+	CompleteJob(ctx context.Context, arg CompleteJobParams) (Job, error)
+	// CountArchivedEntries counts the entries of an account in the archived months
+	CountArchivedEntries(ctx context.Context, accountID int64) (int64, error)
+	// CountArchivedTransfers counts the transfers of an account in the archived months
+	CountArchivedTransfers(ctx context.Context, arg CountArchivedTransfersParams) (int64, error)
+	// CountBlockingScreeningHits counts the hits of the users that are not cleared
+	CountBlockingScreeningHits(ctx context.Context, usernames []string) (int64, error)
+	// CountRecoveryCodes counts the codes the user has not used yet
+	CountRecoveryCodes(ctx context.Context, username string) (int64, error)
+	// CountRiskDecisionsFromIP counts the transfers of the user from the client ip that went through,
+	// allowed at once or held and approved by a banker
+	CountRiskDecisionsFromIP(ctx context.Context, arg CountRiskDecisionsFromIPParams) (int64, error)
+	CountTransfersBetween(ctx context.Context, arg CountTransfersBetweenParams) (int64, error)
+	CreateAccessGrant(ctx context.Context, arg CreateAccessGrantParams) (AccessGrant, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error)
+	CreateApproval(ctx context.Context, arg CreateApprovalParams) (Approval, error)
+	CreateArchivedPartition(ctx context.Context, arg CreateArchivedPartitionParams) (ArchivedPartition, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateJob(ctx context.Context, arg CreateJobParams) (Job, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
+	CreateMoneyRequest(ctx context.Context, arg CreateMoneyRequestParams) (MoneyRequest, error)
+	CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateResetPassword(ctx context.Context, arg CreateResetPasswordParams) (ResetPassword, error)
+	CreateRiskDecision(ctx context.Context, arg CreateRiskDecisionParams) (RiskDecision, error)
+	// CreateScreeningHit records a hit unless the name of the user already matched the entry
+	CreateScreeningHit(ctx context.Context, arg CreateScreeningHitParams) (int64, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	// CreateTwoFactor sets up a new secret for the user, replacing the one they did not confirm.
+	// It returns no row while two-factor authentication is enabled.
+	CreateTwoFactor(ctx context.Context, arg CreateTwoFactorParams) (TwoFactor, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteAccount(ctx context.Context, id int64) error
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	DeleteAccount(ctx context.Context, arg DeleteAccountParams) (int64, error)
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) (int64, error)
+	DeleteLoginLockout(ctx context.Context, arg DeleteLoginLockoutParams) (int64, error)
+	DeletePayee(ctx context.Context, arg DeletePayeeParams) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, username string) (int64, error)
+	// DeleteStaleLoginLockouts forgets the failures older than failed_before that no longer lock anyone out
+	DeleteStaleLoginLockouts(ctx context.Context, failedBefore time.Time) (int64, error)
+	// DeleteStaleRateLimits forgets the counts of the windows that started before started_before
+	DeleteStaleRateLimits(ctx context.Context, startedBefore time.Time) (int64, error)
+	DeleteTwoFactor(ctx context.Context, username string) (int64, error)
+	// EnableTwoFactor enables the secret the user confirmed with the code of last_used_step
+	EnableTwoFactor(ctx context.Context, arg EnableTwoFactorParams) (TwoFactor, error)
+	ExpireMoneyRequests(ctx context.Context) (int64, error)
+	// FailJob records the error of the attempt and runs the job again at run_at,
+	// or gives it up after its last attempt
+	FailJob(ctx context.Context, arg FailJobParams) (Job, error)
+	FailLoginChallenge(ctx context.Context, id int64) (LoginChallenge, error)
+	GetAccessGrant(ctx context.Context, id int64) (AccessGrant, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByOwnerCurrency(ctx context.Context, arg GetAccountByOwnerCurrencyParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error)
+	// GetActiveAccessGrant picks the least restricted grant with the scope
+	// that is still backed by its grantor managing the account
+	GetActiveAccessGrant(ctx context.Context, arg GetActiveAccessGrantParams) (AccessGrant, error)
+	GetApproval(ctx context.Context, id int64) (Approval, error)
+	// GetArchivedEntry reads an entry of the archived months
+	GetArchivedEntry(ctx context.Context, id int64) (Entry, error)
+	// GetArchivedTransfer reads a transfer of the archived months
+	GetArchivedTransfer(ctx context.Context, id int64) (Transfer, error)
+	// GetClientIPHistory counts the sessions a user opened before a time, in total and from a client ip
+	GetClientIPHistory(ctx context.Context, arg GetClientIPHistoryParams) (GetClientIPHistoryRow, error)
+	// GetDiscoverableUser resolves a username or an email to a user who can be paid through the directory
+	GetDiscoverableUser(ctx context.Context, alias string) (User, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetJob(ctx context.Context, id int64) (Job, error)
+	GetLoginChallenge(ctx context.Context, tokenHash string) (LoginChallenge, error)
+	GetLoginLockout(ctx context.Context, arg GetLoginLockoutParams) (LoginLockout, error)
+	GetMoneyRequest(ctx context.Context, id int64) (MoneyRequest, error)
+	GetPayee(ctx context.Context, id int64) (Payee, error)
+	GetScreeningHit(ctx context.Context, id int64) (ScreeningHit, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTwoFactor(ctx context.Context, username string) (TwoFactor, error)
 	GetUser(ctx context.Context, username string) (User, error)
-	ListAccountWithCursor(ctx context.Context, arg ListAccountWithCursorParams) ([]Account, error)
+	// GetUserAccess reads what decides whether the access tokens of a user still hold
+	GetUserAccess(ctx context.Context, username string) (GetUserAccessRow, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	// HitRateLimit counts a request, starting a new window when the current one started
+	// before reset_before
+	HitRateLimit(ctx context.Context, arg HitRateLimitParams) (RateLimit, error)
+	ListAccessGrants(ctx context.Context, username string) ([]AccessGrant, error)
+	ListAccountInvitations(ctx context.Context, username string) ([]AccountMember, error)
+	ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error)
+	ListAccountsBefore(ctx context.Context, arg ListAccountsBeforeParams) ([]Account, error)
+	// ListApprovalsAfter lists approvals oldest first, the order bankers review them in
+	ListApprovalsAfter(ctx context.Context, arg ListApprovalsAfterParams) ([]Approval, error)
+	ListApprovalsBefore(ctx context.Context, arg ListApprovalsBeforeParams) ([]Approval, error)
+	// ListArchivedEntries lists the entries of an account in the archived months
+	ListArchivedEntries(ctx context.Context, arg ListArchivedEntriesParams) ([]Entry, error)
+	ListArchivedEntriesAfter(ctx context.Context, arg ListArchivedEntriesAfterParams) ([]Entry, error)
+	ListArchivedEntriesBefore(ctx context.Context, arg ListArchivedEntriesBeforeParams) ([]Entry, error)
+	ListArchivedPartitions(ctx context.Context, parentTable string) ([]ArchivedPartition, error)
+	// ListArchivedTransfers lists the transfers of an account in the archived months
+	ListArchivedTransfers(ctx context.Context, arg ListArchivedTransfersParams) ([]Transfer, error)
+	ListArchivedTransfersAfter(ctx context.Context, arg ListArchivedTransfersAfterParams) ([]Transfer, error)
+	ListArchivedTransfersBefore(ctx context.Context, arg ListArchivedTransfersBeforeParams) ([]Transfer, error)
+	// ListAuditLogsAfter lists audit logs newest first
+	ListAuditLogsAfter(ctx context.Context, arg ListAuditLogsAfterParams) ([]AuditLog, error)
+	ListAuditLogsBefore(ctx context.Context, arg ListAuditLogsBeforeParams) ([]AuditLog, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entry, error)
+	ListEntriesBefore(ctx context.Context, arg ListEntriesBeforeParams) ([]Entry, error)
+	ListIncomingMoneyRequestsAfter(ctx context.Context, arg ListIncomingMoneyRequestsAfterParams) ([]MoneyRequest, error)
+	ListIncomingMoneyRequestsBefore(ctx context.Context, arg ListIncomingMoneyRequestsBeforeParams) ([]MoneyRequest, error)
+	// ListLoginLockoutsAfter lists the usernames and client ips that are locked out, the longest locked first
+	ListLoginLockoutsAfter(ctx context.Context, arg ListLoginLockoutsAfterParams) ([]LoginLockout, error)
+	ListLoginLockoutsBefore(ctx context.Context, arg ListLoginLockoutsBeforeParams) ([]LoginLockout, error)
+	ListOutgoingMoneyRequestsAfter(ctx context.Context, arg ListOutgoingMoneyRequestsAfterParams) ([]MoneyRequest, error)
+	ListOutgoingMoneyRequestsBefore(ctx context.Context, arg ListOutgoingMoneyRequestsBeforeParams) ([]MoneyRequest, error)
+	ListPayees(ctx context.Context, owner string) ([]Payee, error)
+	ListRiskDecisionsAfter(ctx context.Context, arg ListRiskDecisionsAfterParams) ([]RiskDecision, error)
+	ListRiskDecisionsBefore(ctx context.Context, arg ListRiskDecisionsBeforeParams) ([]RiskDecision, error)
+	// ListScreeningHitsAfter lists screening hits oldest first, the order bankers review them in
+	ListScreeningHitsAfter(ctx context.Context, arg ListScreeningHitsAfterParams) ([]ScreeningHit, error)
+	ListScreeningHitsBefore(ctx context.Context, arg ListScreeningHitsBeforeParams) ([]ScreeningHit, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfer, error)
+	ListTransfersBefore(ctx context.Context, arg ListTransfersBeforeParams) ([]Transfer, error)
+	// ListTransfersSince lists the transfers sent from an account since a time, oldest first
+	ListTransfersSince(ctx context.Context, arg ListTransfersSinceParams) ([]Transfer, error)
+	ListUsersAfter(ctx context.Context, arg ListUsersAfterParams) ([]User, error)
+	ListUsersBefore(ctx context.Context, arg ListUsersBeforeParams) ([]User, error)
+	NotifyAccountEvent(ctx context.Context, arg NotifyAccountEventParams) error
+	// RefundLoginAttempt takes back a login counted by ReserveLoginAttempt whose password turned out
+	// right, and lifts the lockout it started, the one until locked_until
+	RefundLoginAttempt(ctx context.Context, arg RefundLoginAttemptParams) (int64, error)
+	// RehashUserPassword swaps the hash of the password for one of the current scheme. It keeps the version
+	// and password_changed_at, and leaves a password that changed since old_hashed_password was read.
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error)
+	// RejectExpiredApprovals rejects the operations nobody reviewed in time
+	RejectExpiredApprovals(ctx context.Context) (int64, error)
+	// ReserveLoginAttempt counts a login as failed before its password is checked, starting the count
+	// over when the last failure happened before reset_before. The failure that reaches the threshold
+	// locks the scope out for delay_seconds, doubled with every further failure up to max_delay_seconds.
+	// Nothing is counted nor returned while the scope is locked out, so that a burst of parallel logins
+	// cannot get past the threshold before the lockout starts.
+	ReserveLoginAttempt(ctx context.Context, arg ReserveLoginAttemptParams) (LoginLockout, error)
+	// ResolveApproval records the decision of the checker on an approval that is still pending
+	ResolveApproval(ctx context.Context, arg ResolveApprovalParams) (Approval, error)
+	// ResolveMoneyRequest declines or cancels a request that is still pending
+	ResolveMoneyRequest(ctx context.Context, arg ResolveMoneyRequestParams) (MoneyRequest, error)
+	// ResolveScreeningHit records the review of a banker on a hit that is still open
+	ResolveScreeningHit(ctx context.Context, arg ResolveScreeningHitParams) (ScreeningHit, error)
+	RevokeAccessGrant(ctx context.Context, id int64) (AccessGrant, error)
+	SearchAccounts(ctx context.Context, arg SearchAccountsParams) ([]Account, error)
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountMember(ctx context.Context, arg UpdateAccountMemberParams) (AccountMember, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	// UseLoginChallenge consumes the challenge unless it was used or expired
+	UseLoginChallenge(ctx context.Context, id int64) (LoginChallenge, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	// UseResetPassword consumes the token of a password reset link unless it was used or expired
+	UseResetPassword(ctx context.Context, tokenHash string) (ResetPassword, error)
+	// UseTwoFactorStep records the step of a code, unless that code or a later one was used
+	UseTwoFactorStep(ctx context.Context, arg UseTwoFactorStepParams) (TwoFactor, error)
+	// UseUserResetPasswords consumes every reset link of the user that was not used yet
+	UseUserResetPasswords(ctx context.Context, username string) (int64, error)
+	// UseVerifyEmail consumes the token of a verification link unless it was used or expired
+	UseVerifyEmail(ctx context.Context, tokenHash string) (VerifyEmail, error)
 }
 
 var _ Querier = (*Queries)(nil)
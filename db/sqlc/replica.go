@@ -0,0 +1,276 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	replicaHealthCheckInterval = 5 * time.Second
+	replicaHealthCheckTimeout  = time.Second
+)
+
+// replica is a read-only connection pool that serves reads while it is healthy
+type replica struct {
+	connPool *pgxpool.Pool
+	queries  *Queries
+	healthy  atomic.Bool
+}
+
+// replicaSet balances reads over the healthy replicas in round-robin order
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+func newReplicaSet(connPools []*pgxpool.Pool) *replicaSet {
+	set := &replicaSet{}
+	for _, connPool := range connPools {
+		r := &replica{
+			connPool: connPool,
+			queries:  New(connPool),
+		}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+	return set
+}
+
+// pick returns the next healthy replica, or nil if there is none
+func (set *replicaSet) pick() *replica {
+	n := uint64(len(set.replicas))
+	for i := uint64(0); i < n; i++ {
+		r := set.replicas[set.next.Add(1)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// checkHealth pings every replica and takes unreachable ones out of rotation
+func (set *replicaSet) checkHealth(ctx context.Context) {
+	for _, r := range set.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaHealthCheckTimeout)
+		err := r.connPool.Ping(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			log.Printf("replica %s healthy: %t (%v)", r.connPool.Config().ConnConfig.Host, healthy, err)
+		}
+	}
+}
+
+// monitor checks the replicas' health periodically until the context is cancelled
+func (set *replicaSet) monitor(ctx context.Context) {
+	ticker := time.NewTicker(replicaHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			set.checkHealth(ctx)
+		}
+	}
+}
+
+// isConnError reports whether err comes from the connection rather than from the query,
+// in which case the query can safely be retried on another server
+func isConnError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, ErrRecordNotFound) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	return !errors.As(err, &pgErr)
+}
+
+// readFromReplica runs a read-only query on a healthy replica,
+// falling back to the primary when there is none or the replica fails.
+// Only listings go to the replicas: reads that authorize or precondition a write,
+// like GetAccount, stay on the primary so that replica lag cannot make them stale.
+func readFromReplica[T any](ctx context.Context, store *SQLStore, read func(q *Queries) (T, error)) (T, error) {
+	r := store.replicas.pick()
+	if r == nil {
+		return read(store.Queries)
+	}
+
+	result, err := read(r.queries)
+	if isConnError(ctx, err) {
+		log.Println("replica read failed, falling back to primary: ", err)
+		r.healthy.Store(false)
+		return read(store.Queries)
+	}
+
+	return result, err
+}
+
+// ListAccounts reads accounts from a replica
+func (store *SQLStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Account, error) {
+		return q.ListAccounts(ctx, arg)
+	})
+}
+
+// ListAccountsAfter reads accounts from a replica
+func (store *SQLStore) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Account, error) {
+		return q.ListAccountsAfter(ctx, arg)
+	})
+}
+
+// ListAccountsBefore reads accounts from a replica
+func (store *SQLStore) ListAccountsBefore(ctx context.Context, arg ListAccountsBeforeParams) ([]Account, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Account, error) {
+		return q.ListAccountsBefore(ctx, arg)
+	})
+}
+
+// ListAccessGrants reads access grants from a replica
+func (store *SQLStore) ListAccessGrants(ctx context.Context, username string) ([]AccessGrant, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]AccessGrant, error) {
+		return q.ListAccessGrants(ctx, username)
+	})
+}
+
+// ListAccountInvitations reads pending invitations from a replica
+func (store *SQLStore) ListAccountInvitations(ctx context.Context, username string) ([]AccountMember, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]AccountMember, error) {
+		return q.ListAccountInvitations(ctx, username)
+	})
+}
+
+// ListAccountMembers reads account members from a replica
+func (store *SQLStore) ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]AccountMember, error) {
+		return q.ListAccountMembers(ctx, accountID)
+	})
+}
+
+// ListApprovalsAfter reads approvals from a replica
+func (store *SQLStore) ListApprovalsAfter(ctx context.Context, arg ListApprovalsAfterParams) ([]Approval, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Approval, error) {
+		return q.ListApprovalsAfter(ctx, arg)
+	})
+}
+
+// ListApprovalsBefore reads approvals from a replica
+func (store *SQLStore) ListApprovalsBefore(ctx context.Context, arg ListApprovalsBeforeParams) ([]Approval, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Approval, error) {
+		return q.ListApprovalsBefore(ctx, arg)
+	})
+}
+
+// ListAuditLogsAfter reads audit logs from a replica
+func (store *SQLStore) ListAuditLogsAfter(ctx context.Context, arg ListAuditLogsAfterParams) ([]AuditLog, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]AuditLog, error) {
+		return q.ListAuditLogsAfter(ctx, arg)
+	})
+}
+
+// ListAuditLogsBefore reads audit logs from a replica
+func (store *SQLStore) ListAuditLogsBefore(ctx context.Context, arg ListAuditLogsBeforeParams) ([]AuditLog, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]AuditLog, error) {
+		return q.ListAuditLogsBefore(ctx, arg)
+	})
+}
+
+// ListIncomingMoneyRequestsAfter reads money requests from a replica
+func (store *SQLStore) ListIncomingMoneyRequestsAfter(ctx context.Context, arg ListIncomingMoneyRequestsAfterParams) ([]MoneyRequest, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]MoneyRequest, error) {
+		return q.ListIncomingMoneyRequestsAfter(ctx, arg)
+	})
+}
+
+// ListIncomingMoneyRequestsBefore reads money requests from a replica
+func (store *SQLStore) ListIncomingMoneyRequestsBefore(ctx context.Context, arg ListIncomingMoneyRequestsBeforeParams) ([]MoneyRequest, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]MoneyRequest, error) {
+		return q.ListIncomingMoneyRequestsBefore(ctx, arg)
+	})
+}
+
+// ListOutgoingMoneyRequestsAfter reads money requests from a replica
+func (store *SQLStore) ListOutgoingMoneyRequestsAfter(ctx context.Context, arg ListOutgoingMoneyRequestsAfterParams) ([]MoneyRequest, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]MoneyRequest, error) {
+		return q.ListOutgoingMoneyRequestsAfter(ctx, arg)
+	})
+}
+
+// ListOutgoingMoneyRequestsBefore reads money requests from a replica
+func (store *SQLStore) ListOutgoingMoneyRequestsBefore(ctx context.Context, arg ListOutgoingMoneyRequestsBeforeParams) ([]MoneyRequest, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]MoneyRequest, error) {
+		return q.ListOutgoingMoneyRequestsBefore(ctx, arg)
+	})
+}
+
+// ListPayees reads payees from a replica
+func (store *SQLStore) ListPayees(ctx context.Context, owner string) ([]Payee, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Payee, error) {
+		return q.ListPayees(ctx, owner)
+	})
+}
+
+// ListRiskDecisionsAfter reads risk decisions from a replica
+func (store *SQLStore) ListRiskDecisionsAfter(ctx context.Context, arg ListRiskDecisionsAfterParams) ([]RiskDecision, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]RiskDecision, error) {
+		return q.ListRiskDecisionsAfter(ctx, arg)
+	})
+}
+
+// ListRiskDecisionsBefore reads risk decisions from a replica
+func (store *SQLStore) ListRiskDecisionsBefore(ctx context.Context, arg ListRiskDecisionsBeforeParams) ([]RiskDecision, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]RiskDecision, error) {
+		return q.ListRiskDecisionsBefore(ctx, arg)
+	})
+}
+
+// ListScreeningHitsAfter reads screening hits from a replica
+func (store *SQLStore) ListScreeningHitsAfter(ctx context.Context, arg ListScreeningHitsAfterParams) ([]ScreeningHit, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]ScreeningHit, error) {
+		return q.ListScreeningHitsAfter(ctx, arg)
+	})
+}
+
+// ListScreeningHitsBefore reads screening hits from a replica
+func (store *SQLStore) ListScreeningHitsBefore(ctx context.Context, arg ListScreeningHitsBeforeParams) ([]ScreeningHit, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]ScreeningHit, error) {
+		return q.ListScreeningHitsBefore(ctx, arg)
+	})
+}
+
+// ListUsersAfter reads users from a replica
+func (store *SQLStore) ListUsersAfter(ctx context.Context, arg ListUsersAfterParams) ([]User, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]User, error) {
+		return q.ListUsersAfter(ctx, arg)
+	})
+}
+
+// ListUsersBefore reads users from a replica
+func (store *SQLStore) ListUsersBefore(ctx context.Context, arg ListUsersBeforeParams) ([]User, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]User, error) {
+		return q.ListUsersBefore(ctx, arg)
+	})
+}
+
+// SearchAccounts reads accounts from a replica
+func (store *SQLStore) SearchAccounts(ctx context.Context, arg SearchAccountsParams) ([]Account, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]Account, error) {
+		return q.SearchAccounts(ctx, arg)
+	})
+}
+
+// SearchUsers reads users from a replica
+func (store *SQLStore) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	return readFromReplica(ctx, store, func(q *Queries) ([]User, error) {
+		return q.SearchUsers(ctx, arg)
+	})
+}
@@ -12,6 +12,29 @@ import (
 	"github.com/google/uuid"
 )
 
+const blockUserSessions = `-- name: BlockUserSessions :execrows
+WITH revoked_tokens AS (
+  UPDATE users
+  SET tokens_valid_after = now()
+  WHERE username = $1
+)
+UPDATE sessions
+SET is_blocked = true
+WHERE
+  username = $1 AND
+  NOT is_blocked AND
+  expires_at > now()
+`
+
+// BlockUserSessions blocks the sessions of a user and revokes the access tokens issued to them until now
+func (q *Queries) BlockUserSessions(ctx context.Context, username string) (int64, error) {
+	result, err := q.db.Exec(ctx, blockUserSessions, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
   id,
@@ -60,6 +83,33 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 	return i, err
 }
 
+const getClientIPHistory = `-- name: GetClientIPHistory :one
+SELECT
+  count(*) AS sessions,
+  count(*) FILTER (WHERE client_ip = $1) AS client_ip_sessions
+FROM sessions
+WHERE username = $2 AND created_at < $3
+`
+
+type GetClientIPHistoryParams struct {
+	ClientIp string    `json:"client_ip"`
+	Username string    `json:"username"`
+	Before   time.Time `json:"before"`
+}
+
+type GetClientIPHistoryRow struct {
+	Sessions         int64 `json:"sessions"`
+	ClientIpSessions int64 `json:"client_ip_sessions"`
+}
+
+// GetClientIPHistory counts the sessions a user opened before a time, in total and from a client ip
+func (q *Queries) GetClientIPHistory(ctx context.Context, arg GetClientIPHistoryParams) (GetClientIPHistoryRow, error) {
+	row := q.db.QueryRow(ctx, getClientIPHistory, arg.ClientIp, arg.Username, arg.Before)
+	var i GetClientIPHistoryRow
+	err := row.Scan(&i.Sessions, &i.ClientIpSessions)
+	return i, err
+}
+
 const getSession = `-- name: GetSession :one
 SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions
 WHERE id = $1 LIMIT 1
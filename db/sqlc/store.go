@@ -10,18 +10,38 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	AcceptMoneyRequestTx(ctx context.Context, arg AcceptMoneyRequestTxParams) (AcceptMoneyRequestTxResult, error)
+	ApproveTx(ctx context.Context, arg ApproveTxParams) (ApproveTxResult, error)
+	UpdateUserAccessTx(ctx context.Context, arg UpdateUserAccessTxParams) (UpdateUserAccessTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, tokenHash string) (VerifyEmailTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	EnableTwoFactorTx(ctx context.Context, arg EnableTwoFactorTxParams) (EnableTwoFactorTxResult, error)
+	CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (CompleteLoginChallengeTxResult, error)
+	ResetTwoFactorTx(ctx context.Context, username string) (ResetTwoFactorTxResult, error)
+	ListenAccountEvents(ctx context.Context, handle func(AccountEvent)) error
 }
 
 // SQLStore provides all functionalities to execute SQL queries and transaction
 type SQLStore struct {
 	*Queries
 	connPool *pgxpool.Pool
+	replicas *replicaSet
 }
 
-// NewStore creates a new store
-func NewStore(connPool *pgxpool.Pool) Store {
-	return &SQLStore{
+// NewStore creates a new store.
+// Listings are routed to the replicas, if any, while they are healthy.
+func NewStore(connPool *pgxpool.Pool, replicaPools ...*pgxpool.Pool) Store {
+	store := &SQLStore{
 		connPool: connPool,
 		Queries:  New(connPool),
+		replicas: newReplicaSet(replicaPools),
 	}
+
+	if len(replicaPools) > 0 {
+		go store.replicas.monitor(context.Background())
+	}
+
+	return store
 }
@@ -0,0 +1,27 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/db/storetest"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStoreConformance lives in an external test package because storetest imports db
+func TestStoreConformance(t *testing.T) {
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	connPool, err := pgxpool.New(context.Background(), config.DBSource)
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	store := db.NewStore(connPool)
+	storetest.Run(t, func(t *testing.T) db.Store {
+		return store
+	})
+}
@@ -7,8 +7,28 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countTransfersBetween = `-- name: CountTransfersBetween :one
+SELECT count(*) FROM transfers
+WHERE from_account_id = $1 AND to_account_id = $2
+`
+
+type CountTransfersBetweenParams struct {
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+}
+
+func (q *Queries) CountTransfersBetween(ctx context.Context, arg CountTransfersBetweenParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTransfersBetween, arg.FromAccountID, arg.ToAccountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
@@ -103,3 +123,136 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const listTransfersAfter = `-- name: ListTransfersAfter :many
+SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+WHERE
+  (from_account_id = $1 OR to_account_id = $1) AND
+  ($2::timestamptz IS NULL OR
+    (created_at, id) < ($2, $3::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListTransfersAfterParams struct {
+	AccountID       int64              `json:"account_id"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.Int8        `json:"cursor_id"`
+	Limit           int32              `json:"limit"`
+}
+
+func (q *Queries) ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listTransfersAfter,
+		arg.AccountID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersBefore = `-- name: ListTransfersBefore :many
+SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+WHERE
+  (from_account_id = $1 OR to_account_id = $1) AND
+  (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListTransfersBeforeParams struct {
+	AccountID       int64     `json:"account_id"`
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorID        int64     `json:"cursor_id"`
+	Limit           int32     `json:"limit"`
+}
+
+func (q *Queries) ListTransfersBefore(ctx context.Context, arg ListTransfersBeforeParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listTransfersBefore,
+		arg.AccountID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersSince = `-- name: ListTransfersSince :many
+SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+WHERE from_account_id = $1 AND created_at >= $2
+ORDER BY created_at, id
+`
+
+type ListTransfersSinceParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ListTransfersSince lists the transfers sent from an account since a time, oldest first
+func (q *Queries) ListTransfersSince(ctx context.Context, arg ListTransfersSinceParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listTransfersSince, arg.FromAccountID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
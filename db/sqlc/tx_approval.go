@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ApproveTxParams contains the input parameters of the approve transaction
+type ApproveTxParams struct {
+	ID      int64  `json:"id"`
+	Checker string `json:"checker"`
+}
+
+// ApproveTxResult is the result of the approve transaction
+type ApproveTxResult struct {
+	Approval Approval `json:"approval"`
+	// the transfer executed for a transfer approval
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+	// the account adjusted for an adjustment approval
+	Account *Account `json:"account,omitempty"`
+}
+
+// ApproveTx executes the operation held by a pending approval and records the checker
+// within a single serializable db transaction.
+// It returns ErrRecordNotFound if the approval is no longer pending,
+// or if the account of an adjustment changed since it was asked for.
+func (store *SQLStore) ApproveTx(ctx context.Context, arg ApproveTxParams) (ApproveTxResult, error) {
+	var result ApproveTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(q *Queries) error {
+		approval, err := q.GetApproval(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		var transferID pgtype.Int8
+		switch approval.Kind {
+		case util.TransferApproval:
+			transferResult, err := transfer(ctx, q, TransferTxParams{
+				FromAccountID: approval.AccountID,
+				ToAccountID:   approval.ToAccountID.Int64,
+				Amount:        approval.Amount.Int64,
+			})
+			if err != nil {
+				return err
+			}
+			result.Transfer = &transferResult
+			transferID = pgtype.Int8{Int64: transferResult.Transfer.ID, Valid: true}
+		case util.AdjustmentApproval:
+			account, err := q.UpdateAccount(ctx, UpdateAccountParams{
+				ID:      approval.AccountID,
+				Balance: approval.Balance.Int64,
+				Version: approval.AccountVersion,
+			})
+			if err != nil {
+				return err
+			}
+			result.Account = &account
+		}
+
+		result.Approval, err = q.ResolveApproval(ctx, ResolveApprovalParams{
+			ID:         approval.ID,
+			Status:     util.ApprovedApproval,
+			Checker:    pgtype.Text{String: arg.Checker, Valid: true},
+			TransferID: transferID,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AcceptMoneyRequestTxParams contains the input parameters of the accept money request transaction
+type AcceptMoneyRequestTxParams struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	// outcome of the risk rules that let the payment through, recorded with the payment
+	RiskDecision *CreateRiskDecisionParams `json:"risk_decision"`
+}
+
+// AcceptMoneyRequestTxResult is the result of the accept money request transaction
+type AcceptMoneyRequestTxResult struct {
+	MoneyRequest MoneyRequest     `json:"money_request"`
+	Transfer     TransferTxResult `json:"transfer"`
+}
+
+// AcceptMoneyRequestTx pays a pending money request from the given account
+// and marks it accepted within a single serializable db transaction.
+// It returns ErrRecordNotFound if the request is no longer pending.
+func (store *SQLStore) AcceptMoneyRequestTx(ctx context.Context, arg AcceptMoneyRequestTxParams) (AcceptMoneyRequestTxResult, error) {
+	var result AcceptMoneyRequestTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(q *Queries) error {
+		request, err := q.GetMoneyRequest(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   request.ToAccountID,
+			Amount:        request.Amount,
+			RiskDecision:  arg.RiskDecision,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.MoneyRequest, err = q.AcceptMoneyRequest(ctx, AcceptMoneyRequestParams{
+			ID:         request.ID,
+			TransferID: pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
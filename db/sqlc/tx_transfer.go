@@ -1,12 +1,24 @@
 package db
 
-import "context"
+import (
+	"context"
+	"errors"
+
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrAccountNotActive is returned for transfers from or to a frozen or closed account
+var ErrAccountNotActive = errors.New("account is frozen or closed")
 
 // TransferTxParams contains the input parameters of the transfer transaction
 type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// outcome of the risk rules that let the transfer through, recorded with the transfer
+	// so that no decision counts towards the rules for a transfer that never happened
+	RiskDecision *CreateRiskDecisionParams `json:"risk_decision"`
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -20,66 +32,90 @@ type TransferTxResult struct {
 
 // TransferTx performs a money transfer from one account to another
 // It creates a transfer record, add account entries,
-// and update accounts' balance within a single db transaction
+// and update accounts' balance within a single serializable db transaction
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// transfer moves the money within the transaction of q
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (result TransferTxResult, err error) {
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return
+	}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
+	})
+	if err != nil {
+		return
+	}
 
-		// // get account -> update its balance
-		// account1, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
-		// if err != nil {
-		// 	return err
-		// }
-
-		// result.FromAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
-		// 	ID:      arg.FromAccountID,
-		// 	Balance: account1.Balance - arg.Amount,
-		// })
-		// if err != nil {
-		// 	return err
-		// }
-
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	// // get account -> update its balance
+	// account1, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+	// if err != nil {
+	// 	return
+	// }
+
+	// result.FromAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
+	// 	ID:      arg.FromAccountID,
+	// 	Balance: account1.Balance - arg.Amount,
+	// })
+	// if err != nil {
+	// 	return
+	// }
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+
+	if err != nil {
+		return
+	}
 
+	// the balance updates lock both accounts, so their status cannot change before the transfer commits
+	if result.FromAccount.Status != util.ActiveAccount || result.ToAccount.Status != util.ActiveAccount {
+		err = ErrAccountNotActive
+		return
+	}
+
+	if arg.RiskDecision != nil {
+		_, err = q.CreateRiskDecision(ctx, *arg.RiskDecision)
 		if err != nil {
-			return err
+			return
 		}
+	}
 
-		return nil
-	})
+	err = publishAccountEvent(ctx, q, newAccountEvent(result.Transfer, result.FromEntry, result.FromAccount))
+	if err != nil {
+		return
+	}
 
-	return result, err
+	err = publishAccountEvent(ctx, q, newAccountEvent(result.Transfer, result.ToEntry, result.ToAccount))
+	return
 }
 
 func addMoney(ctx context.Context, q *Queries, accountID1 int64, amount1 int64, accountID2 int64, amount2 int64) (account1 Account, account2 Account, err error) {
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EnableTwoFactorTxParams contains the input parameters of the enable two factor transaction
+type EnableTwoFactorTxParams struct {
+	Username string `json:"username"`
+	// time step of the code the user confirmed the secret with
+	Step               int64    `json:"step"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+// EnableTwoFactorTxResult is the result of the enable two factor transaction
+type EnableTwoFactorTxResult struct {
+	TwoFactor     TwoFactor      `json:"two_factor"`
+	RecoveryCodes []RecoveryCode `json:"recovery_codes"`
+}
+
+// EnableTwoFactorTx enables the secret of the user and replaces their recovery codes
+// within a single db transaction. It returns ErrRecordNotFound when the user has no secret
+// waiting for confirmation.
+func (store *SQLStore) EnableTwoFactorTx(ctx context.Context, arg EnableTwoFactorTxParams) (EnableTwoFactorTxResult, error) {
+	var result EnableTwoFactorTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+
+		result.TwoFactor, err = q.EnableTwoFactor(ctx, EnableTwoFactorParams{
+			Username:     arg.Username,
+			LastUsedStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.RecoveryCodes = make([]RecoveryCode, 0, len(arg.RecoveryCodeHashes))
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			code, err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// CompleteLoginChallengeTxParams contains the input parameters of the complete login challenge transaction.
+// Either the step of a valid code or the hash of a recovery code is set.
+type CompleteLoginChallengeTxParams struct {
+	ChallengeID      int64       `json:"challenge_id"`
+	Step             pgtype.Int8 `json:"step"`
+	RecoveryCodeHash pgtype.Text `json:"recovery_code_hash"`
+}
+
+// CompleteLoginChallengeTxResult is the result of the complete login challenge transaction
+type CompleteLoginChallengeTxResult struct {
+	LoginChallenge LoginChallenge `json:"login_challenge"`
+	RecoveryCode   RecoveryCode   `json:"recovery_code"`
+}
+
+// CompleteLoginChallengeTx consumes the challenge and the code that answered it within
+// a single db transaction. It returns ErrRecordNotFound when the challenge was used or expired,
+// or when the code was already used.
+func (store *SQLStore) CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (CompleteLoginChallengeTxResult, error) {
+	var result CompleteLoginChallengeTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+
+		result.LoginChallenge, err = q.UseLoginChallenge(ctx, arg.ChallengeID)
+		if err != nil {
+			return err
+		}
+
+		if arg.RecoveryCodeHash.Valid {
+			result.RecoveryCode, err = q.UseRecoveryCode(ctx, UseRecoveryCodeParams{
+				Username: result.LoginChallenge.Username,
+				CodeHash: arg.RecoveryCodeHash.String,
+			})
+			return err
+		}
+
+		_, err = q.UseTwoFactorStep(ctx, UseTwoFactorStepParams{
+			Username:     result.LoginChallenge.Username,
+			LastUsedStep: arg.Step.Int64,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ResetTwoFactorTxResult is the result of the reset two factor transaction
+type ResetTwoFactorTxResult struct {
+	DeletedRecoveryCodes int64 `json:"deleted_recovery_codes"`
+}
+
+// ResetTwoFactorTx deletes the secret and the recovery codes of the user within a single
+// db transaction. It returns ErrRecordNotFound when the user has not set up two-factor authentication.
+func (store *SQLStore) ResetTwoFactorTx(ctx context.Context, username string) (ResetTwoFactorTxResult, error) {
+	var result ResetTwoFactorTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		rows, err := q.DeleteTwoFactor(ctx, username)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrRecordNotFound
+		}
+
+		result.DeletedRecoveryCodes, err = q.DeleteRecoveryCodes(ctx, username)
+		return err
+	})
+
+	return result, err
+}
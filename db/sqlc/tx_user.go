@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreateUserTxParams contains the input parameters of the create user transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	// jobs enqueued with the user, such as sending the verification email
+	Jobs []CreateJobParams `json:"jobs"`
+	// hits of the full name on the screening list, recorded with the user so that no user
+	// can transfer money before their hits are there for bankers to review
+	ScreeningHits []CreateScreeningHitParams `json:"screening_hits"`
+}
+
+// CreateUserTxResult is the result of the create user transaction
+type CreateUserTxResult struct {
+	User User  `json:"user"`
+	Jobs []Job `json:"jobs"`
+}
+
+// CreateUserTx creates a user, enqueues its jobs and records its screening hits within a single
+// db transaction, so that no user misses its verification email or its screening and no email
+// goes to a user that does not exist
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.Jobs, err = createJobs(ctx, q, arg.Jobs)
+		if err != nil {
+			return err
+		}
+
+		return createScreeningHits(ctx, q, arg.ScreeningHits)
+	})
+
+	return result, err
+}
+
+// UpdateUserTxParams contains the input parameters of the update user transaction
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// jobs enqueued with the change, such as verifying a new email
+	Jobs []CreateJobParams `json:"jobs"`
+	// hits of a new full name on the screening list
+	ScreeningHits []CreateScreeningHitParams `json:"screening_hits"`
+}
+
+// UpdateUserTxResult is the result of the update user transaction
+type UpdateUserTxResult struct {
+	User            User  `json:"user"`
+	Jobs            []Job `json:"jobs"`
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+// UpdateUserTx updates a user, enqueues its jobs and records its screening hits within a single db transaction.
+// A new password blocks all of the user's sessions, as a reset does.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.HashedPassword.Valid {
+			result.RevokedSessions, err = q.BlockUserSessions(ctx, result.User.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Jobs, err = createJobs(ctx, q, arg.Jobs)
+		if err != nil {
+			return err
+		}
+
+		return createScreeningHits(ctx, q, arg.ScreeningHits)
+	})
+
+	return result, err
+}
+
+func createJobs(ctx context.Context, q *Queries, args []CreateJobParams) ([]Job, error) {
+	jobs := []Job{}
+	for _, arg := range args {
+		job, err := q.CreateJob(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func createScreeningHits(ctx context.Context, q *Queries, args []CreateScreeningHitParams) error {
+	for _, arg := range args {
+		if _, err := q.CreateScreeningHit(ctx, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyEmailTxResult is the result of the verify email transaction
+type VerifyEmailTxResult struct {
+	// user as it was before the verification, for the audit log
+	OldUser     User        `json:"old_user"`
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// VerifyEmailTx consumes the token of a verification link and marks the email of its user
+// as verified within a single db transaction. It returns ErrRecordNotFound when the token
+// was used, expired or never existed, or when the user changed their email since.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, tokenHash string) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		result.OldUser, err = q.GetUser(ctx, result.VerifyEmail.Username)
+		if err != nil {
+			return err
+		}
+		if result.OldUser.Email != result.VerifyEmail.Email {
+			return ErrRecordNotFound
+		}
+
+		// the version makes a concurrent change of email fail the verification
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username:        result.OldUser.Username,
+			IsEmailVerified: pgtype.Bool{Bool: true, Valid: true},
+			Version:         pgtype.Int8{Int64: result.OldUser.Version, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ResetPasswordTxParams contains the input parameters of the reset password transaction
+type ResetPasswordTxParams struct {
+	TokenHash      string `json:"token_hash"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// ResetPasswordTxResult is the result of the reset password transaction
+type ResetPasswordTxResult struct {
+	User            User          `json:"user"`
+	ResetPassword   ResetPassword `json:"reset_password"`
+	RevokedSessions int64         `json:"revoked_sessions"`
+}
+
+// ResetPasswordTx consumes the token of a password reset link, sets the new password of its user,
+// consumes their other reset links and blocks all of their sessions within a single db transaction. It returns
+// ErrRecordNotFound when the token was used, expired or never existed.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+
+		result.ResetPassword, err = q.UseResetPassword(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username:          result.ResetPassword.Username,
+			HashedPassword:    pgtype.Text{String: arg.HashedPassword, Valid: true},
+			PasswordChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// links sent before this one would otherwise change the new password again
+		if _, err = q.UseUserResetPasswords(ctx, result.User.Username); err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockUserSessions(ctx, result.User.Username)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// UpdateUserAccessTxParams contains the input parameters of the user access transaction
+type UpdateUserAccessTxParams struct {
+	Username string      `json:"username"`
+	Role     pgtype.Text `json:"role"`
+	Disabled pgtype.Bool `json:"disabled"`
+}
+
+// UpdateUserAccessTxResult is the result of the user access transaction
+type UpdateUserAccessTxResult struct {
+	User            User  `json:"user"`
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+// UpdateUserAccessTx changes the role or the disabled flag of a user
+// and blocks all of the user's sessions within a single db transaction,
+// so that neither the access tokens nor the refresh tokens issued under the previous access hold
+func (store *SQLStore) UpdateUserAccessTx(ctx context.Context, arg UpdateUserAccessTxParams) (UpdateUserAccessTxResult, error) {
+	var result UpdateUserAccessTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: arg.Username,
+			Role:     arg.Role,
+			Disabled: arg.Disabled,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockUserSessions(ctx, arg.Username)
+		return err
+	})
+
+	return result, err
+}
@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -19,7 +20,7 @@ INSERT INTO users (
   email
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after
 `
 
 type CreateUserParams struct {
@@ -45,12 +46,17 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.Disabled,
+		&i.Discoverable,
+		&i.IsEmailVerified,
+		&i.TokensValidAfter,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -65,20 +71,302 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.Disabled,
+		&i.Discoverable,
+		&i.IsEmailVerified,
+		&i.TokensValidAfter,
 	)
 	return i, err
 }
 
+const getDiscoverableUser = `-- name: GetDiscoverableUser :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after FROM users
+WHERE
+  (username = $1 OR email = $1) AND
+  discoverable AND
+  NOT disabled
+LIMIT 1
+`
+
+// GetDiscoverableUser resolves a username or an email to a user who can be paid through the directory
+func (q *Queries) GetDiscoverableUser(ctx context.Context, alias string) (User, error) {
+	row := q.db.QueryRow(ctx, getDiscoverableUser, alias)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.Version,
+		&i.Disabled,
+		&i.Discoverable,
+		&i.IsEmailVerified,
+		&i.TokensValidAfter,
+	)
+	return i, err
+}
+
+const getUserAccess = `-- name: GetUserAccess :one
+SELECT role, disabled, password_changed_at, tokens_valid_after FROM users
+WHERE username = $1 LIMIT 1
+`
+
+type GetUserAccessRow struct {
+	Role              string    `json:"role"`
+	Disabled          bool      `json:"disabled"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	TokensValidAfter  time.Time `json:"tokens_valid_after"`
+}
+
+// GetUserAccess reads what decides whether the access tokens of a user still hold
+func (q *Queries) GetUserAccess(ctx context.Context, username string) (GetUserAccessRow, error) {
+	row := q.db.QueryRow(ctx, getUserAccess, username)
+	var i GetUserAccessRow
+	err := row.Scan(
+		&i.Role,
+		&i.Disabled,
+		&i.PasswordChangedAt,
+		&i.TokensValidAfter,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.Version,
+		&i.Disabled,
+		&i.Discoverable,
+		&i.IsEmailVerified,
+		&i.TokensValidAfter,
+	)
+	return i, err
+}
+
+const listUsersAfter = `-- name: ListUsersAfter :many
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after FROM users
+WHERE
+  $1::timestamptz IS NULL OR
+  (created_at, username) < ($1, $2::varchar)
+ORDER BY created_at DESC, username DESC
+LIMIT $3
+`
+
+type ListUsersAfterParams struct {
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorUsername  pgtype.Text        `json:"cursor_username"`
+	Limit           int32              `json:"limit"`
+}
+
+func (q *Queries) ListUsersAfter(ctx context.Context, arg ListUsersAfterParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersAfter,
+		arg.CursorCreatedAt,
+		arg.CursorUsername,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Username,
+			&i.HashedPassword,
+			&i.FullName,
+			&i.Email,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.Role,
+			&i.Version,
+			&i.Disabled,
+			&i.Discoverable,
+			&i.IsEmailVerified,
+			&i.TokensValidAfter,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersBefore = `-- name: ListUsersBefore :many
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after FROM users
+WHERE
+  (created_at, username) > ($1::timestamptz, $2::varchar)
+ORDER BY created_at, username
+LIMIT $3
+`
+
+type ListUsersBeforeParams struct {
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorUsername  string    `json:"cursor_username"`
+	Limit           int32     `json:"limit"`
+}
+
+func (q *Queries) ListUsersBefore(ctx context.Context, arg ListUsersBeforeParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersBefore,
+		arg.CursorCreatedAt,
+		arg.CursorUsername,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Username,
+			&i.HashedPassword,
+			&i.FullName,
+			&i.Email,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.Role,
+			&i.Version,
+			&i.Disabled,
+			&i.Discoverable,
+			&i.IsEmailVerified,
+			&i.TokensValidAfter,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after FROM users
+WHERE
+  ($1::varchar IS NULL OR
+    username LIKE escape_like($1) || '%') AND
+  ($2::varchar IS NULL OR
+    lower(email) LIKE escape_like(lower($2)) || '%') AND
+  ($3::varchar IS NULL OR
+    lower(full_name) LIKE escape_like(lower($3)) || '%') AND
+  ($4::varchar IS NULL OR CASE
+    WHEN $5::varchar = 'full_name' AND NOT $6::bool THEN (full_name, username) > ($7::varchar, $4)
+    WHEN $5 = 'full_name' THEN (full_name, username) < ($7, $4)
+    WHEN $5 = 'email' AND NOT $6 THEN (email, username) > ($8::varchar, $4)
+    WHEN $5 = 'email' THEN (email, username) < ($8, $4)
+    WHEN $5 = 'created_at' AND NOT $6 THEN (created_at, username) > ($9::timestamptz, $4)
+    WHEN $5 = 'created_at' THEN (created_at, username) < ($9, $4)
+    WHEN NOT $6 THEN username > $4
+    ELSE username < $4
+  END)
+ORDER BY
+  CASE WHEN $5 = 'full_name' AND NOT $6 THEN full_name END,
+  CASE WHEN $5 = 'full_name' AND $6 THEN full_name END DESC,
+  CASE WHEN $5 = 'email' AND NOT $6 THEN email END,
+  CASE WHEN $5 = 'email' AND $6 THEN email END DESC,
+  CASE WHEN $5 = 'created_at' AND NOT $6 THEN created_at END,
+  CASE WHEN $5 = 'created_at' AND $6 THEN created_at END DESC,
+  CASE WHEN NOT $6 THEN username END,
+  CASE WHEN $6 THEN username END DESC
+LIMIT $10;
+`
+
+type SearchUsersParams struct {
+	UsernamePrefix  pgtype.Text        `json:"username_prefix"`
+	EmailPrefix     pgtype.Text        `json:"email_prefix"`
+	FullNamePrefix  pgtype.Text        `json:"full_name_prefix"`
+	CursorUsername  pgtype.Text        `json:"cursor_username"`
+	SortBy          string             `json:"sort_by"`
+	SortDesc        bool               `json:"sort_desc"`
+	CursorFullName  pgtype.Text        `json:"cursor_full_name"`
+	CursorEmail     pgtype.Text        `json:"cursor_email"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	Limit           int32              `json:"limit"`
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, searchUsers,
+		arg.UsernamePrefix,
+		arg.EmailPrefix,
+		arg.FullNamePrefix,
+		arg.CursorUsername,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.CursorFullName,
+		arg.CursorEmail,
+		arg.CursorCreatedAt,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Username,
+			&i.HashedPassword,
+			&i.FullName,
+			&i.Email,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.Role,
+			&i.Version,
+			&i.Disabled,
+			&i.Discoverable,
+			&i.IsEmailVerified,
+			&i.TokensValidAfter,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
   hashed_password = COALESCE($1, hashed_password),
   password_changed_at = COALESCE($2, password_changed_at),
   full_name = COALESCE($3, full_name),
-  email = COALESCE($4, email)
+  email = COALESCE($4, email),
+  role = COALESCE($5, role),
+  disabled = COALESCE($6, disabled),
+  discoverable = COALESCE($7, discoverable),
+  is_email_verified = COALESCE($8, is_email_verified),
+  version = version + 1
 WHERE
-  username = $5
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role
+  username = $9 AND
+  ($10::bigint IS NULL OR version = $10)
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, version, disabled, discoverable, is_email_verified, tokens_valid_after
 `
 
 type UpdateUserParams struct {
@@ -86,7 +374,12 @@ type UpdateUserParams struct {
 	PasswordChangedAt pgtype.Timestamptz `json:"password_changed_at"`
 	FullName          pgtype.Text        `json:"full_name"`
 	Email             pgtype.Text        `json:"email"`
+	Role              pgtype.Text        `json:"role"`
+	Disabled          pgtype.Bool        `json:"disabled"`
+	Discoverable      pgtype.Bool        `json:"discoverable"`
+	IsEmailVerified   pgtype.Bool        `json:"is_email_verified"`
 	Username          string             `json:"username"`
+	Version           pgtype.Int8        `json:"version"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
@@ -95,7 +388,12 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.PasswordChangedAt,
 		arg.FullName,
 		arg.Email,
+		arg.Role,
+		arg.Disabled,
+		arg.Discoverable,
+		arg.IsEmailVerified,
 		arg.Username,
+		arg.Version,
 	)
 	var i User
 	err := row.Scan(
@@ -106,6 +404,33 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.Disabled,
+		&i.Discoverable,
+		&i.IsEmailVerified,
+		&i.TokensValidAfter,
 	)
 	return i, err
 }
+
+const rehashUserPassword = `-- name: RehashUserPassword :execrows
+UPDATE users
+SET hashed_password = $1
+WHERE username = $2 AND hashed_password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewHashedPassword string `json:"new_hashed_password"`
+	Username          string `json:"username"`
+	OldHashedPassword string `json:"old_hashed_password"`
+}
+
+// RehashUserPassword swaps the hash of the password for one of the current scheme. It keeps the version
+// and password_changed_at, and leaves a password that changed since old_hashed_password was read.
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rehashUserPassword, arg.NewHashedPassword, arg.Username, arg.OldHashedPassword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -151,3 +151,27 @@ func TestUpdateUserAllFields(t *testing.T) {
 	require.NotEqual(t, oldUser.Email, updatedUser.Email)
 	require.Equal(t, newEmail, updatedUser.Email)
 }
+
+func TestUpdateUserVersion(t *testing.T) {
+	oldUser := createRandomUser(t)
+	require.Equal(t, int64(1), oldUser.Version)
+
+	arg := UpdateUserParams{
+		FullName: pgtype.Text{
+			String: util.RandomOwner(),
+			Valid:  true,
+		},
+		Username: oldUser.Username,
+		Version: pgtype.Int8{
+			Int64: oldUser.Version,
+			Valid: true,
+		},
+	}
+	updatedUser, err := testStore.UpdateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, oldUser.Version+1, updatedUser.Version)
+
+	// the same version is now stale
+	_, err = testStore.UpdateUser(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,232 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var accessGrantTests = []conformanceTest{
+	{"CreateAccessGrant", testCreateAccessGrant},
+	{"CreateAccessGrantViolations", testCreateAccessGrantViolations},
+	{"GetActiveAccessGrant", testGetActiveAccessGrant},
+	{"GetActiveAccessGrantOfFormerManager", testGetActiveAccessGrantOfFormerManager},
+	{"RevokeAccessGrant", testRevokeAccessGrant},
+}
+
+func createAccessGrant(t *testing.T, store db.Store, account db.Account, grantor string, grantee string, transferLimit int64, scopes ...string) db.AccessGrant {
+	arg := db.CreateAccessGrantParams{
+		Grantor:   grantor,
+		Grantee:   grantee,
+		AccountID: account.ID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if transferLimit > 0 {
+		arg.TransferLimit = pgtype.Int8{Int64: transferLimit, Valid: true}
+	}
+
+	grant, err := store.CreateAccessGrant(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, grant.ID)
+	require.Equal(t, arg.Grantor, grant.Grantor)
+	require.Equal(t, arg.Grantee, grant.Grantee)
+	require.Equal(t, arg.AccountID, grant.AccountID)
+	require.Equal(t, arg.Scopes, grant.Scopes)
+	require.Equal(t, arg.TransferLimit, grant.TransferLimit)
+	require.WithinDuration(t, arg.ExpiresAt, grant.ExpiresAt, time.Millisecond)
+	require.False(t, grant.RevokedAt.Valid)
+	require.NotZero(t, grant.CreatedAt)
+
+	return grant
+}
+
+func testCreateAccessGrant(t *testing.T, store db.Store) {
+	grantor := createRandomUser(t, store)
+	grantee := createRandomUser(t, store)
+	account := createAccount(t, store, grantor.Username, util.USD, 0)
+
+	grant1 := createAccessGrant(t, store, account, grantor.Username, grantee.Username, 50, util.ViewScope, util.TransferScope)
+
+	grant2, err := store.GetAccessGrant(context.Background(), grant1.ID)
+	require.NoError(t, err)
+	require.Equal(t, grant1, grant2)
+
+	for _, username := range []string{grantor.Username, grantee.Username} {
+		grants, err := store.ListAccessGrants(context.Background(), username)
+		require.NoError(t, err)
+		require.Equal(t, []db.AccessGrant{grant1}, grants)
+	}
+}
+
+func testCreateAccessGrantViolations(t *testing.T, store db.Store) {
+	grantor := createRandomUser(t, store)
+	grantee := createRandomUser(t, store)
+	account := createAccount(t, store, grantor.Username, util.USD, 0)
+
+	valid := db.CreateAccessGrantParams{
+		Grantor:   grantor.Username,
+		Grantee:   grantee.Username,
+		AccountID: account.ID,
+		Scopes:    []string{util.ViewScope},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name    string
+		update  func(arg *db.CreateAccessGrantParams)
+		errCode string
+	}{
+		{
+			name:    "NoScope",
+			update:  func(arg *db.CreateAccessGrantParams) { arg.Scopes = []string{} },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "UnsupportedScope",
+			update:  func(arg *db.CreateAccessGrantParams) { arg.Scopes = []string{util.ViewScope, "manage"} },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "NonPositiveTransferLimit",
+			update:  func(arg *db.CreateAccessGrantParams) { arg.TransferLimit = pgtype.Int8{Int64: 0, Valid: true} },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "SelfGrant",
+			update:  func(arg *db.CreateAccessGrantParams) { arg.Grantee = arg.Grantor },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "UnknownGrantee",
+			update:  func(arg *db.CreateAccessGrantParams) { arg.Grantee = util.RandomString(20) },
+			errCode: db.ForeignKeyViolation,
+		},
+		{
+			name:    "UnknownAccount",
+			update:  func(arg *db.CreateAccessGrantParams) { arg.AccountID = account.ID + 1_000_000 },
+			errCode: db.ForeignKeyViolation,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			arg := valid
+			tc.update(&arg)
+
+			_, err := store.CreateAccessGrant(context.Background(), arg)
+			require.Equal(t, tc.errCode, db.ErrCode(err))
+		})
+	}
+}
+
+func testGetActiveAccessGrant(t *testing.T, store db.Store) {
+	grantor := createRandomUser(t, store)
+	grantee := createRandomUser(t, store)
+	account := createAccount(t, store, grantor.Username, util.USD, 0)
+
+	getActive := func(scope string) (db.AccessGrant, error) {
+		return store.GetActiveAccessGrant(context.Background(), db.GetActiveAccessGrantParams{
+			AccountID: account.ID,
+			Grantee:   grantee.Username,
+			Scope:     scope,
+		})
+	}
+
+	_, err := getActive(util.ViewScope)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	view := createAccessGrant(t, store, account, grantor.Username, grantee.Username, 0, util.ViewScope)
+	small := createAccessGrant(t, store, account, grantor.Username, grantee.Username, 10, util.TransferScope)
+	large := createAccessGrant(t, store, account, grantor.Username, grantee.Username, 100, util.TransferScope)
+
+	_, err = store.CreateAccessGrant(context.Background(), db.CreateAccessGrantParams{
+		Grantor:   grantor.Username,
+		Grantee:   grantee.Username,
+		AccountID: account.ID,
+		Scopes:    []string{util.TransferScope},
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	grant, err := getActive(util.ViewScope)
+	require.NoError(t, err)
+	require.Equal(t, view.ID, grant.ID)
+
+	// the expired grant without limit is ignored, leaving the largest limit
+	grant, err = getActive(util.TransferScope)
+	require.NoError(t, err)
+	require.Equal(t, large.ID, grant.ID)
+
+	_, err = store.RevokeAccessGrant(context.Background(), large.ID)
+	require.NoError(t, err)
+
+	grant, err = getActive(util.TransferScope)
+	require.NoError(t, err)
+	require.Equal(t, small.ID, grant.ID)
+}
+
+func testGetActiveAccessGrantOfFormerManager(t *testing.T, store db.Store) {
+	owner := createRandomUser(t, store)
+	manager := createRandomUser(t, store)
+	grantee := createRandomUser(t, store)
+	account := createAccount(t, store, owner.Username, util.USD, 0)
+
+	createAccountMember(t, store, account, manager.Username, util.ManagePermission)
+	_, err := store.AcceptAccountMember(context.Background(), db.AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username:  manager.Username,
+	})
+	require.NoError(t, err)
+
+	grant := createAccessGrant(t, store, account, manager.Username, grantee.Username, 0, util.ViewScope)
+
+	arg := db.GetActiveAccessGrantParams{
+		AccountID: account.ID,
+		Grantee:   grantee.Username,
+		Scope:     util.ViewScope,
+	}
+	active, err := store.GetActiveAccessGrant(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, grant.ID, active.ID)
+
+	// grants stop working once their grantor no longer manages the account
+	_, err = store.UpdateAccountMember(context.Background(), db.UpdateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   manager.Username,
+		Permission: util.ViewPermission,
+	})
+	require.NoError(t, err)
+
+	_, err = store.GetActiveAccessGrant(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testRevokeAccessGrant(t *testing.T, store db.Store) {
+	grantor := createRandomUser(t, store)
+	grantee := createRandomUser(t, store)
+	account := createAccount(t, store, grantor.Username, util.USD, 0)
+	grant := createAccessGrant(t, store, account, grantor.Username, grantee.Username, 0, util.ViewScope)
+
+	revoked, err := store.RevokeAccessGrant(context.Background(), grant.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	_, err = store.RevokeAccessGrant(context.Background(), grant.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	// grants go away with their account
+	_, err = store.DeleteAccount(context.Background(), db.DeleteAccountParams{ID: account.ID})
+	require.NoError(t, err)
+
+	_, err = store.GetAccessGrant(context.Background(), grant.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
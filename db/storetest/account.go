@@ -0,0 +1,374 @@
+package storetest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var accountTests = []conformanceTest{
+	{"CreateAccount", testCreateAccount},
+	{"CreateAccountUniqueViolation", testCreateAccountUniqueViolation},
+	{"CreateAccountForeignKeyViolation", testCreateAccountForeignKeyViolation},
+	{"GetAccountNotFound", testGetAccountNotFound},
+	{"GetAccountForUpdate", testGetAccountForUpdate},
+	{"GetAccountByOwnerCurrency", testGetAccountByOwnerCurrency},
+	{"UpdateAccount", testUpdateAccount},
+	{"UpdateAccountStatus", testUpdateAccountStatus},
+	{"AddAccountBalance", testAddAccountBalance},
+	{"DeleteAccount", testDeleteAccount},
+	{"DeleteAccountInUse", testDeleteAccountInUse},
+	{"ListAccountsPagination", testListAccountsPagination},
+	{"ListAccountsKeyset", testListAccountsKeyset},
+	{"SearchAccounts", testSearchAccounts},
+}
+
+func testCreateAccount(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, util.RandomMoney())
+
+	account2 := getAccount(t, store, account1.ID)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.Owner, account2.Owner)
+	require.Equal(t, account1.Balance, account2.Balance)
+	require.Equal(t, account1.Currency, account2.Currency)
+	require.Equal(t, account1.Version, account2.Version)
+	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
+}
+
+func testCreateAccountUniqueViolation(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 0)
+
+	_, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    account.Owner,
+		Currency: account.Currency,
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+}
+
+func testCreateAccountForeignKeyViolation(t *testing.T, store db.Store) {
+	_, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    util.RandomString(20),
+		Currency: util.USD,
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testGetAccountNotFound(t *testing.T, store db.Store) {
+	_, err := store.GetAccount(context.Background(), -1)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testGetAccountForUpdate(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, util.RandomMoney())
+
+	account2, err := store.GetAccountForUpdate(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.Balance, account2.Balance)
+
+	_, err = store.GetAccountForUpdate(context.Background(), -1)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testGetAccountByOwnerCurrency(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	usd := createAccount(t, store, user.Username, util.USD, 0)
+	eur := createAccount(t, store, user.Username, util.EUR, 0)
+
+	for _, account := range []db.Account{usd, eur} {
+		found, err := store.GetAccountByOwnerCurrency(context.Background(), db.GetAccountByOwnerCurrencyParams{
+			Owner:    user.Username,
+			Currency: account.Currency,
+		})
+		require.NoError(t, err)
+		require.Equal(t, account, found)
+	}
+
+	_, err := store.GetAccountByOwnerCurrency(context.Background(), db.GetAccountByOwnerCurrencyParams{
+		Owner:    user.Username,
+		Currency: util.CAD,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUpdateAccount(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, util.RandomMoney())
+
+	arg := db.UpdateAccountParams{
+		ID:      account1.ID,
+		Balance: util.RandomMoney(),
+		Version: pgtype.Int8{
+			Int64: account1.Version,
+			Valid: true,
+		},
+	}
+	account2, err := store.UpdateAccount(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Balance, account2.Balance)
+	require.Equal(t, account1.Owner, account2.Owner)
+	require.Equal(t, account1.Version+1, account2.Version)
+
+	_, err = store.UpdateAccount(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "stale versions must not match")
+
+	arg.ID = -1
+	arg.Version = pgtype.Int8{}
+	_, err = store.UpdateAccount(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUpdateAccountStatus(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, util.RandomMoney())
+
+	arg := db.UpdateAccountStatusParams{
+		ID:      account1.ID,
+		Status:  util.FrozenAccount,
+		Version: pgtype.Int8{Int64: account1.Version, Valid: true},
+	}
+	account2, err := store.UpdateAccountStatus(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.FrozenAccount, account2.Status)
+	require.Equal(t, account1.Balance, account2.Balance)
+	require.Equal(t, account1.Version+1, account2.Version)
+
+	_, err = store.UpdateAccountStatus(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "stale versions must not match")
+
+	arg.Version = pgtype.Int8{}
+	arg.Status = "dormant"
+	_, err = store.UpdateAccountStatus(context.Background(), arg)
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+}
+
+func testAddAccountBalance(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 100)
+
+	account2, err := store.AddAccountBalance(context.Background(), db.AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: -30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), account2.Balance)
+	require.Equal(t, account1.Version+1, account2.Version)
+
+	_, err = store.AddAccountBalance(context.Background(), db.AddAccountBalanceParams{
+		ID:     -1,
+		Amount: 10,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testDeleteAccount(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 0)
+
+	// a stale version does not match
+	rows, err := store.DeleteAccount(context.Background(), db.DeleteAccountParams{
+		ID:      account.ID,
+		Version: pgtype.Int8{Int64: account.Version + 1, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+	getAccount(t, store, account.ID)
+
+	rows, err = store.DeleteAccount(context.Background(), db.DeleteAccountParams{
+		ID:      account.ID,
+		Version: pgtype.Int8{Int64: account.Version, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetAccount(context.Background(), account.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	// deleting a missing row is not an error
+	rows, err = store.DeleteAccount(context.Background(), db.DeleteAccountParams{ID: account.ID})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func testDeleteAccountInUse(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 0)
+
+	_, err := store.CreateEntry(context.Background(), db.CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DeleteAccount(context.Background(), db.DeleteAccountParams{ID: account.ID})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+
+	getAccount(t, store, account.ID)
+}
+
+func testListAccountsPagination(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createRandomAccount(t, store, 0)
+
+	var accounts []db.Account
+	for _, currency := range []string{util.USD, util.EUR, util.CAD} {
+		accounts = append(accounts, createAccount(t, store, user.Username, currency, 0))
+	}
+
+	var listed []db.Account
+	for pageID := int32(0); pageID < 3; pageID++ {
+		page, err := store.ListAccounts(context.Background(), db.ListAccountsParams{
+			Owner:  user.Username,
+			Limit:  2,
+			Offset: pageID * 2,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, page, "empty pages must be empty slices")
+		listed = append(listed, page...)
+
+		switch pageID {
+		case 0:
+			require.Len(t, page, 2)
+		case 1:
+			require.Len(t, page, 1)
+		default:
+			require.Empty(t, page)
+		}
+	}
+
+	require.Len(t, listed, len(accounts))
+	for i := range accounts {
+		require.Equal(t, accounts[i].ID, listed[i].ID, "accounts must be ordered by id")
+		require.Equal(t, user.Username, listed[i].Owner)
+	}
+}
+
+func testListAccountsKeyset(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createRandomAccount(t, store, 0)
+
+	var accounts []db.Account
+	for _, currency := range []string{util.USD, util.EUR, util.CAD} {
+		accounts = append(accounts, createAccount(t, store, user.Username, currency, 0))
+	}
+
+	checkKeysetPages(t, accounts,
+		func(account db.Account) string {
+			return strconv.FormatInt(account.ID, 10)
+		},
+		func(cursor *db.Account, limit int32) ([]db.Account, error) {
+			arg := db.ListAccountsAfterParams{
+				Owner: user.Username,
+				Limit: limit,
+			}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: cursor.ID, Valid: true}
+			}
+			return store.ListAccountsAfter(context.Background(), arg)
+		},
+		func(cursor db.Account, limit int32) ([]db.Account, error) {
+			return store.ListAccountsBefore(context.Background(), db.ListAccountsBeforeParams{
+				Owner:           user.Username,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				Limit:           limit,
+			})
+		},
+	)
+}
+
+func testSearchAccounts(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	usd := createAccount(t, store, user.Username, util.USD, 10)
+	eur := createAccount(t, store, user.Username, util.EUR, 50)
+	cad := createAccount(t, store, user.Username, util.CAD, 100)
+
+	owner := pgtype.Text{String: user.Username, Valid: true}
+
+	testCases := []struct {
+		name     string
+		arg      db.SearchAccountsParams
+		accounts []db.Account
+	}{
+		{
+			name:     "Owner",
+			arg:      db.SearchAccountsParams{Owner: owner},
+			accounts: []db.Account{usd, eur, cad},
+		},
+		{
+			name: "Currency",
+			arg: db.SearchAccountsParams{
+				Owner:    owner,
+				Currency: pgtype.Text{String: util.EUR, Valid: true},
+			},
+			accounts: []db.Account{eur},
+		},
+		{
+			name: "Status",
+			arg: db.SearchAccountsParams{
+				Owner:  owner,
+				Status: pgtype.Text{String: util.FrozenAccount, Valid: true},
+			},
+			accounts: []db.Account{},
+		},
+		{
+			name: "BalanceRange",
+			arg: db.SearchAccountsParams{
+				Owner:      owner,
+				MinBalance: pgtype.Int8{Int64: 10, Valid: true},
+				MaxBalance: pgtype.Int8{Int64: 50, Valid: true},
+				SortBy:     "balance",
+				SortDesc:   true,
+			},
+			accounts: []db.Account{eur, usd},
+		},
+		{
+			name: "CreatedRange",
+			arg: db.SearchAccountsParams{
+				Owner:       owner,
+				CreatedFrom: pgtype.Timestamptz{Time: usd.CreatedAt, Valid: true},
+				CreatedTo:   pgtype.Timestamptz{Time: usd.CreatedAt, Valid: true},
+			},
+			accounts: []db.Account{},
+		},
+		{
+			name: "SortedPage",
+			arg: db.SearchAccountsParams{
+				Owner:         owner,
+				SortBy:        "balance",
+				SortDesc:      true,
+				CursorID:      pgtype.Int8{Int64: cad.ID, Valid: true},
+				CursorBalance: pgtype.Int8{Int64: cad.Balance, Valid: true},
+			},
+			accounts: []db.Account{eur, usd},
+		},
+		{
+			name: "PageAfterID",
+			arg: db.SearchAccountsParams{
+				Owner:    owner,
+				CursorID: pgtype.Int8{Int64: usd.ID, Valid: true},
+			},
+			accounts: []db.Account{eur, cad},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.arg.Limit == 0 {
+				tc.arg.Limit = 10
+			}
+
+			accounts, err := store.SearchAccounts(context.Background(), tc.arg)
+			require.NoError(t, err)
+			require.NotNil(t, accounts, "empty results must be empty slices")
+			require.Len(t, accounts, len(tc.accounts))
+			for i := range tc.accounts {
+				require.Equal(t, tc.accounts[i].ID, accounts[i].ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+var accountEventTests = []conformanceTest{
+	{"ListenAccountEvents", testListenAccountEvents},
+}
+
+func testListenAccountEvents(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 100)
+	account2 := createRandomAccount(t, store, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// other tests may share the store, so only events of our accounts are kept
+	events := make(chan db.AccountEvent, 16)
+	listening := make(chan error, 1)
+	go func() {
+		listening <- store.ListenAccountEvents(ctx, func(event db.AccountEvent) {
+			if event.AccountID == account1.ID || event.AccountID == account2.ID {
+				events <- event
+			}
+		})
+	}()
+
+	// the listener has no ready signal, so probe it with raw notifications until one arrives
+	probe, err := json.Marshal(db.AccountEvent{AccountID: account1.ID})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		err := store.NotifyAccountEvent(context.Background(), db.NotifyAccountEventParams{
+			Channel: db.AccountEventsChannel,
+			Payload: string(probe),
+		})
+		if err != nil {
+			return false
+		}
+
+		select {
+		case event := <-events:
+			return event.TransferID == 0
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	received := make(map[int64]db.AccountEvent)
+	for len(received) < 2 {
+		select {
+		case event := <-events:
+			if event.TransferID == result.Transfer.ID {
+				received[event.AccountID] = event
+			}
+		case <-ctx.Done():
+			t.Fatal("transfer events were not delivered")
+		}
+	}
+
+	fromEvent := received[account1.ID]
+	require.Equal(t, result.FromEntry.ID, fromEvent.EntryID)
+	require.Equal(t, result.FromEntry.Amount, fromEvent.Amount)
+	require.Equal(t, result.FromAccount.Balance, fromEvent.Balance)
+	require.Equal(t, result.FromAccount.Currency, fromEvent.Currency)
+
+	toEvent := received[account2.ID]
+	require.Equal(t, result.ToEntry.ID, toEvent.EntryID)
+	require.Equal(t, result.ToEntry.Amount, toEvent.Amount)
+	require.Equal(t, result.ToAccount.Balance, toEvent.Balance)
+
+	cancel()
+	require.Error(t, <-listening)
+}
@@ -0,0 +1,203 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var accountMemberTests = []conformanceTest{
+	{"CreateAccountMember", testCreateAccountMember},
+	{"CreateAccountMemberViolations", testCreateAccountMemberViolations},
+	{"AcceptAccountMember", testAcceptAccountMember},
+	{"UpdateAccountMember", testUpdateAccountMember},
+	{"DeleteAccountMember", testDeleteAccountMember},
+	{"ListAccountsOfMember", testListAccountsOfMember},
+}
+
+func createAccountMember(t *testing.T, store db.Store, account db.Account, username string, permission string) db.AccountMember {
+	arg := db.CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   username,
+		Permission: permission,
+		InvitedBy:  account.Owner,
+	}
+
+	member, err := store.CreateAccountMember(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.AccountID, member.AccountID)
+	require.Equal(t, arg.Username, member.Username)
+	require.Equal(t, arg.Permission, member.Permission)
+	require.Equal(t, arg.InvitedBy, member.InvitedBy)
+	require.False(t, member.AcceptedAt.Valid)
+	require.NotZero(t, member.CreatedAt)
+
+	return member
+}
+
+func testCreateAccountMember(t *testing.T, store db.Store) {
+	owner := createRandomUser(t, store)
+	invitee := createRandomUser(t, store)
+	account := createAccount(t, store, owner.Username, util.USD, 0)
+
+	member1 := createAccountMember(t, store, account, invitee.Username, util.ViewPermission)
+
+	member2, err := store.GetAccountMember(context.Background(), db.GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, member1, member2)
+
+	invitations, err := store.ListAccountInvitations(context.Background(), invitee.Username)
+	require.NoError(t, err)
+	require.Equal(t, []db.AccountMember{member1}, invitations)
+
+	members, err := store.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, []db.AccountMember{member1}, members)
+
+	_, err = store.GetAccountMember(context.Background(), db.GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  owner.Username,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testCreateAccountMemberViolations(t *testing.T, store db.Store) {
+	owner := createRandomUser(t, store)
+	invitee := createRandomUser(t, store)
+	account := createAccount(t, store, owner.Username, util.USD, 0)
+	createAccountMember(t, store, account, invitee.Username, util.ViewPermission)
+
+	arg := db.CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   invitee.Username,
+		Permission: util.ManagePermission,
+		InvitedBy:  owner.Username,
+	}
+	_, err := store.CreateAccountMember(context.Background(), arg)
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+
+	unknownUser := arg
+	unknownUser.Username = util.RandomString(20)
+	_, err = store.CreateAccountMember(context.Background(), unknownUser)
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+
+	unknownAccount := arg
+	unknownAccount.AccountID = account.ID + 1_000_000
+	_, err = store.CreateAccountMember(context.Background(), unknownAccount)
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+
+	invalidPermission := arg
+	invalidPermission.Username = createRandomUser(t, store).Username
+	invalidPermission.Permission = "own"
+	_, err = store.CreateAccountMember(context.Background(), invalidPermission)
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+}
+
+func testAcceptAccountMember(t *testing.T, store db.Store) {
+	owner := createRandomUser(t, store)
+	invitee := createRandomUser(t, store)
+	account := createAccount(t, store, owner.Username, util.USD, 0)
+	member1 := createAccountMember(t, store, account, invitee.Username, util.TransactPermission)
+
+	arg := db.AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+	}
+	member2, err := store.AcceptAccountMember(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, member2.AcceptedAt.Valid)
+	require.Equal(t, member1.Permission, member2.Permission)
+
+	invitations, err := store.ListAccountInvitations(context.Background(), invitee.Username)
+	require.NoError(t, err)
+	require.Empty(t, invitations)
+
+	// an invitation can only be accepted once
+	_, err = store.AcceptAccountMember(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUpdateAccountMember(t *testing.T, store db.Store) {
+	owner := createRandomUser(t, store)
+	invitee := createRandomUser(t, store)
+	account := createAccount(t, store, owner.Username, util.USD, 0)
+	createAccountMember(t, store, account, invitee.Username, util.ViewPermission)
+
+	member, err := store.UpdateAccountMember(context.Background(), db.UpdateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   invitee.Username,
+		Permission: util.ManagePermission,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.ManagePermission, member.Permission)
+
+	_, err = store.UpdateAccountMember(context.Background(), db.UpdateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   owner.Username,
+		Permission: util.ManagePermission,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testDeleteAccountMember(t *testing.T, store db.Store) {
+	owner := createRandomUser(t, store)
+	invitee := createRandomUser(t, store)
+	account := createAccount(t, store, owner.Username, util.USD, 0)
+	createAccountMember(t, store, account, invitee.Username, util.ViewPermission)
+
+	arg := db.DeleteAccountMemberParams{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+	}
+	rows, err := store.DeleteAccountMember(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = store.DeleteAccountMember(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	// members go away with their account
+	createAccountMember(t, store, account, invitee.Username, util.ViewPermission)
+	_, err = store.DeleteAccount(context.Background(), db.DeleteAccountParams{ID: account.ID})
+	require.NoError(t, err)
+
+	members, err := store.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Empty(t, members)
+}
+
+func testListAccountsOfMember(t *testing.T, store db.Store) {
+	owner := createRandomUser(t, store)
+	invitee := createRandomUser(t, store)
+	shared := createAccount(t, store, owner.Username, util.USD, 0)
+	pending := createAccount(t, store, owner.Username, util.EUR, 0)
+	own := createAccount(t, store, invitee.Username, util.USD, 0)
+
+	createAccountMember(t, store, shared, invitee.Username, util.ViewPermission)
+	createAccountMember(t, store, pending, invitee.Username, util.ViewPermission)
+	_, err := store.AcceptAccountMember(context.Background(), db.AcceptAccountMemberParams{
+		AccountID: shared.ID,
+		Username:  invitee.Username,
+	})
+	require.NoError(t, err)
+
+	accounts, err := store.ListAccountsAfter(context.Background(), db.ListAccountsAfterParams{
+		Owner: invitee.Username,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+
+	ids := make([]int64, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	require.ElementsMatch(t, []int64{shared.ID, own.ID}, ids, "pending invitations are not listed")
+}
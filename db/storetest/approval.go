@@ -0,0 +1,267 @@
+package storetest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var approvalTests = []conformanceTest{
+	{"CreateApproval", testCreateApproval},
+	{"CreateApprovalViolations", testCreateApprovalViolations},
+	{"ResolveApproval", testResolveApproval},
+	{"RejectExpiredApprovals", testRejectExpiredApprovals},
+	{"ListApprovals", testListApprovals},
+}
+
+func createTransferApproval(t *testing.T, store db.Store, maker string, fromAccount db.Account, toAccount db.Account, expiresAt time.Time) db.Approval {
+	arg := db.CreateApprovalParams{
+		Kind:        util.TransferApproval,
+		Maker:       maker,
+		AccountID:   fromAccount.ID,
+		ToAccountID: pgtype.Int8{Int64: toAccount.ID, Valid: true},
+		Amount:      pgtype.Int8{Int64: util.RandomMoney() + 1, Valid: true},
+		Currency:    fromAccount.Currency,
+		ExpiresAt:   expiresAt,
+	}
+
+	approval, err := store.CreateApproval(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, approval.ID)
+	require.Equal(t, arg.Kind, approval.Kind)
+	require.Equal(t, arg.Maker, approval.Maker)
+	require.False(t, approval.Checker.Valid)
+	require.Equal(t, arg.AccountID, approval.AccountID)
+	require.Equal(t, arg.ToAccountID, approval.ToAccountID)
+	require.Equal(t, arg.Amount, approval.Amount)
+	require.False(t, approval.Balance.Valid)
+	require.False(t, approval.AccountVersion.Valid)
+	require.Equal(t, arg.Currency, approval.Currency)
+	require.Equal(t, util.PendingApproval, approval.Status)
+	require.False(t, approval.TransferID.Valid)
+	require.WithinDuration(t, arg.ExpiresAt, approval.ExpiresAt, time.Millisecond)
+	require.False(t, approval.ResolvedAt.Valid)
+	require.NotZero(t, approval.CreatedAt)
+
+	return approval
+}
+
+func testCreateApproval(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, maker.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	approval1 := createTransferApproval(t, store, maker.Username, fromAccount, toAccount, time.Now().Add(time.Hour))
+
+	approval2, err := store.GetApproval(context.Background(), approval1.ID)
+	require.NoError(t, err)
+	require.Equal(t, approval1, approval2)
+
+	adjustment, err := store.CreateApproval(context.Background(), db.CreateApprovalParams{
+		Kind:           util.AdjustmentApproval,
+		Maker:          maker.Username,
+		AccountID:      toAccount.ID,
+		Balance:        pgtype.Int8{Int64: 500, Valid: true},
+		AccountVersion: pgtype.Int8{Int64: toAccount.Version, Valid: true},
+		Currency:       toAccount.Currency,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AdjustmentApproval, adjustment.Kind)
+	require.Equal(t, int64(500), adjustment.Balance.Int64)
+	require.Equal(t, toAccount.Version, adjustment.AccountVersion.Int64)
+	require.False(t, adjustment.ToAccountID.Valid)
+	require.False(t, adjustment.Amount.Valid)
+}
+
+func testCreateApprovalViolations(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, maker.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	valid := db.CreateApprovalParams{
+		Kind:        util.TransferApproval,
+		Maker:       maker.Username,
+		AccountID:   fromAccount.ID,
+		ToAccountID: pgtype.Int8{Int64: toAccount.ID, Valid: true},
+		Amount:      pgtype.Int8{Int64: 25, Valid: true},
+		Currency:    util.EUR,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name    string
+		update  func(arg *db.CreateApprovalParams)
+		errCode string
+	}{
+		{
+			name:    "UnknownKind",
+			update:  func(arg *db.CreateApprovalParams) { arg.Kind = "withdrawal" },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "TransferWithoutAmount",
+			update:  func(arg *db.CreateApprovalParams) { arg.Amount = pgtype.Int8{} },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "TransferWithoutRecipient",
+			update:  func(arg *db.CreateApprovalParams) { arg.ToAccountID = pgtype.Int8{} },
+			errCode: db.CheckViolation,
+		},
+		{
+			name: "NegativeAdjustment",
+			update: func(arg *db.CreateApprovalParams) {
+				arg.Kind = util.AdjustmentApproval
+				arg.Balance = pgtype.Int8{Int64: -1, Valid: true}
+			},
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "UnknownMaker",
+			update:  func(arg *db.CreateApprovalParams) { arg.Maker = util.RandomString(20) },
+			errCode: db.ForeignKeyViolation,
+		},
+		{
+			name:    "UnknownAccount",
+			update:  func(arg *db.CreateApprovalParams) { arg.ToAccountID.Int64 = toAccount.ID + 1_000_000 },
+			errCode: db.ForeignKeyViolation,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			arg := valid
+			tc.update(&arg)
+
+			_, err := store.CreateApproval(context.Background(), arg)
+			require.Equal(t, tc.errCode, db.ErrCode(err))
+		})
+	}
+}
+
+func testResolveApproval(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	checker := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, maker.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+	approval := createTransferApproval(t, store, maker.Username, fromAccount, toAccount, time.Now().Add(time.Hour))
+
+	// the maker cannot check their own operation
+	_, err := store.ResolveApproval(context.Background(), db.ResolveApprovalParams{
+		ID:      approval.ID,
+		Status:  util.RejectedApproval,
+		Checker: pgtype.Text{String: maker.Username, Valid: true},
+	})
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+
+	rejected, err := store.ResolveApproval(context.Background(), db.ResolveApprovalParams{
+		ID:      approval.ID,
+		Status:  util.RejectedApproval,
+		Checker: pgtype.Text{String: checker.Username, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.RejectedApproval, rejected.Status)
+	require.Equal(t, checker.Username, rejected.Checker.String)
+	require.True(t, rejected.ResolvedAt.Valid)
+
+	// resolved approvals cannot change anymore
+	_, err = store.ResolveApproval(context.Background(), db.ResolveApprovalParams{
+		ID:      approval.ID,
+		Status:  util.ApprovedApproval,
+		Checker: pgtype.Text{String: checker.Username, Valid: true},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testRejectExpiredApprovals(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, maker.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	expired := createTransferApproval(t, store, maker.Username, fromAccount, toAccount, time.Now().Add(-time.Minute))
+	pending := createTransferApproval(t, store, maker.Username, fromAccount, toAccount, time.Now().Add(time.Hour))
+
+	rows, err := store.RejectExpiredApprovals(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, rows, int64(1))
+
+	approval, err := store.GetApproval(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.RejectedApproval, approval.Status)
+	require.False(t, approval.Checker.Valid, "nobody checked the approval")
+	require.WithinDuration(t, expired.ExpiresAt, approval.ResolvedAt.Time, time.Millisecond)
+
+	approval, err = store.GetApproval(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PendingApproval, approval.Status)
+}
+
+func testListApprovals(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	checker := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, maker.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	var approvals []db.Approval
+	for i := 0; i < 3; i++ {
+		approvals = append(approvals, createTransferApproval(t, store, maker.Username, fromAccount, toAccount, time.Now().Add(time.Hour)))
+	}
+
+	rejected, err := store.ResolveApproval(context.Background(), db.ResolveApprovalParams{
+		ID:      approvals[1].ID,
+		Status:  util.RejectedApproval,
+		Checker: pgtype.Text{String: checker.Username, Valid: true},
+	})
+	require.NoError(t, err)
+	approvals[1] = rejected
+
+	makerFilter := pgtype.Text{String: maker.Username, Valid: true}
+	all, err := store.ListApprovalsAfter(context.Background(), db.ListApprovalsAfterParams{
+		Maker: makerFilter,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, approvals, all, "approvals must be oldest first")
+
+	pending, err := store.ListApprovalsAfter(context.Background(), db.ListApprovalsAfterParams{
+		Maker:           makerFilter,
+		Status:          pgtype.Text{String: util.PendingApproval, Valid: true},
+		CursorCreatedAt: pgtype.Timestamptz{Time: approvals[0].CreatedAt, Valid: true},
+		CursorID:        pgtype.Int8{Int64: approvals[0].ID, Valid: true},
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []db.Approval{approvals[2]}, pending)
+
+	checkKeysetPages(t, []db.Approval{approvals[2], approvals[1], approvals[0]},
+		func(approval db.Approval) string {
+			return strconv.FormatInt(approval.ID, 10)
+		},
+		func(cursor *db.Approval, limit int32) ([]db.Approval, error) {
+			arg := db.ListApprovalsAfterParams{Maker: makerFilter, Limit: limit}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: cursor.ID, Valid: true}
+			}
+			return store.ListApprovalsAfter(context.Background(), arg)
+		},
+		func(cursor db.Approval, limit int32) ([]db.Approval, error) {
+			return store.ListApprovalsBefore(context.Background(), db.ListApprovalsBeforeParams{
+				Maker:           makerFilter,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				Limit:           limit,
+			})
+		},
+	)
+}
@@ -0,0 +1,115 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var archivedPartitionTests = []conformanceTest{
+	{"CreateArchivedPartition", testCreateArchivedPartition},
+	{"ListArchivedPartitions", testListArchivedPartitions},
+	{"ArchivedQueriesSkipLiveRows", testArchivedQueriesSkipLiveRows},
+}
+
+func createArchivedPartition(t *testing.T, store db.Store, parentTable string, month time.Time) db.ArchivedPartition {
+	name := parentTable + "_" + month.Format("2006_01")
+	arg := db.CreateArchivedPartitionParams{
+		TableName:   name,
+		ParentTable: parentTable,
+		RangeStart:  month,
+		RangeEnd:    month.AddDate(0, 1, 0),
+		FilePath:    "/archive/" + name + ".jsonl.gz",
+		Checksum:    util.RandomString(64),
+		RowCount:    util.RandomInt(1, 100),
+		MinID:       1,
+		MaxID:       100,
+	}
+
+	partition, err := store.CreateArchivedPartition(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, partition.ID)
+	require.Equal(t, arg.TableName, partition.TableName)
+	require.Equal(t, arg.ParentTable, partition.ParentTable)
+	require.WithinDuration(t, arg.RangeStart, partition.RangeStart, time.Second)
+	require.WithinDuration(t, arg.RangeEnd, partition.RangeEnd, time.Second)
+	require.Equal(t, arg.FilePath, partition.FilePath)
+	require.Equal(t, arg.Checksum, partition.Checksum)
+	require.Equal(t, arg.RowCount, partition.RowCount)
+	require.Equal(t, arg.MinID, partition.MinID)
+	require.Equal(t, arg.MaxID, partition.MaxID)
+	require.NotZero(t, partition.ArchivedAt)
+
+	return partition
+}
+
+func testCreateArchivedPartition(t *testing.T, store db.Store) {
+	parentTable := util.RandomString(12)
+	partition := createArchivedPartition(t, store, parentTable, time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	_, err := store.CreateArchivedPartition(context.Background(), db.CreateArchivedPartitionParams{
+		TableName:   partition.TableName,
+		ParentTable: parentTable,
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+}
+
+func testListArchivedPartitions(t *testing.T, store db.Store) {
+	parentTable := util.RandomString(12)
+	march := createArchivedPartition(t, store, parentTable, time.Date(2000, time.March, 1, 0, 0, 0, 0, time.UTC))
+	january := createArchivedPartition(t, store, parentTable, time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+	createArchivedPartition(t, store, util.RandomString(12), time.Date(2000, time.February, 1, 0, 0, 0, 0, time.UTC))
+
+	partitions, err := store.ListArchivedPartitions(context.Background(), parentTable)
+	require.NoError(t, err)
+	require.Len(t, partitions, 2)
+	require.Equal(t, january.ID, partitions[0].ID, "partitions must be ordered by month")
+	require.Equal(t, march.ID, partitions[1].ID)
+
+	partitions, err = store.ListArchivedPartitions(context.Background(), util.RandomString(12))
+	require.NoError(t, err)
+	require.Empty(t, partitions)
+}
+
+func testArchivedQueriesSkipLiveRows(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account1 := createRandomAccount(t, store, 0)
+	account2 := createRandomAccount(t, store, 0)
+	entry := createRandomEntry(t, store, account1)
+	transfer := createTransfer(t, store, account1, account2)
+
+	_, err := store.GetArchivedEntry(ctx, entry.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+	_, err = store.GetArchivedTransfer(ctx, transfer.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	count, err := store.CountArchivedEntries(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	count, err = store.CountArchivedTransfers(ctx, db.CountArchivedTransfersParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account1.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	entries, err := store.ListArchivedEntriesAfter(ctx, db.ListArchivedEntriesAfterParams{
+		AccountID: account1.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	transfers, err := store.ListArchivedTransfersBefore(ctx, db.ListArchivedTransfersBeforeParams{
+		AccountID:       account1.ID,
+		CursorCreatedAt: transfer.CreatedAt.Add(-time.Hour),
+		Limit:           5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
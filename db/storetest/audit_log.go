@@ -0,0 +1,171 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var auditLogTests = []conformanceTest{
+	{"CreateAuditLog", testCreateAuditLog},
+	{"ListAuditLogsFilters", testListAuditLogsFilters},
+	{"ListAuditLogsOfGrant", testListAuditLogsOfGrant},
+	{"ListAuditLogsKeyset", testListAuditLogsKeyset},
+}
+
+func createAuditLog(t *testing.T, store db.Store, actor string, method string) db.AuditLog {
+	arg := db.CreateAuditLogParams{
+		RequestID:    util.RandomString(16),
+		Actor:        actor,
+		ActorRole:    util.BankerRole,
+		Method:       method,
+		Route:        "/accounts/:id",
+		ResourceType: "accounts",
+		ResourceID:   util.RandomString(6),
+		StatusCode:   200,
+		Changes:      json.RawMessage(`{"balance": {"before": 1, "after": 2}}`),
+		ClientIp:     "127.0.0.1",
+		UserAgent:    "storetest",
+	}
+
+	auditLog, err := store.CreateAuditLog(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, auditLog.ID)
+	require.Equal(t, arg.RequestID, auditLog.RequestID)
+	require.Equal(t, arg.Actor, auditLog.Actor)
+	require.Equal(t, arg.ActorRole, auditLog.ActorRole)
+	require.Equal(t, arg.Method, auditLog.Method)
+	require.Equal(t, arg.Route, auditLog.Route)
+	require.Equal(t, arg.ResourceType, auditLog.ResourceType)
+	require.Equal(t, arg.ResourceID, auditLog.ResourceID)
+	require.Equal(t, arg.StatusCode, auditLog.StatusCode)
+	require.JSONEq(t, string(arg.Changes), string(auditLog.Changes))
+	require.Equal(t, arg.ClientIp, auditLog.ClientIp)
+	require.Equal(t, arg.UserAgent, auditLog.UserAgent)
+	require.NotZero(t, auditLog.CreatedAt)
+
+	return auditLog
+}
+
+func testCreateAuditLog(t *testing.T, store db.Store) {
+	createAuditLog(t, store, util.RandomString(20), "PUT")
+}
+
+func testListAuditLogsFilters(t *testing.T, store db.Store) {
+	actor := util.RandomString(20)
+	put := createAuditLog(t, store, actor, "PUT")
+	del := createAuditLog(t, store, actor, "DELETE")
+	createAuditLog(t, store, util.RandomString(20), "PUT")
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{
+		Actor: pgtype.Text{
+			String: actor,
+			Valid:  true,
+		},
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 2)
+	require.Equal(t, del.ID, auditLogs[0].ID, "audit logs must be newest first")
+	require.Equal(t, put.ID, auditLogs[1].ID)
+
+	auditLogs, err = store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{
+		Actor: pgtype.Text{
+			String: actor,
+			Valid:  true,
+		},
+		Method: pgtype.Text{
+			String: "PUT",
+			Valid:  true,
+		},
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 1)
+	require.Equal(t, put.ID, auditLogs[0].ID)
+
+	auditLogs, err = store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{
+		Actor: pgtype.Text{
+			String: actor,
+			Valid:  true,
+		},
+		CreatedFrom: pgtype.Timestamptz{
+			Time:  time.Now().Add(time.Hour),
+			Valid: true,
+		},
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, auditLogs)
+}
+
+func testListAuditLogsOfGrant(t *testing.T, store db.Store) {
+	grantID := pgtype.Int8{Int64: util.RandomInt(1, 1_000_000_000), Valid: true}
+
+	arg := db.CreateAuditLogParams{
+		RequestID:    util.RandomString(16),
+		Actor:        util.RandomString(20),
+		ActorRole:    util.DepositorRole,
+		Method:       "GET",
+		Route:        "/accounts/:id/entries",
+		ResourceType: "accounts",
+		ResourceID:   util.RandomString(6),
+		StatusCode:   200,
+		GrantID:      grantID,
+	}
+	delegated, err := store.CreateAuditLog(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, grantID, delegated.GrantID)
+
+	direct := createAuditLog(t, store, arg.Actor, "PUT")
+	require.False(t, direct.GrantID.Valid)
+
+	auditLogs, err := store.ListAuditLogsAfter(context.Background(), db.ListAuditLogsAfterParams{
+		GrantID: grantID,
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 1)
+	require.Equal(t, delegated.ID, auditLogs[0].ID)
+}
+
+func testListAuditLogsKeyset(t *testing.T, store db.Store) {
+	actor := pgtype.Text{String: util.RandomString(20), Valid: true}
+	var auditLogs []db.AuditLog
+	for i := 0; i < 3; i++ {
+		auditLogs = append(auditLogs, createAuditLog(t, store, actor.String, "PUT"))
+	}
+
+	checkKeysetPages(t, auditLogs,
+		func(auditLog db.AuditLog) string {
+			return strconv.FormatInt(auditLog.ID, 10)
+		},
+		func(cursor *db.AuditLog, limit int32) ([]db.AuditLog, error) {
+			arg := db.ListAuditLogsAfterParams{
+				Actor: actor,
+				Limit: limit,
+			}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: cursor.ID, Valid: true}
+			}
+			return store.ListAuditLogsAfter(context.Background(), arg)
+		},
+		func(cursor db.AuditLog, limit int32) ([]db.AuditLog, error) {
+			return store.ListAuditLogsBefore(context.Background(), db.ListAuditLogsBeforeParams{
+				Actor:           actor,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				Limit:           limit,
+			})
+		},
+	)
+}
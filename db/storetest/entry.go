@@ -0,0 +1,127 @@
+package storetest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var entryTests = []conformanceTest{
+	{"CreateEntry", testCreateEntry},
+	{"CreateEntryForeignKeyViolation", testCreateEntryForeignKeyViolation},
+	{"GetEntryNotFound", testGetEntryNotFound},
+	{"ListEntriesPagination", testListEntriesPagination},
+	{"ListEntriesKeyset", testListEntriesKeyset},
+}
+
+func createRandomEntry(t *testing.T, store db.Store, account db.Account) db.Entry {
+	arg := db.CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    util.RandomInt(-100, 100),
+	}
+
+	entry, err := store.CreateEntry(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, entry.ID)
+	require.Equal(t, arg.AccountID, entry.AccountID)
+	require.Equal(t, arg.Amount, entry.Amount)
+	require.NotZero(t, entry.CreatedAt)
+
+	return entry
+}
+
+func testCreateEntry(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 0)
+	entry1 := createRandomEntry(t, store, account)
+
+	entry2, err := store.GetEntry(context.Background(), entry1.ID)
+	require.NoError(t, err)
+	require.Equal(t, entry1.ID, entry2.ID)
+	require.Equal(t, entry1.AccountID, entry2.AccountID)
+	require.Equal(t, entry1.Amount, entry2.Amount)
+	require.WithinDuration(t, entry1.CreatedAt, entry2.CreatedAt, time.Second)
+}
+
+func testCreateEntryForeignKeyViolation(t *testing.T, store db.Store) {
+	_, err := store.CreateEntry(context.Background(), db.CreateEntryParams{
+		AccountID: -1,
+		Amount:    10,
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testGetEntryNotFound(t *testing.T, store db.Store) {
+	_, err := store.GetEntry(context.Background(), -1)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testListEntriesPagination(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 0)
+	otherAccount := createRandomAccount(t, store, 0)
+	createRandomEntry(t, store, otherAccount)
+
+	var entries []db.Entry
+	for i := 0; i < 5; i++ {
+		entries = append(entries, createRandomEntry(t, store, account))
+	}
+
+	var listed []db.Entry
+	for offset := int32(0); offset < 6; offset += 2 {
+		page, err := store.ListEntries(context.Background(), db.ListEntriesParams{
+			AccountID: account.ID,
+			Limit:     2,
+			Offset:    offset,
+		})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 2)
+		listed = append(listed, page...)
+	}
+
+	require.Len(t, listed, len(entries))
+	for i := range entries {
+		require.Equal(t, entries[i].ID, listed[i].ID, "entries must be ordered by id")
+	}
+}
+
+func testListEntriesKeyset(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 0)
+	otherAccount := createRandomAccount(t, store, 0)
+	createRandomEntry(t, store, otherAccount)
+
+	var entries []db.Entry
+	for i := 0; i < 3; i++ {
+		entries = append(entries, createRandomEntry(t, store, account))
+	}
+
+	checkKeysetPages(t, entries,
+		func(entry db.Entry) string {
+			return strconv.FormatInt(entry.ID, 10)
+		},
+		func(cursor *db.Entry, limit int32) ([]db.Entry, error) {
+			arg := db.ListEntriesAfterParams{
+				AccountID: account.ID,
+				Limit:     limit,
+			}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: cursor.ID, Valid: true}
+			}
+			return store.ListEntriesAfter(context.Background(), arg)
+		},
+		func(cursor db.Entry, limit int32) ([]db.Entry, error) {
+			return store.ListEntriesBefore(context.Background(), db.ListEntriesBeforeParams{
+				AccountID:       account.ID,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				Limit:           limit,
+			})
+		},
+	)
+}
@@ -0,0 +1,172 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var jobTests = []conformanceTest{
+	{"CreateJob", testCreateJob},
+	{"ClaimJob", testClaimJob},
+	{"CompleteJob", testCompleteJob},
+	{"FailJob", testFailJob},
+	{"ClaimStaleJob", testClaimStaleJob},
+}
+
+func createRandomJob(t *testing.T, store db.Store, maxAttempts int32) db.Job {
+	arg := db.CreateJobParams{
+		Kind:        util.RandomString(12),
+		Payload:     json.RawMessage(`{"username":"` + util.RandomOwner() + `"}`),
+		MaxAttempts: maxAttempts,
+	}
+
+	job, err := store.CreateJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, job.ID)
+	require.Equal(t, arg.Kind, job.Kind)
+	require.JSONEq(t, string(arg.Payload), string(job.Payload))
+	require.Equal(t, util.PendingJob, job.Status)
+	require.Zero(t, job.Attempts)
+	require.Equal(t, arg.MaxAttempts, job.MaxAttempts)
+	require.False(t, job.LastError.Valid)
+	require.WithinDuration(t, time.Now(), job.RunAt, time.Second)
+	require.False(t, job.LockedUntil.Valid)
+	require.False(t, job.FinishedAt.Valid)
+	require.NotZero(t, job.CreatedAt)
+
+	return job
+}
+
+// claimJob claims jobs until it gets the one with the id, the jobs of other tests it claims
+// on the way stay locked until lockedUntil
+func claimJob(t *testing.T, store db.Store, id int64, lockedUntil time.Time) db.Job {
+	for {
+		job, err := store.ClaimJob(context.Background(), pgtype.Timestamptz{Time: lockedUntil, Valid: true})
+		require.NoError(t, err, "job %d is not due", id)
+		if job.ID == id {
+			return job
+		}
+	}
+}
+
+// requireNotClaimable claims every due job and checks that the one with the id is not among them
+func requireNotClaimable(t *testing.T, store db.Store, id int64) {
+	for {
+		job, err := store.ClaimJob(context.Background(), pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true})
+		if err != nil {
+			require.ErrorIs(t, err, db.ErrRecordNotFound)
+			return
+		}
+		require.NotEqual(t, id, job.ID)
+	}
+}
+
+func testCreateJob(t *testing.T, store db.Store) {
+	job1 := createRandomJob(t, store, 3)
+
+	job2, err := store.GetJob(context.Background(), job1.ID)
+	require.NoError(t, err)
+	require.Equal(t, job1, job2)
+
+	_, err = store.GetJob(context.Background(), -1)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	_, err = store.CreateJob(context.Background(), db.CreateJobParams{
+		Kind:    util.RandomString(12),
+		Payload: json.RawMessage(`{}`),
+	})
+	require.Equal(t, db.CheckViolation, db.ErrCode(err), "jobs run at least once")
+}
+
+func testClaimJob(t *testing.T, store db.Store) {
+	created := createRandomJob(t, store, 3)
+	lockedUntil := time.Now().Add(time.Minute).Truncate(time.Microsecond)
+
+	job := claimJob(t, store, created.ID, lockedUntil)
+	require.Equal(t, util.RunningJob, job.Status)
+	require.Equal(t, int32(1), job.Attempts)
+	require.True(t, job.LockedUntil.Valid)
+	require.WithinDuration(t, lockedUntil, job.LockedUntil.Time, time.Millisecond)
+
+	requireNotClaimable(t, store, job.ID)
+}
+
+func testCompleteJob(t *testing.T, store db.Store) {
+	created := createRandomJob(t, store, 3)
+	job := claimJob(t, store, created.ID, time.Now().Add(time.Minute))
+
+	_, err := store.CompleteJob(context.Background(), db.CompleteJobParams{ID: job.ID, Attempts: job.Attempts + 1})
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "the attempt does not hold the job")
+
+	done, err := store.CompleteJob(context.Background(), db.CompleteJobParams{ID: job.ID, Attempts: job.Attempts})
+	require.NoError(t, err)
+	require.Equal(t, util.DoneJob, done.Status)
+	require.False(t, done.LockedUntil.Valid)
+	require.True(t, done.FinishedAt.Valid)
+	require.WithinDuration(t, time.Now(), done.FinishedAt.Time, time.Second)
+
+	_, err = store.CompleteJob(context.Background(), db.CompleteJobParams{ID: job.ID, Attempts: job.Attempts})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	requireNotClaimable(t, store, job.ID)
+}
+
+func testFailJob(t *testing.T, store db.Store) {
+	created := createRandomJob(t, store, 2)
+	job := claimJob(t, store, created.ID, time.Now().Add(time.Minute))
+
+	runAt := time.Now().Add(-time.Second).Truncate(time.Microsecond)
+	retried, err := store.FailJob(context.Background(), db.FailJobParams{
+		LastError: pgtype.Text{String: "connection refused", Valid: true},
+		RunAt:     runAt,
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.PendingJob, retried.Status)
+	require.Equal(t, "connection refused", retried.LastError.String)
+	require.WithinDuration(t, runAt, retried.RunAt, time.Millisecond)
+	require.False(t, retried.LockedUntil.Valid)
+	require.False(t, retried.FinishedAt.Valid)
+
+	job = claimJob(t, store, created.ID, time.Now().Add(time.Minute))
+	require.Equal(t, int32(2), job.Attempts)
+
+	failed, err := store.FailJob(context.Background(), db.FailJobParams{
+		LastError: pgtype.Text{String: "connection reset", Valid: true},
+		RunAt:     time.Now(),
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.FailedJob, failed.Status, "the job gives up after its last attempt")
+	require.Equal(t, "connection reset", failed.LastError.String)
+	require.True(t, failed.FinishedAt.Valid)
+
+	requireNotClaimable(t, store, job.ID)
+}
+
+func testClaimStaleJob(t *testing.T, store db.Store) {
+	created := createRandomJob(t, store, 3)
+	stale := claimJob(t, store, created.ID, time.Now().Add(200*time.Millisecond))
+
+	// the worker holding the job stopped before its lock ran out
+	time.Sleep(300 * time.Millisecond)
+
+	job := claimJob(t, store, created.ID, time.Now().Add(time.Minute))
+	require.Equal(t, util.RunningJob, job.Status)
+	require.Equal(t, int32(2), job.Attempts)
+
+	_, err := store.CompleteJob(context.Background(), db.CompleteJobParams{ID: stale.ID, Attempts: stale.Attempts})
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "the stopped worker lost the job")
+
+	_, err = store.CompleteJob(context.Background(), db.CompleteJobParams{ID: job.ID, Attempts: job.Attempts})
+	require.NoError(t, err)
+}
@@ -0,0 +1,48 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkKeysetPages pages through three rows, given oldest first, with a pair of keyset queries:
+// after lists newest first from the cursor, before lists oldest first up to it.
+// The listing may hold older rows than the given ones, but no newer ones.
+func checkKeysetPages[T any](
+	t *testing.T,
+	rows []T,
+	key func(T) string,
+	after func(cursor *T, limit int32) ([]T, error),
+	before func(cursor T, limit int32) ([]T, error),
+) {
+	require.Len(t, rows, 3)
+
+	keys := func(page []T) []string {
+		keys := []string{}
+		for _, row := range page {
+			keys = append(keys, key(row))
+		}
+		return keys
+	}
+
+	page, err := after(nil, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{key(rows[2]), key(rows[1])}, keys(page), "first page must be newest first")
+
+	page, err = after(&rows[1], 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{key(rows[0])}, keys(page), "next page must start after the cursor")
+
+	page, err = before(rows[0], 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{key(rows[1]), key(rows[2])}, keys(page), "previous page must be oldest first")
+
+	page, err = before(rows[1], 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{key(rows[2])}, keys(page))
+
+	page, err = before(rows[2], 2)
+	require.NoError(t, err)
+	require.Empty(t, page)
+}
@@ -0,0 +1,103 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var loginChallengeTests = []conformanceTest{
+	{"CreateLoginChallenge", testCreateLoginChallenge},
+	{"CreateLoginChallengeViolations", testCreateLoginChallengeViolations},
+	{"FailLoginChallenge", testFailLoginChallenge},
+	{"UseLoginChallenge", testUseLoginChallenge},
+	{"UseExpiredLoginChallenge", testUseExpiredLoginChallenge},
+}
+
+func createLoginChallenge(t *testing.T, store db.Store, user db.User, expiresAt time.Time) db.LoginChallenge {
+	arg := db.CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: expiresAt.Truncate(time.Microsecond),
+	}
+
+	challenge, err := store.CreateLoginChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, challenge.ID)
+	require.Equal(t, arg.Username, challenge.Username)
+	require.Equal(t, arg.TokenHash, challenge.TokenHash)
+	require.Zero(t, challenge.Attempts)
+	require.False(t, challenge.IsUsed)
+	require.WithinDuration(t, arg.ExpiresAt, challenge.ExpiresAt, time.Millisecond)
+	require.NotZero(t, challenge.CreatedAt)
+
+	return challenge
+}
+
+func testCreateLoginChallenge(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	challenge := createLoginChallenge(t, store, user, time.Now().Add(time.Minute))
+
+	got, err := store.GetLoginChallenge(context.Background(), challenge.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, challenge.ID, got.ID)
+	require.Equal(t, challenge.Username, got.Username)
+
+	_, err = store.GetLoginChallenge(context.Background(), util.HashSecret(util.RandomString(32)))
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testCreateLoginChallengeViolations(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	challenge := createLoginChallenge(t, store, user, time.Now().Add(time.Minute))
+
+	_, err := store.CreateLoginChallenge(context.Background(), db.CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: challenge.TokenHash,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+
+	_, err = store.CreateLoginChallenge(context.Background(), db.CreateLoginChallengeParams{
+		Username:  util.RandomString(20),
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testFailLoginChallenge(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	challenge := createLoginChallenge(t, store, user, time.Now().Add(time.Minute))
+
+	for i := 1; i <= 2; i++ {
+		failed, err := store.FailLoginChallenge(context.Background(), challenge.ID)
+		require.NoError(t, err)
+		require.Equal(t, int32(i), failed.Attempts)
+		require.False(t, failed.IsUsed)
+	}
+}
+
+func testUseLoginChallenge(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	challenge := createLoginChallenge(t, store, user, time.Now().Add(time.Minute))
+
+	used, err := store.UseLoginChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.True(t, used.IsUsed)
+
+	_, err = store.UseLoginChallenge(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "challenges are single-use")
+}
+
+func testUseExpiredLoginChallenge(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	challenge := createLoginChallenge(t, store, user, time.Now().Add(-time.Second))
+
+	_, err := store.UseLoginChallenge(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
@@ -0,0 +1,302 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var loginLockoutTests = []conformanceTest{
+	{"ReserveLoginAttempt", testReserveLoginAttempt},
+	{"ReserveLoginAttemptResets", testReserveLoginAttemptResets},
+	{"ReserveLoginAttemptCheckViolation", testReserveLoginAttemptCheckViolation},
+	{"ReserveLoginAttemptLocks", testReserveLoginAttemptLocks},
+	{"RefundLoginAttempt", testRefundLoginAttempt},
+	{"ListLoginLockouts", testListLoginLockouts},
+	{"DeleteLoginLockout", testDeleteLoginLockout},
+	{"DeleteStaleLoginLockouts", testDeleteStaleLoginLockouts},
+}
+
+func reserveLoginAttempt(t *testing.T, store db.Store, arg db.ReserveLoginAttemptParams) db.LoginLockout {
+	lockout, err := store.ReserveLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Scope, lockout.Scope)
+	require.Equal(t, arg.Identifier, lockout.Identifier)
+	require.WithinDuration(t, time.Now(), lockout.LastFailedAt, time.Second)
+
+	return lockout
+}
+
+// recordLoginFailure counts a failed login far below any threshold
+func recordLoginFailure(t *testing.T, store db.Store, scope string, identifier string) db.LoginLockout {
+	return reserveLoginAttempt(t, store, db.ReserveLoginAttemptParams{
+		Scope:           scope,
+		Identifier:      identifier,
+		Threshold:       100,
+		DelaySeconds:    60,
+		MaxDelaySeconds: 60,
+		ResetBefore:     time.Now().Add(-time.Hour),
+	})
+}
+
+// lockLogin counts a failed login that locks the identifier out until about lockedUntil
+func lockLogin(t *testing.T, store db.Store, scope string, identifier string, lockedUntil time.Time) db.LoginLockout {
+	delay := time.Until(lockedUntil).Seconds()
+	lockout := reserveLoginAttempt(t, store, db.ReserveLoginAttemptParams{
+		Scope:           scope,
+		Identifier:      identifier,
+		Threshold:       1,
+		DelaySeconds:    delay,
+		MaxDelaySeconds: delay,
+		ResetBefore:     time.Now().Add(-time.Hour),
+	})
+	require.True(t, lockout.LockedUntil.Valid)
+	require.WithinDuration(t, lockedUntil, lockout.LockedUntil.Time, time.Second)
+	return lockout
+}
+
+func testReserveLoginAttempt(t *testing.T, store db.Store) {
+	// usernames are counted whether a user has them or not
+	username := util.RandomString(20)
+
+	for i := 1; i <= 3; i++ {
+		lockout := recordLoginFailure(t, store, util.UsernameLockout, username)
+		require.Equal(t, int32(i), lockout.FailedAttempts)
+		require.False(t, lockout.LockedUntil.Valid)
+	}
+
+	lockout := recordLoginFailure(t, store, util.ClientIPLockout, username)
+	require.Equal(t, int32(1), lockout.FailedAttempts, "scopes are counted apart")
+
+	got, err := store.GetLoginLockout(context.Background(), db.GetLoginLockoutParams{
+		Scope:      util.UsernameLockout,
+		Identifier: username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), got.FailedAttempts)
+
+	_, err = store.GetLoginLockout(context.Background(), db.GetLoginLockoutParams{
+		Scope:      util.UsernameLockout,
+		Identifier: util.RandomString(20),
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testReserveLoginAttemptResets(t *testing.T, store db.Store) {
+	identifier := util.RandomString(20)
+	recordLoginFailure(t, store, util.UsernameLockout, identifier)
+	recordLoginFailure(t, store, util.UsernameLockout, identifier)
+
+	lockout := reserveLoginAttempt(t, store, db.ReserveLoginAttemptParams{
+		Scope:           util.UsernameLockout,
+		Identifier:      identifier,
+		Threshold:       2,
+		DelaySeconds:    60,
+		MaxDelaySeconds: 60,
+		ResetBefore:     time.Now().Add(time.Second),
+	})
+	require.Equal(t, int32(1), lockout.FailedAttempts)
+	require.False(t, lockout.LockedUntil.Valid)
+}
+
+func testReserveLoginAttemptCheckViolation(t *testing.T, store db.Store) {
+	_, err := store.ReserveLoginAttempt(context.Background(), db.ReserveLoginAttemptParams{
+		Scope:       "email",
+		Identifier:  util.RandomEmail(),
+		Threshold:   1,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+}
+
+func testReserveLoginAttemptLocks(t *testing.T, store db.Store) {
+	arg := db.ReserveLoginAttemptParams{
+		Scope:           util.UsernameLockout,
+		Identifier:      util.RandomString(20),
+		Threshold:       2,
+		DelaySeconds:    -1,
+		MaxDelaySeconds: 100,
+		ResetBefore:     time.Now().Add(-time.Hour),
+	}
+
+	lockout := reserveLoginAttempt(t, store, arg)
+	require.False(t, lockout.LockedUntil.Valid)
+
+	// a lockout that is already over lets the next attempt through
+	lockout = reserveLoginAttempt(t, store, arg)
+	require.Equal(t, int32(2), lockout.FailedAttempts)
+	require.True(t, lockout.LockedUntil.Valid, "the attempt that reaches the threshold locks")
+	require.True(t, lockout.LockedUntil.Time.Before(time.Now()))
+
+	arg.DelaySeconds = 40
+	lockout = reserveLoginAttempt(t, store, arg)
+	require.Equal(t, int32(3), lockout.FailedAttempts)
+	require.WithinDuration(t, time.Now().Add(80*time.Second), lockout.LockedUntil.Time, time.Second, "each further failure doubles the delay")
+
+	_, err := store.ReserveLoginAttempt(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "locked out attempts are refused")
+
+	got, err := store.GetLoginLockout(context.Background(), db.GetLoginLockoutParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+	require.Equal(t, lockout, got, "and not counted")
+
+	lockout = reserveLoginAttempt(t, store, db.ReserveLoginAttemptParams{
+		Scope:           util.ClientIPLockout,
+		Identifier:      util.RandomString(20),
+		Threshold:       1,
+		DelaySeconds:    60,
+		MaxDelaySeconds: 30,
+		ResetBefore:     time.Now().Add(-time.Hour),
+	})
+	require.WithinDuration(t, time.Now().Add(30*time.Second), lockout.LockedUntil.Time, time.Second, "delays are capped")
+}
+
+func testRefundLoginAttempt(t *testing.T, store db.Store) {
+	arg := db.ReserveLoginAttemptParams{
+		Scope:           util.UsernameLockout,
+		Identifier:      util.RandomString(20),
+		Threshold:       2,
+		DelaySeconds:    60,
+		MaxDelaySeconds: 60,
+		ResetBefore:     time.Now().Add(-time.Hour),
+	}
+	first := reserveLoginAttempt(t, store, arg)
+	second := reserveLoginAttempt(t, store, arg)
+	require.True(t, second.LockedUntil.Valid)
+
+	refund := func(lockout db.LoginLockout) db.LoginLockout {
+		rows, err := store.RefundLoginAttempt(context.Background(), db.RefundLoginAttemptParams{
+			LockedUntil: lockout.LockedUntil,
+			Scope:       lockout.Scope,
+			Identifier:  lockout.Identifier,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rows)
+
+		got, err := store.GetLoginLockout(context.Background(), db.GetLoginLockoutParams{
+			Scope:      lockout.Scope,
+			Identifier: lockout.Identifier,
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	// the lockout was started by another attempt, which failed
+	got := refund(first)
+	require.Equal(t, int32(1), got.FailedAttempts)
+	require.Equal(t, second.LockedUntil, got.LockedUntil)
+
+	got = refund(second)
+	require.Zero(t, got.FailedAttempts)
+	require.False(t, got.LockedUntil.Valid, "the lockout of the attempt is lifted")
+
+	rows, err := store.RefundLoginAttempt(context.Background(), db.RefundLoginAttemptParams{
+		Scope:      util.UsernameLockout,
+		Identifier: util.RandomString(20),
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func testListLoginLockouts(t *testing.T, store db.Store) {
+	// lock for longer than any other test, so that these come first
+	later := lockLogin(t, store, util.ClientIPLockout, util.RandomString(20), time.Now().Add(72*time.Hour))
+	sooner := lockLogin(t, store, util.ClientIPLockout, util.RandomString(20), time.Now().Add(71*time.Hour))
+	username := lockLogin(t, store, util.UsernameLockout, util.RandomString(20), time.Now().Add(73*time.Hour))
+	expired := lockLogin(t, store, util.ClientIPLockout, util.RandomString(20), time.Now().Add(-time.Second))
+	recordLoginFailure(t, store, util.ClientIPLockout, util.RandomString(20))
+
+	lockouts, err := store.ListLoginLockoutsAfter(context.Background(), db.ListLoginLockoutsAfterParams{
+		Scope: pgtype.Text{String: util.ClientIPLockout, Valid: true},
+		Limit: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, lockouts, 2)
+	require.Equal(t, later.Identifier, lockouts[0].Identifier)
+	require.Equal(t, sooner.Identifier, lockouts[1].Identifier)
+
+	lockouts, err = store.ListLoginLockoutsAfter(context.Background(), db.ListLoginLockoutsAfterParams{
+		Limit: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	require.Equal(t, username.Identifier, lockouts[0].Identifier)
+
+	lockouts, err = store.ListLoginLockoutsAfter(context.Background(), db.ListLoginLockoutsAfterParams{
+		CursorLockedUntil: username.LockedUntil,
+		CursorScope:       pgtype.Text{String: username.Scope, Valid: true},
+		CursorIdentifier:  pgtype.Text{String: username.Identifier, Valid: true},
+		Limit:             2,
+	})
+	require.NoError(t, err)
+	require.Len(t, lockouts, 2)
+	require.Equal(t, later.Identifier, lockouts[0].Identifier, "next pages must start after the cursor")
+	require.Equal(t, sooner.Identifier, lockouts[1].Identifier)
+
+	lockouts, err = store.ListLoginLockoutsBefore(context.Background(), db.ListLoginLockoutsBeforeParams{
+		CursorLockedUntil: sooner.LockedUntil.Time,
+		CursorScope:       sooner.Scope,
+		CursorIdentifier:  sooner.Identifier,
+		Limit:             2,
+	})
+	require.NoError(t, err)
+	require.Len(t, lockouts, 2)
+	require.Equal(t, later.Identifier, lockouts[0].Identifier, "previous pages must end right before the cursor")
+	require.Equal(t, username.Identifier, lockouts[1].Identifier)
+
+	lockouts, err = store.ListLoginLockoutsAfter(context.Background(), db.ListLoginLockoutsAfterParams{
+		Limit: 1000,
+	})
+	require.NoError(t, err)
+	for _, lockout := range lockouts {
+		require.NotEqual(t, expired.Identifier, lockout.Identifier)
+		require.True(t, lockout.LockedUntil.Time.After(time.Now()))
+	}
+}
+
+func testDeleteLoginLockout(t *testing.T, store db.Store) {
+	lockout := lockLogin(t, store, util.UsernameLockout, util.RandomString(20), time.Now().Add(time.Minute))
+	arg := db.DeleteLoginLockoutParams{
+		Scope:      lockout.Scope,
+		Identifier: lockout.Identifier,
+	}
+
+	rows, err := store.DeleteLoginLockout(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = store.DeleteLoginLockout(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	lockout = recordLoginFailure(t, store, lockout.Scope, lockout.Identifier)
+	require.Equal(t, int32(1), lockout.FailedAttempts, "the count starts over")
+}
+
+func testDeleteStaleLoginLockouts(t *testing.T, store db.Store) {
+	stale := recordLoginFailure(t, store, util.UsernameLockout, util.RandomString(20))
+	locked := lockLogin(t, store, util.UsernameLockout, util.RandomString(20), time.Now().Add(time.Minute))
+
+	_, err := store.DeleteStaleLoginLockouts(context.Background(), time.Now().Add(time.Second))
+	require.NoError(t, err)
+
+	_, err = store.GetLoginLockout(context.Background(), db.GetLoginLockoutParams{
+		Scope:      stale.Scope,
+		Identifier: stale.Identifier,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	_, err = store.GetLoginLockout(context.Background(), db.GetLoginLockoutParams{
+		Scope:      locked.Scope,
+		Identifier: locked.Identifier,
+	})
+	require.NoError(t, err, "logins still locked out are kept")
+}
@@ -0,0 +1,265 @@
+package storetest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var moneyRequestTests = []conformanceTest{
+	{"CreateMoneyRequest", testCreateMoneyRequest},
+	{"CreateMoneyRequestViolations", testCreateMoneyRequestViolations},
+	{"ResolveMoneyRequest", testResolveMoneyRequest},
+	{"ExpireMoneyRequests", testExpireMoneyRequests},
+	{"ListMoneyRequests", testListMoneyRequests},
+}
+
+func createMoneyRequest(t *testing.T, store db.Store, requester string, payer string, toAccount db.Account, expiresAt time.Time) db.MoneyRequest {
+	arg := db.CreateMoneyRequestParams{
+		Requester:   requester,
+		Payer:       payer,
+		ToAccountID: toAccount.ID,
+		Amount:      util.RandomMoney() + 1,
+		Currency:    toAccount.Currency,
+		Note:        util.RandomString(10),
+		ExpiresAt:   expiresAt,
+	}
+
+	request, err := store.CreateMoneyRequest(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, request.ID)
+	require.Equal(t, arg.Requester, request.Requester)
+	require.Equal(t, arg.Payer, request.Payer)
+	require.Equal(t, arg.ToAccountID, request.ToAccountID)
+	require.Equal(t, arg.Amount, request.Amount)
+	require.Equal(t, arg.Currency, request.Currency)
+	require.Equal(t, arg.Note, request.Note)
+	require.Equal(t, util.PendingRequest, request.Status)
+	require.False(t, request.TransferID.Valid)
+	require.WithinDuration(t, arg.ExpiresAt, request.ExpiresAt, time.Millisecond)
+	require.False(t, request.ResolvedAt.Valid)
+	require.NotZero(t, request.CreatedAt)
+
+	return request
+}
+
+func testCreateMoneyRequest(t *testing.T, store db.Store) {
+	requester := createRandomUser(t, store)
+	payer := createRandomUser(t, store)
+	account := createAccount(t, store, requester.Username, util.EUR, 0)
+
+	request1 := createMoneyRequest(t, store, requester.Username, payer.Username, account, time.Now().Add(time.Hour))
+
+	request2, err := store.GetMoneyRequest(context.Background(), request1.ID)
+	require.NoError(t, err)
+	require.Equal(t, request1, request2)
+}
+
+func testCreateMoneyRequestViolations(t *testing.T, store db.Store) {
+	requester := createRandomUser(t, store)
+	payer := createRandomUser(t, store)
+	account := createAccount(t, store, requester.Username, util.EUR, 0)
+
+	valid := db.CreateMoneyRequestParams{
+		Requester:   requester.Username,
+		Payer:       payer.Username,
+		ToAccountID: account.ID,
+		Amount:      25,
+		Currency:    util.EUR,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name    string
+		update  func(arg *db.CreateMoneyRequestParams)
+		errCode string
+	}{
+		{
+			name:    "NonPositiveAmount",
+			update:  func(arg *db.CreateMoneyRequestParams) { arg.Amount = 0 },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "SelfRequest",
+			update:  func(arg *db.CreateMoneyRequestParams) { arg.Payer = arg.Requester },
+			errCode: db.CheckViolation,
+		},
+		{
+			name:    "UnknownPayer",
+			update:  func(arg *db.CreateMoneyRequestParams) { arg.Payer = util.RandomString(20) },
+			errCode: db.ForeignKeyViolation,
+		},
+		{
+			name:    "UnknownAccount",
+			update:  func(arg *db.CreateMoneyRequestParams) { arg.ToAccountID = account.ID + 1_000_000 },
+			errCode: db.ForeignKeyViolation,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			arg := valid
+			tc.update(&arg)
+
+			_, err := store.CreateMoneyRequest(context.Background(), arg)
+			require.Equal(t, tc.errCode, db.ErrCode(err))
+		})
+	}
+}
+
+func testResolveMoneyRequest(t *testing.T, store db.Store) {
+	requester := createRandomUser(t, store)
+	payer := createRandomUser(t, store)
+	account := createAccount(t, store, requester.Username, util.EUR, 0)
+	request := createMoneyRequest(t, store, requester.Username, payer.Username, account, time.Now().Add(time.Hour))
+
+	declined, err := store.ResolveMoneyRequest(context.Background(), db.ResolveMoneyRequestParams{
+		ID:     request.ID,
+		Status: util.DeclinedRequest,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.DeclinedRequest, declined.Status)
+	require.True(t, declined.ResolvedAt.Valid)
+
+	// resolved requests cannot change anymore
+	_, err = store.ResolveMoneyRequest(context.Background(), db.ResolveMoneyRequestParams{
+		ID:     request.ID,
+		Status: util.CancelledRequest,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	_, err = store.AcceptMoneyRequest(context.Background(), db.AcceptMoneyRequestParams{
+		ID:         request.ID,
+		TransferID: pgtype.Int8{Int64: 1, Valid: true},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	request = createMoneyRequest(t, store, requester.Username, payer.Username, account, time.Now().Add(time.Hour))
+	_, err = store.ResolveMoneyRequest(context.Background(), db.ResolveMoneyRequestParams{
+		ID:     request.ID,
+		Status: "paid",
+	})
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+}
+
+func testExpireMoneyRequests(t *testing.T, store db.Store) {
+	requester := createRandomUser(t, store)
+	payer := createRandomUser(t, store)
+	account := createAccount(t, store, requester.Username, util.EUR, 0)
+
+	expired := createMoneyRequest(t, store, requester.Username, payer.Username, account, time.Now().Add(-time.Minute))
+	pending := createMoneyRequest(t, store, requester.Username, payer.Username, account, time.Now().Add(time.Hour))
+
+	// requests past their expiry can no longer be resolved, even before they are marked expired
+	_, err := store.ResolveMoneyRequest(context.Background(), db.ResolveMoneyRequestParams{
+		ID:     expired.ID,
+		Status: util.DeclinedRequest,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	rows, err := store.ExpireMoneyRequests(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, rows, int64(1))
+
+	request, err := store.GetMoneyRequest(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ExpiredRequest, request.Status)
+	require.WithinDuration(t, expired.ExpiresAt, request.ResolvedAt.Time, time.Millisecond)
+
+	request, err = store.GetMoneyRequest(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PendingRequest, request.Status)
+}
+
+func testListMoneyRequests(t *testing.T, store db.Store) {
+	requester := createRandomUser(t, store)
+	payer := createRandomUser(t, store)
+	account := createAccount(t, store, requester.Username, util.EUR, 0)
+
+	var requests []db.MoneyRequest
+	for i := 0; i < 3; i++ {
+		requests = append(requests, createMoneyRequest(t, store, requester.Username, payer.Username, account, time.Now().Add(time.Hour)))
+	}
+
+	declined, err := store.ResolveMoneyRequest(context.Background(), db.ResolveMoneyRequestParams{
+		ID:     requests[1].ID,
+		Status: util.DeclinedRequest,
+	})
+	require.NoError(t, err)
+	requests[1] = declined
+
+	incoming, err := store.ListIncomingMoneyRequestsAfter(context.Background(), db.ListIncomingMoneyRequestsAfterParams{
+		Payer: payer.Username,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []db.MoneyRequest{requests[2], requests[1], requests[0]}, incoming)
+
+	pending, err := store.ListIncomingMoneyRequestsAfter(context.Background(), db.ListIncomingMoneyRequestsAfterParams{
+		Payer:           payer.Username,
+		Status:          pgtype.Text{String: util.PendingRequest, Valid: true},
+		CursorCreatedAt: pgtype.Timestamptz{Time: requests[2].CreatedAt, Valid: true},
+		CursorID:        pgtype.Int8{Int64: requests[2].ID, Valid: true},
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []db.MoneyRequest{requests[0]}, pending)
+
+	checkKeysetPages(t, requests,
+		func(request db.MoneyRequest) string {
+			return strconv.FormatInt(request.ID, 10)
+		},
+		func(cursor *db.MoneyRequest, limit int32) ([]db.MoneyRequest, error) {
+			arg := db.ListOutgoingMoneyRequestsAfterParams{
+				Requester: requester.Username,
+				Limit:     limit,
+			}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: cursor.ID, Valid: true}
+			}
+			return store.ListOutgoingMoneyRequestsAfter(context.Background(), arg)
+		},
+		func(cursor db.MoneyRequest, limit int32) ([]db.MoneyRequest, error) {
+			return store.ListOutgoingMoneyRequestsBefore(context.Background(), db.ListOutgoingMoneyRequestsBeforeParams{
+				Requester:       requester.Username,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				Limit:           limit,
+			})
+		},
+	)
+
+	previous, err := store.ListIncomingMoneyRequestsBefore(context.Background(), db.ListIncomingMoneyRequestsBeforeParams{
+		Payer:           payer.Username,
+		CursorCreatedAt: requests[0].CreatedAt,
+		CursorID:        requests[0].ID,
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []db.MoneyRequest{requests[1], requests[2]}, previous)
+
+	outgoing, err := store.ListOutgoingMoneyRequestsAfter(context.Background(), db.ListOutgoingMoneyRequestsAfterParams{
+		Requester: requester.Username,
+		Status:    pgtype.Text{String: util.DeclinedRequest, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []db.MoneyRequest{declined}, outgoing)
+
+	outgoing, err = store.ListOutgoingMoneyRequestsAfter(context.Background(), db.ListOutgoingMoneyRequestsAfterParams{
+		Requester: payer.Username,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, outgoing)
+}
@@ -0,0 +1,142 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var payeeTests = []conformanceTest{
+	{"CreatePayee", testCreatePayee},
+	{"CreatePayeeViolations", testCreatePayeeViolations},
+	{"ListPayees", testListPayees},
+	{"DeletePayee", testDeletePayee},
+}
+
+func createPayee(t *testing.T, store db.Store, owner string, nickname string, account db.Account) db.Payee {
+	arg := db.CreatePayeeParams{
+		Owner:     owner,
+		Nickname:  nickname,
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	}
+
+	payee, err := store.CreatePayee(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, payee.ID)
+	require.Equal(t, arg.Owner, payee.Owner)
+	require.Equal(t, arg.Nickname, payee.Nickname)
+	require.Equal(t, arg.AccountID, payee.AccountID)
+	require.Equal(t, arg.Currency, payee.Currency)
+	require.NotZero(t, payee.CreatedAt)
+
+	return payee
+}
+
+func testCreatePayee(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	account := createRandomAccount(t, store, 0)
+
+	payee1 := createPayee(t, store, user.Username, "landlord", account)
+
+	payee2, err := store.GetPayee(context.Background(), payee1.ID)
+	require.NoError(t, err)
+	require.Equal(t, payee1, payee2)
+}
+
+func testCreatePayeeViolations(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	account := createRandomAccount(t, store, 0)
+	createPayee(t, store, user.Username, "landlord", account)
+
+	testCases := []struct {
+		name    string
+		arg     db.CreatePayeeParams
+		errCode string
+	}{
+		{
+			name: "DuplicateNickname",
+			arg: db.CreatePayeeParams{
+				Owner:     user.Username,
+				Nickname:  "landlord",
+				AccountID: account.ID,
+				Currency:  account.Currency,
+			},
+			errCode: db.UniqueViolation,
+		},
+		{
+			name: "UnknownOwner",
+			arg: db.CreatePayeeParams{
+				Owner:     util.RandomString(20),
+				Nickname:  "landlord",
+				AccountID: account.ID,
+				Currency:  account.Currency,
+			},
+			errCode: db.ForeignKeyViolation,
+		},
+		{
+			name: "UnknownAccount",
+			arg: db.CreatePayeeParams{
+				Owner:     user.Username,
+				Nickname:  "plumber",
+				AccountID: account.ID + 1_000_000,
+				Currency:  account.Currency,
+			},
+			errCode: db.ForeignKeyViolation,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.CreatePayee(context.Background(), tc.arg)
+			require.Equal(t, tc.errCode, db.ErrCode(err))
+		})
+	}
+}
+
+func testListPayees(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	other := createRandomUser(t, store)
+	account := createRandomAccount(t, store, 0)
+
+	plumber := createPayee(t, store, user.Username, "plumber", account)
+	landlord := createPayee(t, store, user.Username, "landlord", account)
+	createPayee(t, store, other.Username, "landlord", account)
+
+	payees, err := store.ListPayees(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, []db.Payee{landlord, plumber}, payees)
+}
+
+func testDeletePayee(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	other := createRandomUser(t, store)
+	account := createRandomAccount(t, store, 0)
+	payee := createPayee(t, store, user.Username, "landlord", account)
+
+	// only the owner of a payee deletes it
+	rows, err := store.DeletePayee(context.Background(), db.DeletePayeeParams{ID: payee.ID, Owner: other.Username})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.DeletePayee(context.Background(), db.DeletePayeeParams{ID: payee.ID, Owner: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetPayee(context.Background(), payee.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	// payees go away with their account
+	payee = createPayee(t, store, user.Username, "landlord", account)
+	_, err = store.DeleteAccount(context.Background(), db.DeleteAccountParams{ID: account.ID})
+	require.NoError(t, err)
+
+	_, err = store.GetPayee(context.Background(), payee.ID)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
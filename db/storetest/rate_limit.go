@@ -0,0 +1,66 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var rateLimitTests = []conformanceTest{
+	{"HitRateLimit", testHitRateLimit},
+	{"HitRateLimitResets", testHitRateLimitResets},
+	{"DeleteStaleRateLimits", testDeleteStaleRateLimits},
+}
+
+func hitRateLimit(t *testing.T, store db.Store, scope string, identifier string, resetBefore time.Time) db.RateLimit {
+	rateLimit, err := store.HitRateLimit(context.Background(), db.HitRateLimitParams{
+		Scope:       scope,
+		Identifier:  identifier,
+		ResetBefore: resetBefore,
+	})
+	require.NoError(t, err)
+	require.Equal(t, scope, rateLimit.Scope)
+	require.Equal(t, identifier, rateLimit.Identifier)
+	require.WithinDuration(t, time.Now(), rateLimit.WindowStartedAt, time.Second)
+
+	return rateLimit
+}
+
+func testHitRateLimit(t *testing.T, store db.Store) {
+	identifier := util.RandomString(20)
+
+	first := hitRateLimit(t, store, util.PayeeConfirmationRateLimit, identifier, time.Now().Add(-time.Hour))
+	require.Equal(t, int32(1), first.Hits)
+	for i := 2; i <= 3; i++ {
+		rateLimit := hitRateLimit(t, store, util.PayeeConfirmationRateLimit, identifier, time.Now().Add(-time.Hour))
+		require.Equal(t, int32(i), rateLimit.Hits)
+		require.Equal(t, first.WindowStartedAt, rateLimit.WindowStartedAt, "the window only starts with its first hit")
+	}
+
+	rateLimit := hitRateLimit(t, store, "other", identifier, time.Now().Add(-time.Hour))
+	require.Equal(t, int32(1), rateLimit.Hits, "scopes are counted apart")
+}
+
+func testHitRateLimitResets(t *testing.T, store db.Store) {
+	identifier := util.RandomString(20)
+	hitRateLimit(t, store, util.PayeeConfirmationRateLimit, identifier, time.Now().Add(-time.Hour))
+	hitRateLimit(t, store, util.PayeeConfirmationRateLimit, identifier, time.Now().Add(-time.Hour))
+
+	// a window that started before reset_before is over
+	rateLimit := hitRateLimit(t, store, util.PayeeConfirmationRateLimit, identifier, time.Now().Add(time.Second))
+	require.Equal(t, int32(1), rateLimit.Hits)
+}
+
+func testDeleteStaleRateLimits(t *testing.T, store db.Store) {
+	stale := hitRateLimit(t, store, util.PayeeConfirmationRateLimit, util.RandomString(20), time.Now().Add(-time.Hour))
+
+	_, err := store.DeleteStaleRateLimits(context.Background(), time.Now().Add(time.Second))
+	require.NoError(t, err)
+
+	rateLimit := hitRateLimit(t, store, stale.Scope, stale.Identifier, time.Now().Add(-time.Hour))
+	require.Equal(t, int32(1), rateLimit.Hits, "the count of a deleted window starts over")
+}
@@ -0,0 +1,120 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var recoveryCodeTests = []conformanceTest{
+	{"CreateRecoveryCode", testCreateRecoveryCode},
+	{"CreateRecoveryCodeViolations", testCreateRecoveryCodeViolations},
+	{"UseRecoveryCode", testUseRecoveryCode},
+	{"DeleteRecoveryCodes", testDeleteRecoveryCodes},
+}
+
+func createRecoveryCode(t *testing.T, store db.Store, user db.User) db.RecoveryCode {
+	arg := db.CreateRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashSecret(util.RandomString(10)),
+	}
+
+	code, err := store.CreateRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, code.ID)
+	require.Equal(t, arg.Username, code.Username)
+	require.Equal(t, arg.CodeHash, code.CodeHash)
+	require.False(t, code.UsedAt.Valid)
+	require.NotZero(t, code.CreatedAt)
+
+	return code
+}
+
+func testCreateRecoveryCode(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	for i := 0; i < 3; i++ {
+		createRecoveryCode(t, store, user)
+	}
+
+	count, err := store.CountRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+}
+
+func testCreateRecoveryCodeViolations(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	code := createRecoveryCode(t, store, user)
+
+	_, err := store.CreateRecoveryCode(context.Background(), db.CreateRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: code.CodeHash,
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+
+	// another user may have the same code
+	other := createRandomUser(t, store)
+	_, err = store.CreateRecoveryCode(context.Background(), db.CreateRecoveryCodeParams{
+		Username: other.Username,
+		CodeHash: code.CodeHash,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateRecoveryCode(context.Background(), db.CreateRecoveryCodeParams{
+		Username: util.RandomString(20),
+		CodeHash: util.HashSecret(util.RandomString(10)),
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testUseRecoveryCode(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	code := createRecoveryCode(t, store, user)
+	createRecoveryCode(t, store, user)
+
+	_, err := store.UseRecoveryCode(context.Background(), db.UseRecoveryCodeParams{
+		Username: createRandomUser(t, store).Username,
+		CodeHash: code.CodeHash,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "codes only work for their user")
+
+	used, err := store.UseRecoveryCode(context.Background(), db.UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: code.CodeHash,
+	})
+	require.NoError(t, err)
+	require.Equal(t, code.ID, used.ID)
+	require.True(t, used.UsedAt.Valid)
+
+	_, err = store.UseRecoveryCode(context.Background(), db.UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: code.CodeHash,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "codes are single-use")
+
+	count, err := store.CountRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func testDeleteRecoveryCodes(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	other := createRandomUser(t, store)
+	createRecoveryCode(t, store, user)
+	createRecoveryCode(t, store, user)
+	createRecoveryCode(t, store, other)
+
+	rows, err := store.DeleteRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rows)
+
+	count, err := store.CountRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	count, err = store.CountRecoveryCodes(context.Background(), other.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
@@ -0,0 +1,108 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var resetPasswordTests = []conformanceTest{
+	{"CreateResetPassword", testCreateResetPassword},
+	{"CreateResetPasswordViolations", testCreateResetPasswordViolations},
+	{"UseResetPassword", testUseResetPassword},
+	{"UseExpiredResetPassword", testUseExpiredResetPassword},
+	{"UseUserResetPasswords", testUseUserResetPasswords},
+}
+
+func createResetPassword(t *testing.T, store db.Store, user db.User, expiresAt time.Time) db.ResetPassword {
+	arg := db.CreateResetPasswordParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: expiresAt.Truncate(time.Microsecond),
+	}
+
+	resetPassword, err := store.CreateResetPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, resetPassword.ID)
+	require.Equal(t, arg.Username, resetPassword.Username)
+	require.Equal(t, arg.TokenHash, resetPassword.TokenHash)
+	require.False(t, resetPassword.IsUsed)
+	require.WithinDuration(t, arg.ExpiresAt, resetPassword.ExpiresAt, time.Millisecond)
+	require.NotZero(t, resetPassword.CreatedAt)
+
+	return resetPassword
+}
+
+func testCreateResetPassword(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createResetPassword(t, store, user, time.Now().Add(time.Hour))
+}
+
+func testCreateResetPasswordViolations(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	resetPassword := createResetPassword(t, store, user, time.Now().Add(time.Hour))
+
+	_, err := store.CreateResetPassword(context.Background(), db.CreateResetPasswordParams{
+		Username:  user.Username,
+		TokenHash: resetPassword.TokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+
+	_, err = store.CreateResetPassword(context.Background(), db.CreateResetPasswordParams{
+		Username:  util.RandomString(20),
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testUseResetPassword(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	resetPassword := createResetPassword(t, store, user, time.Now().Add(time.Hour))
+
+	used, err := store.UseResetPassword(context.Background(), resetPassword.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, resetPassword.ID, used.ID)
+	require.True(t, used.IsUsed)
+
+	_, err = store.UseResetPassword(context.Background(), resetPassword.TokenHash)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "tokens are single-use")
+
+	_, err = store.UseResetPassword(context.Background(), util.HashSecret(util.RandomString(32)))
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUseExpiredResetPassword(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	resetPassword := createResetPassword(t, store, user, time.Now().Add(-time.Second))
+
+	_, err := store.UseResetPassword(context.Background(), resetPassword.TokenHash)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUseUserResetPasswords(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	used := createResetPassword(t, store, user, time.Now().Add(time.Hour))
+	_, err := store.UseResetPassword(context.Background(), used.TokenHash)
+	require.NoError(t, err)
+	pending := createResetPassword(t, store, user, time.Now().Add(time.Hour))
+	expired := createResetPassword(t, store, user, time.Now().Add(-time.Minute))
+	other := createResetPassword(t, store, createRandomUser(t, store), time.Now().Add(time.Hour))
+
+	rows, err := store.UseUserResetPasswords(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rows, "the used link is left as it is")
+
+	for _, resetPassword := range []db.ResetPassword{pending, expired} {
+		_, err = store.UseResetPassword(context.Background(), resetPassword.TokenHash)
+		require.ErrorIs(t, err, db.ErrRecordNotFound)
+	}
+
+	_, err = store.UseResetPassword(context.Background(), other.TokenHash)
+	require.NoError(t, err, "links of other users still work")
+}
@@ -0,0 +1,223 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var riskDecisionTests = []conformanceTest{
+	{"CreateRiskDecision", testCreateRiskDecision},
+	{"CreateRiskDecisionViolations", testCreateRiskDecisionViolations},
+	{"CountRiskDecisionsFromIP", testCountRiskDecisionsFromIP},
+	{"ListRiskDecisions", testListRiskDecisions},
+}
+
+func createRiskDecision(t *testing.T, store db.Store, username string, fromAccount db.Account, toAccount db.Account, clientIP string, outcome string) db.RiskDecision {
+	arg := db.CreateRiskDecisionParams{
+		Username:      username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        util.RandomMoney() + 1,
+		Currency:      fromAccount.Currency,
+		ClientIp:      clientIP,
+		Outcome:       outcome,
+		FiredRules:    json.RawMessage(`[{"rule":"velocity","outcome":"review","reason":"too fast"}]`),
+	}
+
+	decision, err := store.CreateRiskDecision(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, decision.ID)
+	require.Equal(t, arg.Username, decision.Username)
+	require.Equal(t, arg.FromAccountID, decision.FromAccountID)
+	require.Equal(t, arg.ToAccountID, decision.ToAccountID)
+	require.Equal(t, arg.Amount, decision.Amount)
+	require.Equal(t, arg.Currency, decision.Currency)
+	require.Equal(t, arg.ClientIp, decision.ClientIp)
+	require.Equal(t, arg.Outcome, decision.Outcome)
+	require.JSONEq(t, string(arg.FiredRules), string(decision.FiredRules))
+	require.False(t, decision.ApprovalID.Valid)
+	require.NotZero(t, decision.CreatedAt)
+
+	return decision
+}
+
+func testCreateRiskDecision(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, user.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	createRiskDecision(t, store, user.Username, fromAccount, toAccount, "10.0.0.1", util.AllowOutcome)
+
+	approval := createTransferApproval(t, store, user.Username, fromAccount, toAccount, time.Now().Add(time.Hour))
+	decision, err := store.CreateRiskDecision(context.Background(), db.CreateRiskDecisionParams{
+		Username:      user.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        approval.Amount.Int64,
+		Currency:      approval.Currency,
+		ClientIp:      "10.0.0.1",
+		Outcome:       util.ReviewOutcome,
+		ApprovalID:    pgtype.Int8{Int64: approval.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, approval.ID, decision.ApprovalID.Int64)
+	require.JSONEq(t, `[]`, string(decision.FiredRules), "no rules fired")
+}
+
+func testCreateRiskDecisionViolations(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, user.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	testCases := []struct {
+		name   string
+		update func(arg *db.CreateRiskDecisionParams)
+		code   string
+	}{
+		{"UnknownOutcome", func(arg *db.CreateRiskDecisionParams) { arg.Outcome = "maybe" }, db.CheckViolation},
+		{"UnknownUser", func(arg *db.CreateRiskDecisionParams) { arg.Username = util.RandomString(20) }, db.ForeignKeyViolation},
+		{"UnknownFromAccount", func(arg *db.CreateRiskDecisionParams) { arg.FromAccountID = -1 }, db.ForeignKeyViolation},
+		{"UnknownToAccount", func(arg *db.CreateRiskDecisionParams) { arg.ToAccountID = -1 }, db.ForeignKeyViolation},
+		{"UnknownApproval", func(arg *db.CreateRiskDecisionParams) { arg.ApprovalID = pgtype.Int8{Int64: -1, Valid: true} }, db.ForeignKeyViolation},
+	}
+	for _, tc := range testCases {
+		arg := db.CreateRiskDecisionParams{
+			Username:      user.Username,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        10,
+			Currency:      util.EUR,
+			ClientIp:      "10.0.0.1",
+			Outcome:       util.BlockOutcome,
+		}
+		tc.update(&arg)
+
+		_, err := store.CreateRiskDecision(context.Background(), arg)
+		require.Equal(t, tc.code, db.ErrCode(err), tc.name)
+	}
+}
+
+func testCountRiskDecisionsFromIP(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	otherUser := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, user.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, otherUser.Username, util.EUR, 0)
+
+	createRiskDecision(t, store, user.Username, fromAccount, toAccount, "10.0.0.1", util.AllowOutcome)
+	createRiskDecision(t, store, user.Username, fromAccount, toAccount, "10.0.0.1", util.BlockOutcome)
+	createRiskDecision(t, store, user.Username, fromAccount, toAccount, "10.0.0.2", util.AllowOutcome)
+	createRiskDecision(t, store, otherUser.Username, fromAccount, toAccount, "10.0.0.1", util.AllowOutcome)
+
+	// held transfers only count once a banker approved them
+	for _, status := range []string{util.PendingApproval, util.ApprovedApproval, util.RejectedApproval} {
+		approval := createTransferApproval(t, store, user.Username, fromAccount, toAccount, time.Now().Add(time.Hour))
+		_, err := store.CreateRiskDecision(context.Background(), db.CreateRiskDecisionParams{
+			Username:      user.Username,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        approval.Amount.Int64,
+			Currency:      approval.Currency,
+			ClientIp:      "10.0.0.1",
+			Outcome:       util.ReviewOutcome,
+			FiredRules:    json.RawMessage(`[]`),
+			ApprovalID:    pgtype.Int8{Int64: approval.ID, Valid: true},
+		})
+		require.NoError(t, err)
+
+		if status != util.PendingApproval {
+			_, err = store.ResolveApproval(context.Background(), db.ResolveApprovalParams{
+				ID:      approval.ID,
+				Status:  status,
+				Checker: pgtype.Text{String: otherUser.Username, Valid: true},
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	count, err := store.CountRiskDecisionsFromIP(context.Background(), db.CountRiskDecisionsFromIPParams{
+		Username: user.Username,
+		ClientIp: "10.0.0.1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count, "blocked, pending and rejected transfers do not count")
+
+	count, err = store.CountRiskDecisionsFromIP(context.Background(), db.CountRiskDecisionsFromIPParams{
+		Username: user.Username,
+		ClientIp: "10.0.0.3",
+	})
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func testListRiskDecisions(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, user.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	var decisions []db.RiskDecision
+	for _, outcome := range []string{util.AllowOutcome, util.BlockOutcome, util.AllowOutcome} {
+		decisions = append(decisions, createRiskDecision(t, store, user.Username, fromAccount, toAccount, "10.0.0.1", outcome))
+	}
+
+	approval := createTransferApproval(t, store, user.Username, fromAccount, toAccount, time.Now().Add(time.Hour))
+	reviewed, err := store.CreateRiskDecision(context.Background(), db.CreateRiskDecisionParams{
+		Username:      user.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        approval.Amount.Int64,
+		Currency:      approval.Currency,
+		ClientIp:      "10.0.0.1",
+		Outcome:       util.ReviewOutcome,
+		FiredRules:    json.RawMessage(`[]`),
+		ApprovalID:    pgtype.Int8{Int64: approval.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	all, err := store.ListRiskDecisionsAfter(context.Background(), db.ListRiskDecisionsAfterParams{
+		Username: pgtype.Text{String: user.Username, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	for i, decision := range []db.RiskDecision{reviewed, decisions[2], decisions[1], decisions[0]} {
+		require.Equal(t, decision.ID, all[i].ID, "risk decisions must be newest first")
+	}
+
+	allowed, err := store.ListRiskDecisionsAfter(context.Background(), db.ListRiskDecisionsAfterParams{
+		Username:        pgtype.Text{String: user.Username, Valid: true},
+		Outcome:         pgtype.Text{String: util.AllowOutcome, Valid: true},
+		CursorCreatedAt: pgtype.Timestamptz{Time: decisions[2].CreatedAt, Valid: true},
+		CursorID:        pgtype.Int8{Int64: decisions[2].ID, Valid: true},
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, allowed, 1)
+	require.Equal(t, decisions[0].ID, allowed[0].ID)
+
+	held, err := store.ListRiskDecisionsAfter(context.Background(), db.ListRiskDecisionsAfterParams{
+		ApprovalID: pgtype.Int8{Int64: approval.ID, Valid: true},
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, held, 1)
+	require.Equal(t, reviewed.ID, held[0].ID)
+
+	previous, err := store.ListRiskDecisionsBefore(context.Background(), db.ListRiskDecisionsBeforeParams{
+		Username:        pgtype.Text{String: user.Username, Valid: true},
+		CursorCreatedAt: decisions[1].CreatedAt,
+		CursorID:        decisions[1].ID,
+		Limit:           2,
+	})
+	require.NoError(t, err)
+	require.Len(t, previous, 2)
+	require.Equal(t, decisions[2].ID, previous[0].ID, "previous pages must be oldest first")
+	require.Equal(t, reviewed.ID, previous[1].ID)
+}
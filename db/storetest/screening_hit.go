@@ -0,0 +1,205 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var screeningHitTests = []conformanceTest{
+	{"CreateScreeningHit", testCreateScreeningHit},
+	{"CreateScreeningHitViolations", testCreateScreeningHitViolations},
+	{"ResolveScreeningHit", testResolveScreeningHit},
+	{"CountBlockingScreeningHits", testCountBlockingScreeningHits},
+	{"ListScreeningHits", testListScreeningHits},
+}
+
+func createScreeningHit(t *testing.T, store db.Store, user db.User, listEntryID string) db.ScreeningHit {
+	arg := db.CreateScreeningHitParams{
+		Username:     user.Username,
+		ScreenedName: user.FullName,
+		ListEntryID:  listEntryID,
+		ListedName:   user.FullName,
+		Score:        95,
+	}
+
+	rows, err := store.CreateScreeningHit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	hits, err := store.ListScreeningHitsAfter(context.Background(), db.ListScreeningHitsAfterParams{
+		Username: pgtype.Text{String: user.Username, Valid: true},
+		Limit:    100,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, hits)
+
+	hit := hits[len(hits)-1]
+	require.NotZero(t, hit.ID)
+	require.Equal(t, arg.Username, hit.Username)
+	require.Equal(t, arg.ScreenedName, hit.ScreenedName)
+	require.Equal(t, arg.ListEntryID, hit.ListEntryID)
+	require.Equal(t, arg.ListedName, hit.ListedName)
+	require.Equal(t, arg.Score, hit.Score)
+	require.Equal(t, util.OpenHit, hit.Status)
+	require.False(t, hit.ReviewedBy.Valid)
+	require.False(t, hit.ReviewedAt.Valid)
+	require.NotZero(t, hit.CreatedAt)
+
+	return hit
+}
+
+func testCreateScreeningHit(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	hit1 := createScreeningHit(t, store, user, "SDN-1")
+
+	hit2, err := store.GetScreeningHit(context.Background(), hit1.ID)
+	require.NoError(t, err)
+	require.Equal(t, hit1, hit2)
+
+	rows, err := store.CreateScreeningHit(context.Background(), db.CreateScreeningHitParams{
+		Username:     user.Username,
+		ScreenedName: user.FullName,
+		ListEntryID:  "SDN-1",
+		ListedName:   "another alias",
+		Score:        90,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows, "the name already matched the entry")
+
+	_, err = store.GetScreeningHit(context.Background(), -1)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testCreateScreeningHitViolations(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	_, err := store.CreateScreeningHit(context.Background(), db.CreateScreeningHitParams{
+		Username:     user.Username,
+		ScreenedName: user.FullName,
+		ListEntryID:  "SDN-1",
+		ListedName:   user.FullName,
+		Score:        101,
+	})
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+
+	_, err = store.CreateScreeningHit(context.Background(), db.CreateScreeningHitParams{
+		Username:     util.RandomString(20),
+		ScreenedName: user.FullName,
+		ListEntryID:  "SDN-1",
+		ListedName:   user.FullName,
+		Score:        95,
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testResolveScreeningHit(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	banker := createRandomUser(t, store)
+	hit := createScreeningHit(t, store, user, "SDN-1")
+
+	_, err := store.ResolveScreeningHit(context.Background(), db.ResolveScreeningHitParams{
+		ID:         hit.ID,
+		Status:     util.ClearedHit,
+		ReviewedBy: pgtype.Text{String: user.Username, Valid: true},
+	})
+	require.Equal(t, db.CheckViolation, db.ErrCode(err), "users cannot clear their own hits")
+
+	cleared, err := store.ResolveScreeningHit(context.Background(), db.ResolveScreeningHitParams{
+		ID:         hit.ID,
+		Status:     util.ClearedHit,
+		ReviewedBy: pgtype.Text{String: banker.Username, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.ClearedHit, cleared.Status)
+	require.Equal(t, banker.Username, cleared.ReviewedBy.String)
+	require.True(t, cleared.ReviewedAt.Valid)
+
+	_, err = store.ResolveScreeningHit(context.Background(), db.ResolveScreeningHitParams{
+		ID:         hit.ID,
+		Status:     util.ConfirmedHit,
+		ReviewedBy: pgtype.Text{String: banker.Username, Valid: true},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "hits are reviewed once")
+}
+
+func testCountBlockingScreeningHits(t *testing.T, store db.Store) {
+	user1 := createRandomUser(t, store)
+	user2 := createRandomUser(t, store)
+	user3 := createRandomUser(t, store)
+	banker := createRandomUser(t, store)
+
+	createScreeningHit(t, store, user1, "SDN-1")
+	confirmed := createScreeningHit(t, store, user1, "SDN-2")
+	cleared := createScreeningHit(t, store, user2, "SDN-1")
+
+	for _, arg := range []db.ResolveScreeningHitParams{
+		{ID: confirmed.ID, Status: util.ConfirmedHit, ReviewedBy: pgtype.Text{String: banker.Username, Valid: true}},
+		{ID: cleared.ID, Status: util.ClearedHit, ReviewedBy: pgtype.Text{String: banker.Username, Valid: true}},
+	} {
+		_, err := store.ResolveScreeningHit(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	count := func(usernames ...string) int64 {
+		count, err := store.CountBlockingScreeningHits(context.Background(), usernames)
+		require.NoError(t, err)
+		return count
+	}
+
+	require.Equal(t, int64(2), count(user1.Username, user2.Username, user3.Username))
+	require.Zero(t, count(user2.Username, user3.Username), "cleared hits do not block")
+}
+
+func testListScreeningHits(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	banker := createRandomUser(t, store)
+
+	var hits []db.ScreeningHit
+	for _, listEntryID := range []string{"SDN-1", "SDN-2", "SDN-3"} {
+		hits = append(hits, createScreeningHit(t, store, user, listEntryID))
+	}
+
+	cleared, err := store.ResolveScreeningHit(context.Background(), db.ResolveScreeningHitParams{
+		ID:         hits[1].ID,
+		Status:     util.ClearedHit,
+		ReviewedBy: pgtype.Text{String: banker.Username, Valid: true},
+	})
+	require.NoError(t, err)
+	hits[1] = cleared
+
+	all, err := store.ListScreeningHitsAfter(context.Background(), db.ListScreeningHitsAfterParams{
+		Username: pgtype.Text{String: user.Username, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	for i := range hits {
+		require.Equal(t, hits[i].ID, all[i].ID, "screening hits must be oldest first")
+	}
+
+	open, err := store.ListScreeningHitsAfter(context.Background(), db.ListScreeningHitsAfterParams{
+		Username:        pgtype.Text{String: user.Username, Valid: true},
+		Status:          pgtype.Text{String: util.OpenHit, Valid: true},
+		CursorCreatedAt: pgtype.Timestamptz{Time: hits[0].CreatedAt, Valid: true},
+		CursorID:        pgtype.Int8{Int64: hits[0].ID, Valid: true},
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	require.Equal(t, hits[2].ID, open[0].ID)
+
+	previous, err := store.ListScreeningHitsBefore(context.Background(), db.ListScreeningHitsBeforeParams{
+		Username:        pgtype.Text{String: user.Username, Valid: true},
+		CursorCreatedAt: hits[2].CreatedAt,
+		CursorID:        hits[2].ID,
+		Limit:           1,
+	})
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+	require.Equal(t, hits[1].ID, previous[0].ID, "previous pages must end right before the cursor")
+}
@@ -0,0 +1,168 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var sessionTests = []conformanceTest{
+	{"CreateSession", testCreateSession},
+	{"CreateSessionForeignKeyViolation", testCreateSessionForeignKeyViolation},
+	{"GetSessionNotFound", testGetSessionNotFound},
+	{"BlockUserSessions", testBlockUserSessions},
+	{"GetClientIPHistory", testGetClientIPHistory},
+}
+
+func testCreateSession(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	arg := db.CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "storetest",
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+	session1, err := store.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.ID, session1.ID)
+	require.Equal(t, arg.Username, session1.Username)
+	require.Equal(t, arg.RefreshToken, session1.RefreshToken)
+	require.Equal(t, arg.UserAgent, session1.UserAgent)
+	require.Equal(t, arg.ClientIp, session1.ClientIp)
+	require.Equal(t, arg.IsBlocked, session1.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session1.ExpiresAt, time.Millisecond)
+	require.NotZero(t, session1.CreatedAt)
+
+	session2, err := store.GetSession(context.Background(), arg.ID)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.Equal(t, session1.RefreshToken, session2.RefreshToken)
+
+	_, err = store.CreateSession(context.Background(), arg)
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+}
+
+func testCreateSessionForeignKeyViolation(t *testing.T, store db.Store) {
+	_, err := store.CreateSession(context.Background(), db.CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     util.RandomString(20),
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testGetSessionNotFound(t *testing.T, store db.Store) {
+	_, err := store.GetSession(context.Background(), uuid.New())
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testBlockUserSessions(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	otherUser := createRandomUser(t, store)
+
+	createSession := func(username string, expiresAt time.Time) db.Session {
+		session, err := store.CreateSession(context.Background(), db.CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     username,
+			RefreshToken: util.RandomString(32),
+			ExpiresAt:    expiresAt,
+		})
+		require.NoError(t, err)
+		return session
+	}
+
+	active1 := createSession(user.Username, time.Now().Add(time.Hour))
+	active2 := createSession(user.Username, time.Now().Add(time.Hour))
+	expired := createSession(user.Username, time.Now().Add(-time.Hour))
+	other := createSession(otherUser.Username, time.Now().Add(time.Hour))
+
+	blockedAt := time.Now()
+	rows, err := store.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rows, "only active sessions are counted")
+
+	access, err := store.GetUserAccess(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, blockedAt, access.TokensValidAfter, time.Second, "access tokens are revoked with the sessions")
+
+	access, err = store.GetUserAccess(context.Background(), otherUser.Username)
+	require.NoError(t, err)
+	require.True(t, access.TokensValidAfter.Before(otherUser.CreatedAt))
+
+	for _, session := range []db.Session{active1, active2} {
+		blocked, err := store.GetSession(context.Background(), session.ID)
+		require.NoError(t, err)
+		require.True(t, blocked.IsBlocked)
+	}
+
+	untouched, err := store.GetSession(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.False(t, untouched.IsBlocked)
+
+	untouched, err = store.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.False(t, untouched.IsBlocked)
+
+	rows, err = store.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func testGetClientIPHistory(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	otherUser := createRandomUser(t, store)
+
+	for _, session := range []struct {
+		username string
+		clientIP string
+	}{
+		{user.Username, "10.0.0.1"},
+		{user.Username, "10.0.0.1"},
+		{user.Username, "10.0.0.2"},
+		{otherUser.Username, "10.0.0.3"},
+	} {
+		_, err := store.CreateSession(context.Background(), db.CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     session.username,
+			RefreshToken: util.RandomString(32),
+			ClientIp:     session.clientIP,
+			ExpiresAt:    time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	history, err := store.GetClientIPHistory(context.Background(), db.GetClientIPHistoryParams{
+		ClientIp: "10.0.0.1",
+		Username: user.Username,
+		Before:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, db.GetClientIPHistoryRow{Sessions: 3, ClientIpSessions: 2}, history)
+
+	history, err = store.GetClientIPHistory(context.Background(), db.GetClientIPHistoryParams{
+		ClientIp: "10.0.0.3",
+		Username: user.Username,
+		Before:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, db.GetClientIPHistoryRow{Sessions: 3}, history, "sessions of other users are not counted")
+
+	history, err = store.GetClientIPHistory(context.Background(), db.GetClientIPHistoryParams{
+		ClientIp: "10.0.0.1",
+		Username: user.Username,
+		Before:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, history, "sessions opened since are not counted")
+}
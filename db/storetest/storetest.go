@@ -0,0 +1,133 @@
+// Package storetest provides a conformance suite for db.Store implementations.
+//
+// An implementation proves parity with the postgres store by running
+//
+//	storetest.Run(t, func(t *testing.T) db.Store { return NewStore() })
+//
+// The suite only creates rows with random keys and never assumes a table is empty,
+// so a factory may return the same shared store to every test.
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns the store a test runs against
+type Factory func(t *testing.T) db.Store
+
+type conformanceTest struct {
+	name string
+	run  func(t *testing.T, store db.Store)
+}
+
+// Run checks that the stores built by newStore behave like the postgres store
+func Run(t *testing.T, newStore Factory) {
+	var tests []conformanceTest
+	tests = append(tests, userTests...)
+	tests = append(tests, sessionTests...)
+	tests = append(tests, accountTests...)
+	tests = append(tests, accountMemberTests...)
+	tests = append(tests, accessGrantTests...)
+	tests = append(tests, payeeTests...)
+	tests = append(tests, moneyRequestTests...)
+	tests = append(tests, approvalTests...)
+	tests = append(tests, riskDecisionTests...)
+	tests = append(tests, screeningHitTests...)
+	tests = append(tests, verifyEmailTests...)
+	tests = append(tests, resetPasswordTests...)
+	tests = append(tests, twoFactorTests...)
+	tests = append(tests, recoveryCodeTests...)
+	tests = append(tests, loginChallengeTests...)
+	tests = append(tests, loginLockoutTests...)
+	tests = append(tests, rateLimitTests...)
+	tests = append(tests, jobTests...)
+	tests = append(tests, entryTests...)
+	tests = append(tests, transferTests...)
+	tests = append(tests, auditLogTests...)
+	tests = append(tests, archivedPartitionTests...)
+	tests = append(tests, transferTxTests...)
+	tests = append(tests, moneyRequestTxTests...)
+	tests = append(tests, approvalTxTests...)
+	tests = append(tests, userAccessTxTests...)
+	tests = append(tests, userTxTests...)
+	tests = append(tests, twoFactorTxTests...)
+	tests = append(tests, accountEventTests...)
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, newStore(t))
+		})
+	}
+}
+
+func randomCreateUserParams(t *testing.T) db.CreateUserParams {
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	return db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(6),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomString(12) + "@email.com",
+	}
+}
+
+func createRandomUser(t *testing.T, store db.Store) db.User {
+	arg := randomCreateUserParams(t)
+
+	user, err := store.CreateUser(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Username, user.Username)
+	require.Equal(t, arg.HashedPassword, user.HashedPassword)
+	require.Equal(t, arg.FullName, user.FullName)
+	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, util.DepositorRole, user.Role)
+	require.False(t, user.Disabled)
+	require.False(t, user.Discoverable, "users opt in to the directory")
+	require.False(t, user.IsEmailVerified)
+	require.Equal(t, int64(1), user.Version)
+	require.True(t, user.PasswordChangedAt.IsZero())
+	require.NotZero(t, user.CreatedAt)
+
+	return user
+}
+
+func createAccount(t *testing.T, store db.Store, owner string, currency string, balance int64) db.Account {
+	arg := db.CreateAccountParams{
+		Owner:    owner,
+		Balance:  balance,
+		Currency: currency,
+	}
+
+	account, err := store.CreateAccount(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, account.ID)
+	require.Equal(t, arg.Owner, account.Owner)
+	require.Equal(t, arg.Balance, account.Balance)
+	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, int64(1), account.Version)
+	require.Equal(t, util.ActiveAccount, account.Status)
+	require.NotZero(t, account.CreatedAt)
+
+	return account
+}
+
+func createRandomAccount(t *testing.T, store db.Store, balance int64) db.Account {
+	user := createRandomUser(t, store)
+	return createAccount(t, store, user.Username, util.RandomCurrency(), balance)
+}
+
+func getAccount(t *testing.T, store db.Store, id int64) db.Account {
+	account, err := store.GetAccount(context.Background(), id)
+	require.NoError(t, err)
+	return account
+}
@@ -0,0 +1,189 @@
+package storetest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var transferTests = []conformanceTest{
+	{"CreateTransfer", testCreateTransfer},
+	{"CreateTransferForeignKeyViolation", testCreateTransferForeignKeyViolation},
+	{"GetTransferNotFound", testGetTransferNotFound},
+	{"ListTransfersPagination", testListTransfersPagination},
+	{"ListTransfersKeyset", testListTransfersKeyset},
+	{"CountTransfersBetween", testCountTransfersBetween},
+	{"ListTransfersSince", testListTransfersSince},
+}
+
+func createTransfer(t *testing.T, store db.Store, from db.Account, to db.Account) db.Transfer {
+	arg := db.CreateTransferParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        util.RandomInt(1, 100),
+	}
+
+	transfer, err := store.CreateTransfer(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, transfer.ID)
+	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
+	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
+	require.Equal(t, arg.Amount, transfer.Amount)
+	require.NotZero(t, transfer.CreatedAt)
+
+	return transfer
+}
+
+func testCreateTransfer(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 0)
+	account2 := createRandomAccount(t, store, 0)
+	transfer1 := createTransfer(t, store, account1, account2)
+
+	transfer2, err := store.GetTransfer(context.Background(), transfer1.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer1.ID, transfer2.ID)
+	require.Equal(t, transfer1.FromAccountID, transfer2.FromAccountID)
+	require.Equal(t, transfer1.ToAccountID, transfer2.ToAccountID)
+	require.Equal(t, transfer1.Amount, transfer2.Amount)
+	require.WithinDuration(t, transfer1.CreatedAt, transfer2.CreatedAt, time.Second)
+}
+
+func testCreateTransferForeignKeyViolation(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 0)
+
+	_, err := store.CreateTransfer(context.Background(), db.CreateTransferParams{
+		FromAccountID: account.ID,
+		ToAccountID:   -1,
+		Amount:        10,
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testGetTransferNotFound(t *testing.T, store db.Store) {
+	_, err := store.GetTransfer(context.Background(), -1)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testListTransfersPagination(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 0)
+	account2 := createRandomAccount(t, store, 0)
+	account3 := createRandomAccount(t, store, 0)
+
+	var transfers []db.Transfer
+	for i := 0; i < 2; i++ {
+		transfers = append(transfers, createTransfer(t, store, account1, account2))
+		transfers = append(transfers, createTransfer(t, store, account2, account1))
+	}
+	createTransfer(t, store, account2, account3)
+
+	var listed []db.Transfer
+	for offset := int32(0); offset < 6; offset += 3 {
+		page, err := store.ListTransfers(context.Background(), db.ListTransfersParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account1.ID,
+			Limit:         3,
+			Offset:        offset,
+		})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 3)
+		listed = append(listed, page...)
+	}
+
+	require.Len(t, listed, len(transfers))
+	for i := range transfers {
+		require.Equal(t, transfers[i].ID, listed[i].ID, "transfers must be ordered by id")
+	}
+}
+
+func testListTransfersKeyset(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 0)
+	account2 := createRandomAccount(t, store, 0)
+	account3 := createRandomAccount(t, store, 0)
+	createTransfer(t, store, account2, account3)
+
+	transfers := []db.Transfer{
+		createTransfer(t, store, account1, account2),
+		createTransfer(t, store, account2, account1),
+		createTransfer(t, store, account1, account3),
+	}
+
+	checkKeysetPages(t, transfers,
+		func(transfer db.Transfer) string {
+			return strconv.FormatInt(transfer.ID, 10)
+		},
+		func(cursor *db.Transfer, limit int32) ([]db.Transfer, error) {
+			arg := db.ListTransfersAfterParams{
+				AccountID: account1.ID,
+				Limit:     limit,
+			}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+				arg.CursorID = pgtype.Int8{Int64: cursor.ID, Valid: true}
+			}
+			return store.ListTransfersAfter(context.Background(), arg)
+		},
+		func(cursor db.Transfer, limit int32) ([]db.Transfer, error) {
+			return store.ListTransfersBefore(context.Background(), db.ListTransfersBeforeParams{
+				AccountID:       account1.ID,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				Limit:           limit,
+			})
+		},
+	)
+}
+
+func testCountTransfersBetween(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 0)
+	account2 := createRandomAccount(t, store, 0)
+
+	count := func(from db.Account, to db.Account) int64 {
+		count, err := store.CountTransfersBetween(context.Background(), db.CountTransfersBetweenParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+		})
+		require.NoError(t, err)
+		return count
+	}
+
+	require.Zero(t, count(account1, account2))
+
+	createTransfer(t, store, account1, account2)
+	createTransfer(t, store, account1, account2)
+	createTransfer(t, store, account2, account1)
+
+	require.Equal(t, int64(2), count(account1, account2))
+	require.Equal(t, int64(1), count(account2, account1))
+}
+
+func testListTransfersSince(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 0)
+	account2 := createRandomAccount(t, store, 0)
+
+	var sent []db.Transfer
+	for i := 0; i < 3; i++ {
+		sent = append(sent, createTransfer(t, store, account1, account2))
+	}
+	createTransfer(t, store, account2, account1)
+
+	transfers, err := store.ListTransfersSince(context.Background(), db.ListTransfersSinceParams{
+		FromAccountID: account1.ID,
+		CreatedAt:     time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sent, transfers, "only transfers sent from the account, oldest first")
+
+	transfers, err = store.ListTransfersSince(context.Background(), db.ListTransfersSinceParams{
+		FromAccountID: account1.ID,
+		CreatedAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
@@ -0,0 +1,155 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var twoFactorTests = []conformanceTest{
+	{"CreateTwoFactor", testCreateTwoFactor},
+	{"CreateTwoFactorForeignKeyViolation", testCreateTwoFactorForeignKeyViolation},
+	{"CreateTwoFactorReplacesPending", testCreateTwoFactorReplacesPending},
+	{"EnableTwoFactor", testEnableTwoFactor},
+	{"UseTwoFactorStep", testUseTwoFactorStep},
+	{"DeleteTwoFactor", testDeleteTwoFactor},
+}
+
+func createTwoFactor(t *testing.T, store db.Store, user db.User) db.TwoFactor {
+	arg := db.CreateTwoFactorParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	}
+
+	twoFactor, err := store.CreateTwoFactor(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, twoFactor.Username)
+	require.Equal(t, arg.Secret, twoFactor.Secret)
+	require.False(t, twoFactor.IsEnabled)
+	require.Zero(t, twoFactor.LastUsedStep)
+	require.False(t, twoFactor.EnabledAt.Valid)
+	require.NotZero(t, twoFactor.CreatedAt)
+
+	return twoFactor
+}
+
+func enableTwoFactor(t *testing.T, store db.Store, user db.User, step int64) db.TwoFactor {
+	createTwoFactor(t, store, user)
+
+	twoFactor, err := store.EnableTwoFactor(context.Background(), db.EnableTwoFactorParams{
+		Username:     user.Username,
+		LastUsedStep: step,
+	})
+	require.NoError(t, err)
+	return twoFactor
+}
+
+func testCreateTwoFactor(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	twoFactor := createTwoFactor(t, store, user)
+
+	got, err := store.GetTwoFactor(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, twoFactor, got)
+
+	_, err = store.GetTwoFactor(context.Background(), util.RandomString(20))
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testCreateTwoFactorForeignKeyViolation(t *testing.T, store db.Store) {
+	_, err := store.CreateTwoFactor(context.Background(), db.CreateTwoFactorParams{
+		Username: util.RandomString(20),
+		Secret:   util.RandomString(32),
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testCreateTwoFactorReplacesPending(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createTwoFactor(t, store, user)
+	replaced := createTwoFactor(t, store, user)
+
+	got, err := store.GetTwoFactor(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, replaced.Secret, got.Secret)
+
+	enableTwoFactor(t, store, user, 10)
+
+	_, err = store.CreateTwoFactor(context.Background(), db.CreateTwoFactorParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "an enabled secret is kept")
+}
+
+func testEnableTwoFactor(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	_, err := store.EnableTwoFactor(context.Background(), db.EnableTwoFactorParams{
+		Username:     user.Username,
+		LastUsedStep: 10,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	twoFactor := enableTwoFactor(t, store, user, 10)
+	require.True(t, twoFactor.IsEnabled)
+	require.True(t, twoFactor.EnabledAt.Valid)
+	require.Equal(t, int64(10), twoFactor.LastUsedStep)
+
+	_, err = store.EnableTwoFactor(context.Background(), db.EnableTwoFactorParams{
+		Username:     user.Username,
+		LastUsedStep: 11,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUseTwoFactorStep(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createTwoFactor(t, store, user)
+
+	_, err := store.UseTwoFactorStep(context.Background(), db.UseTwoFactorStepParams{
+		Username:     user.Username,
+		LastUsedStep: 10,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "the secret is not enabled yet")
+
+	_, err = store.EnableTwoFactor(context.Background(), db.EnableTwoFactorParams{
+		Username:     user.Username,
+		LastUsedStep: 10,
+	})
+	require.NoError(t, err)
+
+	for _, step := range []int64{10, 9} {
+		_, err = store.UseTwoFactorStep(context.Background(), db.UseTwoFactorStepParams{
+			Username:     user.Username,
+			LastUsedStep: step,
+		})
+		require.ErrorIs(t, err, db.ErrRecordNotFound)
+	}
+
+	twoFactor, err := store.UseTwoFactorStep(context.Background(), db.UseTwoFactorStepParams{
+		Username:     user.Username,
+		LastUsedStep: 11,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(11), twoFactor.LastUsedStep)
+}
+
+func testDeleteTwoFactor(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	enableTwoFactor(t, store, user, 10)
+
+	rows, err := store.DeleteTwoFactor(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetTwoFactor(context.Background(), user.Username)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	rows, err = store.DeleteTwoFactor(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
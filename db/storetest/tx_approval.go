@@ -0,0 +1,130 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var approvalTxTests = []conformanceTest{
+	{"ApproveTxTransfer", testApproveTxTransfer},
+	{"ApproveTxAdjustment", testApproveTxAdjustment},
+	{"ApproveTxRollback", testApproveTxRollback},
+}
+
+func testApproveTxTransfer(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	checker := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, maker.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+	approval := createTransferApproval(t, store, maker.Username, fromAccount, toAccount, time.Now().Add(time.Hour))
+
+	arg := db.ApproveTxParams{
+		ID:      approval.ID,
+		Checker: checker.Username,
+	}
+	result, err := store.ApproveTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, util.ApprovedApproval, result.Approval.Status)
+	require.Equal(t, checker.Username, result.Approval.Checker.String)
+	require.True(t, result.Approval.ResolvedAt.Valid)
+	require.Nil(t, result.Account)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Approval.TransferID.Int64)
+
+	transfer := result.Transfer.Transfer
+	require.Equal(t, fromAccount.ID, transfer.FromAccountID)
+	require.Equal(t, toAccount.ID, transfer.ToAccountID)
+	require.Equal(t, approval.Amount.Int64, transfer.Amount)
+	require.Equal(t, fromAccount.Balance-transfer.Amount, result.Transfer.FromAccount.Balance)
+	require.Equal(t, transfer.Amount, result.Transfer.ToAccount.Balance)
+
+	// an approval executes once
+	_, err = store.ApproveTx(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	fromAccount, err = store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.FromAccount.Balance, fromAccount.Balance)
+}
+
+func testApproveTxAdjustment(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	checker := createRandomUser(t, store)
+	account := createRandomAccount(t, store, 100)
+
+	approval, err := store.CreateApproval(context.Background(), db.CreateApprovalParams{
+		Kind:           util.AdjustmentApproval,
+		Maker:          maker.Username,
+		AccountID:      account.ID,
+		Balance:        pgtype.Int8{Int64: 500, Valid: true},
+		AccountVersion: pgtype.Int8{Int64: account.Version, Valid: true},
+		Currency:       account.Currency,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	result, err := store.ApproveTx(context.Background(), db.ApproveTxParams{
+		ID:      approval.ID,
+		Checker: checker.Username,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, util.ApprovedApproval, result.Approval.Status)
+	require.False(t, result.Approval.TransferID.Valid)
+	require.Nil(t, result.Transfer)
+	require.NotNil(t, result.Account)
+	require.Equal(t, int64(500), result.Account.Balance)
+	require.Equal(t, account.Version+1, result.Account.Version)
+}
+
+func testApproveTxRollback(t *testing.T, store db.Store) {
+	maker := createRandomUser(t, store)
+	checker := createRandomUser(t, store)
+	fromAccount := createAccount(t, store, maker.Username, util.EUR, 1000)
+	toAccount := createAccount(t, store, createRandomUser(t, store).Username, util.EUR, 0)
+
+	// the transfer is undone when the approval turns out to have timed out
+	approval := createTransferApproval(t, store, maker.Username, fromAccount, toAccount, time.Now().Add(-time.Minute))
+	_, err := store.ApproveTx(context.Background(), db.ApproveTxParams{
+		ID:      approval.ID,
+		Checker: checker.Username,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	account, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, account.Balance)
+
+	account, err = store.GetAccount(context.Background(), toAccount.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.Balance)
+
+	// an adjustment does not apply to an account changed since it was asked for
+	adjustment, err := store.CreateApproval(context.Background(), db.CreateApprovalParams{
+		Kind:           util.AdjustmentApproval,
+		Maker:          maker.Username,
+		AccountID:      toAccount.ID,
+		Balance:        pgtype.Int8{Int64: 500, Valid: true},
+		AccountVersion: pgtype.Int8{Int64: toAccount.Version - 1, Valid: true},
+		Currency:       toAccount.Currency,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = store.ApproveTx(context.Background(), db.ApproveTxParams{
+		ID:      adjustment.ID,
+		Checker: checker.Username,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	adjustment, err = store.GetApproval(context.Background(), adjustment.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PendingApproval, adjustment.Status)
+}
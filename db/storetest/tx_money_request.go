@@ -0,0 +1,73 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var moneyRequestTxTests = []conformanceTest{
+	{"AcceptMoneyRequestTx", testAcceptMoneyRequestTx},
+	{"AcceptMoneyRequestTxRollback", testAcceptMoneyRequestTxRollback},
+}
+
+func testAcceptMoneyRequestTx(t *testing.T, store db.Store) {
+	requester := createRandomUser(t, store)
+	payer := createRandomUser(t, store)
+	toAccount := createAccount(t, store, requester.Username, util.EUR, 0)
+	fromAccount := createAccount(t, store, payer.Username, util.EUR, 1000)
+	request := createMoneyRequest(t, store, requester.Username, payer.Username, toAccount, time.Now().Add(time.Hour))
+
+	arg := db.AcceptMoneyRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: fromAccount.ID,
+	}
+	result, err := store.AcceptMoneyRequestTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, util.AcceptedRequest, result.MoneyRequest.Status)
+	require.True(t, result.MoneyRequest.ResolvedAt.Valid)
+	require.Equal(t, result.Transfer.Transfer.ID, result.MoneyRequest.TransferID.Int64)
+
+	transfer := result.Transfer.Transfer
+	require.Equal(t, fromAccount.ID, transfer.FromAccountID)
+	require.Equal(t, toAccount.ID, transfer.ToAccountID)
+	require.Equal(t, request.Amount, transfer.Amount)
+	require.Equal(t, fromAccount.Balance-request.Amount, result.Transfer.FromAccount.Balance)
+	require.Equal(t, request.Amount, result.Transfer.ToAccount.Balance)
+
+	// a request is paid once
+	_, err = store.AcceptMoneyRequestTx(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	fromAccount, err = store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.FromAccount.Balance, fromAccount.Balance)
+}
+
+func testAcceptMoneyRequestTxRollback(t *testing.T, store db.Store) {
+	requester := createRandomUser(t, store)
+	payer := createRandomUser(t, store)
+	toAccount := createAccount(t, store, requester.Username, util.EUR, 0)
+	fromAccount := createAccount(t, store, payer.Username, util.EUR, 1000)
+	request := createMoneyRequest(t, store, requester.Username, payer.Username, toAccount, time.Now().Add(-time.Minute))
+
+	// the transfer is undone when the request turns out to be expired
+	_, err := store.AcceptMoneyRequestTx(context.Background(), db.AcceptMoneyRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: fromAccount.ID,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	account, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, account.Balance)
+
+	account, err = store.GetAccount(context.Background(), toAccount.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.Balance)
+}
@@ -0,0 +1,258 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var transferTxTests = []conformanceTest{
+	{"TransferTx", testTransferTx},
+	{"TransferTxRollback", testTransferTxRollback},
+	{"TransferTxInactiveAccount", testTransferTxInactiveAccount},
+	{"TransferTxRiskDecision", testTransferTxRiskDecision},
+	{"TransferTxNoLostUpdates", testTransferTxNoLostUpdates},
+	{"TransferTxOpposingNoDeadlock", testTransferTxOpposingNoDeadlock},
+}
+
+// transferTxTimeout bounds concurrent transfers so a deadlock fails the test instead of hanging it
+const transferTxTimeout = 30 * time.Second
+
+func testTransferTx(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 100)
+	account2 := createRandomAccount(t, store, 100)
+
+	arg := db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	transfer := result.Transfer
+	require.NotZero(t, transfer.ID)
+	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
+	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
+	require.Equal(t, arg.Amount, transfer.Amount)
+
+	_, err = store.GetTransfer(context.Background(), transfer.ID)
+	require.NoError(t, err)
+
+	fromEntry := result.FromEntry
+	require.Equal(t, account1.ID, fromEntry.AccountID)
+	require.Equal(t, -arg.Amount, fromEntry.Amount)
+
+	_, err = store.GetEntry(context.Background(), fromEntry.ID)
+	require.NoError(t, err)
+
+	toEntry := result.ToEntry
+	require.Equal(t, account2.ID, toEntry.AccountID)
+	require.Equal(t, arg.Amount, toEntry.Amount)
+
+	_, err = store.GetEntry(context.Background(), toEntry.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, account1.ID, result.FromAccount.ID)
+	require.Equal(t, account1.Balance-arg.Amount, result.FromAccount.Balance)
+	require.Equal(t, account1.Version+1, result.FromAccount.Version)
+
+	require.Equal(t, account2.ID, result.ToAccount.ID)
+	require.Equal(t, account2.Balance+arg.Amount, result.ToAccount.Balance)
+	require.Equal(t, account2.Version+1, result.ToAccount.Version)
+
+	require.Equal(t, result.FromAccount, getAccount(t, store, account1.ID))
+	require.Equal(t, result.ToAccount.Balance, getAccount(t, store, account2.ID).Balance)
+}
+
+func testTransferTxRollback(t *testing.T, store db.Store) {
+	account := createRandomAccount(t, store, 100)
+
+	_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   -1,
+		Amount:        10,
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+
+	updatedAccount := getAccount(t, store, account.ID)
+	require.Equal(t, account.Balance, updatedAccount.Balance)
+	require.Equal(t, account.Version, updatedAccount.Version)
+
+	entries, err := store.ListEntries(context.Background(), db.ListEntriesParams{
+		AccountID: account.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	transfers, err := store.ListTransfers(context.Background(), db.ListTransfersParams{
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID,
+		Limit:         10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
+
+// runTransfers runs the transfers concurrently and fails on the first error
+func runTransfers(t *testing.T, store db.Store, args []db.TransferTxParams) {
+	ctx, cancel := context.WithTimeout(context.Background(), transferTxTimeout)
+	defer cancel()
+
+	errs := make(chan error, len(args))
+	for _, arg := range args {
+		go func(arg db.TransferTxParams) {
+			_, err := store.TransferTx(ctx, arg)
+			errs <- err
+		}(arg)
+	}
+
+	for range args {
+		select {
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-ctx.Done():
+			t.Fatal("concurrent transfers did not finish, possible deadlock")
+		}
+	}
+}
+
+func testTransferTxInactiveAccount(t *testing.T, store db.Store) {
+	for _, status := range []string{util.FrozenAccount, util.ClosedAccount} {
+		account1 := createRandomAccount(t, store, 100)
+		account2 := createRandomAccount(t, store, 100)
+
+		_, err := store.UpdateAccountStatus(context.Background(), db.UpdateAccountStatusParams{
+			ID:     account2.ID,
+			Status: status,
+		})
+		require.NoError(t, err)
+
+		// neither out of the account nor into it
+		_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.ErrorIs(t, err, db.ErrAccountNotActive)
+		_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+			FromAccountID: account2.ID,
+			ToAccountID:   account1.ID,
+			Amount:        10,
+		})
+		require.ErrorIs(t, err, db.ErrAccountNotActive)
+
+		require.Equal(t, account1.Balance, getAccount(t, store, account1.ID).Balance)
+		require.Equal(t, account2.Balance, getAccount(t, store, account2.ID).Balance)
+	}
+}
+
+func testTransferTxRiskDecision(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 100)
+	account2 := createRandomAccount(t, store, 100)
+
+	countDecisions := func() int64 {
+		count, err := store.CountRiskDecisionsFromIP(context.Background(), db.CountRiskDecisionsFromIPParams{
+			Username: account1.Owner,
+			ClientIp: "10.0.0.1",
+		})
+		require.NoError(t, err)
+		return count
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		RiskDecision: &db.CreateRiskDecisionParams{
+			Username:      account1.Owner,
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+			Currency:      account1.Currency,
+			ClientIp:      "10.0.0.1",
+			Outcome:       util.AllowOutcome,
+		},
+	}
+	_, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), countDecisions())
+
+	_, err = store.UpdateAccountStatus(context.Background(), db.UpdateAccountStatusParams{
+		ID:     account2.ID,
+		Status: util.FrozenAccount,
+	})
+	require.NoError(t, err)
+
+	// the decision goes away with the transfer that failed
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrAccountNotActive)
+	require.Equal(t, int64(1), countDecisions())
+}
+
+func testTransferTxNoLostUpdates(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 1000)
+	account2 := createRandomAccount(t, store, 1000)
+
+	n := 10
+	amount := int64(10)
+
+	args := make([]db.TransferTxParams, n)
+	for i := range args {
+		args[i] = db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		}
+	}
+	runTransfers(t, store, args)
+
+	updatedAccount1 := getAccount(t, store, account1.ID)
+	require.Equal(t, account1.Balance-int64(n)*amount, updatedAccount1.Balance)
+	require.Equal(t, account1.Version+int64(n), updatedAccount1.Version)
+
+	updatedAccount2 := getAccount(t, store, account2.ID)
+	require.Equal(t, account2.Balance+int64(n)*amount, updatedAccount2.Balance)
+	require.Equal(t, account2.Version+int64(n), updatedAccount2.Version)
+
+	entries, err := store.ListEntries(context.Background(), db.ListEntriesParams{
+		AccountID: account1.ID,
+		Limit:     int32(n) + 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, n)
+}
+
+func testTransferTxOpposingNoDeadlock(t *testing.T, store db.Store) {
+	account1 := createRandomAccount(t, store, 1000)
+	account2 := createRandomAccount(t, store, 1000)
+
+	n := 10
+	amount := int64(10)
+
+	args := make([]db.TransferTxParams, n)
+	for i := range args {
+		args[i] = db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		}
+		if i%2 == 1 {
+			args[i].FromAccountID, args[i].ToAccountID = account2.ID, account1.ID
+		}
+	}
+	runTransfers(t, store, args)
+
+	updatedAccount1 := getAccount(t, store, account1.ID)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	require.Equal(t, account1.Version+int64(n), updatedAccount1.Version)
+
+	updatedAccount2 := getAccount(t, store, account2.ID)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+	require.Equal(t, account2.Version+int64(n), updatedAccount2.Version)
+}
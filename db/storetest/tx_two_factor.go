@@ -0,0 +1,146 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var twoFactorTxTests = []conformanceTest{
+	{"EnableTwoFactorTx", testEnableTwoFactorTx},
+	{"EnableTwoFactorTxRollback", testEnableTwoFactorTxRollback},
+	{"CompleteLoginChallengeTx", testCompleteLoginChallengeTx},
+	{"CompleteLoginChallengeTxRecoveryCode", testCompleteLoginChallengeTxRecoveryCode},
+	{"ResetTwoFactorTx", testResetTwoFactorTx},
+}
+
+func testEnableTwoFactorTx(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createTwoFactor(t, store, user)
+	stale := createRecoveryCode(t, store, user)
+
+	hashes := []string{util.HashSecret(util.RandomString(10)), util.HashSecret(util.RandomString(10))}
+	result, err := store.EnableTwoFactorTx(context.Background(), db.EnableTwoFactorTxParams{
+		Username:           user.Username,
+		Step:               10,
+		RecoveryCodeHashes: hashes,
+	})
+	require.NoError(t, err)
+	require.True(t, result.TwoFactor.IsEnabled)
+	require.Equal(t, int64(10), result.TwoFactor.LastUsedStep)
+	require.Len(t, result.RecoveryCodes, 2)
+	for i, code := range result.RecoveryCodes {
+		require.Equal(t, hashes[i], code.CodeHash)
+	}
+
+	// the codes of a former setup no longer work
+	_, err = store.UseRecoveryCode(context.Background(), db.UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: stale.CodeHash,
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	count, err := store.CountRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+}
+
+func testEnableTwoFactorTxRollback(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createTwoFactor(t, store, user)
+	createRecoveryCode(t, store, user)
+
+	codeHash := util.HashSecret(util.RandomString(10))
+	_, err := store.EnableTwoFactorTx(context.Background(), db.EnableTwoFactorTxParams{
+		Username:           user.Username,
+		Step:               10,
+		RecoveryCodeHashes: []string{codeHash, codeHash},
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+
+	twoFactor, err := store.GetTwoFactor(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, twoFactor.IsEnabled)
+
+	count, err := store.CountRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func testCompleteLoginChallengeTx(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	enableTwoFactor(t, store, user, 10)
+	challenge := createLoginChallenge(t, store, user, time.Now().Add(time.Minute))
+
+	// a code already used leaves the challenge open
+	_, err := store.CompleteLoginChallengeTx(context.Background(), db.CompleteLoginChallengeTxParams{
+		ChallengeID: challenge.ID,
+		Step:        pgtype.Int8{Int64: 10, Valid: true},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	result, err := store.CompleteLoginChallengeTx(context.Background(), db.CompleteLoginChallengeTxParams{
+		ChallengeID: challenge.ID,
+		Step:        pgtype.Int8{Int64: 11, Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, result.LoginChallenge.IsUsed)
+	require.Equal(t, user.Username, result.LoginChallenge.Username)
+
+	twoFactor, err := store.GetTwoFactor(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(11), twoFactor.LastUsedStep)
+
+	_, err = store.CompleteLoginChallengeTx(context.Background(), db.CompleteLoginChallengeTxParams{
+		ChallengeID: challenge.ID,
+		Step:        pgtype.Int8{Int64: 12, Valid: true},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testCompleteLoginChallengeTxRecoveryCode(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	enableTwoFactor(t, store, user, 10)
+	code := createRecoveryCode(t, store, user)
+	arg := db.CompleteLoginChallengeTxParams{
+		RecoveryCodeHash: pgtype.Text{String: code.CodeHash, Valid: true},
+	}
+
+	arg.ChallengeID = createLoginChallenge(t, store, user, time.Now().Add(time.Minute)).ID
+	result, err := store.CompleteLoginChallengeTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.LoginChallenge.IsUsed)
+	require.Equal(t, code.ID, result.RecoveryCode.ID)
+	require.True(t, result.RecoveryCode.UsedAt.Valid)
+
+	challenge := createLoginChallenge(t, store, user, time.Now().Add(time.Minute))
+	arg.ChallengeID = challenge.ID
+	_, err = store.CompleteLoginChallengeTx(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "recovery codes are single-use")
+
+	challenge, err = store.GetLoginChallenge(context.Background(), challenge.TokenHash)
+	require.NoError(t, err)
+	require.False(t, challenge.IsUsed)
+}
+
+func testResetTwoFactorTx(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	enableTwoFactor(t, store, user, 10)
+	createRecoveryCode(t, store, user)
+	createRecoveryCode(t, store, user)
+
+	result, err := store.ResetTwoFactorTx(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.DeletedRecoveryCodes)
+
+	_, err = store.GetTwoFactor(context.Background(), user.Username)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	_, err = store.ResetTwoFactorTx(context.Background(), user.Username)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
@@ -0,0 +1,255 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var userTxTests = []conformanceTest{
+	{"CreateUserTx", testCreateUserTx},
+	{"CreateUserTxRollback", testCreateUserTxRollback},
+	{"CreateUserTxScreeningHits", testCreateUserTxScreeningHits},
+	{"UpdateUserTx", testUpdateUserTx},
+	{"UpdateUserTxPassword", testUpdateUserTxPassword},
+	{"VerifyEmailTx", testVerifyEmailTx},
+	{"VerifyEmailTxChangedEmail", testVerifyEmailTxChangedEmail},
+	{"ResetPasswordTx", testResetPasswordTx},
+}
+
+func testCreateUserTx(t *testing.T, store db.Store) {
+	arg := db.CreateUserTxParams{
+		CreateUserParams: randomCreateUserParams(t),
+		Jobs: []db.CreateJobParams{{
+			Kind:        util.RandomString(12),
+			Payload:     json.RawMessage(`{"step":1}`),
+			MaxAttempts: 3,
+		}},
+	}
+
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, result.User.Username)
+	require.False(t, result.User.IsEmailVerified)
+	require.Len(t, result.Jobs, 1)
+	require.Equal(t, arg.Jobs[0].Kind, result.Jobs[0].Kind)
+	require.JSONEq(t, `{"step":1}`, string(result.Jobs[0].Payload))
+
+	job, err := store.GetJob(context.Background(), result.Jobs[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PendingJob, job.Status)
+}
+
+func testCreateUserTxRollback(t *testing.T, store db.Store) {
+	arg := db.CreateUserTxParams{
+		CreateUserParams: randomCreateUserParams(t),
+		Jobs: []db.CreateJobParams{{
+			Kind:    util.RandomString(12),
+			Payload: json.RawMessage(`{}`),
+		}},
+	}
+
+	_, err := store.CreateUserTx(context.Background(), arg)
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+
+	_, err = store.GetUser(context.Background(), arg.Username)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "the user is not created without its jobs")
+}
+
+func testCreateUserTxScreeningHits(t *testing.T, store db.Store) {
+	arg := db.CreateUserTxParams{
+		CreateUserParams: randomCreateUserParams(t),
+	}
+	arg.ScreeningHits = []db.CreateScreeningHitParams{{
+		Username:     arg.Username,
+		ScreenedName: arg.FullName,
+		ListEntryID:  "SDN-1",
+		ListedName:   arg.FullName,
+		Score:        95,
+	}}
+
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	count, err := store.CountBlockingScreeningHits(context.Background(), []string{result.User.Username})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count, "the user is created with its hits")
+
+	arg.CreateUserParams = randomCreateUserParams(t)
+	arg.ScreeningHits = []db.CreateScreeningHitParams{{
+		Username:     arg.Username,
+		ScreenedName: arg.FullName,
+		ListEntryID:  "SDN-1",
+		ListedName:   arg.FullName,
+		Score:        101,
+	}}
+
+	_, err = store.CreateUserTx(context.Background(), arg)
+	require.Equal(t, db.CheckViolation, db.ErrCode(err))
+
+	_, err = store.GetUser(context.Background(), arg.Username)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "the user is not created without its hits")
+}
+
+func testUpdateUserTx(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	newEmail := util.RandomString(12) + "@email.com"
+
+	result, err := store.UpdateUserTx(context.Background(), db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username:        user.Username,
+			Email:           pgtype.Text{String: newEmail, Valid: true},
+			IsEmailVerified: pgtype.Bool{Bool: false, Valid: true},
+		},
+		Jobs: []db.CreateJobParams{{
+			Kind:        util.RandomString(12),
+			Payload:     json.RawMessage(`{}`),
+			MaxAttempts: 3,
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, result.User.Email)
+	require.Equal(t, user.Version+1, result.User.Version)
+	require.Len(t, result.Jobs, 1)
+
+	result, err = store.UpdateUserTx(context.Background(), db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: user.Username,
+			FullName: pgtype.Text{String: util.RandomOwner(), Valid: true},
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Jobs)
+
+	_, err = store.UpdateUserTx(context.Background(), db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: user.Username,
+			FullName: pgtype.Text{String: util.RandomOwner(), Valid: true},
+			Version:  pgtype.Int8{Int64: user.Version, Valid: true},
+		},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUpdateUserTxPassword(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	session, err := store.CreateSession(context.Background(), db.CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	result, err := store.UpdateUserTx(context.Background(), db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: user.Username,
+			FullName: pgtype.Text{String: util.RandomOwner(), Valid: true},
+		},
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.RevokedSessions, "only a new password blocks the sessions")
+
+	changedAt := time.Now()
+	result, err = store.UpdateUserTx(context.Background(), db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username:          user.Username,
+			HashedPassword:    pgtype.Text{String: "new-hash", Valid: true},
+			PasswordChangedAt: pgtype.Timestamptz{Time: changedAt, Valid: true},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.RevokedSessions)
+
+	session, err = store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	access, err := store.GetUserAccess(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, changedAt, access.TokensValidAfter, time.Second)
+}
+
+func testVerifyEmailTx(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	verifyEmail := createVerifyEmail(t, store, user, time.Now().Add(time.Hour))
+
+	result, err := store.VerifyEmailTx(context.Background(), verifyEmail.TokenHash)
+	require.NoError(t, err)
+	require.True(t, result.VerifyEmail.IsUsed)
+	require.Equal(t, user.Username, result.User.Username)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, user.Version+1, result.User.Version)
+	require.Equal(t, user, result.OldUser)
+
+	_, err = store.VerifyEmailTx(context.Background(), verifyEmail.TokenHash)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testVerifyEmailTxChangedEmail(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	verifyEmail := createVerifyEmail(t, store, user, time.Now().Add(time.Hour))
+
+	_, err := store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username: user.Username,
+		Email:    pgtype.Text{String: util.RandomString(12) + "@email.com", Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.VerifyEmailTx(context.Background(), verifyEmail.TokenHash)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "the link was sent to the previous email")
+
+	user, err = store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+}
+
+func testResetPasswordTx(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	resetPassword := createResetPassword(t, store, user, time.Now().Add(time.Hour))
+	otherResetPassword := createResetPassword(t, store, user, time.Now().Add(time.Hour))
+
+	session, err := store.CreateSession(context.Background(), db.CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	result, err := store.ResetPasswordTx(context.Background(), db.ResetPasswordTxParams{
+		TokenHash:      resetPassword.TokenHash,
+		HashedPassword: "new-hash",
+	})
+	require.NoError(t, err)
+	require.True(t, result.ResetPassword.IsUsed)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, "new-hash", result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+	require.Equal(t, user.Version+1, result.User.Version)
+	require.Equal(t, int64(1), result.RevokedSessions)
+
+	session, err = store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	for _, tokenHash := range []string{resetPassword.TokenHash, otherResetPassword.TokenHash} {
+		_, err = store.ResetPasswordTx(context.Background(), db.ResetPasswordTxParams{
+			TokenHash:      tokenHash,
+			HashedPassword: "another-hash",
+		})
+		require.ErrorIs(t, err, db.ErrRecordNotFound, "every link of the user is used up")
+	}
+
+	user, err = store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, "new-hash", user.HashedPassword)
+}
@@ -0,0 +1,52 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var userAccessTxTests = []conformanceTest{
+	{"UpdateUserAccessTx", testUpdateUserAccessTx},
+	{"UpdateUserAccessTxNotFound", testUpdateUserAccessTxNotFound},
+}
+
+func testUpdateUserAccessTx(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	session, err := store.CreateSession(context.Background(), db.CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	result, err := store.UpdateUserAccessTx(context.Background(), db.UpdateUserAccessTxParams{
+		Username: user.Username,
+		Disabled: pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.Disabled)
+	require.Equal(t, user.Role, result.User.Role)
+	require.Equal(t, user.Version+1, result.User.Version)
+	require.Equal(t, int64(1), result.RevokedSessions)
+
+	session, err = store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+}
+
+func testUpdateUserAccessTxNotFound(t *testing.T, store db.Store) {
+	_, err := store.UpdateUserAccessTx(context.Background(), db.UpdateUserAccessTxParams{
+		Username: util.RandomString(20),
+		Role:     pgtype.Text{String: util.BankerRole, Valid: true},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
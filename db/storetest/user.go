@@ -0,0 +1,404 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var userTests = []conformanceTest{
+	{"CreateUser", testCreateUser},
+	{"CreateUserUniqueViolation", testCreateUserUniqueViolation},
+	{"GetUserNotFound", testGetUserNotFound},
+	{"UpdateUser", testUpdateUser},
+	{"UpdateUserVersion", testUpdateUserVersion},
+	{"UpdateUserNotFound", testUpdateUserNotFound},
+	{"UpdateUserRoleAndDisabled", testUpdateUserRoleAndDisabled},
+	{"RehashUserPassword", testRehashUserPassword},
+	{"GetDiscoverableUser", testGetDiscoverableUser},
+	{"GetUserByEmail", testGetUserByEmail},
+	{"GetUserAccess", testGetUserAccess},
+	{"ListUsersKeyset", testListUsersKeyset},
+	{"SearchUsers", testSearchUsers},
+}
+
+func testCreateUser(t *testing.T, store db.Store) {
+	user1 := createRandomUser(t, store)
+
+	user2, err := store.GetUser(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, user1.HashedPassword, user2.HashedPassword)
+	require.Equal(t, user1.FullName, user2.FullName)
+	require.Equal(t, user1.Email, user2.Email)
+	require.Equal(t, user1.Role, user2.Role)
+	require.Equal(t, user1.Version, user2.Version)
+	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
+}
+
+func testCreateUserUniqueViolation(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          util.RandomString(12) + "@email.com",
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+
+	_, err = store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(6),
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+}
+
+func testGetUserNotFound(t *testing.T, store db.Store) {
+	_, err := store.GetUser(context.Background(), util.RandomString(20))
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUpdateUser(t *testing.T, store db.Store) {
+	oldUser := createRandomUser(t, store)
+
+	newFullName := util.RandomOwner()
+	passwordChangedAt := time.Now().Truncate(time.Second)
+
+	arg := db.UpdateUserParams{
+		Username: oldUser.Username,
+		FullName: pgtype.Text{
+			String: newFullName,
+			Valid:  true,
+		},
+		HashedPassword: pgtype.Text{
+			String: "new-hash",
+			Valid:  true,
+		},
+		PasswordChangedAt: pgtype.Timestamptz{
+			Time:  passwordChangedAt,
+			Valid: true,
+		},
+	}
+	updatedUser, err := store.UpdateUser(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, newFullName, updatedUser.FullName)
+	require.Equal(t, "new-hash", updatedUser.HashedPassword)
+	require.WithinDuration(t, passwordChangedAt, updatedUser.PasswordChangedAt, time.Millisecond)
+	require.Equal(t, oldUser.Email, updatedUser.Email, "unset fields must be kept")
+	require.Equal(t, oldUser.Version+1, updatedUser.Version)
+}
+
+func testUpdateUserVersion(t *testing.T, store db.Store) {
+	oldUser := createRandomUser(t, store)
+
+	arg := db.UpdateUserParams{
+		Username: oldUser.Username,
+		Email: pgtype.Text{
+			String: util.RandomString(12) + "@email.com",
+			Valid:  true,
+		},
+		Version: pgtype.Int8{
+			Int64: oldUser.Version,
+			Valid: true,
+		},
+	}
+	updatedUser, err := store.UpdateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Email.String, updatedUser.Email)
+
+	_, err = store.UpdateUser(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "stale versions must not match")
+}
+
+func testUpdateUserNotFound(t *testing.T, store db.Store) {
+	_, err := store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username: util.RandomString(20),
+		FullName: pgtype.Text{
+			String: util.RandomOwner(),
+			Valid:  true,
+		},
+	})
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUpdateUserRoleAndDisabled(t *testing.T, store db.Store) {
+	oldUser := createRandomUser(t, store)
+	require.False(t, oldUser.Disabled)
+
+	updatedUser, err := store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username: oldUser.Username,
+		Role:     pgtype.Text{String: util.BankerRole, Valid: true},
+		Disabled: pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, updatedUser.Role)
+	require.True(t, updatedUser.Disabled)
+	require.Equal(t, oldUser.FullName, updatedUser.FullName, "unset fields must be kept")
+
+	updatedUser, err = store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username: oldUser.Username,
+		Disabled: pgtype.Bool{Bool: false, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, updatedUser.Role)
+	require.False(t, updatedUser.Disabled)
+}
+
+func testRehashUserPassword(t *testing.T, store db.Store) {
+	oldUser := createRandomUser(t, store)
+
+	arg := db.RehashUserPasswordParams{
+		Username:          oldUser.Username,
+		OldHashedPassword: oldUser.HashedPassword,
+		NewHashedPassword: "new-hash",
+	}
+	rows, err := store.RehashUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	user, err := store.GetUser(context.Background(), oldUser.Username)
+	require.NoError(t, err)
+	require.Equal(t, "new-hash", user.HashedPassword)
+	require.Equal(t, oldUser.Version, user.Version, "a rehash is not a change of the user")
+	require.Equal(t, oldUser.PasswordChangedAt, user.PasswordChangedAt)
+
+	// the hash read before no longer matches
+	rows, err = store.RehashUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.RehashUserPassword(context.Background(), db.RehashUserPasswordParams{
+		Username:          util.RandomString(20),
+		OldHashedPassword: "new-hash",
+		NewHashedPassword: "newer-hash",
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func testGetDiscoverableUser(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	_, err := store.GetDiscoverableUser(context.Background(), user.Username)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "users are left out of the directory until they opt in")
+
+	user, err = store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username:     user.Username,
+		Discoverable: pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	for _, alias := range []string{user.Username, user.Email} {
+		found, err := store.GetDiscoverableUser(context.Background(), alias)
+		require.NoError(t, err)
+		require.Equal(t, user, found)
+	}
+
+	_, err = store.GetDiscoverableUser(context.Background(), util.RandomString(20))
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	// users who opted out of the directory, or were disabled, cannot be found
+	user, err = store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username:     user.Username,
+		Discoverable: pgtype.Bool{Bool: false, Valid: true},
+	})
+	require.NoError(t, err)
+	require.False(t, user.Discoverable)
+
+	_, err = store.GetDiscoverableUser(context.Background(), user.Email)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	_, err = store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username:     user.Username,
+		Discoverable: pgtype.Bool{Bool: true, Valid: true},
+		Disabled:     pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.GetDiscoverableUser(context.Background(), user.Username)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testGetUserByEmail(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	found, err := store.GetUserByEmail(context.Background(), user.Email)
+	require.NoError(t, err)
+	require.Equal(t, user, found)
+
+	_, err = store.GetUserByEmail(context.Background(), util.RandomEmail())
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testGetUserAccess(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+
+	access, err := store.GetUserAccess(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Role, access.Role)
+	require.False(t, access.Disabled)
+	require.WithinDuration(t, user.PasswordChangedAt, access.PasswordChangedAt, time.Second)
+	require.True(t, access.TokensValidAfter.Before(user.CreatedAt), "tokens of a new user are valid")
+
+	changedAt := time.Now()
+	_, err = store.UpdateUserAccessTx(context.Background(), db.UpdateUserAccessTxParams{
+		Username: user.Username,
+		Role:     pgtype.Text{String: util.BankerRole, Valid: true},
+		Disabled: pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	access, err = store.GetUserAccess(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, access.Role)
+	require.True(t, access.Disabled)
+	require.WithinDuration(t, changedAt, access.TokensValidAfter, time.Second, "a change of access revokes the tokens")
+
+	_, err = store.GetUserAccess(context.Background(), util.RandomString(20))
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testListUsersKeyset(t *testing.T, store db.Store) {
+	users := []db.User{
+		createRandomUser(t, store),
+		createRandomUser(t, store),
+		createRandomUser(t, store),
+	}
+
+	checkKeysetPages(t, users,
+		func(user db.User) string {
+			return user.Username
+		},
+		func(cursor *db.User, limit int32) ([]db.User, error) {
+			arg := db.ListUsersAfterParams{
+				Limit: limit,
+			}
+			if cursor != nil {
+				arg.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+				arg.CursorUsername = pgtype.Text{String: cursor.Username, Valid: true}
+			}
+			return store.ListUsersAfter(context.Background(), arg)
+		},
+		func(cursor db.User, limit int32) ([]db.User, error) {
+			return store.ListUsersBefore(context.Background(), db.ListUsersBeforeParams{
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorUsername:  cursor.Username,
+				Limit:           limit,
+			})
+		},
+	)
+}
+
+func testSearchUsers(t *testing.T, store db.Store) {
+	prefix := util.RandomString(8)
+
+	var users []db.User
+	for _, name := range []string{"carol", "alice", "bob"} {
+		hashedPassword, err := util.HashPassword(util.RandomString(6))
+		require.NoError(t, err)
+
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       prefix + name,
+			HashedPassword: hashedPassword,
+			FullName:       prefix + " " + name,
+			Email:          name + "." + prefix + "@email.com",
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	carol, alice, bob := users[0], users[1], users[2]
+
+	testCases := []struct {
+		name  string
+		arg   db.SearchUsersParams
+		users []db.User
+	}{
+		{
+			name: "UsernamePrefix",
+			arg: db.SearchUsersParams{
+				UsernamePrefix: pgtype.Text{String: prefix, Valid: true},
+			},
+			users: []db.User{alice, bob, carol},
+		},
+		{
+			name: "EmailPrefixIgnoresCase",
+			arg: db.SearchUsersParams{
+				EmailPrefix: pgtype.Text{String: "BOB." + prefix, Valid: true},
+			},
+			users: []db.User{bob},
+		},
+		{
+			name: "FullNamePrefix",
+			arg: db.SearchUsersParams{
+				FullNamePrefix: pgtype.Text{String: prefix + " c", Valid: true},
+			},
+			users: []db.User{carol},
+		},
+		{
+			name: "SortedPage",
+			arg: db.SearchUsersParams{
+				UsernamePrefix: pgtype.Text{String: prefix, Valid: true},
+				SortBy:         "created_at",
+				SortDesc:       true,
+				Limit:          2,
+			},
+			users: []db.User{bob, alice},
+		},
+		{
+			name: "PageAfterFullName",
+			arg: db.SearchUsersParams{
+				UsernamePrefix: pgtype.Text{String: prefix, Valid: true},
+				SortBy:         "full_name",
+				CursorUsername: pgtype.Text{String: alice.Username, Valid: true},
+				CursorFullName: pgtype.Text{String: alice.FullName, Valid: true},
+			},
+			users: []db.User{bob, carol},
+		},
+		{
+			name: "PageAfterEmailDesc",
+			arg: db.SearchUsersParams{
+				UsernamePrefix: pgtype.Text{String: prefix, Valid: true},
+				SortBy:         "email",
+				SortDesc:       true,
+				CursorUsername: pgtype.Text{String: bob.Username, Valid: true},
+				CursorEmail:    pgtype.Text{String: bob.Email, Valid: true},
+			},
+			users: []db.User{alice},
+		},
+		{
+			name: "WildcardsMatchLiterally",
+			arg: db.SearchUsersParams{
+				UsernamePrefix: pgtype.Text{String: prefix[:2] + "_", Valid: true},
+				FullNamePrefix: pgtype.Text{String: "%", Valid: true},
+			},
+			users: []db.User{},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.arg.Limit == 0 {
+				tc.arg.Limit = 10
+			}
+
+			users, err := store.SearchUsers(context.Background(), tc.arg)
+			require.NoError(t, err)
+			require.NotNil(t, users, "empty results must be empty slices")
+			require.Len(t, users, len(tc.users))
+			for i := range tc.users {
+				require.Equal(t, tc.users[i].Username, users[i].Username)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var verifyEmailTests = []conformanceTest{
+	{"CreateVerifyEmail", testCreateVerifyEmail},
+	{"CreateVerifyEmailViolations", testCreateVerifyEmailViolations},
+	{"UseVerifyEmail", testUseVerifyEmail},
+	{"UseExpiredVerifyEmail", testUseExpiredVerifyEmail},
+}
+
+func createVerifyEmail(t *testing.T, store db.Store, user db.User, expiresAt time.Time) db.VerifyEmail {
+	arg := db.CreateVerifyEmailParams{
+		Username:  user.Username,
+		Email:     user.Email,
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: expiresAt.Truncate(time.Microsecond),
+	}
+
+	verifyEmail, err := store.CreateVerifyEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, verifyEmail.ID)
+	require.Equal(t, arg.Username, verifyEmail.Username)
+	require.Equal(t, arg.Email, verifyEmail.Email)
+	require.Equal(t, arg.TokenHash, verifyEmail.TokenHash)
+	require.False(t, verifyEmail.IsUsed)
+	require.WithinDuration(t, arg.ExpiresAt, verifyEmail.ExpiresAt, time.Millisecond)
+	require.NotZero(t, verifyEmail.CreatedAt)
+
+	return verifyEmail
+}
+
+func testCreateVerifyEmail(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	createVerifyEmail(t, store, user, time.Now().Add(time.Hour))
+}
+
+func testCreateVerifyEmailViolations(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	verifyEmail := createVerifyEmail(t, store, user, time.Now().Add(time.Hour))
+
+	_, err := store.CreateVerifyEmail(context.Background(), db.CreateVerifyEmailParams{
+		Username:  user.Username,
+		Email:     user.Email,
+		TokenHash: verifyEmail.TokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrCode(err))
+
+	_, err = store.CreateVerifyEmail(context.Background(), db.CreateVerifyEmailParams{
+		Username:  util.RandomString(20),
+		Email:     util.RandomEmail(),
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrCode(err))
+}
+
+func testUseVerifyEmail(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	verifyEmail := createVerifyEmail(t, store, user, time.Now().Add(time.Hour))
+
+	used, err := store.UseVerifyEmail(context.Background(), verifyEmail.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, verifyEmail.ID, used.ID)
+	require.True(t, used.IsUsed)
+
+	_, err = store.UseVerifyEmail(context.Background(), verifyEmail.TokenHash)
+	require.ErrorIs(t, err, db.ErrRecordNotFound, "tokens are single-use")
+
+	_, err = store.UseVerifyEmail(context.Background(), util.HashSecret(util.RandomString(32)))
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
+
+func testUseExpiredVerifyEmail(t *testing.T, store db.Store) {
+	user := createRandomUser(t, store)
+	verifyEmail := createVerifyEmail(t, store, user, time.Now().Add(-time.Second))
+
+	_, err := store.UseVerifyEmail(context.Background(), verifyEmail.TokenHash)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+}
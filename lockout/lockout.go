@@ -0,0 +1,164 @@
+// Package lockout slows down password guessing by counting failed logins against the username
+// and the client ip they came from.
+//
+// Each scope has a threshold of failures it tolerates. The failure that reaches it locks
+// the scope out for a delay that doubles with every further failure, up to a maximum.
+// The count starts over once the last failure is older than the window.
+//
+// Logins are counted as failed before their password is checked, so that a burst of parallel
+// logins cannot all get in before the lockout starts, and taken back once the password is right.
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+)
+
+// Default settings of the limiter, for those the config leaves out
+const (
+	DefaultUsernameThreshold = 5
+	// DefaultClientIPThreshold is higher than the username one, as many users may share an ip
+	DefaultClientIPThreshold = 20
+	DefaultDelay             = 30 * time.Second
+	DefaultMaxDelay          = time.Hour
+	DefaultWindow            = 24 * time.Hour
+)
+
+// Policy tells when a scope is locked out and for how long: the failure that reaches the threshold
+// locks it out for the delay, doubled with every further failure up to the maximum
+type Policy struct {
+	Threshold int32
+	Delay     time.Duration
+	MaxDelay  time.Duration
+}
+
+// Limiter counts failed logins and tells when a login has to wait
+type Limiter struct {
+	policies map[string]Policy
+	window   time.Duration
+}
+
+// NewLimiter creates a limiter from the config, with the defaults for the settings left out
+func NewLimiter(config util.Config) *Limiter {
+	delay := orDefault(config.LoginLockoutDelay, DefaultDelay)
+	maxDelay := orDefault(config.LoginLockoutMaxDelay, DefaultMaxDelay)
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+
+	return &Limiter{
+		policies: map[string]Policy{
+			util.UsernameLockout: {
+				Threshold: int32(orDefault(config.LoginUsernameThreshold, DefaultUsernameThreshold)),
+				Delay:     delay,
+				MaxDelay:  maxDelay,
+			},
+			util.ClientIPLockout: {
+				Threshold: int32(orDefault(config.LoginClientIPThreshold, DefaultClientIPThreshold)),
+				Delay:     delay,
+				MaxDelay:  maxDelay,
+			},
+		},
+		window: orDefault(config.LoginFailureWindow, DefaultWindow),
+	}
+}
+
+func orDefault[T int | time.Duration](value T, fallback T) T {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// Window returns how long failures are remembered
+func (limiter *Limiter) Window() time.Duration {
+	return limiter.window
+}
+
+// Attempt is a login counted as failed before its password is checked
+type Attempt struct {
+	lockouts []db.LoginLockout
+}
+
+// Reserve counts a login of the username from the client ip as failed before its password is checked,
+// so that a burst of parallel logins cannot all get in before the first failure is counted. It returns
+// how long the login has to wait instead, without counting it, while either scope is locked out.
+func (limiter *Limiter) Reserve(ctx context.Context, store db.Querier, username string, clientIP string) (Attempt, time.Duration, error) {
+	var attempt Attempt
+	for _, scope := range []string{util.UsernameLockout, util.ClientIPLockout} {
+		identifier := username
+		if scope == util.ClientIPLockout {
+			identifier = clientIP
+		}
+
+		policy := limiter.policies[scope]
+		lockout, err := store.ReserveLoginAttempt(ctx, db.ReserveLoginAttemptParams{
+			Scope:           scope,
+			Identifier:      identifier,
+			Threshold:       policy.Threshold,
+			DelaySeconds:    policy.Delay.Seconds(),
+			MaxDelaySeconds: policy.MaxDelay.Seconds(),
+			ResetBefore:     time.Now().Add(-limiter.window),
+		})
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				wait, err := limiter.lockedOut(ctx, store, scope, identifier)
+				if err != nil {
+					return Attempt{}, 0, err
+				}
+				return Attempt{}, wait, limiter.Refund(ctx, store, attempt)
+			}
+			return Attempt{}, 0, err
+		}
+
+		attempt.lockouts = append(attempt.lockouts, lockout)
+	}
+	return attempt, 0, nil
+}
+
+// lockedOut returns how long the scope that refused an attempt stays locked out
+func (limiter *Limiter) lockedOut(ctx context.Context, store db.Querier, scope string, identifier string) (time.Duration, error) {
+	lockout, err := store.GetLoginLockout(ctx, db.GetLoginLockoutParams{
+		Scope:      scope,
+		Identifier: identifier,
+	})
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	// the lockout may have ended or been cleared since, the login still tries again later
+	wait := time.Until(lockout.LockedUntil.Time)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait, nil
+}
+
+// Refund takes back an attempt whose password turned out right, along with the lockouts it started
+func (limiter *Limiter) Refund(ctx context.Context, store db.Querier, attempt Attempt) error {
+	for _, lockout := range attempt.lockouts {
+		_, err := store.RefundLoginAttempt(ctx, db.RefundLoginAttemptParams{
+			LockedUntil: lockout.LockedUntil,
+			Scope:       lockout.Scope,
+			Identifier:  lockout.Identifier,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed clears the failures of the username once its user logged in. Those of the client ip
+// are kept, or one account known to an attacker would let them guess the passwords of others.
+func (limiter *Limiter) Succeed(ctx context.Context, store db.Querier, username string) error {
+	_, err := store.DeleteLoginLockout(ctx, db.DeleteLoginLockoutParams{
+		Scope:      util.UsernameLockout,
+		Identifier: username,
+	})
+	return err
+}
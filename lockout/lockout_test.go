@@ -0,0 +1,120 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLimiterDefaults(t *testing.T) {
+	limiter := NewLimiter(util.Config{LoginLockoutMaxDelay: time.Second})
+	require.Equal(t, DefaultWindow, limiter.Window())
+
+	policy := limiter.policies[util.UsernameLockout]
+	require.Equal(t, int32(DefaultUsernameThreshold), policy.Threshold)
+	require.Equal(t, DefaultDelay, policy.Delay)
+	require.Equal(t, DefaultDelay, policy.MaxDelay, "the maximum is never below the first delay")
+	require.Equal(t, int32(DefaultClientIPThreshold), limiter.policies[util.ClientIPLockout].Threshold)
+}
+
+func TestLimiter(t *testing.T) {
+	store := memorydb.NewStore()
+	limiter := NewLimiter(util.Config{
+		LoginUsernameThreshold: 2,
+		LoginClientIPThreshold: 3,
+		LoginLockoutDelay:      time.Minute,
+	})
+
+	username := util.RandomOwner()
+	reserve := func(username string, clientIP string) (Attempt, time.Duration) {
+		attempt, wait, err := limiter.Reserve(context.Background(), store, username, clientIP)
+		require.NoError(t, err)
+		return attempt, wait
+	}
+	failedAttempts := func(scope string, identifier string) int32 {
+		lockout, err := store.GetLoginLockout(context.Background(), db.GetLoginLockoutParams{
+			Scope:      scope,
+			Identifier: identifier,
+		})
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return 0
+		}
+		require.NoError(t, err)
+		return lockout.FailedAttempts
+	}
+
+	_, wait := reserve(username, "10.0.0.1")
+	require.Zero(t, wait)
+	_, wait = reserve(username, "10.0.0.2")
+	require.Zero(t, wait, "the attempt that reaches the threshold is still checked")
+
+	_, wait = reserve(username, "10.0.0.3")
+	require.InDelta(t, time.Minute, wait, float64(time.Second), "the username is locked from any ip")
+	require.Zero(t, failedAttempts(util.ClientIPLockout, "10.0.0.3"), "refused attempts are not counted")
+	_, wait = reserve(util.RandomOwner(), "10.0.0.2")
+	require.Zero(t, wait)
+
+	// a success clears the username but not the ip
+	require.NoError(t, limiter.Succeed(context.Background(), store, username))
+	_, wait = reserve(username, "10.0.0.3")
+	require.Zero(t, wait)
+	require.Equal(t, int32(2), failedAttempts(util.ClientIPLockout, "10.0.0.2"))
+
+	for i := 0; i < 3; i++ {
+		_, wait = reserve(util.RandomOwner(), "10.0.0.4")
+		require.Zero(t, wait)
+	}
+	_, wait = reserve(username, "10.0.0.4")
+	require.InDelta(t, time.Minute, wait, float64(time.Second), "the ip is locked for any username")
+
+	require.NoError(t, limiter.Succeed(context.Background(), store, username))
+	_, wait = reserve(username, "10.0.0.4")
+	require.NotZero(t, wait)
+	require.Equal(t, int32(3), failedAttempts(util.ClientIPLockout, "10.0.0.4"))
+
+	// attempts with the right password are taken back, along with the lockouts they started
+	other := util.RandomOwner()
+	reserve(other, "10.0.0.5")
+	attempt, wait := reserve(other, "10.0.0.5")
+	require.Zero(t, wait)
+	require.NoError(t, limiter.Refund(context.Background(), store, attempt))
+	require.Equal(t, int32(1), failedAttempts(util.UsernameLockout, other))
+	require.Equal(t, int32(1), failedAttempts(util.ClientIPLockout, "10.0.0.5"))
+	_, wait = reserve(other, "10.0.0.5")
+	require.Zero(t, wait)
+}
+
+func TestLimiterParallelAttempts(t *testing.T) {
+	store := memorydb.NewStore()
+	limiter := NewLimiter(util.Config{
+		LoginUsernameThreshold: 3,
+		LoginLockoutDelay:      time.Minute,
+	})
+	username := util.RandomOwner()
+
+	n := 20
+	waits := make(chan time.Duration)
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, wait, err := limiter.Reserve(context.Background(), store, username, util.RandomString(8))
+			errs <- err
+			waits <- wait
+		}()
+	}
+
+	allowed := 0
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		if <-waits == 0 {
+			allowed++
+		}
+	}
+	require.Equal(t, 3, allowed, "no more attempts than the threshold get through a burst")
+}
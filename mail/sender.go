@@ -0,0 +1,89 @@
+// Package mail sends emails to users through an SMTP server.
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Sender sends emails to users
+type Sender interface {
+	SendEmail(to []string, subject string, content string) error
+}
+
+// SMTPSender sends plain text emails through an SMTP server.
+// In development it points to a local stand-in that catches every email, such as mailpit.
+type SMTPSender struct {
+	address  string
+	from     string
+	username string
+	password string
+}
+
+var _ Sender = (*SMTPSender)(nil)
+
+// NewSMTPSender creates a sender for the SMTP server at the address.
+// Without a username it sends without authenticating.
+func NewSMTPSender(address string, from string, username string, password string) *SMTPSender {
+	return &SMTPSender{
+		address:  address,
+		from:     from,
+		username: username,
+		password: password,
+	}
+}
+
+// SendEmail sends the email to every recipient
+func (sender *SMTPSender) SendEmail(to []string, subject string, content string) error {
+	if len(to) == 0 {
+		return errors.New("email has no recipient")
+	}
+
+	message, err := newMessage(sender.from, to, subject, content)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if sender.username != "" {
+		host, _, err := net.SplitHostPort(sender.address)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address %q: %w", sender.address, err)
+		}
+		auth = smtp.PlainAuth("", sender.username, sender.password, host)
+	}
+
+	if err := smtp.SendMail(sender.address, auth, sender.from, to, message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// newMessage formats the email with its headers, refusing header values
+// that would inject headers of their own
+func newMessage(from string, to []string, subject string, content string) ([]byte, error) {
+	for _, value := range append([]string{from, subject}, to...) {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.New("email header contains a line break")
+		}
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	message.WriteString(strings.ReplaceAll(content, "\n", "\r\n"))
+	return message.Bytes(), nil
+}
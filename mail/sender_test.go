@@ -0,0 +1,133 @@
+package mail
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStandIn is a local SMTP server that accepts every email and keeps it
+type smtpStandIn struct {
+	listener net.Listener
+	received chan receivedEmail
+}
+
+type receivedEmail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	standIn := &smtpStandIn{
+		listener: listener,
+		received: make(chan receivedEmail, 10),
+	}
+	go standIn.serve()
+	return standIn
+}
+
+func (standIn *smtpStandIn) address() string {
+	return standIn.listener.Addr().String()
+}
+
+func (standIn *smtpStandIn) serve() {
+	for {
+		conn, err := standIn.listener.Accept()
+		if err != nil {
+			return
+		}
+		go standIn.handle(conn)
+	}
+}
+
+func (standIn *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	var email receivedEmail
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			email.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			email.to = append(email.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			email.data = data.String()
+			standIn.received <- email
+			email = receivedEmail{}
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	standIn := newSMTPStandIn(t)
+	sender := NewSMTPSender(standIn.address(), "no-reply@simplebank.local", "", "")
+
+	err := sender.SendEmail([]string{"alice@email.com", "bob@email.com"}, "Vérifiez votre email", "Hello,\nfollow the link.")
+	require.NoError(t, err)
+
+	email := <-standIn.received
+	require.Equal(t, "no-reply@simplebank.local", email.from)
+	require.Equal(t, []string{"alice@email.com", "bob@email.com"}, email.to)
+	require.Contains(t, email.data, "From: no-reply@simplebank.local\r\n")
+	require.Contains(t, email.data, "To: alice@email.com, bob@email.com\r\n")
+	require.Contains(t, email.data, "Subject: =?utf-8?q?V=C3=A9rifiez_votre_email?=\r\n")
+	require.Contains(t, email.data, "Content-Type: text/plain; charset=utf-8\r\n")
+	require.True(t, strings.HasSuffix(email.data, "\r\n\r\nHello,\r\nfollow the link.\r\n"))
+}
+
+func TestSMTPSenderErrors(t *testing.T) {
+	standIn := newSMTPStandIn(t)
+	sender := NewSMTPSender(standIn.address(), "no-reply@simplebank.local", "", "")
+
+	err := sender.SendEmail(nil, "subject", "content")
+	require.EqualError(t, err, "email has no recipient")
+
+	err = sender.SendEmail([]string{"alice@email.com"}, "subject\r\nBcc: eve@email.com", "content")
+	require.EqualError(t, err, "email header contains a line break")
+
+	sender = NewSMTPSender("127.0.0.1:1", "no-reply@simplebank.local", "", "")
+	err = sender.SendEmail([]string{"alice@email.com"}, "subject", "content")
+	require.ErrorContains(t, err, "failed to send email")
+}
@@ -2,31 +2,77 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"time"
 
 	"github.com/foyez/simplebank/api"
+	memorydb "github.com/foyez/simplebank/db/memory"
 	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/lockout"
+	"github.com/foyez/simplebank/mail"
+	"github.com/foyez/simplebank/ratelimit"
+	"github.com/foyez/simplebank/screening"
 	"github.com/foyez/simplebank/util"
+	"github.com/foyez/simplebank/worker"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var useMemoryStore = flag.Bool("memory", false, "run against an in-memory store instead of the database")
+
+// usage: main [-memory] [create_partitions | archive_partitions | expire_money_requests | reject_expired_approvals | screen_users | purge_login_lockouts | purge_rate_limits]
+
 func main() {
+	flag.Parse()
+
 	config, err := util.LoadConfig(".")
 	if err != nil {
 		log.Fatal("cannot load config: ", err)
 	}
 
-	connPool, err := pgxpool.New(context.Background(), config.DBSource)
-	if err != nil {
-		log.Fatal("cannot connect to db: ", err)
+	switch flag.Arg(0) {
+	case "create_partitions":
+		runCreatePartitions(config)
+		return
+	case "archive_partitions":
+		runArchivePartitions(config)
+		return
+	case "expire_money_requests":
+		runExpireMoneyRequests(config)
+		return
+	case "reject_expired_approvals":
+		runRejectExpiredApprovals(config)
+		return
+	case "screen_users":
+		runScreenUsers(config)
+		return
+	case "purge_login_lockouts":
+		runPurgeLoginLockouts(config)
+		return
+	case "purge_rate_limits":
+		runPurgeRateLimits(config)
+		return
 	}
 
-	runDBMigration(config.MigrationURL, config.DBSource)
+	var store db.Store
+	if *useMemoryStore {
+		log.Println("using in-memory store, data is lost on exit")
+		store = memorydb.NewStore()
+	} else {
+		store = newSQLStore(config)
+	}
+
+	mailer := mail.NewSMTPSender(config.SMTPAddress, config.EmailSenderAddress, config.SMTPUsername, config.SMTPPassword)
+	jobWorker, err := worker.NewWorker(store, mailer, config)
+	if err != nil {
+		log.Fatal("cannot create worker: ", err)
+	}
+	go jobWorker.Start(context.Background())
 
-	store := db.NewStore(connPool)
 	server, err := api.NewServer(config, store)
 
 	if err != nil {
@@ -39,6 +85,167 @@ func main() {
 	}
 }
 
+func newSQLStore(config util.Config) db.Store {
+	connPool := connectDB(config)
+
+	var replicaPools []*pgxpool.Pool
+	for _, replicaSource := range config.DBReplicaSources {
+		replicaPool, err := pgxpool.New(context.Background(), replicaSource)
+		if err != nil {
+			log.Fatal("cannot connect to replica db: ", err)
+		}
+		replicaPools = append(replicaPools, replicaPool)
+	}
+
+	createPartitions(db.NewPartitionManager(connPool), config)
+
+	return db.NewStore(connPool, replicaPools...)
+}
+
+// connectDB connects to the primary database and brings its schema up to date
+func connectDB(config util.Config) *pgxpool.Pool {
+	connPool, err := pgxpool.New(context.Background(), config.DBSource)
+	if err != nil {
+		log.Fatal("cannot connect to db: ", err)
+	}
+
+	runDBMigration(config.MigrationURL, config.DBSource)
+
+	return connPool
+}
+
+func createPartitions(manager *db.PartitionManager, config util.Config) {
+	created, err := manager.CreatePartitions(context.Background(), time.Now(), config.PartitionPremakeMonths)
+	if err != nil {
+		log.Fatal("cannot create partitions: ", err)
+	}
+
+	for _, name := range created {
+		log.Printf("created partition %s", name)
+	}
+}
+
+// runCreatePartitions creates the partitions of the coming months, it is meant to run from cron
+func runCreatePartitions(config util.Config) {
+	createPartitions(db.NewPartitionManager(connectDB(config)), config)
+	log.Println("partitions are up to date")
+}
+
+// runArchivePartitions moves the partitions older than the retention window to the archive tables,
+// with a copy in archive files
+func runArchivePartitions(config util.Config) {
+	if config.ArchiveRetention <= 0 {
+		log.Fatal("ARCHIVE_RETENTION must be positive")
+	}
+
+	manager := db.NewPartitionManager(connectDB(config))
+	before := time.Now().Add(-config.ArchiveRetention)
+
+	restored, err := manager.RestoreArchives(context.Background())
+	for _, partition := range restored {
+		log.Printf("restored %s: %d rows from %s", partition.TableName, partition.RowCount, partition.FilePath)
+	}
+	if err != nil {
+		log.Fatal("cannot restore archived partitions: ", err)
+	}
+
+	archived, err := manager.ArchivePartitions(context.Background(), config.ArchiveDir, before)
+	for _, partition := range archived {
+		log.Printf("archived %s: %d rows to %s", partition.TableName, partition.RowCount, partition.FilePath)
+	}
+	if err != nil {
+		log.Fatal("cannot archive partitions: ", err)
+	}
+}
+
+// runExpireMoneyRequests marks the pending money requests past their expiry as expired, it is meant to run from cron
+func runExpireMoneyRequests(config util.Config) {
+	store := db.NewStore(connectDB(config))
+
+	expired, err := store.ExpireMoneyRequests(context.Background())
+	if err != nil {
+		log.Fatal("cannot expire money requests: ", err)
+	}
+
+	log.Printf("expired %d money requests", expired)
+}
+
+// runRejectExpiredApprovals rejects the operations no banker reviewed before their timeout, it is meant to run from cron
+func runRejectExpiredApprovals(config util.Config) {
+	store := db.NewStore(connectDB(config))
+
+	rejected, err := store.RejectExpiredApprovals(context.Background())
+	if err != nil {
+		log.Fatal("cannot reject expired approvals: ", err)
+	}
+
+	log.Printf("rejected %d expired approvals", rejected)
+}
+
+// runScreenUsers screens the names of all users against the sanctions list. Users keep the hits
+// they already have, so it only records the matches of entries new to the list.
+func runScreenUsers(config util.Config) {
+	screener, err := screening.LoadScreener(config.SanctionsList, config.ScreeningThreshold)
+	if err != nil {
+		log.Fatal("cannot load sanctions list: ", err)
+	}
+
+	store := db.NewStore(connectDB(config))
+
+	var created int64
+	arg := db.ListUsersAfterParams{Limit: 100}
+	for {
+		users, err := store.ListUsersAfter(context.Background(), arg)
+		if err != nil {
+			log.Fatal("cannot list users: ", err)
+		}
+
+		for _, user := range users {
+			hits, err := screener.ScreenUser(context.Background(), store, user)
+			if err != nil {
+				log.Fatal("cannot screen user: ", err)
+			}
+			created += hits
+		}
+
+		if len(users) < int(arg.Limit) {
+			break
+		}
+		last := users[len(users)-1]
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: last.CreatedAt, Valid: true}
+		arg.CursorUsername = pgtype.Text{String: last.Username, Valid: true}
+	}
+
+	log.Printf("recorded %d new screening hits", created)
+}
+
+// runPurgeLoginLockouts deletes the lockouts whose failures are older than the window and no longer lock out,
+// it is meant to run from cron
+func runPurgeLoginLockouts(config util.Config) {
+	store := db.NewStore(connectDB(config))
+
+	failedBefore := time.Now().Add(-lockout.NewLimiter(config).Window())
+	purged, err := store.DeleteStaleLoginLockouts(context.Background(), failedBefore)
+	if err != nil {
+		log.Fatal("cannot purge login lockouts: ", err)
+	}
+
+	log.Printf("purged %d login lockouts", purged)
+}
+
+// runPurgeRateLimits deletes the counts of the windows that are over, it is meant to run from cron
+func runPurgeRateLimits(config util.Config) {
+	store := db.NewStore(connectDB(config))
+
+	startedBefore := time.Now().Add(-ratelimit.NewLimiter(config).Window())
+	purged, err := store.DeleteStaleRateLimits(context.Background(), startedBefore)
+	if err != nil {
+		log.Fatal("cannot purge rate limits: ", err)
+	}
+
+	log.Printf("purged %d rate limits", purged)
+}
+
 func runDBMigration(migrationURL string, dbSource string) {
 	migration, err := migrate.New(migrationURL, dbSource)
 	if err != nil {
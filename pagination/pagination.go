@@ -0,0 +1,124 @@
+// Package pagination implements keyset pagination with opaque cursors.
+//
+// A listing is ordered by (sort key, id), newest first. A cursor holds the position of
+// the row a page starts after, and is handed to clients as a signed base64 token so that
+// they can neither forge positions nor replay a cursor against another listing.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const minSecretKeySize = 32
+
+// ErrInvalidCursor is returned when a cursor token was not issued for the listing
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the keyset position of a row in a listing
+type Cursor struct {
+	SortKey time.Time `json:"k"`
+	ID      string    `json:"i"`
+	// Value is the sort key of listings ordered by another column than a time, such as searches
+	Value string `json:"v,omitempty"`
+	// Backward cursors select the rows before the position instead of the ones after it
+	Backward bool `json:"b,omitempty"`
+}
+
+// Codec turns cursors into opaque tokens and back
+type Codec struct {
+	secretKey []byte
+}
+
+// NewCodec creates a new Codec signing tokens with the secret key
+func NewCodec(secretKey string) (*Codec, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+
+	return &Codec{
+		secretKey: []byte(secretKey),
+	}, nil
+}
+
+// Encode returns the token of a cursor of the listing identified by scope
+func (codec *Codec) Encode(scope string, cursor Cursor) string {
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(append(codec.sign(scope, payload), payload...))
+}
+
+// Decode returns the cursor of a token issued by Encode for the same scope
+func (codec *Codec) Decode(scope string, token string) (Cursor, error) {
+	var cursor Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < sha256.Size {
+		return cursor, ErrInvalidCursor
+	}
+
+	signature, payload := data[:sha256.Size], data[sha256.Size:]
+	if !hmac.Equal(signature, codec.sign(scope, payload)) {
+		return cursor, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func (codec *Codec) sign(scope string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, codec.secretKey)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Page is a page of a listing with the cursors of the pages around it
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+	Prev  *Cursor
+}
+
+// NewPage builds a page out of rows fetched with a limit of one more than the page size,
+// newest first from a forward cursor, or oldest first from a backward one.
+// The extra row only tells whether the listing goes on past the page.
+func NewPage[T any](rows []T, size int, cursor *Cursor, position func(T) Cursor) Page[T] {
+	hasMore := len(rows) > size
+	if hasMore {
+		rows = rows[:size]
+	}
+
+	backward := cursor != nil && cursor.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := Page[T]{
+		Items: rows,
+	}
+	if len(rows) == 0 {
+		return page
+	}
+
+	if hasMore || backward {
+		next := position(rows[len(rows)-1])
+		page.Next = &next
+	}
+	if (hasMore && backward) || (!backward && cursor != nil) {
+		prev := position(rows[0])
+		prev.Backward = true
+		page.Prev = &prev
+	}
+
+	return page
+}
@@ -0,0 +1,133 @@
+package pagination
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	codec, err := NewCodec(util.RandomString(32))
+	require.NoError(t, err)
+
+	cursor := Cursor{
+		SortKey:  time.Now().Truncate(time.Microsecond),
+		ID:       "42",
+		Backward: true,
+	}
+
+	token := codec.Encode("accounts:alice", cursor)
+	require.NotEmpty(t, token)
+
+	decoded, err := codec.Decode("accounts:alice", token)
+	require.NoError(t, err)
+	require.True(t, cursor.SortKey.Equal(decoded.SortKey))
+	require.Equal(t, cursor.ID, decoded.ID)
+	require.Equal(t, cursor.Backward, decoded.Backward)
+
+	_, err = codec.Decode("accounts:bob", token)
+	require.ErrorIs(t, err, ErrInvalidCursor, "cursors must be bound to their listing")
+
+	tampered := []byte(token)
+	tampered[len(tampered)-2] ^= 1
+	_, err = codec.Decode("accounts:alice", string(tampered))
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	otherCodec, err := NewCodec(util.RandomString(32))
+	require.NoError(t, err)
+	_, err = otherCodec.Decode("accounts:alice", token)
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	for _, token := range []string{"", "!!!", "c2hvcnQ"} {
+		_, err = codec.Decode("accounts:alice", token)
+		require.ErrorIs(t, err, ErrInvalidCursor)
+	}
+}
+
+func TestNewCodecInvalidKeySize(t *testing.T) {
+	_, err := NewCodec(util.RandomString(31))
+	require.Error(t, err)
+}
+
+func TestNewPage(t *testing.T) {
+	position := func(id int) Cursor {
+		return Cursor{ID: strconv.Itoa(id)}
+	}
+	backward := func(id int) *Cursor {
+		return &Cursor{ID: strconv.Itoa(id), Backward: true}
+	}
+	forward := func(id int) *Cursor {
+		return &Cursor{ID: strconv.Itoa(id)}
+	}
+
+	testCases := []struct {
+		name   string
+		rows   []int
+		cursor *Cursor
+		items  []int
+		next   *Cursor
+		prev   *Cursor
+	}{
+		{
+			name:  "FirstPage",
+			rows:  []int{9, 8, 7},
+			items: []int{9, 8},
+			next:  forward(8),
+		},
+		{
+			name:  "OnlyPage",
+			rows:  []int{9, 8},
+			items: []int{9, 8},
+		},
+		{
+			name:   "MiddlePage",
+			rows:   []int{7, 6, 5},
+			cursor: forward(8),
+			items:  []int{7, 6},
+			next:   forward(6),
+			prev:   backward(7),
+		},
+		{
+			name:   "LastPage",
+			rows:   []int{5},
+			cursor: forward(6),
+			items:  []int{5},
+			prev:   backward(5),
+		},
+		{
+			name:   "BackwardPage",
+			rows:   []int{6, 7, 8},
+			cursor: backward(5),
+			items:  []int{7, 6},
+			next:   forward(6),
+			prev:   backward(7),
+		},
+		{
+			name:   "BackwardToFirstPage",
+			rows:   []int{8, 9},
+			cursor: backward(7),
+			items:  []int{9, 8},
+			next:   forward(8),
+		},
+		{
+			name:   "Empty",
+			rows:   []int{},
+			cursor: forward(1),
+			items:  []int{},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			page := NewPage(tc.rows, 2, tc.cursor, position)
+			require.Equal(t, tc.items, page.Items)
+			require.Equal(t, tc.next, page.Next)
+			require.Equal(t, tc.prev, page.Prev)
+		})
+	}
+}
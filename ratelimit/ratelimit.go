@@ -0,0 +1,76 @@
+// Package ratelimit caps how many requests a user, an email or a client ip may make
+// of the kinds that can be abused, such as looking up who holds an account.
+//
+// Each scope allows a limit of requests per window. Requests are counted in the store,
+// so that the limit holds across servers, and counted before they are served, so that
+// a burst of parallel requests cannot all get in before the first is counted.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+)
+
+// Default settings of the limiter, for those the config leaves out
+const (
+	DefaultWindow                 = time.Hour
+	DefaultPayeeConfirmationLimit = 30
+	// DefaultDirectoryLookupLimit is higher than the payee one, as every payment looks up its recipient
+	DefaultDirectoryLookupLimit = 60
+	// DefaultForgotPasswordEmailLimit caps the reset links sent to one inbox
+	DefaultForgotPasswordEmailLimit = 5
+	// DefaultForgotPasswordIPLimit is higher than the email one, as many users may share an ip
+	DefaultForgotPasswordIPLimit = 20
+)
+
+// Limiter counts requests and tells when one has to wait for the next window
+type Limiter struct {
+	limits map[string]int32
+	window time.Duration
+}
+
+// NewLimiter creates a limiter from the config, with the defaults for the settings left out
+func NewLimiter(config util.Config) *Limiter {
+	return &Limiter{
+		limits: map[string]int32{
+			util.PayeeConfirmationRateLimit:   int32(orDefault(config.PayeeConfirmationLimit, DefaultPayeeConfirmationLimit)),
+			util.DirectoryLookupRateLimit:     int32(orDefault(config.DirectoryLookupLimit, DefaultDirectoryLookupLimit)),
+			util.ForgotPasswordEmailRateLimit: int32(orDefault(config.ForgotPasswordEmailLimit, DefaultForgotPasswordEmailLimit)),
+			util.ForgotPasswordIPRateLimit:    int32(orDefault(config.ForgotPasswordIPLimit, DefaultForgotPasswordIPLimit)),
+		},
+		window: orDefault(config.RateLimitWindow, DefaultWindow),
+	}
+}
+
+func orDefault[T int | time.Duration](value T, fallback T) T {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// Window returns how long a count lasts before it starts over
+func (limiter *Limiter) Window() time.Duration {
+	return limiter.window
+}
+
+// Hit counts a request of the identifier in the scope, and returns how long the identifier
+// has to wait when it went over the limit, zero when the request may go ahead
+func (limiter *Limiter) Hit(ctx context.Context, store db.Querier, scope string, identifier string) (time.Duration, error) {
+	rateLimit, err := store.HitRateLimit(ctx, db.HitRateLimitParams{
+		Scope:       scope,
+		Identifier:  identifier,
+		ResetBefore: time.Now().Add(-limiter.window),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if rateLimit.Hits <= limiter.limits[scope] {
+		return 0, nil
+	}
+	return time.Until(rateLimit.WindowStartedAt.Add(limiter.window)), nil
+}
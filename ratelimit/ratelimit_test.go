@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLimiterDefaults(t *testing.T) {
+	limiter := NewLimiter(util.Config{})
+	require.Equal(t, DefaultWindow, limiter.Window())
+	require.Equal(t, int32(DefaultPayeeConfirmationLimit), limiter.limits[util.PayeeConfirmationRateLimit])
+	require.Equal(t, int32(DefaultDirectoryLookupLimit), limiter.limits[util.DirectoryLookupRateLimit])
+}
+
+func TestLimiter(t *testing.T) {
+	store := memorydb.NewStore()
+	limiter := NewLimiter(util.Config{
+		RateLimitWindow:        time.Minute,
+		PayeeConfirmationLimit: 2,
+	})
+
+	hit := func(identifier string) time.Duration {
+		wait, err := limiter.Hit(context.Background(), store, util.PayeeConfirmationRateLimit, identifier)
+		require.NoError(t, err)
+		return wait
+	}
+
+	username := util.RandomOwner()
+	require.Zero(t, hit(username))
+	require.Zero(t, hit(username))
+
+	wait := hit(username)
+	require.Greater(t, wait, 59*time.Second)
+	require.LessOrEqual(t, wait, time.Minute)
+
+	require.Zero(t, hit(util.RandomOwner()), "identifiers are counted apart")
+}
+
+func TestLimiterConcurrentHits(t *testing.T) {
+	store := memorydb.NewStore()
+	limiter := NewLimiter(util.Config{PayeeConfirmationLimit: 5})
+	username := util.RandomOwner()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, err := limiter.Hit(context.Background(), store, util.PayeeConfirmationRateLimit, username)
+			require.NoError(t, err)
+
+			if wait == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 5, allowed, "a burst gets no more requests than the limit")
+}
@@ -0,0 +1,152 @@
+// Package risk assesses transfers against configurable fraud rules before they execute.
+//
+// A rule reads "name:outcome key=value ...", e.g. "velocity:review count=10 window=1h".
+// Each rule looks at one signal of the transfer and, when it fires, asks for its outcome:
+// review parks the transfer for a banker to decide, block refuses it, and allow only records
+// that the rule fired. The transfer gets the most severe outcome of the rules that fired.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+)
+
+// DefaultRules are the rules transfers are assessed against when none are configured
+const DefaultRules = "new_payee_amount:review amount=50000;" +
+	"velocity:review count=10 window=1h;" +
+	"new_client_ip:review age=24h;" +
+	"round_amount_burst:review count=3 window=1h unit=10000"
+
+// Transfer is what the rules know about the transfer being assessed
+type Transfer struct {
+	Username      string
+	ClientIP      string
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+}
+
+// Rule looks at one signal of a transfer
+type Rule interface {
+	// Check returns why the rule fires on the transfer, or an empty reason when it does not
+	Check(ctx context.Context, store db.Querier, transfer Transfer) (reason string, err error)
+}
+
+// Factory creates a rule from the parameters it is configured with
+type Factory func(params Params) (Rule, error)
+
+var factories = map[string]Factory{
+	"new_payee_amount":   newNewPayeeAmountRule,
+	"velocity":           newVelocityRule,
+	"new_client_ip":      newNewClientIPRule,
+	"round_amount_burst": newRoundAmountBurstRule,
+}
+
+// Register makes a rule available to specs under the name.
+// It is meant to be called from init functions, before any engine is created.
+func Register(name string, factory Factory) {
+	factories[name] = factory
+}
+
+// Hit is a rule that fired on a transfer
+type Hit struct {
+	Rule    string `json:"rule"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason"`
+}
+
+// Assessment is the outcome of the rules on a transfer
+type Assessment struct {
+	Outcome string `json:"outcome"`
+	Hits    []Hit  `json:"hits"`
+}
+
+type configuredRule struct {
+	name    string
+	outcome string
+	rule    Rule
+}
+
+// Engine assesses transfers against the configured rules
+type Engine struct {
+	rules []configuredRule
+}
+
+// NewEngine creates an engine from a spec such as
+// "velocity:review count=10 window=1h;new_client_ip:block"
+func NewEngine(spec string) (*Engine, error) {
+	engine := &Engine{}
+
+	for _, entry := range strings.Split(spec, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name, outcome, ok := strings.Cut(fields[0], ":")
+		if !ok || !util.IsSupportedRiskOutcome(outcome) {
+			return nil, fmt.Errorf("invalid risk rule %q", strings.TrimSpace(entry))
+		}
+		factory, ok := factories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown risk rule %s", name)
+		}
+
+		params, err := parseParams(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid risk rule %s: %w", name, err)
+		}
+		rule, err := factory(params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid risk rule %s: %w", name, err)
+		}
+		if err := params.unread(); err != nil {
+			return nil, fmt.Errorf("invalid risk rule %s: %w", name, err)
+		}
+
+		engine.rules = append(engine.rules, configuredRule{name: name, outcome: outcome, rule: rule})
+	}
+
+	return engine, nil
+}
+
+// severity orders the outcomes from the least to the most severe
+var severity = map[string]int{
+	util.AllowOutcome:  0,
+	util.ReviewOutcome: 1,
+	util.BlockOutcome:  2,
+}
+
+// Assess checks the transfer against every rule and returns the rules that fired
+// with the most severe outcome they ask for
+func (engine *Engine) Assess(ctx context.Context, store db.Querier, transfer Transfer) (Assessment, error) {
+	assessment := Assessment{
+		Outcome: util.AllowOutcome,
+		Hits:    []Hit{},
+	}
+
+	for _, configured := range engine.rules {
+		reason, err := configured.rule.Check(ctx, store, transfer)
+		if err != nil {
+			return Assessment{}, fmt.Errorf("cannot check risk rule %s: %w", configured.name, err)
+		}
+		if reason == "" {
+			continue
+		}
+
+		assessment.Hits = append(assessment.Hits, Hit{
+			Rule:    configured.name,
+			Outcome: configured.outcome,
+			Reason:  reason,
+		})
+		if severity[configured.outcome] > severity[assessment.Outcome] {
+			assessment.Outcome = configured.outcome
+		}
+	}
+
+	return assessment, nil
+}
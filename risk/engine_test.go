@@ -0,0 +1,104 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedRule fires with the reason on every transfer
+type fixedRule struct {
+	reason string
+	err    error
+}
+
+func (rule fixedRule) Check(ctx context.Context, store db.Querier, transfer Transfer) (string, error) {
+	return rule.reason, rule.err
+}
+
+func init() {
+	Register("fires", func(params Params) (Rule, error) {
+		return fixedRule{reason: "always"}, nil
+	})
+	Register("quiet", func(params Params) (Rule, error) {
+		return fixedRule{}, nil
+	})
+	Register("broken", func(params Params) (Rule, error) {
+		return fixedRule{err: errors.New("store unavailable")}, nil
+	})
+}
+
+func TestNewEngine(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+		ok   bool
+	}{
+		{"Default", DefaultRules, true},
+		{"Empty", " ; ", true},
+		{"Parameters", "velocity:block count=5 window=30m; round_amount_burst:review unit=500", true},
+		{"MissingOutcome", "velocity", false},
+		{"UnknownOutcome", "velocity:hold", false},
+		{"UnknownRule", "moon_phase:block", false},
+		{"UnknownParameter", "velocity:review cuont=5", false},
+		{"InvalidParameter", "velocity:review count", false},
+		{"DuplicateParameter", "velocity:review count=5 count=6", false},
+		{"NegativeCount", "velocity:review count=-1", false},
+		{"InvalidDuration", "new_client_ip:review age=forever", false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewEngine(tc.spec)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestAssess(t *testing.T) {
+	testCases := []struct {
+		name    string
+		spec    string
+		outcome string
+		hits    []Hit
+	}{
+		{"NoRules", "", util.AllowOutcome, []Hit{}},
+		{"Quiet", "quiet:block", util.AllowOutcome, []Hit{}},
+		{"ShadowRule", "fires:allow", util.AllowOutcome, []Hit{
+			{Rule: "fires", Outcome: util.AllowOutcome, Reason: "always"},
+		}},
+		{"MostSevere", "fires:review; fires:block; fires:allow; quiet:block", util.BlockOutcome, []Hit{
+			{Rule: "fires", Outcome: util.ReviewOutcome, Reason: "always"},
+			{Rule: "fires", Outcome: util.BlockOutcome, Reason: "always"},
+			{Rule: "fires", Outcome: util.AllowOutcome, Reason: "always"},
+		}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			engine, err := NewEngine(tc.spec)
+			require.NoError(t, err)
+
+			assessment, err := engine.Assess(context.Background(), nil, Transfer{})
+			require.NoError(t, err)
+			require.Equal(t, Assessment{Outcome: tc.outcome, Hits: tc.hits}, assessment)
+		})
+	}
+
+	engine, err := NewEngine("fires:review; broken:block")
+	require.NoError(t, err)
+	_, err = engine.Assess(context.Background(), nil, Transfer{})
+	require.ErrorContains(t, err, "broken")
+}
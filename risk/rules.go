@@ -0,0 +1,248 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+)
+
+// Params are the key=value parameters a rule is configured with
+type Params struct {
+	values map[string]string
+	read   map[string]bool
+}
+
+func parseParams(fields []string) (Params, error) {
+	params := Params{
+		values: make(map[string]string),
+		read:   make(map[string]bool),
+	}
+
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" || value == "" {
+			return Params{}, fmt.Errorf("invalid parameter %q", field)
+		}
+		if _, ok := params.values[key]; ok {
+			return Params{}, fmt.Errorf("duplicate parameter %s", key)
+		}
+		params.values[key] = value
+	}
+
+	return params, nil
+}
+
+// Int returns the positive integer parameter, or the fallback when it is not set
+func (params Params) Int(key string, fallback int64) (int64, error) {
+	value, ok := params.lookup(key)
+	if !ok {
+		return fallback, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("parameter %s must be a positive integer", key)
+	}
+	return n, nil
+}
+
+// Duration returns the positive duration parameter, or the fallback when it is not set
+func (params Params) Duration(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := params.lookup(key)
+	if !ok {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("parameter %s must be a positive duration", key)
+	}
+	return d, nil
+}
+
+func (params Params) lookup(key string) (string, bool) {
+	params.read[key] = true
+	value, ok := params.values[key]
+	return value, ok
+}
+
+// unread reports the parameters the rule does not know, usually misspelled ones
+func (params Params) unread() error {
+	var keys []string
+	for key := range params.values {
+		if !params.read[key] {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sort.Strings(keys)
+	return fmt.Errorf("unknown parameters %s", strings.Join(keys, ", "))
+}
+
+// newPayeeAmountRule fires on a large transfer to an account the from account never sent money to
+type newPayeeAmountRule struct {
+	amount int64
+}
+
+func newNewPayeeAmountRule(params Params) (Rule, error) {
+	amount, err := params.Int("amount", 50000)
+	if err != nil {
+		return nil, err
+	}
+	return newPayeeAmountRule{amount: amount}, nil
+}
+
+func (rule newPayeeAmountRule) Check(ctx context.Context, store db.Querier, transfer Transfer) (string, error) {
+	if transfer.Amount < rule.amount {
+		return "", nil
+	}
+
+	count, err := store.CountTransfersBetween(ctx, db.CountTransfersBetweenParams{
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+	})
+	if err != nil || count > 0 {
+		return "", err
+	}
+
+	return fmt.Sprintf("first transfer to account %d is %d, at least %d", transfer.ToAccountID, transfer.Amount, rule.amount), nil
+}
+
+// velocityRule fires when the from account sends more transfers within the window than the count
+type velocityRule struct {
+	count  int64
+	window time.Duration
+}
+
+func newVelocityRule(params Params) (Rule, error) {
+	count, err := params.Int("count", 10)
+	if err != nil {
+		return nil, err
+	}
+	window, err := params.Duration("window", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return velocityRule{count: count, window: window}, nil
+}
+
+func (rule velocityRule) Check(ctx context.Context, store db.Querier, transfer Transfer) (string, error) {
+	transfers, err := store.ListTransfersSince(ctx, db.ListTransfersSinceParams{
+		FromAccountID: transfer.FromAccountID,
+		CreatedAt:     time.Now().Add(-rule.window),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// the transfer being assessed counts too
+	sent := int64(len(transfers)) + 1
+	if sent <= rule.count {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%d transfers from account %d within %s, more than %d", sent, transfer.FromAccountID, rule.window, rule.count), nil
+}
+
+// newClientIPRule fires on the first transfer from a client ip the user opened no session from
+// before the age. Sessions opened since do not count, since whoever holds the credentials
+// can open one right before sending money. Neither do transfers that were blocked or are still
+// held, or a fraudster would make the ip usual by retrying.
+type newClientIPRule struct {
+	age time.Duration
+}
+
+func newNewClientIPRule(params Params) (Rule, error) {
+	age, err := params.Duration("age", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return newClientIPRule{age: age}, nil
+}
+
+func (rule newClientIPRule) Check(ctx context.Context, store db.Querier, transfer Transfer) (string, error) {
+	if transfer.ClientIP == "" {
+		return "", nil
+	}
+
+	history, err := store.GetClientIPHistory(ctx, db.GetClientIPHistoryParams{
+		ClientIp: transfer.ClientIP,
+		Username: transfer.Username,
+		Before:   time.Now().Add(-rule.age),
+	})
+	if err != nil {
+		return "", err
+	}
+	// users without older sessions have no usual client ip to compare with
+	if history.Sessions == 0 || history.ClientIpSessions > 0 {
+		return "", nil
+	}
+
+	decisions, err := store.CountRiskDecisionsFromIP(ctx, db.CountRiskDecisionsFromIPParams{
+		Username: transfer.Username,
+		ClientIp: transfer.ClientIP,
+	})
+	if err != nil || decisions > 0 {
+		return "", err
+	}
+
+	return fmt.Sprintf("first transfer from client ip %s, not used in sessions older than %s", transfer.ClientIP, rule.age), nil
+}
+
+// roundAmountBurstRule fires when the from account sends at least count transfers of multiples
+// of the unit within the window
+type roundAmountBurstRule struct {
+	count  int64
+	window time.Duration
+	unit   int64
+}
+
+func newRoundAmountBurstRule(params Params) (Rule, error) {
+	count, err := params.Int("count", 3)
+	if err != nil {
+		return nil, err
+	}
+	window, err := params.Duration("window", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := params.Int("unit", 10000)
+	if err != nil {
+		return nil, err
+	}
+	return roundAmountBurstRule{count: count, window: window, unit: unit}, nil
+}
+
+func (rule roundAmountBurstRule) Check(ctx context.Context, store db.Querier, transfer Transfer) (string, error) {
+	if transfer.Amount%rule.unit != 0 {
+		return "", nil
+	}
+
+	transfers, err := store.ListTransfersSince(ctx, db.ListTransfersSinceParams{
+		FromAccountID: transfer.FromAccountID,
+		CreatedAt:     time.Now().Add(-rule.window),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	round := int64(1)
+	for _, sent := range transfers {
+		if sent.Amount%rule.unit == 0 {
+			round++
+		}
+	}
+	if round < rule.count {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%d transfers of multiples of %d from account %d within %s", round, rule.unit, transfer.FromAccountID, rule.window), nil
+}
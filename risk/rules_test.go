@@ -0,0 +1,179 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type ruleFixture struct {
+	store       *memorydb.Store
+	user        db.User
+	fromAccount db.Account
+	toAccount   db.Account
+}
+
+func newRuleFixture(t *testing.T) ruleFixture {
+	store := memorydb.NewStore()
+
+	var users []db.User
+	for i := 0; i < 2; i++ {
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       util.RandomOwner() + util.RandomString(6),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomString(12) + "@email.com",
+		})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+
+	var accounts []db.Account
+	for _, user := range users {
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  1000000,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	return ruleFixture{
+		store:       store,
+		user:        users[0],
+		fromAccount: accounts[0],
+		toAccount:   accounts[1],
+	}
+}
+
+func (fixture ruleFixture) transfer(amount int64) Transfer {
+	return Transfer{
+		Username:      fixture.user.Username,
+		ClientIP:      "10.0.0.1",
+		FromAccountID: fixture.fromAccount.ID,
+		ToAccountID:   fixture.toAccount.ID,
+		Amount:        amount,
+	}
+}
+
+func (fixture ruleFixture) send(t *testing.T, amount int64) {
+	_, err := fixture.store.CreateTransfer(context.Background(), db.CreateTransferParams{
+		FromAccountID: fixture.fromAccount.ID,
+		ToAccountID:   fixture.toAccount.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+}
+
+func (fixture ruleFixture) check(t *testing.T, spec string, transfer Transfer) []Hit {
+	engine, err := NewEngine(spec)
+	require.NoError(t, err)
+
+	assessment, err := engine.Assess(context.Background(), fixture.store, transfer)
+	require.NoError(t, err)
+	return assessment.Hits
+}
+
+func TestNewPayeeAmountRule(t *testing.T) {
+	fixture := newRuleFixture(t)
+	spec := "new_payee_amount:review amount=500"
+
+	require.Empty(t, fixture.check(t, spec, fixture.transfer(499)), "small transfers are not checked")
+	hits := fixture.check(t, spec, fixture.transfer(500))
+	require.Len(t, hits, 1)
+	require.Equal(t, "new_payee_amount", hits[0].Rule)
+
+	fixture.send(t, 10)
+	require.Empty(t, fixture.check(t, spec, fixture.transfer(500)), "the account was paid before")
+}
+
+func TestVelocityRule(t *testing.T) {
+	fixture := newRuleFixture(t)
+	spec := "velocity:block count=3 window=1h"
+
+	for i := 0; i < 2; i++ {
+		fixture.send(t, 10)
+	}
+	require.Empty(t, fixture.check(t, spec, fixture.transfer(10)))
+
+	fixture.send(t, 10)
+	hits := fixture.check(t, spec, fixture.transfer(10))
+	require.Len(t, hits, 1)
+	require.Equal(t, util.BlockOutcome, hits[0].Outcome)
+}
+
+func TestNewClientIPRule(t *testing.T) {
+	fixture := newRuleFixture(t)
+	spec := "new_client_ip:review age=1ns"
+
+	createSession := func(clientIP string) {
+		_, err := fixture.store.CreateSession(context.Background(), db.CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     fixture.user.Username,
+			RefreshToken: util.RandomString(32),
+			ClientIp:     clientIP,
+			ExpiresAt:    time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+
+	require.Empty(t, fixture.check(t, spec, fixture.transfer(10)), "users without sessions have no usual client ip")
+
+	createSession("10.0.0.2")
+	hits := fixture.check(t, spec, fixture.transfer(10))
+	require.Len(t, hits, 1)
+	require.Contains(t, hits[0].Reason, "10.0.0.1")
+
+	decide := func(outcome string) {
+		_, err := fixture.store.CreateRiskDecision(context.Background(), db.CreateRiskDecisionParams{
+			Username:      fixture.user.Username,
+			FromAccountID: fixture.fromAccount.ID,
+			ToAccountID:   fixture.toAccount.ID,
+			Amount:        10,
+			Currency:      util.USD,
+			ClientIp:      "10.0.0.1",
+			Outcome:       outcome,
+		})
+		require.NoError(t, err)
+	}
+
+	decide(util.BlockOutcome)
+	require.Len(t, fixture.check(t, spec, fixture.transfer(10)), 1, "blocked transfers do not make the client ip usual")
+
+	decide(util.AllowOutcome)
+	require.Empty(t, fixture.check(t, spec, fixture.transfer(10)), "only the first transfer from the client ip")
+
+	transfer := fixture.transfer(10)
+	transfer.ClientIP = "10.0.0.3"
+	createSession("10.0.0.3")
+	require.Empty(t, fixture.check(t, spec, transfer), "the client ip was used in an older session")
+
+	transfer.ClientIP = ""
+	require.Empty(t, fixture.check(t, spec, transfer))
+}
+
+func TestRoundAmountBurstRule(t *testing.T) {
+	fixture := newRuleFixture(t)
+	spec := "round_amount_burst:review count=3 window=1h unit=1000"
+
+	fixture.send(t, 1000)
+	fixture.send(t, 1234)
+	fixture.send(t, 5000)
+
+	require.Empty(t, fixture.check(t, spec, fixture.transfer(999)), "the transfer is not round")
+	hits := fixture.check(t, spec, fixture.transfer(2000))
+	require.Len(t, hits, 1)
+	require.Contains(t, hits[0].Reason, "3 transfers")
+
+	fixture = newRuleFixture(t)
+	fixture.send(t, 1000)
+	require.Empty(t, fixture.check(t, spec, fixture.transfer(2000)))
+}
@@ -0,0 +1,146 @@
+// Package screening matches names against a sanctions list loaded from a local file.
+//
+// Lists are read from CSV files with an "id" and a "name" column, and an optional
+// "aliases" column separating aliases with "|", or from XML files of the form
+//
+//	<sanctions>
+//	  <entry id="SDN-1">
+//	    <name>John Doe</name>
+//	    <alias>Johnny Doe</alias>
+//	  </entry>
+//	</sanctions>
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is a sanctioned person of the list
+type Entry struct {
+	ID      string   `xml:"id,attr"`
+	Name    string   `xml:"name"`
+	Aliases []string `xml:"alias"`
+}
+
+// LoadList reads the entries of the list file, in the format of its extension
+func LoadList(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open sanctions list: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		entries, err = ReadCSV(file)
+	case ".xml":
+		entries, err = ReadXML(file)
+	default:
+		return nil, fmt.Errorf("unsupported sanctions list format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read sanctions list %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ReadCSV reads the entries of a CSV list, whose first row names the columns
+func ReadCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	idColumn, hasID := columns["id"]
+	nameColumn, hasName := columns["name"]
+	aliasesColumn, hasAliases := columns["aliases"]
+	if !hasID || !hasName {
+		return nil, errors.New("missing id or name column")
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(column int) string {
+			if column >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[column])
+		}
+
+		entry := Entry{ID: field(idColumn), Name: field(nameColumn)}
+		if hasAliases {
+			for _, alias := range strings.Split(field(aliasesColumn), "|") {
+				if alias = strings.TrimSpace(alias); alias != "" {
+					entry.Aliases = append(entry.Aliases, alias)
+				}
+			}
+		}
+
+		if err := entry.validate(); err != nil {
+			line, _ := reader.FieldPos(idColumn)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ReadXML reads the entries of an XML list
+func ReadXML(r io.Reader) ([]Entry, error) {
+	var list struct {
+		Entries []Entry `xml:"entry"`
+	}
+	if err := xml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Entries {
+		entry := &list.Entries[i]
+		entry.ID = strings.TrimSpace(entry.ID)
+		entry.Name = strings.TrimSpace(entry.Name)
+		for j := range entry.Aliases {
+			entry.Aliases[j] = strings.TrimSpace(entry.Aliases[j])
+		}
+
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+	}
+
+	return list.Entries, nil
+}
+
+func (entry Entry) validate() error {
+	if entry.ID == "" {
+		return errors.New("entry without id")
+	}
+	if entry.Name == "" {
+		return fmt.Errorf("entry %s without name", entry.ID)
+	}
+	return nil
+}
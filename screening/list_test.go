@@ -0,0 +1,54 @@
+package screening
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testEntries = []Entry{
+	{ID: "SDN-1", Name: "Ivan Petrovich Sidorov", Aliases: []string{"Ivan Sidorov", "I. P. Sidorov"}},
+	{ID: "SDN-2", Name: "José Luis Garcia Marquez"},
+	{ID: "SDN-3", Name: "Mohammed Al-Rashid", Aliases: []string{"Muhammad Alrashid"}},
+}
+
+func TestLoadList(t *testing.T) {
+	for _, path := range []string{"testdata/sanctions.csv", "testdata/sanctions.xml"} {
+		entries, err := LoadList(path)
+		require.NoError(t, err, path)
+		require.Equal(t, testEntries, entries, path)
+	}
+
+	_, err := LoadList("testdata/sanctions.json")
+	require.Error(t, err)
+}
+
+func TestReadCSVInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"MissingName", "id,aliases\nSDN-1,John\n"},
+		{"EntryWithoutID", "id,name\n,John Doe\n"},
+		{"EntryWithoutName", "id,name\nSDN-1,\n"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadCSV(strings.NewReader(tc.data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestReadXMLInvalid(t *testing.T) {
+	_, err := ReadXML(strings.NewReader(`<sanctions><entry><name>John Doe</name></entry></sanctions>`))
+	require.Error(t, err)
+
+	_, err = ReadXML(strings.NewReader(`<sanctions><entry id="SDN-1">`))
+	require.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package screening
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultThreshold is the score from which names match unless SCREENING_THRESHOLD is set
+const DefaultThreshold = 90
+
+// Match is an entry of the list matching a screened name
+type Match struct {
+	EntryID string
+	// ListedName is the name or alias of the entry that matched
+	ListedName string
+	// Score is the similarity of the names from 0 to 100
+	Score int
+}
+
+type listedName struct {
+	entryID string
+	name    string
+	tokens  []string
+}
+
+// Screener matches names against the entries of a list
+type Screener struct {
+	names     []listedName
+	threshold int
+}
+
+// NewScreener creates a screener matching names that score at least the threshold.
+// A screener without entries matches no name.
+func NewScreener(entries []Entry, threshold int) *Screener {
+	screener := &Screener{threshold: threshold}
+
+	for _, entry := range entries {
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			tokens := tokenize(name)
+			if len(tokens) == 0 {
+				continue
+			}
+			screener.names = append(screener.names, listedName{
+				entryID: entry.ID,
+				name:    name,
+				tokens:  tokens,
+			})
+		}
+	}
+
+	return screener
+}
+
+// Screen returns the entries matching the name, with the best scoring name or alias of each
+func (screener *Screener) Screen(name string) []Match {
+	tokens := tokenize(name)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	best := make(map[string]Match)
+	for _, listed := range screener.names {
+		score := similarity(listed.tokens, tokens)
+		if score < screener.threshold || score <= best[listed.entryID].Score {
+			continue
+		}
+		best[listed.entryID] = Match{EntryID: listed.entryID, ListedName: listed.name, Score: score}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].EntryID < matches[j].EntryID
+	})
+	return matches
+}
+
+// foldMarks strips accents, so that "José" is screened as "jose"
+var foldMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// tokenize lowercases the name, strips its accents and punctuation and sorts its words,
+// so that the order of first and last names does not matter
+func tokenize(name string) []string {
+	folded, _, err := transform.String(foldMarks, name)
+	if err != nil {
+		folded = name
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(tokens)
+	return tokens
+}
+
+// similarity scores from 0 to 100 how much the screened name looks like the listed one.
+// It takes the best of comparing the whole names, which tolerates words spaced differently,
+// and of finding every listed word in the screened name, which tolerates extra middle names.
+func similarity(listed []string, screened []string) int {
+	whole := jaroWinkler(strings.Join(listed, " "), strings.Join(screened, " "))
+
+	var words float64
+	for _, token := range listed {
+		var best float64
+		for _, candidate := range screened {
+			if score := jaroWinkler(token, candidate); score > best {
+				best = score
+			}
+		}
+		words += best
+	}
+	words /= float64(len(listed))
+
+	if words > whole {
+		whole = words
+	}
+	return int(whole*100 + 0.5)
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of the strings from 0 to 1
+func jaroWinkler(a string, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 || len(s2) == 0 {
+		if len(s1) == len(s2) {
+			return 1
+		}
+		return 0
+	}
+
+	longest := len(s1)
+	if len(s2) > longest {
+		longest = len(s2)
+	}
+	window := longest/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	var matches int
+	for i := range s1 {
+		for j := i - window; j <= i+window && j < len(s2); j++ {
+			if j < 0 {
+				continue
+			}
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	var transpositions, k int
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[k] {
+			k++
+		}
+		if s1[i] != s2[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	var prefix int
+	for prefix < 4 && prefix < len(s1) && prefix < len(s2) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
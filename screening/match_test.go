@@ -0,0 +1,80 @@
+package screening
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScreen(t *testing.T) {
+	screener := NewScreener(testEntries, DefaultThreshold)
+
+	testCases := []struct {
+		name    string
+		screen  string
+		entryID string
+	}{
+		{"ExactName", "Ivan Petrovich Sidorov", "SDN-1"},
+		{"ExactAlias", "Ivan Sidorov", "SDN-1"},
+		{"SwappedNames", "Sidorov Ivan", "SDN-1"},
+		{"Misspelled", "Ivan Sydorov", "SDN-1"},
+		{"Accents", "Jose Luis Garcia Márquez", "SDN-2"},
+		{"CaseAndPunctuation", "MOHAMMED AL RASHID", "SDN-3"},
+		{"Spacing", "Muhammad Al Rashid", "SDN-3"},
+		{"ExtraMiddleName", "Ivan Ivanovich Sidorov", "SDN-1"},
+		{"OtherPerson", "Alice Johnson", ""},
+		{"SharedFirstName", "Ivan Smith", ""},
+		{"SharedLastName", "Maria Garcia", ""},
+		{"NoLetters", "...", ""},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			matches := screener.Screen(tc.screen)
+			if tc.entryID == "" {
+				require.Empty(t, matches)
+				return
+			}
+
+			require.Len(t, matches, 1)
+			require.Equal(t, tc.entryID, matches[0].EntryID)
+			require.GreaterOrEqual(t, matches[0].Score, DefaultThreshold)
+			require.LessOrEqual(t, matches[0].Score, 100)
+		})
+	}
+}
+
+func TestScreenBestAlias(t *testing.T) {
+	screener := NewScreener(testEntries, DefaultThreshold)
+
+	matches := screener.Screen("Ivan Sidorov")
+	require.Equal(t, []Match{{EntryID: "SDN-1", ListedName: "Ivan Sidorov", Score: 100}}, matches)
+}
+
+func TestScreenWithoutEntries(t *testing.T) {
+	require.Empty(t, NewScreener(nil, DefaultThreshold).Screen("Ivan Sidorov"))
+}
+
+func TestJaroWinkler(t *testing.T) {
+	require.Equal(t, 1.0, jaroWinkler("martha", "martha"))
+	require.InDelta(t, 0.961, jaroWinkler("martha", "marhta"), 0.001)
+	require.InDelta(t, 0.813, jaroWinkler("dixon", "dicksonx"), 0.001)
+	require.Zero(t, jaroWinkler("abc", "xyz"))
+	require.Zero(t, jaroWinkler("", "abc"))
+}
+
+func TestLoadScreener(t *testing.T) {
+	screener, err := LoadScreener("", 0)
+	require.NoError(t, err)
+	require.Empty(t, screener.Screen("Ivan Sidorov"))
+	require.Equal(t, DefaultThreshold, screener.threshold)
+
+	screener, err = LoadScreener("testdata/sanctions.csv", 80)
+	require.NoError(t, err)
+	require.NotEmpty(t, screener.Screen("Ivan Sidorov"))
+
+	_, err = LoadScreener("testdata/missing.csv", 80)
+	require.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package screening
+
+import (
+	"context"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+)
+
+// LoadScreener creates a screener from the list file and the threshold, or the default one when zero.
+// Without a list file the screener matches no name.
+func LoadScreener(path string, threshold int) (*Screener, error) {
+	if threshold == 0 {
+		threshold = DefaultThreshold
+	}
+
+	var entries []Entry
+	if path != "" {
+		var err error
+		entries, err = LoadList(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewScreener(entries, threshold), nil
+}
+
+// Hits returns the hits to record for the entries matching the full name of a user,
+// none when it matches no entry
+func (screener *Screener) Hits(username string, fullName string) []db.CreateScreeningHitParams {
+	var hits []db.CreateScreeningHitParams
+	for _, match := range screener.Screen(fullName) {
+		hits = append(hits, db.CreateScreeningHitParams{
+			Username:     username,
+			ScreenedName: fullName,
+			ListEntryID:  match.EntryID,
+			ListedName:   match.ListedName,
+			Score:        int32(match.Score),
+		})
+	}
+	return hits
+}
+
+// ScreenUser records a hit for every entry matching the full name of the user
+// and returns how many of them are new
+func (screener *Screener) ScreenUser(ctx context.Context, store db.Querier, user db.User) (int64, error) {
+	var created int64
+	for _, hit := range screener.Hits(user.Username, user.FullName) {
+		rows, err := store.CreateScreeningHit(ctx, hit)
+		if err != nil {
+			return created, err
+		}
+		created += rows
+	}
+	return created, nil
+}
@@ -0,0 +1,43 @@
+package screening
+
+import (
+	"context"
+	"testing"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScreenUser(t *testing.T) {
+	store := memorydb.NewStore()
+	screener := NewScreener(testEntries, DefaultThreshold)
+
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(32),
+		FullName:       "Ivan Sidorov",
+		Email:          util.RandomString(12) + "@email.com",
+	})
+	require.NoError(t, err)
+
+	created, err := screener.ScreenUser(context.Background(), store, user)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), created)
+
+	created, err = screener.ScreenUser(context.Background(), store, user)
+	require.NoError(t, err)
+	require.Zero(t, created, "users are screened again without duplicating hits")
+
+	hits, err := store.ListScreeningHitsAfter(context.Background(), db.ListScreeningHitsAfterParams{
+		Username: pgtype.Text{String: user.Username, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, "SDN-1", hits[0].ListEntryID)
+	require.Equal(t, "Ivan Sidorov", hits[0].ListedName)
+	require.Equal(t, int32(100), hits[0].Score)
+}
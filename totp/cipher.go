@@ -0,0 +1,66 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size of the key secrets are encrypted with, an AES-256 key
+const KeySize = 32
+
+// ErrInvalidEncryptedSecret is returned when a secret was not encrypted by the key for its user
+var ErrInvalidEncryptedSecret = errors.New("invalid encrypted secret")
+
+// SecretCipher encrypts the secrets stored for the users, so that a leak of the database
+// alone does not let anyone generate their codes
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher creates a new SecretCipher encrypting with the key
+func NewSecretCipher(key string) (*SecretCipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", KeySize)
+	}
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+// Encrypt returns the secret of a user encrypted for storage. The username is authenticated
+// along with it, so that a secret copied to the row of another user does not decrypt.
+func (c *SecretCipher) Encrypt(username string, secret string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data := c.aead.Seal(nonce, nonce, []byte(secret), []byte(username))
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+// Decrypt returns the secret of a user from its encryption by Encrypt
+func (c *SecretCipher) Decrypt(username string, encrypted string) (string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", ErrInvalidEncryptedSecret
+	}
+
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	secret, err := c.aead.Open(nil, nonce, ciphertext, []byte(username))
+	if err != nil {
+		return "", ErrInvalidEncryptedSecret
+	}
+	return string(secret), nil
+}
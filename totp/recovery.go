@@ -0,0 +1,50 @@
+package totp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+const (
+	// RecoveryCodeCount is how many recovery codes a user gets when enabling two-factor authentication
+	RecoveryCodeCount = 10
+	// recoveryCodeSize is the number of characters of a recovery code, split in two halves
+	recoveryCodeSize = 10
+	// recoveryAlphabet leaves out the characters that are easily mistaken for others
+	recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// GenerateRecoveryCodes generates the single-use codes a user logs in with when their
+// authenticator app is lost, formatted like "abcde-fghjk"
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	data := make([]byte, recoveryCodeSize)
+	for i := range codes {
+		if _, err := rand.Read(data); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		var code strings.Builder
+		for j, b := range data {
+			if j == recoveryCodeSize/2 {
+				code.WriteByte('-')
+			}
+			// the alphabet is short enough that the modulo bias does not matter
+			code.WriteByte(recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+		}
+		codes[i] = code.String()
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode removes the case, spaces and dashes users may type differently,
+// so that the code hashes the same as when it was generated
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
@@ -0,0 +1,122 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the sha1 key of the test vectors of RFC 6238
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeMatchesRFC6238(t *testing.T) {
+	// the RFC lists 8 digit codes, their last 6 digits are the 6 digit codes
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range testCases {
+		code, err := Code(rfcSecret, Step(time.Unix(tc.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code, "at %d", tc.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	now := time.Now()
+	code, err := Code(secret, Step(now))
+	require.NoError(t, err)
+
+	step, ok := Validate(secret, code, now, 0)
+	require.True(t, ok)
+	require.Equal(t, Step(now), step)
+
+	_, ok = Validate(secret, code, now, step)
+	require.False(t, ok, "a code is only used once")
+
+	_, ok = Validate(secret, code, now.Add(Period), 0)
+	require.True(t, ok, "the previous code is still accepted")
+
+	_, ok = Validate(secret, code, now.Add(3*Period), 0)
+	require.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 0)
+	require.False(t, ok)
+
+	_, ok = Validate("not base32!", code, now, 0)
+	require.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("Simple Bank", "alice", rfcSecret))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/Simple Bank:alice", uri.Path)
+	require.Equal(t, rfcSecret, uri.Query().Get("secret"))
+	require.Equal(t, "Simple Bank", uri.Query().Get("issuer"))
+	require.Equal(t, "6", uri.Query().Get("digits"))
+	require.Equal(t, "30", uri.Query().Get("period"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		require.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+
+	require.Equal(t, "abcdefghjk", NormalizeRecoveryCode(" ABCDE-fghjk"))
+}
+
+func TestSecretCipher(t *testing.T) {
+	_, err := NewSecretCipher("too short")
+	require.Error(t, err)
+
+	c, err := NewSecretCipher("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt("alice", rfcSecret)
+	require.NoError(t, err)
+	require.NotContains(t, encrypted, rfcSecret)
+
+	again, err := c.Encrypt("alice", rfcSecret)
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted, again, "every encryption has its own nonce")
+
+	secret, err := c.Decrypt("alice", encrypted)
+	require.NoError(t, err)
+	require.Equal(t, rfcSecret, secret)
+
+	// secrets only decrypt for their user and with their key
+	_, err = c.Decrypt("bob", encrypted)
+	require.ErrorIs(t, err, ErrInvalidEncryptedSecret)
+
+	other, err := NewSecretCipher("abcdefghijklmnopqrstuvwxyzabcdef")
+	require.NoError(t, err)
+	_, err = other.Decrypt("alice", encrypted)
+	require.ErrorIs(t, err, ErrInvalidEncryptedSecret)
+
+	_, err = c.Decrypt("alice", rfcSecret)
+	require.ErrorIs(t, err, ErrInvalidEncryptedSecret)
+}
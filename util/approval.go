@@ -0,0 +1,23 @@
+package util
+
+// Constants for all kinds of operations held for approval
+const (
+	TransferApproval   = "transfer"
+	AdjustmentApproval = "adjustment"
+)
+
+// Constants for all statuses of approvals
+const (
+	PendingApproval  = "pending"
+	ApprovedApproval = "approved"
+	RejectedApproval = "rejected"
+)
+
+// IsSupportedApprovalStatus returns true if the approval status is supported
+func IsSupportedApprovalStatus(status string) bool {
+	switch status {
+	case PendingApproval, ApprovedApproval, RejectedApproval:
+		return true
+	}
+	return false
+}
@@ -8,12 +8,52 @@ import (
 
 // Config stores all configuration of the application.
 type Config struct {
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	MigrationURL         string        `mapstructure:"MIGRATION_URL"`
-	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	DBSource                  string        `mapstructure:"DB_SOURCE"`
+	DBReplicaSources          []string      `mapstructure:"DB_REPLICA_SOURCES"`
+	MigrationURL              string        `mapstructure:"MIGRATION_URL"`
+	ServerAddress             string        `mapstructure:"SERVER_ADDRESS"`
+	TrustedProxies            []string      `mapstructure:"TRUSTED_PROXIES"`
+	TokenSymmetricKey         string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	CursorSymmetricKey        string        `mapstructure:"CURSOR_SYMMETRIC_KEY"`
+	TwoFactorSecretKey        string        `mapstructure:"TWO_FACTOR_SECRET_KEY"`
+	AccessTokenDuration       time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration      time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RequireIfMatch            bool          `mapstructure:"REQUIRE_IF_MATCH"`
+	PartitionPremakeMonths    int           `mapstructure:"PARTITION_PREMAKE_MONTHS"`
+	ArchiveRetention          time.Duration `mapstructure:"ARCHIVE_RETENTION"`
+	ArchiveDir                string        `mapstructure:"ARCHIVE_DIR"`
+	RolePermissions           string        `mapstructure:"ROLE_PERMISSIONS"`
+	TransferApprovalThreshold int64         `mapstructure:"TRANSFER_APPROVAL_THRESHOLD"`
+	ApprovalTimeout           time.Duration `mapstructure:"APPROVAL_TIMEOUT"`
+	RiskRules                 string        `mapstructure:"RISK_RULES"`
+	SanctionsList             string        `mapstructure:"SANCTIONS_LIST"`
+	ScreeningThreshold        int           `mapstructure:"SCREENING_THRESHOLD"`
+	SMTPAddress               string        `mapstructure:"SMTP_ADDRESS"`
+	SMTPUsername              string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword              string        `mapstructure:"SMTP_PASSWORD"`
+	EmailSenderAddress        string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	VerifyEmailURL            string        `mapstructure:"VERIFY_EMAIL_URL"`
+	VerifyEmailDuration       time.Duration `mapstructure:"VERIFY_EMAIL_DURATION"`
+	RequireVerifiedEmail      bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	ResetPasswordURL          string        `mapstructure:"RESET_PASSWORD_URL"`
+	ResetPasswordDuration     time.Duration `mapstructure:"RESET_PASSWORD_DURATION"`
+	LoginUsernameThreshold    int           `mapstructure:"LOGIN_USERNAME_THRESHOLD"`
+	LoginClientIPThreshold    int           `mapstructure:"LOGIN_CLIENT_IP_THRESHOLD"`
+	LoginLockoutDelay         time.Duration `mapstructure:"LOGIN_LOCKOUT_DELAY"`
+	LoginLockoutMaxDelay      time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX_DELAY"`
+	LoginFailureWindow        time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	PasswordScheme            string        `mapstructure:"PASSWORD_SCHEME"`
+	Argon2Memory              uint32        `mapstructure:"ARGON2_MEMORY"`
+	Argon2Time                uint32        `mapstructure:"ARGON2_TIME"`
+	Argon2Parallelism         uint8         `mapstructure:"ARGON2_PARALLELISM"`
+	PasswordMinLength         int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength         int           `mapstructure:"PASSWORD_MAX_LENGTH"`
+	BreachedPasswords         string        `mapstructure:"BREACHED_PASSWORDS"`
+	RateLimitWindow           time.Duration `mapstructure:"RATE_LIMIT_WINDOW"`
+	PayeeConfirmationLimit    int           `mapstructure:"PAYEE_CONFIRMATION_LIMIT"`
+	DirectoryLookupLimit      int           `mapstructure:"DIRECTORY_LOOKUP_LIMIT"`
+	ForgotPasswordEmailLimit  int           `mapstructure:"FORGOT_PASSWORD_EMAIL_LIMIT"`
+	ForgotPasswordIPLimit     int           `mapstructure:"FORGOT_PASSWORD_IP_LIMIT"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
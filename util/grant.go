@@ -0,0 +1,16 @@
+package util
+
+// Constants for all scopes of access grants
+const (
+	ViewScope     = "view"
+	TransferScope = "transfer"
+)
+
+// IsSupportedGrantScope returns true if the access grant scope is supported
+func IsSupportedGrantScope(scope string) bool {
+	switch scope {
+	case ViewScope, TransferScope:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+package util
+
+// Constants for all statuses of background jobs
+const (
+	PendingJob = "pending"
+	RunningJob = "running"
+	DoneJob    = "done"
+	FailedJob  = "failed"
+)
@@ -0,0 +1,16 @@
+package util
+
+// Constants for what failed logins are counted against
+const (
+	UsernameLockout = "username"
+	ClientIPLockout = "client_ip"
+)
+
+// IsSupportedLockoutScope returns true if the login lockout scope is supported
+func IsSupportedLockoutScope(scope string) bool {
+	switch scope {
+	case UsernameLockout, ClientIPLockout:
+		return true
+	}
+	return false
+}
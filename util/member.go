@@ -0,0 +1,26 @@
+package util
+
+// Constants for all permissions of account members, from the weakest to the strongest
+const (
+	ViewPermission     = "view"
+	TransactPermission = "transact"
+	ManagePermission   = "manage"
+)
+
+var memberPermissionRanks = map[string]int{
+	ViewPermission:     1,
+	TransactPermission: 2,
+	ManagePermission:   3,
+}
+
+// IsSupportedMemberPermission returns true if the member permission is supported
+func IsSupportedMemberPermission(permission string) bool {
+	_, ok := memberPermissionRanks[permission]
+	return ok
+}
+
+// MemberPermissionIncludes returns true if the granted permission allows what the required one does
+func MemberPermissionIncludes(granted string, required string) bool {
+	rank, ok := memberPermissionRanks[granted]
+	return ok && rank >= memberPermissionRanks[required]
+}
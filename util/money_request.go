@@ -0,0 +1,19 @@
+package util
+
+// Constants for all statuses of money requests
+const (
+	PendingRequest   = "pending"
+	AcceptedRequest  = "accepted"
+	DeclinedRequest  = "declined"
+	CancelledRequest = "cancelled"
+	ExpiredRequest   = "expired"
+)
+
+// IsSupportedMoneyRequestStatus returns true if the money request status is supported
+func IsSupportedMoneyRequestStatus(status string) bool {
+	switch status {
+	case PendingRequest, AcceptedRequest, DeclinedRequest, CancelledRequest, ExpiredRequest:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskName keeps the initial of every word of a full name and hides the rest,
+// e.g. "John Smith" becomes "J*** S***"
+func MaskName(fullName string) string {
+	words := strings.Fields(fullName)
+	for i, word := range words {
+		initial, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(initial) + "***"
+	}
+	return strings.Join(words, " ")
+}
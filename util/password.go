@@ -1,21 +1,198 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword returns the bcrypt hash of the password
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+// Constants for all supported password hashing schemes
+const (
+	Argon2idScheme = "argon2id"
+	BcryptScheme   = "bcrypt"
+)
+
+// IsSupportedPasswordScheme returns true if the password hashing scheme is supported
+func IsSupportedPasswordScheme(scheme string) bool {
+	switch scheme {
+	case Argon2idScheme, BcryptScheme:
+		return true
+	}
+	return false
+}
+
+// Default Argon2id parameters, the ones OWASP recommends
+const (
+	DefaultArgon2Memory      = 19 * 1024 // KiB
+	DefaultArgon2Time        = 2
+	DefaultArgon2Parallelism = 1
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// ErrMismatchedPassword is returned when the password does not match its hash, whatever the scheme
+var ErrMismatchedPassword = errors.New("password does not match")
+
+// Argon2Params are the costs of an Argon2id hash
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Time        uint32
+	Parallelism uint8
+}
+
+// PasswordHasher hashes passwords with the configured scheme. Hashes record their scheme and costs,
+// so that the ones made before the scheme or the costs changed still verify, and can be told apart.
+type PasswordHasher struct {
+	scheme     string
+	argon2     Argon2Params
+	bcryptCost int
+}
+
+// NewPasswordHasher creates a hasher from the config, Argon2id with the default costs for the settings left out
+func NewPasswordHasher(config Config) (*PasswordHasher, error) {
+	scheme := config.PasswordScheme
+	if scheme == "" {
+		scheme = Argon2idScheme
+	}
+	if !IsSupportedPasswordScheme(scheme) {
+		return nil, fmt.Errorf("unsupported password scheme %q", scheme)
+	}
+
+	hasher := &PasswordHasher{
+		scheme: scheme,
+		argon2: Argon2Params{
+			Memory:      config.Argon2Memory,
+			Time:        config.Argon2Time,
+			Parallelism: config.Argon2Parallelism,
+		},
+		bcryptCost: bcrypt.DefaultCost,
+	}
+	if hasher.argon2.Memory == 0 {
+		hasher.argon2.Memory = DefaultArgon2Memory
+	}
+	if hasher.argon2.Time == 0 {
+		hasher.argon2.Time = DefaultArgon2Time
+	}
+	if hasher.argon2.Parallelism == 0 {
+		hasher.argon2.Parallelism = DefaultArgon2Parallelism
+	}
+	return hasher, nil
+}
+
+var defaultPasswordHasher, _ = NewPasswordHasher(Config{})
+
+// Hash returns the hash of the password in the scheme of the hasher
+func (hasher *PasswordHasher) Hash(password string) (string, error) {
+	if hasher.scheme == BcryptScheme {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), hasher.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hashedPassword), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashedPassword), nil
+	key := argon2.IDKey([]byte(password), salt, hasher.argon2.Time, hasher.argon2.Memory, hasher.argon2.Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		hasher.argon2.Memory,
+		hasher.argon2.Time,
+		hasher.argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
-// CheckPassword checks if the provided password is correct or not
+// NeedsRehash tells if the hash was made with another scheme or other costs than the ones of the hasher
+func (hasher *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	if isBcryptHash(hashedPassword) {
+		if hasher.scheme != BcryptScheme {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		return err != nil || cost != hasher.bcryptCost
+	}
+
+	params, _, _, err := parseArgon2Hash(hashedPassword)
+	return err != nil || hasher.scheme != Argon2idScheme || params != hasher.argon2
+}
+
+// HashPassword returns the hash of the password in the default scheme, Argon2id
+func HashPassword(password string) (string, error) {
+	return defaultPasswordHasher.Hash(password)
+}
+
+// CheckPassword checks if the provided password is correct or not, whichever scheme hashed it
 func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if isBcryptHash(hashedPassword) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedPassword
+		}
+		return err
+	}
+
+	params, salt, key, err := parseArgon2Hash(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func isBcryptHash(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") ||
+		strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+// parseArgon2Hash reads a hash of the form $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+func parseArgon2Hash(hashedPassword string) (params Argon2Params, salt []byte, key []byte, err error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != Argon2idScheme {
+		return params, nil, nil, errors.New("unknown password hash format")
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	if params.Time == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errors.New("invalid argon2id parameters: zero time or parallelism")
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	if len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id key: empty")
+	}
+	return params, salt, key, nil
 }
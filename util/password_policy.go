@@ -0,0 +1,223 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Default lengths of the password policy, the ones NIST recommends
+const (
+	DefaultPasswordMinLength = 8
+	DefaultPasswordMaxLength = 64
+)
+
+// ErrBreachedPassword is returned for a password found in the breached password list
+var ErrBreachedPassword = errors.New("password appeared in a data breach, choose another one")
+
+// ErrBreachedPasswordList is returned when the breached password list cannot be searched
+var ErrBreachedPasswordList = errors.New("cannot search breached password list")
+
+// PasswordPolicy tells which passwords users may choose: long enough, not too long to hash,
+// and not among the breached passwords of a local list.
+//
+// The list holds the SHA-1 hashes of the passwords in hex, one per line and sorted by hash, as in
+// the downloads of Have I Been Pwned ordered by hash. Anything after a ":" on a line, such as a count,
+// is ignored. The list is far too large to hold in memory, so it is bisected on disk instead.
+type PasswordPolicy struct {
+	minLength int
+	maxLength int
+	breached  *breachedList
+}
+
+// LoadPasswordPolicy creates a policy from the config, with the default lengths for the settings left out.
+// Without a breached password list the policy only checks lengths.
+func LoadPasswordPolicy(config Config) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		minLength: config.PasswordMinLength,
+		maxLength: config.PasswordMaxLength,
+	}
+	if policy.minLength <= 0 {
+		policy.minLength = DefaultPasswordMinLength
+	}
+	if policy.maxLength <= 0 {
+		policy.maxLength = DefaultPasswordMaxLength
+	}
+	if policy.maxLength < policy.minLength {
+		return nil, fmt.Errorf("password max length %d is below the min length %d", policy.maxLength, policy.minLength)
+	}
+
+	if config.BreachedPasswords != "" {
+		breached, err := openBreachedList(config.BreachedPasswords)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+
+	return policy, nil
+}
+
+// Check returns why the password may not be chosen, nil when it may
+func (policy *PasswordPolicy) Check(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < policy.minLength {
+		return fmt.Errorf("password must have at least %d characters", policy.minLength)
+	}
+	if length > policy.maxLength {
+		return fmt.Errorf("password must have at most %d characters", policy.maxLength)
+	}
+
+	if policy.breached == nil {
+		return nil
+	}
+	breached, err := policy.breached.contains(sha1.Sum([]byte(password)))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrBreachedPasswordList, err)
+	}
+	if breached {
+		return ErrBreachedPassword
+	}
+	return nil
+}
+
+// breachedList is a sorted list of password hashes, searched by bisecting the offsets of its file
+type breachedList struct {
+	file *os.File
+	size int64
+}
+
+// openBreachedList opens the list at path, once it checked that every line holds a hash in order
+func openBreachedList(path string) (*breachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open breached password list: %w", err)
+	}
+
+	if err := checkBreachedList(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot read breached password list %s: %w", path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot read breached password list %s: %w", path, err)
+	}
+	return &breachedList{file: file, size: info.Size()}, nil
+}
+
+// checkBreachedList reads the list through once, as the bisection silently misses hashes out of order
+func checkBreachedList(r io.Reader) error {
+	var previous [sha1.Size]byte
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		hash, ok, err := parseBreachedLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if !ok {
+			continue
+		}
+
+		if bytes.Compare(hash[:], previous[:]) < 0 {
+			return fmt.Errorf("line %d: not sorted by hash", line)
+		}
+		previous = hash
+	}
+	return scanner.Err()
+}
+
+// parseBreachedLine returns the hash of a line of the list, ok false for a blank line
+func parseBreachedLine(line string) (hash [sha1.Size]byte, ok bool, err error) {
+	hexHash, _, _ := strings.Cut(line, ":")
+	hexHash = strings.TrimSpace(hexHash)
+	if hexHash == "" {
+		return hash, false, nil
+	}
+
+	if len(hexHash) != hex.EncodedLen(sha1.Size) {
+		return hash, false, errors.New("not a SHA-1 hash")
+	}
+	if _, err := hex.Decode(hash[:], []byte(hexHash)); err != nil {
+		return hash, false, errors.New("not a SHA-1 hash")
+	}
+	return hash, true, nil
+}
+
+// contains tells whether the hash is in the list. The lines starting before lo hold smaller hashes
+// and the ones starting at or after hi greater hashes, so the hash can only be on a line in between.
+func (list *breachedList) contains(hash [sha1.Size]byte) (bool, error) {
+	lo, hi := int64(0), list.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, end, lineHash, err := list.lineAt(mid)
+		if err != nil {
+			return false, err
+		}
+
+		if start >= hi {
+			// no line starts between mid and hi
+			hi = mid
+			continue
+		}
+
+		switch bytes.Compare(lineHash[:], hash[:]) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = end
+		default:
+			hi = start
+		}
+	}
+	return false, nil
+}
+
+// lineAt returns the hash of the first line that starts at or after the offset, along with the offsets
+// the line starts and ends at. Blank lines are skipped, and start is the size of the list past the last hash.
+func (list *breachedList) lineAt(offset int64) (start int64, end int64, hash [sha1.Size]byte, err error) {
+	start = offset
+	if offset > 0 {
+		// a line starts at the offset only when the byte before it ends the previous line
+		start = offset - 1
+	}
+	reader := bufio.NewReader(io.NewSectionReader(list.file, start, list.size-start))
+
+	if offset > 0 {
+		skipped, err := reader.ReadString('\n')
+		start += int64(len(skipped))
+		if err == io.EOF {
+			return list.size, list.size, hash, nil
+		}
+		if err != nil {
+			return 0, 0, hash, err
+		}
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return 0, 0, hash, err
+		}
+
+		hash, ok, parseErr := parseBreachedLine(line)
+		if parseErr != nil {
+			return 0, 0, hash, fmt.Errorf("offset %d: %w", start, parseErr)
+		}
+		if ok {
+			return start, start + int64(len(line)), hash, nil
+		}
+		if err == io.EOF {
+			return list.size, list.size, hash, nil
+		}
+		start += int64(len(line))
+	}
+}
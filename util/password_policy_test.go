@@ -0,0 +1,81 @@
+package util
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	breached := "password123"
+	hash := sha1.Sum([]byte(breached))
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	list := strings.ToUpper(hex.EncodeToString(hash[:])) + ":2254650\n\n"
+	require.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+
+	policy, err := LoadPasswordPolicy(Config{BreachedPasswords: path})
+	require.NoError(t, err)
+
+	require.NoError(t, policy.Check(RandomString(DefaultPasswordMinLength)))
+	require.NoError(t, policy.Check(strings.Repeat("é", DefaultPasswordMaxLength)), "lengths count characters, not bytes")
+	require.ErrorContains(t, policy.Check(RandomString(DefaultPasswordMinLength-1)), "at least")
+	require.ErrorContains(t, policy.Check(RandomString(DefaultPasswordMaxLength+1)), "at most")
+	require.ErrorIs(t, policy.Check(breached), ErrBreachedPassword)
+	require.NoError(t, policy.Check(strings.ToUpper(breached)))
+}
+
+func TestPasswordPolicyBisectsBreachedList(t *testing.T) {
+	breached := make([]string, 500)
+	lines := make([]string, len(breached))
+	for i := range breached {
+		breached[i] = RandomString(12)
+		hash := sha1.Sum([]byte(breached[i]))
+		// counts of any length and blank lines make for lines of any length
+		lines[i] = fmt.Sprintf("%X:%d\n", hash, RandomInt(1, 1_000_000))
+		if i%7 == 0 {
+			lines[i] += "\n"
+		}
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "")), 0o600))
+
+	policy, err := LoadPasswordPolicy(Config{BreachedPasswords: path})
+	require.NoError(t, err)
+
+	for _, password := range breached {
+		require.ErrorIs(t, policy.Check(password), ErrBreachedPassword, password)
+		require.NoError(t, policy.Check(password+"!"))
+	}
+}
+
+func TestLoadPasswordPolicyErrors(t *testing.T) {
+	_, err := LoadPasswordPolicy(Config{PasswordMinLength: 12, PasswordMaxLength: 10})
+	require.Error(t, err)
+
+	_, err = LoadPasswordPolicy(Config{BreachedPasswords: filepath.Join(t.TempDir(), "missing.txt")})
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("not a hash\n"), 0o600))
+	_, err = LoadPasswordPolicy(Config{BreachedPasswords: path})
+	require.ErrorContains(t, err, "line 1")
+
+	first, second := sha1.Sum([]byte("first")), sha1.Sum([]byte("second"))
+	if bytes.Compare(first[:], second[:]) < 0 {
+		first, second = second, first
+	}
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf("%X\n%X\n", first, second)), 0o600))
+	_, err = LoadPasswordPolicy(Config{BreachedPasswords: path})
+	require.ErrorContains(t, err, "line 2: not sorted")
+}
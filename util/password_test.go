@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -13,16 +14,78 @@ func TestPassword(t *testing.T) {
 	hashedPassword1, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword1)
+	require.True(t, strings.HasPrefix(hashedPassword1, "$argon2id$v=19$m=19456,t=2,p=1$"))
 
 	err = CheckPassword(password, hashedPassword1)
 	require.NoError(t, err)
 
 	wrongPassword := RandomString(7)
 	err = CheckPassword(wrongPassword, hashedPassword1)
-	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+	require.EqualError(t, err, ErrMismatchedPassword.Error())
 
 	hashedPassword2, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword2, hashedPassword1)
 }
+
+func TestCheckBcryptPassword(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	require.NoError(t, CheckPassword(password, string(hashedPassword)))
+	require.ErrorIs(t, CheckPassword(RandomString(7), string(hashedPassword)), ErrMismatchedPassword)
+}
+
+func TestCheckInvalidPasswordHash(t *testing.T) {
+	hashedPassword, err := HashPassword(RandomString(6))
+	require.NoError(t, err)
+
+	for _, invalid := range []string{
+		"",
+		"plain",
+		strings.Replace(hashedPassword, "argon2id", "argon2i", 1),
+		strings.Replace(hashedPassword, "v=19", "v=16", 1),
+		strings.Replace(hashedPassword, "p=1", "p=0", 1),
+		hashedPassword[:strings.LastIndex(hashedPassword, "$")+1],
+	} {
+		err := CheckPassword(RandomString(6), invalid)
+		require.Error(t, err, invalid)
+		require.NotErrorIs(t, err, ErrMismatchedPassword, invalid)
+	}
+}
+
+func TestPasswordHasher(t *testing.T) {
+	_, err := NewPasswordHasher(Config{PasswordScheme: "md5"})
+	require.Error(t, err)
+
+	hasher, err := NewPasswordHasher(Config{Argon2Memory: 8 * 1024, Argon2Time: 1, Argon2Parallelism: 2})
+	require.NoError(t, err)
+
+	password := RandomString(6)
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=8192,t=1,p=2$"))
+	require.NoError(t, CheckPassword(password, hashedPassword))
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+
+	// hashes made with other costs or another scheme are rehashed
+	defaultHashedPassword, err := HashPassword(password)
+	require.NoError(t, err)
+	require.True(t, hasher.NeedsRehash(defaultHashedPassword))
+
+	bcryptHasher, err := NewPasswordHasher(Config{PasswordScheme: BcryptScheme})
+	require.NoError(t, err)
+	bcryptHashedPassword, err := bcryptHasher.Hash(password)
+	require.NoError(t, err)
+	require.NoError(t, CheckPassword(password, bcryptHashedPassword))
+	require.False(t, bcryptHasher.NeedsRehash(bcryptHashedPassword))
+	require.True(t, bcryptHasher.NeedsRehash(hashedPassword))
+	require.True(t, hasher.NeedsRehash(bcryptHashedPassword))
+
+	minCostHashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.True(t, bcryptHasher.NeedsRehash(string(minCostHashedPassword)))
+}
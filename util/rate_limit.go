@@ -0,0 +1,9 @@
+package util
+
+// Constants for the requests counted against a rate limit
+const (
+	PayeeConfirmationRateLimit   = "payee_confirmation"
+	DirectoryLookupRateLimit     = "directory_lookup"
+	ForgotPasswordEmailRateLimit = "forgot_password_email"
+	ForgotPasswordIPRateLimit    = "forgot_password_ip"
+)
@@ -0,0 +1,17 @@
+package util
+
+// Constants for all outcomes of the risk rules on a transfer
+const (
+	AllowOutcome  = "allow"
+	ReviewOutcome = "review"
+	BlockOutcome  = "block"
+)
+
+// IsSupportedRiskOutcome returns true if the risk outcome is supported
+func IsSupportedRiskOutcome(outcome string) bool {
+	switch outcome {
+	case AllowOutcome, ReviewOutcome, BlockOutcome:
+		return true
+	}
+	return false
+}
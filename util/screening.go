@@ -0,0 +1,17 @@
+package util
+
+// Constants for all statuses of screening hits
+const (
+	OpenHit      = "open"
+	ClearedHit   = "cleared"
+	ConfirmedHit = "confirmed"
+)
+
+// IsSupportedScreeningHitStatus returns true if the screening hit status is supported
+func IsSupportedScreeningHitStatus(status string) bool {
+	switch status {
+	case OpenHit, ClearedHit, ConfirmedHit:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// secretSize is the number of random bytes in a secret, enough that it cannot be guessed
+const secretSize = 32
+
+// NewSecret generates a random secret to send to a user, such as the token of a verification link,
+// and the hash to store in its place
+func NewSecret() (secret string, hash string, err error) {
+	data := make([]byte, secretSize)
+	if _, err := rand.Read(data); err != nil {
+		return "", "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	secret = base64.RawURLEncoding.EncodeToString(data)
+	return secret, HashSecret(secret), nil
+}
+
+// HashSecret returns the hash stored for a secret. Secrets are random and long,
+// so a fast hash is enough and lets them be looked up by hash.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	secret1, hash1, err := NewSecret()
+	require.NoError(t, err)
+	require.Len(t, secret1, 43)
+	require.Equal(t, HashSecret(secret1), hash1)
+	require.NotContains(t, hash1, secret1)
+
+	secret2, hash2, err := NewSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret1, secret2)
+	require.NotEqual(t, hash1, hash2)
+}
@@ -0,0 +1,17 @@
+package util
+
+// Constants for all account statuses
+const (
+	ActiveAccount = "active"
+	FrozenAccount = "frozen"
+	ClosedAccount = "closed"
+)
+
+// IsSupportedAccountStatus returns true if the account status is supported
+func IsSupportedAccountStatus(status string) bool {
+	switch status {
+	case ActiveAccount, FrozenAccount, ClosedAccount:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+)
+
+// SendResetPasswordJob is the kind of the jobs sending a password reset link to a user
+const SendResetPasswordJob = "send_reset_password"
+
+// defaultResetPasswordDuration is how long a reset link works unless RESET_PASSWORD_DURATION is set
+const defaultResetPasswordDuration = 15 * time.Minute
+
+// ResetPasswordPayload is the payload of the send_reset_password jobs
+type ResetPasswordPayload struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// NewResetPasswordJob builds the job sending a password reset link to the email of the user
+func NewResetPasswordJob(username string, email string) (db.CreateJobParams, error) {
+	return newJob(SendResetPasswordJob, ResetPasswordPayload{
+		Username: username,
+		Email:    email,
+	})
+}
+
+// sendResetPassword sends a link with a new single-use token. The token is only in the email,
+// the store keeps its hash.
+func (worker *Worker) sendResetPassword(ctx context.Context, data json.RawMessage) error {
+	var payload ResetPasswordPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	user, err := worker.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		return err
+	}
+	if user.Disabled || user.Email != payload.Email {
+		// disabled users cannot log in anyway, and the link only goes to the email that asked for it
+		return nil
+	}
+
+	token, tokenHash, err := util.NewSecret()
+	if err != nil {
+		return err
+	}
+
+	duration := worker.config.ResetPasswordDuration
+	if duration == 0 {
+		duration = defaultResetPasswordDuration
+	}
+
+	_, err = worker.store.CreateResetPassword(ctx, db.CreateResetPasswordParams{
+		Username:  user.Username,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(duration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := linkWithToken(worker.config.ResetPasswordURL, token)
+	if err != nil {
+		return fmt.Errorf("invalid RESET_PASSWORD_URL: %w", err)
+	}
+
+	subject := "Reset your Simple Bank password"
+	content := fmt.Sprintf(`Hello %s,
+
+someone asked to reset the password of your account %s.
+Follow this link within %s to choose a new password:
+
+%s
+
+Resetting the password signs you out of every device.
+If you did not ask for it, you can ignore this email, your password stays the same.
+`, user.FullName, user.Username, duration, link)
+
+	return worker.mailer.SendEmail([]string{user.Email}, subject, content)
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+)
+
+// SendVerifyEmailJob is the kind of the jobs sending a verification link to a user
+const SendVerifyEmailJob = "send_verify_email"
+
+// defaultVerifyEmailDuration is how long a verification link works unless VERIFY_EMAIL_DURATION is set
+const defaultVerifyEmailDuration = 24 * time.Hour
+
+// VerifyEmailPayload is the payload of the send_verify_email jobs
+type VerifyEmailPayload struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// NewVerifyEmailJob builds the job sending a verification link to the email of the user
+func NewVerifyEmailJob(username string, email string) (db.CreateJobParams, error) {
+	return newJob(SendVerifyEmailJob, VerifyEmailPayload{
+		Username: username,
+		Email:    email,
+	})
+}
+
+// sendVerifyEmail sends a link with a new single-use token. The token is only in the email,
+// the store keeps its hash.
+func (worker *Worker) sendVerifyEmail(ctx context.Context, data json.RawMessage) error {
+	var payload VerifyEmailPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	user, err := worker.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified || user.Email != payload.Email {
+		// the email was verified or changed since, a later job sends the link to the new one
+		return nil
+	}
+
+	token, tokenHash, err := util.NewSecret()
+	if err != nil {
+		return err
+	}
+
+	duration := worker.config.VerifyEmailDuration
+	if duration == 0 {
+		duration = defaultVerifyEmailDuration
+	}
+
+	_, err = worker.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:  user.Username,
+		Email:     user.Email,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(duration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := linkWithToken(worker.config.VerifyEmailURL, token)
+	if err != nil {
+		return fmt.Errorf("invalid VERIFY_EMAIL_URL: %w", err)
+	}
+
+	subject := "Verify your email for Simple Bank"
+	content := fmt.Sprintf(`Hello %s,
+
+please verify your email by following this link within %s:
+
+%s
+
+You cannot send money until your email is verified.
+If you did not sign up to Simple Bank, you can ignore this email.
+`, user.FullName, duration, link)
+
+	return worker.mailer.SendEmail([]string{user.Email}, subject, content)
+}
@@ -0,0 +1,164 @@
+// Package worker runs the background jobs enqueued in the jobs table, such as sending emails.
+//
+// Jobs are enqueued in the same transaction as the change that needs them, so that a job is
+// never lost nor sent for a change that was rolled back. Any number of workers can run side by
+// side: each job is claimed by one of them at a time.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/mail"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// maxAttempts is how many times a job runs before it is given up
+	maxAttempts = 5
+	// pollInterval is how long a worker waits for new jobs once none is due
+	pollInterval = time.Second
+	// lockDuration is how long an attempt may run before another worker takes the job over
+	lockDuration   = time.Minute
+	retryBaseDelay = 10 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// Handler runs a job with its payload. An error makes the job run again later.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Worker claims the jobs that are due and runs them with the handler of their kind
+type Worker struct {
+	store    db.Store
+	mailer   mail.Sender
+	config   util.Config
+	handlers map[string]Handler
+}
+
+// NewWorker creates a worker running every kind of job
+func NewWorker(store db.Store, mailer mail.Sender, config util.Config) (*Worker, error) {
+	if config.VerifyEmailURL == "" {
+		return nil, errors.New("VERIFY_EMAIL_URL must be set")
+	}
+	if config.ResetPasswordURL == "" {
+		return nil, errors.New("RESET_PASSWORD_URL must be set")
+	}
+
+	worker := &Worker{
+		store:  store,
+		mailer: mailer,
+		config: config,
+	}
+	worker.handlers = map[string]Handler{
+		SendVerifyEmailJob:   worker.sendVerifyEmail,
+		SendResetPasswordJob: worker.sendResetPassword,
+	}
+	return worker, nil
+}
+
+// Start runs the jobs as they become due until ctx is done
+func (worker *Worker) Start(ctx context.Context) {
+	for {
+		ran, err := worker.RunNext(ctx)
+		if err != nil {
+			log.Println("cannot run job: ", err)
+		}
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// RunNext claims the next job that is due and runs it. It reports whether there was one.
+func (worker *Worker) RunNext(ctx context.Context) (bool, error) {
+	lockedUntil := pgtype.Timestamptz{Time: time.Now().Add(lockDuration), Valid: true}
+	job, err := worker.store.ClaimJob(ctx, lockedUntil)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	err = worker.run(ctx, job)
+	if err != nil {
+		log.Printf("job %d (%s) failed attempt %d/%d: %v", job.ID, job.Kind, job.Attempts, job.MaxAttempts, err)
+		_, err = worker.store.FailJob(ctx, db.FailJobParams{
+			LastError: pgtype.Text{String: err.Error(), Valid: true},
+			RunAt:     time.Now().Add(retryDelay(job.Attempts)),
+			ID:        job.ID,
+			Attempts:  job.Attempts,
+		})
+	} else {
+		_, err = worker.store.CompleteJob(ctx, db.CompleteJobParams{
+			ID:       job.ID,
+			Attempts: job.Attempts,
+		})
+	}
+
+	if errors.Is(err, db.ErrRecordNotFound) {
+		// the attempt outlived its lock and another worker took the job over
+		return true, fmt.Errorf("job %d was taken over during attempt %d", job.ID, job.Attempts)
+	}
+	return true, err
+}
+
+func (worker *Worker) run(ctx context.Context, job db.Job) error {
+	handler, ok := worker.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lockDuration)
+	defer cancel()
+	return handler(ctx, job.Payload)
+}
+
+// retryDelay returns how long a job waits after its failed attempt, doubling with every attempt
+func retryDelay(attempts int32) time.Duration {
+	delay := retryBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// newJob builds the job of the kind to enqueue with the change that needs it
+func newJob(kind string, payload any) (db.CreateJobParams, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.CreateJobParams{}, err
+	}
+
+	return db.CreateJobParams{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: maxAttempts,
+	}, nil
+}
+
+// linkWithToken adds the token to the query of the link to the page that consumes it
+func linkWithToken(rawURL string, token string) (string, error) {
+	link, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
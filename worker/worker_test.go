@@ -0,0 +1,248 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	memorydb "github.com/foyez/simplebank/db/memory"
+	db "github.com/foyez/simplebank/db/sqlc"
+	"github.com/foyez/simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMailer keeps the emails it sends, or fails to send them while err is set
+type fakeMailer struct {
+	sent []sentEmail
+	err  error
+}
+
+type sentEmail struct {
+	to      []string
+	subject string
+	content string
+}
+
+func (mailer *fakeMailer) SendEmail(to []string, subject string, content string) error {
+	if mailer.err != nil {
+		return mailer.err
+	}
+	mailer.sent = append(mailer.sent, sentEmail{to, subject, content})
+	return nil
+}
+
+func newTestWorker(t *testing.T) (*Worker, db.Store, *fakeMailer) {
+	store := memorydb.NewStore()
+	mailer := &fakeMailer{}
+
+	worker, err := NewWorker(store, mailer, util.Config{
+		VerifyEmailURL:      "http://localhost:3000/verify_email",
+		VerifyEmailDuration: time.Hour,
+		ResetPasswordURL:    "http://localhost:3000/reset_password",
+	})
+	require.NoError(t, err)
+	return worker, store, mailer
+}
+
+func createUserWithJob(t *testing.T, store db.Store) (db.User, db.Job) {
+	username := util.RandomOwner()
+	email := util.RandomEmail()
+	job, err := NewVerifyEmailJob(username, email)
+	require.NoError(t, err)
+
+	result, err := store.CreateUserTx(context.Background(), db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       username,
+			HashedPassword: util.RandomString(60),
+			FullName:       util.RandomOwner(),
+			Email:          email,
+		},
+		Jobs: []db.CreateJobParams{job},
+	})
+	require.NoError(t, err)
+	return result.User, result.Jobs[0]
+}
+
+var tokenPattern = regexp.MustCompile(`http://\S+`)
+
+func TestSendVerifyEmail(t *testing.T) {
+	worker, store, mailer := newTestWorker(t)
+	user, job := createUserWithJob(t, store)
+
+	ran, err := worker.RunNext(context.Background())
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	job, err = store.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.DoneJob, job.Status)
+	require.True(t, job.FinishedAt.Valid)
+
+	require.Len(t, mailer.sent, 1)
+	require.Equal(t, []string{user.Email}, mailer.sent[0].to)
+	require.Contains(t, mailer.sent[0].content, user.FullName)
+
+	link, err := url.Parse(tokenPattern.FindString(mailer.sent[0].content))
+	require.NoError(t, err)
+	require.Equal(t, "/verify_email", link.Path)
+	token := link.Query().Get("token")
+	require.NotEmpty(t, token)
+
+	result, err := store.VerifyEmailTx(context.Background(), util.HashSecret(token))
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+
+	ran, err = worker.RunNext(context.Background())
+	require.NoError(t, err)
+	require.False(t, ran, "no job is left")
+}
+
+func TestSendVerifyEmailRetry(t *testing.T) {
+	worker, store, mailer := newTestWorker(t)
+	_, job := createUserWithJob(t, store)
+	mailer.err = errors.New("connection refused")
+
+	ran, err := worker.RunNext(context.Background())
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	job, err = store.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PendingJob, job.Status)
+	require.Equal(t, int32(1), job.Attempts)
+	require.Equal(t, "connection refused", job.LastError.String)
+	require.WithinDuration(t, time.Now().Add(retryBaseDelay), job.RunAt, time.Second)
+
+	ran, err = worker.RunNext(context.Background())
+	require.NoError(t, err)
+	require.False(t, ran, "the job waits for its retry")
+}
+
+func TestSendVerifyEmailSkipsStaleJob(t *testing.T) {
+	worker, store, mailer := newTestWorker(t)
+
+	// one user got verified and the other changed their email before their job ran
+	verifiedUser, verifiedJob := createUserWithJob(t, store)
+	_, err := store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username:        verifiedUser.Username,
+		IsEmailVerified: pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	movedUser, movedJob := createUserWithJob(t, store)
+	_, err = store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username: movedUser.Username,
+		Email:    pgtype.Text{String: util.RandomEmail(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	for _, job := range []db.Job{verifiedJob, movedJob} {
+		ran, err := worker.RunNext(context.Background())
+		require.NoError(t, err)
+		require.True(t, ran)
+
+		job, err = store.GetJob(context.Background(), job.ID)
+		require.NoError(t, err)
+		require.Equal(t, util.DoneJob, job.Status)
+	}
+	require.Empty(t, mailer.sent)
+}
+
+func TestSendResetPassword(t *testing.T) {
+	worker, store, mailer := newTestWorker(t)
+	user, _ := createUserWithJob(t, store)
+
+	arg, err := NewResetPasswordJob(user.Username, user.Email)
+	require.NoError(t, err)
+	job, err := store.CreateJob(context.Background(), arg)
+	require.NoError(t, err)
+
+	for {
+		ran, err := worker.RunNext(context.Background())
+		require.NoError(t, err)
+		if !ran {
+			break
+		}
+	}
+
+	job, err = store.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.DoneJob, job.Status)
+
+	require.Len(t, mailer.sent, 2, "the verification link and the reset link")
+	require.Equal(t, []string{user.Email}, mailer.sent[1].to)
+
+	link, err := url.Parse(tokenPattern.FindString(mailer.sent[1].content))
+	require.NoError(t, err)
+	require.Equal(t, "/reset_password", link.Path)
+
+	result, err := store.ResetPasswordTx(context.Background(), db.ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(link.Query().Get("token")),
+		HashedPassword: util.RandomString(60),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+}
+
+func TestSendResetPasswordSkipsDisabledUser(t *testing.T) {
+	worker, store, mailer := newTestWorker(t)
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(60),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	_, err = store.UpdateUser(context.Background(), db.UpdateUserParams{
+		Username: user.Username,
+		Disabled: pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	arg, err := NewResetPasswordJob(user.Username, user.Email)
+	require.NoError(t, err)
+	_, err = store.CreateJob(context.Background(), arg)
+	require.NoError(t, err)
+
+	ran, err := worker.RunNext(context.Background())
+	require.NoError(t, err)
+	require.True(t, ran)
+	require.Empty(t, mailer.sent)
+}
+
+func TestRunUnknownJob(t *testing.T) {
+	worker, store, _ := newTestWorker(t)
+
+	job, err := store.CreateJob(context.Background(), db.CreateJobParams{
+		Kind:        "unknown",
+		Payload:     []byte(`{}`),
+		MaxAttempts: 1,
+	})
+	require.NoError(t, err)
+
+	ran, err := worker.RunNext(context.Background())
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	job, err = store.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.FailedJob, job.Status)
+	require.Equal(t, `unknown job kind "unknown"`, job.LastError.String)
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, retryBaseDelay, retryDelay(1))
+	require.Equal(t, 4*retryBaseDelay, retryDelay(3))
+	require.Equal(t, retryMaxDelay, retryDelay(20))
+	require.Equal(t, retryMaxDelay, retryDelay(100))
+}
+
+func TestNewWorkerRequiresURL(t *testing.T) {
+	_, err := NewWorker(memorydb.NewStore(), &fakeMailer{}, util.Config{})
+	require.Error(t, err)
+}